@@ -14,7 +14,7 @@ import (
 //	@license.url	http://www.apache.org/licenses/LICENSE-2.0.html
 
 // @host		localhost:8080
-// @BasePath    /api/v1/files
+// @BasePath    /api/v1
 func main() {
 	cfg, err := config.New()
 	if err != nil {
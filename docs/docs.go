@@ -19,40 +19,56 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/append": {
-            "post": {
-                "description": "Appends data to an existing file",
-                "consumes": [
-                    "multipart/form-data"
-                ],
+        "/audit": {
+            "get": {
+                "description": "Returns the latest calls matching the filters, the oldest first. Every record carries the hash of the record before it. Needs an admin while access control is enabled",
                 "produces": [
-                    "text/plain"
+                    "application/json"
                 ],
                 "tags": [
-                    "appending"
+                    "audit"
                 ],
-                "summary": "Append data to a file",
+                "summary": "Query the audit log",
                 "parameters": [
                     {
-                        "type": "file",
-                        "description": "File to append",
-                        "name": "file",
-                        "in": "formData",
-                        "required": true
+                        "type": "string",
+                        "description": "Only calls on this path or below it, as source or destination",
+                        "name": "path_prefix",
+                        "in": "query"
                     },
                     {
                         "type": "string",
-                        "description": "Path to the file",
-                        "name": "file_path",
-                        "in": "query",
-                        "required": true
+                        "description": "Only calls made by this subject",
+                        "name": "user",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only calls made at or after this unix or RFC 3339 time",
+                        "name": "since",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only calls made at or before this unix or RFC 3339 time",
+                        "name": "until",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of latest records, 1000 if not set",
+                        "name": "limit",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Status: {status}",
+                        "description": "Records",
                         "schema": {
-                            "type": "string"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.AuditRecord"
+                            }
                         }
                     },
                     "400": {
@@ -61,6 +77,18 @@ const docTemplate = `{
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
                     },
+                    "403": {
+                        "description": "Caller is not an admin",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "412": {
+                        "description": "Audit log is disabled",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
@@ -70,37 +98,31 @@ const docTemplate = `{
                 }
             }
         },
-        "/delete": {
-            "delete": {
-                "description": "Deletes a file based on the provided path",
-                "consumes": [
-                    "application/json"
-                ],
+        "/audit/verify": {
+            "get": {
+                "description": "Checks the hash chain of the whole audit log and reports the first record that was changed, removed or inserted. Needs an admin while access control is enabled",
                 "produces": [
-                    "text/plain"
+                    "application/json"
                 ],
                 "tags": [
-                    "deleting"
-                ],
-                "summary": "Delete a file",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "description": "Path to the file",
-                        "name": "file_path",
-                        "in": "query",
-                        "required": true
-                    }
+                    "audit"
                 ],
+                "summary": "Verify the audit log",
                 "responses": {
                     "200": {
-                        "description": "Status: {status}",
+                        "description": "Result of the check",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/models.AuditVerification"
                         }
                     },
-                    "404": {
-                        "description": "File not found",
+                    "403": {
+                        "description": "Caller is not an admin",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "412": {
+                        "description": "Audit log is disabled",
                         "schema": {
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
@@ -114,37 +136,42 @@ const docTemplate = `{
                 }
             }
         },
-        "/download": {
+        "/files/acl": {
             "get": {
-                "description": "Retrieves a file based on the provided path",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Returns the grants on a path and everything below it, sorted by path and principal. Without a path all grants are listed. Admins only",
                 "produces": [
-                    "application/octet-stream"
+                    "application/json"
                 ],
                 "tags": [
-                    "downloading"
+                    "access control"
                 ],
-                "summary": "Download a file",
+                "summary": "List grants",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Path to the file",
-                        "name": "file_path",
-                        "in": "query",
-                        "required": true
+                        "description": "Directory or file path",
+                        "name": "path",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "The requested file",
+                        "description": "Grants",
                         "schema": {
-                            "type": "file"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Grant"
+                            }
                         }
                     },
-                    "404": {
-                        "description": "File not found",
+                    "403": {
+                        "description": "Caller is not an admin",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "412": {
+                        "description": "Access control is disabled",
                         "schema": {
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
@@ -156,38 +183,52 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/list": {
-            "get": {
-                "description": "Returns a list of files and directories in the specified path",
-                "consumes": [
-                    "application/json"
-                ],
+            },
+            "put": {
+                "description": "Grants a role and/or permissions on a path and everything below it, replacing the previous grant of the principal on the path. Admins only",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "listing"
+                    "access control"
                 ],
-                "summary": "List directory contents",
+                "summary": "Set a grant",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Directory path",
+                        "description": "Directory or file path",
                         "name": "path",
                         "in": "query",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "example": "\"group:developers\"",
+                        "description": "*, user:\u003csubject\u003e or group:\u003cname\u003e",
+                        "name": "principal",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "example": "\"editor\"",
+                        "description": "Role to grant",
+                        "name": "role",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "\"read,list\"",
+                        "description": "Comma separated permissions: read, write, delete, list",
+                        "name": "permissions",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "List of directory entries",
+                        "description": "Stored grant",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/models.FileEntry"
-                            }
+                            "$ref": "#/definitions/models.Grant"
                         }
                     },
                     "400": {
@@ -196,6 +237,18 @@ const docTemplate = `{
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
                     },
+                    "403": {
+                        "description": "Caller is not an admin",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "412": {
+                        "description": "Access control is disabled",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
@@ -203,46 +256,47 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/move": {
-            "post": {
-                "description": "Moves a file to a new location",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "text/plain"
-                ],
+            },
+            "delete": {
+                "description": "Removes the grant of a principal on a path. Admins only",
                 "tags": [
-                    "moving"
+                    "access control"
                 ],
-                "summary": "Move a file",
+                "summary": "Remove a grant",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Source path",
-                        "name": "src_path",
+                        "description": "Directory or file path",
+                        "name": "path",
                         "in": "query",
                         "required": true
                     },
                     {
                         "type": "string",
-                        "description": "Destination path",
-                        "name": "dst_path",
+                        "description": "*, user:\u003csubject\u003e or group:\u003cname\u003e",
+                        "name": "principal",
                         "in": "query",
                         "required": true
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "Status: {status}",
+                    "204": {
+                        "description": "Grant removed"
+                    },
+                    "403": {
+                        "description": "Caller is not an admin",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/models.ErrorResponse"
                         }
                     },
                     "404": {
-                        "description": "File not found",
+                        "description": "Grant not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "412": {
+                        "description": "Access control is disabled",
                         "schema": {
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
@@ -256,37 +310,93 @@ const docTemplate = `{
                 }
             }
         },
-        "/read": {
+        "/files/acl/access": {
             "get": {
-                "description": "Returns the content of a specific file",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Returns the permissions the caller has on a path. Admins may ask for another subject and groups",
                 "produces": [
-                    "application/octet-stream"
+                    "application/json"
                 ],
                 "tags": [
-                    "reading"
+                    "access control"
                 ],
-                "summary": "Read a file",
+                "summary": "Get effective permissions",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Path to the file",
-                        "name": "file_path",
+                        "description": "Directory or file path",
+                        "name": "path",
                         "in": "query",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Subject to check instead of the caller",
+                        "name": "subject",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma separated groups of the subject",
+                        "name": "groups",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Content of the file",
+                        "description": "Effective permissions",
                         "schema": {
-                            "type": "file"
+                            "$ref": "#/definitions/models.Access"
                         }
                     },
-                    "404": {
-                        "description": "File not found",
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Caller is not an admin",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "412": {
+                        "description": "Access control is disabled",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/files/acl/roles": {
+            "get": {
+                "description": "Returns the roles grants may refer to with their permissions",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "access control"
+                ],
+                "summary": "List roles",
+                "responses": {
+                    "200": {
+                        "description": "Roles",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Role"
+                            }
+                        }
+                    },
+                    "412": {
+                        "description": "Access control is disabled",
                         "schema": {
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
@@ -300,34 +410,39 @@ const docTemplate = `{
                 }
             }
         },
-        "/upload": {
+        "/files/append": {
             "post": {
-                "description": "Accepts a multipart file upload",
+                "description": "Appends data to an existing file. The data is sent as multipart form field \"file\" or as a raw application/octet-stream body, and streamed to storage as it arrives",
                 "consumes": [
-                    "multipart/form-data"
+                    "multipart/form-data",
+                    "application/octet-stream"
                 ],
                 "produces": [
                     "text/plain"
                 ],
                 "tags": [
-                    "uploading"
+                    "appending"
                 ],
-                "summary": "Uploads a file",
+                "summary": "Append data to a file",
                 "parameters": [
                     {
                         "type": "file",
-                        "description": "File to upload",
+                        "description": "Data to append, for multipart/form-data requests",
                         "name": "file",
-                        "in": "formData",
-                        "required": true
+                        "in": "formData"
                     },
                     {
                         "type": "string",
-                        "example": "\"/documents/report.pdf\"",
-                        "description": "Path to save the file",
+                        "description": "Path to the file",
                         "name": "file_path",
                         "in": "query",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Checksums of the whole file after the append, e.g. sha-256=\u003cbase64\u003e; a mismatch rejects the append",
+                        "name": "Digest",
+                        "in": "header"
                     }
                 ],
                 "responses": {
@@ -343,40 +458,2859 @@ const docTemplate = `{
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
                     },
+                    "415": {
+                        "description": "Body is neither multipart/form-data nor application/octet-stream",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
+                    },
+                    "507": {
+                        "description": "Quota exceeded",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "models.ErrorResponse": {
-            "type": "object",
-            "properties": {
-                "code": {
-                    "type": "integer",
-                    "example": 400
-                },
-                "message": {
-                    "type": "string",
-                    "example": "invalid request parameters"
-                }
-            }
         },
-        "models.FileEntry": {
-            "type": "object",
-            "properties": {
-                "is_directory": {
-                    "type": "boolean",
-                    "example": false
-                },
-                "name": {
-                    "type": "string",
-                    "example": "report.pdf"
+        "/files/delete": {
+            "delete": {
+                "description": "Deletes a file based on the provided path. The file is moved to the trash when it is enabled",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "text/plain"
+                ],
+                "tags": [
+                    "deleting"
+                ],
+                "summary": "Delete a file",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Path to the file",
+                        "name": "file_path",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Status: {status}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "File not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/files/directories": {
+            "post": {
+                "description": "Creates a directory and its missing parents. Succeeds if the directory exists",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "directories"
+                ],
+                "summary": "Create a directory",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Directory path",
+                        "name": "path",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created directory",
+                        "schema": {
+                            "$ref": "#/definitions/models.PathResult"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "A file exists at the path",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Deletes a directory. With recursive the content is deleted too, file by file, and every entry is reported. Files are moved to the trash when it is enabled",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "directories"
+                ],
+                "summary": "Delete a directory",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Directory path",
+                        "name": "path",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Delete the content of the directory",
+                        "name": "recursive",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "All entries deleted",
+                        "schema": {
+                            "$ref": "#/definitions/models.TreeReport"
+                        }
+                    },
+                    "207": {
+                        "description": "Some entries failed",
+                        "schema": {
+                            "$ref": "#/definitions/models.TreeReport"
+                        }
+                    },
+                    "404": {
+                        "description": "Directory not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "412": {
+                        "description": "Not a directory or not empty",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/files/download": {
+            "get": {
+                "description": "Retrieves a file based on the provided path",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/octet-stream"
+                ],
+                "tags": [
+                    "downloading"
+                ],
+                "summary": "Download a file",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Path to the file",
+                        "name": "file_path",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Byte ranges to return, e.g. bytes=0-1023,-512",
+                        "name": "Range",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "The requested file",
+                        "schema": {
+                            "type": "file"
+                        },
+                        "headers": {
+                            "Digest": {
+                                "type": "string",
+                                "description": "SHA-256 of the file, sha-256=\u003cbase64\u003e; sent as a trailer if the server has no stored digest"
+                            },
+                            "ETag": {
+                                "type": "string",
+                                "description": "Hex SHA-256 of the file, if the server has a stored digest"
+                            }
+                        }
+                    },
+                    "206": {
+                        "description": "The requested ranges of the file",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "404": {
+                        "description": "File not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "416": {
+                        "description": "Range not satisfiable",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/files/list": {
+            "get": {
+                "description": "Returns a page of the files and directories in the specified path with their metadata. The cursor of the next page is returned in the X-Next-Cursor header",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "listing"
+                ],
+                "summary": "List directory contents",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Directory path",
+                        "name": "path",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "name",
+                            "size",
+                            "mtime"
+                        ],
+                        "type": "string",
+                        "description": "Sort by name, size or mtime",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "asc",
+                            "desc"
+                        ],
+                        "type": "string",
+                        "description": "Sort order",
+                        "name": "order",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Shell pattern the names must match, e.g. *.log",
+                        "name": "pattern",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of entries",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "X-Next-Cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Add the SHA-256 of every file the caller may read",
+                        "name": "checksum",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "List of directory entries",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.FileEntry"
+                            }
+                        },
+                        "headers": {
+                            "X-Next-Cursor": {
+                                "type": "string",
+                                "description": "Cursor of the next page, absent on the last page"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Directory not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/files/move": {
+            "post": {
+                "description": "Moves a file to a new location",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "text/plain"
+                ],
+                "tags": [
+                    "moving"
+                ],
+                "summary": "Move a file",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Source path",
+                        "name": "src_path",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Destination path",
+                        "name": "dst_path",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Status: {status}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "File not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "507": {
+                        "description": "Quota exceeded",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/files/overwrite": {
+            "put": {
+                "description": "Replaces an existing file with a new one, sent as multipart form field \"file\" or as a raw application/octet-stream body and streamed to storage as it arrives",
+                "consumes": [
+                    "multipart/form-data",
+                    "application/octet-stream"
+                ],
+                "produces": [
+                    "text/plain"
+                ],
+                "tags": [
+                    "overwriting"
+                ],
+                "summary": "Overwrite a file",
+                "parameters": [
+                    {
+                        "type": "file",
+                        "description": "File to upload, for multipart/form-data requests",
+                        "name": "file",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Path to save the file",
+                        "name": "file_path",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Checksums of the file, e.g. sha-256=\u003cbase64\u003e, crc32c=\u003cbase64\u003e; a mismatch rejects the upload",
+                        "name": "Digest",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Status: {status}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "415": {
+                        "description": "Body is neither multipart/form-data nor application/octet-stream",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "507": {
+                        "description": "Quota exceeded",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/files/paths/copy": {
+            "post": {
+                "description": "Copies a file, or with recursive a directory tree, merging into an existing directory. Every entry is reported",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "directories"
+                ],
+                "summary": "Copy a path",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Path to copy",
+                        "name": "source",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Path to copy to",
+                        "name": "destination",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Copy directories with their content",
+                        "name": "recursive",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Replace existing files",
+                        "name": "overwrite",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "All entries copied",
+                        "schema": {
+                            "$ref": "#/definitions/models.TreeReport"
+                        }
+                    },
+                    "207": {
+                        "description": "Some entries failed",
+                        "schema": {
+                            "$ref": "#/definitions/models.TreeReport"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Source not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/files/paths/move": {
+            "post": {
+                "description": "Moves a file or a directory tree, merging into an existing directory. Every entry is reported",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "directories"
+                ],
+                "summary": "Move a path",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Path to move",
+                        "name": "source",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Path to move to",
+                        "name": "destination",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Replace existing files",
+                        "name": "overwrite",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "All entries moved",
+                        "schema": {
+                            "$ref": "#/definitions/models.TreeReport"
+                        }
+                    },
+                    "207": {
+                        "description": "Some entries failed",
+                        "schema": {
+                            "$ref": "#/definitions/models.TreeReport"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Source not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/files/read": {
+            "get": {
+                "description": "Returns the content of a specific file",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/octet-stream"
+                ],
+                "tags": [
+                    "reading"
+                ],
+                "summary": "Read a file",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Path to the file",
+                        "name": "file_path",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Byte ranges to return, e.g. bytes=0-1023,-512",
+                        "name": "Range",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Content of the file",
+                        "schema": {
+                            "type": "file"
+                        },
+                        "headers": {
+                            "Digest": {
+                                "type": "string",
+                                "description": "SHA-256 of the file, sha-256=\u003cbase64\u003e; sent as a trailer if the server has no stored digest"
+                            },
+                            "ETag": {
+                                "type": "string",
+                                "description": "Hex SHA-256 of the file, if the server has a stored digest"
+                            }
+                        }
+                    },
+                    "206": {
+                        "description": "The requested ranges of the file",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "404": {
+                        "description": "File not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "416": {
+                        "description": "Range not satisfiable",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/files/retention": {
+            "get": {
+                "description": "Returns the rule that limits the versions kept for the files of a directory, inherited from the nearest parent with a rule",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "versions"
+                ],
+                "summary": "Get a retention rule",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Directory path",
+                        "name": "path",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Effective rule",
+                        "schema": {
+                            "$ref": "#/definitions/models.RetentionRule"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Limits the versions kept for the files of a directory and its subdirectories. Without limits the directory inherits the rule of its parent again",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "versions"
+                ],
+                "summary": "Set a retention rule",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Directory path",
+                        "name": "path",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of versions to keep per file, 0 for no limit",
+                        "name": "keep",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum age of versions in seconds, 0 for no limit",
+                        "name": "max_age",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Effective rule",
+                        "schema": {
+                            "$ref": "#/definitions/models.RetentionRule"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/files/stat": {
+            "get": {
+                "description": "Returns the metadata of a file or directory",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "listing"
+                ],
+                "summary": "Stat a path",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "File or directory path",
+                        "name": "path",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Add the SHA-256 of the file; needs read access",
+                        "name": "checksum",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Metadata of the path",
+                        "schema": {
+                            "$ref": "#/definitions/models.FileStat"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Path not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/files/trash": {
+            "get": {
+                "description": "Returns the files deleted from under a path, most recently deleted first. Without a path the whole trash is listed",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "trash"
+                ],
+                "summary": "List the trash",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Directory or file path",
+                        "name": "path",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Deleted files",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.TrashEntry"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Permanently deletes the files deleted from under a path. Without a path the whole trash is emptied",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "trash"
+                ],
+                "summary": "Empty the trash",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Directory or file path",
+                        "name": "path",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Number of deleted entries",
+                        "schema": {
+                            "$ref": "#/definitions/models.EmptyTrashResult"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/files/trash/{entry_id}/restore": {
+            "post": {
+                "description": "Moves a deleted file back to its original path or to the given destination. An existing file is never replaced",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "trash"
+                ],
+                "summary": "Restore from the trash",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Trash entry ID",
+                        "name": "entry_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Path to restore to",
+                        "name": "destination",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Restored file",
+                        "schema": {
+                            "$ref": "#/definitions/models.TrashEntry"
+                        }
+                    },
+                    "404": {
+                        "description": "Entry not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Target already exists",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "507": {
+                        "description": "Quota exceeded",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/files/upload": {
+            "put": {
+                "description": "Accepts the file as multipart form field \"file\" or as a raw application/octet-stream body. The file is streamed to storage as it arrives, so its size is not limited",
+                "consumes": [
+                    "multipart/form-data",
+                    "application/octet-stream"
+                ],
+                "produces": [
+                    "text/plain"
+                ],
+                "tags": [
+                    "uploading"
+                ],
+                "summary": "Uploads a file",
+                "parameters": [
+                    {
+                        "type": "file",
+                        "description": "File to upload, for multipart/form-data requests",
+                        "name": "file",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "example": "\"/documents/report.pdf\"",
+                        "description": "Path to save the file",
+                        "name": "file_path",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Checksums of the file, e.g. sha-256=\u003cbase64\u003e, crc32c=\u003cbase64\u003e; a mismatch rejects the upload",
+                        "name": "Digest",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Status: {status}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "415": {
+                        "description": "Body is neither multipart/form-data nor application/octet-stream",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "507": {
+                        "description": "Quota exceeded",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Accepts the file as multipart form field \"file\" or as a raw application/octet-stream body. The file is streamed to storage as it arrives, so its size is not limited",
+                "consumes": [
+                    "multipart/form-data",
+                    "application/octet-stream"
+                ],
+                "produces": [
+                    "text/plain"
+                ],
+                "tags": [
+                    "uploading"
+                ],
+                "summary": "Uploads a file",
+                "parameters": [
+                    {
+                        "type": "file",
+                        "description": "File to upload, for multipart/form-data requests",
+                        "name": "file",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "example": "\"/documents/report.pdf\"",
+                        "description": "Path to save the file",
+                        "name": "file_path",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Checksums of the file, e.g. sha-256=\u003cbase64\u003e, crc32c=\u003cbase64\u003e; a mismatch rejects the upload",
+                        "name": "Digest",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Status: {status}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "415": {
+                        "description": "Body is neither multipart/form-data nor application/octet-stream",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "507": {
+                        "description": "Quota exceeded",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/files/upload/batch": {
+            "post": {
+                "description": "Stores every file of a multipart/form-data body, at the path given as its file name, or every file and directory of a tar, tar.gz or zip body, below a directory. Files are streamed to storage one after the other as they arrive; zip archives are spooled to a temporary file first, up to the size set by HTTP_MAX_SPOOL_SIZE. Every entry is reported, a failed one does not stop the others",
+                "consumes": [
+                    "multipart/form-data",
+                    "application/x-tar",
+                    "application/gzip",
+                    "application/zip"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "uploading"
+                ],
+                "summary": "Upload several files",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Directory to store the files in, the root if empty",
+                        "name": "path",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "All entries stored",
+                        "schema": {
+                            "$ref": "#/definitions/models.UploadReport"
+                        }
+                    },
+                    "207": {
+                        "description": "Some entries failed",
+                        "schema": {
+                            "$ref": "#/definitions/models.UploadReport"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "Zip archive is larger than the spool limit",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "415": {
+                        "description": "Body is neither multipart/form-data nor an archive",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/files/uploads": {
+            "post": {
+                "description": "Creates a resumable upload session. Chunks are staged until the session is finalized",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "uploading"
+                ],
+                "summary": "Create an upload session",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "\"/builds/artifact.tar.gz\"",
+                        "description": "Path to save the file",
+                        "name": "file_path",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Expected size of the file in bytes",
+                        "name": "size",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Expected SHA-256 of the file as hex, checked on finalize",
+                        "name": "sha256",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created session",
+                        "schema": {
+                            "$ref": "#/definitions/models.UploadSession"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/files/uploads/{upload_id}": {
+            "get": {
+                "description": "Returns the committed offset of an upload session, used to resume after a disconnect",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "uploading"
+                ],
+                "summary": "Get an upload session",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Upload session ID",
+                        "name": "upload_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Session state",
+                        "schema": {
+                            "$ref": "#/definitions/models.UploadSession"
+                        }
+                    },
+                    "404": {
+                        "description": "Session not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Streams the request body into the session starting at the given offset",
+                "consumes": [
+                    "application/octet-stream"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "uploading"
+                ],
+                "summary": "Upload a chunk",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Upload session ID",
+                        "name": "upload_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Offset of the first byte of the body",
+                        "name": "offset",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Session state after the chunk",
+                        "schema": {
+                            "$ref": "#/definitions/models.UploadSession"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Session not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Session is used by another request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "412": {
+                        "description": "Offset does not match the committed offset",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Deletes the staged data of an upload session",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "uploading"
+                ],
+                "summary": "Abort an upload session",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Upload session ID",
+                        "name": "upload_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Aborted session",
+                        "schema": {
+                            "$ref": "#/definitions/models.UploadSession"
+                        }
+                    },
+                    "404": {
+                        "description": "Session not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/files/uploads/{upload_id}/finalize": {
+            "post": {
+                "description": "Moves the staged data to the target path and closes the session",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "uploading"
+                ],
+                "summary": "Finalize an upload session",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Upload session ID",
+                        "name": "upload_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Finalized session",
+                        "schema": {
+                            "$ref": "#/definitions/models.UploadSession"
+                        }
+                    },
+                    "400": {
+                        "description": "Uploaded data does not match the declared SHA-256",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Session not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "412": {
+                        "description": "Uploaded size does not match the declared size",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "507": {
+                        "description": "Quota exceeded",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/files/usage": {
+            "get": {
+                "description": "Returns the bytes and files stored in the root and in every top level directory with their quotas. A limit of 0 is unlimited",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "quotas"
+                ],
+                "summary": "List namespace usage",
+                "responses": {
+                    "200": {
+                        "description": "Usage of the namespaces",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.NamespaceUsage"
+                            }
+                        }
+                    },
+                    "412": {
+                        "description": "Quotas are disabled",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/files/usage/namespace": {
+            "get": {
+                "description": "Returns the bytes and files stored in a top level directory, or in the root for \"/\", with its quota. With recount the usage is counted again instead of being read from the usage index",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "quotas"
+                ],
+                "summary": "Get namespace usage",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "\"team-a\"",
+                        "description": "Top level directory or /",
+                        "name": "namespace",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Count the usage again",
+                        "name": "recount",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Usage of the namespace",
+                        "schema": {
+                            "$ref": "#/definitions/models.NamespaceUsage"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid namespace",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "412": {
+                        "description": "Quotas are disabled",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/files/versions": {
+            "get": {
+                "description": "Returns the kept versions of a file, newest first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "versions"
+                ],
+                "summary": "List file versions",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Path to the file",
+                        "name": "file_path",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Versions of the file",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.FileVersion"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/files/versions/{version_id}": {
+            "get": {
+                "description": "Streams the content of one version of a file",
+                "produces": [
+                    "application/octet-stream"
+                ],
+                "tags": [
+                    "versions"
+                ],
+                "summary": "Download a file version",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Version ID",
+                        "name": "version_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Path to the file",
+                        "name": "file_path",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Content of the version",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "404": {
+                        "description": "Version not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/files/versions/{version_id}/restore": {
+            "post": {
+                "description": "Replaces the file with one of its versions. The replaced content is kept as a new version",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "versions"
+                ],
+                "summary": "Restore a file version",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Version ID",
+                        "name": "version_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Path to the file",
+                        "name": "file_path",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Restored version",
+                        "schema": {
+                            "$ref": "#/definitions/models.FileVersion"
+                        }
+                    },
+                    "404": {
+                        "description": "Version not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "507": {
+                        "description": "Quota exceeded",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/files/watch": {
+            "get": {
+                "description": "Streams the changes in a directory as server-sent events, whether they were made through the API or directly on disk. Every event has its sequence number as id, its type (create, modify, delete or move) as name and a models.WatchEvent as data. After a reconnect the events after the Last-Event-ID header or the since parameter are sent first. If they are no longer buffered the answer is 412 and the directory has to be listed again",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "watch"
+                ],
+                "summary": "Watch a directory",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Directory path, the root if empty",
+                        "name": "path",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Report changes in subdirectories too",
+                        "name": "recursive",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Sequence number of the last event seen",
+                        "name": "since",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Sequence number of the last event seen, sent by EventSource",
+                        "name": "Last-Event-ID",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Stream of events",
+                        "schema": {
+                            "$ref": "#/definitions/models.WatchEvent"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Listing the directory is not allowed",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "412": {
+                        "description": "Events were lost or watching is disabled",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/share": {
+            "get": {
+                "description": "Returns the links that have not expired, the ones expiring first first. Callers see the links they created; admins see all",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "sharing"
+                ],
+                "summary": "List share links",
+                "responses": {
+                    "200": {
+                        "description": "Shares",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Share"
+                            }
+                        }
+                    },
+                    "412": {
+                        "description": "Sharing is disabled",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Issues a signed link that allows a download of a file, or an upload to it, without credentials until it expires. Downloads are GET requests to the link; uploads POST the file as multipart form field \"file\" to it. The caller needs the permission the link grants",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "sharing"
+                ],
+                "summary": "Create a share link",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Path to the file",
+                        "name": "path",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "download",
+                            "upload"
+                        ],
+                        "type": "string",
+                        "description": "download or upload",
+                        "name": "operation",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Lifetime of the link in seconds, a day by default",
+                        "name": "ttl",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of times the link may be used, 0 for no limit",
+                        "name": "max_uses",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of bytes that may be transferred with the link, 0 for no limit",
+                        "name": "max_bytes",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Share with its link",
+                        "schema": {
+                            "$ref": "#/definitions/models.Share"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Permission denied",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "File not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "412": {
+                        "description": "Sharing is disabled",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/share/{share_id}": {
+            "delete": {
+                "description": "Makes a link unusable before it expires",
+                "tags": [
+                    "sharing"
+                ],
+                "summary": "Revoke a share link",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Share ID",
+                        "name": "share_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Share revoked"
+                    },
+                    "403": {
+                        "description": "Share belongs to another caller",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Share not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "412": {
+                        "description": "Sharing is disabled",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks": {
+            "get": {
+                "description": "Returns the webhooks, the oldest first, without their secrets",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "List webhooks",
+                "responses": {
+                    "200": {
+                        "description": "Webhooks",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Webhook"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Caller is not an admin",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "412": {
+                        "description": "Webhooks are disabled",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Registers a URL that every change below a path is posted to as JSON. Requests carry the X-FM-Delivery, X-FM-Event and X-FM-Signature headers; the signature is \"t=\u003cunix time\u003e,v1=\u003chex HMAC-SHA256 of the time, a dot and the body\u003e\" keyed with the secret. Failed deliveries are retried with exponential backoff and end up in the dead-letter log. The secret is only returned here. Needs an admin while access control is enabled",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Create a webhook",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "URL the changes are posted to",
+                        "name": "url",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only changes of this path and below it, all if empty",
+                        "name": "path_prefix",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma separated event types: create, modify, delete, move; all if empty",
+                        "name": "types",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Key of the signatures, generated if empty",
+                        "name": "X-Webhook-Secret",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Webhook with its secret",
+                        "schema": {
+                            "$ref": "#/definitions/models.Webhook"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Caller is not an admin",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "412": {
+                        "description": "Webhooks are disabled",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/dead-letters": {
+            "get": {
+                "description": "Returns the deliveries that failed after their last attempt, the latest first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "List dead letters",
+                "responses": {
+                    "200": {
+                        "description": "Dead letters",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Delivery"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Caller is not an admin",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "412": {
+                        "description": "Webhooks are disabled",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/dead-letters/{delivery_id}/redeliver": {
+            "post": {
+                "description": "Takes a delivery out of the dead-letter log and tries it again with a fresh number of attempts",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Retry a dead letter",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Delivery ID",
+                        "name": "delivery_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Queued delivery",
+                        "schema": {
+                            "$ref": "#/definitions/models.Delivery"
+                        }
+                    },
+                    "403": {
+                        "description": "Caller is not an admin",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Dead letter or its webhook not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "412": {
+                        "description": "Webhooks are disabled",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Webhook delivery is not running or the queue of the webhook is full",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/deliveries": {
+            "get": {
+                "description": "Returns the recent deliveries, the latest first. The history is kept in memory and starts empty after a restart",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "List webhook deliveries",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Only deliveries of this webhook",
+                        "name": "webhook_id",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "pending",
+                            "delivered",
+                            "failed"
+                        ],
+                        "type": "string",
+                        "description": "Only deliveries with this status",
+                        "name": "status",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Deliveries",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Delivery"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Caller is not an admin",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "412": {
+                        "description": "Webhooks are disabled",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/{webhook_id}": {
+            "delete": {
+                "description": "Stops the deliveries to a webhook, including the pending ones",
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Delete a webhook",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Webhook ID",
+                        "name": "webhook_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Webhook deleted"
+                    },
+                    "403": {
+                        "description": "Caller is not an admin",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Webhook not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "412": {
+                        "description": "Webhooks are disabled",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "models.Access": {
+            "type": "object",
+            "properties": {
+                "admin": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "path": {
+                    "type": "string",
+                    "example": "/projects/site"
+                },
+                "permissions": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "read",
+                        "write",
+                        "list"
+                    ]
+                },
+                "subject": {
+                    "type": "string",
+                    "example": "alice"
+                }
+            }
+        },
+        "models.AuditRecord": {
+            "type": "object",
+            "properties": {
+                "auth_method": {
+                    "type": "string",
+                    "example": "jwt"
+                },
+                "bytes": {
+                    "type": "integer",
+                    "example": 52428
+                },
+                "caller": {
+                    "type": "string",
+                    "example": "alice"
+                },
+                "destination": {
+                    "type": "string",
+                    "example": ""
+                },
+                "duration_ms": {
+                    "type": "integer",
+                    "example": 12
+                },
+                "error": {
+                    "type": "string",
+                    "example": ""
+                },
+                "hash": {
+                    "type": "string",
+                    "example": "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
+                },
+                "operation": {
+                    "type": "string",
+                    "example": "/file_manager.FileService/Upload"
+                },
+                "path": {
+                    "type": "string",
+                    "example": "incoming/report.csv"
+                },
+                "prev_hash": {
+                    "type": "string",
+                    "example": "5e884898da28047151d0e56f8dc6292773603d0d6aabbdd62a11ef721d1542d8"
+                },
+                "remote": {
+                    "type": "string",
+                    "example": "10.0.0.7:51234"
+                },
+                "seq": {
+                    "type": "integer",
+                    "example": 42
+                },
+                "status": {
+                    "type": "string",
+                    "example": "OK"
+                },
+                "time": {
+                    "type": "integer",
+                    "example": 1735084800
+                }
+            }
+        },
+        "models.AuditVerification": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string",
+                    "example": ""
+                },
+                "records": {
+                    "type": "integer",
+                    "example": 1024
+                },
+                "valid": {
+                    "type": "boolean",
+                    "example": true
+                }
+            }
+        },
+        "models.Delivery": {
+            "type": "object",
+            "properties": {
+                "attempts": {
+                    "type": "integer",
+                    "example": 2
+                },
+                "created_at": {
+                    "type": "integer",
+                    "example": 1735084800
+                },
+                "id": {
+                    "type": "string",
+                    "example": "9d3c7a1e-6b2f-4c8d-a5e0-7f1b2c3d4e5f"
+                },
+                "is_dir": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "last_error": {
+                    "type": "string",
+                    "example": "receiver answered 503 Service Unavailable"
+                },
+                "next_attempt": {
+                    "type": "integer",
+                    "example": 1735084807
+                },
+                "old_path": {
+                    "type": "string",
+                    "example": ""
+                },
+                "path": {
+                    "type": "string",
+                    "example": "incoming/report.csv"
+                },
+                "response_code": {
+                    "type": "integer",
+                    "example": 503
+                },
+                "seq": {
+                    "type": "integer",
+                    "example": 1735084800000042
+                },
+                "status": {
+                    "type": "string",
+                    "example": "pending"
+                },
+                "type": {
+                    "type": "string",
+                    "example": "create"
+                },
+                "updated_at": {
+                    "type": "integer",
+                    "example": 1735084803
+                },
+                "url": {
+                    "type": "string",
+                    "example": "https://indexer.example.com/hooks/files"
+                },
+                "webhook_id": {
+                    "type": "string",
+                    "example": "0b8f6f52-1f0e-4f3b-9a53-3c1d7e2f9a41"
+                }
+            }
+        },
+        "models.EmptyTrashResult": {
+            "type": "object",
+            "properties": {
+                "removed": {
+                    "type": "integer",
+                    "example": 12
+                }
+            }
+        },
+        "models.ErrorResponse": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "integer",
+                    "example": 400
+                },
+                "message": {
+                    "type": "string",
+                    "example": "invalid request parameters"
+                }
+            }
+        },
+        "models.FileEntry": {
+            "type": "object",
+            "properties": {
+                "checksum": {
+                    "type": "string",
+                    "example": "sha256:9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
+                },
+                "content_type": {
+                    "type": "string",
+                    "example": "application/pdf"
+                },
+                "is_directory": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "mod_time": {
+                    "type": "integer",
+                    "example": 1718000000
+                },
+                "mode": {
+                    "type": "string",
+                    "example": "-rw-r--r--"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "report.pdf"
+                },
+                "physical_size": {
+                    "type": "integer",
+                    "example": 262144
+                },
+                "size": {
+                    "type": "integer",
+                    "example": 1048576
+                }
+            }
+        },
+        "models.FileStat": {
+            "type": "object",
+            "properties": {
+                "checksum": {
+                    "type": "string",
+                    "example": "sha256:9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
+                },
+                "content_type": {
+                    "type": "string",
+                    "example": "application/pdf"
+                },
+                "is_directory": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "mod_time": {
+                    "type": "integer",
+                    "example": 1718000000
+                },
+                "mode": {
+                    "type": "string",
+                    "example": "-rw-r--r--"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "report.pdf"
+                },
+                "path": {
+                    "type": "string",
+                    "example": "/documents/report.pdf"
+                },
+                "physical_size": {
+                    "type": "integer",
+                    "example": 262144
+                },
+                "size": {
+                    "type": "integer",
+                    "example": 1048576
+                }
+            }
+        },
+        "models.FileVersion": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "integer",
+                    "example": 1718000000
+                },
+                "file_name": {
+                    "type": "string",
+                    "example": "/documents/report.pdf"
+                },
+                "reason": {
+                    "type": "string",
+                    "example": "overwrite"
+                },
+                "size": {
+                    "type": "integer",
+                    "example": 1048576
+                },
+                "version_id": {
+                    "type": "string",
+                    "example": "01718000000000000000-overwrite"
+                }
+            }
+        },
+        "models.Grant": {
+            "type": "object",
+            "properties": {
+                "path": {
+                    "type": "string",
+                    "example": "/projects"
+                },
+                "permissions": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "read",
+                        "list"
+                    ]
+                },
+                "principal": {
+                    "type": "string",
+                    "example": "group:developers"
+                },
+                "role": {
+                    "type": "string",
+                    "example": "editor"
+                }
+            }
+        },
+        "models.NamespaceUsage": {
+            "type": "object",
+            "properties": {
+                "bytes": {
+                    "type": "integer",
+                    "example": 734003200
+                },
+                "files": {
+                    "type": "integer",
+                    "example": 1250
+                },
+                "max_bytes": {
+                    "type": "integer",
+                    "example": 10737418240
+                },
+                "max_files": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "namespace": {
+                    "type": "string",
+                    "example": "team-a"
+                }
+            }
+        },
+        "models.PathResult": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string",
+                    "example": "path already exists: /backup/site/index.html"
+                },
+                "is_dir": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "path": {
+                    "type": "string",
+                    "example": "/projects/site/index.html"
+                },
+                "target": {
+                    "type": "string",
+                    "example": "/backup/site/index.html"
+                }
+            }
+        },
+        "models.RetentionRule": {
+            "type": "object",
+            "properties": {
+                "keep_versions": {
+                    "type": "integer",
+                    "example": 10
+                },
+                "max_age_seconds": {
+                    "type": "integer",
+                    "example": 2592000
+                },
+                "path": {
+                    "type": "string",
+                    "example": "/documents"
+                }
+            }
+        },
+        "models.Role": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string",
+                    "example": "viewer"
+                },
+                "permissions": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "read",
+                        "list"
+                    ]
+                }
+            }
+        },
+        "models.Share": {
+            "type": "object",
+            "properties": {
+                "bytes": {
+                    "type": "integer",
+                    "example": 1048576
+                },
+                "created_at": {
+                    "type": "integer",
+                    "example": 1735084800
+                },
+                "created_by": {
+                    "type": "string",
+                    "example": "alice"
+                },
+                "expires_at": {
+                    "type": "integer",
+                    "example": 1735689600
+                },
+                "id": {
+                    "type": "string",
+                    "example": "5f0c6a8e-8d3f-4a43-9c1e-2b7f4f1f2a10"
+                },
+                "max_bytes": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "max_uses": {
+                    "type": "integer",
+                    "example": 3
+                },
+                "operation": {
+                    "type": "string",
+                    "example": "download"
+                },
+                "path": {
+                    "type": "string",
+                    "example": "reports/q3.pdf"
+                },
+                "url": {
+                    "type": "string",
+                    "example": "https://files.example.com/api/v1/files/download?file_path=reports%2Fq3.pdf\u0026share=..."
+                },
+                "uses": {
+                    "type": "integer",
+                    "example": 1
+                }
+            }
+        },
+        "models.TrashEntry": {
+            "type": "object",
+            "properties": {
+                "deleted_at": {
+                    "type": "integer",
+                    "example": 1718000000
+                },
+                "entry_id": {
+                    "type": "string",
+                    "example": "5b7f3c2e-8a0d-4f61-9e3b-2c4d6a8b0e1f"
+                },
+                "file_name": {
+                    "type": "string",
+                    "example": "/documents/report.pdf"
+                },
+                "size": {
+                    "type": "integer",
+                    "example": 1048576
+                }
+            }
+        },
+        "models.TreeReport": {
+            "type": "object",
+            "properties": {
+                "done": {
+                    "type": "integer",
+                    "example": 41
+                },
+                "entries": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.PathResult"
+                    }
+                },
+                "failed": {
+                    "type": "integer",
+                    "example": 1
+                }
+            }
+        },
+        "models.UploadReport": {
+            "type": "object",
+            "properties": {
+                "failed": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "files": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.UploadResult"
+                    }
+                },
+                "uploaded": {
+                    "type": "integer",
+                    "example": 41
+                }
+            }
+        },
+        "models.UploadResult": {
+            "type": "object",
+            "properties": {
+                "bytes": {
+                    "type": "integer",
+                    "example": 52428
+                },
+                "code": {
+                    "description": "Code is the HTTP status of the entry.",
+                    "type": "integer",
+                    "example": 200
+                },
+                "error": {
+                    "type": "string",
+                    "example": ""
+                },
+                "is_dir": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "path": {
+                    "type": "string",
+                    "example": "photos/2024/beach.jpg"
+                }
+            }
+        },
+        "models.UploadSession": {
+            "type": "object",
+            "properties": {
+                "committed_offset": {
+                    "type": "integer",
+                    "example": 1048576
+                },
+                "created_at": {
+                    "type": "integer",
+                    "example": 1718000000
+                },
+                "file_name": {
+                    "type": "string",
+                    "example": "/builds/artifact.tar.gz"
+                },
+                "sha256": {
+                    "type": "string",
+                    "example": "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
+                },
+                "total_size": {
+                    "type": "integer",
+                    "example": 4294967296
+                },
+                "upload_id": {
+                    "type": "string",
+                    "example": "1f0d5a0e-7c1b-4b8e-9a51-1b2f8c6a1d2e"
+                }
+            }
+        },
+        "models.WatchEvent": {
+            "type": "object",
+            "properties": {
+                "is_dir": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "old_path": {
+                    "type": "string",
+                    "example": "reports/q3.pdf"
+                },
+                "path": {
+                    "type": "string",
+                    "example": "reports/q3-final.pdf"
+                },
+                "seq": {
+                    "type": "integer",
+                    "example": 1735084800000042
+                },
+                "time": {
+                    "type": "integer",
+                    "example": 1735084800
+                },
+                "type": {
+                    "type": "string",
+                    "example": "move"
+                }
+            }
+        },
+        "models.Webhook": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "integer",
+                    "example": 1735084800
+                },
+                "created_by": {
+                    "type": "string",
+                    "example": "alice"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "0b8f6f52-1f0e-4f3b-9a53-3c1d7e2f9a41"
+                },
+                "path_prefix": {
+                    "type": "string",
+                    "example": "incoming"
+                },
+                "secret": {
+                    "type": "string",
+                    "example": "q3J8kT0y6hQ2lV9cR1mXzA4bN7eW5uF0sD8gH2jK3pY"
+                },
+                "types": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "create",
+                        "modify"
+                    ]
+                },
+                "url": {
+                    "type": "string",
+                    "example": "https://indexer.example.com/hooks/files"
                 }
             }
         }
@@ -387,7 +3321,7 @@ const docTemplate = `{
 var SwaggerInfo = &swag.Spec{
 	Version:          "1.0",
 	Host:             "localhost:8080",
-	BasePath:         "/api/v1/files",
+	BasePath:         "/api/v1",
 	Schemes:          []string{},
 	Title:            "Swagger Example API",
 	Description:      "Api for file management",
@@ -2,9 +2,12 @@ package App
 
 import (
 	"context"
+	"github.com/JunBSer/FileManager/internal/auth"
 	"github.com/JunBSer/FileManager/internal/config"
+	"github.com/JunBSer/FileManager/internal/metrics"
 	"github.com/JunBSer/FileManager/internal/repository"
 	"github.com/JunBSer/FileManager/internal/service"
+	"github.com/JunBSer/FileManager/internal/tracing"
 	"github.com/JunBSer/FileManager/internal/transport/grpc"
 	"github.com/JunBSer/FileManager/pkg/logger"
 	"go.uber.org/zap"
@@ -21,14 +24,127 @@ func MustRun(cfg *config.Config) {
 
 	mainLogger.Info(ctx, "Starting file-service...")
 
-	fileRepo := repository.New(cfg.Storage.StoragePath, cfg.Storage.MaxSize, cfg.Storage.ReadSize)
-	fileService := service.New(fileRepo)
+	shutdownTracing, err := tracing.Setup(ctx, cfg.Tracing, cfg.App.ServiceName)
+	if err != nil {
+		panic(err)
+	}
+
+	fileRepo, err := repository.Open(ctx, cfg.Storage)
+	if err != nil {
+		panic(err)
+	}
+	if cfg.Metrics.Enabled {
+		fileRepo = metrics.ObserveRepository(fileRepo)
+	}
+	if dedup, ok := repository.Layer[*repository.DedupRepo](fileRepo); ok && cfg.Storage.Dedup.GCInterval > 0 {
+		go dedup.RunGC(ctx, cfg.Storage.Dedup.GCInterval)
+	}
+	if encrypted, ok := repository.Layer[*repository.EncryptedRepo](fileRepo); ok && cfg.Storage.Encryption.Rewrap {
+		go encrypted.RunRewrap(ctx)
+	}
+	var events *repository.EventHub
+	if watch, ok := repository.Layer[*repository.WatchRepo](fileRepo); ok {
+		events = watch.Events()
+		if local, ok := repository.Layer[*repository.FileStorageRepo](fileRepo); ok && cfg.Storage.Watch.Disk {
+			go watch.RunDiskWatch(ctx, local.Root())
+		}
+	}
+
+	versionStore := repository.NewVersionStore(fileRepo, cfg.Storage.Versions)
+	versions := versionStore
+	if !cfg.Storage.Versions.Enabled {
+		versions = nil
+	}
+
+	trashStore := repository.NewTrash(fileRepo)
+	trash := trashStore
+	if !cfg.Storage.Trash.Enabled {
+		trash = nil
+	} else if cfg.Storage.Trash.MaxAge > 0 && cfg.Storage.Trash.PurgeInterval > 0 {
+		go trashStore.RunPurge(ctx, cfg.Storage.Trash.PurgeInterval, cfg.Storage.Trash.MaxAge)
+	}
 
-	grpcServer, err := grpc.New(ctx, &cfg.GRPc, fileService)
+	roles, err := repository.ParseRoles(cfg.Storage.ACL.Roles)
+	if err != nil {
+		panic(err)
+	}
+	acl, err := service.NewACL(repository.NewACLStore(fileRepo, roles), cfg.Storage.ACL)
 	if err != nil {
 		panic(err)
 	}
 
+	fileService := service.New(fileRepo, versions, trash, acl)
+	sessionService := service.NewUploadSessionService(repository.NewUploadSessionStore(fileRepo), versions, acl)
+	versionService := service.NewVersionService(versionStore, fileRepo, acl)
+	trashService := service.NewTrashService(trashStore, acl)
+	quotas, ok := repository.Layer[*repository.QuotaRepo](fileRepo)
+	if ok && cfg.Metrics.Enabled {
+		metrics.Registry.MustRegister(metrics.NewStorageCollector(ctx, quotas))
+	}
+	quotaService := service.NewQuotaService(quotas, acl)
+	aclService := service.NewACLService(acl)
+	shareService := service.NewShareService(repository.NewShareStore(fileRepo), cfg.Storage.Share, acl)
+	watchService := service.NewWatchService(events, acl)
+	webhookService := service.NewWebhookService(repository.NewWebhookStore(fileRepo), events, cfg.Storage.Webhooks, acl)
+	go webhookService.Run(ctx)
+
+	var auditLog *repository.AuditLog
+	if cfg.Storage.Audit.Enabled {
+		auditLog, err = repository.OpenAuditLog(cfg.Storage.Audit.Path)
+		if err != nil {
+			panic(err)
+		}
+		defer auditLog.Close()
+		if torn := auditLog.Torn(); torn > 0 {
+			mainLogger.Error(ctx, "Cut off a partial last record of the audit log", zap.String("path", cfg.Storage.Audit.Path), zap.Int64("bytes", torn))
+		}
+		go func() {
+			n, err := auditLog.Verify()
+			if err != nil {
+				mainLogger.Error(ctx, "Audit log failed verification", zap.String("path", cfg.Storage.Audit.Path), zap.Error(err))
+				return
+			}
+			mainLogger.Info(ctx, "Audit log verified", zap.String("path", cfg.Storage.Audit.Path), zap.Uint64("records", n))
+		}()
+	}
+	auditService := service.NewAuditService(auditLog, acl)
+
+	var storagePath string
+	if cfg.Storage.Backend == "local" {
+		storagePath = cfg.Storage.StoragePath
+	}
+	healthService := service.NewHealthService(storagePath, cfg.Storage.Health)
+
+	authn, err := auth.New(cfg.Auth)
+	if err != nil {
+		panic(err)
+	}
+
+	grpcServer, err := grpc.New(ctx, &cfg.GRPc, grpc.Services{
+		Files:    fileService,
+		Sessions: sessionService,
+		Versions: versionService,
+		Trash:    trashService,
+		Quotas:   quotaService,
+		ACL:      aclService,
+		Shares:   shareService,
+		Watch:    watchService,
+		Webhooks: webhookService,
+		Audit:    auditService,
+		Health:   healthService,
+	}, authn)
+	if err != nil {
+		panic(err)
+	}
+
+	if cfg.Metrics.Enabled {
+		go func() {
+			if err := metrics.Serve(ctx, cfg.Metrics); err != nil {
+				mainLogger.Error(ctx, "Error occurred while serving metrics", zap.Error(err))
+			}
+		}()
+	}
+
 	graceCh := make(chan os.Signal, 2)
 	signal.Notify(graceCh, syscall.SIGINT, syscall.SIGTERM)
 
@@ -42,4 +158,7 @@ func MustRun(cfg *config.Config) {
 	sig := <-graceCh
 	mainLogger.Info(ctx, "Shutting down...", zap.String("signal", sig.String()))
 	grpcServer.Stop(ctx)
+	if err := shutdownTracing(ctx); err != nil {
+		mainLogger.Error(ctx, "Error occurred while flushing traces", zap.Error(err))
+	}
 }
@@ -4,6 +4,7 @@ import (
 	"context"
 	"github.com/JunBSer/FileManager/internal/config"
 	"github.com/JunBSer/FileManager/internal/gateway"
+	"github.com/JunBSer/FileManager/internal/tracing"
 	"github.com/JunBSer/FileManager/pkg/logger"
 	"go.uber.org/zap"
 	"os"
@@ -19,7 +20,13 @@ func MustRun(cfg *config.Config) {
 
 	mainLogger.Info(ctx, "Starting gateway...")
 
-	gw, err := gateway.New(ctx, &cfg.GRPc, &cfg.Http, &cfg.Gw)
+	shutdownTracing, err := tracing.Setup(ctx, cfg.Tracing, "Gateway")
+	if err != nil {
+		mainLogger.Error(ctx, "Error occurred while setting up tracing", zap.Error(err))
+		panic(err)
+	}
+
+	gw, err := gateway.New(ctx, &cfg.GRPc, &cfg.Http, &cfg.Gw, &cfg.Auth, &cfg.Metrics)
 	if err != nil {
 		mainLogger.Error(ctx, "Error occurred while creating gateway", zap.Error(err))
 		panic(err)
@@ -38,4 +45,7 @@ func MustRun(cfg *config.Config) {
 	sig := <-graceCh
 	mainLogger.Info(ctx, "Shutting down...", zap.String("signal", sig.String()))
 	gw.Stop(ctx)
+	if err := shutdownTracing(ctx); err != nil {
+		mainLogger.Error(ctx, "Error occurred while flushing traces", zap.Error(err))
+	}
 }
@@ -0,0 +1,77 @@
+package auth
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// apiKeys maps the SHA-256 of every key to its subject, so keys are not
+// kept in memory and lookups do not leak their content through timing.
+type apiKeys struct {
+	subjects map[[sha256.Size]byte]string
+}
+
+// loadAPIKeys reads the subject=key pairs of list and of the lines of file.
+// Empty lines and lines starting with # are ignored in the file.
+func loadAPIKeys(list, file string) (*apiKeys, error) {
+	keys := &apiKeys{subjects: map[[sha256.Size]byte]string{}}
+
+	add := func(pair, source string) error {
+		subject, key, ok := strings.Cut(pair, "=")
+		subject, key = strings.TrimSpace(subject), strings.TrimSpace(key)
+		if !ok || subject == "" || key == "" {
+			return fmt.Errorf("invalid API key in %s", source)
+		}
+
+		sum := sha256.Sum256([]byte(key))
+		if other, exists := keys.subjects[sum]; exists && other != subject {
+			return fmt.Errorf("API key of %s is used by %s too", subject, other)
+		}
+		keys.subjects[sum] = subject
+		return nil
+	}
+
+	for _, pair := range strings.Split(list, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		if err := add(pair, "AUTH_API_KEYS"); err != nil {
+			return nil, err
+		}
+	}
+
+	if file != "" {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+
+		scanner := bufio.NewScanner(bytes.NewReader(data))
+		for line := 1; scanner.Scan(); line++ {
+			text := strings.TrimSpace(scanner.Text())
+			if text == "" || strings.HasPrefix(text, "#") {
+				continue
+			}
+			if err = add(text, fmt.Sprintf("%s line %d", file, line)); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if len(keys.subjects) == 0 {
+		return nil, fmt.Errorf("apikey authentication needs at least one API key")
+	}
+	return keys, nil
+}
+
+func (k *apiKeys) authenticate(key string) (*Identity, error) {
+	subject, ok := k.subjects[sha256.Sum256([]byte(key))]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown API key", ErrInvalidCredentials)
+	}
+	return &Identity{Subject: subject, Method: MethodAPIKey}, nil
+}
@@ -0,0 +1,196 @@
+// Package auth authenticates the callers of the gateway and the gRPC server
+// with static API keys, HMAC signed JWTs or TLS client certificates.
+package auth
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"go.uber.org/zap"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	MethodAPIKey = "apikey"
+	MethodJWT    = "jwt"
+	MethodMTLS   = "mtls"
+	// MethodShare marks callers authorized by a share token instead of
+	// credentials. It cannot be configured.
+	MethodShare = "share"
+)
+
+var (
+	ErrNoCredentials      = errors.New("no credentials")
+	ErrInvalidCredentials = errors.New("invalid credentials")
+	ErrUntrustedProxy     = errors.New("caller may not act for other identities")
+)
+
+type Config struct {
+	// Methods is a comma separated list of the accepted credentials: apikey,
+	// jwt and mtls. Without methods every caller is accepted anonymously.
+	Methods string `env:"AUTH_METHODS"`
+	// APIKeys is a comma separated list of subject=key pairs.
+	APIKeys string `env:"AUTH_API_KEYS"`
+	// APIKeysFile holds one subject=key pair per line.
+	APIKeysFile string `env:"AUTH_API_KEYS_FILE"`
+	// JWKSFile is a JSON Web Key Set of the HMAC keys JWTs are signed with.
+	// It is read again when it changes.
+	JWKSFile    string        `env:"AUTH_JWKS_FILE"`
+	JWTIssuer   string        `env:"AUTH_JWT_ISSUER"`
+	JWTAudience string        `env:"AUTH_JWT_AUDIENCE"`
+	JWTLeeway   time.Duration `env:"AUTH_JWT_LEEWAY" envDefault:"30s"`
+	// ClientCAFile holds the PEM certificates client certificates must be
+	// issued by.
+	ClientCAFile string `env:"AUTH_CLIENT_CA_FILE"`
+	// TrustedProxies is a comma separated list of subjects that may pass on
+	// the identity of their callers, like the gateway.
+	TrustedProxies string `env:"AUTH_TRUSTED_PROXIES"`
+	// BackendAPIKey is the key the gateway authenticates to the gRPC server
+	// with.
+	BackendAPIKey string `env:"AUTH_BACKEND_API_KEY"`
+}
+
+// Identity is an authenticated caller.
+type Identity struct {
+	Subject string
+	Groups  []string
+	// Method is the kind of credentials the caller presented.
+	Method string
+	// Via is the trusted proxy that passed on the identity, if any.
+	Via string
+}
+
+// Credentials are what a request presents to prove its identity.
+type Credentials struct {
+	// Bearer is the token of an "Authorization: Bearer" header.
+	Bearer string
+	APIKey string
+	// Certificates are the TLS client certificates, leaf first.
+	Certificates []*x509.Certificate
+}
+
+// Authenticator checks credentials with the configured methods.
+type Authenticator struct {
+	keys    *apiKeys
+	jwt     *jwtVerifier
+	mtls    *certVerifier
+	trusted []string
+}
+
+type identityKey struct{}
+
+// New creates the authenticator of cfg. It returns nil if no method is
+// configured; a nil Authenticator accepts every caller.
+func New(cfg Config) (*Authenticator, error) {
+	a := &Authenticator{}
+
+	for _, method := range strings.Split(cfg.Methods, ",") {
+		var err error
+
+		switch strings.ToLower(strings.TrimSpace(method)) {
+		case "":
+			continue
+		case MethodAPIKey:
+			a.keys, err = loadAPIKeys(cfg.APIKeys, cfg.APIKeysFile)
+		case MethodJWT:
+			a.jwt, err = newJWTVerifier(cfg.JWKSFile, cfg.JWTIssuer, cfg.JWTAudience, cfg.JWTLeeway)
+		case MethodMTLS:
+			a.mtls, err = loadCertVerifier(cfg.ClientCAFile)
+		default:
+			err = fmt.Errorf("unknown authentication method %q", method)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if a.keys == nil && a.jwt == nil && a.mtls == nil {
+		return nil, nil
+	}
+
+	for _, subject := range strings.Split(cfg.TrustedProxies, ",") {
+		if subject = strings.TrimSpace(subject); subject != "" {
+			a.trusted = append(a.trusted, subject)
+		}
+	}
+	return a, nil
+}
+
+// Enabled reports whether callers have to authenticate.
+func (a *Authenticator) Enabled() bool {
+	return a != nil
+}
+
+// Authenticate returns the identity creds prove. Credentials of every
+// presented kind must be valid; it fails with ErrNoCredentials if none of
+// the configured kinds were presented.
+func (a *Authenticator) Authenticate(creds Credentials) (*Identity, error) {
+	var id *Identity
+	check := func(found *Identity, err error) error {
+		if err != nil {
+			return err
+		}
+		if id == nil {
+			id = found
+		}
+		return nil
+	}
+
+	if a.keys != nil && creds.APIKey != "" {
+		if err := check(a.keys.authenticate(creds.APIKey)); err != nil {
+			return nil, err
+		}
+	}
+	if a.jwt != nil && creds.Bearer != "" {
+		if err := check(a.jwt.authenticate(creds.Bearer)); err != nil {
+			return nil, err
+		}
+	}
+	if a.mtls != nil && len(creds.Certificates) > 0 {
+		if err := check(a.mtls.authenticate(creds.Certificates)); err != nil {
+			return nil, err
+		}
+	}
+
+	if id == nil {
+		return nil, ErrNoCredentials
+	}
+	return id, nil
+}
+
+// Delegate returns the identity a proxy passed on for its caller. Only
+// trusted proxies may do so.
+func (a *Authenticator) Delegate(proxy *Identity, caller Identity) (*Identity, error) {
+	if !slices.Contains(a.trusted, proxy.Subject) {
+		return nil, fmt.Errorf("%w: %s", ErrUntrustedProxy, proxy.Subject)
+	}
+	if caller.Subject == "" {
+		return nil, fmt.Errorf("%w: empty subject", ErrInvalidCredentials)
+	}
+
+	caller.Via = proxy.Subject
+	return &caller, nil
+}
+
+// NewContext returns a copy of ctx carrying id.
+func NewContext(ctx context.Context, id *Identity) context.Context {
+	return context.WithValue(ctx, identityKey{}, id)
+}
+
+// FromContext returns the identity of the caller of ctx.
+func FromContext(ctx context.Context) (*Identity, bool) {
+	id, ok := ctx.Value(identityKey{}).(*Identity)
+	return id, ok
+}
+
+// LogFields describe id in log lines.
+func (id *Identity) LogFields() []zap.Field {
+	fields := []zap.Field{zap.String("subject", id.Subject), zap.String("authMethod", id.Method)}
+	if id.Via != "" {
+		fields = append(fields, zap.String("via", id.Via))
+	}
+	return fields
+}
@@ -0,0 +1,176 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+func newTestCA(t *testing.T) *testCA {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return &testCA{cert: cert, key: key}
+}
+
+func (ca *testCA) issue(t *testing.T, subject pkix.Name, usage x509.ExtKeyUsage) *x509.Certificate {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      subject,
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return cert
+}
+
+func (ca *testCA) file(t *testing.T) string {
+	t.Helper()
+
+	file := filepath.Join(t.TempDir(), "ca.pem")
+	data := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.cert.Raw})
+	require.NoError(t, os.WriteFile(file, data, 0600))
+	return file
+}
+
+func TestNew(t *testing.T) {
+	authn, err := New(Config{})
+	require.NoError(t, err)
+	assert.Nil(t, authn)
+	assert.False(t, authn.Enabled())
+
+	_, err = New(Config{Methods: "password"})
+	assert.Error(t, err)
+
+	_, err = New(Config{Methods: "apikey"})
+	assert.Error(t, err, "API key authentication needs keys")
+
+	_, err = New(Config{Methods: "apikey", APIKeys: "alice=1,bob=1"})
+	assert.Error(t, err, "keys must be unique")
+}
+
+func TestAuthenticator_APIKeys(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "keys")
+	require.NoError(t, os.WriteFile(file, []byte("# service keys\n\ngateway = gw-secret\n"), 0600))
+
+	authn, err := New(Config{Methods: "apikey", APIKeys: "alice=alice-secret", APIKeysFile: file})
+	require.NoError(t, err)
+	require.True(t, authn.Enabled())
+
+	id, err := authn.Authenticate(Credentials{APIKey: "alice-secret"})
+	require.NoError(t, err)
+	assert.Equal(t, &Identity{Subject: "alice", Method: MethodAPIKey}, id)
+
+	id, err = authn.Authenticate(Credentials{APIKey: "gw-secret"})
+	require.NoError(t, err)
+	assert.Equal(t, "gateway", id.Subject)
+
+	_, err = authn.Authenticate(Credentials{APIKey: "guess"})
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+
+	_, err = authn.Authenticate(Credentials{})
+	assert.ErrorIs(t, err, ErrNoCredentials)
+
+	_, err = authn.Authenticate(Credentials{Bearer: "token"})
+	assert.ErrorIs(t, err, ErrNoCredentials, "credentials of methods that are not enabled are ignored")
+}
+
+func TestAuthenticator_Methods(t *testing.T) {
+	jwks := filepath.Join(t.TempDir(), "jwks.json")
+	writeJWKS(t, jwks, map[string]string{"k1": "secret"})
+
+	authn, err := New(Config{Methods: "apikey, jwt", APIKeys: "alice=alice-secret", JWKSFile: jwks})
+	require.NoError(t, err)
+
+	token := signJWT(t, "k1", "secret", map[string]any{"sub": "bob", "exp": time.Now().Add(time.Hour).Unix()})
+
+	id, err := authn.Authenticate(Credentials{Bearer: token})
+	require.NoError(t, err)
+	assert.Equal(t, "bob", id.Subject)
+
+	id, err = authn.Authenticate(Credentials{APIKey: "alice-secret", Bearer: token})
+	require.NoError(t, err)
+	assert.Equal(t, "alice", id.Subject)
+
+	_, err = authn.Authenticate(Credentials{APIKey: "alice-secret", Bearer: "forged"})
+	assert.ErrorIs(t, err, ErrInvalidCredentials, "every presented credential must be valid")
+}
+
+func TestAuthenticator_MTLS(t *testing.T) {
+	ca := newTestCA(t)
+	authn, err := New(Config{Methods: "mtls", ClientCAFile: ca.file(t)})
+	require.NoError(t, err)
+	assert.NotNil(t, authn.Roots())
+
+	client := ca.issue(t, pkix.Name{CommonName: "carol", OrganizationalUnit: []string{"ops"}}, x509.ExtKeyUsageClientAuth)
+	id, err := authn.Authenticate(Credentials{Certificates: []*x509.Certificate{client}})
+	require.NoError(t, err)
+	assert.Equal(t, &Identity{Subject: "carol", Groups: []string{"ops"}, Method: MethodMTLS}, id)
+
+	server := ca.issue(t, pkix.Name{CommonName: "server"}, x509.ExtKeyUsageServerAuth)
+	_, err = authn.Authenticate(Credentials{Certificates: []*x509.Certificate{server}})
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+
+	stranger := newTestCA(t).issue(t, pkix.Name{CommonName: "carol"}, x509.ExtKeyUsageClientAuth)
+	_, err = authn.Authenticate(Credentials{Certificates: []*x509.Certificate{stranger}})
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+}
+
+func TestAuthenticator_Delegate(t *testing.T) {
+	authn, err := New(Config{Methods: "apikey", APIKeys: "gateway=gw-secret,alice=alice-secret", TrustedProxies: "gateway"})
+	require.NoError(t, err)
+
+	proxy, err := authn.Authenticate(Credentials{APIKey: "gw-secret"})
+	require.NoError(t, err)
+
+	id, err := authn.Delegate(proxy, Identity{Subject: "bob", Method: MethodJWT})
+	require.NoError(t, err)
+	assert.Equal(t, &Identity{Subject: "bob", Method: MethodJWT, Via: "gateway"}, id)
+
+	alice, err := authn.Authenticate(Credentials{APIKey: "alice-secret"})
+	require.NoError(t, err)
+	_, err = authn.Delegate(alice, Identity{Subject: "bob"})
+	assert.ErrorIs(t, err, ErrUntrustedProxy)
+
+	_, err = authn.Delegate(proxy, Identity{})
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+}
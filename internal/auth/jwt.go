@@ -0,0 +1,239 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+var jwtAlgorithms = map[string]func() hash.Hash{
+	"HS256": sha256.New,
+	"HS384": sha512.New384,
+	"HS512": sha512.New,
+}
+
+// jwk is a key of a JSON Web Key Set. Only symmetric keys are used.
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	K   string `json:"k"`
+}
+
+type jwtKey struct {
+	alg    string
+	secret []byte
+}
+
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+// audience is the aud claim, which may be a string or a list of strings.
+type audience []string
+
+type jwtClaims struct {
+	Subject   string   `json:"sub"`
+	Issuer    string   `json:"iss"`
+	Audience  audience `json:"aud"`
+	ExpiresAt *int64   `json:"exp"`
+	NotBefore *int64   `json:"nbf"`
+	Groups    []string `json:"groups"`
+}
+
+// jwtVerifier checks HMAC signed JWTs with the keys of a JWKS file. The file
+// is read again when its modification time changes, so keys can be rotated
+// without a restart.
+type jwtVerifier struct {
+	file     string
+	issuer   string
+	audience string
+	leeway   time.Duration
+	now      func() time.Time
+
+	mu      sync.Mutex
+	keys    map[string]jwtKey
+	modTime time.Time
+}
+
+func (a *audience) UnmarshalJSON(data []byte) error {
+	var one string
+	if err := json.Unmarshal(data, &one); err == nil {
+		*a = audience{one}
+		return nil
+	}
+
+	var many []string
+	if err := json.Unmarshal(data, &many); err != nil {
+		return err
+	}
+	*a = many
+	return nil
+}
+
+func newJWTVerifier(file, issuer, audience string, leeway time.Duration) (*jwtVerifier, error) {
+	if file == "" {
+		return nil, fmt.Errorf("jwt authentication needs a JWKS file")
+	}
+
+	v := &jwtVerifier{file: file, issuer: issuer, audience: audience, leeway: leeway, now: time.Now}
+	if err := v.reload(); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// parseJWKS returns the symmetric keys of a JWKS by key ID.
+func parseJWKS(data []byte) (map[string]jwtKey, error) {
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("invalid JWKS: %w", err)
+	}
+
+	keys := map[string]jwtKey{}
+	for _, key := range set.Keys {
+		if key.Kty != "oct" {
+			continue
+		}
+		if _, ok := jwtAlgorithms[key.Alg]; key.Alg != "" && !ok {
+			return nil, fmt.Errorf("unsupported algorithm %q of key %q", key.Alg, key.Kid)
+		}
+
+		secret, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(key.K, "="))
+		if err != nil || len(secret) == 0 {
+			return nil, fmt.Errorf("invalid secret of key %q", key.Kid)
+		}
+		keys[key.Kid] = jwtKey{alg: key.Alg, secret: secret}
+	}
+
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("JWKS has no symmetric keys")
+	}
+	return keys, nil
+}
+
+// reload reads the JWKS file if it changed since it was read last. A file
+// that cannot be used keeps the previous keys in place.
+func (v *jwtVerifier) reload() error {
+	info, err := os.Stat(v.file)
+	if err != nil {
+		return err
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if v.keys != nil && info.ModTime().Equal(v.modTime) {
+		return nil
+	}
+
+	data, err := os.ReadFile(v.file)
+	if err != nil {
+		return err
+	}
+	keys, err := parseJWKS(data)
+	if err != nil {
+		return fmt.Errorf("%s: %w", v.file, err)
+	}
+
+	v.keys, v.modTime = keys, info.ModTime()
+	return nil
+}
+
+func (v *jwtVerifier) key(kid string) (jwtKey, bool) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if key, ok := v.keys[kid]; ok {
+		return key, true
+	}
+	if kid == "" && len(v.keys) == 1 {
+		for _, key := range v.keys {
+			return key, true
+		}
+	}
+	return jwtKey{}, false
+}
+
+func (v *jwtVerifier) authenticate(token string) (*Identity, error) {
+	invalid := func(reason string) error {
+		return fmt.Errorf("%w: %s", ErrInvalidCredentials, reason)
+	}
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, invalid("malformed token")
+	}
+
+	var header jwtHeader
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, invalid("malformed header")
+	}
+	newHash, ok := jwtAlgorithms[header.Alg]
+	if !ok {
+		return nil, invalid(fmt.Sprintf("unsupported algorithm %q", header.Alg))
+	}
+
+	// Errors only keep the keys that were read before.
+	_ = v.reload()
+	key, ok := v.key(header.Kid)
+	if !ok {
+		return nil, invalid(fmt.Sprintf("unknown key %q", header.Kid))
+	}
+	if key.alg != "" && key.alg != header.Alg {
+		return nil, invalid("algorithm does not match the key")
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, invalid("malformed signature")
+	}
+	mac := hmac.New(newHash, key.secret)
+	mac.Write([]byte(parts[0] + "." + parts[1]))
+	if !hmac.Equal(signature, mac.Sum(nil)) {
+		return nil, invalid("bad signature")
+	}
+
+	var claims jwtClaims
+	if err = decodeSegment(parts[1], &claims); err != nil {
+		return nil, invalid("malformed claims")
+	}
+
+	now := v.now()
+	switch {
+	case claims.Subject == "":
+		return nil, invalid("missing subject")
+	case claims.ExpiresAt == nil:
+		return nil, invalid("missing expiry")
+	case now.After(time.Unix(*claims.ExpiresAt, 0).Add(v.leeway)):
+		return nil, invalid("token expired")
+	case claims.NotBefore != nil && now.Before(time.Unix(*claims.NotBefore, 0).Add(-v.leeway)):
+		return nil, invalid("token not valid yet")
+	case v.issuer != "" && claims.Issuer != v.issuer:
+		return nil, invalid("wrong issuer")
+	case v.audience != "" && !slices.Contains(claims.Audience, v.audience):
+		return nil, invalid("wrong audience")
+	}
+
+	return &Identity{Subject: claims.Subject, Groups: claims.Groups, Method: MethodJWT}, nil
+}
+
+func decodeSegment(segment string, v any) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
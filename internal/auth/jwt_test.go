@@ -0,0 +1,120 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeJWKS(t *testing.T, file string, keys map[string]string) {
+	t.Helper()
+
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	for kid, secret := range keys {
+		set.Keys = append(set.Keys, jwk{Kty: "oct", Kid: kid, Alg: "HS256", K: base64.RawURLEncoding.EncodeToString([]byte(secret))})
+	}
+	data, err := json.Marshal(set)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(file, data, 0600))
+}
+
+func signJWT(t *testing.T, kid, secret string, claims map[string]any) string {
+	t.Helper()
+
+	header, err := json.Marshal(map[string]string{"alg": "HS256", "typ": "JWT", "kid": kid})
+	require.NoError(t, err)
+	payload, err := json.Marshal(claims)
+	require.NoError(t, err)
+
+	unsigned := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(unsigned))
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func TestJWTVerifier(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "jwks.json")
+	writeJWKS(t, file, map[string]string{"k1": "first secret"})
+
+	v, err := newJWTVerifier(file, "issuer", "filemanager", time.Minute)
+	require.NoError(t, err)
+
+	now := time.Now()
+	claims := func(changes map[string]any) map[string]any {
+		c := map[string]any{"sub": "alice", "iss": "issuer", "aud": []string{"other", "filemanager"},
+			"exp": now.Add(time.Hour).Unix(), "groups": []string{"admins"}}
+		for k, val := range changes {
+			if val == nil {
+				delete(c, k)
+				continue
+			}
+			c[k] = val
+		}
+		return c
+	}
+
+	t.Run("valid token", func(t *testing.T) {
+		id, err := v.authenticate(signJWT(t, "k1", "first secret", claims(nil)))
+		require.NoError(t, err)
+		assert.Equal(t, &Identity{Subject: "alice", Groups: []string{"admins"}, Method: MethodJWT}, id)
+	})
+
+	t.Run("audience string", func(t *testing.T) {
+		_, err := v.authenticate(signJWT(t, "k1", "first secret", claims(map[string]any{"aud": "filemanager"})))
+		assert.NoError(t, err)
+	})
+
+	tests := []struct {
+		testName string
+		token    string
+	}{
+		{"Expired", signJWT(t, "k1", "first secret", claims(map[string]any{"exp": now.Add(-2 * time.Minute).Unix()}))},
+		{"Not valid yet", signJWT(t, "k1", "first secret", claims(map[string]any{"nbf": now.Add(2 * time.Minute).Unix()}))},
+		{"Missing expiry", signJWT(t, "k1", "first secret", claims(map[string]any{"exp": nil}))},
+		{"Missing subject", signJWT(t, "k1", "first secret", claims(map[string]any{"sub": nil}))},
+		{"Wrong audience", signJWT(t, "k1", "first secret", claims(map[string]any{"aud": "other"}))},
+		{"Wrong issuer", signJWT(t, "k1", "first secret", claims(map[string]any{"iss": "someone"}))},
+		{"Bad signature", signJWT(t, "k1", "wrong secret", claims(nil))},
+		{"Unknown key", signJWT(t, "k2", "first secret", claims(nil))},
+		{"Malformed", "not.a-token"},
+	}
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			_, err := v.authenticate(test.token)
+			assert.ErrorIs(t, err, ErrInvalidCredentials)
+		})
+	}
+
+	t.Run("expiry within leeway", func(t *testing.T) {
+		_, err := v.authenticate(signJWT(t, "k1", "first secret", claims(map[string]any{"exp": now.Add(-30 * time.Second).Unix()})))
+		assert.NoError(t, err)
+	})
+
+	t.Run("JWKS reload", func(t *testing.T) {
+		writeJWKS(t, file, map[string]string{"k2": "second secret"})
+		later := time.Now().Add(time.Second)
+		require.NoError(t, os.Chtimes(file, later, later))
+
+		_, err := v.authenticate(signJWT(t, "k2", "second secret", claims(nil)))
+		assert.NoError(t, err)
+		_, err = v.authenticate(signJWT(t, "k1", "first secret", claims(nil)))
+		assert.ErrorIs(t, err, ErrInvalidCredentials)
+
+		require.NoError(t, os.WriteFile(file, []byte("broken"), 0600))
+		later = later.Add(time.Second)
+		require.NoError(t, os.Chtimes(file, later, later))
+
+		_, err = v.authenticate(signJWT(t, "k2", "second secret", claims(nil)))
+		assert.NoError(t, err, "keys are kept when the JWKS file is broken")
+	})
+}
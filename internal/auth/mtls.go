@@ -0,0 +1,62 @@
+package auth
+
+import (
+	"crypto/x509"
+	"fmt"
+	"os"
+)
+
+// certVerifier accepts client certificates issued by one of its CAs. The
+// subject is the common name of the certificate and the groups are its
+// organizational units.
+type certVerifier struct {
+	roots *x509.CertPool
+}
+
+func loadCertVerifier(caFile string) (*certVerifier, error) {
+	if caFile == "" {
+		return nil, fmt.Errorf("mtls authentication needs a client CA file")
+	}
+
+	data, err := os.ReadFile(caFile)
+	if err != nil {
+		return nil, err
+	}
+
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("no certificates in %s", caFile)
+	}
+	return &certVerifier{roots: roots}, nil
+}
+
+// Roots returns the CAs client certificates are checked against, for TLS
+// configurations that ask clients for certificates.
+func (a *Authenticator) Roots() *x509.CertPool {
+	if a == nil || a.mtls == nil {
+		return nil
+	}
+	return a.mtls.roots
+}
+
+func (v *certVerifier) authenticate(chain []*x509.Certificate) (*Identity, error) {
+	intermediates := x509.NewCertPool()
+	for _, cert := range chain[1:] {
+		intermediates.AddCert(cert)
+	}
+
+	leaf := chain[0]
+	_, err := leaf.Verify(x509.VerifyOptions{
+		Roots:         v.roots,
+		Intermediates: intermediates,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCredentials, err)
+	}
+	if leaf.Subject.CommonName == "" {
+		return nil, fmt.Errorf("%w: client certificate has no common name", ErrInvalidCredentials)
+	}
+
+	return &Identity{Subject: leaf.Subject.CommonName, Groups: leaf.Subject.OrganizationalUnit, Method: MethodMTLS}, nil
+}
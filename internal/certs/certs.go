@@ -0,0 +1,323 @@
+// Package certs builds the TLS configurations of the HTTP gateway, the gRPC
+// server and the gateway's connection to the gRPC server. Certificates are
+// read again when their files change, so they can be rotated without a
+// restart.
+package certs
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	ClientAuthNone    = "none"
+	ClientAuthRequest = "request"
+	ClientAuthRequire = "require"
+)
+
+// ServerConfig is the TLS configuration of a listener.
+type ServerConfig struct {
+	Enabled  bool   `env:"TLS_ENABLED"`
+	CertFile string `env:"TLS_CERT_FILE"`
+	KeyFile  string `env:"TLS_KEY_FILE"`
+	// ClientAuth is none, request or require. Requested certificates are
+	// verified if the client sends one.
+	ClientAuth string `env:"TLS_CLIENT_AUTH" envDefault:"request"`
+	// ClientCAFile holds the PEM certificates client certificates must be
+	// issued by.
+	ClientCAFile string `env:"TLS_CLIENT_CA_FILE"`
+	// SelfSigned issues the certificate from a development CA in
+	// SelfSignedDir when no certificate file is set.
+	SelfSigned    bool   `env:"TLS_SELF_SIGNED"`
+	SelfSignedDir string `env:"TLS_SELF_SIGNED_DIR" envDefault:"certs"`
+	// Hosts is a comma separated list of the DNS names and IP addresses of
+	// self-signed certificates.
+	Hosts string `env:"TLS_HOSTS" envDefault:"localhost,127.0.0.1"`
+}
+
+// ClientConfig is the TLS configuration of a connection to a server.
+type ClientConfig struct {
+	Enabled bool `env:"TLS_ENABLED"`
+	// CAFile holds the PEM certificates the server certificate must be issued
+	// by. The system roots are used without it.
+	CAFile     string `env:"TLS_CA_FILE"`
+	ServerName string `env:"TLS_SERVER_NAME"`
+	// CertFile and KeyFile are the client certificate for mutual TLS.
+	CertFile string `env:"TLS_CERT_FILE"`
+	KeyFile  string `env:"TLS_KEY_FILE"`
+	// SelfSigned trusts the development CA in SelfSignedDir and issues a
+	// client certificate from it when no files are set.
+	SelfSigned    bool   `env:"TLS_SELF_SIGNED"`
+	SelfSignedDir string `env:"TLS_SELF_SIGNED_DIR" envDefault:"certs"`
+}
+
+// keyPair is a certificate and key file pair that is loaded again when one
+// of the files changes. A pair that cannot be loaded keeps the previous
+// certificate in place.
+type keyPair struct {
+	certFile, keyFile string
+
+	mu       sync.Mutex
+	cert     *tls.Certificate
+	modTimes [2]time.Time
+}
+
+func newKeyPair(certFile, keyFile string) (*keyPair, error) {
+	if certFile == "" || keyFile == "" {
+		return nil, fmt.Errorf("TLS needs a certificate and a key file")
+	}
+
+	kp := &keyPair{certFile: certFile, keyFile: keyFile}
+	if _, err := kp.certificate(); err != nil {
+		return nil, err
+	}
+	return kp, nil
+}
+
+func modTimes(files ...string) ([2]time.Time, error) {
+	var times [2]time.Time
+	for i, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			return times, err
+		}
+		times[i] = info.ModTime()
+	}
+	return times, nil
+}
+
+func (kp *keyPair) certificate() (*tls.Certificate, error) {
+	kp.mu.Lock()
+	defer kp.mu.Unlock()
+
+	times, err := modTimes(kp.certFile, kp.keyFile)
+	if err != nil || (kp.cert != nil && times == kp.modTimes) {
+		if kp.cert != nil {
+			return kp.cert, nil
+		}
+		return nil, err
+	}
+
+	cert, err := tls.LoadX509KeyPair(kp.certFile, kp.keyFile)
+	if err != nil {
+		if kp.cert != nil {
+			return kp.cert, nil
+		}
+		return nil, err
+	}
+	kp.cert, kp.modTimes = &cert, times
+	return kp.cert, nil
+}
+
+// certPool is a file of PEM certificates that is loaded again when it
+// changes.
+type certPool struct {
+	file string
+
+	mu      sync.Mutex
+	pool    *x509.CertPool
+	modTime time.Time
+}
+
+func newCertPool(file string) (*certPool, error) {
+	cp := &certPool{file: file}
+	if _, err := cp.get(); err != nil {
+		return nil, err
+	}
+	return cp, nil
+}
+
+func loadPool(file string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("no certificates in %s", file)
+	}
+	return pool, nil
+}
+
+func (cp *certPool) get() (*x509.CertPool, error) {
+	cp.mu.Lock()
+	defer cp.mu.Unlock()
+
+	info, err := os.Stat(cp.file)
+	if err != nil || (cp.pool != nil && info.ModTime().Equal(cp.modTime)) {
+		if cp.pool != nil {
+			return cp.pool, nil
+		}
+		return nil, err
+	}
+
+	pool, err := loadPool(cp.file)
+	if err != nil {
+		if cp.pool != nil {
+			return cp.pool, nil
+		}
+		return nil, err
+	}
+	cp.pool, cp.modTime = pool, info.ModTime()
+	return cp.pool, nil
+}
+
+// Server returns the TLS configuration of cfg, or nil if TLS is disabled.
+// name is the common name of a self-signed certificate. roots are the client
+// CAs used when cfg has no client CA file.
+func Server(cfg ServerConfig, name string, roots *x509.CertPool) (*tls.Config, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+
+	if cfg.SelfSigned {
+		ca, err := EnsureCA(cfg.SelfSignedDir)
+		if err != nil {
+			return nil, err
+		}
+		if cfg.CertFile == "" {
+			if cfg.CertFile, cfg.KeyFile, err = ca.Issue(name, splitHosts(cfg.Hosts)); err != nil {
+				return nil, err
+			}
+		}
+		if cfg.ClientCAFile == "" && roots == nil {
+			cfg.ClientCAFile = ca.CertFile()
+		}
+	}
+
+	kp, err := newKeyPair(cfg.CertFile, cfg.KeyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	clientCAs := func() (*x509.CertPool, error) { return roots, nil }
+	if cfg.ClientCAFile != "" {
+		cp, err := newCertPool(cfg.ClientCAFile)
+		if err != nil {
+			return nil, err
+		}
+		clientCAs = cp.get
+	}
+	hasCAs := cfg.ClientCAFile != "" || roots != nil
+
+	config := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+			return kp.certificate()
+		},
+	}
+
+	switch cfg.ClientAuth {
+	case ClientAuthNone:
+		config.ClientAuth = tls.NoClientCert
+		return config, nil
+	case "", ClientAuthRequest:
+		config.ClientAuth = tls.RequestClientCert
+	case ClientAuthRequire:
+		if !hasCAs {
+			return nil, fmt.Errorf("TLS client authentication %q needs a client CA file", cfg.ClientAuth)
+		}
+		config.ClientAuth = tls.RequireAnyClientCert
+	default:
+		return nil, fmt.Errorf("unknown TLS client authentication %q, want none, request or require", cfg.ClientAuth)
+	}
+
+	// Client certificates are verified here instead of through ClientCAs,
+	// so a changed CA file applies to the next handshake. Without CAs they
+	// are left to the authenticator.
+	if hasCAs {
+		config.VerifyPeerCertificate = func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+			if len(rawCerts) == 0 {
+				return nil
+			}
+			pool, err := clientCAs()
+			if err != nil {
+				return err
+			}
+			return verifyClient(rawCerts, pool)
+		}
+	}
+	return config, nil
+}
+
+func verifyClient(rawCerts [][]byte, roots *x509.CertPool) error {
+	intermediates := x509.NewCertPool()
+	var leaf *x509.Certificate
+	for i, raw := range rawCerts {
+		cert, err := x509.ParseCertificate(raw)
+		if err != nil {
+			return err
+		}
+		if i == 0 {
+			leaf = cert
+		} else {
+			intermediates.AddCert(cert)
+		}
+	}
+
+	_, err := leaf.Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	})
+	return err
+}
+
+// Client returns the TLS configuration of cfg, or nil if TLS is disabled.
+// name is the common name of a self-signed client certificate.
+func Client(cfg ClientConfig, name string) (*tls.Config, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+
+	if cfg.SelfSigned {
+		ca, err := EnsureCA(cfg.SelfSignedDir)
+		if err != nil {
+			return nil, err
+		}
+		if cfg.CAFile == "" {
+			cfg.CAFile = ca.CertFile()
+		}
+		if cfg.CertFile == "" {
+			if cfg.CertFile, cfg.KeyFile, err = ca.Issue(name, nil); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	config := &tls.Config{MinVersion: tls.VersionTLS12, ServerName: cfg.ServerName}
+	if cfg.CAFile != "" {
+		pool, err := loadPool(cfg.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		config.RootCAs = pool
+	}
+
+	if cfg.CertFile != "" || cfg.KeyFile != "" {
+		kp, err := newKeyPair(cfg.CertFile, cfg.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		config.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			return kp.certificate()
+		}
+	}
+	return config, nil
+}
+
+func splitHosts(raw string) []string {
+	var hosts []string
+	for _, host := range strings.Split(raw, ",") {
+		if host = strings.TrimSpace(host); host != "" {
+			hosts = append(hosts, host)
+		}
+	}
+	return hosts
+}
@@ -0,0 +1,206 @@
+package certs
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// handshake connects client to server and returns the certificates both
+// sides saw.
+func handshake(t *testing.T, server, client *tls.Config) (*x509.Certificate, []*x509.Certificate, error) {
+	t.Helper()
+
+	lis, err := tls.Listen("tcp", "127.0.0.1:0", server)
+	require.NoError(t, err)
+	defer lis.Close()
+
+	peers := make(chan []*x509.Certificate, 1)
+	go func() {
+		conn, err := lis.Accept()
+		if err != nil {
+			peers <- nil
+			return
+		}
+		defer conn.Close()
+
+		tlsConn := conn.(*tls.Conn)
+		if tlsConn.Handshake() != nil {
+			peers <- nil
+			return
+		}
+		peers <- tlsConn.ConnectionState().PeerCertificates
+		_, _ = conn.Read(make([]byte, 1))
+	}()
+
+	conn, err := tls.Dial("tcp", lis.Addr().String(), client)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer conn.Close()
+
+	// TLS 1.3 reports a rejected client certificate on the first read.
+	_ = conn.SetReadDeadline(time.Now().Add(100 * time.Millisecond))
+	if _, err = conn.Read(make([]byte, 1)); err != nil {
+		if netErr, ok := err.(net.Error); !ok || !netErr.Timeout() {
+			return nil, nil, err
+		}
+	}
+	return conn.ConnectionState().PeerCertificates[0], <-peers, nil
+}
+
+func TestSelfSigned(t *testing.T) {
+	dir := t.TempDir()
+
+	ca, err := EnsureCA(dir)
+	require.NoError(t, err)
+	again, err := EnsureCA(dir)
+	require.NoError(t, err)
+	assert.Equal(t, ca.cert.Raw, again.cert.Raw)
+
+	certFile, keyFile, err := ca.Issue("file-service", []string{"localhost", "127.0.0.1"})
+	require.NoError(t, err)
+	first, err := os.ReadFile(certFile)
+	require.NoError(t, err)
+
+	t.Run("Issue reuses valid certificates", func(t *testing.T) {
+		_, _, err := ca.Issue("file-service", []string{"localhost"})
+		require.NoError(t, err)
+		data, err := os.ReadFile(certFile)
+		require.NoError(t, err)
+		assert.Equal(t, first, data)
+	})
+
+	t.Run("Issue covers new hosts", func(t *testing.T) {
+		_, _, err := ca.Issue("file-service", []string{"localhost", "files.internal"})
+		require.NoError(t, err)
+
+		pair, err := tls.LoadX509KeyPair(certFile, keyFile)
+		require.NoError(t, err)
+		cert, err := x509.ParseCertificate(pair.Certificate[0])
+		require.NoError(t, err)
+		assert.NoError(t, cert.VerifyHostname("files.internal"))
+		assert.Equal(t, "file-service", cert.Subject.CommonName)
+	})
+
+	info, err := os.Stat(filepath.Join(dir, "ca-key.pem"))
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+}
+
+func TestServerAndClient(t *testing.T) {
+	dir := t.TempDir()
+
+	server, err := Server(ServerConfig{Enabled: true, SelfSigned: true, SelfSignedDir: dir, Hosts: "127.0.0.1"}, "file-service", nil)
+	require.NoError(t, err)
+
+	t.Run("Disabled", func(t *testing.T) {
+		config, err := Server(ServerConfig{}, "file-service", nil)
+		assert.NoError(t, err)
+		assert.Nil(t, config)
+
+		config, err = Client(ClientConfig{}, "gateway")
+		assert.NoError(t, err)
+		assert.Nil(t, config)
+	})
+
+	t.Run("Mutual TLS", func(t *testing.T) {
+		client, err := Client(ClientConfig{Enabled: true, SelfSigned: true, SelfSignedDir: dir}, "gateway")
+		require.NoError(t, err)
+
+		serverCert, clientCerts, err := handshake(t, server, client)
+		require.NoError(t, err)
+		assert.Equal(t, "file-service", serverCert.Subject.CommonName)
+		require.Len(t, clientCerts, 1)
+		assert.Equal(t, "gateway", clientCerts[0].Subject.CommonName)
+	})
+
+	t.Run("Client certificates are optional by default", func(t *testing.T) {
+		client, err := Client(ClientConfig{Enabled: true, CAFile: filepath.Join(dir, "ca.pem")}, "gateway")
+		require.NoError(t, err)
+
+		_, clientCerts, err := handshake(t, server, client)
+		require.NoError(t, err)
+		assert.Empty(t, clientCerts)
+	})
+
+	t.Run("Required client certificates", func(t *testing.T) {
+		required, err := Server(ServerConfig{Enabled: true, SelfSigned: true, SelfSignedDir: dir, Hosts: "127.0.0.1", ClientAuth: ClientAuthRequire}, "file-service", nil)
+		require.NoError(t, err)
+		client, err := Client(ClientConfig{Enabled: true, CAFile: filepath.Join(dir, "ca.pem")}, "gateway")
+		require.NoError(t, err)
+
+		_, _, err = handshake(t, required, client)
+		assert.Error(t, err)
+	})
+
+	t.Run("Foreign client certificates are rejected", func(t *testing.T) {
+		foreign, err := EnsureCA(t.TempDir())
+		require.NoError(t, err)
+		certFile, keyFile, err := foreign.Issue("mallory", nil)
+		require.NoError(t, err)
+
+		client, err := Client(ClientConfig{Enabled: true, CAFile: filepath.Join(dir, "ca.pem"), CertFile: certFile, KeyFile: keyFile}, "gateway")
+		require.NoError(t, err)
+
+		_, _, err = handshake(t, server, client)
+		assert.Error(t, err)
+	})
+
+	t.Run("Untrusted server", func(t *testing.T) {
+		_, err := Client(ClientConfig{Enabled: true, CAFile: filepath.Join(dir, "missing.pem")}, "gateway")
+		assert.Error(t, err)
+
+		_, _, err = handshake(t, server, &tls.Config{MinVersion: tls.VersionTLS12})
+		assert.Error(t, err)
+	})
+
+	t.Run("Invalid client authentication", func(t *testing.T) {
+		_, err := Server(ServerConfig{Enabled: true, SelfSigned: true, SelfSignedDir: dir, ClientAuth: "always"}, "file-service", nil)
+		assert.Error(t, err)
+	})
+}
+
+func TestReload(t *testing.T) {
+	dir := t.TempDir()
+	ca, err := EnsureCA(dir)
+	require.NoError(t, err)
+	certFile, keyFile, err := ca.Issue("file-service", []string{"127.0.0.1"})
+	require.NoError(t, err)
+
+	server, err := Server(ServerConfig{Enabled: true, CertFile: certFile, KeyFile: keyFile, ClientAuth: ClientAuthNone}, "", nil)
+	require.NoError(t, err)
+	client, err := Client(ClientConfig{Enabled: true, CAFile: ca.CertFile()}, "")
+	require.NoError(t, err)
+
+	before, _, err := handshake(t, server, client)
+	require.NoError(t, err)
+
+	// A new host makes Issue replace the files.
+	newCert, newKey, err := ca.Issue("file-service", []string{"127.0.0.1", "localhost"})
+	require.NoError(t, err)
+	require.Equal(t, certFile, newCert)
+	require.Equal(t, keyFile, newKey)
+	future := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(certFile, future, future))
+
+	after, _, err := handshake(t, server, client)
+	require.NoError(t, err)
+	assert.NotEqual(t, before.SerialNumber, after.SerialNumber)
+	assert.NoError(t, after.VerifyHostname("localhost"))
+
+	t.Run("Broken files keep the previous certificate", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(keyFile, []byte("garbage"), 0600))
+
+		kept, _, err := handshake(t, server, client)
+		require.NoError(t, err)
+		assert.Equal(t, after.SerialNumber, kept.SerialNumber)
+	})
+}
@@ -1,8 +1,11 @@
 package config
 
 import (
+	"github.com/JunBSer/FileManager/internal/auth"
 	"github.com/JunBSer/FileManager/internal/gateway"
+	"github.com/JunBSer/FileManager/internal/metrics"
 	"github.com/JunBSer/FileManager/internal/repository"
+	"github.com/JunBSer/FileManager/internal/tracing"
 	"github.com/JunBSer/FileManager/internal/transport/grpc"
 	"github.com/ilyakaznacheev/cleanenv"
 )
@@ -15,6 +18,9 @@ type (
 		Http    gateway.Config
 		Storage repository.FileStorageConfig
 		Gw      gateway.GwConfig
+		Auth    auth.Config
+		Metrics metrics.Config
+		Tracing tracing.Config
 	}
 
 	App struct {
@@ -0,0 +1,207 @@
+package gateway
+
+import (
+	"github.com/JunBSer/FileManager/internal/models"
+	"github.com/JunBSer/FileManager/pkg/api/fmpb"
+	"github.com/JunBSer/FileManager/pkg/logger"
+	"go.uber.org/zap"
+	"net/http"
+	"strings"
+)
+
+func toModelGrant(grant *fmpb.Grant) models.Grant {
+	return models.Grant{
+		Path:        grant.Path,
+		Principal:   grant.Principal,
+		Role:        grant.Role,
+		Permissions: append([]string{}, grant.Permissions...),
+	}
+}
+
+// listParam reads an optional comma separated query parameter.
+func listParam(r *http.Request, name string) []string {
+	var values []string
+	for _, value := range strings.Split(r.URL.Query().Get(name), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// ListGrants lists the grants of the access control list
+// @Summary List grants
+// @Description Returns the grants on a path and everything below it, sorted by path and principal. Without a path all grants are listed. Admins only
+// @Tags access control
+// @Produce application/json
+// @Param path query string false "Directory or file path"
+// @Success 200 {array} models.Grant "Grants"
+// @Failure 403 {object} models.ErrorResponse "Caller is not an admin"
+// @Failure 412 {object} models.ErrorResponse "Access control is disabled"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /files/acl [get]
+func (h Handler) ListGrants(w http.ResponseWriter, r *http.Request) {
+	lg := logger.GetLoggerFromContext(r.Context())
+	path := r.URL.Query().Get("path")
+
+	res, err := h.gw.client.ACL.ListGrants(r.Context(), &fmpb.ListGrantsRequest{Path: path})
+	if err != nil {
+		WriteError(w, err)
+		lg.Error(r.Context(), "Error listing grants", zap.String("path", path), zap.Error(err))
+		return
+	}
+
+	grants := make([]models.Grant, 0, len(res.Grants))
+	for _, grant := range res.Grants {
+		grants = append(grants, toModelGrant(grant))
+	}
+
+	h.EncodeJSON(w, http.StatusOK, grants, r.Context())
+}
+
+// SetGrant grants permissions on a path
+// @Summary Set a grant
+// @Description Grants a role and/or permissions on a path and everything below it, replacing the previous grant of the principal on the path. Admins only
+// @Tags access control
+// @Produce application/json
+// @Param path query string true "Directory or file path"
+// @Param principal query string true "*, user:<subject> or group:<name>" example("group:developers")
+// @Param role query string false "Role to grant" example("editor")
+// @Param permissions query string false "Comma separated permissions: read, write, delete, list" example("read,list")
+// @Success 200 {object} models.Grant "Stored grant"
+// @Failure 400 {object} models.ErrorResponse "Invalid request"
+// @Failure 403 {object} models.ErrorResponse "Caller is not an admin"
+// @Failure 412 {object} models.ErrorResponse "Access control is disabled"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /files/acl [put]
+func (h Handler) SetGrant(w http.ResponseWriter, r *http.Request) {
+	lg := logger.GetLoggerFromContext(r.Context())
+
+	path, err := h.HandleFilePath("path", w, r)
+	if err != nil {
+		lg.Debug(r.Context(), "Error handling file path", zap.String("path", path))
+		return
+	}
+	principal, err := h.HandleFilePath("principal", w, r)
+	if err != nil {
+		return
+	}
+
+	res, err := h.gw.client.ACL.SetGrant(r.Context(), &fmpb.Grant{
+		Path:        path,
+		Principal:   principal,
+		Role:        r.URL.Query().Get("role"),
+		Permissions: listParam(r, "permissions"),
+	})
+	if err != nil {
+		WriteError(w, err)
+		lg.Error(r.Context(), "Error setting grant", zap.String("path", path), zap.Error(err))
+		return
+	}
+
+	h.EncodeJSON(w, http.StatusOK, toModelGrant(res), r.Context())
+}
+
+// RemoveGrant removes a grant
+// @Summary Remove a grant
+// @Description Removes the grant of a principal on a path. Admins only
+// @Tags access control
+// @Param path query string true "Directory or file path"
+// @Param principal query string true "*, user:<subject> or group:<name>"
+// @Success 204 "Grant removed"
+// @Failure 403 {object} models.ErrorResponse "Caller is not an admin"
+// @Failure 404 {object} models.ErrorResponse "Grant not found"
+// @Failure 412 {object} models.ErrorResponse "Access control is disabled"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /files/acl [delete]
+func (h Handler) RemoveGrant(w http.ResponseWriter, r *http.Request) {
+	lg := logger.GetLoggerFromContext(r.Context())
+
+	path, err := h.HandleFilePath("path", w, r)
+	if err != nil {
+		lg.Debug(r.Context(), "Error handling file path", zap.String("path", path))
+		return
+	}
+	principal, err := h.HandleFilePath("principal", w, r)
+	if err != nil {
+		return
+	}
+
+	_, err = h.gw.client.ACL.RemoveGrant(r.Context(), &fmpb.RemoveGrantRequest{Path: path, Principal: principal})
+	if err != nil {
+		WriteError(w, err)
+		lg.Error(r.Context(), "Error removing grant", zap.String("path", path), zap.Error(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetAccess returns effective permissions
+// @Summary Get effective permissions
+// @Description Returns the permissions the caller has on a path. Admins may ask for another subject and groups
+// @Tags access control
+// @Produce application/json
+// @Param path query string true "Directory or file path"
+// @Param subject query string false "Subject to check instead of the caller"
+// @Param groups query string false "Comma separated groups of the subject"
+// @Success 200 {object} models.Access "Effective permissions"
+// @Failure 400 {object} models.ErrorResponse "Invalid request"
+// @Failure 403 {object} models.ErrorResponse "Caller is not an admin"
+// @Failure 412 {object} models.ErrorResponse "Access control is disabled"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /files/acl/access [get]
+func (h Handler) GetAccess(w http.ResponseWriter, r *http.Request) {
+	lg := logger.GetLoggerFromContext(r.Context())
+
+	path, err := h.HandleFilePath("path", w, r)
+	if err != nil {
+		lg.Debug(r.Context(), "Error handling file path", zap.String("path", path))
+		return
+	}
+
+	res, err := h.gw.client.ACL.GetAccess(r.Context(), &fmpb.GetAccessRequest{
+		Path:    path,
+		Subject: r.URL.Query().Get("subject"),
+		Groups:  listParam(r, "groups"),
+	})
+	if err != nil {
+		WriteError(w, err)
+		lg.Error(r.Context(), "Error getting access", zap.String("path", path), zap.Error(err))
+		return
+	}
+
+	h.EncodeJSON(w, http.StatusOK, models.Access{
+		Path:        res.Path,
+		Subject:     res.Subject,
+		Permissions: append([]string{}, res.Permissions...),
+		Admin:       res.Admin,
+	}, r.Context())
+}
+
+// ListRoles lists the roles
+// @Summary List roles
+// @Description Returns the roles grants may refer to with their permissions
+// @Tags access control
+// @Produce application/json
+// @Success 200 {array} models.Role "Roles"
+// @Failure 412 {object} models.ErrorResponse "Access control is disabled"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /files/acl/roles [get]
+func (h Handler) ListRoles(w http.ResponseWriter, r *http.Request) {
+	lg := logger.GetLoggerFromContext(r.Context())
+
+	res, err := h.gw.client.ACL.ListRoles(r.Context(), &fmpb.ListRolesRequest{})
+	if err != nil {
+		WriteError(w, err)
+		lg.Error(r.Context(), "Error listing roles", zap.Error(err))
+		return
+	}
+
+	roles := make([]models.Role, 0, len(res.Roles))
+	for _, role := range res.Roles {
+		roles = append(roles, models.Role{Name: role.Name, Permissions: append([]string{}, role.Permissions...)})
+	}
+
+	h.EncodeJSON(w, http.StatusOK, roles, r.Context())
+}
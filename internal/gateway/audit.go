@@ -0,0 +1,121 @@
+package gateway
+
+import (
+	"github.com/JunBSer/FileManager/internal/models"
+	"github.com/JunBSer/FileManager/pkg/api/fmpb"
+	"github.com/JunBSer/FileManager/pkg/logger"
+	"go.uber.org/zap"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// timeParam reads the query parameter name as unix time or RFC 3339 time. It
+// is 0 if the parameter is not set.
+func timeParam(w http.ResponseWriter, r *http.Request, name string) (int64, bool) {
+	raw := r.URL.Query().Get(name)
+	if raw == "" {
+		return 0, true
+	}
+	if unix, err := strconv.ParseInt(raw, 10, 64); err == nil && unix >= 0 {
+		return unix, true
+	}
+	t, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		http.Error(w, name+" must be a unix time or an RFC 3339 time", http.StatusBadRequest)
+		return 0, false
+	}
+	return t.Unix(), true
+}
+
+func toModelAuditRecord(rec *fmpb.AuditRecord) models.AuditRecord {
+	return models.AuditRecord{
+		Seq:         rec.Seq,
+		Time:        rec.Time,
+		Caller:      rec.Caller,
+		AuthMethod:  rec.AuthMethod,
+		Remote:      rec.Remote,
+		Operation:   rec.Operation,
+		Path:        rec.Path,
+		Destination: rec.Destination,
+		Bytes:       rec.Bytes,
+		Status:      rec.Status,
+		Error:       rec.Error,
+		DurationMs:  rec.DurationMs,
+		PrevHash:    rec.PrevHash,
+		Hash:        rec.Hash,
+	}
+}
+
+// QueryAudit reads the audit log
+// @Summary Query the audit log
+// @Description Returns the latest calls matching the filters, the oldest first. Every record carries the hash of the record before it. Needs an admin while access control is enabled
+// @Tags audit
+// @Produce application/json
+// @Param path_prefix query string false "Only calls on this path or below it, as source or destination"
+// @Param user query string false "Only calls made by this subject"
+// @Param since query string false "Only calls made at or after this unix or RFC 3339 time"
+// @Param until query string false "Only calls made at or before this unix or RFC 3339 time"
+// @Param limit query int false "Number of latest records, 1000 if not set"
+// @Success 200 {array} models.AuditRecord "Records"
+// @Failure 400 {object} models.ErrorResponse "Invalid request"
+// @Failure 403 {object} models.ErrorResponse "Caller is not an admin"
+// @Failure 412 {object} models.ErrorResponse "Audit log is disabled"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /audit [get]
+func (h Handler) QueryAudit(w http.ResponseWriter, r *http.Request) {
+	lg := logger.GetLoggerFromContext(r.Context())
+
+	req := &fmpb.QueryAuditRequest{PathPrefix: r.URL.Query().Get("path_prefix"), User: r.URL.Query().Get("user")}
+	var ok bool
+	if req.Since, ok = timeParam(w, r, "since"); !ok {
+		return
+	}
+	if req.Until, ok = timeParam(w, r, "until"); !ok {
+		return
+	}
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		limit, err := strconv.ParseInt(raw, 10, 32)
+		if err != nil || limit < 0 {
+			http.Error(w, "limit must be a non-negative integer", http.StatusBadRequest)
+			return
+		}
+		req.Limit = int32(limit)
+	}
+
+	res, err := h.gw.client.Audit.QueryAudit(r.Context(), req)
+	if err != nil {
+		WriteError(w, err)
+		lg.Error(r.Context(), "Error querying audit log", zap.String("path_prefix", req.PathPrefix), zap.Error(err))
+		return
+	}
+
+	records := make([]models.AuditRecord, 0, len(res.Records))
+	for _, rec := range res.Records {
+		records = append(records, toModelAuditRecord(rec))
+	}
+	h.EncodeJSON(w, http.StatusOK, records, r.Context())
+}
+
+// VerifyAudit checks the audit log
+// @Summary Verify the audit log
+// @Description Checks the hash chain of the whole audit log and reports the first record that was changed, removed or inserted. Needs an admin while access control is enabled
+// @Tags audit
+// @Produce application/json
+// @Success 200 {object} models.AuditVerification "Result of the check"
+// @Failure 403 {object} models.ErrorResponse "Caller is not an admin"
+// @Failure 412 {object} models.ErrorResponse "Audit log is disabled"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /audit/verify [get]
+func (h Handler) VerifyAudit(w http.ResponseWriter, r *http.Request) {
+	lg := logger.GetLoggerFromContext(r.Context())
+
+	res, err := h.gw.client.Audit.VerifyAudit(r.Context(), &fmpb.VerifyAuditRequest{})
+	if err != nil {
+		WriteError(w, err)
+		lg.Error(r.Context(), "Error verifying audit log", zap.Error(err))
+		return
+	}
+
+	h.EncodeJSON(w, http.StatusOK, models.AuditVerification{Valid: res.Valid, Records: res.Records, Error: res.Error}, r.Context())
+}
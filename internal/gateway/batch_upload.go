@@ -0,0 +1,273 @@
+package gateway
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	myErr "github.com/JunBSer/FileManager/internal/gateway/error"
+	"github.com/JunBSer/FileManager/internal/models"
+	"github.com/JunBSer/FileManager/pkg/api/fmpb"
+	"github.com/JunBSer/FileManager/pkg/logger"
+	"go.uber.org/zap"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+)
+
+// errUnsupportedBatch is returned by batchBody for bodies that are neither
+// multipart/form-data nor an archive.
+var errUnsupportedBatch = errors.New("upload must be multipart/form-data, a tar, tar.gz or zip archive")
+
+// batchEntry is a file or a directory of a multi-file upload. The content of
+// a file must be read before the next entry is asked for.
+type batchEntry struct {
+	name    string
+	isDir   bool
+	content io.Reader
+}
+
+// batchEntries returns the next entry of a multi-file upload, or io.EOF after
+// the last one.
+type batchEntries func() (batchEntry, error)
+
+// multipartEntries returns the file parts of a multipart body, named by
+// their file names. Browsers send the path of a file relative to the
+// uploaded folder as its file name. Parts that are not files are skipped.
+func multipartEntries(r *http.Request) (batchEntries, error) {
+	reader, err := r.MultipartReader()
+	if err != nil {
+		return nil, err
+	}
+
+	return func() (batchEntry, error) {
+		for {
+			part, err := reader.NextPart()
+			if err != nil {
+				return batchEntry{}, err
+			}
+			// FileName drops the directories of the name, so it is read
+			// from the header.
+			_, params, err := mime.ParseMediaType(part.Header.Get("Content-Disposition"))
+			if err == nil && params["filename"] != "" {
+				return batchEntry{name: params["filename"], content: part}, nil
+			}
+		}
+	}, nil
+}
+
+// tarEntries returns the files and directories of a tar archive. Links and
+// other special entries are skipped.
+func tarEntries(body io.Reader) batchEntries {
+	reader := tar.NewReader(body)
+
+	return func() (batchEntry, error) {
+		for {
+			header, err := reader.Next()
+			if err != nil {
+				return batchEntry{}, err
+			}
+			switch header.Typeflag {
+			case tar.TypeReg:
+				return batchEntry{name: header.Name, content: reader}, nil
+			case tar.TypeDir:
+				return batchEntry{name: header.Name, isDir: true}, nil
+			}
+		}
+	}
+}
+
+// zipEntries returns the files and directories of a zip archive. The
+// directory of a zip archive is at its end, so body is spooled to a
+// temporary file first. The returned function removes it.
+func zipEntries(body io.Reader) (batchEntries, func(), error) {
+	spool, err := os.CreateTemp("", "fm-upload-*.zip")
+	if err != nil {
+		return nil, nil, err
+	}
+	cleanup := func() {
+		_ = spool.Close()
+		_ = os.Remove(spool.Name())
+	}
+
+	size, err := io.Copy(spool, body)
+	if err != nil {
+		cleanup()
+		return nil, nil, myErr.ReadError{Err: err, Src: "request"}
+	}
+	archive, err := zip.NewReader(spool, size)
+	if err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+
+	files := archive.File
+	var current io.Closer
+	return func() (batchEntry, error) {
+		if current != nil {
+			_ = current.Close()
+			current = nil
+		}
+		if len(files) == 0 {
+			return batchEntry{}, io.EOF
+		}
+		file := files[0]
+		files = files[1:]
+
+		if file.FileInfo().IsDir() {
+			return batchEntry{name: file.Name, isDir: true}, nil
+		}
+		content, err := file.Open()
+		if err != nil {
+			return batchEntry{}, err
+		}
+		current = content
+		return batchEntry{name: file.Name, content: content}, nil
+	}, cleanup, nil
+}
+
+// batchBody returns the entries of the body of a multi-file upload and a
+// function releasing what they hold. Bodies that are spooled must not be
+// larger than maxSpool bytes.
+func batchBody(w http.ResponseWriter, r *http.Request, maxSpool int64) (batchEntries, func(), error) {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		return nil, nil, errUnsupportedBatch
+	}
+
+	noop := func() {}
+	switch mediaType {
+	case "multipart/form-data":
+		entries, err := multipartEntries(r)
+		return entries, noop, err
+	case "application/x-tar", "application/tar":
+		return tarEntries(r.Body), noop, nil
+	case "application/gzip", "application/x-gzip", "application/x-gtar":
+		unzipped, err := gzip.NewReader(r.Body)
+		if err != nil {
+			return nil, nil, err
+		}
+		return tarEntries(unzipped), noop, nil
+	case "application/zip", "application/x-zip-compressed":
+		return zipEntries(http.MaxBytesReader(w, r.Body, maxSpool))
+	}
+	return nil, nil, errUnsupportedBatch
+}
+
+// batchPath returns the path an entry named name is stored at below dir.
+// Names must stay inside dir.
+func batchPath(dir, name string) (string, error) {
+	rel := path.Clean(strings.ReplaceAll(name, `\`, "/"))
+	if rel == "." || rel == ".." || path.IsAbs(rel) || strings.HasPrefix(rel, "../") {
+		return "", fmt.Errorf("invalid path %q", name)
+	}
+	return path.Join(dir, rel), nil
+}
+
+// uploadEntry stores entry below dir and returns its outcome.
+func (h Handler) uploadEntry(ctx context.Context, dir string, entry batchEntry) (models.UploadResult, error) {
+	result := models.UploadResult{Path: entry.name, IsDir: entry.isDir}
+
+	target, err := batchPath(dir, entry.name)
+	if err != nil {
+		result.Code = http.StatusBadRequest
+		result.Error = err.Error()
+		return result, err
+	}
+	result.Path = target
+
+	if entry.isDir {
+		_, err = h.gw.client.Dirs.CreateDirectory(ctx, &fmpb.CreateDirectoryRequest{Path: target})
+	} else {
+		content := &countingBody{ReadCloser: io.NopCloser(entry.content)}
+		_, err = h.sendFile(ctx, h.gw.client.Cl.Upload, target, content)
+		result.Bytes = content.bytes
+	}
+
+	result.Code = HTTPStatus(err)
+	if err != nil {
+		result.Error = err.Error()
+		if errors.As(err, &myErr.ReadError{}) {
+			result.Code = http.StatusBadRequest
+		}
+	}
+	return result, err
+}
+
+// UploadBatch uploads several files at once
+// @Summary Upload several files
+// @Description Stores every file of a multipart/form-data body, at the path given as its file name, or every file and directory of a tar, tar.gz or zip body, below a directory. Files are streamed to storage one after the other as they arrive; zip archives are spooled to a temporary file first, up to the size set by HTTP_MAX_SPOOL_SIZE. Every entry is reported, a failed one does not stop the others
+// @Tags uploading
+// @Accept multipart/form-data
+// @Accept application/x-tar
+// @Accept application/gzip
+// @Accept application/zip
+// @Produce application/json
+// @Param path query string false "Directory to store the files in, the root if empty"
+// @Success 200 {object} models.UploadReport "All entries stored"
+// @Success 207 {object} models.UploadReport "Some entries failed"
+// @Failure 400 {object} models.ErrorResponse "Invalid request"
+// @Failure 413 {object} models.ErrorResponse "Zip archive is larger than the spool limit"
+// @Failure 415 {object} models.ErrorResponse "Body is neither multipart/form-data nor an archive"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /files/upload/batch [post]
+func (h Handler) UploadBatch(w http.ResponseWriter, r *http.Request) {
+	lg := logger.GetLoggerFromContext(r.Context())
+	defer r.Body.Close()
+
+	dir := r.URL.Query().Get("path")
+
+	next, cleanup, err := batchBody(w, r, h.gw.maxSpool)
+	if err != nil {
+		code := http.StatusBadRequest
+		var tooLarge *http.MaxBytesError
+		switch {
+		case errors.Is(err, errUnsupportedBatch):
+			code = http.StatusUnsupportedMediaType
+		case errors.As(err, &tooLarge):
+			code = http.StatusRequestEntityTooLarge
+		}
+		http.Error(w, "Invalid file upload: "+err.Error(), code)
+		lg.Error(r.Context(), "Error reading upload", zap.Error(err))
+		return
+	}
+	defer cleanup()
+
+	report := models.UploadReport{Files: []models.UploadResult{}}
+	for {
+		entry, err := next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			// The rest of the body cannot be read.
+			lg.Error(r.Context(), "Error reading upload", zap.Error(err))
+			report.Failed++
+			report.Files = append(report.Files, models.UploadResult{Code: http.StatusBadRequest, Error: err.Error()})
+			break
+		}
+
+		result, err := h.uploadEntry(r.Context(), dir, entry)
+		report.Files = append(report.Files, result)
+		if err == nil {
+			report.Uploaded++
+			continue
+		}
+		report.Failed++
+		lg.Error(r.Context(), "Error uploading entry", zap.String("path", result.Path), zap.Error(err))
+		if errors.As(err, &myErr.ReadError{}) {
+			break
+		}
+	}
+
+	code := http.StatusOK
+	if report.Failed > 0 {
+		code = http.StatusMultiStatus
+	}
+	h.EncodeJSON(w, code, report, r.Context())
+}
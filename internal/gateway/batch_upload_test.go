@@ -0,0 +1,219 @@
+package gateway
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"testing"
+
+	"github.com/JunBSer/FileManager/internal/models"
+	"github.com/JunBSer/FileManager/internal/transport/grpc"
+	"github.com/JunBSer/FileManager/pkg/api/fmpb"
+	"github.com/JunBSer/FileManager/pkg/logger"
+	"github.com/JunBSer/proto_fileManager/pkg/api/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	gogrpc "google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// fakeBackend stores uploaded files and created directories. Uploads of
+// full.txt fail as if a quota was exceeded.
+type fakeBackend struct {
+	proto.FileServiceClient
+	fmpb.DirectoryServiceClient
+	files map[string]string
+	dirs  []string
+}
+
+type fakeBackendStream struct {
+	fakeUploadStream
+	backend *fakeBackend
+	name    string
+}
+
+func (s *fakeBackendStream) Send(chunk *proto.FileChunk) error {
+	s.name = chunk.FileName
+	return s.fakeUploadStream.Send(chunk)
+}
+
+func (s *fakeBackendStream) CloseAndRecv() (*proto.StatusResponse, error) {
+	if s.name == "" {
+		return nil, status.Error(codes.InvalidArgument, "no file name")
+	}
+	if s.name == "upload/full.txt" {
+		return nil, status.Error(codes.ResourceExhausted, "quota exceeded")
+	}
+	s.backend.files[s.name] = string(bytes.Join(s.chunks, nil))
+	return s.fakeUploadStream.CloseAndRecv()
+}
+
+func (b *fakeBackend) Upload(ctx context.Context, _ ...gogrpc.CallOption) (proto.FileService_UploadClient, error) {
+	return &fakeBackendStream{backend: b}, nil
+}
+
+func (b *fakeBackend) CreateDirectory(_ context.Context, req *fmpb.CreateDirectoryRequest, _ ...gogrpc.CallOption) (*fmpb.PathResult, error) {
+	b.dirs = append(b.dirs, req.Path)
+	return &fmpb.PathResult{Path: req.Path, IsDir: true}, nil
+}
+
+func tarBody(t *testing.T, compress bool) (io.Reader, string) {
+	var body bytes.Buffer
+	var dst io.Writer = &body
+	var zw *gzip.Writer
+	if compress {
+		zw = gzip.NewWriter(&body)
+		dst = zw
+	}
+	tw := tar.NewWriter(dst)
+	require.NoError(t, tw.WriteHeader(&tar.Header{Name: "site/", Typeflag: tar.TypeDir, Mode: 0o755}))
+	require.NoError(t, tw.WriteHeader(&tar.Header{Name: "site/index.html", Typeflag: tar.TypeReg, Mode: 0o644, Size: 5}))
+	_, err := tw.Write([]byte("hello"))
+	require.NoError(t, err)
+	require.NoError(t, tw.WriteHeader(&tar.Header{Name: "site/.keep", Typeflag: tar.TypeReg, Mode: 0o644}))
+	require.NoError(t, tw.WriteHeader(&tar.Header{Name: "site/link", Typeflag: tar.TypeSymlink, Linkname: "index.html"}))
+	require.NoError(t, tw.Close())
+
+	if compress {
+		require.NoError(t, zw.Close())
+		return &body, "application/gzip"
+	}
+	return &body, "application/x-tar"
+}
+
+func TestHandler_UploadBatch(t *testing.T) {
+	multipartBody := func() (io.Reader, string) {
+		var body bytes.Buffer
+		mw := multipart.NewWriter(&body)
+		require.NoError(t, mw.WriteField("comment", "not a file"))
+		for name, content := range map[string]string{"photos/a.jpg": "aaa", "photos/2024/b.jpg": "bbbb", "full.txt": "x", "../evil": "x"} {
+			header := textproto.MIMEHeader{}
+			header.Set("Content-Disposition", `form-data; name="files"; filename="`+name+`"`)
+			part, err := mw.CreatePart(header)
+			require.NoError(t, err)
+			_, err = part.Write([]byte(content))
+			require.NoError(t, err)
+		}
+		require.NoError(t, mw.Close())
+		return &body, mw.FormDataContentType()
+	}
+	zipBody := func() (io.Reader, string) {
+		var body bytes.Buffer
+		zw := zip.NewWriter(&body)
+		_, err := zw.Create("docs/")
+		require.NoError(t, err)
+		f, err := zw.Create("docs/readme.md")
+		require.NoError(t, err)
+		_, err = f.Write([]byte("# readme"))
+		require.NoError(t, err)
+		require.NoError(t, zw.Close())
+		return &body, "application/zip"
+	}
+
+	tests := []struct {
+		testName  string
+		body      func() (io.Reader, string)
+		wantCode  int
+		wantFiles map[string]string
+		wantDirs  []string
+		wantFail  int
+	}{
+		{"multipart", multipartBody, http.StatusMultiStatus,
+			map[string]string{"upload/photos/a.jpg": "aaa", "upload/photos/2024/b.jpg": "bbbb"}, nil, 2},
+		{"tar", func() (io.Reader, string) { return tarBody(t, false) }, http.StatusOK,
+			map[string]string{"upload/site/index.html": "hello", "upload/site/.keep": ""}, []string{"upload/site"}, 0},
+		{"tar.gz", func() (io.Reader, string) { return tarBody(t, true) }, http.StatusOK,
+			map[string]string{"upload/site/index.html": "hello", "upload/site/.keep": ""}, []string{"upload/site"}, 0},
+		{"zip", zipBody, http.StatusOK,
+			map[string]string{"upload/docs/readme.md": "# readme"}, []string{"upload/docs"}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.testName, func(t *testing.T) {
+			backend := &fakeBackend{files: map[string]string{}}
+			h := Handler{gw: &Gateway{maxSize: 1, maxSpool: 1 << 20, client: &grpc.Client{Cl: backend, Dirs: backend}}}
+
+			body, contentType := tt.body()
+			req := httptest.NewRequest("POST", "/api/v1/files/upload/batch?path=upload", body)
+			req.Header.Set("Content-Type", contentType)
+			req = req.WithContext(context.WithValue(context.Background(), logger.Key, logger.New("gw test", "debug")))
+			rec := httptest.NewRecorder()
+			h.UploadBatch(rec, req)
+
+			assert.Equal(t, tt.wantCode, rec.Code, rec.Body.String())
+			assert.Equal(t, tt.wantFiles, backend.files)
+			assert.Equal(t, tt.wantDirs, backend.dirs)
+
+			var report models.UploadReport
+			require.NoError(t, json.NewDecoder(rec.Body).Decode(&report))
+			assert.Equal(t, len(tt.wantFiles)+len(tt.wantDirs), report.Uploaded)
+			assert.Equal(t, tt.wantFail, report.Failed)
+			for _, file := range report.Files {
+				if file.Error == "" {
+					assert.Equal(t, http.StatusOK, file.Code)
+					assert.Equal(t, int64(len(tt.wantFiles[file.Path])), file.Bytes)
+				}
+				if file.Path == "upload/full.txt" {
+					assert.Equal(t, http.StatusInsufficientStorage, file.Code)
+				}
+			}
+		})
+	}
+
+	t.Run("unsupported type", func(t *testing.T) {
+		h := Handler{gw: &Gateway{maxSize: 1}}
+		req := httptest.NewRequest("POST", "/api/v1/files/upload/batch", bytes.NewReader([]byte("x")))
+		req.Header.Set("Content-Type", "text/plain")
+		req = req.WithContext(context.WithValue(context.Background(), logger.Key, logger.New("gw test", "debug")))
+		rec := httptest.NewRecorder()
+		h.UploadBatch(rec, req)
+		assert.Equal(t, http.StatusUnsupportedMediaType, rec.Code)
+	})
+
+	t.Run("zip over the spool limit", func(t *testing.T) {
+		backend := &fakeBackend{files: map[string]string{}}
+		h := Handler{gw: &Gateway{maxSize: 1, maxSpool: 16, client: &grpc.Client{Cl: backend, Dirs: backend}}}
+		body, contentType := zipBody()
+		req := httptest.NewRequest("POST", "/api/v1/files/upload/batch", body)
+		req.Header.Set("Content-Type", contentType)
+		req = req.WithContext(context.WithValue(context.Background(), logger.Key, logger.New("gw test", "debug")))
+		rec := httptest.NewRecorder()
+		h.UploadBatch(rec, req)
+		assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+		assert.Empty(t, backend.files)
+	})
+}
+
+func TestBatchPath(t *testing.T) {
+	tests := []struct {
+		dir, name, want string
+		wantErr         bool
+	}{
+		{"", "a/b.txt", "a/b.txt", false},
+		{"up", "./a//b.txt", "up/a/b.txt", false},
+		{"up", `dir\file.txt`, "up/dir/file.txt", false},
+		{"up", "a/../b.txt", "up/b.txt", false},
+		{"up", "../b.txt", "", true},
+		{"up", "/etc/passwd", "", true},
+		{"up", "..", "", true},
+		{"up", "", "", true},
+	}
+	for _, tt := range tests {
+		got, err := batchPath(tt.dir, tt.name)
+		if tt.wantErr {
+			assert.Error(t, err, tt.name)
+			continue
+		}
+		assert.NoError(t, err, tt.name)
+		assert.Equal(t, tt.want, got)
+	}
+}
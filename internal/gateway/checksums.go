@@ -0,0 +1,86 @@
+package gateway
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"github.com/JunBSer/FileManager/pkg/api/fmpb"
+	"google.golang.org/grpc/metadata"
+	"net/http"
+	"strings"
+)
+
+// checksumContext passes the checksums of the Digest request header (RFC 3230)
+// to the backend as outgoing metadata of the request context. The sha-256 and
+// crc32c algorithms are understood, both with base64 values; others are
+// ignored.
+func checksumContext(r *http.Request) (context.Context, error) {
+	ctx := r.Context()
+
+	for _, header := range r.Header.Values("Digest") {
+		for _, part := range strings.Split(header, ",") {
+			alg, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+			if !ok {
+				return nil, fmt.Errorf("malformed Digest %q", part)
+			}
+
+			switch strings.ToLower(alg) {
+			case "sha-256":
+				sum, err := base64.StdEncoding.DecodeString(value)
+				if err != nil || len(sum) != 32 {
+					return nil, fmt.Errorf("malformed sha-256 digest %q", value)
+				}
+				ctx = metadata.AppendToOutgoingContext(ctx, fmpb.SHA256MetadataKey, hex.EncodeToString(sum))
+			case "crc32c":
+				sum, err := base64.StdEncoding.DecodeString(value)
+				if err != nil || len(sum) != 4 {
+					return nil, fmt.Errorf("malformed crc32c digest %q", value)
+				}
+				ctx = metadata.AppendToOutgoingContext(ctx, fmpb.CRC32CMetadataKey, fmt.Sprintf("%08x", binary.BigEndian.Uint32(sum)))
+			}
+		}
+	}
+
+	return ctx, nil
+}
+
+// digestHeaders returns the Digest and ETag values of a hex SHA-256 sent by
+// the backend in md, or false if md does not hold one.
+func digestHeaders(md metadata.MD) (digest, etag string, ok bool) {
+	values := md.Get(fmpb.SHA256MetadataKey)
+	if len(values) == 0 {
+		return "", "", false
+	}
+
+	sum, err := hex.DecodeString(values[0])
+	if err != nil {
+		return "", "", false
+	}
+	return "sha-256=" + base64.StdEncoding.EncodeToString(sum), `"` + values[0] + `"`, true
+}
+
+// setDigest sets the Digest and ETag headers of a download from the response
+// header of the backend. If the backend does not know the digest before
+// sending the file, Digest is announced as a trailer and filled by
+// setDigestTrailer.
+func setDigest(w http.ResponseWriter, header metadata.MD) {
+	digest, etag, ok := digestHeaders(header)
+	if !ok {
+		w.Header().Set("Trailer", "Digest")
+		return
+	}
+
+	w.Header().Set("Digest", digest)
+	w.Header().Set("ETag", etag)
+}
+
+func setDigestTrailer(w http.ResponseWriter, trailer metadata.MD) {
+	if w.Header().Get("Trailer") == "" {
+		return
+	}
+	if digest, _, ok := digestHeaders(trailer); ok {
+		w.Header().Set("Digest", digest)
+	}
+}
@@ -0,0 +1,55 @@
+package gateway
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/JunBSer/FileManager/pkg/api/fmpb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestChecksumContext(t *testing.T) {
+	tests := []struct {
+		testName string
+		header   string
+		sha256   string
+		crc32c   string
+		isErr    bool
+	}{
+		{"No header", "", "", "", false},
+		{"SHA-256", "sha-256=LPJNul+wow4m6DsqxbninhsWHlwfp0JecwQzYpOLmCQ=", "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824", "", false},
+		{"Both", "SHA-256=LPJNul+wow4m6DsqxbninhsWHlwfp0JecwQzYpOLmCQ=, crc32c=mnG7TA==", "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824", "9a71bb4c", false},
+		{"Unknown algorithm is ignored", "md5=XUFAKrxLKna5cZ2REBfFkg==", "", "", false},
+		{"Short SHA-256", "sha-256=YWJj", "", "", true},
+		{"No value", "sha-256", "", "", true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			r := httptest.NewRequest("POST", "/api/v1/files/upload", nil)
+			if test.header != "" {
+				r.Header.Set("Digest", test.header)
+			}
+
+			ctx, err := checksumContext(r)
+			if test.isErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			md, _ := metadata.FromOutgoingContext(ctx)
+			assert.Equal(t, test.sha256, first(md.Get(fmpb.SHA256MetadataKey)))
+			assert.Equal(t, test.crc32c, first(md.Get(fmpb.CRC32CMetadataKey)))
+		})
+	}
+}
+
+func first(values []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
@@ -0,0 +1,225 @@
+package gateway
+
+import (
+	"github.com/JunBSer/FileManager/internal/models"
+	"github.com/JunBSer/FileManager/pkg/api/fmpb"
+	"github.com/JunBSer/FileManager/pkg/logger"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"io"
+	"net/http"
+	"strconv"
+)
+
+func toModelPathResult(result *fmpb.PathResult) models.PathResult {
+	return models.PathResult{
+		Path:   result.Path,
+		Target: result.Target,
+		IsDir:  result.IsDir,
+		Error:  result.Error,
+	}
+}
+
+// boolParam reads an optional boolean query parameter.
+func boolParam(w http.ResponseWriter, r *http.Request, name string) (bool, bool) {
+	raw := r.URL.Query().Get(name)
+	if raw == "" {
+		return false, true
+	}
+
+	value, err := strconv.ParseBool(raw)
+	if err != nil {
+		http.Error(w, name+" must be a boolean", http.StatusBadRequest)
+		return false, false
+	}
+	return value, true
+}
+
+// writeTreeReport collects the per-entry results of a directory operation.
+// The response is 200 if every entry succeeded and 207 if some failed.
+func (h Handler) writeTreeReport(w http.ResponseWriter, r *http.Request, stream grpc.ServerStreamingClient[fmpb.PathResult]) {
+	lg := logger.GetLoggerFromContext(r.Context())
+
+	report := models.TreeReport{Entries: []models.PathResult{}}
+	for {
+		result, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			WriteError(w, err)
+			lg.Error(r.Context(), "Error receiving directory operation results", zap.Error(err))
+			return
+		}
+
+		if result.Code != 0 {
+			report.Failed++
+		} else {
+			report.Done++
+		}
+		report.Entries = append(report.Entries, toModelPathResult(result))
+	}
+
+	code := http.StatusOK
+	if report.Failed > 0 {
+		code = http.StatusMultiStatus
+	}
+	h.EncodeJSON(w, code, report, r.Context())
+}
+
+// CreateDirectory creates a directory
+// @Summary Create a directory
+// @Description Creates a directory and its missing parents. Succeeds if the directory exists
+// @Tags directories
+// @Produce application/json
+// @Param path query string true "Directory path"
+// @Success 201 {object} models.PathResult "Created directory"
+// @Failure 400 {object} models.ErrorResponse "Invalid request"
+// @Failure 409 {object} models.ErrorResponse "A file exists at the path"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /files/directories [post]
+func (h Handler) CreateDirectory(w http.ResponseWriter, r *http.Request) {
+	lg := logger.GetLoggerFromContext(r.Context())
+
+	dirPath, err := h.HandleFilePath("path", w, r)
+	if err != nil {
+		lg.Debug(r.Context(), "Error handling file path", zap.String("path", dirPath))
+		return
+	}
+
+	res, err := h.gw.client.Dirs.CreateDirectory(r.Context(), &fmpb.CreateDirectoryRequest{Path: dirPath})
+	if err != nil {
+		WriteError(w, err)
+		lg.Error(r.Context(), "Error creating directory", zap.String("path", dirPath), zap.Error(err))
+		return
+	}
+
+	h.EncodeJSON(w, http.StatusCreated, toModelPathResult(res), r.Context())
+}
+
+// DeleteDirectory deletes a directory
+// @Summary Delete a directory
+// @Description Deletes a directory. With recursive the content is deleted too, file by file, and every entry is reported. Files are moved to the trash when it is enabled
+// @Tags directories
+// @Produce application/json
+// @Param path query string true "Directory path"
+// @Param recursive query bool false "Delete the content of the directory"
+// @Success 200 {object} models.TreeReport "All entries deleted"
+// @Success 207 {object} models.TreeReport "Some entries failed"
+// @Failure 404 {object} models.ErrorResponse "Directory not found"
+// @Failure 412 {object} models.ErrorResponse "Not a directory or not empty"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /files/directories [delete]
+func (h Handler) DeleteDirectory(w http.ResponseWriter, r *http.Request) {
+	lg := logger.GetLoggerFromContext(r.Context())
+
+	dirPath, err := h.HandleFilePath("path", w, r)
+	if err != nil {
+		lg.Debug(r.Context(), "Error handling file path", zap.String("path", dirPath))
+		return
+	}
+	recursive, ok := boolParam(w, r, "recursive")
+	if !ok {
+		return
+	}
+
+	stream, err := h.gw.client.Dirs.DeleteDirectory(r.Context(),
+		&fmpb.DeleteDirectoryRequest{Path: dirPath, Recursive: recursive})
+	if err != nil {
+		WriteError(w, err)
+		lg.Error(r.Context(), "Error getting stream", zap.Error(err))
+		return
+	}
+
+	h.writeTreeReport(w, r, stream)
+}
+
+// treeRequest reads the parameters shared by copy and move.
+func (h Handler) treeRequest(w http.ResponseWriter, r *http.Request) (*fmpb.TreeRequest, bool) {
+	source, err := h.HandleFilePath("source", w, r)
+	if err != nil {
+		return nil, false
+	}
+	destination, err := h.HandleFilePath("destination", w, r)
+	if err != nil {
+		return nil, false
+	}
+
+	recursive, ok := boolParam(w, r, "recursive")
+	if !ok {
+		return nil, false
+	}
+	overwrite, ok := boolParam(w, r, "overwrite")
+	if !ok {
+		return nil, false
+	}
+
+	return &fmpb.TreeRequest{Source: source, Destination: destination, Recursive: recursive, Overwrite: overwrite}, true
+}
+
+// CopyPath copies a file or a directory tree
+// @Summary Copy a path
+// @Description Copies a file, or with recursive a directory tree, merging into an existing directory. Every entry is reported
+// @Tags directories
+// @Produce application/json
+// @Param source query string true "Path to copy"
+// @Param destination query string true "Path to copy to"
+// @Param recursive query bool false "Copy directories with their content"
+// @Param overwrite query bool false "Replace existing files"
+// @Success 200 {object} models.TreeReport "All entries copied"
+// @Success 207 {object} models.TreeReport "Some entries failed"
+// @Failure 400 {object} models.ErrorResponse "Invalid request"
+// @Failure 404 {object} models.ErrorResponse "Source not found"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /files/paths/copy [post]
+func (h Handler) CopyPath(w http.ResponseWriter, r *http.Request) {
+	lg := logger.GetLoggerFromContext(r.Context())
+
+	req, ok := h.treeRequest(w, r)
+	if !ok {
+		lg.Debug(r.Context(), "Error handling copy parameters")
+		return
+	}
+
+	stream, err := h.gw.client.Dirs.CopyPath(r.Context(), req)
+	if err != nil {
+		WriteError(w, err)
+		lg.Error(r.Context(), "Error getting stream", zap.Error(err))
+		return
+	}
+
+	h.writeTreeReport(w, r, stream)
+}
+
+// MovePath moves a file or a directory tree
+// @Summary Move a path
+// @Description Moves a file or a directory tree, merging into an existing directory. Every entry is reported
+// @Tags directories
+// @Produce application/json
+// @Param source query string true "Path to move"
+// @Param destination query string true "Path to move to"
+// @Param overwrite query bool false "Replace existing files"
+// @Success 200 {object} models.TreeReport "All entries moved"
+// @Success 207 {object} models.TreeReport "Some entries failed"
+// @Failure 400 {object} models.ErrorResponse "Invalid request"
+// @Failure 404 {object} models.ErrorResponse "Source not found"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /files/paths/move [post]
+func (h Handler) MovePath(w http.ResponseWriter, r *http.Request) {
+	lg := logger.GetLoggerFromContext(r.Context())
+
+	req, ok := h.treeRequest(w, r)
+	if !ok {
+		lg.Debug(r.Context(), "Error handling move parameters")
+		return
+	}
+
+	stream, err := h.gw.client.Dirs.MovePath(r.Context(), req)
+	if err != nil {
+		WriteError(w, err)
+		lg.Error(r.Context(), "Error getting stream", zap.Error(err))
+		return
+	}
+
+	h.writeTreeReport(w, r, stream)
+}
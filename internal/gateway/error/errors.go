@@ -27,3 +27,11 @@ func (e WriteError) Error() string {
 func (e ReadError) Error() string {
 	return "Error while reading " + e.Src + e.Err.Error()
 }
+
+func (e WriteError) Unwrap() error {
+	return e.Err
+}
+
+func (e ReadError) Unwrap() error {
+	return e.Err
+}
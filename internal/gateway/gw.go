@@ -3,6 +3,9 @@ package gateway
 import (
 	"context"
 	"fmt"
+	"github.com/JunBSer/FileManager/internal/auth"
+	"github.com/JunBSer/FileManager/internal/certs"
+	"github.com/JunBSer/FileManager/internal/metrics"
 	"github.com/JunBSer/FileManager/internal/transport/grpc"
 	"github.com/JunBSer/FileManager/pkg/logger"
 	"github.com/gorilla/mux"
@@ -11,30 +14,70 @@ import (
 )
 
 type Config struct {
-	Host string `env:"HTTP_HOST" envDefault:"localhost"`
-	Port int    `env:"HTTP_PORT" envDefault:"8080"`
+	Host string             `env:"HTTP_HOST" envDefault:"localhost"`
+	Port int                `env:"HTTP_PORT" envDefault:"8080"`
+	TLS  certs.ServerConfig `env-prefix:"HTTP_"`
 }
 
+// ClientName is the common name of the self-signed certificates of the
+// gateway.
+const ClientName = "gateway"
+
 type GwConfig struct {
+	// MaxSize is the size in KiB of the chunks files are streamed in. It
+	// bounds the memory an upload or download takes, not the file size.
 	MaxSize int64 `env:"FILE_MAX_SIZE" envDefault:"32"`
+	// MaxSpoolSize is the size in MiB up to which the body of a batch
+	// upload is spooled to a temporary file, as zip archives are. Larger
+	// bodies are rejected.
+	MaxSpoolSize int64 `env:"HTTP_MAX_SPOOL_SIZE" envDefault:"1024"`
 }
 type Gateway struct {
 	client  *grpc.Client
 	srv     *http.Server
 	maxSize int64
+	// maxSpool is the limit of spooled bodies in bytes.
+	maxSpool int64
 }
 
-func New(ctx context.Context, grpcConfig *grpc.Config, httpConfig *Config, gwConf *GwConfig) (*Gateway, error) {
-	client, err := grpc.NewClient(ctx, grpcConfig.GRPCHost, grpcConfig.GRPCPort)
+func New(ctx context.Context, grpcConfig *grpc.Config, httpConfig *Config, gwConf *GwConfig, authConfig *auth.Config, metricsConfig *metrics.Config) (*Gateway, error) {
+	lg := logger.GetLoggerFromContext(ctx)
+
+	authn, err := auth.New(*authConfig)
+	if err != nil {
+		lg.Error(ctx, "Error to create authenticator", zap.Error(err))
+		return nil, err
+	}
+
+	tlsConfig, err := certs.Server(httpConfig.TLS, ClientName, authn.Roots())
+	if err != nil {
+		lg.Error(ctx, "Error to configure TLS", zap.Error(err))
+		return nil, err
+	}
+
+	clientTLS, err := certs.Client(grpcConfig.ClientTLS, ClientName)
+	if err != nil {
+		lg.Error(ctx, "Error to configure backend TLS", zap.Error(err))
+		return nil, err
+	}
+
+	client, err := grpc.NewClient(ctx, grpcConfig.GRPCHost, grpcConfig.GRPCPort, authConfig.BackendAPIKey, clientTLS)
 	if err != nil {
 		return nil, err
 	}
 
 	router := mux.NewRouter()
+	if metricsConfig.Enabled {
+		router.Use(MetricsMiddleware)
+		router.Handle(metrics.Path, metrics.Handler()).Methods("GET")
+	}
+	router.Use(TracingMiddleware)
+	router.Use(ShareMiddleware("/api/v1/files/download", "/api/v1/files/upload"), AuthMiddleware(authn, lg, "/swagger/", metrics.Path, HealthzPath, ReadyzPath), LoggerMiddleware(lg))
 
 	gw := &Gateway{
-		client:  client,
-		maxSize: gwConf.MaxSize,
+		client:   client,
+		maxSize:  gwConf.MaxSize,
+		maxSpool: gwConf.MaxSpoolSize << 20,
 	}
 
 	handler := NewGatewayHandler(gw)
@@ -42,16 +85,21 @@ func New(ctx context.Context, grpcConfig *grpc.Config, httpConfig *Config, gwCon
 	handler.SetupRoutes(ctx, router)
 
 	gw.srv = &http.Server{
-		Addr:    fmt.Sprintf("%s:%d", httpConfig.Host, httpConfig.Port),
-		Handler: router,
+		Addr:      fmt.Sprintf("%s:%d", httpConfig.Host, httpConfig.Port),
+		Handler:   router,
+		TLSConfig: tlsConfig,
 	}
 
-	logger.GetLoggerFromContext(ctx).Info(ctx, "Gateway created successfully")
+	lg.Info(ctx, "Gateway created successfully", zap.Bool("authentication", authn.Enabled()), zap.Bool("tls", tlsConfig != nil), zap.Bool("metrics", metricsConfig.Enabled))
 	return gw, nil
 }
 
 func (gw *Gateway) Start(ctx context.Context) error {
 	logger.GetLoggerFromContext(ctx).Info(ctx, "Starting HTTP server __ gateway__", zap.String("addr", gw.srv.Addr))
+	if gw.srv.TLSConfig != nil {
+		// The certificate comes from TLSConfig.GetCertificate.
+		return gw.srv.ListenAndServeTLS("", "")
+	}
 	return gw.srv.ListenAndServe()
 }
 
@@ -4,14 +4,20 @@ import (
 	"bufio"
 	"context"
 	"encoding/json"
+	"errors"
 	myErr "github.com/JunBSer/FileManager/internal/gateway/error"
+	"github.com/JunBSer/FileManager/internal/models"
+	"github.com/JunBSer/FileManager/internal/transport/grpc"
+	"github.com/JunBSer/FileManager/pkg/api/fmpb"
 	"github.com/JunBSer/FileManager/pkg/logger"
 	"github.com/JunBSer/proto_fileManager/pkg/api/proto"
 	"go.uber.org/zap"
+	gogrpc "google.golang.org/grpc"
 	"io"
-	"log"
-	"mime/multipart"
+	"io/fs"
+	"mime"
 	"net/http"
+	"strconv"
 )
 
 type Handler struct {
@@ -57,54 +63,103 @@ func (h Handler) ProcessDownloadFile(w http.ResponseWriter, stream proto.FileSer
 	return cnt, nil
 }
 
-func (h Handler) ProcessUploadFile(fileName string, file multipart.File, stream proto.FileService_UploadClient) error {
+// ProcessUploadFile sends the content of file to stream in chunks of
+// FILE_MAX_SIZE KiB, so memory use does not depend on the size of the file.
+// An empty file is sent as one empty chunk, which names it. Errors reading
+// file are returned as ReadError.
+func (h Handler) ProcessUploadFile(fileName string, file io.Reader, stream proto.FileService_UploadClient) error {
 	buf := make([]byte, h.gw.maxSize<<10)
 
-	for {
-		bytesRead, err := file.Read(buf)
-		if err != nil {
-			if err == io.EOF {
-				break
-			}
-			return err
+	for first := true; ; first = false {
+		bytesRead, err := io.ReadFull(file, buf)
+		if err == io.EOF && !first {
+			break
+		}
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			return myErr.ReadError{Err: err, Src: "request"}
 		}
 
 		req := proto.FileChunk{FileName: fileName, Content: buf[:bytesRead]}
 		if err := stream.Send(&req); err != nil {
+			// The server ended the stream, e.g. because a quota is exceeded.
+			// CloseAndRecv returns its status.
+			if err == io.EOF {
+				return nil
+			}
 			return err
 		}
+		if bytesRead < len(buf) {
+			break
+		}
 	}
 
 	return nil
 }
 
-// Upload uploads a file
-// @Summary Uploads a file
-// @Description Accepts a multipart file upload
-// @Tags uploading
-// @Accept multipart/form-data
-// @Produce text/plain
-// @Param file formData file true "File to upload"
-// @Param file_path query string true "Path to save the file" example("/documents/report.pdf")
-// @Success 200 {string} string "Status: {status}"
-// @Failure 400 {object} models.ErrorResponse "Invalid request"
-// @Failure 500 {object} models.ErrorResponse "Internal server error"
-// @Router /upload [post]
-func (h Handler) Upload(w http.ResponseWriter, r *http.Request) {
-	lg := logger.GetLoggerFromContext(r.Context())
+// errUnsupportedUpload is returned by uploadBody for bodies that are neither
+// multipart/form-data nor application/octet-stream.
+var errUnsupportedUpload = errors.New("upload must be multipart/form-data or application/octet-stream")
 
-	if r.Method != "POST" {
-		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
-		return
+// uploadBody returns the content of the file of an upload request: the part
+// named "file" of a multipart/form-data body or a whole
+// application/octet-stream body. Nothing is buffered, the file is read as it
+// arrives.
+func uploadBody(r *http.Request) (io.Reader, error) {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		return nil, errUnsupportedUpload
 	}
 
-	r.Body = http.MaxBytesReader(w, r.Body, h.gw.maxSize<<20)
-	defer r.Body.Close()
+	switch mediaType {
+	case "application/octet-stream":
+		return r.Body, nil
+	case "multipart/form-data":
+		reader, err := r.MultipartReader()
+		if err != nil {
+			return nil, err
+		}
+		for {
+			part, err := reader.NextPart()
+			if err == io.EOF {
+				return nil, errors.New("file field is missing")
+			}
+			if err != nil {
+				return nil, err
+			}
+			if part.FormName() == "file" {
+				return part, nil
+			}
+		}
+	}
+	return nil, errUnsupportedUpload
+}
 
-	if err := r.ParseMultipartForm(h.gw.maxSize << 20); err != nil {
-		http.Error(w, "Invalid file upload: "+err.Error(), http.StatusBadRequest)
-		return
+// uploadCall opens the stream of an upload, append or overwrite.
+type uploadCall func(ctx context.Context, opts ...gogrpc.CallOption) (proto.FileService_UploadClient, error)
+
+// sendFile streams file to a new stream of call and returns the response of
+// the server. The stream is canceled if file cannot be read or sent
+// completely, so nothing is stored.
+func (h Handler) sendFile(ctx context.Context, call uploadCall, fileName string, file io.Reader) (*proto.StatusResponse, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	stream, err := call(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := h.ProcessUploadFile(fileName, file, stream); err != nil {
+		return nil, err
 	}
+	return stream.CloseAndRecv()
+}
+
+// StreamUpload forwards the file of r to the stream opened by call as it is
+// received. If the request fails before the file is complete, the stream is
+// canceled, so nothing is stored.
+func (h Handler) StreamUpload(w http.ResponseWriter, r *http.Request, call uploadCall) {
+	lg := logger.GetLoggerFromContext(r.Context())
+	defer r.Body.Close()
 
 	fileName, err := h.HandleFilePath("file_path", w, r)
 	if err != nil {
@@ -112,47 +167,64 @@ func (h Handler) Upload(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	file, _, err := r.FormFile("file")
-	defer func() {
-		err = file.Close()
-		if err != nil {
-			log.Println(err)
-		}
-	}()
-
+	file, err := uploadBody(r)
 	if err != nil {
-		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		code := http.StatusBadRequest
+		if errors.Is(err, errUnsupportedUpload) {
+			code = http.StatusUnsupportedMediaType
+		}
+		http.Error(w, "Invalid file upload: "+err.Error(), code)
 		lg.Error(r.Context(), "Error reading file", zap.Error(err))
 		return
 	}
 
-	stream, err := h.gw.client.Cl.Upload(r.Context())
+	ctx, err := checksumContext(r)
 	if err != nil {
-		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
-		lg.Error(r.Context(), "Error getting stream", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
-	defer stream.CloseSend()
 
-	err = h.ProcessUploadFile(fileName, file, stream)
+	res, err := h.sendFile(ctx, call, fileName, file)
 	if err != nil {
-		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
 		lg.Error(r.Context(), "Error processing file", zap.Error(err))
+		if errors.As(err, &myErr.ReadError{}) {
+			http.Error(w, "Invalid file upload: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		WriteError(w, err)
 		return
 	}
 
-	res, err := stream.CloseAndRecv()
+	_, err = w.Write([]byte("Status: " + res.GetStatus().String()))
 	if err != nil {
 		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
-		lg.Error(r.Context(), "Error receiving response", zap.Error(err))
-		return
 	}
+}
 
-	_, err = w.Write([]byte("Status: " + res.GetStatus().String()))
-	if err != nil {
-		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+// Upload uploads a file
+// @Summary Uploads a file
+// @Description Accepts the file as multipart form field "file" or as a raw application/octet-stream body. The file is streamed to storage as it arrives, so its size is not limited
+// @Tags uploading
+// @Accept multipart/form-data
+// @Accept application/octet-stream
+// @Produce text/plain
+// @Param file formData file false "File to upload, for multipart/form-data requests"
+// @Param file_path query string true "Path to save the file" example("/documents/report.pdf")
+// @Param Digest header string false "Checksums of the file, e.g. sha-256=<base64>, crc32c=<base64>; a mismatch rejects the upload"
+// @Success 200 {string} string "Status: {status}"
+// @Failure 400 {object} models.ErrorResponse "Invalid request"
+// @Failure 415 {object} models.ErrorResponse "Body is neither multipart/form-data nor application/octet-stream"
+// @Failure 507 {object} models.ErrorResponse "Quota exceeded"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /files/upload [post]
+// @Router /files/upload [put]
+func (h Handler) Upload(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" && r.Method != "PUT" {
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
 	}
 
+	h.StreamUpload(w, r, h.gw.client.Cl.Upload)
 }
 
 // Download retrieves a file
@@ -162,10 +234,15 @@ func (h Handler) Upload(w http.ResponseWriter, r *http.Request) {
 // @Accept application/json
 // @Produce application/octet-stream
 // @Param file_path query string true "Path to the file"
+// @Param Range header string false "Byte ranges to return, e.g. bytes=0-1023,-512"
 // @Success 200 {file} file "The requested file"
+// @Header 200 {string} Digest "SHA-256 of the file, sha-256=<base64>; sent as a trailer if the server has no stored digest"
+// @Header 200 {string} ETag "Hex SHA-256 of the file, if the server has a stored digest"
+// @Success 206 {file} file "The requested ranges of the file"
 // @Failure 404 {object} models.ErrorResponse "File not found"
+// @Failure 416 {object} models.ErrorResponse "Range not satisfiable"
 // @Failure 500 {object} models.ErrorResponse "Internal server error"
-// @Router /download [get]
+// @Router /files/download [get]
 func (h Handler) Download(w http.ResponseWriter, r *http.Request) {
 	lg := logger.GetLoggerFromContext(r.Context())
 	if r.Method != "GET" {
@@ -179,23 +256,42 @@ func (h Handler) Download(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	w.Header().Set("Accept-Ranges", "bytes")
+	w.Header().Set("Content-Disposition", "attachment; filename*=UTF-8''"+fileName)
+
+	// Share tokens only cover whole downloads, so shared requests ignore
+	// ranges.
+	if _, shared := grpc.ShareToken(r.Context()); !shared {
+		if ranges, ok := h.HandleRange(r); ok {
+			h.ProcessRangeFile(w, r, fileName, ranges)
+			return
+		}
+	}
+
 	stream, err := h.gw.client.Cl.Download(r.Context(), &proto.FileRequest{FileName: fileName})
 	if err != nil {
-		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		WriteError(w, err)
 		lg.Error(r.Context(), "Error getting stream", zap.Error(err))
 		return
 	}
 
 	defer stream.CloseSend()
-	w.Header().Set("Content-Disposition", "attachment; filename*=UTF-8''"+fileName)
+
+	if header, err := stream.Header(); err == nil {
+		setDigest(w, header)
+	}
 
 	cnt, err := h.ProcessDownloadFile(w, stream)
 	if err != nil {
 		lg.Error(r.Context(), "Error processing file", zap.Error(err))
 		if cnt == 0 {
-			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			w.Header().Del("Trailer")
+			w.Header().Del("Content-Disposition")
+			WriteError(w, err)
 		}
+		return
 	}
+	setDigestTrailer(w, stream.Trailer())
 
 }
 
@@ -206,10 +302,15 @@ func (h Handler) Download(w http.ResponseWriter, r *http.Request) {
 // @Accept application/json
 // @Produce application/octet-stream
 // @Param file_path query string true "Path to the file"
+// @Param Range header string false "Byte ranges to return, e.g. bytes=0-1023,-512"
 // @Success 200 {file} file "Content of the file"
+// @Header 200 {string} Digest "SHA-256 of the file, sha-256=<base64>; sent as a trailer if the server has no stored digest"
+// @Header 200 {string} ETag "Hex SHA-256 of the file, if the server has a stored digest"
+// @Success 206 {file} file "The requested ranges of the file"
 // @Failure 404 {object} models.ErrorResponse "File not found"
+// @Failure 416 {object} models.ErrorResponse "Range not satisfiable"
 // @Failure 500 {object} models.ErrorResponse "Internal server error"
-// @Router /read [get]
+// @Router /files/read [get]
 func (h Handler) Read(w http.ResponseWriter, r *http.Request) {
 	lg := logger.GetLoggerFromContext(r.Context())
 	if r.Method != "GET" {
@@ -223,179 +324,92 @@ func (h Handler) Read(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	w.Header().Set("Accept-Ranges", "bytes")
+
+	if ranges, ok := h.HandleRange(r); ok {
+		h.ProcessRangeFile(w, r, fileName, ranges)
+		return
+	}
+
 	stream, err := h.gw.client.Cl.Read(r.Context(), &proto.FileRequest{FileName: fileName})
 	if err != nil {
-		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		WriteError(w, err)
 		lg.Error(r.Context(), "Error getting stream", zap.Error(err))
 		return
 	}
 
 	defer stream.CloseSend()
 
+	if header, err := stream.Header(); err == nil {
+		setDigest(w, header)
+	}
+
 	cnt, err := h.ProcessDownloadFile(w, stream)
 	if err != nil {
 		lg.Error(r.Context(), "Error processing file", zap.Error(err))
 		if cnt == 0 {
-			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			w.Header().Del("Trailer")
+			w.Header().Del("Content-Disposition")
+			WriteError(w, err)
 		}
+		return
 	}
+	setDigestTrailer(w, stream.Trailer())
 }
 
 // Append appends data to a file
 // @Summary Append data to a file
-// @Description Appends data to an existing file
+// @Description Appends data to an existing file. The data is sent as multipart form field "file" or as a raw application/octet-stream body, and streamed to storage as it arrives
 // @Tags appending
 // @Accept multipart/form-data
+// @Accept application/octet-stream
 // @Produce text/plain
-// @Param file formData file true "File to append"
+// @Param file formData file false "Data to append, for multipart/form-data requests"
 // @Param file_path query string true "Path to the file"
+// @Param Digest header string false "Checksums of the whole file after the append, e.g. sha-256=<base64>; a mismatch rejects the append"
 // @Success 200 {string} string "Status: {status}"
 // @Failure 400 {object} models.ErrorResponse "Invalid request"
+// @Failure 415 {object} models.ErrorResponse "Body is neither multipart/form-data nor application/octet-stream"
+// @Failure 507 {object} models.ErrorResponse "Quota exceeded"
 // @Failure 500 {object} models.ErrorResponse "Internal server error"
-// @Router /append [post]
+// @Router /files/append [post]
 func (h Handler) Append(w http.ResponseWriter, r *http.Request) {
-	lg := logger.GetLoggerFromContext(r.Context())
-
 	if r.Method != "POST" {
 		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
 		return
 	}
 
-	r.Body = http.MaxBytesReader(w, r.Body, h.gw.maxSize<<20)
-	defer r.Body.Close()
-
-	if err := r.ParseMultipartForm(h.gw.maxSize << 20); err != nil {
-		http.Error(w, "Invalid file upload: "+err.Error(), http.StatusBadRequest)
-		return
-	}
-
-	fileName, err := h.HandleFilePath("file_path", w, r)
-	if err != nil {
-		lg.Error(r.Context(), "Error handling file path", zap.String("fileName", fileName))
-		return
-	}
-
-	file, _, err := r.FormFile("file")
-	defer func() {
-		err = file.Close()
-		if err != nil {
-			log.Println(err)
-		}
-	}()
-
-	if err != nil {
-		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
-		lg.Error(r.Context(), "Error reading file", zap.Error(err))
-		return
-	}
-
-	stream, err := h.gw.client.Cl.Append(r.Context())
-	if err != nil {
-		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
-		lg.Error(r.Context(), "Error getting stream", zap.Error(err))
-		return
-	}
-	defer stream.CloseSend()
-
-	err = h.ProcessUploadFile(fileName, file, stream)
-	if err != nil {
-		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
-		lg.Error(r.Context(), "Error processing file", zap.Error(err))
-		return
-	}
-
-	res, err := stream.CloseAndRecv()
-	if err != nil {
-		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
-		lg.Error(r.Context(), "Error receiving response and closing stream", zap.Error(err))
-		return
-	}
-
-	_, err = w.Write([]byte("Status: " + res.GetStatus().String()))
-	if err != nil {
-		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
-	}
+	h.StreamUpload(w, r, h.gw.client.Cl.Append)
 }
 
 // Overwrite replaces a file with a new one
 // @Summary Overwrite a file
-// @Description Replaces an existing file with a new one
+// @Description Replaces an existing file with a new one, sent as multipart form field "file" or as a raw application/octet-stream body and streamed to storage as it arrives
 // @Tags overwriting
 // @Accept multipart/form-data
+// @Accept application/octet-stream
 // @Produce text/plain
-// @Param file formData file true "File to upload"
+// @Param file formData file false "File to upload, for multipart/form-data requests"
 // @Param file_path query string true "Path to save the file"
+// @Param Digest header string false "Checksums of the file, e.g. sha-256=<base64>, crc32c=<base64>; a mismatch rejects the upload"
 // @Success 200 {string} string "Status: {status}"
 // @Failure 400 {object} models.ErrorResponse "Invalid request"
+// @Failure 415 {object} models.ErrorResponse "Body is neither multipart/form-data nor application/octet-stream"
+// @Failure 507 {object} models.ErrorResponse "Quota exceeded"
 // @Failure 500 {object} models.ErrorResponse "Internal server error"
-// @Router/overwrite [put]
+// @Router /files/overwrite [put]
 func (h Handler) Overwrite(w http.ResponseWriter, r *http.Request) {
-	lg := logger.GetLoggerFromContext(r.Context())
-
 	if r.Method != "PUT" {
 		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
 		return
 	}
 
-	r.Body = http.MaxBytesReader(w, r.Body, h.gw.maxSize<<20)
-	defer r.Body.Close()
-
-	if err := r.ParseMultipartForm(h.gw.maxSize << 20); err != nil {
-		http.Error(w, "Invalid file upload: "+err.Error(), http.StatusBadRequest)
-		return
-	}
-
-	fileName, err := h.HandleFilePath("file_path", w, r)
-	if err != nil {
-		lg.Error(r.Context(), "Error handling file path", zap.String("fileName", fileName))
-		return
-	}
-
-	file, _, err := r.FormFile("file")
-	defer func() {
-		err = file.Close()
-		if err != nil {
-			log.Println(err)
-		}
-	}()
-
-	if err != nil {
-		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
-		lg.Error(r.Context(), "Error reading file", zap.Error(err))
-		return
-	}
-
-	stream, err := h.gw.client.Cl.OverwriteFile(r.Context())
-	if err != nil {
-		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
-		lg.Error(r.Context(), "Error getting stream", zap.Error(err))
-		return
-	}
-	defer stream.CloseSend()
-
-	err = h.ProcessUploadFile(fileName, file, stream)
-	if err != nil {
-		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
-		lg.Error(r.Context(), "Error processing file", zap.Error(err))
-		return
-	}
-
-	res, err := stream.CloseAndRecv()
-	if err != nil {
-		lg.Error(r.Context(), "Error closing stream", zap.Error(err))
-		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
-		return
-	}
-
-	_, err = w.Write([]byte("Status: " + res.GetStatus().String()))
-	if err != nil {
-		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
-	}
+	h.StreamUpload(w, r, h.gw.client.Cl.OverwriteFile)
 }
 
 // Delete removes a file
 // @Summary Delete a file
-// @Description Deletes a file based on the provided path
+// @Description Deletes a file based on the provided path. The file is moved to the trash when it is enabled
 // @Tags deleting
 // @Accept application/json
 // @Produce text/plain
@@ -403,7 +417,7 @@ func (h Handler) Overwrite(w http.ResponseWriter, r *http.Request) {
 // @Success 200 {string} string "Status: {status}"
 // @Failure 404 {object} models.ErrorResponse "File not found"
 // @Failure 500 {object} models.ErrorResponse "Internal server error"
-// @Router /delete [delete]
+// @Router /files/delete [delete]
 func (h Handler) Delete(w http.ResponseWriter, r *http.Request) {
 	lg := logger.GetLoggerFromContext(r.Context())
 	if r.Method != "DELETE" {
@@ -418,7 +432,7 @@ func (h Handler) Delete(w http.ResponseWriter, r *http.Request) {
 
 	res, err := h.gw.client.Cl.Delete(r.Context(), &proto.FileRequest{FileName: fileName})
 	if err != nil {
-		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		WriteError(w, err)
 		lg.Error(r.Context(), "Error getting stream", zap.Error(err))
 		return
 	}
@@ -440,8 +454,9 @@ func (h Handler) Delete(w http.ResponseWriter, r *http.Request) {
 // @Param dst_path query string true "Destination path"
 // @Success 200 {string} string "Status: {status}"
 // @Failure 404 {object} models.ErrorResponse "File not found"
+// @Failure 507 {object} models.ErrorResponse "Quota exceeded"
 // @Failure 500 {object} models.ErrorResponse "Internal server error"
-// @Router /move [post]
+// @Router /files/move [post]
 func (h Handler) MoveFile(w http.ResponseWriter, r *http.Request) {
 	lg := logger.GetLoggerFromContext(r.Context())
 	if r.Method != "POST" {
@@ -463,7 +478,7 @@ func (h Handler) MoveFile(w http.ResponseWriter, r *http.Request) {
 
 	res, err := h.gw.client.Cl.MoveFile(r.Context(), &proto.OperationRequest{Destination: dstFileName, Source: srcFileName})
 	if err != nil {
-		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		WriteError(w, err)
 		lg.Debug(r.Context(), "Error moving file", zap.Error(err))
 		return
 	}
@@ -475,24 +490,30 @@ func (h Handler) MoveFile(w http.ResponseWriter, r *http.Request) {
 
 }
 
+func toModelEntry(stat *fmpb.FileStat) models.FileEntry {
+	return models.FileEntry{
+		Name:         stat.Name,
+		IsDirectory:  stat.IsDir,
+		Size:         stat.Size,
+		PhysicalSize: stat.PhysicalSize,
+		ModTime:      stat.ModTime,
+		Mode:         fs.FileMode(stat.Mode).String(),
+		ContentType:  stat.ContentType,
+		Checksum:     stat.Checksum,
+	}
+}
+
 func (h Handler) EncodeDirectoryResponse(
 	w http.ResponseWriter,
-	entries []*proto.DirectoryEntry,
+	entries []*fmpb.FileStat,
 	dirPath string,
 	ctx context.Context,
 ) {
 	lg := logger.GetLoggerFromContext(ctx)
-	type Entry struct {
-		Name        string `json:"name"`
-		IsDirectory bool   `json:"is_directory"`
-	}
 
-	jsonEntries := make([]Entry, 0, len(entries))
+	jsonEntries := make([]models.FileEntry, 0, len(entries))
 	for _, e := range entries {
-		jsonEntries = append(jsonEntries, Entry{
-			Name:        e.Name,
-			IsDirectory: e.IsDir,
-		})
+		jsonEntries = append(jsonEntries, toModelEntry(e))
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -505,15 +526,23 @@ func (h Handler) EncodeDirectoryResponse(
 
 // ListDir lists files in a directory
 // @Summary List directory contents
-// @Description Returns a list of files and directories in the specified path
+// @Description Returns a page of the files and directories in the specified path with their metadata. The cursor of the next page is returned in the X-Next-Cursor header
 // @Tags listing
 // @Accept application/json
 // @Produce application/json
 // @Param path query string true "Directory path"
+// @Param sort query string false "Sort by name, size or mtime" Enums(name, size, mtime)
+// @Param order query string false "Sort order" Enums(asc, desc)
+// @Param pattern query string false "Shell pattern the names must match, e.g. *.log"
+// @Param limit query int false "Maximum number of entries"
+// @Param cursor query string false "X-Next-Cursor of the previous page"
+// @Param checksum query bool false "Add the SHA-256 of every file the caller may read"
 // @Success 200 {array} models.FileEntry "List of directory entries"
+// @Header 200 {string} X-Next-Cursor "Cursor of the next page, absent on the last page"
 // @Failure 400 {object} models.ErrorResponse "Invalid request"
+// @Failure 404 {object} models.ErrorResponse "Directory not found"
 // @Failure 500 {object} models.ErrorResponse "Internal server error"
-// @Router /list [get]
+// @Router /files/list [get]
 func (h Handler) ListDir(w http.ResponseWriter, r *http.Request) {
 	lg := logger.GetLoggerFromContext(r.Context())
 
@@ -528,18 +557,94 @@ func (h Handler) ListDir(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	res, err := h.gw.client.Cl.ListDirectory(r.Context(),
-		&proto.DirectoryRequest{Path: dirPath})
+	req := &fmpb.ListDirectoryRequest{
+		Path:    dirPath,
+		Pattern: r.URL.Query().Get("pattern"),
+		Cursor:  r.URL.Query().Get("cursor"),
+	}
+
+	switch r.URL.Query().Get("sort") {
+	case "", "name":
+	case "size":
+		req.SortBy = fmpb.SortField_SORT_FIELD_SIZE
+	case "mtime":
+		req.SortBy = fmpb.SortField_SORT_FIELD_MOD_TIME
+	default:
+		http.Error(w, "sort must be name, size or mtime", http.StatusBadRequest)
+		return
+	}
+
+	switch r.URL.Query().Get("order") {
+	case "", "asc":
+	case "desc":
+		req.Descending = true
+	default:
+		http.Error(w, "order must be asc or desc", http.StatusBadRequest)
+		return
+	}
+
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		limit, err := strconv.ParseInt(raw, 10, 32)
+		if err != nil || limit < 0 {
+			http.Error(w, "limit must be a non-negative integer", http.StatusBadRequest)
+			return
+		}
+		req.PageSize = int32(limit)
+	}
+
+	var ok bool
+	if req.Checksum, ok = boolParam(w, r, "checksum"); !ok {
+		return
+	}
+
+	res, err := h.gw.client.Meta.ListDirectory(r.Context(), req)
 	if err != nil {
-		http.Error(w, http.StatusText(http.StatusInternalServerError),
-			http.StatusInternalServerError)
+		WriteError(w, err)
 		lg.Error(r.Context(), "Error getting directory listing",
 			zap.String("path", dirPath),
 			zap.Error(err))
 		return
 	}
 
+	if res.NextCursor != "" {
+		w.Header().Set("X-Next-Cursor", res.NextCursor)
+	}
 	h.EncodeDirectoryResponse(w, res.Entries, dirPath, r.Context())
 }
 
+// Stat describes a single path
+// @Summary Stat a path
+// @Description Returns the metadata of a file or directory
+// @Tags listing
+// @Produce application/json
+// @Param path query string true "File or directory path"
+// @Param checksum query bool false "Add the SHA-256 of the file; needs read access"
+// @Success 200 {object} models.FileStat "Metadata of the path"
+// @Failure 400 {object} models.ErrorResponse "Invalid request"
+// @Failure 404 {object} models.ErrorResponse "Path not found"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /files/stat [get]
+func (h Handler) Stat(w http.ResponseWriter, r *http.Request) {
+	lg := logger.GetLoggerFromContext(r.Context())
+
+	filePath, err := h.HandleFilePath("path", w, r)
+	if err != nil {
+		lg.Debug(r.Context(), "Error handling file path", zap.String("path", filePath))
+		return
+	}
+	checksum, ok := boolParam(w, r, "checksum")
+	if !ok {
+		return
+	}
+
+	res, err := h.gw.client.Meta.Stat(r.Context(), &fmpb.StatRequest{Path: filePath, Checksum: checksum})
+	if err != nil {
+		WriteError(w, err)
+		lg.Error(r.Context(), "Error getting file metadata", zap.String("path", filePath), zap.Error(err))
+		return
+	}
+
+	h.EncodeJSON(w, http.StatusOK, models.FileStat{Path: res.Path, FileEntry: toModelEntry(res)}, r.Context())
+}
+
 // Handlers with a bit of large logic :) <3
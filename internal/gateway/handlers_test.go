@@ -1,15 +1,23 @@
 package gateway
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	myErr "github.com/JunBSer/FileManager/internal/gateway/error"
+	"io"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/JunBSer/FileManager/pkg/logger"
+	"github.com/JunBSer/proto_fileManager/pkg/api/proto"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	gogrpc "google.golang.org/grpc"
 )
 
 func TestHandler_HandleFilePath(t *testing.T) {
@@ -45,3 +53,90 @@ func TestHandler_HandleFilePath(t *testing.T) {
 		assert.Equal(t, http.StatusBadRequest, w.Code)
 	})
 }
+
+type fakeUploadStream struct {
+	gogrpc.ClientStream
+	ctx    context.Context
+	chunks [][]byte
+	closed bool
+}
+
+func (s *fakeUploadStream) Send(chunk *proto.FileChunk) error {
+	s.chunks = append(s.chunks, append([]byte(nil), chunk.Content...))
+	return nil
+}
+
+func (s *fakeUploadStream) CloseAndRecv() (*proto.StatusResponse, error) {
+	s.closed = true
+	return &proto.StatusResponse{Status: proto.Status_STATUS_SUCCESS}, nil
+}
+
+type failingReader struct{}
+
+func (failingReader) Read([]byte) (int, error) {
+	return 0, errors.New("connection reset")
+}
+
+func TestHandler_StreamUpload(t *testing.T) {
+	h := &Handler{gw: &Gateway{maxSize: 1}}
+	content := bytes.Repeat([]byte("0123456789"), 500)
+
+	multipartBody := func(field string) (io.Reader, string) {
+		var body bytes.Buffer
+		mw := multipart.NewWriter(&body)
+		require.NoError(t, mw.WriteField("comment", "before the file"))
+		part, err := mw.CreateFormFile(field, "data.bin")
+		require.NoError(t, err)
+		_, err = part.Write(content)
+		require.NoError(t, err)
+		require.NoError(t, mw.Close())
+		return &body, mw.FormDataContentType()
+	}
+
+	tests := []struct {
+		testName    string
+		body        func() (io.Reader, string)
+		wantCode    int
+		wantContent []byte
+	}{
+		{"multipart", func() (io.Reader, string) { return multipartBody("file") }, http.StatusOK, content},
+		{"octet stream", func() (io.Reader, string) { return bytes.NewReader(content), "application/octet-stream" }, http.StatusOK, content},
+		{"empty octet stream", func() (io.Reader, string) { return strings.NewReader(""), "application/octet-stream" }, http.StatusOK, nil},
+		{"missing file field", func() (io.Reader, string) { return multipartBody("other") }, http.StatusBadRequest, nil},
+		{"unsupported type", func() (io.Reader, string) { return strings.NewReader("x"), "text/plain" }, http.StatusUnsupportedMediaType, nil},
+		{"broken body", func() (io.Reader, string) {
+			return io.MultiReader(bytes.NewReader(content), failingReader{}), "application/octet-stream"
+		}, http.StatusBadRequest, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.testName, func(t *testing.T) {
+			body, contentType := tt.body()
+			req := httptest.NewRequest("PUT", "/api/v1/files/upload?file_path=data.bin", body)
+			req.Header.Set("Content-Type", contentType)
+			req = req.WithContext(context.WithValue(context.Background(), logger.Key, logger.New("gw test", "debug")))
+			rec := httptest.NewRecorder()
+
+			stream := &fakeUploadStream{}
+			h.StreamUpload(rec, req, func(ctx context.Context, _ ...gogrpc.CallOption) (proto.FileService_UploadClient, error) {
+				stream.ctx = ctx
+				return stream, nil
+			})
+
+			assert.Equal(t, tt.wantCode, rec.Code, rec.Body.String())
+			if tt.wantCode != http.StatusOK {
+				assert.False(t, stream.closed, "failed upload is not committed")
+				if stream.ctx != nil {
+					assert.Error(t, stream.ctx.Err(), "failed upload is canceled")
+				}
+				return
+			}
+
+			assert.True(t, stream.closed)
+			assert.Equal(t, string(tt.wantContent), string(bytes.Join(stream.chunks, nil)))
+			for _, chunk := range stream.chunks {
+				assert.LessOrEqual(t, len(chunk), 1<<10)
+			}
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package gateway
+
+import (
+	"context"
+	"github.com/JunBSer/FileManager/internal/models"
+	"github.com/JunBSer/FileManager/pkg/logger"
+	"go.uber.org/zap"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"net/http"
+	"time"
+)
+
+// Paths of the probes, which are served without credentials.
+const (
+	HealthzPath = "/healthz"
+	ReadyzPath  = "/readyz"
+)
+
+// probeTimeout bounds the health check of the backend a probe makes.
+const probeTimeout = 2 * time.Second
+
+// checkBackend asks the backend for its health. Status is UNREACHABLE if it
+// does not answer.
+func (h Handler) checkBackend(ctx context.Context) (models.Health, error) {
+	ctx, cancel := context.WithTimeout(ctx, probeTimeout)
+	defer cancel()
+
+	res, err := h.gw.client.Health.Check(ctx, &healthpb.HealthCheckRequest{})
+	if err != nil {
+		return models.Health{Status: "UNREACHABLE", Error: err.Error()}, err
+	}
+	return models.Health{Status: res.Status.String()}, nil
+}
+
+// Healthz tells whether the gateway is alive, that is whether it can reach
+// the backend. It does not care whether the backend can store files.
+func (h Handler) Healthz(w http.ResponseWriter, r *http.Request) {
+	res, err := h.checkBackend(r.Context())
+	if err != nil {
+		logger.GetLoggerFromContext(r.Context()).Error(r.Context(), "Backend is unreachable", zap.Error(err))
+		h.EncodeJSON(w, http.StatusServiceUnavailable, res, r.Context())
+		return
+	}
+	h.EncodeJSON(w, http.StatusOK, res, r.Context())
+}
+
+// Readyz tells whether requests can be served, that is whether the backend
+// is reachable and reports that it is serving.
+func (h Handler) Readyz(w http.ResponseWriter, r *http.Request) {
+	res, err := h.checkBackend(r.Context())
+	if err != nil {
+		logger.GetLoggerFromContext(r.Context()).Error(r.Context(), "Backend is unreachable", zap.Error(err))
+	}
+	if res.Status != healthpb.HealthCheckResponse_SERVING.String() {
+		h.EncodeJSON(w, http.StatusServiceUnavailable, res, r.Context())
+		return
+	}
+	h.EncodeJSON(w, http.StatusOK, res, r.Context())
+}
@@ -0,0 +1,67 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/JunBSer/FileManager/internal/models"
+	"github.com/JunBSer/FileManager/internal/transport/grpc"
+	"github.com/JunBSer/FileManager/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	gogrpc "google.golang.org/grpc"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+type fakeHealth struct {
+	healthpb.HealthClient
+	status healthpb.HealthCheckResponse_ServingStatus
+	err    error
+}
+
+func (f fakeHealth) Check(context.Context, *healthpb.HealthCheckRequest, ...gogrpc.CallOption) (*healthpb.HealthCheckResponse, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &healthpb.HealthCheckResponse{Status: f.status}, nil
+}
+
+func TestProbes(t *testing.T) {
+	tests := []struct {
+		testName    string
+		backend     fakeHealth
+		wantStatus  string
+		wantHealthz int
+		wantReadyz  int
+	}{
+		{"serving", fakeHealth{status: healthpb.HealthCheckResponse_SERVING}, "SERVING", http.StatusOK, http.StatusOK},
+		{"not serving", fakeHealth{status: healthpb.HealthCheckResponse_NOT_SERVING}, "NOT_SERVING", http.StatusOK, http.StatusServiceUnavailable},
+		{"unreachable", fakeHealth{err: errors.New("connection refused")}, "UNREACHABLE", http.StatusServiceUnavailable, http.StatusServiceUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.testName, func(t *testing.T) {
+			h := Handler{gw: &Gateway{client: &grpc.Client{Health: tt.backend}}}
+			ctx := context.WithValue(context.Background(), logger.Key, logger.New("gw test", "debug"))
+
+			for path, want := range map[string]int{HealthzPath: tt.wantHealthz, ReadyzPath: tt.wantReadyz} {
+				rec := httptest.NewRecorder()
+				req := httptest.NewRequest("GET", path, nil).WithContext(ctx)
+				if path == HealthzPath {
+					h.Healthz(rec, req)
+				} else {
+					h.Readyz(rec, req)
+				}
+				assert.Equal(t, want, rec.Code, path)
+
+				var res models.Health
+				require.NoError(t, json.NewDecoder(rec.Body).Decode(&res))
+				assert.Equal(t, tt.wantStatus, res.Status, path)
+			}
+		})
+	}
+}
@@ -2,10 +2,26 @@ package gateway
 
 import (
 	"context"
+	"errors"
+	"github.com/JunBSer/FileManager/internal/auth"
+	"github.com/JunBSer/FileManager/internal/metrics"
+	"github.com/JunBSer/FileManager/internal/tracing"
+	"github.com/JunBSer/FileManager/internal/transport/grpc"
 	"github.com/JunBSer/FileManager/pkg/logger"
 	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
+	"io"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 )
 
 func CorsMiddleware(next http.Handler) http.Handler {
@@ -22,6 +38,108 @@ func CorsMiddleware(next http.Handler) http.Handler {
 		next.ServeHTTP(w, r)
 	})
 }
+
+// statusRecorder remembers the status code and counts the body bytes of a
+// response.
+type statusRecorder struct {
+	http.ResponseWriter
+	code  int
+	bytes int64
+}
+
+func (w *statusRecorder) WriteHeader(code int) {
+	if w.code == 0 {
+		w.code = code
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *statusRecorder) Write(b []byte) (int, error) {
+	if w.code == 0 {
+		w.code = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.bytes += int64(n)
+	return n, err
+}
+
+// Unwrap lets http.ResponseController reach the flusher of the connection.
+func (w *statusRecorder) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// countingBody counts the bytes read from a request body.
+type countingBody struct {
+	io.ReadCloser
+	bytes int64
+}
+
+func (b *countingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.bytes += int64(n)
+	return n, err
+}
+
+// MetricsMiddleware counts the requests by route template, method and status
+// code, and observes their duration and the size of their bodies.
+func MetricsMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route := routeTemplate(r)
+		metrics.HTTPInFlight.Inc()
+		defer metrics.HTTPInFlight.Dec()
+		start := time.Now()
+
+		rec := &statusRecorder{ResponseWriter: w}
+		body := &countingBody{ReadCloser: r.Body}
+		r.Body = body
+		next.ServeHTTP(rec, r)
+
+		if rec.code == 0 {
+			rec.code = http.StatusOK
+		}
+		metrics.HTTPRequests.WithLabelValues(route, r.Method, strconv.Itoa(rec.code)).Inc()
+		metrics.HTTPDuration.WithLabelValues(route, r.Method).Observe(time.Since(start).Seconds())
+		metrics.HTTPBytes.WithLabelValues(route, metrics.Received).Add(float64(body.bytes))
+		metrics.HTTPBytes.WithLabelValues(route, metrics.Sent).Add(float64(rec.bytes))
+	})
+}
+
+// routeTemplate returns the path template of the route of r, or "unmatched".
+func routeTemplate(r *http.Request) string {
+	if current := mux.CurrentRoute(r); current != nil {
+		if tmpl, err := current.GetPathTemplate(); err == nil {
+			return tmpl
+		}
+	}
+	return "unmatched"
+}
+
+// TracingMiddleware serves every request in a span continuing the trace of
+// the traceparent header, if any. The gRPC client passes the span on to the
+// backend.
+func TracingMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route := routeTemplate(r)
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		ctx, span := tracing.Start(ctx, r.Method+" "+route,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(semconv.HTTPRequestMethodKey.String(r.Method), semconv.HTTPRoute(route), semconv.URLPath(r.URL.Path)),
+		)
+		defer span.End()
+
+		rec := &statusRecorder{ResponseWriter: w}
+		next.ServeHTTP(rec, r.WithContext(ctx))
+
+		if rec.code == 0 {
+			rec.code = http.StatusOK
+		}
+		span.SetAttributes(semconv.HTTPResponseStatusCode(rec.code))
+		if rec.code >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(rec.code))
+		}
+	})
+}
+
 func LoggerMiddleware(l logger.Logger) func(next http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -32,7 +150,11 @@ func LoggerMiddleware(l logger.Logger) func(next http.Handler) http.Handler {
 				return
 			}
 
-			requestLogger := l.CreateChildLogger(zap.String("requestID", id.String()))
+			fields := []zap.Field{zap.String("requestID", id.String())}
+			if caller, ok := auth.FromContext(r.Context()); ok {
+				fields = append(fields, caller.LogFields()...)
+			}
+			requestLogger := l.CreateChildLogger(fields...)
 
 			requestLogger.Info(r.Context(), "Request started",
 				zap.String("method", r.Method),
@@ -40,6 +162,7 @@ func LoggerMiddleware(l logger.Logger) func(next http.Handler) http.Handler {
 			)
 
 			ctx := r.Context()
+			trace.SpanFromContext(ctx).SetAttributes(attribute.String("request.id", id.String()))
 			ctx = context.WithValue(ctx, logger.RequestID, id.String())
 			ctx = context.WithValue(ctx, logger.Key, requestLogger)
 			r = r.WithContext(ctx)
@@ -51,6 +174,66 @@ func LoggerMiddleware(l logger.Logger) func(next http.Handler) http.Handler {
 	}
 }
 
+// AuthMiddleware rejects requests without valid credentials and stores the
+// identity of the caller in the request context. Paths starting with one of
+// public are served to everybody, and requests with a share token are left to
+// the backend. A nil authenticator accepts every request.
+func AuthMiddleware(authn *auth.Authenticator, l logger.Logger, public ...string) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if _, shared := grpc.ShareToken(r.Context()); shared || !authn.Enabled() {
+				next.ServeHTTP(w, r)
+				return
+			}
+			for _, prefix := range public {
+				if strings.HasPrefix(r.URL.Path, prefix) {
+					next.ServeHTTP(w, r)
+					return
+				}
+			}
+
+			id, err := authn.Authenticate(requestCredentials(r))
+			if err != nil {
+				l.Info(r.Context(), "Request rejected",
+					zap.String("method", r.Method),
+					zap.String("url", r.RequestURI),
+					zap.String("remoteAddr", r.RemoteAddr),
+					zap.Error(err),
+				)
+				challenge := "Bearer"
+				if !errors.Is(err, auth.ErrNoCredentials) {
+					challenge = `Bearer error="invalid_token"`
+				}
+				w.Header().Set("WWW-Authenticate", challenge)
+				http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(auth.NewContext(r.Context(), id)))
+		})
+	}
+}
+
+// requestCredentials collects the credentials of r: a bearer token, an API
+// key of the X-API-Key header or of an "Authorization: ApiKey" header and the
+// TLS client certificates.
+func requestCredentials(r *http.Request) auth.Credentials {
+	creds := auth.Credentials{APIKey: r.Header.Get("X-API-Key")}
+
+	scheme, value, _ := strings.Cut(r.Header.Get("Authorization"), " ")
+	switch {
+	case strings.EqualFold(scheme, "Bearer"):
+		creds.Bearer = strings.TrimSpace(value)
+	case strings.EqualFold(scheme, "ApiKey") && creds.APIKey == "":
+		creds.APIKey = strings.TrimSpace(value)
+	}
+
+	if r.TLS != nil {
+		creds.Certificates = r.TLS.PeerCertificates
+	}
+	return creds
+}
+
 func TransportFromServMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/octet-stream")
@@ -0,0 +1,179 @@
+package gateway
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/JunBSer/FileManager/internal/auth"
+	"github.com/JunBSer/FileManager/internal/metrics"
+	"github.com/JunBSer/FileManager/internal/tracing"
+	"github.com/JunBSer/FileManager/internal/transport/grpc"
+	"github.com/JunBSer/FileManager/pkg/logger"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+func TestAuthMiddleware(t *testing.T) {
+	authn, err := auth.New(auth.Config{Methods: "apikey", APIKeys: "alice=alice-secret"})
+	require.NoError(t, err)
+
+	var caller *auth.Identity
+	handler := AuthMiddleware(authn, logger.New("gw test", "debug"), "/swagger/")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		caller, _ = auth.FromContext(r.Context())
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	tests := []struct {
+		testName  string
+		path      string
+		header    http.Header
+		status    int
+		challenge string
+		subject   string
+	}{
+		{"API key header", "/api/v1/files/list", http.Header{"X-Api-Key": {"alice-secret"}}, http.StatusOK, "", "alice"},
+		{"API key authorization", "/api/v1/files/list", http.Header{"Authorization": {"ApiKey alice-secret"}}, http.StatusOK, "", "alice"},
+		{"No credentials", "/api/v1/files/list", nil, http.StatusUnauthorized, "Bearer", ""},
+		{"Wrong key", "/api/v1/files/list", http.Header{"X-Api-Key": {"guess"}}, http.StatusUnauthorized, `Bearer error="invalid_token"`, ""},
+		{"Public path", "/swagger/index.html", nil, http.StatusOK, "", ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			caller = nil
+			req := httptest.NewRequest("GET", test.path, nil)
+			for name, values := range test.header {
+				req.Header[name] = values
+			}
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, req)
+
+			assert.Equal(t, test.status, w.Code)
+			assert.Equal(t, test.challenge, w.Header().Get("WWW-Authenticate"))
+			if test.subject == "" {
+				assert.Nil(t, caller)
+				return
+			}
+			require.NotNil(t, caller)
+			assert.Equal(t, test.subject, caller.Subject)
+		})
+	}
+
+	t.Run("disabled", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		AuthMiddleware(nil, logger.New("gw test", "debug"))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		})).ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/files/list", nil))
+
+		assert.Equal(t, http.StatusNoContent, w.Code)
+	})
+}
+
+func TestShareMiddleware(t *testing.T) {
+	authn, err := auth.New(auth.Config{Methods: "apikey", APIKeys: "alice=alice-secret"})
+	require.NoError(t, err)
+
+	var token string
+	handler := ShareMiddleware("/api/v1/files/download")(AuthMiddleware(authn, logger.New("gw test", "debug"))(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token, _ = grpc.ShareToken(r.Context())
+			w.WriteHeader(http.StatusOK)
+		})))
+
+	tests := []struct {
+		testName string
+		target   string
+		status   int
+		token    string
+	}{
+		{"Shared download", "/api/v1/files/download?file_path=a.txt&share=abc", http.StatusOK, "abc"},
+		{"Download without token", "/api/v1/files/download?file_path=a.txt", http.StatusUnauthorized, ""},
+		{"Token on another path", "/api/v1/files/delete?file_path=a.txt&share=abc", http.StatusUnauthorized, ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			token = ""
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest("GET", test.target, nil))
+
+			assert.Equal(t, test.status, w.Code)
+			assert.Equal(t, test.token, token)
+		})
+	}
+}
+
+func TestMetricsMiddleware(t *testing.T) {
+	router := mux.NewRouter()
+	router.Use(MetricsMiddleware)
+	router.HandleFunc("/metrics-test/{id}", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write(append(body, body...))
+	}).Methods("POST")
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest("POST", "/metrics-test/42", strings.NewReader("abc")))
+	assert.Equal(t, http.StatusCreated, rec.Code)
+
+	scrape := httptest.NewRecorder()
+	metrics.Handler().ServeHTTP(scrape, httptest.NewRequest("GET", metrics.Path, nil))
+	text := scrape.Body.String()
+	assert.Contains(t, text, `fm_http_requests_total{code="201",method="POST",route="/metrics-test/{id}"} 1`)
+	assert.Contains(t, text, `fm_http_request_duration_seconds_count{method="POST",route="/metrics-test/{id}"} 1`)
+	assert.Contains(t, text, `fm_http_bytes_total{direction="received",route="/metrics-test/{id}"} 3`)
+	assert.Contains(t, text, `fm_http_bytes_total{direction="sent",route="/metrics-test/{id}"} 6`)
+	assert.Contains(t, text, `fm_http_requests_in_flight 0`)
+}
+
+func TestTracingMiddleware(t *testing.T) {
+	_, err := tracing.Setup(context.Background(), tracing.Config{Exporter: tracing.ExporterNone}, "gw test")
+	require.NoError(t, err)
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	otel.SetTracerProvider(provider)
+	t.Cleanup(func() { otel.SetTracerProvider(noop.NewTracerProvider()) })
+
+	const (
+		traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+		spanID  = "00f067aa0ba902b7"
+	)
+
+	var handled trace.SpanContext
+	var requestID string
+	router := mux.NewRouter()
+	router.Use(TracingMiddleware, LoggerMiddleware(logger.New("gw test", "debug")))
+	router.HandleFunc("/trace-test/{id}", func(w http.ResponseWriter, r *http.Request) {
+		handled = trace.SpanContextFromContext(r.Context())
+		requestID, _ = r.Context().Value(logger.RequestID).(string)
+		w.WriteHeader(http.StatusNotFound)
+	}).Methods("GET")
+
+	req := httptest.NewRequest("GET", "/trace-test/42", nil)
+	req.Header.Set("traceparent", "00-"+traceID+"-"+spanID+"-01")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	assert.Equal(t, traceID, handled.TraceID().String())
+	assert.NotEmpty(t, requestID)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	span := spans[0]
+	assert.Equal(t, "GET /trace-test/{id}", span.Name())
+	assert.Equal(t, handled.SpanID(), span.SpanContext().SpanID())
+	assert.Equal(t, spanID, span.Parent().SpanID().String())
+	assert.Contains(t, span.Attributes(), attribute.Int("http.response.status_code", http.StatusNotFound))
+	assert.Contains(t, span.Attributes(), attribute.String("request.id", requestID))
+}
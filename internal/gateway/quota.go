@@ -0,0 +1,77 @@
+package gateway
+
+import (
+	"github.com/JunBSer/FileManager/internal/models"
+	"github.com/JunBSer/FileManager/pkg/api/fmpb"
+	"github.com/JunBSer/FileManager/pkg/logger"
+	"go.uber.org/zap"
+	"net/http"
+)
+
+func toModelUsage(usage *fmpb.NamespaceUsage) models.NamespaceUsage {
+	return models.NamespaceUsage{
+		Namespace: usage.Namespace,
+		Bytes:     usage.Bytes,
+		Files:     usage.Files,
+		MaxBytes:  usage.MaxBytes,
+		MaxFiles:  usage.MaxFiles,
+	}
+}
+
+// ListUsage reports the usage of all namespaces
+// @Summary List namespace usage
+// @Description Returns the bytes and files stored in the root and in every top level directory with their quotas. A limit of 0 is unlimited
+// @Tags quotas
+// @Produce application/json
+// @Success 200 {array} models.NamespaceUsage "Usage of the namespaces"
+// @Failure 412 {object} models.ErrorResponse "Quotas are disabled"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /files/usage [get]
+func (h Handler) ListUsage(w http.ResponseWriter, r *http.Request) {
+	lg := logger.GetLoggerFromContext(r.Context())
+
+	res, err := h.gw.client.Quotas.ListUsage(r.Context(), &fmpb.ListUsageRequest{})
+	if err != nil {
+		WriteError(w, err)
+		lg.Error(r.Context(), "Error listing usage", zap.Error(err))
+		return
+	}
+
+	usage := make([]models.NamespaceUsage, 0, len(res.Namespaces))
+	for _, u := range res.Namespaces {
+		usage = append(usage, toModelUsage(u))
+	}
+
+	h.EncodeJSON(w, http.StatusOK, usage, r.Context())
+}
+
+// GetUsage reports the usage of one namespace
+// @Summary Get namespace usage
+// @Description Returns the bytes and files stored in a top level directory, or in the root for "/", with its quota. With recount the usage is counted again instead of being read from the usage index
+// @Tags quotas
+// @Produce application/json
+// @Param namespace query string true "Top level directory or /" example("team-a")
+// @Param recount query bool false "Count the usage again"
+// @Success 200 {object} models.NamespaceUsage "Usage of the namespace"
+// @Failure 400 {object} models.ErrorResponse "Invalid namespace"
+// @Failure 412 {object} models.ErrorResponse "Quotas are disabled"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /files/usage/namespace [get]
+func (h Handler) GetUsage(w http.ResponseWriter, r *http.Request) {
+	lg := logger.GetLoggerFromContext(r.Context())
+	namespace := r.URL.Query().Get("namespace")
+
+	recount, ok := boolParam(w, r, "recount")
+	if !ok {
+		return
+	}
+
+	res, err := h.gw.client.Quotas.GetUsage(r.Context(), &fmpb.GetUsageRequest{Namespace: namespace, Recount: recount})
+	if err != nil {
+		WriteError(w, err)
+		lg.Error(r.Context(), "Error getting usage", zap.String("namespace", namespace), zap.Error(err))
+		return
+	}
+
+	h.EncodeJSON(w, http.StatusOK, toModelUsage(res), r.Context())
+}
@@ -0,0 +1,180 @@
+package gateway
+
+import (
+	"bufio"
+	"fmt"
+	myErr "github.com/JunBSer/FileManager/internal/gateway/error"
+	"github.com/JunBSer/FileManager/pkg/api/fmpb"
+	"github.com/JunBSer/FileManager/pkg/logger"
+	"go.uber.org/zap"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"strconv"
+	"strings"
+)
+
+// maxRanges limits the number of ranges accepted in one Range header.
+const maxRanges = 64
+
+// ParseRangeHeader parses a "bytes=" Range header. Open ranges ("500-") get a
+// zero length and suffix ranges ("-500") a negative offset, as the backend
+// expects them in fmpb.ByteRange.
+func ParseRangeHeader(header string) ([]*fmpb.ByteRange, error) {
+	const prefix = "bytes="
+	if !strings.HasPrefix(header, prefix) {
+		return nil, fmt.Errorf("unsupported range unit in %q", header)
+	}
+
+	specs := strings.Split(header[len(prefix):], ",")
+	if len(specs) > maxRanges {
+		return nil, fmt.Errorf("too many ranges: %d", len(specs))
+	}
+
+	ranges := make([]*fmpb.ByteRange, 0, len(specs))
+	for _, spec := range specs {
+		spec = strings.TrimSpace(spec)
+		start, end, ok := strings.Cut(spec, "-")
+		if !ok {
+			return nil, fmt.Errorf("invalid range %q", spec)
+		}
+
+		if start == "" {
+			n, err := strconv.ParseInt(end, 10, 64)
+			if err != nil || n <= 0 {
+				return nil, fmt.Errorf("invalid suffix range %q", spec)
+			}
+			ranges = append(ranges, &fmpb.ByteRange{Offset: -n})
+			continue
+		}
+
+		offset, err := strconv.ParseInt(start, 10, 64)
+		if err != nil || offset < 0 {
+			return nil, fmt.Errorf("invalid range start %q", spec)
+		}
+
+		if end == "" {
+			ranges = append(ranges, &fmpb.ByteRange{Offset: offset})
+			continue
+		}
+
+		last, err := strconv.ParseInt(end, 10, 64)
+		if err != nil || last < offset {
+			return nil, fmt.Errorf("invalid range end %q", spec)
+		}
+		ranges = append(ranges, &fmpb.ByteRange{Offset: offset, Length: last - offset + 1})
+	}
+
+	return ranges, nil
+}
+
+func contentRange(r *fmpb.ByteRange, size int64) string {
+	return fmt.Sprintf("bytes %d-%d/%d", r.Offset, r.Offset+r.Length-1, size)
+}
+
+// ProcessRangeFile answers a range request with 206 Partial Content. A single
+// range is written as is, several ranges as multipart/byteranges.
+func (h Handler) ProcessRangeFile(w http.ResponseWriter, r *http.Request, fileName string, ranges []*fmpb.ByteRange) {
+	lg := logger.GetLoggerFromContext(r.Context())
+
+	stream, err := h.gw.client.Ranges.ReadRange(r.Context(), &fmpb.ReadRangeRequest{FileName: fileName, Ranges: ranges})
+	if err != nil {
+		WriteError(w, err)
+		lg.Error(r.Context(), "Error getting stream", zap.Error(err))
+		return
+	}
+
+	head, err := stream.Recv()
+	if err != nil {
+		WriteError(w, err)
+		lg.Error(r.Context(), "Error reading range header", zap.Error(err))
+		return
+	}
+
+	if len(head.Ranges) == 0 {
+		w.Header().Set("Content-Range", fmt.Sprintf("bytes */%d", head.FileSize))
+		http.Error(w, http.StatusText(http.StatusRequestedRangeNotSatisfiable), http.StatusRequestedRangeNotSatisfiable)
+		return
+	}
+
+	bufWriter := bufio.NewWriterSize(w, int(h.gw.maxSize)<<10)
+	defer bufWriter.Flush()
+
+	var mw *multipart.Writer
+	if len(head.Ranges) == 1 {
+		w.Header().Set("Content-Range", contentRange(head.Ranges[0], head.FileSize))
+		w.Header().Set("Content-Length", strconv.FormatInt(head.Ranges[0].Length, 10))
+	} else {
+		mw = multipart.NewWriter(bufWriter)
+		w.Header().Set("Content-Type", "multipart/byteranges; boundary="+mw.Boundary())
+	}
+	w.WriteHeader(http.StatusPartialContent)
+
+	if _, err = h.ProcessRangeParts(bufWriter, stream, mw, head); err != nil {
+		lg.Error(r.Context(), "Error processing ranges", zap.Error(err))
+		return
+	}
+
+	if mw != nil {
+		if err = mw.Close(); err != nil {
+			lg.Error(r.Context(), "Error closing multipart response", zap.Error(err))
+		}
+	}
+}
+
+// ProcessRangeParts copies range chunks to dst. If mw is not nil, every range
+// is written as its own part with a Content-Range header.
+func (h Handler) ProcessRangeParts(dst io.Writer, stream fmpb.RangeReadService_ReadRangeClient, mw *multipart.Writer, head *fmpb.RangeChunk) (int64, error) {
+	part := dst
+	current := int32(-1)
+	cnt := int64(0)
+
+	for {
+		res, err := stream.Recv()
+		if err != nil {
+			if err == io.EOF {
+				return cnt, nil
+			}
+			return cnt, myErr.ReadError{Err: err, Src: "stream"}
+		}
+
+		if mw != nil && res.RangeIndex != current {
+			if res.RangeIndex < 0 || int(res.RangeIndex) >= len(head.Ranges) {
+				return cnt, fmt.Errorf("unexpected range index %d", res.RangeIndex)
+			}
+
+			current = res.RangeIndex
+			part, err = mw.CreatePart(textproto.MIMEHeader{
+				"Content-Type":  {"application/octet-stream"},
+				"Content-Range": {contentRange(head.Ranges[current], head.FileSize)},
+			})
+			if err != nil {
+				return cnt, myErr.WriteError{Err: err, Src: "response"}
+			}
+		}
+
+		n, err := part.Write(res.Content)
+		if err != nil {
+			return cnt, myErr.WriteError{Err: err, Src: "response"}
+		}
+		cnt += int64(n)
+	}
+}
+
+// HandleRange returns the ranges of the request. Requests without a Range
+// header or with one that cannot be parsed are served in full.
+func (h Handler) HandleRange(r *http.Request) ([]*fmpb.ByteRange, bool) {
+	header := r.Header.Get("Range")
+	if header == "" {
+		return nil, false
+	}
+
+	ranges, err := ParseRangeHeader(header)
+	if err != nil {
+		logger.GetLoggerFromContext(r.Context()).Debug(r.Context(), "Ignoring Range header", zap.Error(err))
+		return nil, false
+	}
+
+	return ranges, true
+}
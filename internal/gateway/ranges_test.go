@@ -0,0 +1,43 @@
+package gateway
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRangeHeader(t *testing.T) {
+	tests := []struct {
+		testName string
+		header   string
+		expected [][2]int64
+		isErr    bool
+	}{
+		{"Closed range", "bytes=0-499", [][2]int64{{0, 500}}, false},
+		{"Open range", "bytes=500-", [][2]int64{{500, 0}}, false},
+		{"Suffix range", "bytes=-200", [][2]int64{{-200, 0}}, false},
+		{"Several ranges", "bytes=0-0, 10-19,-5", [][2]int64{{0, 1}, {10, 10}, {-5, 0}}, false},
+		{"Wrong unit", "items=0-1", nil, true},
+		{"Reversed range", "bytes=10-5", nil, true},
+		{"Empty suffix", "bytes=-0", nil, true},
+		{"Garbage", "bytes=abc", nil, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			ranges, err := ParseRangeHeader(test.header)
+			if test.isErr {
+				assert.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Len(t, ranges, len(test.expected))
+			for i, r := range ranges {
+				assert.Equal(t, test.expected[i][0], r.Offset)
+				assert.Equal(t, test.expected[i][1], r.Length)
+			}
+		})
+	}
+}
@@ -9,9 +9,12 @@ import (
 
 func (h Handler) SetupRoutes(ctx context.Context, r *mux.Router) {
 	r.PathPrefix("/swagger/").Handler(httpSwagger.WrapHandler)
+	r.HandleFunc(HealthzPath, h.Healthz).Methods("GET")
+	r.HandleFunc(ReadyzPath, h.Readyz).Methods("GET")
 
 	filesRouter := r.PathPrefix("/api/v1/files").Subrouter()
-	filesRouter.HandleFunc("/upload", h.Upload).Methods("POST")
+	filesRouter.HandleFunc("/upload", h.Upload).Methods("POST", "PUT")
+	filesRouter.HandleFunc("/upload/batch", h.UploadBatch).Methods("POST")
 	filesRouter.Handle("/download", http.HandlerFunc(h.Download)).Methods("GET")
 	filesRouter.Handle("/read", http.HandlerFunc(h.Read)).Methods("GET")
 	filesRouter.HandleFunc("/append", h.Append).Methods("POST")
@@ -19,4 +22,53 @@ func (h Handler) SetupRoutes(ctx context.Context, r *mux.Router) {
 	filesRouter.HandleFunc("/delete", h.Delete).Methods("DELETE")
 	filesRouter.HandleFunc("/move", h.MoveFile).Methods("POST")
 	filesRouter.HandleFunc("/list", h.ListDir).Methods("GET")
+	filesRouter.HandleFunc("/stat", h.Stat).Methods("GET")
+	filesRouter.HandleFunc("/watch", h.Watch).Methods("GET")
+
+	filesRouter.HandleFunc("/uploads", h.CreateUploadSession).Methods("POST")
+	filesRouter.HandleFunc("/uploads/{upload_id}", h.GetUploadSession).Methods("GET")
+	filesRouter.HandleFunc("/uploads/{upload_id}", h.UploadSessionChunk).Methods("PUT")
+	filesRouter.HandleFunc("/uploads/{upload_id}", h.AbortUploadSession).Methods("DELETE")
+	filesRouter.HandleFunc("/uploads/{upload_id}/finalize", h.FinalizeUploadSession).Methods("POST")
+
+	filesRouter.HandleFunc("/versions", h.ListVersions).Methods("GET")
+	filesRouter.HandleFunc("/versions/{version_id}", h.DownloadVersion).Methods("GET")
+	filesRouter.HandleFunc("/versions/{version_id}/restore", h.RestoreVersion).Methods("POST")
+	filesRouter.HandleFunc("/retention", h.GetRetention).Methods("GET")
+	filesRouter.HandleFunc("/retention", h.SetRetention).Methods("PUT")
+
+	filesRouter.HandleFunc("/trash", h.ListTrash).Methods("GET")
+	filesRouter.HandleFunc("/trash", h.EmptyTrash).Methods("DELETE")
+	filesRouter.HandleFunc("/trash/{entry_id}/restore", h.RestoreTrash).Methods("POST")
+
+	filesRouter.HandleFunc("/directories", h.CreateDirectory).Methods("POST")
+	filesRouter.HandleFunc("/directories", h.DeleteDirectory).Methods("DELETE")
+	filesRouter.HandleFunc("/paths/copy", h.CopyPath).Methods("POST")
+	filesRouter.HandleFunc("/paths/move", h.MovePath).Methods("POST")
+
+	filesRouter.HandleFunc("/usage", h.ListUsage).Methods("GET")
+	filesRouter.HandleFunc("/usage/namespace", h.GetUsage).Methods("GET")
+
+	filesRouter.HandleFunc("/acl", h.ListGrants).Methods("GET")
+	filesRouter.HandleFunc("/acl", h.SetGrant).Methods("PUT")
+	filesRouter.HandleFunc("/acl", h.RemoveGrant).Methods("DELETE")
+	filesRouter.HandleFunc("/acl/access", h.GetAccess).Methods("GET")
+	filesRouter.HandleFunc("/acl/roles", h.ListRoles).Methods("GET")
+
+	shareRouter := r.PathPrefix("/api/v1/share").Subrouter()
+	shareRouter.HandleFunc("", h.CreateShare).Methods("POST")
+	shareRouter.HandleFunc("", h.ListShares).Methods("GET")
+	shareRouter.HandleFunc("/{share_id}", h.RevokeShare).Methods("DELETE")
+
+	webhookRouter := r.PathPrefix("/api/v1/webhooks").Subrouter()
+	webhookRouter.HandleFunc("", h.CreateWebhook).Methods("POST")
+	webhookRouter.HandleFunc("", h.ListWebhooks).Methods("GET")
+	webhookRouter.HandleFunc("/deliveries", h.ListDeliveries).Methods("GET")
+	webhookRouter.HandleFunc("/dead-letters", h.ListDeadLetters).Methods("GET")
+	webhookRouter.HandleFunc("/dead-letters/{delivery_id}/redeliver", h.Redeliver).Methods("POST")
+	webhookRouter.HandleFunc("/{webhook_id}", h.DeleteWebhook).Methods("DELETE")
+
+	auditRouter := r.PathPrefix("/api/v1/audit").Subrouter()
+	auditRouter.HandleFunc("", h.QueryAudit).Methods("GET")
+	auditRouter.HandleFunc("/verify", h.VerifyAudit).Methods("GET")
 }
@@ -0,0 +1,168 @@
+package gateway
+
+import (
+	"github.com/JunBSer/FileManager/internal/models"
+	"github.com/JunBSer/FileManager/internal/transport/grpc"
+	"github.com/JunBSer/FileManager/pkg/api/fmpb"
+	"github.com/JunBSer/FileManager/pkg/logger"
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// ShareParam is the query parameter share tokens are passed in.
+const ShareParam = "share"
+
+// ShareMiddleware takes the share token of requests to one of paths. Such
+// requests are authorized by the backend with the token instead of
+// credentials, so AuthMiddleware lets them through.
+func ShareMiddleware(paths ...string) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token := r.URL.Query().Get(ShareParam)
+			for _, path := range paths {
+				if token != "" && r.URL.Path == path {
+					next.ServeHTTP(w, r.WithContext(grpc.WithShareToken(r.Context(), token)))
+					return
+				}
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// shareURL returns the URL the share can be used with through this gateway.
+func shareURL(r *http.Request, share *fmpb.Share) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+
+	endpoint := "/api/v1/files/download"
+	if share.Operation == "upload" {
+		endpoint = "/api/v1/files/upload"
+	}
+	query := url.Values{"file_path": {share.Path}, ShareParam: {share.Token}}
+	return scheme + "://" + r.Host + endpoint + "?" + query.Encode()
+}
+
+func toModelShare(share *fmpb.Share) models.Share {
+	return models.Share{
+		ID:        share.Id,
+		Path:      share.Path,
+		Operation: share.Operation,
+		ExpiresAt: share.ExpiresAt,
+		MaxUses:   share.MaxUses,
+		MaxBytes:  share.MaxBytes,
+		Uses:      share.Uses,
+		Bytes:     share.Bytes,
+		CreatedBy: share.CreatedBy,
+		CreatedAt: share.CreatedAt,
+	}
+}
+
+// CreateShare creates a share link
+// @Summary Create a share link
+// @Description Issues a signed link that allows a download of a file, or an upload to it, without credentials until it expires. Downloads are GET requests to the link; uploads POST the file as multipart form field "file" to it. The caller needs the permission the link grants
+// @Tags sharing
+// @Produce application/json
+// @Param path query string true "Path to the file"
+// @Param operation query string true "download or upload" Enums(download, upload)
+// @Param ttl query int false "Lifetime of the link in seconds, a day by default"
+// @Param max_uses query int false "Number of times the link may be used, 0 for no limit"
+// @Param max_bytes query int false "Number of bytes that may be transferred with the link, 0 for no limit"
+// @Success 201 {object} models.Share "Share with its link"
+// @Failure 400 {object} models.ErrorResponse "Invalid request"
+// @Failure 403 {object} models.ErrorResponse "Permission denied"
+// @Failure 404 {object} models.ErrorResponse "File not found"
+// @Failure 412 {object} models.ErrorResponse "Sharing is disabled"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /share [post]
+func (h Handler) CreateShare(w http.ResponseWriter, r *http.Request) {
+	lg := logger.GetLoggerFromContext(r.Context())
+
+	path, err := h.HandleFilePath("path", w, r)
+	if err != nil {
+		lg.Debug(r.Context(), "Error handling file path", zap.String("path", path))
+		return
+	}
+
+	req := &fmpb.CreateShareRequest{Path: path, Operation: r.URL.Query().Get("operation")}
+	for name, value := range map[string]*int64{"ttl": &req.TtlSeconds, "max_uses": &req.MaxUses, "max_bytes": &req.MaxBytes} {
+		raw := r.URL.Query().Get(name)
+		if raw == "" {
+			continue
+		}
+		if *value, err = strconv.ParseInt(raw, 10, 64); err != nil || *value < 0 {
+			http.Error(w, name+" must be a non-negative integer", http.StatusBadRequest)
+			return
+		}
+	}
+
+	res, err := h.gw.client.Shares.CreateShare(r.Context(), req)
+	if err != nil {
+		WriteError(w, err)
+		lg.Error(r.Context(), "Error creating share", zap.String("path", path), zap.Error(err))
+		return
+	}
+
+	share := toModelShare(res)
+	share.URL = shareURL(r, res)
+	h.EncodeJSON(w, http.StatusCreated, share, r.Context())
+}
+
+// ListShares lists the share links
+// @Summary List share links
+// @Description Returns the links that have not expired, the ones expiring first first. Callers see the links they created; admins see all
+// @Tags sharing
+// @Produce application/json
+// @Success 200 {array} models.Share "Shares"
+// @Failure 412 {object} models.ErrorResponse "Sharing is disabled"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /share [get]
+func (h Handler) ListShares(w http.ResponseWriter, r *http.Request) {
+	lg := logger.GetLoggerFromContext(r.Context())
+
+	res, err := h.gw.client.Shares.ListShares(r.Context(), &fmpb.ListSharesRequest{})
+	if err != nil {
+		WriteError(w, err)
+		lg.Error(r.Context(), "Error listing shares", zap.Error(err))
+		return
+	}
+
+	shares := make([]models.Share, 0, len(res.Shares))
+	for _, share := range res.Shares {
+		shares = append(shares, toModelShare(share))
+	}
+
+	h.EncodeJSON(w, http.StatusOK, shares, r.Context())
+}
+
+// RevokeShare revokes a share link
+// @Summary Revoke a share link
+// @Description Makes a link unusable before it expires
+// @Tags sharing
+// @Param share_id path string true "Share ID"
+// @Success 204 "Share revoked"
+// @Failure 403 {object} models.ErrorResponse "Share belongs to another caller"
+// @Failure 404 {object} models.ErrorResponse "Share not found"
+// @Failure 412 {object} models.ErrorResponse "Sharing is disabled"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /share/{share_id} [delete]
+func (h Handler) RevokeShare(w http.ResponseWriter, r *http.Request) {
+	lg := logger.GetLoggerFromContext(r.Context())
+
+	id := strings.TrimSpace(mux.Vars(r)["share_id"])
+
+	_, err := h.gw.client.Shares.RevokeShare(r.Context(), &fmpb.RevokeShareRequest{Id: id})
+	if err != nil {
+		WriteError(w, err)
+		lg.Error(r.Context(), "Error revoking share", zap.String("id", id), zap.Error(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,53 @@
+package gateway
+
+import (
+	"encoding/json"
+	"github.com/JunBSer/FileManager/internal/models"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"net/http"
+)
+
+// HTTPStatus maps an error returned by the gRPC backend to an HTTP status code.
+func HTTPStatus(err error) int {
+	switch status.Code(err) {
+	case codes.OK:
+		return http.StatusOK
+	case codes.InvalidArgument:
+		return http.StatusBadRequest
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.AlreadyExists, codes.Aborted:
+		return http.StatusConflict
+	case codes.FailedPrecondition, codes.OutOfRange:
+		return http.StatusPreconditionFailed
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized
+	case codes.ResourceExhausted:
+		return http.StatusInsufficientStorage
+	case codes.Unavailable:
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// WriteError answers with the HTTP status of an error returned by the gRPC
+// backend and a models.ErrorResponse body. Client errors carry the message of
+// the backend, server errors only the status text.
+func WriteError(w http.ResponseWriter, err error) {
+	code := HTTPStatus(err)
+
+	message := http.StatusText(code)
+	if st, ok := status.FromError(err); ok && code < http.StatusInternalServerError {
+		message = st.Message()
+	}
+
+	w.Header().Del("Content-Length")
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(models.ErrorResponse{Code: code, Message: message})
+}
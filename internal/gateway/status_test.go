@@ -0,0 +1,46 @@
+package gateway
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/JunBSer/FileManager/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestWriteError(t *testing.T) {
+	tests := []struct {
+		testName string
+		err      error
+		code     int
+		message  string
+	}{
+		{"Permission denied", status.Error(codes.PermissionDenied, "permission denied: bob may not write docs/a.txt"),
+			http.StatusForbidden, "permission denied: bob may not write docs/a.txt"},
+		{"Not found", status.Error(codes.NotFound, "grant not found"), http.StatusNotFound, "grant not found"},
+		{"Internal error hides details", status.Error(codes.Internal, "disk on fire"),
+			http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError)},
+		{"Plain error", errors.New("broken"), http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError)},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			WriteError(w, test.err)
+
+			assert.Equal(t, test.code, w.Code)
+			assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+
+			var res models.ErrorResponse
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+			assert.Equal(t, test.code, res.Code)
+			assert.Equal(t, test.message, res.Message)
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package gateway
+
+import (
+	"github.com/JunBSer/FileManager/internal/models"
+	"github.com/JunBSer/FileManager/pkg/api/fmpb"
+	"github.com/JunBSer/FileManager/pkg/logger"
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+	"net/http"
+)
+
+func toModelTrashEntry(entry *fmpb.TrashEntry) models.TrashEntry {
+	return models.TrashEntry{
+		EntryID:   entry.EntryId,
+		FileName:  entry.FileName,
+		Size:      entry.Size,
+		DeletedAt: entry.DeletedAt,
+	}
+}
+
+// ListTrash lists deleted files
+// @Summary List the trash
+// @Description Returns the files deleted from under a path, most recently deleted first. Without a path the whole trash is listed
+// @Tags trash
+// @Produce application/json
+// @Param path query string false "Directory or file path"
+// @Success 200 {array} models.TrashEntry "Deleted files"
+// @Failure 400 {object} models.ErrorResponse "Invalid request"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /files/trash [get]
+func (h Handler) ListTrash(w http.ResponseWriter, r *http.Request) {
+	lg := logger.GetLoggerFromContext(r.Context())
+	path := r.URL.Query().Get("path")
+
+	res, err := h.gw.client.Trash.ListTrash(r.Context(), &fmpb.ListTrashRequest{Path: path})
+	if err != nil {
+		WriteError(w, err)
+		lg.Error(r.Context(), "Error listing trash", zap.String("path", path), zap.Error(err))
+		return
+	}
+
+	entries := make([]models.TrashEntry, 0, len(res.Entries))
+	for _, entry := range res.Entries {
+		entries = append(entries, toModelTrashEntry(entry))
+	}
+
+	h.EncodeJSON(w, http.StatusOK, entries, r.Context())
+}
+
+// RestoreTrash restores a deleted file
+// @Summary Restore from the trash
+// @Description Moves a deleted file back to its original path or to the given destination. An existing file is never replaced
+// @Tags trash
+// @Produce application/json
+// @Param entry_id path string true "Trash entry ID"
+// @Param destination query string false "Path to restore to"
+// @Success 200 {object} models.TrashEntry "Restored file"
+// @Failure 404 {object} models.ErrorResponse "Entry not found"
+// @Failure 409 {object} models.ErrorResponse "Target already exists"
+// @Failure 507 {object} models.ErrorResponse "Quota exceeded"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /files/trash/{entry_id}/restore [post]
+func (h Handler) RestoreTrash(w http.ResponseWriter, r *http.Request) {
+	lg := logger.GetLoggerFromContext(r.Context())
+	entryID := mux.Vars(r)["entry_id"]
+
+	res, err := h.gw.client.Trash.Restore(r.Context(),
+		&fmpb.RestoreRequest{EntryId: entryID, Destination: r.URL.Query().Get("destination")})
+	if err != nil {
+		WriteError(w, err)
+		lg.Error(r.Context(), "Error restoring from trash", zap.String("entryID", entryID), zap.Error(err))
+		return
+	}
+
+	h.EncodeJSON(w, http.StatusOK, toModelTrashEntry(res), r.Context())
+}
+
+// EmptyTrash permanently deletes trashed files
+// @Summary Empty the trash
+// @Description Permanently deletes the files deleted from under a path. Without a path the whole trash is emptied
+// @Tags trash
+// @Produce application/json
+// @Param path query string false "Directory or file path"
+// @Success 200 {object} models.EmptyTrashResult "Number of deleted entries"
+// @Failure 400 {object} models.ErrorResponse "Invalid request"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /files/trash [delete]
+func (h Handler) EmptyTrash(w http.ResponseWriter, r *http.Request) {
+	lg := logger.GetLoggerFromContext(r.Context())
+	path := r.URL.Query().Get("path")
+
+	res, err := h.gw.client.Trash.EmptyTrash(r.Context(), &fmpb.EmptyTrashRequest{Path: path})
+	if err != nil {
+		WriteError(w, err)
+		lg.Error(r.Context(), "Error emptying trash", zap.String("path", path), zap.Error(err))
+		return
+	}
+
+	h.EncodeJSON(w, http.StatusOK, models.EmptyTrashResult{Removed: res.Removed}, r.Context())
+}
@@ -0,0 +1,224 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/JunBSer/FileManager/internal/models"
+	"github.com/JunBSer/FileManager/pkg/api/fmpb"
+	"github.com/JunBSer/FileManager/pkg/logger"
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+	"hash/crc32"
+	"io"
+	"net/http"
+	"strconv"
+)
+
+func (h Handler) EncodeUploadSession(w http.ResponseWriter, code int, session *fmpb.UploadSession, ctx context.Context) {
+	lg := logger.GetLoggerFromContext(ctx)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	err := json.NewEncoder(w).Encode(models.UploadSession{
+		UploadID:        session.UploadId,
+		FileName:        session.FileName,
+		CommittedOffset: session.CommittedOffset,
+		TotalSize:       session.TotalSize,
+		CreatedAt:       session.CreatedAt,
+		SHA256:          session.Sha256,
+	})
+	if err != nil {
+		lg.Error(ctx, "Error encoding JSON response", zap.String("uploadID", session.UploadId), zap.Error(err))
+	}
+}
+
+var castagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+// ProcessUploadChunks sends body to the session in chunks that carry their
+// CRC32C, so the backend rejects data damaged on the way.
+func (h Handler) ProcessUploadChunks(uploadID string, offset int64, body io.Reader, stream fmpb.UploadSessionService_UploadChunksClient) error {
+	buf := make([]byte, h.gw.maxSize<<10)
+
+	for {
+		bytesRead, err := io.ReadFull(body, buf)
+		if bytesRead > 0 {
+			crc := crc32.Checksum(buf[:bytesRead], castagnoli)
+			chunk := fmpb.UploadChunk{UploadId: uploadID, Offset: offset, Content: buf[:bytesRead], Crc32C: &crc}
+			if sendErr := stream.Send(&chunk); sendErr != nil {
+				return sendErr
+			}
+			offset += int64(bytesRead)
+		}
+		if err != nil {
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				return nil
+			}
+			return err
+		}
+	}
+}
+
+// CreateUploadSession starts a resumable upload
+// @Summary Create an upload session
+// @Description Creates a resumable upload session. Chunks are staged until the session is finalized
+// @Tags uploading
+// @Produce application/json
+// @Param file_path query string true "Path to save the file" example("/builds/artifact.tar.gz")
+// @Param size query int false "Expected size of the file in bytes"
+// @Param sha256 query string false "Expected SHA-256 of the file as hex, checked on finalize"
+// @Success 201 {object} models.UploadSession "Created session"
+// @Failure 400 {object} models.ErrorResponse "Invalid request"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /files/uploads [post]
+func (h Handler) CreateUploadSession(w http.ResponseWriter, r *http.Request) {
+	lg := logger.GetLoggerFromContext(r.Context())
+
+	fileName, err := h.HandleFilePath("file_path", w, r)
+	if err != nil {
+		lg.Debug(r.Context(), "Error handling file path", zap.String("fileName", fileName))
+		return
+	}
+
+	var size int64
+	if rawSize := r.URL.Query().Get("size"); rawSize != "" {
+		size, err = strconv.ParseInt(rawSize, 10, 64)
+		if err != nil || size < 0 {
+			http.Error(w, "size must be a non-negative integer", http.StatusBadRequest)
+			return
+		}
+	}
+
+	res, err := h.gw.client.Sessions.CreateUploadSession(r.Context(),
+		&fmpb.CreateUploadSessionRequest{FileName: fileName, TotalSize: size, Sha256: r.URL.Query().Get("sha256")})
+	if err != nil {
+		WriteError(w, err)
+		lg.Error(r.Context(), "Error creating upload session", zap.Error(err))
+		return
+	}
+
+	h.EncodeUploadSession(w, http.StatusCreated, res, r.Context())
+}
+
+// GetUploadSession returns the state of an upload session
+// @Summary Get an upload session
+// @Description Returns the committed offset of an upload session, used to resume after a disconnect
+// @Tags uploading
+// @Produce application/json
+// @Param upload_id path string true "Upload session ID"
+// @Success 200 {object} models.UploadSession "Session state"
+// @Failure 404 {object} models.ErrorResponse "Session not found"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /files/uploads/{upload_id} [get]
+func (h Handler) GetUploadSession(w http.ResponseWriter, r *http.Request) {
+	lg := logger.GetLoggerFromContext(r.Context())
+	uploadID := mux.Vars(r)["upload_id"]
+
+	res, err := h.gw.client.Sessions.GetUploadSession(r.Context(), &fmpb.UploadSessionRequest{UploadId: uploadID})
+	if err != nil {
+		WriteError(w, err)
+		lg.Error(r.Context(), "Error getting upload session", zap.String("uploadID", uploadID), zap.Error(err))
+		return
+	}
+
+	h.EncodeUploadSession(w, http.StatusOK, res, r.Context())
+}
+
+// UploadSessionChunk sends data to an upload session
+// @Summary Upload a chunk
+// @Description Streams the request body into the session starting at the given offset
+// @Tags uploading
+// @Accept application/octet-stream
+// @Produce application/json
+// @Param upload_id path string true "Upload session ID"
+// @Param offset query int true "Offset of the first byte of the body"
+// @Success 200 {object} models.UploadSession "Session state after the chunk"
+// @Failure 400 {object} models.ErrorResponse "Invalid request"
+// @Failure 404 {object} models.ErrorResponse "Session not found"
+// @Failure 409 {object} models.ErrorResponse "Session is used by another request"
+// @Failure 412 {object} models.ErrorResponse "Offset does not match the committed offset"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /files/uploads/{upload_id} [put]
+func (h Handler) UploadSessionChunk(w http.ResponseWriter, r *http.Request) {
+	lg := logger.GetLoggerFromContext(r.Context())
+	uploadID := mux.Vars(r)["upload_id"]
+	defer r.Body.Close()
+
+	offset, err := strconv.ParseInt(r.URL.Query().Get("offset"), 10, 64)
+	if err != nil || offset < 0 {
+		http.Error(w, "offset parameter must be a non-negative integer", http.StatusBadRequest)
+		return
+	}
+
+	stream, err := h.gw.client.Sessions.UploadChunks(r.Context())
+	if err != nil {
+		WriteError(w, err)
+		lg.Error(r.Context(), "Error getting stream", zap.Error(err))
+		return
+	}
+
+	// Whatever reached the backend stays committed, so a broken body is only
+	// logged and the client learns the committed offset from the response.
+	if err = h.ProcessUploadChunks(uploadID, offset, r.Body, stream); err != nil {
+		lg.Error(r.Context(), "Error processing chunk", zap.String("uploadID", uploadID), zap.Error(err))
+	}
+
+	res, err := stream.CloseAndRecv()
+	if err != nil {
+		WriteError(w, err)
+		lg.Error(r.Context(), "Error receiving response", zap.String("uploadID", uploadID), zap.Error(err))
+		return
+	}
+
+	h.EncodeUploadSession(w, http.StatusOK, res, r.Context())
+}
+
+// FinalizeUploadSession commits an upload session
+// @Summary Finalize an upload session
+// @Description Moves the staged data to the target path and closes the session
+// @Tags uploading
+// @Produce application/json
+// @Param upload_id path string true "Upload session ID"
+// @Success 200 {object} models.UploadSession "Finalized session"
+// @Failure 404 {object} models.ErrorResponse "Session not found"
+// @Failure 400 {object} models.ErrorResponse "Uploaded data does not match the declared SHA-256"
+// @Failure 412 {object} models.ErrorResponse "Uploaded size does not match the declared size"
+// @Failure 507 {object} models.ErrorResponse "Quota exceeded"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /files/uploads/{upload_id}/finalize [post]
+func (h Handler) FinalizeUploadSession(w http.ResponseWriter, r *http.Request) {
+	lg := logger.GetLoggerFromContext(r.Context())
+	uploadID := mux.Vars(r)["upload_id"]
+
+	res, err := h.gw.client.Sessions.FinalizeUploadSession(r.Context(), &fmpb.UploadSessionRequest{UploadId: uploadID})
+	if err != nil {
+		WriteError(w, err)
+		lg.Error(r.Context(), "Error finalizing upload session", zap.String("uploadID", uploadID), zap.Error(err))
+		return
+	}
+
+	h.EncodeUploadSession(w, http.StatusOK, res, r.Context())
+}
+
+// AbortUploadSession drops an upload session
+// @Summary Abort an upload session
+// @Description Deletes the staged data of an upload session
+// @Tags uploading
+// @Produce application/json
+// @Param upload_id path string true "Upload session ID"
+// @Success 200 {object} models.UploadSession "Aborted session"
+// @Failure 404 {object} models.ErrorResponse "Session not found"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /files/uploads/{upload_id} [delete]
+func (h Handler) AbortUploadSession(w http.ResponseWriter, r *http.Request) {
+	lg := logger.GetLoggerFromContext(r.Context())
+	uploadID := mux.Vars(r)["upload_id"]
+
+	res, err := h.gw.client.Sessions.AbortUploadSession(r.Context(), &fmpb.UploadSessionRequest{UploadId: uploadID})
+	if err != nil {
+		WriteError(w, err)
+		lg.Error(r.Context(), "Error aborting upload session", zap.String("uploadID", uploadID), zap.Error(err))
+		return
+	}
+
+	h.EncodeUploadSession(w, http.StatusOK, res, r.Context())
+}
@@ -0,0 +1,240 @@
+package gateway
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"github.com/JunBSer/FileManager/internal/models"
+	"github.com/JunBSer/FileManager/pkg/api/fmpb"
+	"github.com/JunBSer/FileManager/pkg/logger"
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+	"io"
+	"net/http"
+	"strconv"
+)
+
+func toModelVersion(version *fmpb.FileVersion) models.FileVersion {
+	return models.FileVersion{
+		VersionID: version.VersionId,
+		FileName:  version.FileName,
+		Size:      version.Size,
+		CreatedAt: version.CreatedAt,
+		Reason:    version.Reason,
+	}
+}
+
+func (h Handler) EncodeJSON(w http.ResponseWriter, code int, v any, ctx context.Context) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		logger.GetLoggerFromContext(ctx).Error(ctx, "Error encoding JSON response", zap.Error(err))
+	}
+}
+
+// ListVersions lists the previous contents of a file
+// @Summary List file versions
+// @Description Returns the kept versions of a file, newest first
+// @Tags versions
+// @Produce application/json
+// @Param file_path query string true "Path to the file"
+// @Success 200 {array} models.FileVersion "Versions of the file"
+// @Failure 400 {object} models.ErrorResponse "Invalid request"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /files/versions [get]
+func (h Handler) ListVersions(w http.ResponseWriter, r *http.Request) {
+	lg := logger.GetLoggerFromContext(r.Context())
+
+	fileName, err := h.HandleFilePath("file_path", w, r)
+	if err != nil {
+		lg.Debug(r.Context(), "Error handling file path", zap.String("fileName", fileName))
+		return
+	}
+
+	res, err := h.gw.client.Versions.ListVersions(r.Context(), &fmpb.ListVersionsRequest{FileName: fileName})
+	if err != nil {
+		WriteError(w, err)
+		lg.Error(r.Context(), "Error listing versions", zap.String("fileName", fileName), zap.Error(err))
+		return
+	}
+
+	versions := make([]models.FileVersion, 0, len(res.Versions))
+	for _, version := range res.Versions {
+		versions = append(versions, toModelVersion(version))
+	}
+
+	h.EncodeJSON(w, http.StatusOK, versions, r.Context())
+}
+
+// DownloadVersion retrieves a previous content of a file
+// @Summary Download a file version
+// @Description Streams the content of one version of a file
+// @Tags versions
+// @Produce application/octet-stream
+// @Param version_id path string true "Version ID"
+// @Param file_path query string true "Path to the file"
+// @Success 200 {file} file "Content of the version"
+// @Failure 404 {object} models.ErrorResponse "Version not found"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /files/versions/{version_id} [get]
+func (h Handler) DownloadVersion(w http.ResponseWriter, r *http.Request) {
+	lg := logger.GetLoggerFromContext(r.Context())
+	versionID := mux.Vars(r)["version_id"]
+
+	fileName, err := h.HandleFilePath("file_path", w, r)
+	if err != nil {
+		lg.Debug(r.Context(), "Error handling file path", zap.String("fileName", fileName))
+		return
+	}
+
+	stream, err := h.gw.client.Versions.DownloadVersion(r.Context(),
+		&fmpb.VersionRequest{FileName: fileName, VersionId: versionID})
+	if err != nil {
+		WriteError(w, err)
+		lg.Error(r.Context(), "Error getting stream", zap.Error(err))
+		return
+	}
+
+	// The first message tells whether the version exists before any byte of
+	// the response is written.
+	first, err := stream.Recv()
+	if err != nil && err != io.EOF {
+		WriteError(w, err)
+		lg.Error(r.Context(), "Error downloading version", zap.String("versionID", versionID), zap.Error(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Disposition", "attachment; filename*=UTF-8''"+fileName)
+
+	bufWriter := bufio.NewWriterSize(w, int(h.gw.maxSize)<<10)
+	defer bufWriter.Flush()
+
+	for chunk := first; err == nil; chunk, err = stream.Recv() {
+		if _, err = bufWriter.Write(chunk.Content); err != nil {
+			lg.Error(r.Context(), "Error writing response", zap.Error(err))
+			return
+		}
+	}
+	if err != io.EOF {
+		lg.Error(r.Context(), "Error downloading version", zap.String("versionID", versionID), zap.Error(err))
+	}
+}
+
+// RestoreVersion makes a version the current content of a file
+// @Summary Restore a file version
+// @Description Replaces the file with one of its versions. The replaced content is kept as a new version
+// @Tags versions
+// @Produce application/json
+// @Param version_id path string true "Version ID"
+// @Param file_path query string true "Path to the file"
+// @Success 200 {object} models.FileVersion "Restored version"
+// @Failure 404 {object} models.ErrorResponse "Version not found"
+// @Failure 507 {object} models.ErrorResponse "Quota exceeded"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /files/versions/{version_id}/restore [post]
+func (h Handler) RestoreVersion(w http.ResponseWriter, r *http.Request) {
+	lg := logger.GetLoggerFromContext(r.Context())
+	versionID := mux.Vars(r)["version_id"]
+
+	fileName, err := h.HandleFilePath("file_path", w, r)
+	if err != nil {
+		lg.Debug(r.Context(), "Error handling file path", zap.String("fileName", fileName))
+		return
+	}
+
+	res, err := h.gw.client.Versions.RestoreVersion(r.Context(),
+		&fmpb.VersionRequest{FileName: fileName, VersionId: versionID})
+	if err != nil {
+		WriteError(w, err)
+		lg.Error(r.Context(), "Error restoring version", zap.String("versionID", versionID), zap.Error(err))
+		return
+	}
+
+	h.EncodeJSON(w, http.StatusOK, toModelVersion(res), r.Context())
+}
+
+// GetRetention returns the retention rule of a directory
+// @Summary Get a retention rule
+// @Description Returns the rule that limits the versions kept for the files of a directory, inherited from the nearest parent with a rule
+// @Tags versions
+// @Produce application/json
+// @Param path query string true "Directory path"
+// @Success 200 {object} models.RetentionRule "Effective rule"
+// @Failure 400 {object} models.ErrorResponse "Invalid request"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /files/retention [get]
+func (h Handler) GetRetention(w http.ResponseWriter, r *http.Request) {
+	lg := logger.GetLoggerFromContext(r.Context())
+
+	dirPath, err := h.HandleFilePath("path", w, r)
+	if err != nil {
+		lg.Debug(r.Context(), "Error handling file path", zap.String("path", dirPath))
+		return
+	}
+
+	res, err := h.gw.client.Versions.GetRetention(r.Context(), &fmpb.RetentionRequest{Path: dirPath})
+	if err != nil {
+		WriteError(w, err)
+		lg.Error(r.Context(), "Error getting retention rule", zap.String("path", dirPath), zap.Error(err))
+		return
+	}
+
+	h.EncodeJSON(w, http.StatusOK, models.RetentionRule{
+		Path:          res.Path,
+		KeepVersions:  res.KeepVersions,
+		MaxAgeSeconds: res.MaxAgeSeconds,
+	}, r.Context())
+}
+
+// SetRetention sets the retention rule of a directory
+// @Summary Set a retention rule
+// @Description Limits the versions kept for the files of a directory and its subdirectories. Without limits the directory inherits the rule of its parent again
+// @Tags versions
+// @Produce application/json
+// @Param path query string true "Directory path"
+// @Param keep query int false "Number of versions to keep per file, 0 for no limit"
+// @Param max_age query int false "Maximum age of versions in seconds, 0 for no limit"
+// @Success 200 {object} models.RetentionRule "Effective rule"
+// @Failure 400 {object} models.ErrorResponse "Invalid request"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /files/retention [put]
+func (h Handler) SetRetention(w http.ResponseWriter, r *http.Request) {
+	lg := logger.GetLoggerFromContext(r.Context())
+
+	dirPath, err := h.HandleFilePath("path", w, r)
+	if err != nil {
+		lg.Debug(r.Context(), "Error handling file path", zap.String("path", dirPath))
+		return
+	}
+
+	var keep, maxAge int64
+	if raw := r.URL.Query().Get("keep"); raw != "" {
+		keep, err = strconv.ParseInt(raw, 10, 32)
+		if err != nil || keep < 0 {
+			http.Error(w, "keep must be a non-negative integer", http.StatusBadRequest)
+			return
+		}
+	}
+	if raw := r.URL.Query().Get("max_age"); raw != "" {
+		maxAge, err = strconv.ParseInt(raw, 10, 64)
+		if err != nil || maxAge < 0 {
+			http.Error(w, "max_age must be a non-negative integer", http.StatusBadRequest)
+			return
+		}
+	}
+
+	res, err := h.gw.client.Versions.SetRetention(r.Context(),
+		&fmpb.RetentionRule{Path: dirPath, KeepVersions: int32(keep), MaxAgeSeconds: maxAge})
+	if err != nil {
+		WriteError(w, err)
+		lg.Error(r.Context(), "Error setting retention rule", zap.String("path", dirPath), zap.Error(err))
+		return
+	}
+
+	h.EncodeJSON(w, http.StatusOK, models.RetentionRule{
+		Path:          res.Path,
+		KeepVersions:  res.KeepVersions,
+		MaxAgeSeconds: res.MaxAgeSeconds,
+	}, r.Context())
+}
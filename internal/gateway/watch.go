@@ -0,0 +1,161 @@
+package gateway
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/JunBSer/FileManager/internal/models"
+	"github.com/JunBSer/FileManager/pkg/api/fmpb"
+	"github.com/JunBSer/FileManager/pkg/logger"
+	"go.uber.org/zap"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// sseKeepAlive is how often an idle event stream gets a comment, so that
+// proxies do not close it.
+const sseKeepAlive = 30 * time.Second
+
+func toModelWatchEvent(event *fmpb.WatchEvent) models.WatchEvent {
+	return models.WatchEvent{
+		Seq:     event.Seq,
+		Type:    event.Type,
+		Path:    event.Path,
+		OldPath: event.OldPath,
+		IsDir:   event.IsDir,
+		Time:    event.Time,
+	}
+}
+
+// watchSince returns the sequence number a watch resumes after: the
+// Last-Event-ID header of a reconnecting EventSource, or the since
+// parameter.
+func watchSince(w http.ResponseWriter, r *http.Request) (uint64, bool) {
+	raw := r.Header.Get("Last-Event-ID")
+	if raw == "" {
+		raw = r.URL.Query().Get("since")
+	}
+	if raw == "" {
+		return 0, true
+	}
+
+	since, err := strconv.ParseUint(raw, 10, 64)
+	if err != nil {
+		http.Error(w, "since must be a sequence number", http.StatusBadRequest)
+		return 0, false
+	}
+	return since, true
+}
+
+// writeSSE writes event as a server-sent event named after its type, with
+// its sequence number as id.
+func writeSSE(w http.ResponseWriter, event *fmpb.WatchEvent) error {
+	data, err := json.Marshal(toModelWatchEvent(event))
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.Seq, event.Type, data)
+	return err
+}
+
+// Watch streams the changes in a directory
+// @Summary Watch a directory
+// @Description Streams the changes in a directory as server-sent events, whether they were made through the API or directly on disk. Every event has its sequence number as id, its type (create, modify, delete or move) as name and a models.WatchEvent as data. After a reconnect the events after the Last-Event-ID header or the since parameter are sent first. If they are no longer buffered the answer is 412 and the directory has to be listed again
+// @Tags watch
+// @Produce text/event-stream
+// @Param path query string false "Directory path, the root if empty"
+// @Param recursive query bool false "Report changes in subdirectories too"
+// @Param since query int false "Sequence number of the last event seen"
+// @Param Last-Event-ID header string false "Sequence number of the last event seen, sent by EventSource"
+// @Success 200 {object} models.WatchEvent "Stream of events"
+// @Failure 400 {object} models.ErrorResponse "Invalid request"
+// @Failure 403 {object} models.ErrorResponse "Listing the directory is not allowed"
+// @Failure 412 {object} models.ErrorResponse "Events were lost or watching is disabled"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /files/watch [get]
+func (h Handler) Watch(w http.ResponseWriter, r *http.Request) {
+	lg := logger.GetLoggerFromContext(r.Context())
+
+	dirPath := r.URL.Query().Get("path")
+	recursive, ok := boolParam(w, r, "recursive")
+	if !ok {
+		return
+	}
+	since, ok := watchSince(w, r)
+	if !ok {
+		return
+	}
+
+	stream, err := h.gw.client.Watch.Watch(r.Context(),
+		&fmpb.WatchRequest{Path: dirPath, Recursive: recursive, Since: since})
+	if err != nil {
+		WriteError(w, err)
+		lg.Error(r.Context(), "Error getting stream", zap.Error(err))
+		return
+	}
+
+	// The backend sends headers once the watch started; without them the
+	// call failed and Recv returns the error.
+	if header, err := stream.Header(); err != nil || header == nil {
+		if err == nil {
+			_, err = stream.Recv()
+		}
+		WriteError(w, err)
+		lg.Error(r.Context(), "Error starting watch", zap.String("path", dirPath), zap.Error(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	rc := http.NewResponseController(w)
+	if err = rc.Flush(); err != nil {
+		lg.Error(r.Context(), "Error flushing event stream", zap.Error(err))
+		return
+	}
+
+	events := make(chan *fmpb.WatchEvent)
+	errs := make(chan error, 1)
+	go func() {
+		for {
+			event, err := stream.Recv()
+			if err != nil {
+				errs <- err
+				return
+			}
+			select {
+			case events <- event:
+			case <-r.Context().Done():
+				return
+			}
+		}
+	}()
+
+	ticker := time.NewTicker(sseKeepAlive)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case err = <-errs:
+			// The headers are sent, so the client only learns that the
+			// stream ended; it reconnects with the last id it got.
+			lg.Info(r.Context(), "Watch ended", zap.String("path", dirPath), zap.Error(err))
+			return
+		case <-ticker.C:
+			_, err = fmt.Fprint(w, ": ping\n\n")
+		case event := <-events:
+			err = writeSSE(w, event)
+		}
+		if err == nil {
+			err = rc.Flush()
+		}
+		if err != nil {
+			lg.Debug(r.Context(), "Error writing event stream", zap.Error(err))
+			return
+		}
+	}
+}
@@ -0,0 +1,226 @@
+package gateway
+
+import (
+	"github.com/JunBSer/FileManager/internal/models"
+	"github.com/JunBSer/FileManager/pkg/api/fmpb"
+	"github.com/JunBSer/FileManager/pkg/logger"
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+	"net/http"
+	"strings"
+)
+
+// WebhookSecretHeader carries the secret of a new webhook, so that it does
+// not end up in logged URLs.
+const WebhookSecretHeader = "X-Webhook-Secret"
+
+func toModelWebhook(hook *fmpb.Webhook) models.Webhook {
+	return models.Webhook{
+		ID:         hook.Id,
+		PathPrefix: hook.PathPrefix,
+		Types:      hook.Types,
+		URL:        hook.Url,
+		CreatedBy:  hook.CreatedBy,
+		CreatedAt:  hook.CreatedAt,
+		Secret:     hook.Secret,
+	}
+}
+
+func toModelDelivery(d *fmpb.Delivery) models.Delivery {
+	return models.Delivery{
+		ID:           d.Id,
+		WebhookID:    d.WebhookId,
+		URL:          d.Url,
+		Seq:          d.Seq,
+		Type:         d.Type,
+		Path:         d.Path,
+		OldPath:      d.OldPath,
+		IsDir:        d.IsDir,
+		Status:       d.Status,
+		Attempts:     d.Attempts,
+		ResponseCode: d.ResponseCode,
+		LastError:    d.LastError,
+		CreatedAt:    d.CreatedAt,
+		UpdatedAt:    d.UpdatedAt,
+		NextAttempt:  d.NextAttempt,
+	}
+}
+
+func (h Handler) encodeDeliveries(w http.ResponseWriter, r *http.Request, res *fmpb.ListDeliveriesResponse) {
+	deliveries := make([]models.Delivery, 0, len(res.Deliveries))
+	for _, d := range res.Deliveries {
+		deliveries = append(deliveries, toModelDelivery(d))
+	}
+	h.EncodeJSON(w, http.StatusOK, deliveries, r.Context())
+}
+
+// CreateWebhook registers a webhook
+// @Summary Create a webhook
+// @Description Registers a URL that every change below a path is posted to as JSON. Requests carry the X-FM-Delivery, X-FM-Event and X-FM-Signature headers; the signature is "t=<unix time>,v1=<hex HMAC-SHA256 of the time, a dot and the body>" keyed with the secret. Failed deliveries are retried with exponential backoff and end up in the dead-letter log. The secret is only returned here. Needs an admin while access control is enabled
+// @Tags webhooks
+// @Produce application/json
+// @Param url query string true "URL the changes are posted to"
+// @Param path_prefix query string false "Only changes of this path and below it, all if empty"
+// @Param types query string false "Comma separated event types: create, modify, delete, move; all if empty"
+// @Param X-Webhook-Secret header string false "Key of the signatures, generated if empty"
+// @Success 201 {object} models.Webhook "Webhook with its secret"
+// @Failure 400 {object} models.ErrorResponse "Invalid request"
+// @Failure 403 {object} models.ErrorResponse "Caller is not an admin"
+// @Failure 412 {object} models.ErrorResponse "Webhooks are disabled"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /webhooks [post]
+func (h Handler) CreateWebhook(w http.ResponseWriter, r *http.Request) {
+	lg := logger.GetLoggerFromContext(r.Context())
+
+	target, err := h.HandleFilePath("url", w, r)
+	if err != nil {
+		lg.Debug(r.Context(), "Error handling webhook url")
+		return
+	}
+
+	res, err := h.gw.client.Webhooks.CreateWebhook(r.Context(), &fmpb.CreateWebhookRequest{
+		PathPrefix: r.URL.Query().Get("path_prefix"),
+		Types:      listParam(r, "types"),
+		Url:        target,
+		Secret:     r.Header.Get(WebhookSecretHeader),
+	})
+	if err != nil {
+		WriteError(w, err)
+		lg.Error(r.Context(), "Error creating webhook", zap.String("url", target), zap.Error(err))
+		return
+	}
+
+	h.EncodeJSON(w, http.StatusCreated, toModelWebhook(res), r.Context())
+}
+
+// ListWebhooks lists the webhooks
+// @Summary List webhooks
+// @Description Returns the webhooks, the oldest first, without their secrets
+// @Tags webhooks
+// @Produce application/json
+// @Success 200 {array} models.Webhook "Webhooks"
+// @Failure 403 {object} models.ErrorResponse "Caller is not an admin"
+// @Failure 412 {object} models.ErrorResponse "Webhooks are disabled"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /webhooks [get]
+func (h Handler) ListWebhooks(w http.ResponseWriter, r *http.Request) {
+	lg := logger.GetLoggerFromContext(r.Context())
+
+	res, err := h.gw.client.Webhooks.ListWebhooks(r.Context(), &fmpb.ListWebhooksRequest{})
+	if err != nil {
+		WriteError(w, err)
+		lg.Error(r.Context(), "Error listing webhooks", zap.Error(err))
+		return
+	}
+
+	hooks := make([]models.Webhook, 0, len(res.Webhooks))
+	for _, hook := range res.Webhooks {
+		hooks = append(hooks, toModelWebhook(hook))
+	}
+	h.EncodeJSON(w, http.StatusOK, hooks, r.Context())
+}
+
+// DeleteWebhook deletes a webhook
+// @Summary Delete a webhook
+// @Description Stops the deliveries to a webhook, including the pending ones
+// @Tags webhooks
+// @Param webhook_id path string true "Webhook ID"
+// @Success 204 "Webhook deleted"
+// @Failure 403 {object} models.ErrorResponse "Caller is not an admin"
+// @Failure 404 {object} models.ErrorResponse "Webhook not found"
+// @Failure 412 {object} models.ErrorResponse "Webhooks are disabled"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /webhooks/{webhook_id} [delete]
+func (h Handler) DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	lg := logger.GetLoggerFromContext(r.Context())
+
+	id := strings.TrimSpace(mux.Vars(r)["webhook_id"])
+
+	_, err := h.gw.client.Webhooks.DeleteWebhook(r.Context(), &fmpb.DeleteWebhookRequest{Id: id})
+	if err != nil {
+		WriteError(w, err)
+		lg.Error(r.Context(), "Error deleting webhook", zap.String("id", id), zap.Error(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListDeliveries lists recent webhook deliveries
+// @Summary List webhook deliveries
+// @Description Returns the recent deliveries, the latest first. The history is kept in memory and starts empty after a restart
+// @Tags webhooks
+// @Produce application/json
+// @Param webhook_id query string false "Only deliveries of this webhook"
+// @Param status query string false "Only deliveries with this status" Enums(pending, delivered, failed)
+// @Success 200 {array} models.Delivery "Deliveries"
+// @Failure 403 {object} models.ErrorResponse "Caller is not an admin"
+// @Failure 412 {object} models.ErrorResponse "Webhooks are disabled"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /webhooks/deliveries [get]
+func (h Handler) ListDeliveries(w http.ResponseWriter, r *http.Request) {
+	lg := logger.GetLoggerFromContext(r.Context())
+
+	res, err := h.gw.client.Webhooks.ListDeliveries(r.Context(), &fmpb.ListDeliveriesRequest{
+		WebhookId: r.URL.Query().Get("webhook_id"),
+		Status:    r.URL.Query().Get("status"),
+	})
+	if err != nil {
+		WriteError(w, err)
+		lg.Error(r.Context(), "Error listing webhook deliveries", zap.Error(err))
+		return
+	}
+
+	h.encodeDeliveries(w, r, res)
+}
+
+// ListDeadLetters lists the failed webhook deliveries
+// @Summary List dead letters
+// @Description Returns the deliveries that failed after their last attempt, the latest first
+// @Tags webhooks
+// @Produce application/json
+// @Success 200 {array} models.Delivery "Dead letters"
+// @Failure 403 {object} models.ErrorResponse "Caller is not an admin"
+// @Failure 412 {object} models.ErrorResponse "Webhooks are disabled"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /webhooks/dead-letters [get]
+func (h Handler) ListDeadLetters(w http.ResponseWriter, r *http.Request) {
+	lg := logger.GetLoggerFromContext(r.Context())
+
+	res, err := h.gw.client.Webhooks.ListDeadLetters(r.Context(), &fmpb.ListDeadLettersRequest{})
+	if err != nil {
+		WriteError(w, err)
+		lg.Error(r.Context(), "Error listing dead letters", zap.Error(err))
+		return
+	}
+
+	h.encodeDeliveries(w, r, res)
+}
+
+// Redeliver retries a dead letter
+// @Summary Retry a dead letter
+// @Description Takes a delivery out of the dead-letter log and tries it again with a fresh number of attempts
+// @Tags webhooks
+// @Produce application/json
+// @Param delivery_id path string true "Delivery ID"
+// @Success 202 {object} models.Delivery "Queued delivery"
+// @Failure 403 {object} models.ErrorResponse "Caller is not an admin"
+// @Failure 404 {object} models.ErrorResponse "Dead letter or its webhook not found"
+// @Failure 412 {object} models.ErrorResponse "Webhooks are disabled"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Failure 503 {object} models.ErrorResponse "Webhook delivery is not running or the queue of the webhook is full"
+// @Router /webhooks/dead-letters/{delivery_id}/redeliver [post]
+func (h Handler) Redeliver(w http.ResponseWriter, r *http.Request) {
+	lg := logger.GetLoggerFromContext(r.Context())
+
+	id := strings.TrimSpace(mux.Vars(r)["delivery_id"])
+
+	res, err := h.gw.client.Webhooks.Redeliver(r.Context(), &fmpb.RedeliverRequest{Id: id})
+	if err != nil {
+		WriteError(w, err)
+		lg.Error(r.Context(), "Error redelivering dead letter", zap.String("id", id), zap.Error(err))
+		return
+	}
+
+	h.EncodeJSON(w, http.StatusAccepted, toModelDelivery(res), r.Context())
+}
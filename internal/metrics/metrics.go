@@ -0,0 +1,113 @@
+// Package metrics holds the Prometheus metrics of the gateway and of the gRPC
+// server. The gateway serves them on /metrics next to its API, the gRPC
+// server on a listener of its own.
+package metrics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/JunBSer/FileManager/pkg/logger"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.uber.org/zap"
+	"net/http"
+	"time"
+)
+
+// Path is where the metrics are served.
+const Path = "/metrics"
+
+// Directions of transferred bytes, seen from the server: received bytes are
+// uploaded, sent bytes downloaded.
+const (
+	Received = "received"
+	Sent     = "sent"
+)
+
+type Config struct {
+	Enabled bool `env:"METRICS_ENABLED" envDefault:"true"`
+	// Host and Port are the address the gRPC server serves the metrics on.
+	Host string `env:"METRICS_HOST" envDefault:"localhost"`
+	Port int    `env:"METRICS_PORT" envDefault:"9090"`
+}
+
+// Registry holds the metrics of this package and those of the Go runtime and
+// the process.
+var Registry = prometheus.NewRegistry()
+
+var factory = promauto.With(Registry)
+
+var (
+	HTTPRequests = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "fm", Subsystem: "http", Name: "requests_total",
+		Help: "HTTP requests by route template, method and status code.",
+	}, []string{"route", "method", "code"})
+	HTTPDuration = factory.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "fm", Subsystem: "http", Name: "request_duration_seconds",
+		Help:    "Time to serve HTTP requests by route template and method.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"route", "method"})
+	HTTPInFlight = factory.NewGauge(prometheus.GaugeOpts{
+		Namespace: "fm", Subsystem: "http", Name: "requests_in_flight",
+		Help: "HTTP requests being served.",
+	})
+	HTTPBytes = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "fm", Subsystem: "http", Name: "bytes_total",
+		Help: "Bytes of HTTP request and response bodies by route template and direction.",
+	}, []string{"route", "direction"})
+
+	RPCRequests = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "fm", Subsystem: "grpc", Name: "requests_total",
+		Help: "gRPC calls by method and status code.",
+	}, []string{"method", "code"})
+	RPCDuration = factory.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "fm", Subsystem: "grpc", Name: "request_duration_seconds",
+		Help:    "Time to handle gRPC calls by method.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method"})
+	RPCStreamsInFlight = factory.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "fm", Subsystem: "grpc", Name: "streams_in_flight",
+		Help: "Open gRPC streams by method.",
+	}, []string{"method"})
+	RPCBytes = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "fm", Subsystem: "grpc", Name: "file_bytes_total",
+		Help: "File bytes of gRPC messages by method and direction.",
+	}, []string{"method", "direction"})
+
+	RepositoryErrors = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "fm", Subsystem: "repository", Name: "errors_total",
+		Help: "Failed storage operations by operation and error type.",
+	}, []string{"operation", "type"})
+)
+
+func init() {
+	Registry.MustRegister(collectors.NewGoCollector(), collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}))
+}
+
+// Handler serves the metrics of Registry.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{})
+}
+
+// Serve serves the metrics on the address of cfg until ctx is done.
+func Serve(ctx context.Context, cfg Config) error {
+	lg := logger.GetLoggerFromContext(ctx)
+
+	mux := http.NewServeMux()
+	mux.Handle(Path, Handler())
+	srv := &http.Server{Addr: fmt.Sprintf("%s:%d", cfg.Host, cfg.Port), Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+
+	go func() {
+		<-ctx.Done()
+		_ = srv.Close()
+	}()
+
+	lg.Info(ctx, "Serving metrics", zap.String("addr", srv.Addr), zap.String("path", Path))
+	if err := srv.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"github.com/JunBSer/FileManager/internal/repository"
+	"github.com/JunBSer/FileManager/pkg/logger"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+	"io/fs"
+)
+
+// ErrorType returns the label of the kind of a repository error.
+func ErrorType(err error) string {
+	switch {
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return "canceled"
+	case errors.Is(err, fs.ErrNotExist):
+		return "not_found"
+	case errors.Is(err, fs.ErrExist), errors.Is(err, repository.ErrPathExists):
+		return "exists"
+	case errors.Is(err, fs.ErrPermission):
+		return "permission"
+	case errors.Is(err, repository.ErrQuotaExceeded):
+		return "quota"
+	case errors.Is(err, repository.ErrCorruptedFile), errors.Is(err, repository.ErrCorruptedCompression),
+		errors.Is(err, repository.ErrChecksumMismatch), errors.Is(err, repository.ErrUnknownKey):
+		return "corrupted"
+	case errors.Is(err, repository.ErrNotDirectory), errors.Is(err, repository.ErrIsDirectory),
+		errors.Is(err, repository.ErrDirNotEmpty):
+		return "invalid"
+	}
+	return "other"
+}
+
+// ObserveRepository counts the errors of repo in RepositoryErrors.
+func ObserveRepository(repo repository.FileRepository) *repository.ObservedRepo {
+	return repository.NewObserved(repo, func(operation string, err error) {
+		RepositoryErrors.WithLabelValues(operation, ErrorType(err)).Inc()
+	})
+}
+
+// StorageCollector reports the usage of every namespace kept by the quota
+// layer when the metrics are scraped.
+type StorageCollector struct {
+	ctx    context.Context
+	quotas *repository.QuotaRepo
+	bytes  *prometheus.Desc
+	files  *prometheus.Desc
+}
+
+// NewStorageCollector creates a collector of the usage of quotas. ctx is used
+// for the storage operations of the scrapes.
+func NewStorageCollector(ctx context.Context, quotas *repository.QuotaRepo) *StorageCollector {
+	return &StorageCollector{
+		ctx:    ctx,
+		quotas: quotas,
+		bytes:  prometheus.NewDesc("fm_storage_used_bytes", "Logical size of the files of a namespace.", []string{"namespace"}, nil),
+		files:  prometheus.NewDesc("fm_storage_files", "Number of files of a namespace.", []string{"namespace"}, nil),
+	}
+}
+
+func (c *StorageCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.bytes
+	ch <- c.files
+}
+
+func (c *StorageCollector) Collect(ch chan<- prometheus.Metric) {
+	usage, err := c.quotas.List(c.ctx)
+	if err != nil {
+		logger.GetLoggerFromContext(c.ctx).Error(c.ctx, "Error collecting storage usage", zap.Error(err))
+		return
+	}
+
+	for _, u := range usage {
+		ns := repository.NamespaceName(u.Namespace)
+		ch <- prometheus.MustNewConstMetric(c.bytes, prometheus.GaugeValue, float64(u.Bytes), ns)
+		ch <- prometheus.MustNewConstMetric(c.files, prometheus.GaugeValue, float64(u.Files), ns)
+	}
+}
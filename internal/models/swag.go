@@ -8,6 +8,206 @@ type ErrorResponse struct {
 
 // FileEntry file list element
 type FileEntry struct {
-	Name        string `json:"name" example:"report.pdf"`
-	IsDirectory bool   `json:"is_directory" example:"false"`
+	Name         string `json:"name" example:"report.pdf"`
+	IsDirectory  bool   `json:"is_directory" example:"false"`
+	Size         int64  `json:"size" example:"1048576"`
+	PhysicalSize int64  `json:"physical_size" example:"262144"`
+	ModTime      int64  `json:"mod_time" example:"1718000000"`
+	Mode         string `json:"mode" example:"-rw-r--r--"`
+	ContentType  string `json:"content_type,omitempty" example:"application/pdf"`
+	Checksum     string `json:"checksum,omitempty" example:"sha256:9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"`
+}
+
+// FileStat metadata of one path
+type FileStat struct {
+	Path string `json:"path" example:"/documents/report.pdf"`
+	FileEntry
+}
+
+// UploadSession state of a resumable upload
+type UploadSession struct {
+	UploadID        string `json:"upload_id" example:"1f0d5a0e-7c1b-4b8e-9a51-1b2f8c6a1d2e"`
+	FileName        string `json:"file_name" example:"/builds/artifact.tar.gz"`
+	CommittedOffset int64  `json:"committed_offset" example:"1048576"`
+	TotalSize       int64  `json:"total_size" example:"4294967296"`
+	CreatedAt       int64  `json:"created_at" example:"1718000000"`
+	SHA256          string `json:"sha256,omitempty" example:"9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"`
+}
+
+// FileVersion previous content of a file
+type FileVersion struct {
+	VersionID string `json:"version_id" example:"01718000000000000000-overwrite"`
+	FileName  string `json:"file_name" example:"/documents/report.pdf"`
+	Size      int64  `json:"size" example:"1048576"`
+	CreatedAt int64  `json:"created_at" example:"1718000000"`
+	Reason    string `json:"reason" example:"overwrite"`
+}
+
+// RetentionRule limits of the versions kept for a directory
+type RetentionRule struct {
+	Path          string `json:"path" example:"/documents"`
+	KeepVersions  int32  `json:"keep_versions" example:"10"`
+	MaxAgeSeconds int64  `json:"max_age_seconds" example:"2592000"`
+}
+
+// TrashEntry deleted file kept for restore
+type TrashEntry struct {
+	EntryID   string `json:"entry_id" example:"5b7f3c2e-8a0d-4f61-9e3b-2c4d6a8b0e1f"`
+	FileName  string `json:"file_name" example:"/documents/report.pdf"`
+	Size      int64  `json:"size" example:"1048576"`
+	DeletedAt int64  `json:"deleted_at" example:"1718000000"`
+}
+
+// EmptyTrashResult number of purged trash entries
+type EmptyTrashResult struct {
+	Removed int32 `json:"removed" example:"12"`
+}
+
+// PathResult outcome of one entry of a directory operation
+type PathResult struct {
+	Path   string `json:"path" example:"/projects/site/index.html"`
+	Target string `json:"target,omitempty" example:"/backup/site/index.html"`
+	IsDir  bool   `json:"is_dir" example:"false"`
+	Error  string `json:"error,omitempty" example:"path already exists: /backup/site/index.html"`
+}
+
+// TreeReport outcome of a directory operation
+type TreeReport struct {
+	Done    int          `json:"done" example:"41"`
+	Failed  int          `json:"failed" example:"1"`
+	Entries []PathResult `json:"entries"`
+}
+
+// UploadResult outcome of one entry of a multi-file upload
+type UploadResult struct {
+	Path  string `json:"path" example:"photos/2024/beach.jpg"`
+	IsDir bool   `json:"is_dir" example:"false"`
+	Bytes int64  `json:"bytes" example:"52428"`
+	// Code is the HTTP status of the entry.
+	Code  int    `json:"code" example:"200"`
+	Error string `json:"error,omitempty" example:""`
+}
+
+// UploadReport outcome of a multi-file upload
+type UploadReport struct {
+	Uploaded int            `json:"uploaded" example:"41"`
+	Failed   int            `json:"failed" example:"1"`
+	Files    []UploadResult `json:"files"`
+}
+
+// NamespaceUsage storage used by a top level directory and its quota
+type NamespaceUsage struct {
+	Namespace string `json:"namespace" example:"team-a"`
+	Bytes     int64  `json:"bytes" example:"734003200"`
+	Files     int64  `json:"files" example:"1250"`
+	MaxBytes  int64  `json:"max_bytes" example:"10737418240"`
+	MaxFiles  int64  `json:"max_files" example:"0"`
+}
+
+// Grant permissions of a principal on a path and everything below it
+type Grant struct {
+	Path        string   `json:"path" example:"/projects"`
+	Principal   string   `json:"principal" example:"group:developers"`
+	Role        string   `json:"role,omitempty" example:"editor"`
+	Permissions []string `json:"permissions" example:"read,list"`
+}
+
+// Access effective permissions of an identity on a path
+type Access struct {
+	Path        string   `json:"path" example:"/projects/site"`
+	Subject     string   `json:"subject" example:"alice"`
+	Permissions []string `json:"permissions" example:"read,write,list"`
+	Admin       bool     `json:"admin" example:"false"`
+}
+
+// Role named set of permissions
+type Role struct {
+	Name        string   `json:"name" example:"viewer"`
+	Permissions []string `json:"permissions" example:"read,list"`
+}
+
+// Share signed link that allows one operation on one file without credentials
+type Share struct {
+	ID        string `json:"id" example:"5f0c6a8e-8d3f-4a43-9c1e-2b7f4f1f2a10"`
+	Path      string `json:"path" example:"reports/q3.pdf"`
+	Operation string `json:"operation" example:"download"`
+	ExpiresAt int64  `json:"expires_at" example:"1735689600"`
+	MaxUses   int64  `json:"max_uses" example:"3"`
+	MaxBytes  int64  `json:"max_bytes" example:"0"`
+	Uses      int64  `json:"uses" example:"1"`
+	Bytes     int64  `json:"bytes" example:"1048576"`
+	CreatedBy string `json:"created_by,omitempty" example:"alice"`
+	CreatedAt int64  `json:"created_at" example:"1735084800"`
+	URL       string `json:"url,omitempty" example:"https://files.example.com/api/v1/files/download?file_path=reports%2Fq3.pdf&share=..."`
+}
+
+// WatchEvent change of a file or directory, sent as the data of a server-sent event
+type WatchEvent struct {
+	Seq     uint64 `json:"seq" example:"1735084800000042"`
+	Type    string `json:"type" example:"move"`
+	Path    string `json:"path" example:"reports/q3-final.pdf"`
+	OldPath string `json:"old_path,omitempty" example:"reports/q3.pdf"`
+	IsDir   bool   `json:"is_dir" example:"false"`
+	Time    int64  `json:"time" example:"1735084800"`
+}
+
+// Webhook HTTP callback for the changes below a path
+type Webhook struct {
+	ID         string   `json:"id" example:"0b8f6f52-1f0e-4f3b-9a53-3c1d7e2f9a41"`
+	PathPrefix string   `json:"path_prefix" example:"incoming"`
+	Types      []string `json:"types,omitempty" example:"create,modify"`
+	URL        string   `json:"url" example:"https://indexer.example.com/hooks/files"`
+	CreatedBy  string   `json:"created_by,omitempty" example:"alice"`
+	CreatedAt  int64    `json:"created_at" example:"1735084800"`
+	Secret     string   `json:"secret,omitempty" example:"q3J8kT0y6hQ2lV9cR1mXzA4bN7eW5uF0sD8gH2jK3pY"`
+}
+
+// Delivery posting of one change to one webhook
+type Delivery struct {
+	ID           string `json:"id" example:"9d3c7a1e-6b2f-4c8d-a5e0-7f1b2c3d4e5f"`
+	WebhookID    string `json:"webhook_id" example:"0b8f6f52-1f0e-4f3b-9a53-3c1d7e2f9a41"`
+	URL          string `json:"url" example:"https://indexer.example.com/hooks/files"`
+	Seq          uint64 `json:"seq" example:"1735084800000042"`
+	Type         string `json:"type" example:"create"`
+	Path         string `json:"path" example:"incoming/report.csv"`
+	OldPath      string `json:"old_path,omitempty" example:""`
+	IsDir        bool   `json:"is_dir" example:"false"`
+	Status       string `json:"status" example:"pending"`
+	Attempts     int32  `json:"attempts" example:"2"`
+	ResponseCode int32  `json:"response_code,omitempty" example:"503"`
+	LastError    string `json:"last_error,omitempty" example:"receiver answered 503 Service Unavailable"`
+	CreatedAt    int64  `json:"created_at" example:"1735084800"`
+	UpdatedAt    int64  `json:"updated_at" example:"1735084803"`
+	NextAttempt  int64  `json:"next_attempt,omitempty" example:"1735084807"`
+}
+
+// AuditRecord call recorded in the audit log
+type AuditRecord struct {
+	Seq         uint64 `json:"seq" example:"42"`
+	Time        int64  `json:"time" example:"1735084800"`
+	Caller      string `json:"caller,omitempty" example:"alice"`
+	AuthMethod  string `json:"auth_method,omitempty" example:"jwt"`
+	Remote      string `json:"remote,omitempty" example:"10.0.0.7:51234"`
+	Operation   string `json:"operation" example:"/file_manager.FileService/Upload"`
+	Path        string `json:"path,omitempty" example:"incoming/report.csv"`
+	Destination string `json:"destination,omitempty" example:""`
+	Bytes       int64  `json:"bytes" example:"52428"`
+	Status      string `json:"status" example:"OK"`
+	Error       string `json:"error,omitempty" example:""`
+	DurationMs  int64  `json:"duration_ms" example:"12"`
+	PrevHash    string `json:"prev_hash" example:"5e884898da28047151d0e56f8dc6292773603d0d6aabbdd62a11ef721d1542d8"`
+	Hash        string `json:"hash" example:"9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"`
+}
+
+// AuditVerification result of checking the audit log
+type AuditVerification struct {
+	Valid   bool   `json:"valid" example:"true"`
+	Records uint64 `json:"records" example:"1024"`
+	Error   string `json:"error,omitempty" example:""`
+}
+
+// Health health of the backend as seen by a probe of the gateway
+type Health struct {
+	Status string `json:"status" example:"SERVING"`
+	Error  string `json:"error,omitempty" example:""`
 }
@@ -0,0 +1,403 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/JunBSer/FileManager/pkg/logger"
+	"go.uber.org/zap"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+var aclPath = filepath.Join(SystemDir, "acl.json")
+
+var (
+	ErrInvalidPermission = errors.New("invalid permission")
+	ErrInvalidPrincipal  = errors.New("invalid principal")
+	ErrUnknownRole       = errors.New("unknown role")
+	ErrGrantNotFound     = errors.New("grant not found")
+)
+
+// Permission is a set of operations on paths.
+type Permission uint8
+
+const (
+	PermRead Permission = 1 << iota
+	PermWrite
+	PermDelete
+	PermList
+
+	PermAll = PermRead | PermWrite | PermDelete | PermList
+)
+
+var permissionNames = []struct {
+	perm Permission
+	name string
+}{
+	{PermRead, "read"},
+	{PermWrite, "write"},
+	{PermDelete, "delete"},
+	{PermList, "list"},
+}
+
+// Principals of grants: everybody, including anonymous callers, a user by
+// subject or the members of a group.
+const (
+	Everyone       = "*"
+	UserPrincipal  = "user:"
+	GroupPrincipal = "group:"
+)
+
+// DefaultRoles are the roles that exist without configuration.
+var DefaultRoles = map[string]Permission{
+	"viewer": PermRead | PermList,
+	"editor": PermRead | PermWrite | PermList,
+	"owner":  PermAll,
+}
+
+type ACLConfig struct {
+	Enabled bool `env:"ACL_ENABLED" envDefault:"false"`
+	// Admins is a comma separated list of principals that pass every check
+	// and manage the grants.
+	Admins string `env:"ACL_ADMINS"`
+	// Roles is a comma separated list of name=permission+permission pairs.
+	// They are added to DefaultRoles and may replace them.
+	Roles string `env:"ACL_ROLES"`
+}
+
+// ACLEntry grants the permissions of Role and Permissions on Path and
+// everything below it to Principal.
+type ACLEntry struct {
+	Path        string     `json:"path"`
+	Principal   string     `json:"principal"`
+	Role        string     `json:"role,omitempty"`
+	Permissions Permission `json:"permissions"`
+}
+
+// ACLStore keeps the grants as JSON under SystemDir. Grants only add
+// permissions, so a permission on a directory is a permission on its whole
+// tree.
+type ACLStore struct {
+	repo  FileRepository
+	roles map[string]Permission
+
+	mu      sync.Mutex
+	entries map[string][]ACLEntry
+}
+
+// ParsePermissions parses a list of permission names separated by commas or
+// plus signs. "all" stands for every permission.
+func ParsePermissions(raw string) (Permission, error) {
+	var perm Permission
+	for _, name := range strings.FieldsFunc(raw, func(r rune) bool { return r == ',' || r == '+' }) {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "all" {
+			perm |= PermAll
+			continue
+		}
+
+		found := false
+		for _, p := range permissionNames {
+			if p.name == name {
+				perm, found = perm|p.perm, true
+				break
+			}
+		}
+		if !found {
+			return 0, fmt.Errorf("%w: %q", ErrInvalidPermission, name)
+		}
+	}
+	return perm, nil
+}
+
+// Names returns the names of the permissions of p.
+func (p Permission) Names() []string {
+	names := []string{}
+	for _, perm := range permissionNames {
+		if p&perm.perm != 0 {
+			names = append(names, perm.name)
+		}
+	}
+	return names
+}
+
+func (p Permission) String() string {
+	return strings.Join(p.Names(), ",")
+}
+
+func (p Permission) MarshalText() ([]byte, error) {
+	return []byte(p.String()), nil
+}
+
+func (p *Permission) UnmarshalText(text []byte) error {
+	perm, err := ParsePermissions(string(text))
+	if err != nil {
+		return err
+	}
+	*p = perm
+	return nil
+}
+
+// ParseRoles returns DefaultRoles with the roles of raw added.
+func ParseRoles(raw string) (map[string]Permission, error) {
+	roles := make(map[string]Permission, len(DefaultRoles))
+	for name, perm := range DefaultRoles {
+		roles[name] = perm
+	}
+
+	for _, pair := range strings.Split(raw, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+
+		name, perms, ok := strings.Cut(pair, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid role %q", pair)
+		}
+		perm, err := ParsePermissions(perms)
+		if err != nil {
+			return nil, fmt.Errorf("role %s: %w", name, err)
+		}
+		roles[name] = perm
+	}
+	return roles, nil
+}
+
+// ParsePrincipal checks that raw is Everyone or a user or group principal.
+func ParsePrincipal(raw string) (string, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == Everyone {
+		return raw, nil
+	}
+
+	for _, prefix := range []string{UserPrincipal, GroupPrincipal} {
+		if name, ok := strings.CutPrefix(raw, prefix); ok && name != "" {
+			return raw, nil
+		}
+	}
+	return "", fmt.Errorf("%w: %q, want *, user:<subject> or group:<name>", ErrInvalidPrincipal, raw)
+}
+
+// Principals returns the principals a caller is granted permissions by. An
+// empty subject is an anonymous caller.
+func Principals(subject string, groups []string) []string {
+	principals := []string{Everyone}
+	if subject != "" {
+		principals = append(principals, UserPrincipal+subject)
+	}
+	for _, group := range groups {
+		principals = append(principals, GroupPrincipal+group)
+	}
+	return principals
+}
+
+// NewACLStore creates the grant store. roles are the roles grants may refer
+// to.
+func NewACLStore(repo FileRepository, roles map[string]Permission) *ACLStore {
+	return &ACLStore{repo: repo, roles: roles}
+}
+
+// Roles returns the permissions of every role.
+func (s *ACLStore) Roles() map[string]Permission {
+	return s.roles
+}
+
+// Granted returns the permissions entry grants.
+func (s *ACLStore) Granted(entry ACLEntry) Permission {
+	return entry.Permissions | s.roles[entry.Role]
+}
+
+// loadEntries must be called with s.mu held.
+func (s *ACLStore) loadEntries(ctx context.Context) error {
+	if s.entries != nil {
+		return nil
+	}
+
+	var list []ACLEntry
+
+	file, err := s.repo.GetFileHandle(ctx, aclPath, Read)
+	if err == nil {
+		defer file.Close()
+
+		var data []byte
+		if data, err = io.ReadAll(file); err != nil {
+			return err
+		}
+		if err = json.Unmarshal(data, &list); err != nil {
+			return fmt.Errorf("corrupted access control list: %w", err)
+		}
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+
+	entries := map[string][]ACLEntry{}
+	for _, entry := range list {
+		entries[entry.Path] = append(entries[entry.Path], entry)
+	}
+	s.entries = entries
+	return nil
+}
+
+// Effective returns the permissions principals have on path: everything
+// granted to one of them on path or a directory above it.
+func (s *ACLStore) Effective(ctx context.Context, path string, principals []string) (Permission, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.loadEntries(ctx); err != nil {
+		return 0, err
+	}
+
+	var perm Permission
+	for current := cleanDir(path); ; current = filepath.Dir(current) {
+		for _, entry := range s.entries[current] {
+			for _, principal := range principals {
+				if entry.Principal == principal {
+					perm |= s.Granted(entry)
+				}
+			}
+		}
+		if current == filepath.Dir(current) {
+			break
+		}
+	}
+	return perm, nil
+}
+
+// List returns the grants on path and everything below it, sorted by path
+// and principal.
+func (s *ACLStore) List(ctx context.Context, path string) ([]ACLEntry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.loadEntries(ctx); err != nil {
+		return nil, err
+	}
+
+	root := cleanDir(path)
+	var res []ACLEntry
+	for dir, entries := range s.entries {
+		if root == string(filepath.Separator) || dir == root || strings.HasPrefix(dir, root+string(filepath.Separator)) {
+			res = append(res, entries...)
+		}
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Path != res[j].Path {
+			return res[i].Path < res[j].Path
+		}
+		return res[i].Principal < res[j].Principal
+	})
+	return res, nil
+}
+
+// Set stores entry in place of the grant of its principal on its path.
+func (s *ACLStore) Set(ctx context.Context, entry ACLEntry) (ACLEntry, error) {
+	var err error
+	if entry.Principal, err = ParsePrincipal(entry.Principal); err != nil {
+		return ACLEntry{}, err
+	}
+	if _, ok := s.roles[entry.Role]; entry.Role != "" && !ok {
+		return ACLEntry{}, fmt.Errorf("%w: %q", ErrUnknownRole, entry.Role)
+	}
+	if entry.Role == "" && entry.Permissions == 0 {
+		return ACLEntry{}, fmt.Errorf("%w: a grant needs a role or permissions", ErrInvalidPermission)
+	}
+	if IsSystemPath(entry.Path) {
+		return ACLEntry{}, fmt.Errorf("path %q is reserved", entry.Path)
+	}
+	entry.Path = cleanDir(entry.Path)
+
+	err = s.update(ctx, entry.Path, func(entries []ACLEntry) ([]ACLEntry, error) {
+		for i := range entries {
+			if entries[i].Principal == entry.Principal {
+				entries[i] = entry
+				return entries, nil
+			}
+		}
+		return append(entries, entry), nil
+	})
+	if err != nil {
+		return ACLEntry{}, err
+	}
+
+	logger.GetLoggerFromContext(ctx).Info(ctx, "Grant set", zap.String("path", entry.Path),
+		zap.String("principal", entry.Principal), zap.String("role", entry.Role), zap.Stringer("permissions", entry.Permissions))
+	return entry, nil
+}
+
+// Remove drops the grant of principal on path.
+func (s *ACLStore) Remove(ctx context.Context, path, principal string) error {
+	path = cleanDir(path)
+
+	err := s.update(ctx, path, func(entries []ACLEntry) ([]ACLEntry, error) {
+		for i := range entries {
+			if entries[i].Principal == principal {
+				return append(entries[:i], entries[i+1:]...), nil
+			}
+		}
+		return nil, fmt.Errorf("%w: %s on %s", ErrGrantNotFound, principal, path)
+	})
+	if err != nil {
+		return err
+	}
+
+	logger.GetLoggerFromContext(ctx).Info(ctx, "Grant removed", zap.String("path", path), zap.String("principal", principal))
+	return nil
+}
+
+// update replaces the grants on path with the result of change and writes
+// all grants.
+func (s *ACLStore) update(ctx context.Context, path string, change func([]ACLEntry) ([]ACLEntry, error)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.loadEntries(ctx); err != nil {
+		return err
+	}
+
+	changed, err := change(append([]ACLEntry(nil), s.entries[path]...))
+	if err != nil {
+		return err
+	}
+
+	entries := make(map[string][]ACLEntry, len(s.entries)+1)
+	list := []ACLEntry{}
+	for dir, e := range s.entries {
+		if dir != path {
+			entries[dir] = e
+			list = append(list, e...)
+		}
+	}
+	if len(changed) > 0 {
+		entries[path] = changed
+		list = append(list, changed...)
+	}
+
+	data, err := json.Marshal(list)
+	if err != nil {
+		return err
+	}
+
+	file, err := s.repo.CreateTempFile(ctx, aclPath)
+	if err != nil {
+		return err
+	}
+	if _, err = s.repo.AppendData(ctx, file, data, 0); err == nil {
+		err = s.repo.CommitTempFile(ctx, file, aclPath)
+	}
+	if err != nil {
+		_ = s.repo.DiscardTempFile(ctx, file)
+		return err
+	}
+
+	s.entries = entries
+	return nil
+}
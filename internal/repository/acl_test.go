@@ -0,0 +1,124 @@
+package repository
+
+import (
+	"context"
+	"github.com/JunBSer/FileManager/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestParsePermissions(t *testing.T) {
+	perm, err := ParsePermissions("read, list")
+	require.NoError(t, err)
+	assert.Equal(t, PermRead|PermList, perm)
+
+	perm, err = ParsePermissions("write+delete")
+	require.NoError(t, err)
+	assert.Equal(t, PermWrite|PermDelete, perm)
+
+	perm, err = ParsePermissions("all")
+	require.NoError(t, err)
+	assert.Equal(t, PermAll, perm)
+	assert.Equal(t, []string{"read", "write", "delete", "list"}, perm.Names())
+
+	_, err = ParsePermissions("read,execute")
+	assert.ErrorIs(t, err, ErrInvalidPermission)
+}
+
+func TestParseRoles(t *testing.T) {
+	roles, err := ParseRoles("auditor=read+list")
+	require.NoError(t, err)
+	assert.Equal(t, PermRead|PermList, roles["auditor"])
+	assert.Equal(t, PermAll, roles["owner"])
+
+	_, err = ParseRoles("auditor")
+	assert.Error(t, err)
+}
+
+func TestParsePrincipal(t *testing.T) {
+	for _, raw := range []string{"*", "user:alice", "group:developers"} {
+		principal, err := ParsePrincipal(raw)
+		require.NoError(t, err)
+		assert.Equal(t, raw, principal)
+	}
+	for _, raw := range []string{"alice", "user:", "team:x"} {
+		_, err := ParsePrincipal(raw)
+		assert.ErrorIs(t, err, ErrInvalidPrincipal, raw)
+	}
+}
+
+func TestACLStore(t *testing.T) {
+	ctx := context.WithValue(context.Background(), logger.Key, logger.New("test", "debug"))
+	repo := NewMemory(2048)
+	store := NewACLStore(repo, DefaultRoles)
+
+	alice := Principals("alice", []string{"developers"})
+	bob := Principals("bob", nil)
+
+	_, err := store.Set(ctx, ACLEntry{Path: "projects", Principal: "group:developers", Role: "viewer"})
+	require.NoError(t, err)
+	_, err = store.Set(ctx, ACLEntry{Path: "projects/app", Principal: "user:alice", Permissions: PermWrite})
+	require.NoError(t, err)
+	_, err = store.Set(ctx, ACLEntry{Path: "/", Principal: Everyone, Permissions: PermList})
+	require.NoError(t, err)
+
+	t.Run("Effective inherits grants", func(t *testing.T) {
+		perm, err := store.Effective(ctx, "projects/app/main.go", alice)
+		require.NoError(t, err)
+		assert.Equal(t, PermRead|PermWrite|PermList, perm)
+
+		perm, err = store.Effective(ctx, "projects/readme.md", alice)
+		require.NoError(t, err)
+		assert.Equal(t, PermRead|PermList, perm)
+
+		perm, err = store.Effective(ctx, "projects/app/main.go", bob)
+		require.NoError(t, err)
+		assert.Equal(t, PermList, perm)
+	})
+
+	t.Run("Invalid grants", func(t *testing.T) {
+		_, err := store.Set(ctx, ACLEntry{Path: "projects", Principal: "bob", Role: "viewer"})
+		assert.ErrorIs(t, err, ErrInvalidPrincipal)
+		_, err = store.Set(ctx, ACLEntry{Path: "projects", Principal: "user:bob", Role: "superuser"})
+		assert.ErrorIs(t, err, ErrUnknownRole)
+		_, err = store.Set(ctx, ACLEntry{Path: "projects", Principal: "user:bob"})
+		assert.ErrorIs(t, err, ErrInvalidPermission)
+		_, err = store.Set(ctx, ACLEntry{Path: SystemDir, Principal: "user:bob", Role: "owner"})
+		assert.Error(t, err)
+	})
+
+	t.Run("Set replaces the grant of a principal", func(t *testing.T) {
+		_, err := store.Set(ctx, ACLEntry{Path: "projects/app", Principal: "user:alice", Role: "editor"})
+		require.NoError(t, err)
+
+		entries, err := store.List(ctx, "projects")
+		require.NoError(t, err)
+		require.Len(t, entries, 2)
+		assert.Equal(t, "/projects", entries[0].Path)
+		assert.Equal(t, "/projects/app", entries[1].Path)
+		assert.Equal(t, "editor", entries[1].Role)
+		assert.Equal(t, PermRead|PermWrite|PermList, store.Granted(entries[1]))
+	})
+
+	t.Run("Grants survive a reload", func(t *testing.T) {
+		reloaded := NewACLStore(repo, DefaultRoles)
+
+		entries, err := reloaded.List(ctx, "")
+		require.NoError(t, err)
+		assert.Len(t, entries, 3)
+
+		perm, err := reloaded.Effective(ctx, "projects/app", alice)
+		require.NoError(t, err)
+		assert.Equal(t, PermRead|PermWrite|PermList, perm)
+	})
+
+	t.Run("Remove", func(t *testing.T) {
+		require.NoError(t, store.Remove(ctx, "projects", "group:developers"))
+		assert.ErrorIs(t, store.Remove(ctx, "projects", "group:developers"), ErrGrantNotFound)
+
+		perm, err := store.Effective(ctx, "projects/readme.md", alice)
+		require.NoError(t, err)
+		assert.Equal(t, PermList, perm)
+	})
+}
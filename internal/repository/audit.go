@@ -0,0 +1,314 @@
+package repository
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrAuditChainBroken is returned when a record of the audit log does not
+// follow the one before it, that is when the log was changed after it was
+// written.
+var ErrAuditChainBroken = errors.New("audit log chain is broken")
+
+type AuditConfig struct {
+	Enabled bool `env:"AUDIT_ENABLED" envDefault:"true"`
+	// Path is the file the log is appended to. It should be outside the
+	// storage directory, where it cannot be reached through the API.
+	Path string `env:"AUDIT_FILE" envDefault:"/var/tmp/file-service-audit.jsonl"`
+}
+
+// AuditRecord is one call made to the service. Paths are in the form of
+// event paths. Hash is the SHA-256 of the record with an empty Hash, and
+// Prev the hash of the record before it, which chains the records so that
+// changing, removing or inserting one breaks the chain.
+type AuditRecord struct {
+	Seq         uint64        `json:"seq"`
+	Time        time.Time     `json:"time"`
+	Caller      string        `json:"caller,omitempty"`
+	AuthMethod  string        `json:"auth_method,omitempty"`
+	Remote      string        `json:"remote,omitempty"`
+	Operation   string        `json:"operation"`
+	Path        string        `json:"path,omitempty"`
+	Destination string        `json:"destination,omitempty"`
+	Bytes       int64         `json:"bytes"`
+	Status      string        `json:"status"`
+	Error       string        `json:"error,omitempty"`
+	Duration    time.Duration `json:"duration_ns"`
+	Prev        string        `json:"prev"`
+	Hash        string        `json:"hash,omitempty"`
+}
+
+// AuditFilter selects audit records. Zero fields match every record.
+type AuditFilter struct {
+	// PathPrefix matches records whose path or destination is the prefix or
+	// below it.
+	PathPrefix string
+	Caller     string
+	Since      time.Time
+	Until      time.Time
+	// Limit keeps only the latest matching records.
+	Limit int
+}
+
+// AuditLog is an append-only JSONL file of hash-chained audit records.
+type AuditLog struct {
+	mu   sync.Mutex
+	path string
+	file *os.File
+	size int64
+	seq  uint64
+	last string
+	torn int64
+}
+
+// OpenAuditLog opens the audit log at path, creating it if needed, and
+// continues the chain of its last complete record. A partial last line, left
+// by a crash during Append, is cut off; Torn tells its length. Records that
+// are not valid are left for Verify to report.
+func OpenAuditLog(path string) (*AuditLog, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, err
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, err
+	}
+
+	log := &AuditLog{path: path, file: file}
+	if err := log.recover(); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("error reading audit log %s: %w", path, err)
+	}
+	return log, nil
+}
+
+// recover reads the log to find its last complete record and cuts off a
+// partial line after it. A last line that only misses its line break is a
+// complete record and is kept.
+func (log *AuditLog) recover() error {
+	if _, err := log.file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	reader := bufio.NewReader(log.file)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			return err
+		}
+
+		var rec AuditRecord
+		complete := json.Unmarshal(line, &rec) == nil && rec.Hash != ""
+		if err == io.EOF {
+			if len(line) == 0 {
+				return nil
+			}
+			if complete {
+				if _, err := log.file.Write([]byte("\n")); err != nil {
+					return err
+				}
+				log.size += int64(len(line)) + 1
+				log.seq, log.last = rec.Seq, rec.Hash
+				return nil
+			}
+			log.torn = int64(len(line))
+			return log.file.Truncate(log.size)
+		}
+
+		log.size += int64(len(line))
+		if complete {
+			log.seq, log.last = rec.Seq, rec.Hash
+		}
+	}
+}
+
+// Torn returns the length of the partial last line cut off when the log was
+// opened, 0 if there was none.
+func (log *AuditLog) Torn() int64 {
+	return log.torn
+}
+
+// hashAudit returns the hash of rec, leaving out its own hash.
+func hashAudit(rec AuditRecord) (string, error) {
+	rec.Hash = ""
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// scanAudit calls fn with every record in r.
+func scanAudit(r io.Reader, fn func(rec AuditRecord) error) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var rec AuditRecord
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			return fmt.Errorf("%w: line %d is not a record: %v", ErrAuditChainBroken, line, err)
+		}
+		if err := fn(rec); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// Append numbers rec, chains it to the last record and writes it to the log.
+func (log *AuditLog) Append(rec AuditRecord) (AuditRecord, error) {
+	log.mu.Lock()
+	defer log.mu.Unlock()
+
+	if rec.Time.IsZero() {
+		rec.Time = time.Now()
+	}
+	rec.Time = rec.Time.UTC()
+	rec.Path, rec.Destination = auditPath(rec.Path), auditPath(rec.Destination)
+	rec.Seq, rec.Prev = log.seq+1, log.last
+
+	hash, err := hashAudit(rec)
+	if err != nil {
+		return AuditRecord{}, err
+	}
+	rec.Hash = hash
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return AuditRecord{}, err
+	}
+	n, err := log.file.Write(append(data, '\n'))
+	if err != nil {
+		// Do not leave a partial line for the next record to follow.
+		if n > 0 {
+			err = errors.Join(err, log.file.Truncate(log.size))
+		}
+		return AuditRecord{}, err
+	}
+
+	log.size += int64(n)
+	log.seq, log.last = rec.Seq, rec.Hash
+	return rec, nil
+}
+
+func auditPath(path string) string {
+	if path == "" {
+		return ""
+	}
+	return EventPath(path)
+}
+
+// snapshot opens the log for reading up to the last complete record, and
+// returns the number and hash of that record.
+func (log *AuditLog) snapshot() (io.ReadCloser, uint64, string, error) {
+	log.mu.Lock()
+	defer log.mu.Unlock()
+
+	info, err := log.file.Stat()
+	if err != nil {
+		return nil, 0, "", err
+	}
+	file, err := os.Open(log.path)
+	if err != nil {
+		return nil, 0, "", err
+	}
+	return struct {
+		io.Reader
+		io.Closer
+	}{io.LimitReader(file, info.Size()), file}, log.seq, log.last, nil
+}
+
+// Query returns the records matching f, oldest first.
+func (log *AuditLog) Query(f AuditFilter) ([]AuditRecord, error) {
+	r, _, _, err := log.snapshot()
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	prefix := auditPath(f.PathPrefix)
+	below := func(path string) bool {
+		return path != "" && (prefix == "" || path == prefix || strings.HasPrefix(path, prefix+"/"))
+	}
+
+	var records []AuditRecord
+	err = scanAudit(r, func(rec AuditRecord) error {
+		switch {
+		case prefix != "" && !below(rec.Path) && !below(rec.Destination):
+		case f.Caller != "" && rec.Caller != f.Caller:
+		case !f.Since.IsZero() && rec.Time.Before(f.Since):
+		case !f.Until.IsZero() && rec.Time.After(f.Until):
+		default:
+			records = append(records, rec)
+			if f.Limit > 0 && len(records) > 2*f.Limit {
+				records = append(records[:0], records[len(records)-f.Limit:]...)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if f.Limit > 0 && len(records) > f.Limit {
+		records = records[len(records)-f.Limit:]
+	}
+	return records, nil
+}
+
+// Verify checks the chain of the whole log and returns the number of records
+// in it. It fails with ErrAuditChainBroken at the first record that was
+// changed, removed or inserted.
+func (log *AuditLog) Verify() (uint64, error) {
+	r, seq, last, err := log.snapshot()
+	if err != nil {
+		return 0, err
+	}
+	defer r.Close()
+
+	var n uint64
+	var prev string
+	err = scanAudit(r, func(rec AuditRecord) error {
+		n++
+		if rec.Seq != n {
+			return fmt.Errorf("%w: record %d found where record %d was expected", ErrAuditChainBroken, rec.Seq, n)
+		}
+		if rec.Prev != prev {
+			return fmt.Errorf("%w: record %d does not follow the record before it", ErrAuditChainBroken, rec.Seq)
+		}
+		hash, err := hashAudit(rec)
+		if err != nil {
+			return err
+		}
+		if rec.Hash != hash {
+			return fmt.Errorf("%w: record %d was changed", ErrAuditChainBroken, rec.Seq)
+		}
+		prev = rec.Hash
+		return nil
+	})
+	if err != nil {
+		return n, err
+	}
+
+	if n != seq || prev != last {
+		return n, fmt.Errorf("%w: the log ends at record %d, record %d was written", ErrAuditChainBroken, n, seq)
+	}
+	return n, nil
+}
+
+func (log *AuditLog) Close() error {
+	return log.file.Close()
+}
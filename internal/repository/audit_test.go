@@ -0,0 +1,132 @@
+package repository
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAuditLog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit", "log.jsonl")
+	log, err := OpenAuditLog(path)
+	require.NoError(t, err)
+
+	start := time.Now().Add(-time.Hour)
+	for i, rec := range []AuditRecord{
+		{Caller: "alice", Operation: "/file_manager.FileService/Upload", Path: "/docs/a.txt", Bytes: 10, Status: "OK", Time: start},
+		{Caller: "bob", Operation: "/file_manager.FileService/Download", Path: "docs/a.txt", Bytes: 10, Status: "OK", Time: start.Add(time.Minute)},
+		{Caller: "alice", Operation: "/file_manager.FileService/MoveFile", Path: "docs/a.txt", Destination: "archive/a.txt", Status: "OK", Time: start.Add(2 * time.Minute)},
+		{Caller: "bob", Operation: "/file_manager.FileService/Delete", Path: "docs2/b.txt", Status: "NotFound", Error: "file not found", Time: start.Add(3 * time.Minute)},
+	} {
+		got, err := log.Append(rec)
+		require.NoError(t, err)
+		assert.Equal(t, uint64(i+1), got.Seq)
+		assert.NotEmpty(t, got.Hash)
+	}
+
+	records, err := log.Query(AuditFilter{PathPrefix: "docs"})
+	require.NoError(t, err)
+	require.Len(t, records, 3)
+	assert.Equal(t, "docs/a.txt", records[0].Path)
+	assert.Equal(t, records[0].Hash, records[1].Prev)
+
+	records, err = log.Query(AuditFilter{PathPrefix: "/archive/"})
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	assert.Equal(t, uint64(3), records[0].Seq)
+
+	records, err = log.Query(AuditFilter{Caller: "bob", Since: start.Add(30 * time.Second)})
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+
+	records, err = log.Query(AuditFilter{Until: start.Add(2 * time.Minute), Limit: 2})
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	assert.Equal(t, uint64(2), records[0].Seq)
+	assert.Equal(t, uint64(3), records[1].Seq)
+
+	n, err := log.Verify()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(4), n)
+
+	t.Run("Reopen", func(t *testing.T) {
+		require.NoError(t, log.Close())
+		log, err = OpenAuditLog(path)
+		require.NoError(t, err)
+
+		rec, err := log.Append(AuditRecord{Caller: "carol", Operation: "/file_manager.FileService/ListDir", Status: "OK"})
+		require.NoError(t, err)
+		assert.Equal(t, uint64(5), rec.Seq)
+		n, err := log.Verify()
+		require.NoError(t, err)
+		assert.Equal(t, uint64(5), n)
+	})
+
+	t.Run("Torn", func(t *testing.T) {
+		require.NoError(t, log.Close())
+		file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0)
+		require.NoError(t, err)
+		_, err = file.WriteString(`{"seq":6,"caller":"da`)
+		require.NoError(t, err)
+		require.NoError(t, file.Close())
+
+		log, err = OpenAuditLog(path)
+		require.NoError(t, err)
+		assert.Equal(t, int64(len(`{"seq":6,"caller":"da`)), log.Torn())
+		rec, err := log.Append(AuditRecord{Caller: "dave", Operation: "/file_manager.FileService/ListDir", Status: "OK"})
+		require.NoError(t, err)
+		assert.Equal(t, uint64(6), rec.Seq)
+		n, err := log.Verify()
+		require.NoError(t, err)
+		assert.Equal(t, uint64(6), n)
+
+		// A record that only misses its line break is kept.
+		require.NoError(t, log.Close())
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(path, bytes.TrimSuffix(data, []byte("\n")), 0o600))
+
+		log, err = OpenAuditLog(path)
+		require.NoError(t, err)
+		assert.Zero(t, log.Torn())
+		rec, err = log.Append(AuditRecord{Caller: "dave", Operation: "/file_manager.FileService/ListDir", Status: "OK"})
+		require.NoError(t, err)
+		assert.Equal(t, uint64(7), rec.Seq)
+		n, err = log.Verify()
+		require.NoError(t, err)
+		assert.Equal(t, uint64(7), n)
+	})
+
+	t.Run("Tampering", func(t *testing.T) {
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		lines := strings.SplitAfter(string(data), "\n")
+
+		changed := strings.Join(lines, "")
+		changed = strings.Replace(changed, `"caller":"bob"`, `"caller":"eve"`, 1)
+		require.NoError(t, os.WriteFile(path, []byte(changed), 0o600))
+		_, err = log.Verify()
+		assert.ErrorIs(t, err, ErrAuditChainBroken)
+		assert.ErrorContains(t, err, "record 2 was changed")
+
+		removed := lines[0] + strings.Join(lines[2:], "")
+		require.NoError(t, os.WriteFile(path, []byte(removed), 0o600))
+		_, err = log.Verify()
+		assert.ErrorIs(t, err, ErrAuditChainBroken)
+		// Only Verify fails on a broken chain, the log can still be opened.
+		reopened, err := OpenAuditLog(path)
+		require.NoError(t, err)
+		require.NoError(t, reopened.Close())
+
+		truncated := strings.Join(lines[:3], "")
+		require.NoError(t, os.WriteFile(path, []byte(truncated), 0o600))
+		n, err := log.Verify()
+		assert.ErrorIs(t, err, ErrAuditChainBroken)
+		assert.Equal(t, uint64(3), n)
+	})
+}
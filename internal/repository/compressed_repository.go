@@ -0,0 +1,741 @@
+package repository
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"github.com/JunBSer/FileManager/pkg/logger"
+	"github.com/klauspost/compress/zstd"
+	"go.uber.org/zap"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const (
+	compMagic       = "FMCOMPR1"
+	compEndMagic    = "FMCOMPND"
+	compHeaderSize  = len(compMagic) + 1
+	compTrailerSize = 4 + 8 + len(compEndMagic)
+	compIndexSize   = 8
+
+	MaxCompressionFrameSize = 64 << 20
+
+	// sniffLen is the number of bytes http.DetectContentType looks at.
+	sniffLen = 512
+)
+
+const (
+	CompressionNone = "none"
+	CompressionGzip = "gzip"
+	CompressionZstd = "zstd"
+)
+
+var ErrCorruptedCompression = errors.New("corrupted compressed file")
+
+type CompressionConfig struct {
+	Enabled bool `env:"FILE_COMPRESSION" envDefault:"false"`
+	// Rules is a comma separated list of pattern=algorithm pairs, see
+	// ParseCompressionPolicy.
+	Rules     string `env:"FILE_COMPRESSION_RULES" envDefault:"*=zstd"`
+	FrameSize int64  `env:"FILE_COMPRESSION_FRAME_SIZE" envDefault:"1048576"`
+}
+
+// CompressionPolicy selects the algorithm a file is stored with from its
+// path. Directory rules win over extension rules, and longer directories
+// over shorter ones. Files under SystemDir are never compressed, because
+// some of them are written in place chunk by chunk.
+type CompressionPolicy struct {
+	dirs []dirRule
+	exts map[string]string
+	def  string
+}
+
+type dirRule struct {
+	dir  string
+	algo string
+}
+
+// CompressedRepo compresses files with zstd or gzip before they reach
+// another backend, as chosen by a CompressionPolicy. Content that is already
+// compressed, or does not get smaller, is stored as it is. A compressed file
+// is a sequence of independently compressed frames followed by an index, so
+// reads can start at any offset. Writes go through a spool file and are
+// compressed when the handle is closed or committed.
+type CompressedRepo struct {
+	handleIO
+	inner     FileRepository
+	policy    *CompressionPolicy
+	frameSize int64
+}
+
+type codec interface {
+	compress(src []byte) ([]byte, error)
+	decompress(src []byte, size int64) ([]byte, error)
+}
+
+type gzipCodec struct{}
+
+type zstdCodec struct{}
+
+var codecs = map[byte]codec{1: gzipCodec{}, 2: zstdCodec{}}
+
+var codecIDs = map[string]byte{CompressionGzip: 1, CompressionZstd: 2}
+
+var (
+	zstdEncoder = sync.OnceValues(func() (*zstd.Encoder, error) { return zstd.NewWriter(nil) })
+	zstdDecoder = sync.OnceValues(func() (*zstd.Decoder, error) {
+		return zstd.NewReader(nil, zstd.WithDecoderMaxMemory(MaxCompressionFrameSize))
+	})
+)
+
+// compFrame is a compressed frame at off of size bytes holding plain bytes.
+type compFrame struct {
+	off   int64
+	size  int64
+	plain int64
+}
+
+// compressedReader reads a compressed file frame by frame. The last loaded
+// frame is cached.
+type compressedReader struct {
+	mu     sync.Mutex
+	inner  FileHandle
+	codec  codec
+	name   string
+	frames []compFrame
+	ends   []int64
+	size   int64
+	off    int64
+	frame  int
+	data   []byte
+	closed bool
+}
+
+// precompressedTypes are content types of formats that are compressed
+// already.
+var precompressedTypes = map[string]bool{
+	"application/x-gzip":           true,
+	"application/zip":              true,
+	"application/x-rar-compressed": true,
+	"application/ogg":              true,
+	"image/jpeg":                   true,
+	"image/png":                    true,
+	"image/gif":                    true,
+	"image/webp":                   true,
+	"audio/mpeg":                   true,
+	"video/mp4":                    true,
+	"video/webm":                   true,
+	"font/woff":                    true,
+	"font/woff2":                   true,
+}
+
+// precompressedMagic are signatures of compressed formats
+// http.DetectContentType does not know: zstd, xz, bzip2 and 7z.
+var precompressedMagic = [][]byte{
+	{0x28, 0xb5, 0x2f, 0xfd},
+	{0xfd, '7', 'z', 'X', 'Z', 0x00},
+	[]byte("BZh"),
+	{'7', 'z', 0xbc, 0xaf, 0x27, 0x1c},
+}
+
+// ParseCompressionPolicy parses rules such as "logs/=zstd,.csv=gzip,*=none".
+// A pattern ending with a slash is a directory, one starting with a dot an
+// extension and "*" matches every other file. Algorithms are zstd, gzip and
+// none; files no rule matches are not compressed.
+func ParseCompressionPolicy(rules string) (*CompressionPolicy, error) {
+	p := &CompressionPolicy{exts: map[string]string{}, def: CompressionNone}
+
+	for _, rule := range strings.Split(rules, ",") {
+		rule = strings.TrimSpace(rule)
+		if rule == "" {
+			continue
+		}
+
+		pattern, algo, ok := strings.Cut(rule, "=")
+		algo = strings.ToLower(strings.TrimSpace(algo))
+		pattern = strings.TrimSpace(pattern)
+		if !ok || pattern == "" {
+			return nil, fmt.Errorf("invalid compression rule %q", rule)
+		}
+		if _, known := codecIDs[algo]; !known && algo != CompressionNone {
+			return nil, fmt.Errorf("unknown compression algorithm %q", algo)
+		}
+
+		switch {
+		case pattern == "*":
+			p.def = algo
+		case strings.HasSuffix(pattern, "/"):
+			dir, err := CleanKey(pattern)
+			if err != nil {
+				return nil, fmt.Errorf("invalid compression rule %q: %w", rule, err)
+			}
+			p.dirs = append(p.dirs, dirRule{dir: dir, algo: algo})
+		case strings.HasPrefix(pattern, ".") && !strings.Contains(pattern, "/"):
+			p.exts[strings.ToLower(pattern)] = algo
+		default:
+			return nil, fmt.Errorf("invalid compression pattern %q", pattern)
+		}
+	}
+
+	sort.SliceStable(p.dirs, func(i, j int) bool { return len(p.dirs[i].dir) > len(p.dirs[j].dir) })
+	return p, nil
+}
+
+// Algorithm returns the algorithm files at path are stored with.
+func (p *CompressionPolicy) Algorithm(userPath string) string {
+	key, err := CleanKey(userPath)
+	if err != nil || IsSystemPath(key) {
+		return CompressionNone
+	}
+
+	for _, rule := range p.dirs {
+		if strings.HasPrefix(key, rule.dir+"/") {
+			return rule.algo
+		}
+	}
+	if algo, ok := p.exts[strings.ToLower(path.Ext(key))]; ok {
+		return algo
+	}
+	return p.def
+}
+
+// isPrecompressed reports whether head, the start of a file, belongs to a
+// compressed format.
+func isPrecompressed(head []byte) bool {
+	for _, magic := range precompressedMagic {
+		if bytes.HasPrefix(head, magic) {
+			return true
+		}
+	}
+
+	contentType, _, _ := strings.Cut(http.DetectContentType(head), ";")
+	return precompressedTypes[contentType]
+}
+
+func (gzipCodec) compress(src []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write(src); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (gzipCodec) decompress(src []byte, size int64) ([]byte, error) {
+	r, err := gzip.NewReader(bytes.NewReader(src))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	return io.ReadAll(io.LimitReader(r, size+1))
+}
+
+func (zstdCodec) compress(src []byte) ([]byte, error) {
+	enc, err := zstdEncoder()
+	if err != nil {
+		return nil, err
+	}
+	return enc.EncodeAll(src, make([]byte, 0, len(src)/2)), nil
+}
+
+func (zstdCodec) decompress(src []byte, size int64) ([]byte, error) {
+	dec, err := zstdDecoder()
+	if err != nil {
+		return nil, err
+	}
+	return dec.DecodeAll(src, make([]byte, 0, size))
+}
+
+func NewCompressed(inner FileRepository, policy *CompressionPolicy, frameSize int64) *CompressedRepo {
+	return &CompressedRepo{
+		handleIO:  handleIO{readSize: inner.GetReadSize()},
+		inner:     inner,
+		policy:    policy,
+		frameSize: frameSize,
+	}
+}
+
+// Unwrap returns the backend below repo.
+func (repo *CompressedRepo) Unwrap() FileRepository {
+	return repo.inner
+}
+
+// readCompressed reads the frame index of file. It returns nil if the file
+// is not compressed.
+func readCompressed(file FileHandle, name string) (*compressedReader, error) {
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	physical := info.Size()
+	if physical < int64(compHeaderSize) {
+		return nil, nil
+	}
+
+	if _, err = file.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	header := make([]byte, compHeaderSize)
+	if _, err = io.ReadFull(file, header); err != nil {
+		return nil, err
+	}
+	if !bytes.HasPrefix(header, []byte(compMagic)) {
+		return nil, nil
+	}
+
+	corrupted := func(reason string) error {
+		return fmt.Errorf("%w %s: %s", ErrCorruptedCompression, name, reason)
+	}
+
+	c, ok := codecs[header[len(compMagic)]]
+	if !ok {
+		return nil, corrupted("unknown algorithm")
+	}
+	if physical < int64(compHeaderSize+compTrailerSize) {
+		return nil, corrupted("short file")
+	}
+
+	if _, err = file.Seek(physical-int64(compTrailerSize), io.SeekStart); err != nil {
+		return nil, err
+	}
+	trailer := make([]byte, compTrailerSize)
+	if _, err = io.ReadFull(file, trailer); err != nil {
+		return nil, err
+	}
+	if string(trailer[12:]) != compEndMagic {
+		return nil, corrupted("missing trailer")
+	}
+
+	count := int64(binary.BigEndian.Uint32(trailer))
+	size := int64(binary.BigEndian.Uint64(trailer[4:]))
+	indexStart := physical - int64(compTrailerSize) - count*compIndexSize
+	if indexStart < int64(compHeaderSize) {
+		return nil, corrupted("short index")
+	}
+
+	if _, err = file.Seek(indexStart, io.SeekStart); err != nil {
+		return nil, err
+	}
+	index := make([]byte, count*compIndexSize)
+	if _, err = io.ReadFull(file, index); err != nil {
+		return nil, err
+	}
+
+	r := &compressedReader{inner: file, codec: c, name: name, size: size, frame: -1}
+	off, end := int64(compHeaderSize), int64(0)
+	for i := int64(0); i < count; i++ {
+		entry := index[i*compIndexSize:]
+		frame := compFrame{
+			off:   off,
+			size:  int64(binary.BigEndian.Uint32(entry)),
+			plain: int64(binary.BigEndian.Uint32(entry[4:])),
+		}
+		if frame.plain > MaxCompressionFrameSize {
+			return nil, corrupted("frame too large")
+		}
+
+		off += frame.size
+		end += frame.plain
+		r.frames = append(r.frames, frame)
+		r.ends = append(r.ends, end)
+	}
+	if off != indexStart || end != size {
+		return nil, corrupted("index does not match the content")
+	}
+	return r, nil
+}
+
+// reader returns a handle reading the plain content of file.
+func (repo *CompressedRepo) reader(file FileHandle, name string) (FileHandle, error) {
+	r, err := readCompressed(file, name)
+	if err != nil {
+		return nil, err
+	}
+	if r == nil {
+		_, err = file.Seek(0, io.SeekStart)
+		return file, err
+	}
+	return r, nil
+}
+
+// writeFrames compresses r into w and returns the number of bytes written.
+func (repo *CompressedRepo) writeFrames(w io.Writer, r io.Reader, algo string) (int64, error) {
+	id := codecIDs[algo]
+	c := codecs[id]
+
+	written := int64(0)
+	write := func(b []byte) error {
+		n, err := w.Write(b)
+		written += int64(n)
+		return err
+	}
+
+	if err := write(append([]byte(compMagic), id)); err != nil {
+		return written, err
+	}
+
+	var index []byte
+	count, size := uint32(0), uint64(0)
+	buf := make([]byte, repo.frameSize)
+	for {
+		n, err := io.ReadFull(r, buf)
+		if n > 0 {
+			frame, cErr := c.compress(buf[:n])
+			if cErr != nil {
+				return written, cErr
+			}
+			if cErr = write(frame); cErr != nil {
+				return written, cErr
+			}
+
+			index = binary.BigEndian.AppendUint32(index, uint32(len(frame)))
+			index = binary.BigEndian.AppendUint32(index, uint32(n))
+			count++
+			size += uint64(n)
+		}
+
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			break
+		}
+		if err != nil {
+			return written, err
+		}
+	}
+
+	trailer := binary.BigEndian.AppendUint32(index, count)
+	trailer = binary.BigEndian.AppendUint64(trailer, size)
+	return written, write(append(trailer, compEndMagic...))
+}
+
+// store writes the size bytes of r to path with the algorithm of the
+// policy. If r can seek, content that does not get smaller is stored plain.
+func (repo *CompressedRepo) store(ctx context.Context, path string, r io.Reader, size int64) error {
+	lg := logger.GetLoggerFromContext(ctx)
+
+	src := bufio.NewReaderSize(r, sniffLen)
+	head, _ := src.Peek(sniffLen)
+
+	algo := repo.policy.Algorithm(path)
+	if algo != CompressionNone && isPrecompressed(head) {
+		lg.Debug(ctx, "Skipping compression of compressed content", zap.String("path", path))
+		algo = CompressionNone
+	}
+
+	file, err := repo.inner.CreateTempFile(ctx, path)
+	if err != nil {
+		return err
+	}
+
+	if algo != CompressionNone {
+		var physical int64
+		physical, err = repo.writeFrames(file, src, algo)
+
+		seeker, ok := r.(io.Seeker)
+		if err == nil && physical >= size && ok {
+			lg.Debug(ctx, "Content does not compress, storing it plain", zap.String("path", path))
+			_ = repo.inner.DiscardTempFile(ctx, file)
+
+			if _, err = seeker.Seek(0, io.SeekStart); err != nil {
+				return err
+			}
+			if file, err = repo.inner.CreateTempFile(ctx, path); err != nil {
+				return err
+			}
+			algo = CompressionNone
+			src.Reset(r)
+		}
+	}
+	if err == nil && algo == CompressionNone {
+		_, err = io.Copy(file, src)
+	}
+
+	if err == nil {
+		err = repo.inner.CommitTempFile(ctx, file, path)
+	}
+	if err != nil {
+		_ = repo.inner.DiscardTempFile(ctx, file)
+		return err
+	}
+
+	lg.Debug(ctx, "File was stored", zap.String("path", path), zap.String("compression", algo))
+	return nil
+}
+
+func (repo *CompressedRepo) spool(ctx context.Context, path string, temp bool) (*spoolHandle, error) {
+	handle, err := newSpool(ctx, path, temp, repo.store)
+	if err != nil {
+		logger.GetLoggerFromContext(ctx).Error(ctx, "Error creating spool file", zap.Error(err))
+		return nil, err
+	}
+
+	return handle, nil
+}
+
+// GetFileHandle opens compressed files, and files the policy compresses, for
+// writing through a spool file. Other files are opened in the inner backend.
+func (repo *CompressedRepo) GetFileHandle(ctx context.Context, path string, openOption int) (FileHandle, error) {
+	lg := logger.GetLoggerFromContext(ctx)
+
+	file, err := repo.inner.GetFileHandle(ctx, path, Read)
+	if err != nil && (openOption == Read || !os.IsNotExist(err)) {
+		return nil, err
+	}
+
+	var current FileHandle
+	if file != nil {
+		if current, err = repo.reader(file, filepath.Base(path)); err != nil {
+			lg.Error(ctx, "Error opening compressed file", zap.String("path", path), zap.Error(err))
+			_ = file.Close()
+			return nil, err
+		}
+	}
+	if openOption == Read {
+		return current, nil
+	}
+
+	_, compressed := current.(*compressedReader)
+	if !compressed && repo.policy.Algorithm(path) == CompressionNone {
+		if current != nil {
+			_ = current.Close()
+		}
+		return repo.inner.GetFileHandle(ctx, path, openOption)
+	}
+
+	handle, err := repo.spool(ctx, path, false)
+	if err != nil {
+		if current != nil {
+			_ = current.Close()
+		}
+		return nil, err
+	}
+
+	if current != nil {
+		err = handle.fill(current)
+		_ = current.Close()
+		if err != nil {
+			lg.Error(ctx, "Error loading file", zap.String("path", path), zap.Error(err))
+			_ = handle.discard()
+			return nil, err
+		}
+		return handle, nil
+	}
+
+	created, err := repo.inner.GetFileHandle(ctx, path, CreateAndW)
+	if err == nil {
+		err = created.Close()
+	}
+	if err != nil {
+		_ = handle.discard()
+		return nil, err
+	}
+	return handle, nil
+}
+
+func (repo *CompressedRepo) MoveFile(ctx context.Context, srcPath, dstPath string) error {
+	return repo.inner.MoveFile(ctx, srcPath, dstPath)
+}
+
+func (repo *CompressedRepo) MoveFileExclusive(ctx context.Context, srcPath, dstPath string) error {
+	return moveExclusive(ctx, repo.inner, srcPath, dstPath)
+}
+
+// CopyFile copies the stored content, so the copy keeps the compression of
+// the source.
+func (repo *CompressedRepo) CopyFile(ctx context.Context, srcPath, dstPath string) error {
+	return copyFile(ctx, repo.inner, srcPath, dstPath)
+}
+
+func (repo *CompressedRepo) DeleteFile(ctx context.Context, path string) error {
+	return repo.inner.DeleteFile(ctx, path)
+}
+
+func (repo *CompressedRepo) ListDir(ctx context.Context, path string) ([]DirectoryEntry, error) {
+	return repo.inner.ListDir(ctx, path)
+}
+
+func (repo *CompressedRepo) CreateDir(ctx context.Context, path string) error {
+	return repo.inner.CreateDir(ctx, path)
+}
+
+// Stat reports the plain size of compressed files. PhysicalSize of the
+// result is the size they take in the inner backend.
+func (repo *CompressedRepo) Stat(ctx context.Context, path string) (fs.FileInfo, error) {
+	info, err := repo.inner.Stat(ctx, path)
+	if err != nil || info.IsDir() || info.Size() < int64(compHeaderSize+compTrailerSize) {
+		return info, err
+	}
+
+	file, err := repo.inner.GetFileHandle(ctx, path, Read)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	r, err := readCompressed(file, filepath.Base(path))
+	if err != nil || r == nil {
+		return info, err
+	}
+	return sizedFileInfo{FileInfo: info, size: r.size}, nil
+}
+
+func (repo *CompressedRepo) CreateTempFile(ctx context.Context, path string) (FileHandle, error) {
+	if repo.policy.Algorithm(path) == CompressionNone {
+		return repo.inner.CreateTempFile(ctx, path)
+	}
+	return repo.spool(ctx, path, true)
+}
+
+func (repo *CompressedRepo) CommitTempFile(ctx context.Context, file FileHandle, path string) error {
+	h, ok := file.(*spoolHandle)
+	if !ok {
+		return repo.inner.CommitTempFile(ctx, file, path)
+	}
+	if !h.temp {
+		return fmt.Errorf("file handle was not created by CreateTempFile")
+	}
+	defer h.discard()
+
+	h.key = path
+	if err := h.upload(ctx); err != nil {
+		logger.GetLoggerFromContext(ctx).Error(ctx, "Error storing file", zap.String("path", path), zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+func (repo *CompressedRepo) DiscardTempFile(ctx context.Context, file FileHandle) error {
+	h, ok := file.(*spoolHandle)
+	if !ok {
+		return repo.inner.DiscardTempFile(ctx, file)
+	}
+	if !h.temp {
+		return fmt.Errorf("file handle was not created by CreateTempFile")
+	}
+
+	h.discard()
+	return nil
+}
+
+func (r *compressedReader) load(index int) error {
+	if r.frame == index {
+		return nil
+	}
+
+	frame := r.frames[index]
+	if _, err := r.inner.Seek(frame.off, io.SeekStart); err != nil {
+		return err
+	}
+	buf := make([]byte, frame.size)
+	if _, err := io.ReadFull(r.inner, buf); err != nil {
+		return err
+	}
+
+	data, err := r.codec.decompress(buf, frame.plain)
+	if err != nil || int64(len(data)) != frame.plain {
+		return fmt.Errorf("%w %s: frame %d", ErrCorruptedCompression, r.name, index)
+	}
+
+	r.frame, r.data = index, data
+	return nil
+}
+
+func (r *compressedReader) Read(b []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return 0, os.ErrClosed
+	}
+	if r.off >= r.size {
+		return 0, io.EOF
+	}
+
+	n := 0
+	for n < len(b) && r.off < r.size {
+		index := sort.Search(len(r.ends), func(i int) bool { return r.ends[i] > r.off })
+		start := r.ends[index] - r.frames[index].plain
+
+		if err := r.load(index); err != nil {
+			return n, err
+		}
+
+		copied := copy(b[n:], r.data[r.off-start:])
+		n += copied
+		r.off += int64(copied)
+	}
+
+	return n, nil
+}
+
+func (r *compressedReader) Seek(offset int64, whence int) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return 0, os.ErrClosed
+	}
+
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += r.off
+	case io.SeekEnd:
+		offset += r.size
+	default:
+		return 0, fmt.Errorf("invalid whence %d", whence)
+	}
+
+	if offset < 0 {
+		return 0, fmt.Errorf("negative position %d", offset)
+	}
+	r.off = offset
+
+	return offset, nil
+}
+
+func (r *compressedReader) Write(b []byte) (int, error) {
+	return 0, fmt.Errorf("%s is opened for reading only", r.name)
+}
+
+func (r *compressedReader) Stat() (fs.FileInfo, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return nil, os.ErrClosed
+	}
+
+	info, err := r.inner.Stat()
+	if err != nil {
+		return nil, err
+	}
+	return sizedFileInfo{FileInfo: info, size: r.size}, nil
+}
+
+func (r *compressedReader) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return os.ErrClosed
+	}
+	r.closed = true
+	r.data = nil
+
+	return r.inner.Close()
+}
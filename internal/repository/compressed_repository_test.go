@@ -0,0 +1,172 @@
+package repository
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/rand"
+	"github.com/JunBSer/FileManager/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"strings"
+	"testing"
+)
+
+func newTestPolicy(t *testing.T, rules string) *CompressionPolicy {
+	policy, err := ParseCompressionPolicy(rules)
+	require.NoError(t, err)
+	return policy
+}
+
+func TestConformance_CompressedZstd(t *testing.T) {
+	runConformance(t, func(t *testing.T) FileRepository {
+		return NewCompressed(NewMemory(2048), newTestPolicy(t, "*=zstd"), 4)
+	})
+}
+
+func TestConformance_CompressedGzip(t *testing.T) {
+	runConformance(t, func(t *testing.T) FileRepository {
+		return NewCompressed(NewMemory(2048), newTestPolicy(t, "*=gzip"), 4)
+	})
+}
+
+func TestCompressionPolicy(t *testing.T) {
+	policy := newTestPolicy(t, "logs/=zstd, logs/raw/=none, .CSV=gzip, *=none")
+
+	assert.Equal(t, CompressionZstd, policy.Algorithm("logs/app.log"))
+	assert.Equal(t, CompressionZstd, policy.Algorithm("/logs/2024/export.csv"))
+	assert.Equal(t, CompressionNone, policy.Algorithm("logs/raw/app.log"))
+	assert.Equal(t, CompressionGzip, policy.Algorithm("exports/report.csv"))
+	assert.Equal(t, CompressionNone, policy.Algorithm("exports/report.txt"))
+	assert.Equal(t, CompressionNone, policy.Algorithm(".fm/versions/logs/app.log/1"))
+
+	assert.Equal(t, CompressionNone, newTestPolicy(t, "").Algorithm("file.txt"))
+
+	for _, rules := range []string{"logs/=brotli", "logs", "=zstd", "../up/=zstd"} {
+		_, err := ParseCompressionPolicy(rules)
+		assert.Error(t, err, rules)
+	}
+}
+
+func TestCompressedRepo(t *testing.T) {
+	ctx := context.Background()
+	lg := logger.New("test", "debug")
+	ctx = context.WithValue(ctx, logger.Key, lg)
+
+	readAll := func(t *testing.T, repo FileRepository, path string) []byte {
+		f, err := repo.GetFileHandle(ctx, path, Read)
+		require.NoError(t, err)
+		defer f.Close()
+
+		data, err := io.ReadAll(f)
+		require.NoError(t, err)
+		return data
+	}
+
+	upload := func(t *testing.T, repo FileRepository, path string, data []byte) {
+		f, err := repo.CreateTempFile(ctx, path)
+		require.NoError(t, err)
+		_, err = repo.AppendData(ctx, f, data, 0)
+		require.NoError(t, err)
+		require.NoError(t, repo.CommitTempFile(ctx, f, path))
+	}
+
+	logLines := []byte(strings.Repeat("2024-06-10 12:00:00 INFO request served in 12ms\n", 200))
+
+	t.Run("logs are stored compressed", func(t *testing.T) {
+		inner := NewMemory(2048)
+		repo := NewCompressed(inner, newTestPolicy(t, "logs/=zstd,.csv=gzip"), 1024)
+
+		upload(t, repo, "logs/app.log", logLines)
+		upload(t, repo, "exports/data.csv", logLines)
+		upload(t, repo, "exports/data.txt", logLines)
+
+		for _, path := range []string{"logs/app.log", "exports/data.csv"} {
+			assert.Equal(t, logLines, readAll(t, repo, path))
+			assert.True(t, bytes.HasPrefix(readAll(t, inner, path), []byte(compMagic)), path)
+
+			info, err := repo.Stat(ctx, path)
+			require.NoError(t, err)
+			assert.Equal(t, int64(len(logLines)), info.Size())
+			assert.Less(t, PhysicalSize(info), info.Size()/5)
+		}
+
+		assert.Equal(t, logLines, readAll(t, inner, "exports/data.txt"))
+		info, err := repo.Stat(ctx, "exports/data.txt")
+		require.NoError(t, err)
+		assert.Equal(t, info.Size(), PhysicalSize(info))
+	})
+
+	t.Run("range reads and appends", func(t *testing.T) {
+		repo := NewCompressed(NewMemory(2048), newTestPolicy(t, "*=zstd"), 100)
+		upload(t, repo, "app.log", logLines)
+
+		f, err := repo.GetFileHandle(ctx, "app.log", Read)
+		require.NoError(t, err)
+		buf, n, err := repo.ReadFile(ctx, f, 4970)
+		require.NoError(t, err)
+		assert.Equal(t, logLines[4970:4970+n], buf[:n])
+		require.NoError(t, f.Close())
+
+		f, err = repo.GetFileHandle(ctx, "app.log", Write)
+		require.NoError(t, err)
+		_, err = repo.AppendData(ctx, f, []byte("tail\n"), int64(len(logLines)))
+		require.NoError(t, err)
+		require.NoError(t, f.Close())
+
+		assert.Equal(t, append(append([]byte{}, logLines...), "tail\n"...), readAll(t, repo, "app.log"))
+	})
+
+	t.Run("compressed and random content is stored plain", func(t *testing.T) {
+		inner := NewMemory(2048)
+		repo := NewCompressed(inner, newTestPolicy(t, "*=zstd"), 1024)
+
+		var gz bytes.Buffer
+		w := gzip.NewWriter(&gz)
+		_, _ = w.Write(logLines)
+		require.NoError(t, w.Close())
+		upload(t, repo, "logs.gz", gz.Bytes())
+		assert.Equal(t, gz.Bytes(), readAll(t, inner, "logs.gz"))
+
+		random := make([]byte, 4096)
+		_, _ = rand.Read(random)
+		upload(t, repo, "random.bin", random)
+		assert.Equal(t, random, readAll(t, inner, "random.bin"))
+		assert.Equal(t, random, readAll(t, repo, "random.bin"))
+	})
+
+	t.Run("plain files stay readable", func(t *testing.T) {
+		inner := NewMemory(2048)
+		upload(t, inner, "old.log", logLines)
+
+		repo := NewCompressed(inner, newTestPolicy(t, "*=zstd"), 1024)
+		assert.Equal(t, logLines, readAll(t, repo, "old.log"))
+
+		// Rewriting a plain file stores it with the policy.
+		f, err := repo.GetFileHandle(ctx, "old.log", Write)
+		require.NoError(t, err)
+		_, err = repo.AppendData(ctx, f, []byte("new"), 0)
+		require.NoError(t, err)
+		require.NoError(t, f.Close())
+
+		assert.True(t, bytes.HasPrefix(readAll(t, inner, "old.log"), []byte(compMagic)))
+		assert.Equal(t, append([]byte("new"), logLines[3:]...), readAll(t, repo, "old.log"))
+	})
+
+	t.Run("corrupted frames are rejected", func(t *testing.T) {
+		inner := NewMemory(2048)
+		repo := NewCompressed(inner, newTestPolicy(t, "*=gzip"), 1024)
+		upload(t, repo, "app.log", logLines)
+
+		raw := readAll(t, inner, "app.log")
+		raw[compHeaderSize+20] ^= 0xff
+		upload(t, inner, "app.log", raw)
+
+		f, err := repo.GetFileHandle(ctx, "app.log", Read)
+		require.NoError(t, err)
+		defer f.Close()
+		_, err = io.ReadAll(f)
+		assert.ErrorIs(t, err, ErrCorruptedCompression)
+	})
+}
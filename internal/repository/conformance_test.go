@@ -0,0 +1,356 @@
+package repository
+
+import (
+	"context"
+	"github.com/JunBSer/FileManager/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"os"
+	"sort"
+	"testing"
+)
+
+// runConformance checks the behaviour every FileRepository backend must
+// share. It only goes through the interface, so newRepo may return any
+// backend with an empty storage.
+func runConformance(t *testing.T, newRepo func(t *testing.T) FileRepository) {
+	ctx := context.Background()
+	lg := logger.New("test", "debug")
+	ctx = context.WithValue(ctx, logger.Key, lg)
+
+	writeFile := func(t *testing.T, repo FileRepository, path, data string) {
+		f, err := repo.GetFileHandle(ctx, path, CreateAndW)
+		require.NoError(t, err)
+		_, err = repo.AppendData(ctx, f, []byte(data), 0)
+		require.NoError(t, err)
+		require.NoError(t, f.Close())
+	}
+
+	readFile := func(t *testing.T, repo FileRepository, path string) string {
+		f, err := repo.GetFileHandle(ctx, path, Read)
+		require.NoError(t, err)
+		defer f.Close()
+
+		data, err := io.ReadAll(f)
+		require.NoError(t, err)
+		return string(data)
+	}
+
+	t.Run("GetFileHandle", func(t *testing.T) {
+		repo := newRepo(t)
+
+		f, err := repo.GetFileHandle(ctx, "newfile.txt", CreateAndW)
+		require.NoError(t, err)
+		info, err := f.Stat()
+		require.NoError(t, err)
+		assert.Equal(t, "newfile.txt", info.Name())
+		require.NoError(t, f.Close())
+
+		f, err = repo.GetFileHandle(ctx, "newfile.txt", Read)
+		require.NoError(t, err)
+		require.NoError(t, f.Close())
+
+		_, err = repo.GetFileHandle(ctx, "missing.txt", Read)
+		assert.True(t, os.IsNotExist(err))
+
+		_, err = repo.GetFileHandle(ctx, "../invalid.txt", CreateAndW)
+		assert.Error(t, err)
+	})
+
+	t.Run("AppendData", func(t *testing.T) {
+		repo := newRepo(t)
+
+		f, err := repo.GetFileHandle(ctx, "append_test.txt", CreateAndW)
+		require.NoError(t, err)
+
+		written, err := repo.AppendData(ctx, f, []byte("hello "), 0)
+		require.NoError(t, err)
+		require.Equal(t, int64(6), written)
+
+		written, err = repo.AppendData(ctx, f, []byte("world"), 6)
+		require.NoError(t, err)
+		require.Equal(t, int64(5), written)
+		require.NoError(t, f.Close())
+
+		assert.Equal(t, "hello world", readFile(t, repo, "append_test.txt"))
+
+		_, err = repo.AppendData(ctx, f, []byte("test"), 0)
+		require.Error(t, err)
+	})
+
+	t.Run("Reopen keeps content", func(t *testing.T) {
+		repo := newRepo(t)
+		writeFile(t, repo, "reopen.txt", "hello")
+
+		f, err := repo.GetFileHandle(ctx, "reopen.txt", Write)
+		require.NoError(t, err)
+		_, err = repo.AppendData(ctx, f, []byte(" world"), 5)
+		require.NoError(t, err)
+		require.NoError(t, f.Close())
+
+		assert.Equal(t, "hello world", readFile(t, repo, "reopen.txt"))
+	})
+
+	t.Run("ReadFile", func(t *testing.T) {
+		repo := newRepo(t)
+		testData := "this is test data for reading"
+		writeFile(t, repo, "read_test.txt", testData)
+
+		f, err := repo.GetFileHandle(ctx, "read_test.txt", Read)
+		require.NoError(t, err)
+		defer f.Close()
+
+		info, err := f.Stat()
+		require.NoError(t, err)
+		assert.Equal(t, int64(len(testData)), info.Size())
+
+		buf, n, err := repo.ReadFile(ctx, f, 0)
+		require.NoError(t, err)
+		require.Equal(t, testData, string(buf[:n]))
+
+		buf, n, err = repo.ReadFile(ctx, f, 5)
+		require.NoError(t, err)
+		require.Equal(t, testData[5:], string(buf[:n]))
+
+		_, _, err = repo.ReadFile(ctx, f, int64(len(testData)+10))
+		require.Equal(t, io.EOF, err)
+	})
+
+	t.Run("DeleteFile", func(t *testing.T) {
+		repo := newRepo(t)
+		writeFile(t, repo, "delete_file.txt", "Hello World!")
+
+		require.NoError(t, repo.DeleteFile(ctx, "delete_file.txt"))
+		_, err := repo.GetFileHandle(ctx, "delete_file.txt", Read)
+		assert.True(t, os.IsNotExist(err))
+
+		err = repo.DeleteFile(ctx, "non_existing_file.txt")
+		require.Error(t, err)
+		assert.True(t, os.IsNotExist(err))
+
+		writeFile(t, repo, "full/file.txt", "data")
+		err = repo.DeleteFile(ctx, "full")
+		require.Error(t, err)
+		assert.False(t, os.IsNotExist(err))
+	})
+
+	t.Run("ListDir", func(t *testing.T) {
+		repo := newRepo(t)
+		writeFile(t, repo, "test_list_dir/file1.txt", "content")
+		writeFile(t, repo, "test_list_dir/file2.jpg", "content")
+		writeFile(t, repo, "test_list_dir/subdir/nested.txt", "content")
+
+		entries, err := repo.ListDir(ctx, "test_list_dir")
+		require.NoError(t, err)
+		sort.Slice(entries, func(i, j int) bool { return entries[i].Name < entries[j].Name })
+		assert.Equal(t, []DirectoryEntry{
+			{Name: "file1.txt"},
+			{Name: "file2.jpg"},
+			{Name: "subdir", IsDir: true},
+		}, entries)
+
+		_, err = repo.ListDir(ctx, "non_existent_dir")
+		require.Error(t, err)
+		assert.True(t, os.IsNotExist(err))
+	})
+
+	t.Run("ListDir root", func(t *testing.T) {
+		repo := newRepo(t)
+
+		entries, err := repo.ListDir(ctx, "/")
+		require.NoError(t, err)
+		assert.Empty(t, entries)
+
+		writeFile(t, repo, "top.txt", "content")
+		writeFile(t, repo, "dir/nested.txt", "content")
+
+		for _, root := range []string{"", "/", "."} {
+			entries, err = repo.ListDir(ctx, root)
+			require.NoError(t, err)
+			sort.Slice(entries, func(i, j int) bool { return entries[i].Name < entries[j].Name })
+			assert.Equal(t, []DirectoryEntry{{Name: "dir", IsDir: true}, {Name: "top.txt"}}, entries)
+		}
+
+		_, err = repo.ListDir(ctx, "..")
+		assert.Error(t, err)
+	})
+
+	t.Run("MoveFile", func(t *testing.T) {
+		repo := newRepo(t)
+		writeFile(t, repo, "source.txt", "test content")
+
+		require.NoError(t, repo.MoveFile(ctx, "source.txt", "nested/dest.txt"))
+		_, err := repo.GetFileHandle(ctx, "source.txt", Read)
+		assert.True(t, os.IsNotExist(err))
+		assert.Equal(t, "test content", readFile(t, repo, "nested/dest.txt"))
+
+		require.NoError(t, repo.MoveFile(ctx, "nested", "moved"))
+		assert.Equal(t, "test content", readFile(t, repo, "moved/dest.txt"))
+
+		err = repo.MoveFile(ctx, "missing.txt", "new.txt")
+		require.Error(t, err)
+		assert.True(t, os.IsNotExist(err))
+
+		assert.Error(t, repo.MoveFile(ctx, "moved/dest.txt", "../../etc/passwd"))
+	})
+
+	t.Run("MoveFile exclusive", func(t *testing.T) {
+		repo := newRepo(t)
+		writeFile(t, repo, "source.txt", "source")
+		writeFile(t, repo, "taken.txt", "taken")
+		writeFile(t, repo, "dir/file.txt", "in dir")
+		writeFile(t, repo, "other/file.txt", "in other")
+
+		err := moveExclusive(ctx, repo, "source.txt", "taken.txt")
+		assert.True(t, os.IsExist(err), err)
+		assert.Equal(t, "source", readFile(t, repo, "source.txt"))
+		assert.Equal(t, "taken", readFile(t, repo, "taken.txt"))
+
+		err = moveExclusive(ctx, repo, "dir", "other")
+		assert.True(t, os.IsExist(err), err)
+		assert.Equal(t, "in other", readFile(t, repo, "other/file.txt"))
+
+		require.NoError(t, moveExclusive(ctx, repo, "source.txt", "nested/dest.txt"))
+		assert.Equal(t, "source", readFile(t, repo, "nested/dest.txt"))
+		_, err = repo.Stat(ctx, "source.txt")
+		assert.True(t, os.IsNotExist(err))
+
+		require.NoError(t, moveExclusive(ctx, repo, "dir", "moved"))
+		assert.Equal(t, "in dir", readFile(t, repo, "moved/file.txt"))
+	})
+
+	t.Run("Stat", func(t *testing.T) {
+		repo := newRepo(t)
+		writeFile(t, repo, "stat/file.txt", "content")
+
+		info, err := repo.Stat(ctx, "stat/file.txt")
+		require.NoError(t, err)
+		assert.Equal(t, "file.txt", info.Name())
+		assert.Equal(t, int64(7), info.Size())
+		assert.False(t, info.IsDir())
+
+		info, err = repo.Stat(ctx, "stat")
+		require.NoError(t, err)
+		assert.True(t, info.IsDir())
+
+		_, err = repo.Stat(ctx, "stat/missing.txt")
+		assert.True(t, os.IsNotExist(err))
+	})
+
+	t.Run("CreateDir", func(t *testing.T) {
+		repo := newRepo(t)
+		writeFile(t, repo, "file.txt", "content")
+
+		require.NoError(t, repo.CreateDir(ctx, "empty/nested"))
+		require.NoError(t, repo.CreateDir(ctx, "empty/nested"))
+
+		entries, err := repo.ListDir(ctx, "empty")
+		require.NoError(t, err)
+		assert.Equal(t, []DirectoryEntry{{Name: "nested", IsDir: true}}, entries)
+
+		entries, err = repo.ListDir(ctx, "empty/nested")
+		require.NoError(t, err)
+		assert.Empty(t, entries)
+
+		assert.Error(t, repo.DeleteFile(ctx, "empty"))
+		require.NoError(t, repo.MoveFile(ctx, "empty", "moved_empty"))
+		require.NoError(t, repo.DeleteFile(ctx, "moved_empty/nested"))
+		_, err = repo.ListDir(ctx, "moved_empty/nested")
+		assert.True(t, os.IsNotExist(err))
+
+		assert.Error(t, repo.CreateDir(ctx, "file.txt"))
+		assert.Error(t, repo.CreateDir(ctx, "file.txt/sub"))
+		assert.Equal(t, "content", readFile(t, repo, "file.txt"))
+	})
+
+	t.Run("TempFile", func(t *testing.T) {
+		repo := newRepo(t)
+		writeFile(t, repo, "atomic/report.txt", "old")
+
+		f, err := repo.CreateTempFile(ctx, "atomic/report.txt")
+		require.NoError(t, err)
+		_, err = repo.AppendData(ctx, f, []byte("new content"), 0)
+		require.NoError(t, err)
+
+		entries, err := repo.ListDir(ctx, "atomic")
+		require.NoError(t, err)
+		assert.Equal(t, []DirectoryEntry{{Name: "report.txt"}}, entries)
+		assert.Equal(t, "old", readFile(t, repo, "atomic/report.txt"))
+
+		require.NoError(t, repo.CommitTempFile(ctx, f, "atomic/report.txt"))
+		assert.Equal(t, "new content", readFile(t, repo, "atomic/report.txt"))
+
+		f, err = repo.CreateTempFile(ctx, "atomic/report.txt")
+		require.NoError(t, err)
+		_, err = repo.AppendData(ctx, f, []byte("half"), 0)
+		require.NoError(t, err)
+		require.NoError(t, repo.DiscardTempFile(ctx, f))
+		assert.Equal(t, "new content", readFile(t, repo, "atomic/report.txt"))
+	})
+}
+
+func TestConformance_Local(t *testing.T) {
+	runConformance(t, func(t *testing.T) FileRepository {
+		fullPath := CreateTempDir(t)
+		t.Cleanup(func() { os.RemoveAll(fullPath) })
+
+		return New(relPath, 1024*1024, 2048)
+	})
+}
+
+func TestConformance_Memory(t *testing.T) {
+	runConformance(t, func(t *testing.T) FileRepository {
+		return NewMemory(2048)
+	})
+}
+
+func TestConformance_S3(t *testing.T) {
+	runConformance(t, func(t *testing.T) FileRepository {
+		cfg := newS3Stub(t, S3Config{Region: "us-east-1", Bucket: "files", Prefix: "storage", AccessKey: "key", SecretKey: "secret"})
+
+		repo, err := NewS3(cfg, 2048)
+		require.NoError(t, err)
+		return repo
+	})
+}
+
+func TestOpen(t *testing.T) {
+	ctx := context.WithValue(context.Background(), logger.Key, logger.New("test", "debug"))
+
+	assert.Equal(t, []string{"local", "memory", "s3"}, Backends())
+
+	repo, err := Open(ctx, FileStorageConfig{Backend: "memory", ReadSize: 2048})
+	require.NoError(t, err)
+	assert.IsType(t, &MemoryRepo{}, repo)
+
+	_, err = Open(ctx, FileStorageConfig{Backend: "tape"})
+	assert.Error(t, err)
+
+	repo, err = Open(ctx, FileStorageConfig{
+		Backend:     "memory",
+		ReadSize:    2048,
+		Dedup:       DedupConfig{Enabled: true, ChunkSize: 1024},
+		Compression: CompressionConfig{Enabled: true, Rules: "*=zstd", FrameSize: 1024},
+	})
+	require.NoError(t, err)
+	assert.IsType(t, &CompressedRepo{}, repo)
+	_, ok := Layer[*DedupRepo](repo)
+	assert.True(t, ok)
+	_, ok = Layer[*EncryptedRepo](repo)
+	assert.False(t, ok)
+
+	repo, err = Open(ctx, FileStorageConfig{
+		Backend:  "memory",
+		ReadSize: 2048,
+		Quota:    QuotaConfig{Enabled: true, MaxBytes: 1024, Limits: "team-a=2048/10"},
+	})
+	require.NoError(t, err)
+	quotas, ok := Layer[*QuotaRepo](repo)
+	require.True(t, ok)
+	assert.Equal(t, QuotaLimit{Bytes: 2048, Files: 10}, quotas.Limit("team-a"))
+	assert.Equal(t, QuotaLimit{Bytes: 1024}, quotas.Limit("team-b"))
+
+	_, err = Open(ctx, FileStorageConfig{Backend: "memory", Quota: QuotaConfig{Enabled: true, Limits: "team-a=lots"}})
+	assert.Error(t, err)
+}
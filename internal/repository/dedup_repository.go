@@ -0,0 +1,710 @@
+package repository
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/JunBSer/FileManager/pkg/logger"
+	"go.uber.org/zap"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	dedupDir = filepath.Join(SystemDir, "dedup")
+	blobsDir = filepath.Join(dedupDir, "blobs")
+	indexDir = filepath.Join(dedupDir, "index")
+)
+
+type DedupConfig struct {
+	Enabled    bool          `env:"FILE_DEDUP" envDefault:"false"`
+	ChunkSize  int64         `env:"FILE_DEDUP_CHUNK_SIZE" envDefault:"1048576"`
+	GCInterval time.Duration `env:"FILE_DEDUP_GC_INTERVAL" envDefault:"1h"`
+}
+
+// Copier is implemented by backends that copy files without going through
+// a FileHandle.
+type Copier interface {
+	CopyFile(ctx context.Context, srcPath, dstPath string) error
+}
+
+// DedupRepo is a content-addressable layer over another backend. File bodies
+// are split into chunks stored once per SHA-256 under blobsDir, and every path
+// maps to a JSON manifest under indexDir listing its chunks. Moving and
+// copying files only touch manifests; unreferenced chunks are removed by GC.
+type DedupRepo struct {
+	handleIO
+	inner     FileRepository
+	chunkSize int64
+	refs      blobRefs
+	gcMu      sync.Mutex
+	// movesMu is held for writing by GC while it walks the manifests, so
+	// a manifest cannot move past the walk unseen.
+	movesMu sync.RWMutex
+}
+
+type manifest struct {
+	Size    int64      `json:"size"`
+	ModTime time.Time  `json:"mod_time"`
+	Chunks  []chunkRef `json:"chunks"`
+}
+
+type chunkRef struct {
+	Hash string `json:"hash"`
+	Size int64  `json:"size"`
+}
+
+// blobRefs tracks chunks used by writes that have not committed their
+// manifest yet. While GC runs, every chunk touched by a write is remembered
+// until GC ends, so a chunk GC found unreferenced is never removed under a
+// write that is about to reference it.
+type blobRefs struct {
+	mu      sync.Mutex
+	pending map[string]int
+	running bool
+	touched map[string]bool
+}
+
+type GCStats struct {
+	Blobs   int
+	Removed int
+}
+
+// dedupReader reads a file chunk by chunk. The last loaded chunk is cached.
+type dedupReader struct {
+	mu       sync.Mutex
+	ctx      context.Context
+	repo     *DedupRepo
+	name     string
+	manifest *manifest
+	ends     []int64
+	off      int64
+	chunk    int
+	data     []byte
+	closed   bool
+}
+
+func NewDedup(inner FileRepository, chunkSize int64) *DedupRepo {
+	return &DedupRepo{
+		handleIO:  handleIO{readSize: inner.GetReadSize()},
+		inner:     inner,
+		chunkSize: chunkSize,
+		refs:      blobRefs{pending: map[string]int{}},
+	}
+}
+
+func newDedupReader(ctx context.Context, repo *DedupRepo, name string, m *manifest) *dedupReader {
+	ends := make([]int64, len(m.Chunks))
+	end := int64(0)
+	for i, chunk := range m.Chunks {
+		end += chunk.Size
+		ends[i] = end
+	}
+
+	return &dedupReader{ctx: ctx, repo: repo, name: name, manifest: m, ends: ends, chunk: -1}
+}
+
+// Unwrap returns the backend below repo.
+func (repo *DedupRepo) Unwrap() FileRepository {
+	return repo.inner
+}
+
+func blobPath(hash string) string {
+	return filepath.Join(blobsDir, hash[:2], hash)
+}
+
+func indexPath(key string) string {
+	return filepath.Join(indexDir, key)
+}
+
+func isDedupPath(key string) bool {
+	return key == dedupDir || strings.HasPrefix(key, dedupDir+"/")
+}
+
+func (r *blobRefs) acquire(hash string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.pending[hash]++
+	if r.running {
+		r.touched[hash] = true
+	}
+}
+
+func (r *blobRefs) release(hashes []string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, hash := range hashes {
+		if r.pending[hash]--; r.pending[hash] <= 0 {
+			delete(r.pending, hash)
+		}
+		if r.running {
+			r.touched[hash] = true
+		}
+	}
+}
+
+// dedupKey cleans a user path and rejects the paths used by the store itself.
+func dedupKey(userPath string) (string, error) {
+	key, err := CleanKey(userPath)
+	if err != nil {
+		return "", err
+	}
+	if isDedupPath(key) {
+		return "", fmt.Errorf("path %q is reserved", userPath)
+	}
+	return key, nil
+}
+
+// renamePathError replaces the internal path in not-exist errors of the
+// inner backend with the path of the caller.
+func renamePathError(err error, op, userPath string) error {
+	if os.IsNotExist(err) {
+		return notExist(op, userPath)
+	}
+	return err
+}
+
+func (repo *DedupRepo) readAll(ctx context.Context, p string) ([]byte, error) {
+	file, err := repo.inner.GetFileHandle(ctx, p, Read)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return io.ReadAll(file)
+}
+
+// writeAll atomically replaces the content of p in the inner backend.
+func (repo *DedupRepo) writeAll(ctx context.Context, p string, data []byte) error {
+	file, err := repo.inner.CreateTempFile(ctx, p)
+	if err != nil {
+		return err
+	}
+
+	if _, err = repo.inner.AppendData(ctx, file, data, 0); err == nil {
+		err = repo.inner.CommitTempFile(ctx, file, p)
+	}
+	if err != nil {
+		_ = repo.inner.DiscardTempFile(ctx, file)
+		return err
+	}
+
+	return nil
+}
+
+func (repo *DedupRepo) readManifest(ctx context.Context, p string) (*manifest, error) {
+	data, err := repo.readAll(ctx, p)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &manifest{}
+	if err = json.Unmarshal(data, m); err != nil {
+		return nil, fmt.Errorf("corrupted manifest %s: %w", p, err)
+	}
+	return m, nil
+}
+
+func (repo *DedupRepo) writeManifest(ctx context.Context, key string, m *manifest) error {
+	data, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	return repo.writeAll(ctx, indexPath(key), data)
+}
+
+func (repo *DedupRepo) hasBlob(ctx context.Context, hash string) (bool, error) {
+	file, err := repo.inner.GetFileHandle(ctx, blobPath(hash), Read)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, file.Close()
+}
+
+// store splits r into chunks, writes the missing ones and commits a manifest
+// for key. Chunks stay protected from GC until the manifest is written.
+func (repo *DedupRepo) store(ctx context.Context, key string, r io.Reader, size int64) error {
+	var hashes []string
+	defer func() { repo.refs.release(hashes) }()
+
+	m := &manifest{Size: size, ModTime: time.Now()}
+	buf := make([]byte, repo.chunkSize)
+	for {
+		n, err := io.ReadFull(r, buf)
+		if n > 0 {
+			sum := sha256.Sum256(buf[:n])
+			hash := hex.EncodeToString(sum[:])
+
+			repo.refs.acquire(hash)
+			hashes = append(hashes, hash)
+
+			exists, hErr := repo.hasBlob(ctx, hash)
+			if hErr == nil && !exists {
+				hErr = repo.writeAll(ctx, blobPath(hash), buf[:n])
+			}
+			if hErr != nil {
+				return hErr
+			}
+
+			m.Chunks = append(m.Chunks, chunkRef{Hash: hash, Size: int64(n)})
+		}
+
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := repo.writeManifest(ctx, key, m); err != nil {
+		return err
+	}
+
+	logger.GetLoggerFromContext(ctx).Debug(ctx, "File was stored",
+		zap.String("path", key), zap.Int("chunks", len(m.Chunks)))
+	return nil
+}
+
+func (repo *DedupRepo) spool(ctx context.Context, key string, temp bool) (*spoolHandle, error) {
+	handle, err := newSpool(ctx, key, temp, repo.store)
+	if err != nil {
+		logger.GetLoggerFromContext(ctx).Error(ctx, "Error creating spool file", zap.Error(err))
+		return nil, err
+	}
+
+	return handle, nil
+}
+
+func (repo *DedupRepo) GetFileHandle(ctx context.Context, path string, openOption int) (FileHandle, error) {
+	lg := logger.GetLoggerFromContext(ctx)
+
+	key, err := dedupKey(path)
+	if err != nil {
+		lg.Debug(ctx, "Error to open file: path is invalid", zap.String("path", path))
+		return nil, err
+	}
+
+	m, err := repo.readManifest(ctx, indexPath(key))
+	if err != nil && !os.IsNotExist(err) {
+		lg.Error(ctx, "Error reading manifest", zap.String("path", key), zap.Error(err))
+		return nil, err
+	}
+
+	if openOption == Read {
+		if m == nil {
+			return nil, notExist("open", path)
+		}
+		return newDedupReader(ctx, repo, filepath.Base(key), m), nil
+	}
+
+	handle, err := repo.spool(ctx, key, false)
+	if err != nil {
+		return nil, err
+	}
+
+	if m != nil {
+		reader := newDedupReader(ctx, repo, filepath.Base(key), m)
+		if err = handle.fill(reader); err != nil {
+			lg.Error(ctx, "Error loading file", zap.String("path", key), zap.Error(err))
+			handle.discard()
+			return nil, err
+		}
+		return handle, nil
+	}
+
+	if err = repo.writeManifest(ctx, key, &manifest{ModTime: time.Now()}); err != nil {
+		lg.Error(ctx, "Error creating file", zap.String("path", key), zap.Error(err))
+		handle.discard()
+		return nil, err
+	}
+	lg.Info(ctx, "File was created", zap.String("path", key))
+
+	return handle, nil
+}
+
+// MoveFile moves the manifest of a file or a whole directory of manifests.
+func (repo *DedupRepo) MoveFile(ctx context.Context, srcPath, dstPath string) error {
+	return repo.move(ctx, srcPath, dstPath, false)
+}
+
+func (repo *DedupRepo) MoveFileExclusive(ctx context.Context, srcPath, dstPath string) error {
+	return repo.move(ctx, srcPath, dstPath, true)
+}
+
+func (repo *DedupRepo) move(ctx context.Context, srcPath, dstPath string, exclusive bool) error {
+	srcKey, err := dedupKey(srcPath)
+	if err != nil {
+		return err
+	}
+	dstKey, err := dedupKey(dstPath)
+	if err != nil {
+		return err
+	}
+
+	repo.movesMu.RLock()
+	defer repo.movesMu.RUnlock()
+
+	err = moveWith(repo.inner, exclusive)(ctx, indexPath(srcKey), indexPath(dstKey))
+	if os.IsExist(err) {
+		return alreadyExists("rename", dstPath)
+	}
+	return renamePathError(err, "rename", srcPath)
+}
+
+// CopyFile writes a second manifest referencing the chunks of srcPath.
+func (repo *DedupRepo) CopyFile(ctx context.Context, srcPath, dstPath string) error {
+	srcKey, err := dedupKey(srcPath)
+	if err != nil {
+		return err
+	}
+	dstKey, err := dedupKey(dstPath)
+	if err != nil {
+		return err
+	}
+
+	var hashes []string
+	defer func() { repo.refs.release(hashes) }()
+
+	m, err := repo.readManifest(ctx, indexPath(srcKey))
+	if err != nil {
+		return renamePathError(err, "copy", srcPath)
+	}
+	for _, chunk := range m.Chunks {
+		repo.refs.acquire(chunk.Hash)
+		hashes = append(hashes, chunk.Hash)
+	}
+
+	// The source may have been replaced and collected in the meantime.
+	for _, chunk := range m.Chunks {
+		exists, err := repo.hasBlob(ctx, chunk.Hash)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return notExist("copy", srcPath)
+		}
+	}
+
+	m.ModTime = time.Now()
+	return repo.writeManifest(ctx, dstKey, m)
+}
+
+func (repo *DedupRepo) DeleteFile(ctx context.Context, path string) error {
+	key, err := dedupKey(path)
+	if err != nil {
+		return err
+	}
+
+	err = repo.inner.DeleteFile(ctx, indexPath(key))
+	return renamePathError(err, "remove", path)
+}
+
+func (repo *DedupRepo) ListDir(ctx context.Context, path string) ([]DirectoryEntry, error) {
+	if isRoot(path) {
+		entries, err := repo.inner.ListDir(ctx, indexDir)
+		if os.IsNotExist(err) {
+			return []DirectoryEntry{}, nil
+		}
+		return entries, err
+	}
+
+	key, err := dedupKey(path)
+	if err != nil {
+		logger.GetLoggerFromContext(ctx).Debug(ctx, "Error to list dir: path is invalid")
+		return nil, err
+	}
+
+	entries, err := repo.inner.ListDir(ctx, indexPath(key))
+	return entries, renamePathError(err, "readdir", path)
+}
+
+func (repo *DedupRepo) Stat(ctx context.Context, path string) (fs.FileInfo, error) {
+	key, err := dedupKey(path)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := repo.inner.Stat(ctx, indexPath(key))
+	if err != nil || info.IsDir() {
+		return info, renamePathError(err, "stat", path)
+	}
+
+	m, err := repo.readManifest(ctx, indexPath(key))
+	if err != nil {
+		return nil, renamePathError(err, "stat", path)
+	}
+	return memFileInfo{name: filepath.Base(key), size: m.Size, modTime: m.ModTime}, nil
+}
+
+func (repo *DedupRepo) CreateDir(ctx context.Context, path string) error {
+	key, err := dedupKey(path)
+	if err != nil {
+		return err
+	}
+
+	err = repo.inner.CreateDir(ctx, indexPath(key))
+	return renamePathError(err, "mkdir", path)
+}
+
+func (repo *DedupRepo) CreateTempFile(ctx context.Context, path string) (FileHandle, error) {
+	key, err := dedupKey(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return repo.spool(ctx, key, true)
+}
+
+func (repo *DedupRepo) CommitTempFile(ctx context.Context, file FileHandle, path string) error {
+	key, err := dedupKey(path)
+	if err != nil {
+		return err
+	}
+
+	h, ok := file.(*spoolHandle)
+	if !ok || !h.temp {
+		return fmt.Errorf("file handle was not created by CreateTempFile")
+	}
+	defer h.discard()
+
+	h.key = key
+	if err = h.upload(ctx); err != nil {
+		logger.GetLoggerFromContext(ctx).Error(ctx, "Error storing file", zap.String("path", key), zap.Error(err))
+		return err
+	}
+
+	logger.GetLoggerFromContext(ctx).Info(ctx, "File was committed", zap.String("path", key))
+	return nil
+}
+
+func (repo *DedupRepo) DiscardTempFile(ctx context.Context, file FileHandle) error {
+	h, ok := file.(*spoolHandle)
+	if !ok || !h.temp {
+		return fmt.Errorf("file handle was not created by CreateTempFile")
+	}
+
+	h.discard()
+	return nil
+}
+
+// walk calls fn for every file under dir of repo. A missing dir has no
+// files.
+func walk(ctx context.Context, repo FileRepository, dir string, fn func(p string) error) error {
+	entries, err := repo.ListDir(ctx, dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	for _, entry := range entries {
+		p := filepath.Join(dir, entry.Name)
+		if entry.IsDir {
+			err = walk(ctx, repo, p, fn)
+		} else {
+			err = fn(p)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GC removes chunks that are not referenced by any manifest. It is safe to
+// run while files are written and moved through the same DedupRepo; moves
+// wait while GC reads the manifests.
+func (repo *DedupRepo) GC(ctx context.Context) (GCStats, error) {
+	repo.gcMu.Lock()
+	defer repo.gcMu.Unlock()
+
+	repo.refs.mu.Lock()
+	repo.refs.running = true
+	repo.refs.touched = map[string]bool{}
+	repo.refs.mu.Unlock()
+
+	defer func() {
+		repo.refs.mu.Lock()
+		repo.refs.running = false
+		repo.refs.touched = nil
+		repo.refs.mu.Unlock()
+	}()
+
+	live := map[string]bool{}
+	repo.movesMu.Lock()
+	err := walk(ctx, repo.inner, indexDir, func(p string) error {
+		m, err := repo.readManifest(ctx, p)
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		for _, chunk := range m.Chunks {
+			live[chunk.Hash] = true
+		}
+		return nil
+	})
+	repo.movesMu.Unlock()
+	if err != nil {
+		return GCStats{}, err
+	}
+
+	stats := GCStats{}
+	err = walk(ctx, repo.inner, blobsDir, func(p string) error {
+		hash := filepath.Base(p)
+		stats.Blobs++
+		if live[hash] {
+			return nil
+		}
+
+		repo.refs.mu.Lock()
+		defer repo.refs.mu.Unlock()
+
+		if repo.refs.pending[hash] > 0 || repo.refs.touched[hash] {
+			return nil
+		}
+		if err := repo.inner.DeleteFile(ctx, p); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		stats.Removed++
+		return nil
+	})
+
+	return stats, err
+}
+
+// RunGC runs GC every interval until ctx is done.
+func (repo *DedupRepo) RunGC(ctx context.Context, interval time.Duration) {
+	lg := logger.GetLoggerFromContext(ctx)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			stats, err := repo.GC(ctx)
+			if err != nil {
+				lg.Error(ctx, "Error collecting chunks", zap.Error(err))
+				continue
+			}
+			lg.Info(ctx, "Chunks collected", zap.Int("blobs", stats.Blobs), zap.Int("removed", stats.Removed))
+		}
+	}
+}
+
+func (r *dedupReader) load(index int) error {
+	if r.chunk == index {
+		return nil
+	}
+
+	hash := r.manifest.Chunks[index].Hash
+	data, err := r.repo.readAll(r.ctx, blobPath(hash))
+	if err != nil {
+		return fmt.Errorf("chunk %s of %s: %w", hash, r.name, err)
+	}
+
+	sum := sha256.Sum256(data)
+	if hex.EncodeToString(sum[:]) != hash {
+		return fmt.Errorf("chunk %s of %s is corrupted", hash, r.name)
+	}
+
+	r.chunk, r.data = index, data
+	return nil
+}
+
+func (r *dedupReader) Read(b []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return 0, os.ErrClosed
+	}
+	if r.off >= r.manifest.Size {
+		return 0, io.EOF
+	}
+
+	n := 0
+	for n < len(b) && r.off < r.manifest.Size {
+		index := sort.Search(len(r.ends), func(i int) bool { return r.ends[i] > r.off })
+		start := r.ends[index] - r.manifest.Chunks[index].Size
+
+		if err := r.load(index); err != nil {
+			return n, err
+		}
+
+		copied := copy(b[n:], r.data[r.off-start:])
+		n += copied
+		r.off += int64(copied)
+	}
+
+	return n, nil
+}
+
+func (r *dedupReader) Seek(offset int64, whence int) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return 0, os.ErrClosed
+	}
+
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += r.off
+	case io.SeekEnd:
+		offset += r.manifest.Size
+	default:
+		return 0, fmt.Errorf("invalid whence %d", whence)
+	}
+
+	if offset < 0 {
+		return 0, fmt.Errorf("negative position %d", offset)
+	}
+	r.off = offset
+
+	return offset, nil
+}
+
+func (r *dedupReader) Write(b []byte) (int, error) {
+	return 0, fmt.Errorf("%s is opened for reading only", r.name)
+}
+
+func (r *dedupReader) Stat() (fs.FileInfo, error) {
+	if r.closed {
+		return nil, os.ErrClosed
+	}
+	return memFileInfo{name: path.Base(r.name), size: r.manifest.Size, modTime: r.manifest.ModTime}, nil
+}
+
+func (r *dedupReader) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return os.ErrClosed
+	}
+	r.closed = true
+	r.data = nil
+
+	return nil
+}
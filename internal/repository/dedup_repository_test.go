@@ -0,0 +1,176 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"github.com/JunBSer/FileManager/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestConformance_Dedup(t *testing.T) {
+	runConformance(t, func(t *testing.T) FileRepository {
+		return NewDedup(NewMemory(2048), 4)
+	})
+}
+
+func TestDedupRepo(t *testing.T) {
+	ctx := context.Background()
+	lg := logger.New("test", "debug")
+	ctx = context.WithValue(ctx, logger.Key, lg)
+
+	inner := NewMemory(2048)
+	repo := NewDedup(inner, 4)
+
+	writeFile := func(t *testing.T, path, data string) {
+		f, err := repo.CreateTempFile(ctx, path)
+		require.NoError(t, err)
+		_, err = repo.AppendData(ctx, f, []byte(data), 0)
+		require.NoError(t, err)
+		require.NoError(t, repo.CommitTempFile(ctx, f, path))
+	}
+
+	readFile := func(t *testing.T, path string) string {
+		f, err := repo.GetFileHandle(ctx, path, Read)
+		require.NoError(t, err)
+		defer f.Close()
+
+		data, err := io.ReadAll(f)
+		require.NoError(t, err)
+		return string(data)
+	}
+
+	countBlobs := func(t *testing.T) int {
+		cnt := 0
+		require.NoError(t, walk(ctx, repo.inner, blobsDir, func(string) error {
+			cnt++
+			return nil
+		}))
+		return cnt
+	}
+
+	t.Run("Identical content is stored once", func(t *testing.T) {
+		writeFile(t, "vendor/a/lib.go", "package lib")
+		writeFile(t, "vendor/b/lib.go", "package lib")
+
+		assert.Equal(t, 3, countBlobs(t))
+		assert.Equal(t, "package lib", readFile(t, "vendor/b/lib.go"))
+	})
+
+	t.Run("Copy and move touch only manifests", func(t *testing.T) {
+		require.NoError(t, repo.CopyFile(ctx, "vendor/a/lib.go", "copy.go"))
+		require.NoError(t, repo.MoveFile(ctx, "vendor/b", "moved"))
+
+		assert.Equal(t, 3, countBlobs(t))
+		assert.Equal(t, "package lib", readFile(t, "copy.go"))
+		assert.Equal(t, "package lib", readFile(t, "moved/lib.go"))
+	})
+
+	t.Run("Read with seek crosses chunks", func(t *testing.T) {
+		f, err := repo.GetFileHandle(ctx, "copy.go", Read)
+		require.NoError(t, err)
+		defer f.Close()
+
+		buf, n, err := repo.ReadFile(ctx, f, 3)
+		require.NoError(t, err)
+		assert.Equal(t, "kage lib", string(buf[:n]))
+	})
+
+	t.Run("GC removes only unreferenced chunks", func(t *testing.T) {
+		writeFile(t, "report.txt", "unique content")
+		require.NoError(t, repo.DeleteFile(ctx, "report.txt"))
+
+		stats, err := repo.GC(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 4, stats.Removed)
+		assert.Equal(t, 3, countBlobs(t))
+		assert.Equal(t, "package lib", readFile(t, "copy.go"))
+	})
+
+	t.Run("Reserved paths are rejected", func(t *testing.T) {
+		_, err := repo.GetFileHandle(ctx, blobsDir, Read)
+		assert.Error(t, err)
+	})
+
+	t.Run("GC is safe alongside uploads", func(t *testing.T) {
+		const writers = 8
+		content := strings.Repeat("shared data ", 16)
+
+		var wg sync.WaitGroup
+		stop := make(chan struct{})
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+					_, err := repo.GC(ctx)
+					assert.NoError(t, err)
+				}
+			}
+		}()
+
+		var uploads sync.WaitGroup
+		for i := 0; i < writers; i++ {
+			uploads.Add(1)
+			go func(i int) {
+				defer uploads.Done()
+				for j := 0; j < 10; j++ {
+					path := fmt.Sprintf("concurrent/%d.txt", i)
+					writeFile(t, path, content)
+					if j%2 == 0 {
+						assert.NoError(t, repo.DeleteFile(ctx, path))
+					}
+				}
+			}(i)
+		}
+		uploads.Wait()
+		close(stop)
+		wg.Wait()
+
+		_, err := repo.GC(ctx)
+		require.NoError(t, err)
+		for i := 0; i < writers; i++ {
+			assert.Equal(t, content, readFile(t, fmt.Sprintf("concurrent/%d.txt", i)))
+		}
+	})
+
+	t.Run("GC is safe alongside moves", func(t *testing.T) {
+		// The file moves between a directory GC walks last and one it
+		// walks first, so a walk may miss it in both.
+		content := "moved data only used here"
+		writeFile(t, "zz/moved.txt", content)
+
+		var wg sync.WaitGroup
+		stop := make(chan struct{})
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+					_, err := repo.GC(ctx)
+					assert.NoError(t, err)
+				}
+			}
+		}()
+
+		src, dst := "zz/moved.txt", "aa/moved.txt"
+		for i := 0; i < 2000; i++ {
+			require.NoError(t, repo.MoveFile(ctx, src, dst))
+			src, dst = dst, src
+		}
+		close(stop)
+		wg.Wait()
+
+		assert.Equal(t, content, readFile(t, src))
+	})
+}
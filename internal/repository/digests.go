@@ -0,0 +1,148 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/JunBSer/FileManager/pkg/logger"
+	"go.uber.org/zap"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+var digestsDir = filepath.Join(SystemDir, "digests")
+
+var ErrChecksumMismatch = errors.New("checksum mismatch")
+
+// digestRecord is the stored SHA-256 of a file together with the size and
+// modification time the file had when it was computed.
+type digestRecord struct {
+	SHA256  string    `json:"sha256"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"mod_time"`
+}
+
+// DigestStore keeps the SHA-256 of files under SystemDir. A record is only
+// trusted while the size and modification time of the file are the ones it
+// was stored with, so files changed by other means are never reported with a
+// stale digest.
+type DigestStore struct {
+	repo FileRepository
+}
+
+func NewDigestStore(repo FileRepository) *DigestStore {
+	return &DigestStore{repo: repo}
+}
+
+func digestPath(path string) string {
+	return filepath.Join(digestsDir, cleanDir(path)) + ".json"
+}
+
+// writeJSON stores v as the content of path, replacing it at once.
+func writeJSON(ctx context.Context, repo FileRepository, path string, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	file, err := repo.CreateTempFile(ctx, path)
+	if err != nil {
+		return err
+	}
+	if _, err = repo.AppendData(ctx, file, data, 0); err == nil {
+		err = repo.CommitTempFile(ctx, file, path)
+	}
+	if err != nil {
+		_ = repo.DiscardTempFile(ctx, file)
+		return err
+	}
+	return nil
+}
+
+// readJSON decodes the JSON file at path into v.
+func readJSON(ctx context.Context, repo FileRepository, path string, v any) error {
+	file, err := repo.GetFileHandle(ctx, path, Read)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return err
+	}
+	if err = json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("corrupted %s: %w", path, err)
+	}
+	return nil
+}
+
+// Put stores sum, a hex SHA-256, as the digest of the current content of
+// path.
+func (s *DigestStore) Put(ctx context.Context, path, sum string) error {
+	info, err := s.repo.Stat(ctx, path)
+	if err != nil {
+		return err
+	}
+
+	record := digestRecord{SHA256: sum, Size: info.Size(), ModTime: info.ModTime().UTC()}
+	if err = writeJSON(ctx, s.repo, digestPath(path), record); err != nil {
+		logger.GetLoggerFromContext(ctx).Error(ctx, "Error storing digest", zap.String("path", path), zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// Get returns the stored SHA-256 of path, or "" if none is stored or the file
+// changed since.
+func (s *DigestStore) Get(ctx context.Context, path string) (string, error) {
+	file, err := s.repo.GetFileHandle(ctx, digestPath(path), Read)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", err
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return "", err
+	}
+
+	var record digestRecord
+	if err = json.Unmarshal(data, &record); err != nil {
+		logger.GetLoggerFromContext(ctx).Debug(ctx, "Ignoring corrupted digest", zap.String("path", path), zap.Error(err))
+		return "", nil
+	}
+
+	info, err := s.repo.Stat(ctx, path)
+	if err != nil {
+		return "", err
+	}
+	if info.IsDir() || info.Size() != record.Size || !info.ModTime().Equal(record.ModTime) {
+		return "", nil
+	}
+	return record.SHA256, nil
+}
+
+// Move moves the digest of src to dst. The record stays valid only if the
+// backend keeps the modification time on rename.
+func (s *DigestStore) Move(ctx context.Context, src, dst string) error {
+	err := s.repo.MoveFile(ctx, digestPath(src), digestPath(dst))
+	if err != nil && os.IsNotExist(err) {
+		return s.Forget(ctx, dst)
+	}
+	return err
+}
+
+// Forget drops the digest of path.
+func (s *DigestStore) Forget(ctx context.Context, path string) error {
+	if err := s.repo.DeleteFile(ctx, digestPath(path)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"context"
+	"github.com/JunBSer/FileManager/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestDigestStore(t *testing.T) {
+	ctx := context.WithValue(context.Background(), logger.Key, logger.New("test", "debug"))
+
+	repo := NewMemory(2048)
+	store := NewDigestStore(repo)
+
+	write := func(path, content string) {
+		f, err := repo.CreateTempFile(ctx, path)
+		require.NoError(t, err)
+		_, err = repo.AppendData(ctx, f, []byte(content), 0)
+		require.NoError(t, err)
+		require.NoError(t, repo.CommitTempFile(ctx, f, path))
+	}
+
+	write("docs/a.txt", "hello")
+
+	t.Run("Unknown file", func(t *testing.T) {
+		sum, err := store.Get(ctx, "docs/a.txt")
+		require.NoError(t, err)
+		assert.Empty(t, sum)
+	})
+
+	t.Run("Stored digest", func(t *testing.T) {
+		require.NoError(t, store.Put(ctx, "docs/a.txt", "abc"))
+
+		sum, err := store.Get(ctx, "docs/a.txt")
+		require.NoError(t, err)
+		assert.Equal(t, "abc", sum)
+	})
+
+	t.Run("Follows a move", func(t *testing.T) {
+		require.NoError(t, repo.MoveFile(ctx, "docs/a.txt", "docs/b.txt"))
+		require.NoError(t, store.Move(ctx, "docs/a.txt", "docs/b.txt"))
+
+		sum, err := store.Get(ctx, "docs/b.txt")
+		require.NoError(t, err)
+		assert.Equal(t, "abc", sum)
+	})
+
+	t.Run("Changed file is not trusted", func(t *testing.T) {
+		write("docs/b.txt", "changed")
+
+		sum, err := store.Get(ctx, "docs/b.txt")
+		require.NoError(t, err)
+		assert.Empty(t, sum)
+	})
+
+	t.Run("Forget", func(t *testing.T) {
+		require.NoError(t, store.Put(ctx, "docs/b.txt", "def"))
+		require.NoError(t, store.Forget(ctx, "docs/b.txt"))
+		require.NoError(t, store.Forget(ctx, "docs/b.txt"))
+
+		sum, err := store.Get(ctx, "docs/b.txt")
+		require.NoError(t, err)
+		assert.Empty(t, sum)
+	})
+}
@@ -0,0 +1,711 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"github.com/JunBSer/FileManager/pkg/logger"
+	"go.uber.org/zap"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+const (
+	cryptMagic      = "FMCRYPT1"
+	cryptFileIDSize = 16
+	dataKeySize     = 32
+	gcmNonceSize    = 12
+	gcmTagSize      = 16
+	chunkOverhead   = gcmNonceSize + gcmTagSize
+	cryptPrefixSize = len(cryptMagic) + masterKeyIDSize + cryptFileIDSize + 4
+	cryptHeaderSize = cryptPrefixSize + gcmNonceSize + dataKeySize + gcmTagSize
+
+	MaxEncryptionChunkSize = 16 << 20
+)
+
+var ErrCorruptedFile = errors.New("corrupted encrypted file")
+
+// EncryptedRepo encrypts file bodies with AES-GCM before they reach another
+// backend. Every file has its own data key, stored in the file header wrapped
+// by a master key of the Keyring. The body is split into chunks sealed one by
+// one, so files can be read from any offset and written in place without
+// touching the other chunks. Each chunk is bound to its file, its index and
+// whether it is the last one, so chunks cannot be swapped or cut off.
+//
+// Files written before encryption was enabled are read and written as plain
+// files.
+type EncryptedRepo struct {
+	handleIO
+	inner     FileRepository
+	keys      *Keyring
+	chunkSize int64
+}
+
+// cryptHeader starts every encrypted file:
+// magic | master key ID | file ID | chunk size | nonce | wrapped data key.
+type cryptHeader struct {
+	keyID     [masterKeyIDSize]byte
+	fileID    [cryptFileIDSize]byte
+	chunkSize int64
+	wrapped   []byte
+}
+
+// cryptHandle reads and writes the plain content of an encrypted file. The
+// chunk at the current offset is cached and written back when another chunk
+// is loaded or the handle is closed.
+type cryptHandle struct {
+	mu       sync.Mutex
+	inner    FileHandle
+	name     string
+	header   *cryptHeader
+	aead     cipher.AEAD
+	writable bool
+	temp     bool
+	closed   bool
+	off      int64
+	size     int64
+	// stored is the plain size of the chunks written to inner so far.
+	stored int64
+	// diskLast is the index of the chunk sealed as last in inner, or -1.
+	diskLast int64
+	index    int64
+	data     []byte
+	dirty    bool
+}
+
+type RewrapStats struct {
+	Files     int
+	Rewrapped int
+	Failed    int
+}
+
+// sizedFileInfo reports the plain size of a file that a wrapper stores
+// encrypted or compressed.
+type sizedFileInfo struct {
+	fs.FileInfo
+	size int64
+}
+
+func (fi sizedFileInfo) Size() int64 { return fi.size }
+
+func (fi sizedFileInfo) PhysicalSize() int64 { return PhysicalSize(fi.FileInfo) }
+
+// PhysicalSize returns the number of bytes the file of info takes in the
+// storage, which differs from Size for encrypted and compressed files.
+func PhysicalSize(info fs.FileInfo) int64 {
+	if p, ok := info.(interface{ PhysicalSize() int64 }); ok {
+		return p.PhysicalSize()
+	}
+	return info.Size()
+}
+
+func NewEncrypted(inner FileRepository, keys *Keyring, chunkSize int64) *EncryptedRepo {
+	return &EncryptedRepo{
+		handleIO:  handleIO{readSize: inner.GetReadSize()},
+		inner:     inner,
+		keys:      keys,
+		chunkSize: chunkSize,
+	}
+}
+
+// Unwrap returns the backend below repo.
+func (repo *EncryptedRepo) Unwrap() FileRepository {
+	return repo.inner
+}
+
+func (h *cryptHeader) prefix() []byte {
+	b := make([]byte, 0, cryptHeaderSize)
+	b = append(b, cryptMagic...)
+	b = append(b, h.keyID[:]...)
+	b = append(b, h.fileID[:]...)
+	return binary.BigEndian.AppendUint32(b, uint32(h.chunkSize))
+}
+
+func (h *cryptHeader) marshal() []byte {
+	return append(h.prefix(), h.wrapped...)
+}
+
+func parseCryptHeader(b []byte) (*cryptHeader, error) {
+	if len(b) < cryptHeaderSize || !bytes.HasPrefix(b, []byte(cryptMagic)) {
+		return nil, fmt.Errorf("%w: short header", ErrCorruptedFile)
+	}
+
+	h := &cryptHeader{}
+	off := len(cryptMagic)
+	off += copy(h.keyID[:], b[off:])
+	off += copy(h.fileID[:], b[off:])
+	h.chunkSize = int64(binary.BigEndian.Uint32(b[off:]))
+	h.wrapped = append([]byte(nil), b[cryptPrefixSize:cryptHeaderSize]...)
+
+	if h.chunkSize <= 0 || h.chunkSize > MaxEncryptionChunkSize {
+		return nil, fmt.Errorf("%w: chunk size %d", ErrCorruptedFile, h.chunkSize)
+	}
+	return h, nil
+}
+
+// readCryptHeader reads the header at the start of file. It returns nil if
+// the file is not encrypted.
+func readCryptHeader(file FileHandle) (*cryptHeader, error) {
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	buf := make([]byte, cryptHeaderSize)
+	n, err := io.ReadFull(file, buf)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return nil, err
+	}
+	if !bytes.HasPrefix(buf[:n], []byte(cryptMagic)) {
+		return nil, nil
+	}
+	return parseCryptHeader(buf[:n])
+}
+
+// plainSize returns the size of the content of an encrypted file of
+// physical bytes.
+func plainSize(physical, chunkSize int64) (int64, error) {
+	body := physical - int64(cryptHeaderSize)
+	if body < 0 {
+		return 0, fmt.Errorf("%w: short header", ErrCorruptedFile)
+	}
+
+	stride := chunkSize + chunkOverhead
+	size := body / stride * chunkSize
+	if rem := body % stride; rem > 0 {
+		if rem <= chunkOverhead {
+			return 0, fmt.Errorf("%w: truncated chunk", ErrCorruptedFile)
+		}
+		size += rem - chunkOverhead
+	}
+	return size, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// newHeader creates the header of a new file with a fresh data key.
+func (repo *EncryptedRepo) newHeader() (*cryptHeader, []byte, error) {
+	h := &cryptHeader{chunkSize: repo.chunkSize}
+	dek := make([]byte, dataKeySize)
+
+	if _, err := rand.Read(h.fileID[:]); err != nil {
+		return nil, nil, err
+	}
+	if _, err := rand.Read(dek); err != nil {
+		return nil, nil, err
+	}
+	if err := repo.keys.wrap(h, dek); err != nil {
+		return nil, nil, err
+	}
+	return h, dek, nil
+}
+
+// open wraps a handle of the inner backend. Empty files opened for writing
+// get a header; plain files are returned as they are.
+func (repo *EncryptedRepo) open(file FileHandle, name string, writable, temp bool) (FileHandle, error) {
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	var h *cryptHeader
+	if info.Size() > 0 {
+		if h, err = readCryptHeader(file); err != nil {
+			return nil, err
+		}
+		if h == nil {
+			_, err = file.Seek(0, io.SeekStart)
+			return file, err
+		}
+	} else if !writable {
+		return file, nil
+	}
+
+	handle := &cryptHandle{inner: file, name: name, writable: writable, temp: temp, diskLast: -1, index: -1}
+
+	var dek []byte
+	if h == nil {
+		if h, dek, err = repo.newHeader(); err != nil {
+			return nil, err
+		}
+		if _, err = file.Seek(0, io.SeekStart); err != nil {
+			return nil, err
+		}
+		if _, err = file.Write(h.marshal()); err != nil {
+			return nil, err
+		}
+	} else {
+		if dek, err = repo.keys.unwrap(h); err != nil {
+			return nil, err
+		}
+		if handle.size, err = plainSize(info.Size(), h.chunkSize); err != nil {
+			return nil, err
+		}
+		handle.stored = handle.size
+		if handle.size > 0 {
+			handle.diskLast = (handle.size - 1) / h.chunkSize
+		}
+	}
+
+	handle.header = h
+	if handle.aead, err = newGCM(dek); err != nil {
+		return nil, err
+	}
+	return handle, nil
+}
+
+func (repo *EncryptedRepo) GetFileHandle(ctx context.Context, path string, openOption int) (FileHandle, error) {
+	// Chunks are read back to be changed, so writable files are opened for
+	// reading too.
+	innerOption := openOption
+	if openOption != Read {
+		innerOption = Write
+	}
+
+	file, err := repo.inner.GetFileHandle(ctx, path, innerOption)
+	if err != nil {
+		return nil, err
+	}
+
+	handle, err := repo.open(file, filepath.Base(path), openOption != Read, false)
+	if err != nil {
+		logger.GetLoggerFromContext(ctx).Error(ctx, "Error opening encrypted file", zap.String("path", path), zap.Error(err))
+		_ = file.Close()
+		return nil, err
+	}
+	return handle, nil
+}
+
+func (repo *EncryptedRepo) MoveFile(ctx context.Context, srcPath, dstPath string) error {
+	return repo.inner.MoveFile(ctx, srcPath, dstPath)
+}
+
+func (repo *EncryptedRepo) MoveFileExclusive(ctx context.Context, srcPath, dstPath string) error {
+	return moveExclusive(ctx, repo.inner, srcPath, dstPath)
+}
+
+// CopyFile copies the encrypted content, so the copy shares the data key of
+// the source.
+func (repo *EncryptedRepo) CopyFile(ctx context.Context, srcPath, dstPath string) error {
+	return copyFile(ctx, repo.inner, srcPath, dstPath)
+}
+
+func (repo *EncryptedRepo) DeleteFile(ctx context.Context, path string) error {
+	return repo.inner.DeleteFile(ctx, path)
+}
+
+func (repo *EncryptedRepo) ListDir(ctx context.Context, path string) ([]DirectoryEntry, error) {
+	return repo.inner.ListDir(ctx, path)
+}
+
+func (repo *EncryptedRepo) CreateDir(ctx context.Context, path string) error {
+	return repo.inner.CreateDir(ctx, path)
+}
+
+func (repo *EncryptedRepo) Stat(ctx context.Context, path string) (fs.FileInfo, error) {
+	info, err := repo.inner.Stat(ctx, path)
+	if err != nil || info.IsDir() || info.Size() == 0 {
+		return info, err
+	}
+
+	file, err := repo.inner.GetFileHandle(ctx, path, Read)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	h, err := readCryptHeader(file)
+	if err != nil || h == nil {
+		return info, err
+	}
+
+	size, err := plainSize(info.Size(), h.chunkSize)
+	if err != nil {
+		return nil, err
+	}
+	return sizedFileInfo{FileInfo: info, size: size}, nil
+}
+
+func (repo *EncryptedRepo) CreateTempFile(ctx context.Context, path string) (FileHandle, error) {
+	file, err := repo.inner.CreateTempFile(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+
+	handle, err := repo.open(file, filepath.Base(path), true, true)
+	if err != nil {
+		logger.GetLoggerFromContext(ctx).Error(ctx, "Error creating encrypted file", zap.String("path", path), zap.Error(err))
+		_ = repo.inner.DiscardTempFile(ctx, file)
+		return nil, err
+	}
+	return handle, nil
+}
+
+func tempCryptHandle(file FileHandle) (*cryptHandle, error) {
+	h, ok := file.(*cryptHandle)
+	if !ok || !h.temp {
+		return nil, fmt.Errorf("file handle was not created by CreateTempFile")
+	}
+	return h, nil
+}
+
+func (repo *EncryptedRepo) CommitTempFile(ctx context.Context, file FileHandle, path string) error {
+	h, err := tempCryptHandle(file)
+	if err != nil {
+		return err
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		return os.ErrClosed
+	}
+	if err = h.flush(); err != nil {
+		return err
+	}
+	h.closed = true
+
+	return repo.inner.CommitTempFile(ctx, h.inner, path)
+}
+
+func (repo *EncryptedRepo) DiscardTempFile(ctx context.Context, file FileHandle) error {
+	h, err := tempCryptHandle(file)
+	if err != nil {
+		return err
+	}
+
+	h.mu.Lock()
+	h.closed = true
+	h.mu.Unlock()
+
+	return repo.inner.DiscardTempFile(ctx, h.inner)
+}
+
+// rewrapFile rewraps the data key of path with the current master key. Only
+// the header is written.
+func (repo *EncryptedRepo) rewrapFile(ctx context.Context, path string) (bool, error) {
+	file, err := repo.inner.GetFileHandle(ctx, path, Read)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	h, err := readCryptHeader(file)
+	_ = file.Close()
+	if err != nil || h == nil || repo.keys.current(h.keyID) {
+		return false, err
+	}
+
+	file, err = repo.inner.GetFileHandle(ctx, path, Write)
+	if err != nil {
+		return false, err
+	}
+
+	// The file may have been replaced since it was checked.
+	latest, err := readCryptHeader(file)
+	if err != nil || latest == nil || latest.fileID != h.fileID || repo.keys.current(latest.keyID) {
+		_ = file.Close()
+		return false, err
+	}
+
+	dek, err := repo.keys.unwrap(latest)
+	if err == nil {
+		err = repo.keys.wrap(latest, dek)
+	}
+	if err == nil {
+		_, err = file.Seek(0, io.SeekStart)
+	}
+	if err == nil {
+		_, err = file.Write(latest.marshal())
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	return err == nil, err
+}
+
+// Rewrap rewraps the data keys of all files that were wrapped by an old
+// master key. File bodies are left as they are. Files that cannot be
+// rewrapped are logged and counted as failed.
+func (repo *EncryptedRepo) Rewrap(ctx context.Context) (RewrapStats, error) {
+	lg := logger.GetLoggerFromContext(ctx)
+
+	var stats RewrapStats
+	err := walk(ctx, repo.inner, "", func(p string) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		stats.Files++
+		rewrapped, err := repo.rewrapFile(ctx, p)
+		if err != nil {
+			stats.Failed++
+			lg.Error(ctx, "Error rewrapping data key", zap.String("path", p), zap.Error(err))
+			return nil
+		}
+		if rewrapped {
+			stats.Rewrapped++
+		}
+		return nil
+	})
+	return stats, err
+}
+
+// RunRewrap runs Rewrap once and logs the result.
+func (repo *EncryptedRepo) RunRewrap(ctx context.Context) {
+	lg := logger.GetLoggerFromContext(ctx)
+
+	stats, err := repo.Rewrap(ctx)
+	if err != nil {
+		lg.Error(ctx, "Error rewrapping data keys", zap.Error(err))
+		return
+	}
+	lg.Info(ctx, "Data keys rewrapped", zap.Int("files", stats.Files),
+		zap.Int("rewrapped", stats.Rewrapped), zap.Int("failed", stats.Failed))
+}
+
+func (h *cryptHandle) chunkPos(i int64) int64 {
+	return int64(cryptHeaderSize) + i*(h.header.chunkSize+chunkOverhead)
+}
+
+func (h *cryptHandle) chunkAAD(i int64, last bool) []byte {
+	b := make([]byte, 0, cryptFileIDSize+9)
+	b = append(b, h.header.fileID[:]...)
+	b = binary.BigEndian.AppendUint64(b, uint64(i))
+	if last {
+		return append(b, 1)
+	}
+	return append(b, 0)
+}
+
+func (h *cryptHandle) readChunk(i, size int64, last bool) ([]byte, error) {
+	if _, err := h.inner.Seek(h.chunkPos(i), io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	buf := make([]byte, size+chunkOverhead)
+	if _, err := io.ReadFull(h.inner, buf); err != nil {
+		return nil, err
+	}
+
+	data, err := h.aead.Open(nil, buf[:gcmNonceSize], buf[gcmNonceSize:], h.chunkAAD(i, last))
+	if err != nil {
+		return nil, fmt.Errorf("%w: chunk %d of %s", ErrCorruptedFile, i, h.name)
+	}
+	return data, nil
+}
+
+func (h *cryptHandle) writeChunk(i int64, data []byte, last bool) error {
+	buf := make([]byte, gcmNonceSize, len(data)+chunkOverhead)
+	if _, err := rand.Read(buf); err != nil {
+		return err
+	}
+	buf = h.aead.Seal(buf, buf, data, h.chunkAAD(i, last))
+
+	if _, err := h.inner.Seek(h.chunkPos(i), io.SeekStart); err != nil {
+		return err
+	}
+	_, err := h.inner.Write(buf)
+	return err
+}
+
+// load makes chunk i the cached one. Chunks past the stored content are
+// empty.
+func (h *cryptHandle) load(i int64) error {
+	if h.index == i {
+		return nil
+	}
+	if err := h.flush(); err != nil {
+		return err
+	}
+
+	var data []byte
+	if start := i * h.header.chunkSize; start < h.stored {
+		var err error
+		data, err = h.readChunk(i, min(h.header.chunkSize, h.stored-start), i == h.diskLast)
+		if err != nil {
+			return err
+		}
+	}
+
+	h.index, h.data = i, data
+	return nil
+}
+
+// flush writes the cached chunk back if it was changed. When it becomes the
+// last chunk, the previous last chunk is sealed again as an inner one.
+func (h *cryptHandle) flush() error {
+	if !h.dirty {
+		return nil
+	}
+
+	cs := h.header.chunkSize
+	last := h.index == (h.size-1)/cs
+	if last && h.diskLast >= 0 && h.diskLast != h.index {
+		data, err := h.readChunk(h.diskLast, min(cs, h.stored-h.diskLast*cs), true)
+		if err != nil {
+			return err
+		}
+		if err = h.writeChunk(h.diskLast, data, false); err != nil {
+			return err
+		}
+		h.diskLast = -1
+	}
+
+	if err := h.writeChunk(h.index, h.data, last); err != nil {
+		return err
+	}
+
+	h.stored = max(h.stored, h.index*cs+int64(len(h.data)))
+	if last {
+		h.diskLast = h.index
+	} else if h.diskLast == h.index {
+		h.diskLast = -1
+	}
+	h.dirty = false
+	return nil
+}
+
+func (h *cryptHandle) Read(b []byte) (int, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		return 0, os.ErrClosed
+	}
+	if h.off >= h.size {
+		return 0, io.EOF
+	}
+
+	n := 0
+	for n < len(b) && h.off < h.size {
+		i := h.off / h.header.chunkSize
+		if err := h.load(i); err != nil {
+			return n, err
+		}
+
+		within := h.off - i*h.header.chunkSize
+		if within >= int64(len(h.data)) {
+			return n, fmt.Errorf("%w: chunk %d of %s is short", ErrCorruptedFile, i, h.name)
+		}
+		copied := copy(b[n:], h.data[within:])
+		n += copied
+		h.off += int64(copied)
+	}
+	return n, nil
+}
+
+// Write writes b at the current offset. Writing past the end fills the gap
+// with zeros.
+func (h *cryptHandle) Write(b []byte) (int, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		return 0, os.ErrClosed
+	}
+	if !h.writable {
+		return 0, fmt.Errorf("%s is opened for reading only", h.name)
+	}
+
+	n := len(b)
+	if h.off > h.size {
+		b = append(make([]byte, h.off-h.size, h.off-h.size+int64(n)), b...)
+		h.off = h.size
+	}
+	if end := h.off + int64(len(b)); end > h.size {
+		h.size = end
+	}
+
+	cs := h.header.chunkSize
+	for len(b) > 0 {
+		i := h.off / cs
+		if err := h.load(i); err != nil {
+			return 0, err
+		}
+
+		within := h.off - i*cs
+		end := min(cs, within+int64(len(b)))
+		if int64(len(h.data)) < end {
+			h.data = append(h.data, make([]byte, end-int64(len(h.data)))...)
+		}
+
+		copied := copy(h.data[within:end], b)
+		h.dirty = true
+		b = b[copied:]
+		h.off += int64(copied)
+	}
+	return n, nil
+}
+
+func (h *cryptHandle) Seek(offset int64, whence int) (int64, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		return 0, os.ErrClosed
+	}
+
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += h.off
+	case io.SeekEnd:
+		offset += h.size
+	default:
+		return 0, fmt.Errorf("invalid whence %d", whence)
+	}
+	if offset < 0 {
+		return 0, fmt.Errorf("negative position %d", offset)
+	}
+
+	h.off = offset
+	return offset, nil
+}
+
+func (h *cryptHandle) Stat() (fs.FileInfo, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		return nil, os.ErrClosed
+	}
+
+	info, err := h.inner.Stat()
+	if err != nil {
+		return nil, err
+	}
+	return sizedFileInfo{FileInfo: info, size: h.size}, nil
+}
+
+// Close writes back the cached chunk and closes the inner handle.
+func (h *cryptHandle) Close() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		return os.ErrClosed
+	}
+	h.closed = true
+
+	err := h.flush()
+	if closeErr := h.inner.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
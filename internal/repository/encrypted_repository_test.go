@@ -0,0 +1,203 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"github.com/JunBSer/FileManager/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func testKey(b byte) []byte {
+	return bytes.Repeat([]byte{b}, masterKeySize)
+}
+
+func newTestKeyring(t *testing.T, keys ...[]byte) *Keyring {
+	ring, err := NewKeyring(keys...)
+	require.NoError(t, err)
+	return ring
+}
+
+func TestConformance_EncryptedMemory(t *testing.T) {
+	runConformance(t, func(t *testing.T) FileRepository {
+		return NewEncrypted(NewMemory(2048), newTestKeyring(t, testKey(1)), 4)
+	})
+}
+
+func TestConformance_EncryptedLocal(t *testing.T) {
+	runConformance(t, func(t *testing.T) FileRepository {
+		fullPath := CreateTempDir(t)
+		t.Cleanup(func() { os.RemoveAll(fullPath) })
+
+		return NewEncrypted(New(relPath, 1024*1024, 2048), newTestKeyring(t, testKey(1)), 4)
+	})
+}
+
+func TestEncryptedRepo(t *testing.T) {
+	ctx := context.Background()
+	lg := logger.New("test", "debug")
+	ctx = context.WithValue(ctx, logger.Key, lg)
+
+	readAll := func(t *testing.T, repo FileRepository, path string) string {
+		f, err := repo.GetFileHandle(ctx, path, Read)
+		require.NoError(t, err)
+		defer f.Close()
+
+		data, err := io.ReadAll(f)
+		require.NoError(t, err)
+		return string(data)
+	}
+
+	writeAt := func(t *testing.T, repo FileRepository, path, data string, pos int64) {
+		f, err := repo.GetFileHandle(ctx, path, Write)
+		require.NoError(t, err)
+		_, err = repo.AppendData(ctx, f, []byte(data), pos)
+		require.NoError(t, err)
+		require.NoError(t, f.Close())
+	}
+
+	t.Run("content is not stored in plain", func(t *testing.T) {
+		inner := NewMemory(2048)
+		repo := NewEncrypted(inner, newTestKeyring(t, testKey(1)), 8)
+
+		writeAt(t, repo, "secret.txt", "attack at dawn, attack at dawn", 0)
+
+		raw := readAll(t, inner, "secret.txt")
+		assert.NotContains(t, raw, "attack")
+		assert.True(t, strings.HasPrefix(raw, cryptMagic))
+		assert.Equal(t, "attack at dawn, attack at dawn", readAll(t, repo, "secret.txt"))
+
+		info, err := repo.Stat(ctx, "secret.txt")
+		require.NoError(t, err)
+		assert.Equal(t, int64(30), info.Size())
+	})
+
+	t.Run("range reads and appends", func(t *testing.T) {
+		repo := NewEncrypted(NewMemory(2048), newTestKeyring(t, testKey(1)), 4)
+
+		writeAt(t, repo, "file.txt", "0123456789", 0)
+		writeAt(t, repo, "file.txt", "abcdef", 10)
+		writeAt(t, repo, "file.txt", "XY", 3)
+		writeAt(t, repo, "file.txt", "!", 20)
+		assert.Equal(t, "012XY56789abcdef\x00\x00\x00\x00!", readAll(t, repo, "file.txt"))
+
+		f, err := repo.GetFileHandle(ctx, "file.txt", Read)
+		require.NoError(t, err)
+		defer f.Close()
+
+		buf, n, err := repo.ReadFile(ctx, f, 7)
+		require.NoError(t, err)
+		assert.Equal(t, "789abcdef\x00\x00\x00\x00!", string(buf[:n]))
+
+		_, err = f.Seek(-1, io.SeekEnd)
+		require.NoError(t, err)
+		rest, err := io.ReadAll(f)
+		require.NoError(t, err)
+		assert.Equal(t, "!", string(rest))
+	})
+
+	t.Run("tampered chunks are rejected", func(t *testing.T) {
+		inner := NewMemory(2048)
+		repo := NewEncrypted(inner, newTestKeyring(t, testKey(1)), 4)
+		writeAt(t, repo, "file.txt", "0123456789", 0)
+
+		raw := []byte(readAll(t, inner, "file.txt"))
+		raw[len(raw)-1] ^= 1
+		writeAt(t, inner, "file.txt", string(raw), 0)
+
+		f, err := repo.GetFileHandle(ctx, "file.txt", Read)
+		require.NoError(t, err)
+		defer f.Close()
+		_, err = io.ReadAll(f)
+		assert.ErrorIs(t, err, ErrCorruptedFile)
+
+		// Cutting off the last chunk is detected too.
+		inner = NewMemory(2048)
+		repo = NewEncrypted(inner, newTestKeyring(t, testKey(1)), 4)
+		writeAt(t, repo, "file.txt", "0123456789", 0)
+		raw = []byte(readAll(t, inner, "file.txt"))
+
+		require.NoError(t, inner.DeleteFile(ctx, "file.txt"))
+		writeAt(t, inner, "file.txt", string(raw[:cryptHeaderSize+2*(4+chunkOverhead)]), 0)
+
+		f, err = repo.GetFileHandle(ctx, "file.txt", Read)
+		require.NoError(t, err)
+		defer f.Close()
+		_, err = io.ReadAll(f)
+		assert.ErrorIs(t, err, ErrCorruptedFile)
+	})
+
+	t.Run("plain files stay readable", func(t *testing.T) {
+		inner := NewMemory(2048)
+		writeAt(t, inner, "old.txt", "written before encryption", 0)
+
+		repo := NewEncrypted(inner, newTestKeyring(t, testKey(1)), 4)
+		assert.Equal(t, "written before encryption", readAll(t, repo, "old.txt"))
+
+		info, err := repo.Stat(ctx, "old.txt")
+		require.NoError(t, err)
+		assert.Equal(t, int64(25), info.Size())
+	})
+
+	t.Run("rewrap keeps bodies", func(t *testing.T) {
+		inner := NewMemory(2048)
+		oldKey, newKey := testKey(1), testKey(2)
+
+		repo := NewEncrypted(inner, newTestKeyring(t, oldKey), 4)
+		writeAt(t, repo, "a.txt", "first file", 0)
+		writeAt(t, repo, "dir/b.txt", "second file", 0)
+		before := readAll(t, inner, "a.txt")
+
+		_, err := NewEncrypted(inner, newTestKeyring(t, newKey), 4).GetFileHandle(ctx, "a.txt", Read)
+		assert.ErrorIs(t, err, ErrUnknownKey)
+
+		rotated := NewEncrypted(inner, newTestKeyring(t, newKey, oldKey), 4)
+		assert.Equal(t, "first file", readAll(t, rotated, "a.txt"))
+
+		stats, err := rotated.Rewrap(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, RewrapStats{Files: 2, Rewrapped: 2}, stats)
+
+		after := readAll(t, inner, "a.txt")
+		assert.NotEqual(t, before[:cryptHeaderSize], after[:cryptHeaderSize])
+		assert.Equal(t, before[cryptHeaderSize:], after[cryptHeaderSize:])
+
+		onlyNew := NewEncrypted(inner, newTestKeyring(t, newKey), 4)
+		assert.Equal(t, "first file", readAll(t, onlyNew, "a.txt"))
+		assert.Equal(t, "second file", readAll(t, onlyNew, "dir/b.txt"))
+
+		stats, err = onlyNew.Rewrap(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, RewrapStats{Files: 2}, stats)
+	})
+}
+
+func TestLoadKeyring(t *testing.T) {
+	current := base64.StdEncoding.EncodeToString(testKey(1))
+	old := base64.StdEncoding.EncodeToString(testKey(2))
+
+	keyFile := filepath.Join(t.TempDir(), "keys")
+	require.NoError(t, os.WriteFile(keyFile, []byte("# rotated keys\n"+current+"\n\n"+old+"\n"), 0o600))
+
+	ring, err := LoadKeyring(EncryptionConfig{KeyFile: keyFile})
+	require.NoError(t, err)
+	require.Len(t, ring.keys, 2)
+	assert.True(t, ring.current(ring.keys[0].id))
+
+	ring, err = LoadKeyring(EncryptionConfig{Key: old, KeyFile: keyFile})
+	require.NoError(t, err)
+	require.Len(t, ring.keys, 2)
+	assert.Equal(t, ring.keys[0].id, newTestKeyring(t, testKey(2)).keys[0].id)
+
+	_, err = LoadKeyring(EncryptionConfig{})
+	assert.Error(t, err)
+
+	_, err = LoadKeyring(EncryptionConfig{Key: base64.StdEncoding.EncodeToString([]byte("short"))})
+	assert.Error(t, err)
+}
@@ -20,10 +20,28 @@ const (
 	Write      = os.O_RDWR
 )
 
+// tempMarker is a part of the names of temp files that are written next to
+// their target until they are committed.
+const tempMarker = ".fmtmp-"
+
 type FileStorageConfig struct {
 	StoragePath string `env:"FILE_STORAGE_PATH" envDefault:"/var/tmp/storage"`
 	MaxSize     int64  `env:"FILE_MAX_SIZE" envDefault:"10"`
 	ReadSize    int64  `env:"FILE_READ_SIZE" envDefault:"2048"`
+	Backend     string `env:"FILE_STORAGE_BACKEND" envDefault:"local"`
+	S3          S3Config
+	Dedup       DedupConfig
+	Encryption  EncryptionConfig
+	Compression CompressionConfig
+	Quota       QuotaConfig
+	Versions    VersionConfig
+	Trash       TrashConfig
+	ACL         ACLConfig
+	Share       ShareConfig
+	Watch       WatchConfig
+	Webhooks    WebhookConfig
+	Audit       AuditConfig
+	Health      HealthConfig
 }
 
 type FileRepository interface {
@@ -33,13 +51,52 @@ type FileRepository interface {
 	DeleteFile(ctx context.Context, path string) error
 	ReadFile(ctx context.Context, file FileHandle, pos int64) ([]byte, int64, error)
 	ListDir(ctx context.Context, path string) ([]DirectoryEntry, error)
+	CreateDir(ctx context.Context, path string) error
+	Stat(ctx context.Context, path string) (fs.FileInfo, error)
 	GetReadSize() int64
+	CreateTempFile(ctx context.Context, path string) (FileHandle, error)
+	CommitTempFile(ctx context.Context, file FileHandle, path string) error
+	DiscardTempFile(ctx context.Context, file FileHandle) error
+}
+
+// ExclusiveMover is implemented by backends that can move a file or a
+// directory without replacing what is at the target. MoveFileExclusive
+// fails with an error satisfying os.IsExist if dstPath is taken.
+type ExclusiveMover interface {
+	MoveFileExclusive(ctx context.Context, srcPath, dstPath string) error
+}
+
+// moveExclusive moves srcPath to dstPath unless dstPath exists. Backends that
+// are not ExclusiveMovers are checked before the move, which leaves a short
+// window for a target to appear.
+func moveExclusive(ctx context.Context, repo FileRepository, srcPath, dstPath string) error {
+	if mover, ok := repo.(ExclusiveMover); ok {
+		return mover.MoveFileExclusive(ctx, srcPath, dstPath)
+	}
+
+	if _, err := repo.Stat(ctx, dstPath); err == nil {
+		return alreadyExists("rename", dstPath)
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+	return repo.MoveFile(ctx, srcPath, dstPath)
+}
+
+// moveWith returns the MoveFile of repo, or its exclusive move if exclusive
+// is set.
+func moveWith(repo FileRepository, exclusive bool) func(ctx context.Context, srcPath, dstPath string) error {
+	if exclusive {
+		return func(ctx context.Context, srcPath, dstPath string) error {
+			return moveExclusive(ctx, repo, srcPath, dstPath)
+		}
+	}
+	return repo.MoveFile
 }
 
 type FileStorageRepo struct {
 	storagePath string
 	maxSize     int64
-	readSize    int64
+	handleIO
 }
 
 type DirectoryEntry struct {
@@ -69,11 +126,12 @@ func New(storagePath string, maxSize int64, readSize int64) *FileStorageRepo {
 		return nil
 	}
 
-	return &FileStorageRepo{storagePath: fullPath, maxSize: maxSize, readSize: readSize}
+	return &FileStorageRepo{storagePath: fullPath, maxSize: maxSize, handleIO: handleIO{readSize: readSize}}
 }
 
-func (repo *FileStorageRepo) GetReadSize() int64 {
-	return repo.readSize
+// Root returns the storage directory.
+func (repo *FileStorageRepo) Root() string {
+	return repo.storagePath
 }
 
 func (repo *FileStorageRepo) BuildPath(path string) string {
@@ -172,75 +230,60 @@ func (repo *FileStorageRepo) GetFileHandle(ctx context.Context, path string, ope
 	return file, nil
 }
 
-func (repo *FileStorageRepo) AppendData(ctx context.Context, file FileHandle, data []byte, pos int64) (int64, error) {
-	var err error
-	lg := logger.GetLoggerFromContext(ctx)
-
-	_, err = file.Seek(pos, 0)
-	if err != nil {
-		lg.Error(ctx, "Error seeking file", zap.Int64("position", pos), zap.Error(err))
-	}
-
-	wCnt, err := file.Write(data)
-	if err != nil {
-		lg.Error(ctx, "Error writing to file", zap.Int64("position", pos), zap.Error(err))
-		return -1, err
-	}
-
-	lg.Info(ctx, fmt.Sprintf("Wrote %d bytes to file", wCnt))
-
-	return int64(wCnt), err
-}
-
+// CopyFile copies the file srcPath to dstPath through a temp file, so readers
+// of dstPath never see a partial copy.
 func (repo *FileStorageRepo) CopyFile(ctx context.Context, srcPath string, dstPath string) error {
-	srcFullPath := repo.BuildPath(srcPath)
-	dstFullPath := repo.BuildPath(dstPath)
-
 	lg := logger.GetLoggerFromContext(ctx)
 
-	err := repo.ValidatePath(ctx, dstFullPath)
+	srcFile, err := repo.GetFileHandle(ctx, srcPath, Read)
 	if err != nil {
-		lg.Debug(ctx, "Error to copy file: dstPath path is invalid")
+		lg.Debug(ctx, "Error to copy file: srcFile is not exist")
 		return err
 	}
+	defer srcFile.Close()
 
-	err = repo.ValidatePath(ctx, srcFullPath)
+	info, err := srcFile.Stat()
 	if err != nil {
-		lg.Debug(ctx, "Error to copy file: srcPath path is invalid")
 		return err
 	}
+	if info.IsDir() {
+		return fmt.Errorf("%s is a directory", srcPath)
+	}
 
-	srcFile, err := repo.GetFileHandle(ctx, srcFullPath, Read)
+	dstFile, err := repo.CreateTempFile(ctx, dstPath)
 	if err != nil {
-		lg.Debug(ctx, "Error to copy file: srcFile is not exist")
+		lg.Debug(ctx, "Error to copy file: can not create and write file")
 		return err
 	}
 
-	defer func() {
-		err = srcFile.Close()
-		if err != nil {
-			lg.Error(ctx, "Error closing file", zap.String("path", srcFullPath), zap.Error(err))
-		}
-	}()
-
-	dstFile, err := repo.GetFileHandle(ctx, dstFullPath, CreateAndW)
+	if _, err = io.Copy(dstFile, srcFile); err == nil {
+		err = repo.CommitTempFile(ctx, dstFile, dstPath)
+	}
 	if err != nil {
-		lg.Debug(ctx, "Error to copy file: can not create and write file")
+		lg.Error(ctx, "Error to copy file: can not copy file", zap.Error(err))
+		_ = repo.DiscardTempFile(ctx, dstFile)
+		return err
 	}
 
-	func() {
-		err = dstFile.Close()
-		if err != nil {
-			lg.Error(ctx, "Error closing file", zap.String("path", srcFullPath), zap.Error(err))
-		}
-	}()
+	return nil
+}
 
-	_, err = io.Copy(dstFile, srcFile)
-	if err != nil {
-		lg.Error(ctx, "Error to copy file: can not copy file", zap.Error(err))
+// CreateDir creates path and any missing parents. It succeeds if the
+// directory exists already.
+func (repo *FileStorageRepo) CreateDir(ctx context.Context, path string) error {
+	lg := logger.GetLoggerFromContext(ctx)
+
+	fullPath := repo.BuildPath(path)
+	if err := repo.ValidatePath(ctx, fullPath); err != nil {
+		lg.Debug(ctx, "Error to create dir: path is invalid")
+		return err
 	}
 
-	return err
+	if err := os.MkdirAll(fullPath, 0o755); err != nil {
+		lg.Error(ctx, "Error creating directory", zap.String("path", fullPath), zap.Error(err))
+		return err
+	}
+	return nil
 }
 
 func (repo *FileStorageRepo) DeleteFile(ctx context.Context, path string) error {
@@ -261,35 +304,26 @@ func (repo *FileStorageRepo) DeleteFile(ctx context.Context, path string) error
 	return err
 }
 
-func (repo *FileStorageRepo) ReadFile(ctx context.Context, file FileHandle, pos int64) ([]byte, int64, error) {
-	lg := logger.GetLoggerFromContext(ctx)
-
-	_, err := file.Seek(pos, 0)
-	if err != nil {
-		lg.Error(ctx, "Error seeking file", zap.Int64("position", pos), zap.Error(err))
-	}
-
-	buf := make([]byte, repo.readSize)
-	bRead, err := file.Read(buf)
-
-	if err != nil {
-		lg.Error(ctx, "Error reading file", zap.Int64("position", pos), zap.Error(err))
-		return nil, 0, err
+func (repo *FileStorageRepo) Stat(ctx context.Context, path string) (fs.FileInfo, error) {
+	fullPath := repo.BuildPath(path)
+	if err := repo.ValidatePath(ctx, fullPath); err != nil {
+		logger.GetLoggerFromContext(ctx).Debug(ctx, "Error to stat: path is invalid")
+		return nil, err
 	}
 
-	lg.Debug(ctx, fmt.Sprintf("Read %d bytes from file", bRead))
-
-	return buf, int64(bRead), err
+	return os.Stat(fullPath)
 }
 
 func (repo *FileStorageRepo) ListDir(ctx context.Context, path string) ([]DirectoryEntry, error) {
 	lg := logger.GetLoggerFromContext(ctx)
 
-	fullPath := repo.BuildPath(path)
-	err := repo.ValidatePath(ctx, fullPath)
-	if err != nil {
-		lg.Debug(ctx, "Error to list dir: path is invalid")
-		return nil, err
+	fullPath := repo.storagePath
+	if !isRoot(path) {
+		fullPath = repo.BuildPath(path)
+		if err := repo.ValidatePath(ctx, fullPath); err != nil {
+			lg.Debug(ctx, "Error to list dir: path is invalid")
+			return nil, err
+		}
 	}
 
 	entries, err := os.ReadDir(fullPath)
@@ -300,7 +334,7 @@ func (repo *FileStorageRepo) ListDir(ctx context.Context, path string) ([]Direct
 
 	result := make([]DirectoryEntry, 0, len(entries))
 	for _, entry := range entries {
-		if err != nil {
+		if IsTempName(entry.Name()) {
 			continue
 		}
 
@@ -331,6 +365,17 @@ func (repo *FileStorageRepo) MoveFile(ctx context.Context, srcPath, dstPath stri
 		return err
 	}
 
+	if _, err = os.Stat(srcFullPath); err != nil {
+		lg.Debug(ctx, "Error to move file: source is not exist")
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(dstFullPath), 0o755)
+	if err != nil {
+		lg.Error(ctx, "Error creating directory", zap.String("path", dstFullPath), zap.Error(err))
+		return err
+	}
+
 	err = os.Rename(srcFullPath, dstFullPath)
 	if err != nil {
 		lg.Debug(ctx, "Error to copy file: can not move file")
@@ -338,3 +383,143 @@ func (repo *FileStorageRepo) MoveFile(ctx context.Context, srcPath, dstPath stri
 	}
 	return nil
 }
+
+// MoveFileExclusive moves like MoveFile, but never replaces dstPath. A file
+// is linked to its new name, which fails if the name is taken, before its old
+// name is removed.
+func (repo *FileStorageRepo) MoveFileExclusive(ctx context.Context, srcPath, dstPath string) error {
+	srcFullPath := repo.BuildPath(srcPath)
+	dstFullPath := repo.BuildPath(dstPath)
+
+	lg := logger.GetLoggerFromContext(ctx)
+
+	if err := repo.ValidatePath(ctx, dstFullPath); err != nil {
+		lg.Debug(ctx, "Error to move file: dstPath path is invalid")
+		return err
+	}
+	if err := repo.ValidatePath(ctx, srcFullPath); err != nil {
+		lg.Debug(ctx, "Error to move file: srcPath path is invalid")
+		return err
+	}
+
+	info, err := os.Stat(srcFullPath)
+	if err != nil {
+		lg.Debug(ctx, "Error to move file: source is not exist")
+		return err
+	}
+
+	if err = os.MkdirAll(filepath.Dir(dstFullPath), 0o755); err != nil {
+		lg.Error(ctx, "Error creating directory", zap.String("path", dstFullPath), zap.Error(err))
+		return err
+	}
+
+	if !info.IsDir() {
+		if err = os.Link(srcFullPath, dstFullPath); err != nil {
+			return err
+		}
+		if err = os.Remove(srcFullPath); err != nil {
+			_ = os.Remove(dstFullPath)
+			return err
+		}
+		return nil
+	}
+
+	// A rename replaces an empty directory at most, so nothing is lost if
+	// the target appears after it was checked.
+	if _, err = os.Lstat(dstFullPath); err == nil {
+		return alreadyExists("rename", dstPath)
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+	return os.Rename(srcFullPath, dstFullPath)
+}
+
+// IsTempName reports whether name is a temp file created by CreateTempFile.
+func IsTempName(name string) bool {
+	return strings.HasPrefix(name, ".") && strings.Contains(name, tempMarker)
+}
+
+// CreateTempFile creates a hidden file in the directory of path. Data written
+// to it becomes visible under path only after CommitTempFile.
+func (repo *FileStorageRepo) CreateTempFile(ctx context.Context, path string) (FileHandle, error) {
+	fullPath := repo.BuildPath(path)
+	lg := logger.GetLoggerFromContext(ctx)
+
+	if err := repo.ValidatePath(ctx, fullPath); err != nil {
+		return nil, err
+	}
+
+	dir := filepath.Dir(fullPath)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		lg.Error(ctx, "Error creating directory", zap.String("path", dir), zap.Error(err))
+		return nil, err
+	}
+
+	file, err := os.CreateTemp(dir, "."+filepath.Base(fullPath)+tempMarker+"*")
+	if err != nil {
+		lg.Error(ctx, "Error creating temp file", zap.String("path", fullPath), zap.Error(err))
+		return nil, err
+	}
+
+	lg.Debug(ctx, "Temp file was created", zap.String("path", file.Name()))
+	return file, nil
+}
+
+// CommitTempFile flushes the temp file to disk and renames it over path.
+func (repo *FileStorageRepo) CommitTempFile(ctx context.Context, file FileHandle, path string) error {
+	fullPath := repo.BuildPath(path)
+	lg := logger.GetLoggerFromContext(ctx)
+
+	if err := repo.ValidatePath(ctx, fullPath); err != nil {
+		return err
+	}
+
+	tmp, ok := file.(*os.File)
+	if !ok {
+		return fmt.Errorf("file handle was not created by CreateTempFile")
+	}
+
+	if err := tmp.Sync(); err != nil {
+		lg.Error(ctx, "Error syncing temp file", zap.String("path", tmp.Name()), zap.Error(err))
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		lg.Error(ctx, "Error closing temp file", zap.String("path", tmp.Name()), zap.Error(err))
+		return err
+	}
+
+	if err := os.Rename(tmp.Name(), fullPath); err != nil {
+		lg.Error(ctx, "Error renaming temp file", zap.String("path", fullPath), zap.Error(err))
+		return err
+	}
+
+	if dir, err := os.Open(filepath.Dir(fullPath)); err == nil {
+		_ = dir.Sync()
+		_ = dir.Close()
+	}
+
+	lg.Info(ctx, "File was committed", zap.String("path", fullPath))
+	return nil
+}
+
+// DiscardTempFile closes and removes a temp file that was not committed.
+func (repo *FileStorageRepo) DiscardTempFile(ctx context.Context, file FileHandle) error {
+	lg := logger.GetLoggerFromContext(ctx)
+
+	tmp, ok := file.(*os.File)
+	if !ok {
+		return fmt.Errorf("file handle was not created by CreateTempFile")
+	}
+
+	_ = tmp.Close()
+
+	err := os.Remove(tmp.Name())
+	if err != nil && !os.IsNotExist(err) {
+		lg.Error(ctx, "Error removing temp file", zap.String("path", tmp.Name()), zap.Error(err))
+		return err
+	}
+
+	lg.Debug(ctx, "Temp file was discarded", zap.String("path", tmp.Name()))
+	return nil
+}
@@ -421,3 +421,58 @@ func TestFileStorageRepo_MoveFile(t *testing.T) {
 
 	defer os.RemoveAll(fullPath)
 }
+
+func TestFileStorageRepo_TempFile(t *testing.T) {
+	fullPath := CreateTempDir(t)
+	repo := New(relPath, 1024*1024, 2048)
+
+	ctx := context.Background()
+	lg := logger.New("test", "debug")
+	ctx = context.WithValue(ctx, logger.Key, lg)
+
+	target := "atomic/report.txt"
+	require.NoError(t, os.MkdirAll(filepath.Join(fullPath, "atomic"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(fullPath, target), []byte("old"), 0644))
+
+	t.Run("Temp file is hidden until commit", func(t *testing.T) {
+		f, err := repo.CreateTempFile(ctx, target)
+		require.NoError(t, err)
+
+		_, err = repo.AppendData(ctx, f, []byte("new content"), 0)
+		require.NoError(t, err)
+
+		entries, err := repo.ListDir(ctx, "atomic")
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+		assert.Equal(t, "report.txt", entries[0].Name)
+
+		data, err := os.ReadFile(filepath.Join(fullPath, target))
+		require.NoError(t, err)
+		assert.Equal(t, "old", string(data))
+
+		require.NoError(t, repo.CommitTempFile(ctx, f, target))
+
+		data, err = os.ReadFile(filepath.Join(fullPath, target))
+		require.NoError(t, err)
+		assert.Equal(t, "new content", string(data))
+	})
+
+	t.Run("Discard keeps previous version", func(t *testing.T) {
+		f, err := repo.CreateTempFile(ctx, target)
+		require.NoError(t, err)
+
+		_, err = repo.AppendData(ctx, f, []byte("half"), 0)
+		require.NoError(t, err)
+		require.NoError(t, repo.DiscardTempFile(ctx, f))
+
+		data, err := os.ReadFile(filepath.Join(fullPath, target))
+		require.NoError(t, err)
+		assert.Equal(t, "new content", string(data))
+
+		entries, err := os.ReadDir(filepath.Join(fullPath, "atomic"))
+		require.NoError(t, err)
+		assert.Len(t, entries, 1)
+	})
+
+	defer os.RemoveAll(fullPath)
+}
@@ -0,0 +1,112 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/JunBSer/FileManager/internal/tracing"
+	"github.com/JunBSer/FileManager/pkg/logger"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"io"
+	"path"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+)
+
+// handleIO implements the FileRepository methods that only work on an open
+// FileHandle, so every backend shares them.
+type handleIO struct {
+	readSize int64
+}
+
+func (h handleIO) GetReadSize() int64 {
+	return h.readSize
+}
+
+func (h handleIO) AppendData(ctx context.Context, file FileHandle, data []byte, pos int64) (wCnt int64, err error) {
+	ctx, span := tracing.Start(ctx, "repository.AppendData", trace.WithAttributes(attribute.Int64("position", pos), attribute.Int("bytes", len(data))))
+	defer func() { tracing.End(span, err) }()
+	lg := logger.GetLoggerFromContext(ctx)
+
+	_, err = file.Seek(pos, 0)
+	if err != nil {
+		lg.Error(ctx, "Error seeking file", zap.Int64("position", pos), zap.Error(err))
+	}
+
+	n, err := file.Write(data)
+	if err != nil {
+		lg.Error(ctx, "Error writing to file", zap.Int64("position", pos), zap.Error(err))
+		return -1, err
+	}
+
+	lg.Info(ctx, fmt.Sprintf("Wrote %d bytes to file", n))
+
+	return int64(n), err
+}
+
+func (h handleIO) ReadFile(ctx context.Context, file FileHandle, pos int64) (buf []byte, bRead int64, err error) {
+	ctx, span := tracing.Start(ctx, "repository.ReadFile", trace.WithAttributes(attribute.Int64("position", pos)))
+	defer func() {
+		span.SetAttributes(attribute.Int64("bytes", bRead))
+		if errors.Is(err, io.EOF) {
+			tracing.End(span, nil)
+			return
+		}
+		tracing.End(span, err)
+	}()
+	lg := logger.GetLoggerFromContext(ctx)
+
+	_, err = file.Seek(pos, 0)
+	if err != nil {
+		lg.Error(ctx, "Error seeking file", zap.Int64("position", pos), zap.Error(err))
+	}
+
+	buf = make([]byte, h.readSize)
+	n, err := file.Read(buf)
+
+	if err != nil {
+		lg.Error(ctx, "Error reading file", zap.Int64("position", pos), zap.Error(err))
+		return nil, 0, err
+	}
+
+	lg.Debug(ctx, fmt.Sprintf("Read %d bytes from file", n))
+
+	return buf, int64(n), err
+}
+
+// isRoot reports whether userPath names the storage root itself, which
+// ListDir accepts although it is not a valid file path.
+func isRoot(userPath string) bool {
+	return path.Join("root", filepath.ToSlash(userPath)) == "root"
+}
+
+// CleanKey turns a user path into a slash separated key relative to the
+// storage root. It applies the same rules as FileStorageRepo.ValidatePath for
+// backends that are not backed by a directory tree.
+func CleanKey(userPath string) (string, error) {
+	const root = "root"
+
+	joined := path.Join(root, filepath.ToSlash(userPath))
+	if joined == root {
+		return "", fmt.Errorf("path cannot be empty")
+	}
+	if !strings.HasPrefix(joined, root+"/") {
+		return "", fmt.Errorf("path %q is outside root directory", userPath)
+	}
+
+	invalidChars := `[*?"<>|]`
+	if runtime.GOOS == "windows" {
+		invalidChars = `[*?"<>|:]`
+	}
+
+	re := regexp.MustCompile("[" + regexp.QuoteMeta(invalidChars) + "]")
+	if match := re.FindString(path.Base(joined)); match != "" {
+		return "", fmt.Errorf("path contains invalid character %q", match)
+	}
+
+	return strings.TrimPrefix(joined, root+"/"), nil
+}
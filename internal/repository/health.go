@@ -0,0 +1,44 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// ErrLowDiskSpace is returned by CheckStorage when the storage directory has
+// less free space than required.
+var ErrLowDiskSpace = errors.New("not enough free space in storage")
+
+type HealthConfig struct {
+	// MinFreeBytes is the free space the storage directory needs for the
+	// service to be ready.
+	MinFreeBytes int64 `env:"HEALTH_MIN_FREE_BYTES" envDefault:"104857600"`
+	// Interval is how often the storage is checked.
+	Interval time.Duration `env:"HEALTH_CHECK_INTERVAL" envDefault:"10s"`
+}
+
+// CheckStorage checks that files can be written to dir, by creating and
+// removing a probe file, and that it has at least minFree bytes free. Free
+// space is not checked on systems it cannot be read on.
+func CheckStorage(dir string, minFree int64) error {
+	probe, err := os.CreateTemp(dir, ".health-*")
+	if err != nil {
+		return fmt.Errorf("storage is not writable: %w", err)
+	}
+	_, err = probe.Write([]byte("ok"))
+	err = errors.Join(err, probe.Close(), os.Remove(probe.Name()))
+	if err != nil {
+		return fmt.Errorf("storage is not writable: %w", err)
+	}
+
+	free, err := freeSpace(dir)
+	if err != nil {
+		return fmt.Errorf("cannot read free space of storage: %w", err)
+	}
+	if free >= 0 && free < minFree {
+		return fmt.Errorf("%w: %d bytes free, %d required", ErrLowDiskSpace, free, minFree)
+	}
+	return nil
+}
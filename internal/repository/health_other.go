@@ -0,0 +1,8 @@
+//go:build !linux && !darwin && !freebsd
+
+package repository
+
+// freeSpace returns -1, free space is unknown on this system.
+func freeSpace(string) (int64, error) {
+	return -1, nil
+}
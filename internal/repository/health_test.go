@@ -0,0 +1,26 @@
+package repository
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckStorage(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, CheckStorage(dir, 0))
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Empty(t, entries, "probe file is removed")
+
+	assert.Error(t, CheckStorage(filepath.Join(dir, "missing"), 0))
+
+	if free, err := freeSpace(dir); err == nil && free >= 0 {
+		assert.ErrorIs(t, CheckStorage(dir, math.MaxInt64), ErrLowDiskSpace)
+	}
+}
@@ -0,0 +1,164 @@
+package repository
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+const (
+	masterKeySize   = 32
+	masterKeyIDSize = 8
+)
+
+var ErrUnknownKey = errors.New("data key is wrapped by an unknown master key")
+
+type EncryptionConfig struct {
+	Enabled bool `env:"FILE_ENCRYPTION" envDefault:"false"`
+	// Key is the base64 master key that wraps the data keys of new files.
+	Key string `env:"FILE_ENCRYPTION_KEY"`
+	// KeyFile holds one base64 master key per line. Without Key, its first
+	// key wraps new data keys; the others only unwrap old ones.
+	KeyFile   string `env:"FILE_ENCRYPTION_KEY_FILE"`
+	ChunkSize int64  `env:"FILE_ENCRYPTION_CHUNK_SIZE" envDefault:"65536"`
+	// Rewrap rewraps the data keys of all files with the current master key
+	// in the background when the service starts.
+	Rewrap bool `env:"FILE_ENCRYPTION_REWRAP" envDefault:"false"`
+}
+
+type masterKey struct {
+	id   [masterKeyIDSize]byte
+	aead cipher.AEAD
+}
+
+// Keyring holds the master keys that wrap the data keys of encrypted files.
+// A master key is identified by the first bytes of its SHA-256, which is
+// stored with every wrapped data key.
+type Keyring struct {
+	keys []masterKey
+}
+
+// NewKeyring creates a keyring of 32 byte master keys. The first key wraps
+// new data keys, the others are kept to unwrap the data keys of files that
+// were not rewrapped yet.
+func NewKeyring(keys ...[]byte) (*Keyring, error) {
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("no master key")
+	}
+
+	ring := &Keyring{}
+	seen := map[[masterKeyIDSize]byte]bool{}
+	for _, key := range keys {
+		if len(key) != masterKeySize {
+			return nil, fmt.Errorf("master key must be %d bytes, got %d", masterKeySize, len(key))
+		}
+
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, err
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, err
+		}
+
+		mk := masterKey{aead: aead}
+		sum := sha256.Sum256(key)
+		copy(mk.id[:], sum[:])
+		if seen[mk.id] {
+			continue
+		}
+		seen[mk.id] = true
+		ring.keys = append(ring.keys, mk)
+	}
+	return ring, nil
+}
+
+// LoadKeyring reads the master keys of cfg. Key comes first if it is set,
+// followed by the keys of KeyFile. Empty lines and lines starting with #
+// are ignored in the key file.
+func LoadKeyring(cfg EncryptionConfig) (*Keyring, error) {
+	var keys [][]byte
+
+	if cfg.Key != "" {
+		key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(cfg.Key))
+		if err != nil {
+			return nil, fmt.Errorf("invalid master key: %w", err)
+		}
+		keys = append(keys, key)
+	}
+
+	if cfg.KeyFile != "" {
+		data, err := os.ReadFile(cfg.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+
+		scanner := bufio.NewScanner(bytes.NewReader(data))
+		for line := 1; scanner.Scan(); line++ {
+			text := strings.TrimSpace(scanner.Text())
+			if text == "" || strings.HasPrefix(text, "#") {
+				continue
+			}
+
+			key, err := base64.StdEncoding.DecodeString(text)
+			if err != nil {
+				return nil, fmt.Errorf("invalid master key in %s line %d: %w", cfg.KeyFile, line, err)
+			}
+			keys = append(keys, key)
+		}
+	}
+
+	return NewKeyring(keys...)
+}
+
+func (k *Keyring) find(id [masterKeyIDSize]byte) (masterKey, bool) {
+	for _, mk := range k.keys {
+		if mk.id == id {
+			return mk, true
+		}
+	}
+	return masterKey{}, false
+}
+
+// current reports whether id is the key that wraps new data keys.
+func (k *Keyring) current(id [masterKeyIDSize]byte) bool {
+	return k.keys[0].id == id
+}
+
+// wrap encrypts dek with the current master key into h. The header fields
+// before the wrapped key are authenticated with it.
+func (k *Keyring) wrap(h *cryptHeader, dek []byte) error {
+	mk := k.keys[0]
+	h.keyID = mk.id
+
+	nonce := make([]byte, mk.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+	h.wrapped = mk.aead.Seal(nonce, nonce, dek, h.prefix())
+	return nil
+}
+
+// unwrap decrypts the data key of h.
+func (k *Keyring) unwrap(h *cryptHeader) ([]byte, error) {
+	mk, ok := k.find(h.keyID)
+	if !ok {
+		return nil, fmt.Errorf("%w %x", ErrUnknownKey, h.keyID)
+	}
+
+	size := mk.aead.NonceSize()
+	dek, err := mk.aead.Open(nil, h.wrapped[:size], h.wrapped[size:], h.prefix())
+	if err != nil {
+		return nil, fmt.Errorf("cannot unwrap data key: %w", err)
+	}
+	return dek, nil
+}
@@ -0,0 +1,460 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/JunBSer/FileManager/pkg/logger"
+	"go.uber.org/zap"
+	"io"
+	"io/fs"
+	"os"
+	pathpkg "path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+var errNotEmpty = errors.New("directory not empty")
+
+// MemoryRepo keeps files in memory. It is meant for tests and ephemeral
+// caches; directories exist as long as they contain files or were created
+// with CreateDir.
+type MemoryRepo struct {
+	handleIO
+	mu    sync.RWMutex
+	files map[string]*memNode
+	dirs  map[string]bool
+}
+
+type memNode struct {
+	mu      sync.RWMutex
+	data    []byte
+	modTime time.Time
+}
+
+type memHandle struct {
+	mu     sync.Mutex
+	node   *memNode
+	key    string
+	flag   int
+	off    int64
+	temp   bool
+	closed bool
+}
+
+type memFileInfo struct {
+	name    string
+	size    int64
+	modTime time.Time
+	isDir   bool
+}
+
+func (fi memFileInfo) Name() string       { return fi.name }
+func (fi memFileInfo) Size() int64        { return fi.size }
+func (fi memFileInfo) ModTime() time.Time { return fi.modTime }
+func (fi memFileInfo) IsDir() bool        { return fi.isDir }
+func (fi memFileInfo) Sys() interface{}   { return nil }
+
+func (fi memFileInfo) Mode() fs.FileMode {
+	if fi.isDir {
+		return fs.ModeDir | 0o755
+	}
+	return 0o644
+}
+
+func init() {
+	Register("memory", func(ctx context.Context, cfg FileStorageConfig) (FileRepository, error) {
+		return NewMemory(cfg.ReadSize), nil
+	})
+}
+
+func NewMemory(readSize int64) *MemoryRepo {
+	return &MemoryRepo{handleIO: handleIO{readSize: readSize}, files: map[string]*memNode{}, dirs: map[string]bool{}}
+}
+
+func notExist(op, path string) error {
+	return &fs.PathError{Op: op, Path: path, Err: fs.ErrNotExist}
+}
+
+func alreadyExists(op, path string) error {
+	return &fs.PathError{Op: op, Path: path, Err: fs.ErrExist}
+}
+
+// hasChildren must be called with repo.mu held.
+func (repo *MemoryRepo) hasChildren(key string) bool {
+	prefix := key + "/"
+	for k := range repo.files {
+		if strings.HasPrefix(k, prefix) {
+			return true
+		}
+	}
+	for k := range repo.dirs {
+		if strings.HasPrefix(k, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// isDir must be called with repo.mu held.
+func (repo *MemoryRepo) isDir(key string) bool {
+	return repo.dirs[key] || repo.hasChildren(key)
+}
+
+func (repo *MemoryRepo) GetFileHandle(ctx context.Context, path string, openOption int) (FileHandle, error) {
+	lg := logger.GetLoggerFromContext(ctx)
+
+	key, err := CleanKey(path)
+	if err != nil {
+		lg.Debug(ctx, "Error to open file: path is invalid", zap.String("path", path))
+		return nil, err
+	}
+
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	node, ok := repo.files[key]
+	if !ok {
+		if openOption == Read {
+			return nil, notExist("open", path)
+		}
+		if repo.isDir(key) {
+			return nil, fmt.Errorf("%s is a directory", path)
+		}
+
+		node = &memNode{modTime: time.Now()}
+		repo.files[key] = node
+		lg.Info(ctx, "File was created", zap.String("path", key))
+	}
+
+	return &memHandle{node: node, key: key, flag: openOption}, nil
+}
+
+func (repo *MemoryRepo) MoveFile(ctx context.Context, srcPath, dstPath string) error {
+	return repo.move(ctx, srcPath, dstPath, false)
+}
+
+// MoveFileExclusive moves like MoveFile, but fails if dstPath exists.
+func (repo *MemoryRepo) MoveFileExclusive(ctx context.Context, srcPath, dstPath string) error {
+	return repo.move(ctx, srcPath, dstPath, true)
+}
+
+func (repo *MemoryRepo) move(ctx context.Context, srcPath, dstPath string, exclusive bool) error {
+	srcKey, err := CleanKey(srcPath)
+	if err != nil {
+		return err
+	}
+	dstKey, err := CleanKey(dstPath)
+	if err != nil {
+		return err
+	}
+
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	if _, taken := repo.files[dstKey]; exclusive && (taken || repo.isDir(dstKey)) {
+		return alreadyExists("rename", dstPath)
+	}
+
+	if node, ok := repo.files[srcKey]; ok {
+		delete(repo.files, srcKey)
+		repo.files[dstKey] = node
+		return nil
+	}
+
+	if !repo.isDir(srcKey) {
+		return notExist("rename", srcPath)
+	}
+
+	prefix := srcKey + "/"
+	for k, node := range repo.files {
+		if strings.HasPrefix(k, prefix) {
+			delete(repo.files, k)
+			repo.files[dstKey+"/"+strings.TrimPrefix(k, prefix)] = node
+		}
+	}
+	for k := range repo.dirs {
+		if k == srcKey || strings.HasPrefix(k, prefix) {
+			delete(repo.dirs, k)
+			repo.dirs[dstKey+strings.TrimPrefix(k, srcKey)] = true
+		}
+	}
+
+	logger.GetLoggerFromContext(ctx).Debug(ctx, "Directory was moved", zap.String("src", srcKey), zap.String("dst", dstKey))
+	return nil
+}
+
+func (repo *MemoryRepo) DeleteFile(ctx context.Context, path string) error {
+	key, err := CleanKey(path)
+	if err != nil {
+		return err
+	}
+
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	if _, ok := repo.files[key]; ok {
+		delete(repo.files, key)
+		return nil
+	}
+
+	if repo.hasChildren(key) {
+		return &fs.PathError{Op: "remove", Path: path, Err: errNotEmpty}
+	}
+	if repo.dirs[key] {
+		delete(repo.dirs, key)
+		return nil
+	}
+
+	logger.GetLoggerFromContext(ctx).Debug(ctx, "Error deleting file: not exist", zap.String("path", key))
+	return notExist("remove", path)
+}
+
+func (repo *MemoryRepo) ListDir(ctx context.Context, path string) ([]DirectoryEntry, error) {
+	var key, prefix string
+	if !isRoot(path) {
+		var err error
+		if key, err = CleanKey(path); err != nil {
+			logger.GetLoggerFromContext(ctx).Debug(ctx, "Error to list dir: path is invalid")
+			return nil, err
+		}
+		prefix = key + "/"
+	}
+
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	entries := map[string]bool{}
+	for k := range repo.files {
+		if !strings.HasPrefix(k, prefix) {
+			continue
+		}
+		name, _, isDir := strings.Cut(strings.TrimPrefix(k, prefix), "/")
+		entries[name] = entries[name] || isDir
+	}
+	for k := range repo.dirs {
+		if strings.HasPrefix(k, prefix) {
+			name, _, _ := strings.Cut(strings.TrimPrefix(k, prefix), "/")
+			entries[name] = true
+		}
+	}
+
+	if len(entries) == 0 {
+		if key == "" || repo.dirs[key] {
+			return []DirectoryEntry{}, nil
+		}
+		if _, ok := repo.files[key]; ok {
+			return nil, fmt.Errorf("%s is not a directory", path)
+		}
+		return nil, notExist("readdir", path)
+	}
+
+	result := make([]DirectoryEntry, 0, len(entries))
+	for name, isDir := range entries {
+		result = append(result, DirectoryEntry{Name: name, IsDir: isDir})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+
+	return result, nil
+}
+
+func (repo *MemoryRepo) Stat(ctx context.Context, path string) (fs.FileInfo, error) {
+	key, err := CleanKey(path)
+	if err != nil {
+		logger.GetLoggerFromContext(ctx).Debug(ctx, "Error to stat: path is invalid")
+		return nil, err
+	}
+
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	if node, ok := repo.files[key]; ok {
+		node.mu.RLock()
+		defer node.mu.RUnlock()
+		return memFileInfo{name: pathpkg.Base(key), size: int64(len(node.data)), modTime: node.modTime}, nil
+	}
+	if repo.isDir(key) {
+		return memFileInfo{name: pathpkg.Base(key), isDir: true}, nil
+	}
+	return nil, notExist("stat", path)
+}
+
+func (repo *MemoryRepo) CreateDir(ctx context.Context, path string) error {
+	key, err := CleanKey(path)
+	if err != nil {
+		logger.GetLoggerFromContext(ctx).Debug(ctx, "Error to create dir: path is invalid")
+		return err
+	}
+
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	for parent := key; ; parent = parent[:strings.LastIndex(parent, "/")] {
+		if _, ok := repo.files[parent]; ok {
+			return &fs.PathError{Op: "mkdir", Path: path, Err: fmt.Errorf("%s is not a directory", parent)}
+		}
+		if !strings.Contains(parent, "/") {
+			break
+		}
+	}
+
+	repo.dirs[key] = true
+	return nil
+}
+
+func (repo *MemoryRepo) CreateTempFile(ctx context.Context, path string) (FileHandle, error) {
+	key, err := CleanKey(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return &memHandle{node: &memNode{modTime: time.Now()}, key: key, flag: Write, temp: true}, nil
+}
+
+func (repo *MemoryRepo) CommitTempFile(ctx context.Context, file FileHandle, path string) error {
+	key, err := CleanKey(path)
+	if err != nil {
+		return err
+	}
+
+	h, ok := file.(*memHandle)
+	if !ok || !h.temp {
+		return fmt.Errorf("file handle was not created by CreateTempFile")
+	}
+
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	if repo.isDir(key) {
+		return fmt.Errorf("%s is a directory", path)
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		return os.ErrClosed
+	}
+	h.closed = true
+	repo.files[key] = h.node
+
+	logger.GetLoggerFromContext(ctx).Info(ctx, "File was committed", zap.String("path", key))
+	return nil
+}
+
+func (repo *MemoryRepo) DiscardTempFile(ctx context.Context, file FileHandle) error {
+	h, ok := file.(*memHandle)
+	if !ok || !h.temp {
+		return fmt.Errorf("file handle was not created by CreateTempFile")
+	}
+
+	h.mu.Lock()
+	h.closed = true
+	h.mu.Unlock()
+	return nil
+}
+
+func (h *memHandle) Read(b []byte) (int, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		return 0, os.ErrClosed
+	}
+	if h.flag&os.O_WRONLY != 0 {
+		return 0, fmt.Errorf("%s is opened for writing only", h.key)
+	}
+
+	h.node.mu.RLock()
+	defer h.node.mu.RUnlock()
+
+	if h.off >= int64(len(h.node.data)) {
+		return 0, io.EOF
+	}
+	n := copy(b, h.node.data[h.off:])
+	h.off += int64(n)
+
+	return n, nil
+}
+
+func (h *memHandle) Write(b []byte) (int, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		return 0, os.ErrClosed
+	}
+	if h.flag == Read {
+		return 0, fmt.Errorf("%s is opened for reading only", h.key)
+	}
+
+	h.node.mu.Lock()
+	defer h.node.mu.Unlock()
+
+	end := h.off + int64(len(b))
+	if end > int64(len(h.node.data)) {
+		grown := make([]byte, end)
+		copy(grown, h.node.data)
+		h.node.data = grown
+	}
+	copy(h.node.data[h.off:], b)
+	h.off = end
+	h.node.modTime = time.Now()
+
+	return len(b), nil
+}
+
+func (h *memHandle) Seek(offset int64, whence int) (int64, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		return 0, os.ErrClosed
+	}
+
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += h.off
+	case io.SeekEnd:
+		h.node.mu.RLock()
+		offset += int64(len(h.node.data))
+		h.node.mu.RUnlock()
+	default:
+		return 0, fmt.Errorf("invalid whence %d", whence)
+	}
+
+	if offset < 0 {
+		return 0, fmt.Errorf("negative position %d", offset)
+	}
+	h.off = offset
+
+	return offset, nil
+}
+
+func (h *memHandle) Stat() (fs.FileInfo, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		return nil, os.ErrClosed
+	}
+
+	h.node.mu.RLock()
+	defer h.node.mu.RUnlock()
+
+	return memFileInfo{name: pathpkg.Base(h.key), size: int64(len(h.node.data)), modTime: h.node.modTime}, nil
+}
+
+func (h *memHandle) Close() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		return os.ErrClosed
+	}
+	h.closed = true
+
+	return nil
+}
@@ -0,0 +1,132 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+)
+
+// Observer is told about every failed operation of an ObservedRepo.
+type Observer func(operation string, err error)
+
+// ObservedRepo reports the errors of the repository below it, for metrics.
+// Errors of the handles it opens are reported as read, write and close.
+// Looking up a path that does not exist and closing a handle twice are not
+// errors.
+type ObservedRepo struct {
+	handleIO
+	inner   FileRepository
+	observe Observer
+}
+
+type observedHandle struct {
+	FileHandle
+	observe Observer
+}
+
+func NewObserved(inner FileRepository, observe Observer) *ObservedRepo {
+	return &ObservedRepo{handleIO: handleIO{readSize: inner.GetReadSize()}, inner: inner, observe: observe}
+}
+
+// Unwrap returns the backend below repo.
+func (repo *ObservedRepo) Unwrap() FileRepository {
+	return repo.inner
+}
+
+func (repo *ObservedRepo) report(operation string, err error) error {
+	if err != nil {
+		repo.observe(operation, err)
+	}
+	return err
+}
+
+func (repo *ObservedRepo) handle(file FileHandle, err error) (FileHandle, error) {
+	if err != nil {
+		return nil, err
+	}
+	return &observedHandle{FileHandle: file, observe: repo.observe}, nil
+}
+
+// unwrapObserved returns the handle of the repository below repo.
+func unwrapObserved(file FileHandle) FileHandle {
+	if h, ok := file.(*observedHandle); ok {
+		return h.FileHandle
+	}
+	return file
+}
+
+func (repo *ObservedRepo) GetFileHandle(ctx context.Context, path string, openOption int) (FileHandle, error) {
+	file, err := repo.inner.GetFileHandle(ctx, path, openOption)
+	return repo.handle(file, repo.report("open", err))
+}
+
+func (repo *ObservedRepo) MoveFile(ctx context.Context, srcPath, dstPath string) error {
+	return repo.report("move", repo.inner.MoveFile(ctx, srcPath, dstPath))
+}
+
+func (repo *ObservedRepo) MoveFileExclusive(ctx context.Context, srcPath, dstPath string) error {
+	return repo.report("move", moveExclusive(ctx, repo.inner, srcPath, dstPath))
+}
+
+func (repo *ObservedRepo) CopyFile(ctx context.Context, srcPath, dstPath string) error {
+	return repo.report("copy", copyFile(ctx, repo.inner, srcPath, dstPath))
+}
+
+func (repo *ObservedRepo) DeleteFile(ctx context.Context, path string) error {
+	return repo.report("delete", repo.inner.DeleteFile(ctx, path))
+}
+
+func (repo *ObservedRepo) ListDir(ctx context.Context, path string) ([]DirectoryEntry, error) {
+	entries, err := repo.inner.ListDir(ctx, path)
+	return entries, repo.report("list", err)
+}
+
+func (repo *ObservedRepo) CreateDir(ctx context.Context, path string) error {
+	return repo.report("create_dir", repo.inner.CreateDir(ctx, path))
+}
+
+func (repo *ObservedRepo) Stat(ctx context.Context, path string) (fs.FileInfo, error) {
+	info, err := repo.inner.Stat(ctx, path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		repo.observe("stat", err)
+	}
+	return info, err
+}
+
+func (repo *ObservedRepo) CreateTempFile(ctx context.Context, path string) (FileHandle, error) {
+	file, err := repo.inner.CreateTempFile(ctx, path)
+	return repo.handle(file, repo.report("create_temp", err))
+}
+
+func (repo *ObservedRepo) CommitTempFile(ctx context.Context, file FileHandle, path string) error {
+	return repo.report("commit_temp", repo.inner.CommitTempFile(ctx, unwrapObserved(file), path))
+}
+
+func (repo *ObservedRepo) DiscardTempFile(ctx context.Context, file FileHandle) error {
+	return repo.report("discard_temp", repo.inner.DiscardTempFile(ctx, unwrapObserved(file)))
+}
+
+func (h *observedHandle) Read(b []byte) (int, error) {
+	n, err := h.FileHandle.Read(b)
+	if err != nil && err != io.EOF {
+		h.observe("read", err)
+	}
+	return n, err
+}
+
+func (h *observedHandle) Write(b []byte) (int, error) {
+	n, err := h.FileHandle.Write(b)
+	if err != nil {
+		h.observe("write", err)
+	}
+	return n, err
+}
+
+func (h *observedHandle) Close() error {
+	err := h.FileHandle.Close()
+	if err != nil && !errors.Is(err, fs.ErrClosed) {
+		h.observe("close", err)
+	}
+	return err
+}
@@ -0,0 +1,52 @@
+package repository
+
+import (
+	"context"
+	"github.com/JunBSer/FileManager/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io/fs"
+	"testing"
+)
+
+func TestConformance_Observed(t *testing.T) {
+	runConformance(t, func(t *testing.T) FileRepository {
+		return NewObserved(NewQuota(NewMemory(2048), QuotaLimit{}, nil), func(string, error) {})
+	})
+}
+
+func TestObservedRepo(t *testing.T) {
+	ctx := context.WithValue(context.Background(), logger.Key, logger.New("test", "debug"))
+
+	var operations []string
+	var errs []error
+	repo := NewObserved(NewQuota(NewMemory(2048), QuotaLimit{Bytes: 4}, nil), func(operation string, err error) {
+		operations = append(operations, operation)
+		errs = append(errs, err)
+	})
+
+	_, err := repo.Stat(ctx, "missing.txt")
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+	assert.Empty(t, operations, "a missing path is not an error")
+
+	_, err = repo.GetFileHandle(ctx, "missing.txt", Read)
+	assert.Error(t, err)
+	assert.ErrorIs(t, repo.DeleteFile(ctx, "missing.txt"), fs.ErrNotExist)
+
+	file, err := repo.CreateTempFile(ctx, "a.txt")
+	require.NoError(t, err)
+	_, err = file.Write([]byte("too long"))
+	assert.ErrorIs(t, err, ErrQuotaExceeded)
+	_, err = file.Write([]byte("ok"))
+	require.NoError(t, err)
+	require.NoError(t, repo.CommitTempFile(ctx, file, "a.txt"))
+
+	assert.Equal(t, []string{"open", "delete", "write"}, operations)
+	assert.ErrorIs(t, errs[2], ErrQuotaExceeded)
+
+	q, ok := Layer[*QuotaRepo](repo)
+	require.True(t, ok)
+	usage, err := q.Usage(ctx, "")
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), usage.Bytes)
+}
@@ -0,0 +1,723 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/JunBSer/FileManager/pkg/logger"
+	"go.uber.org/zap"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+var usageDir = filepath.Join(SystemDir, "usage")
+
+var (
+	ErrQuotaExceeded    = errors.New("quota exceeded")
+	ErrInvalidNamespace = errors.New("invalid namespace")
+)
+
+// RootNamespace names the namespace of the files stored in the root in
+// quota limits and usage reports.
+const RootNamespace = "/"
+
+type QuotaConfig struct {
+	Enabled bool `env:"FILE_QUOTA" envDefault:"false"`
+	// MaxBytes and MaxFiles limit every namespace that has no limits of its
+	// own. Zero means unlimited.
+	MaxBytes int64 `env:"FILE_QUOTA_MAX_BYTES" envDefault:"0"`
+	MaxFiles int64 `env:"FILE_QUOTA_MAX_FILES" envDefault:"0"`
+	// Limits is a comma separated list of namespace=bytes/files pairs, see
+	// ParseQuotaLimits.
+	Limits string `env:"FILE_QUOTA_LIMITS"`
+}
+
+// QuotaLimit caps the logical size and the number of files of a namespace.
+// Zero fields are not limited.
+type QuotaLimit struct {
+	Bytes int64
+	Files int64
+}
+
+// Usage is what a namespace stores, and what it may store.
+type Usage struct {
+	Namespace string
+	Bytes     int64
+	Files     int64
+	Limit     QuotaLimit
+}
+
+type usageRecord struct {
+	Bytes int64 `json:"bytes"`
+	Files int64 `json:"files"`
+}
+
+// nsUsage is the usage of a namespace and the space reserved for writes that
+// are in progress.
+type nsUsage struct {
+	usageRecord
+	reserved usageRecord
+}
+
+// QuotaRepo enforces quotas on the bytes and files of every namespace, the
+// top level directory of a path. The usage of a namespace is counted with a
+// walk only the first time it is needed; afterwards every change updates it
+// and it is kept in a record under SystemDir. Files under SystemDir are not
+// counted.
+//
+// Writes reserve the space they need beyond the size of the file they
+// replace before it is written, and fail with ErrQuotaExceeded if it does
+// not fit. Reservations turn into usage when a file is committed or closed.
+type QuotaRepo struct {
+	handleIO
+	inner    FileRepository
+	defaults QuotaLimit
+	limits   map[string]QuotaLimit
+
+	mu    sync.Mutex
+	usage map[string]*nsUsage
+}
+
+// quotaHandle tracks the size of a file while it is written.
+type quotaHandle struct {
+	FileHandle
+	repo *QuotaRepo
+	ctx  context.Context
+	ns   string
+	temp bool
+	// base is the size of the file the handle replaces or extends; writes
+	// up to it need no reservation.
+	base     int64
+	size     int64
+	reserved int64
+	newFile  bool
+}
+
+// ParseQuotaLimits parses limits such as "team-a=10737418240/1000,/=0/50".
+// A limit is a namespace, the maximum number of bytes and optionally the
+// maximum number of files; zero means unlimited. The namespace of the files
+// in the root is written as RootNamespace.
+func ParseQuotaLimits(limits string) (map[string]QuotaLimit, error) {
+	parsed := map[string]QuotaLimit{}
+
+	for _, rule := range strings.Split(limits, ",") {
+		rule = strings.TrimSpace(rule)
+		if rule == "" {
+			continue
+		}
+
+		name, value, ok := strings.Cut(rule, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid quota limit %q", rule)
+		}
+		ns, err := ParseNamespace(strings.TrimSpace(name))
+		if err != nil {
+			return nil, fmt.Errorf("invalid quota limit %q: %w", rule, err)
+		}
+
+		bytes, files, hasFiles := strings.Cut(strings.TrimSpace(value), "/")
+		var limit QuotaLimit
+		if limit.Bytes, err = strconv.ParseInt(bytes, 10, 64); err != nil || limit.Bytes < 0 {
+			return nil, fmt.Errorf("invalid byte limit in %q", rule)
+		}
+		if hasFiles {
+			if limit.Files, err = strconv.ParseInt(files, 10, 64); err != nil || limit.Files < 0 {
+				return nil, fmt.Errorf("invalid file limit in %q", rule)
+			}
+		}
+		parsed[ns] = limit
+	}
+
+	return parsed, nil
+}
+
+// ParseNamespace turns a namespace name of a request into the namespace
+// Namespace returns for its files. RootNamespace and "" stand for the root.
+func ParseNamespace(name string) (string, error) {
+	ns := strings.Trim(name, "/")
+	if ns == "" {
+		return "", nil
+	}
+	if ns == SystemDir || Namespace(filepath.Join(ns, "file")) != ns {
+		return "", fmt.Errorf("%w %q", ErrInvalidNamespace, name)
+	}
+	return ns, nil
+}
+
+// NamespaceName returns the name of ns in usage reports.
+func NamespaceName(ns string) string {
+	if ns == "" {
+		return RootNamespace
+	}
+	return ns
+}
+
+func NewQuota(inner FileRepository, defaults QuotaLimit, limits map[string]QuotaLimit) *QuotaRepo {
+	return &QuotaRepo{
+		handleIO: handleIO{readSize: inner.GetReadSize()},
+		inner:    inner,
+		defaults: defaults,
+		limits:   limits,
+		usage:    map[string]*nsUsage{},
+	}
+}
+
+// Unwrap returns the backend below repo.
+func (repo *QuotaRepo) Unwrap() FileRepository {
+	return repo.inner
+}
+
+// Limit returns the limits of namespace ns.
+func (repo *QuotaRepo) Limit(ns string) QuotaLimit {
+	if limit, ok := repo.limits[ns]; ok {
+		return limit
+	}
+	return repo.defaults
+}
+
+func usagePath(ns string) string {
+	if ns == "" {
+		return filepath.Join(usageDir, "top.json")
+	}
+	return filepath.Join(usageDir, "ns", ns+".json")
+}
+
+// counted returns the namespace path is accounted to, and false for paths
+// that are not counted.
+func counted(path string) (string, bool) {
+	if IsSystemPath(path) {
+		return "", false
+	}
+	return Namespace(path), true
+}
+
+// count sums up the files of ns with a walk.
+func (repo *QuotaRepo) count(ctx context.Context, ns string) (usageRecord, error) {
+	var rec usageRecord
+	add := func(p string) error {
+		size, ok, err := repo.fileSize(ctx, p)
+		if ok {
+			rec.Bytes += size
+			rec.Files++
+		}
+		return err
+	}
+
+	if ns != "" {
+		return rec, walk(ctx, repo.inner, ns, add)
+	}
+
+	entries, err := repo.inner.ListDir(ctx, "")
+	if err != nil {
+		return rec, err
+	}
+	for _, entry := range entries {
+		if entry.IsDir {
+			continue
+		}
+		if err = add(entry.Name); err != nil {
+			return rec, err
+		}
+	}
+	return rec, nil
+}
+
+// load returns the usage of ns. It must be called with repo.mu held.
+func (repo *QuotaRepo) load(ctx context.Context, ns string) (*nsUsage, error) {
+	if u, ok := repo.usage[ns]; ok {
+		return u, nil
+	}
+
+	u := &nsUsage{}
+	file, err := repo.inner.GetFileHandle(ctx, usagePath(ns), Read)
+	switch {
+	case err == nil:
+		var data []byte
+		data, err = io.ReadAll(file)
+		_ = file.Close()
+		if err != nil {
+			return nil, err
+		}
+		if err = json.Unmarshal(data, &u.usageRecord); err != nil {
+			return nil, fmt.Errorf("corrupted usage record of namespace %q: %w", NamespaceName(ns), err)
+		}
+	case os.IsNotExist(err):
+		logger.GetLoggerFromContext(ctx).Info(ctx, "Counting namespace usage", zap.String("namespace", NamespaceName(ns)))
+		if u.usageRecord, err = repo.count(ctx, ns); err != nil {
+			return nil, err
+		}
+		if err = writeJSON(ctx, repo.inner, usagePath(ns), u.usageRecord); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, err
+	}
+
+	repo.usage[ns] = u
+	return u, nil
+}
+
+// reserve reserves r in ns if it fits into the limits of ns.
+func (repo *QuotaRepo) reserve(ctx context.Context, ns string, r usageRecord) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	u, err := repo.load(ctx, ns)
+	if err != nil {
+		return err
+	}
+
+	limit := repo.Limit(ns)
+	if limit.Bytes > 0 && r.Bytes > 0 && u.Bytes+u.reserved.Bytes+r.Bytes > limit.Bytes {
+		return fmt.Errorf("%w: namespace %q is limited to %d bytes", ErrQuotaExceeded, NamespaceName(ns), limit.Bytes)
+	}
+	if limit.Files > 0 && r.Files > 0 && u.Files+u.reserved.Files+r.Files > limit.Files {
+		return fmt.Errorf("%w: namespace %q is limited to %d files", ErrQuotaExceeded, NamespaceName(ns), limit.Files)
+	}
+
+	u.reserved.Bytes += r.Bytes
+	u.reserved.Files += r.Files
+	return nil
+}
+
+func (repo *QuotaRepo) release(ns string, r usageRecord) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	if u, ok := repo.usage[ns]; ok {
+		u.reserved.Bytes -= r.Bytes
+		u.reserved.Files -= r.Files
+	}
+}
+
+// apply adds deltas to the usage of their namespaces. The change has
+// happened already, so errors saving the records are only logged.
+func (repo *QuotaRepo) apply(ctx context.Context, deltas map[string]usageRecord) {
+	lg := logger.GetLoggerFromContext(ctx)
+
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	for ns, d := range deltas {
+		if d == (usageRecord{}) {
+			continue
+		}
+
+		u, err := repo.load(ctx, ns)
+		if err == nil {
+			u.Bytes = max(u.Bytes+d.Bytes, 0)
+			u.Files = max(u.Files+d.Files, 0)
+			err = writeJSON(ctx, repo.inner, usagePath(ns), u.usageRecord)
+		}
+		if err != nil {
+			lg.Error(ctx, "Error updating namespace usage", zap.String("namespace", NamespaceName(ns)), zap.Error(err))
+		}
+	}
+}
+
+// change runs op, which changes the usage of namespaces by deltas. Growth is
+// reserved before op runs, so op is not run if it does not fit.
+func (repo *QuotaRepo) change(ctx context.Context, deltas map[string]usageRecord, op func() error) error {
+	reserved := map[string]usageRecord{}
+	defer func() {
+		for ns, r := range reserved {
+			repo.release(ns, r)
+		}
+	}()
+
+	for ns, d := range deltas {
+		r := usageRecord{Bytes: max(d.Bytes, 0), Files: max(d.Files, 0)}
+		if r == (usageRecord{}) {
+			continue
+		}
+		if err := repo.reserve(ctx, ns, r); err != nil {
+			return err
+		}
+		reserved[ns] = r
+	}
+
+	if err := op(); err != nil {
+		return err
+	}
+	repo.apply(ctx, deltas)
+	return nil
+}
+
+// fileSize returns the size of path and whether it is a file.
+func (repo *QuotaRepo) fileSize(ctx context.Context, path string) (int64, bool, error) {
+	info, err := repo.inner.Stat(ctx, path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, false, nil
+		}
+		return 0, false, err
+	}
+	if info.IsDir() {
+		return 0, false, nil
+	}
+	return info.Size(), true, nil
+}
+
+// moveDeltas returns how moving srcPath to dstPath changes the usage of the
+// namespaces.
+func (repo *QuotaRepo) moveDeltas(ctx context.Context, srcPath, dstPath string) (map[string]usageRecord, error) {
+	deltas := map[string]usageRecord{}
+	add := func(path string, bytes, files int64) {
+		if ns, ok := counted(path); ok {
+			d := deltas[ns]
+			deltas[ns] = usageRecord{Bytes: d.Bytes + bytes, Files: d.Files + files}
+		}
+	}
+
+	info, err := repo.inner.Stat(ctx, srcPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if !info.IsDir() {
+		size, exists, err := repo.fileSize(ctx, dstPath)
+		if err != nil {
+			return nil, err
+		}
+		if exists {
+			add(dstPath, -size, -1)
+		}
+		add(srcPath, -info.Size(), -1)
+		add(dstPath, info.Size(), 1)
+		return deltas, nil
+	}
+
+	// The files of a directory below the top level stay in their namespace
+	// when it moves within it.
+	srcNs, srcCounted := counted(filepath.Join(srcPath, "file"))
+	dstNs, dstCounted := counted(filepath.Join(dstPath, "file"))
+	if srcCounted == dstCounted && srcNs == dstNs {
+		return deltas, nil
+	}
+
+	err = walk(ctx, repo.inner, srcPath, func(p string) error {
+		size, ok, err := repo.fileSize(ctx, p)
+		if ok {
+			add(p, -size, -1)
+			add(target(srcPath, dstPath, p), size, 1)
+		}
+		return err
+	})
+	return deltas, err
+}
+
+// GetFileHandle opens counted files for writing in a handle that reserves
+// the space the writes need. A new file takes a file of the quota when it is
+// opened.
+func (repo *QuotaRepo) GetFileHandle(ctx context.Context, path string, openOption int) (FileHandle, error) {
+	ns, ok := counted(path)
+	if openOption == Read || !ok {
+		return repo.inner.GetFileHandle(ctx, path, openOption)
+	}
+
+	size, exists, err := repo.fileSize(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+
+	h := &quotaHandle{repo: repo, ctx: ctx, ns: ns, base: size, size: size, newFile: !exists}
+	if h.newFile {
+		if err = repo.reserve(ctx, ns, usageRecord{Files: 1}); err != nil {
+			return nil, err
+		}
+	}
+
+	if h.FileHandle, err = repo.inner.GetFileHandle(ctx, path, openOption); err != nil {
+		h.release()
+		return nil, err
+	}
+	return h, nil
+}
+
+func (repo *QuotaRepo) MoveFile(ctx context.Context, srcPath, dstPath string) error {
+	return repo.move(ctx, srcPath, dstPath, false)
+}
+
+func (repo *QuotaRepo) MoveFileExclusive(ctx context.Context, srcPath, dstPath string) error {
+	return repo.move(ctx, srcPath, dstPath, true)
+}
+
+func (repo *QuotaRepo) move(ctx context.Context, srcPath, dstPath string, exclusive bool) error {
+	move := moveWith(repo.inner, exclusive)
+	if IsSystemPath(srcPath) && IsSystemPath(dstPath) {
+		return move(ctx, srcPath, dstPath)
+	}
+
+	deltas, err := repo.moveDeltas(ctx, srcPath, dstPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return move(ctx, srcPath, dstPath)
+		}
+		return err
+	}
+
+	return repo.change(ctx, deltas, func() error {
+		return move(ctx, srcPath, dstPath)
+	})
+}
+
+func (repo *QuotaRepo) CopyFile(ctx context.Context, srcPath, dstPath string) error {
+	ns, ok := counted(dstPath)
+	if !ok {
+		return copyFile(ctx, repo.inner, srcPath, dstPath)
+	}
+
+	size, _, err := repo.fileSize(ctx, srcPath)
+	if err != nil {
+		return err
+	}
+	old, exists, err := repo.fileSize(ctx, dstPath)
+	if err != nil {
+		return err
+	}
+
+	d := usageRecord{Bytes: size - old, Files: 1}
+	if exists {
+		d.Files = 0
+	}
+	return repo.change(ctx, map[string]usageRecord{ns: d}, func() error {
+		return copyFile(ctx, repo.inner, srcPath, dstPath)
+	})
+}
+
+func (repo *QuotaRepo) DeleteFile(ctx context.Context, path string) error {
+	ns, ok := counted(path)
+	if !ok {
+		return repo.inner.DeleteFile(ctx, path)
+	}
+
+	size, exists, err := repo.fileSize(ctx, path)
+	if err != nil || !exists {
+		return repo.inner.DeleteFile(ctx, path)
+	}
+
+	return repo.change(ctx, map[string]usageRecord{ns: {Bytes: -size, Files: -1}}, func() error {
+		return repo.inner.DeleteFile(ctx, path)
+	})
+}
+
+// ListDir leaves SystemDir out of the root, which holds the usage records
+// even if no other service data was written.
+func (repo *QuotaRepo) ListDir(ctx context.Context, path string) ([]DirectoryEntry, error) {
+	entries, err := repo.inner.ListDir(ctx, path)
+	if err != nil || !isRoot(path) {
+		return entries, err
+	}
+
+	result := make([]DirectoryEntry, 0, len(entries))
+	for _, entry := range entries {
+		if entry.Name != SystemDir {
+			result = append(result, entry)
+		}
+	}
+	return result, nil
+}
+
+func (repo *QuotaRepo) CreateDir(ctx context.Context, path string) error {
+	return repo.inner.CreateDir(ctx, path)
+}
+
+func (repo *QuotaRepo) Stat(ctx context.Context, path string) (fs.FileInfo, error) {
+	return repo.inner.Stat(ctx, path)
+}
+
+// CreateTempFile takes a file of the quota if path does not exist yet. The
+// temp file may grow to the size of the file it replaces without reserving
+// space.
+func (repo *QuotaRepo) CreateTempFile(ctx context.Context, path string) (FileHandle, error) {
+	ns, ok := counted(path)
+	if !ok {
+		return repo.inner.CreateTempFile(ctx, path)
+	}
+
+	size, exists, err := repo.fileSize(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+
+	h := &quotaHandle{repo: repo, ctx: ctx, ns: ns, temp: true, base: size, newFile: !exists}
+	if h.newFile {
+		if err = repo.reserve(ctx, ns, usageRecord{Files: 1}); err != nil {
+			return nil, err
+		}
+	}
+
+	if h.FileHandle, err = repo.inner.CreateTempFile(ctx, path); err != nil {
+		h.release()
+		return nil, err
+	}
+	return h, nil
+}
+
+func (repo *QuotaRepo) CommitTempFile(ctx context.Context, file FileHandle, path string) error {
+	h, ok := file.(*quotaHandle)
+	if !ok {
+		return repo.inner.CommitTempFile(ctx, file, path)
+	}
+	if !h.temp {
+		return fmt.Errorf("file handle was not created by CreateTempFile")
+	}
+	defer h.release()
+
+	ns, ok := counted(path)
+	if !ok {
+		return repo.inner.CommitTempFile(ctx, h.FileHandle, path)
+	}
+
+	old, exists, err := repo.fileSize(ctx, path)
+	if err != nil {
+		return err
+	}
+	if err = repo.inner.CommitTempFile(ctx, h.FileHandle, path); err != nil {
+		return err
+	}
+
+	d := usageRecord{Bytes: h.size - old, Files: 1}
+	if exists {
+		d.Files = 0
+	}
+	repo.apply(ctx, map[string]usageRecord{ns: d})
+	return nil
+}
+
+func (repo *QuotaRepo) DiscardTempFile(ctx context.Context, file FileHandle) error {
+	h, ok := file.(*quotaHandle)
+	if !ok {
+		return repo.inner.DiscardTempFile(ctx, file)
+	}
+	if !h.temp {
+		return fmt.Errorf("file handle was not created by CreateTempFile")
+	}
+
+	h.release()
+	return repo.inner.DiscardTempFile(ctx, h.FileHandle)
+}
+
+// Usage returns the usage of namespace ns, "" for the files in the root.
+func (repo *QuotaRepo) Usage(ctx context.Context, ns string) (Usage, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	u, err := repo.load(ctx, ns)
+	if err != nil {
+		return Usage{}, err
+	}
+	return Usage{Namespace: ns, Bytes: u.Bytes, Files: u.Files, Limit: repo.Limit(ns)}, nil
+}
+
+// List returns the usage of the root and of every top level directory,
+// sorted by namespace.
+func (repo *QuotaRepo) List(ctx context.Context) ([]Usage, error) {
+	entries, err := repo.inner.ListDir(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+
+	namespaces := []string{""}
+	for _, entry := range entries {
+		if entry.IsDir && entry.Name != SystemDir {
+			namespaces = append(namespaces, entry.Name)
+		}
+	}
+	sort.Strings(namespaces)
+
+	usage := make([]Usage, 0, len(namespaces))
+	for _, ns := range namespaces {
+		u, err := repo.Usage(ctx, ns)
+		if err != nil {
+			return nil, err
+		}
+		usage = append(usage, u)
+	}
+	return usage, nil
+}
+
+// Recount counts the usage of ns again with a walk. It repairs records that
+// drifted, e.g. after files were changed while quotas were disabled.
+func (repo *QuotaRepo) Recount(ctx context.Context, ns string) (Usage, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	rec, err := repo.count(ctx, ns)
+	if err != nil {
+		return Usage{}, err
+	}
+	if err = writeJSON(ctx, repo.inner, usagePath(ns), rec); err != nil {
+		return Usage{}, err
+	}
+
+	u, ok := repo.usage[ns]
+	if !ok {
+		u = &nsUsage{}
+		repo.usage[ns] = u
+	}
+	u.usageRecord = rec
+
+	logger.GetLoggerFromContext(ctx).Info(ctx, "Namespace usage was recounted",
+		zap.String("namespace", NamespaceName(ns)), zap.Int64("bytes", rec.Bytes), zap.Int64("files", rec.Files))
+	return Usage{Namespace: ns, Bytes: rec.Bytes, Files: rec.Files, Limit: repo.Limit(ns)}, nil
+}
+
+func (h *quotaHandle) release() {
+	r := usageRecord{Bytes: h.reserved}
+	if h.newFile {
+		r.Files = 1
+	}
+	h.reserved, h.newFile = 0, false
+
+	if r != (usageRecord{}) {
+		h.repo.release(h.ns, r)
+	}
+}
+
+// Write reserves the space the data needs beyond the space reserved already.
+// Nothing is written if it does not fit.
+func (h *quotaHandle) Write(b []byte) (int, error) {
+	pos, err := h.FileHandle.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return 0, err
+	}
+
+	end := pos + int64(len(b))
+	if grow := end - h.base - h.reserved; grow > 0 {
+		if err = h.repo.reserve(h.ctx, h.ns, usageRecord{Bytes: grow}); err != nil {
+			return 0, err
+		}
+		h.reserved += grow
+	}
+
+	n, err := h.FileHandle.Write(b)
+	h.size = max(h.size, pos+int64(n))
+	return n, err
+}
+
+// Close turns the reservations of the handle into usage. Temp files are
+// accounted by CommitTempFile.
+func (h *quotaHandle) Close() error {
+	if h.temp {
+		return h.FileHandle.Close()
+	}
+
+	err := h.FileHandle.Close()
+	if err == nil {
+		d := usageRecord{Bytes: h.size - h.base}
+		if h.newFile {
+			d.Files = 1
+		}
+		h.repo.apply(h.ctx, map[string]usageRecord{h.ns: d})
+	}
+	h.release()
+	return err
+}
@@ -0,0 +1,199 @@
+package repository
+
+import (
+	"context"
+	"github.com/JunBSer/FileManager/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+)
+
+func TestConformance_Quota(t *testing.T) {
+	runConformance(t, func(t *testing.T) FileRepository {
+		return NewQuota(NewMemory(2048), QuotaLimit{}, nil)
+	})
+}
+
+func TestParseQuotaLimits(t *testing.T) {
+	limits, err := ParseQuotaLimits("team-a=1024/10, /team-b/=2048, /=0/5")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]QuotaLimit{
+		"team-a": {Bytes: 1024, Files: 10},
+		"team-b": {Bytes: 2048},
+		"":       {Files: 5},
+	}, limits)
+
+	for _, rules := range []string{"team-a", "team-a=-1", "team-a=1/x", "a/b=1", ".fm=1", "..=1"} {
+		_, err = ParseQuotaLimits(rules)
+		assert.Error(t, err, rules)
+	}
+}
+
+func TestQuotaRepo(t *testing.T) {
+	ctx := context.Background()
+	lg := logger.New("test", "debug")
+	ctx = context.WithValue(ctx, logger.Key, lg)
+
+	upload := func(repo FileRepository, path string, chunks ...string) error {
+		f, err := repo.CreateTempFile(ctx, path)
+		if err != nil {
+			return err
+		}
+		pos := int64(0)
+		for _, chunk := range chunks {
+			n, err := repo.AppendData(ctx, f, []byte(chunk), pos)
+			if err != nil {
+				_ = repo.DiscardTempFile(ctx, f)
+				return err
+			}
+			pos += n
+		}
+		return repo.CommitTempFile(ctx, f, path)
+	}
+
+	usage := func(t *testing.T, repo *QuotaRepo, ns string) (int64, int64) {
+		u, err := repo.Usage(ctx, ns)
+		require.NoError(t, err)
+		return u.Bytes, u.Files
+	}
+
+	newRepo := func() (*MemoryRepo, *QuotaRepo) {
+		inner := NewMemory(2048)
+		return inner, NewQuota(inner, QuotaLimit{}, map[string]QuotaLimit{"team-a": {Bytes: 100, Files: 3}})
+	}
+
+	t.Run("uploads fail when the quota is exceeded", func(t *testing.T) {
+		inner, repo := newRepo()
+
+		require.NoError(t, upload(repo, "team-a/a.txt", strings.Repeat("a", 60)))
+		err := upload(repo, "team-a/b.txt", strings.Repeat("b", 30), strings.Repeat("b", 30))
+		assert.ErrorIs(t, err, ErrQuotaExceeded)
+
+		_, err = inner.Stat(ctx, "team-a/b.txt")
+		assert.Error(t, err)
+		bytes, files := usage(t, repo, "team-a")
+		assert.Equal(t, int64(60), bytes)
+		assert.Equal(t, int64(1), files)
+
+		// Other namespaces are not limited.
+		require.NoError(t, upload(repo, "team-b/big.txt", strings.Repeat("b", 500)))
+		bytes, files = usage(t, repo, "team-b")
+		assert.Equal(t, int64(500), bytes)
+		assert.Equal(t, int64(1), files)
+
+		// Replacing a file only needs the space it grows by.
+		require.NoError(t, upload(repo, "team-a/a.txt", strings.Repeat("a", 100)))
+		bytes, files = usage(t, repo, "team-a")
+		assert.Equal(t, int64(100), bytes)
+		assert.Equal(t, int64(1), files)
+	})
+
+	t.Run("file count is limited", func(t *testing.T) {
+		_, repo := newRepo()
+
+		for _, name := range []string{"a", "b", "c"} {
+			require.NoError(t, upload(repo, "team-a/"+name, name))
+		}
+		assert.ErrorIs(t, upload(repo, "team-a/d", "d"), ErrQuotaExceeded)
+		require.NoError(t, upload(repo, "team-a/c", "cc"))
+
+		_, err := repo.GetFileHandle(ctx, "team-a/d", CreateAndW)
+		assert.ErrorIs(t, err, ErrQuotaExceeded)
+	})
+
+	t.Run("writes in place are counted", func(t *testing.T) {
+		_, repo := newRepo()
+
+		f, err := repo.GetFileHandle(ctx, "team-a/log.txt", CreateAndW)
+		require.NoError(t, err)
+		_, err = repo.AppendData(ctx, f, []byte(strings.Repeat("x", 80)), 0)
+		require.NoError(t, err)
+		_, err = repo.AppendData(ctx, f, []byte("x"), 10)
+		require.NoError(t, err)
+		_, err = repo.AppendData(ctx, f, []byte(strings.Repeat("x", 30)), 80)
+		assert.ErrorIs(t, err, ErrQuotaExceeded)
+		require.NoError(t, f.Close())
+
+		bytes, files := usage(t, repo, "team-a")
+		assert.Equal(t, int64(80), bytes)
+		assert.Equal(t, int64(1), files)
+	})
+
+	t.Run("deletes, moves and copies update the usage", func(t *testing.T) {
+		_, repo := newRepo()
+
+		require.NoError(t, upload(repo, "team-a/a.txt", strings.Repeat("a", 40)))
+		require.NoError(t, upload(repo, "team-b/dir/b.txt", strings.Repeat("b", 50)))
+		require.NoError(t, upload(repo, "team-b/dir/c.txt", strings.Repeat("c", 70)))
+
+		assert.ErrorIs(t, repo.MoveFile(ctx, "team-b/dir", "team-a/dir"), ErrQuotaExceeded)
+		assert.ErrorIs(t, repo.CopyFile(ctx, "team-b/dir/c.txt", "team-a/c.txt"), ErrQuotaExceeded)
+
+		require.NoError(t, repo.MoveFile(ctx, "team-b/dir/b.txt", "team-a/b.txt"))
+		require.NoError(t, repo.CopyFile(ctx, "team-a/a.txt", "team-b/a.txt"))
+		require.NoError(t, repo.MoveFile(ctx, "team-b/dir", "team-b/moved"))
+
+		bytes, files := usage(t, repo, "team-a")
+		assert.Equal(t, int64(90), bytes)
+		assert.Equal(t, int64(2), files)
+		bytes, files = usage(t, repo, "team-b")
+		assert.Equal(t, int64(110), bytes)
+		assert.Equal(t, int64(2), files)
+
+		// Renaming a namespace moves its usage.
+		require.NoError(t, repo.MoveFile(ctx, "team-b", "team-c"))
+		bytes, files = usage(t, repo, "team-c")
+		assert.Equal(t, int64(110), bytes)
+		assert.Equal(t, int64(2), files)
+		bytes, _ = usage(t, repo, "team-b")
+		assert.Zero(t, bytes)
+
+		// Trashed files stop counting and count again when restored.
+		trash := NewTrash(repo)
+		entry, err := trash.Move(ctx, "team-a/b.txt")
+		require.NoError(t, err)
+		bytes, files = usage(t, repo, "team-a")
+		assert.Equal(t, int64(40), bytes)
+		assert.Equal(t, int64(1), files)
+
+		require.NoError(t, upload(repo, "team-a/c.txt", strings.Repeat("c", 50)))
+		_, err = trash.Restore(ctx, entry.ID, "")
+		assert.ErrorIs(t, err, ErrQuotaExceeded)
+
+		require.NoError(t, repo.DeleteFile(ctx, "team-a/c.txt"))
+		_, err = trash.Restore(ctx, entry.ID, "")
+		require.NoError(t, err)
+		bytes, files = usage(t, repo, "team-a")
+		assert.Equal(t, int64(90), bytes)
+		assert.Equal(t, int64(2), files)
+	})
+
+	t.Run("usage is counted once and kept", func(t *testing.T) {
+		inner := NewMemory(2048)
+		require.NoError(t, upload(inner, "root.txt", "12345"))
+		require.NoError(t, upload(inner, "team-a/a.txt", "123"))
+		require.NoError(t, upload(inner, "team-a/sub/b.txt", "1234"))
+
+		repo := NewQuota(inner, QuotaLimit{}, nil)
+		list, err := repo.List(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, []Usage{
+			{Namespace: "", Bytes: 5, Files: 1},
+			{Namespace: "team-a", Bytes: 7, Files: 2},
+		}, list)
+
+		// A new repo uses the stored record instead of walking again.
+		require.NoError(t, upload(inner, "team-a/c.txt", "12"))
+		bytes, files := usage(t, NewQuota(inner, QuotaLimit{}, nil), "team-a")
+		assert.Equal(t, int64(7), bytes)
+		assert.Equal(t, int64(2), files)
+
+		u, err := repo.Recount(ctx, "team-a")
+		require.NoError(t, err)
+		assert.Equal(t, Usage{Namespace: "team-a", Bytes: 9, Files: 3}, u)
+		bytes, files = usage(t, NewQuota(inner, QuotaLimit{}, nil), "team-a")
+		assert.Equal(t, int64(9), bytes)
+		assert.Equal(t, int64(3), files)
+	})
+}
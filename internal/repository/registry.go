@@ -0,0 +1,153 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"github.com/JunBSer/FileManager/pkg/logger"
+	"go.uber.org/zap"
+	"sort"
+	"sync"
+)
+
+// Factory creates a FileRepository from the storage configuration.
+type Factory func(ctx context.Context, cfg FileStorageConfig) (FileRepository, error)
+
+var (
+	backendsMu sync.RWMutex
+	backends   = map[string]Factory{}
+)
+
+// Register makes a storage backend available under name. It is meant to be
+// called from init functions and panics on duplicate names.
+func Register(name string, factory Factory) {
+	backendsMu.Lock()
+	defer backendsMu.Unlock()
+
+	if _, ok := backends[name]; ok {
+		panic(fmt.Sprintf("storage backend %q is already registered", name))
+	}
+	backends[name] = factory
+}
+
+// Backends returns the names of all registered backends.
+func Backends() []string {
+	backendsMu.RLock()
+	defer backendsMu.RUnlock()
+
+	names := make([]string, 0, len(backends))
+	for name := range backends {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// Open creates the backend selected by cfg.Backend and wraps it in the
+// layers that cfg enables, from the innermost to the outermost:
+//
+//   - EncryptedRepo
+//   - DedupRepo
+//   - CompressedRepo
+//   - QuotaRepo
+//   - WatchRepo
+//
+// Content is thus compressed before it is deduplicated and encrypted, and
+// quotas count its plain size.
+func Open(ctx context.Context, cfg FileStorageConfig) (FileRepository, error) {
+	backendsMu.RLock()
+	factory, ok := backends[cfg.Backend]
+	backendsMu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("unknown storage backend %q, available: %v", cfg.Backend, Backends())
+	}
+
+	repo, err := factory(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.Encryption.Enabled {
+		if cfg.Encryption.ChunkSize <= 0 || cfg.Encryption.ChunkSize > MaxEncryptionChunkSize {
+			return nil, fmt.Errorf("invalid encryption chunk size %d", cfg.Encryption.ChunkSize)
+		}
+		keys, err := LoadKeyring(cfg.Encryption)
+		if err != nil {
+			return nil, err
+		}
+
+		repo = NewEncrypted(repo, keys, cfg.Encryption.ChunkSize)
+	}
+
+	if cfg.Dedup.Enabled {
+		if cfg.Dedup.ChunkSize <= 0 {
+			return nil, fmt.Errorf("invalid dedup chunk size %d", cfg.Dedup.ChunkSize)
+		}
+		repo = NewDedup(repo, cfg.Dedup.ChunkSize)
+	}
+
+	if cfg.Compression.Enabled {
+		if cfg.Compression.FrameSize <= 0 || cfg.Compression.FrameSize > MaxCompressionFrameSize {
+			return nil, fmt.Errorf("invalid compression frame size %d", cfg.Compression.FrameSize)
+		}
+		policy, err := ParseCompressionPolicy(cfg.Compression.Rules)
+		if err != nil {
+			return nil, err
+		}
+		repo = NewCompressed(repo, policy, cfg.Compression.FrameSize)
+	}
+
+	if cfg.Quota.Enabled {
+		if cfg.Quota.MaxBytes < 0 || cfg.Quota.MaxFiles < 0 {
+			return nil, fmt.Errorf("invalid default quota %d bytes, %d files", cfg.Quota.MaxBytes, cfg.Quota.MaxFiles)
+		}
+		limits, err := ParseQuotaLimits(cfg.Quota.Limits)
+		if err != nil {
+			return nil, err
+		}
+		repo = NewQuota(repo, QuotaLimit{Bytes: cfg.Quota.MaxBytes, Files: cfg.Quota.MaxFiles}, limits)
+	}
+
+	if cfg.Watch.Enabled {
+		if cfg.Watch.Buffer < 0 {
+			return nil, fmt.Errorf("invalid watch buffer %d", cfg.Watch.Buffer)
+		}
+		repo = NewWatch(repo, NewEventHub(cfg.Watch.Buffer))
+	}
+
+	logger.GetLoggerFromContext(ctx).Info(ctx, "Storage backend opened",
+		zap.String("backend", cfg.Backend), zap.Bool("encryption", cfg.Encryption.Enabled),
+		zap.Bool("dedup", cfg.Dedup.Enabled), zap.Bool("compression", cfg.Compression.Enabled),
+		zap.Bool("quota", cfg.Quota.Enabled), zap.Bool("watch", cfg.Watch.Enabled))
+	return repo, nil
+}
+
+// Layer returns the first repository of type T in the chain of wrappers
+// starting at repo.
+func Layer[T FileRepository](repo FileRepository) (T, bool) {
+	for repo != nil {
+		if layer, ok := repo.(T); ok {
+			return layer, true
+		}
+
+		wrapper, ok := repo.(interface{ Unwrap() FileRepository })
+		if !ok {
+			break
+		}
+		repo = wrapper.Unwrap()
+	}
+
+	var zero T
+	return zero, false
+}
+
+func init() {
+	Register("local", func(ctx context.Context, cfg FileStorageConfig) (FileRepository, error) {
+		repo := New(cfg.StoragePath, cfg.MaxSize, cfg.ReadSize)
+		if repo == nil {
+			return nil, fmt.Errorf("cannot create storage directory %q", cfg.StoragePath)
+		}
+		return repo, nil
+	})
+}
@@ -0,0 +1,331 @@
+package repository
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	s3Algorithm     = "AWS4-HMAC-SHA256"
+	s3UnsignedBody  = "UNSIGNED-PAYLOAD"
+	s3TimeFormat    = "20060102T150405Z"
+	s3ShortTimeForm = "20060102"
+)
+
+type S3Config struct {
+	Endpoint  string `env:"S3_ENDPOINT" envDefault:"http://localhost:9000"`
+	Region    string `env:"S3_REGION" envDefault:"us-east-1"`
+	Bucket    string `env:"S3_BUCKET" envDefault:"files"`
+	Prefix    string `env:"S3_PREFIX" envDefault:""`
+	AccessKey string `env:"S3_ACCESS_KEY" envDefault:""`
+	SecretKey string `env:"S3_SECRET_KEY" envDefault:""`
+}
+
+// s3Client is a minimal S3 API client using path-style addressing and
+// Signature V4, enough to talk to AWS S3, MinIO and compatible stores.
+type s3Client struct {
+	cfg      S3Config
+	endpoint *url.URL
+	http     *http.Client
+}
+
+type s3Object struct {
+	Key          string    `xml:"Key"`
+	Size         int64     `xml:"Size"`
+	LastModified time.Time `xml:"LastModified"`
+}
+
+type s3ListResult struct {
+	Contents              []s3Object `xml:"Contents"`
+	CommonPrefixes        []string   `xml:"CommonPrefixes>Prefix"`
+	IsTruncated           bool       `xml:"IsTruncated"`
+	NextContinuationToken string     `xml:"NextContinuationToken"`
+}
+
+type s3Error struct {
+	Code    string `xml:"Code"`
+	Message string `xml:"Message"`
+}
+
+func newS3Client(cfg S3Config) (*s3Client, error) {
+	endpoint, err := url.Parse(cfg.Endpoint)
+	if err != nil || endpoint.Host == "" {
+		return nil, fmt.Errorf("invalid S3 endpoint %q", cfg.Endpoint)
+	}
+	if cfg.Bucket == "" {
+		return nil, fmt.Errorf("S3 bucket is not configured")
+	}
+
+	return &s3Client{cfg: cfg, endpoint: endpoint, http: &http.Client{}}, nil
+}
+
+// s3Escape encodes s the way Signature V4 expects: everything except
+// unreserved characters is percent-encoded, '/' is kept if keepSlash is set.
+func s3Escape(s string, keepSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' ||
+			c == '-' || c == '_' || c == '.' || c == '~' || (keepSlash && c == '/') {
+			b.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&b, "%%%02X", c)
+	}
+	return b.String()
+}
+
+func s3CanonicalQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		values := append([]string(nil), query[k]...)
+		sort.Strings(values)
+		for _, v := range values {
+			parts = append(parts, s3Escape(k, false)+"="+s3Escape(v, false))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// signature computes the Signature V4 of a request. header must contain the
+// host, x-amz-date and x-amz-content-sha256 values.
+func (c *s3Client) signature(method, escapedPath, rawQuery string, header http.Header) (string, string) {
+	names := make([]string, 0, len(header))
+	for name := range header {
+		lower := strings.ToLower(name)
+		if lower == "host" || strings.HasPrefix(lower, "x-amz-") {
+			names = append(names, lower)
+		}
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + strings.TrimSpace(header.Get(name)) + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	query, _ := url.ParseQuery(rawQuery)
+	canonicalRequest := strings.Join([]string{
+		method,
+		escapedPath,
+		s3CanonicalQuery(query),
+		canonicalHeaders.String(),
+		signedHeaders,
+		header.Get("X-Amz-Content-Sha256"),
+	}, "\n")
+
+	amzDate := header.Get("X-Amz-Date")
+	scope := amzDate[:8] + "/" + c.cfg.Region + "/s3/aws4_request"
+	hash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := s3Algorithm + "\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(hash[:])
+
+	key := hmacSHA256([]byte("AWS4"+c.cfg.SecretKey), amzDate[:8])
+	key = hmacSHA256(key, c.cfg.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+
+	return signedHeaders, hex.EncodeToString(hmacSHA256(key, stringToSign))
+}
+
+func (c *s3Client) objectKey(key string) string {
+	if c.cfg.Prefix == "" {
+		return key
+	}
+	// Keep the trailing slash of directory markers that path.Join would drop.
+	return strings.TrimSuffix(c.cfg.Prefix, "/") + "/" + key
+}
+
+func (c *s3Client) do(ctx context.Context, method, key string, query url.Values, header http.Header, body io.Reader, size int64) (*http.Response, error) {
+	objectPath := "/" + c.cfg.Bucket
+	if key != "" {
+		objectPath += "/" + key
+	}
+
+	u := *c.endpoint
+	u.Path = strings.TrimSuffix(c.endpoint.Path, "/") + objectPath
+	u.RawPath = strings.TrimSuffix(c.endpoint.EscapedPath(), "/") + s3Escape(objectPath, true)
+	u.RawQuery = s3CanonicalQuery(query)
+
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	// The transport closes request bodies; callers keep ownership of theirs.
+	if body != nil && size > 0 {
+		req.Body = io.NopCloser(body)
+		req.ContentLength = size
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+
+	req.Header.Set("Host", u.Host)
+	req.Header.Set("X-Amz-Date", time.Now().UTC().Format(s3TimeFormat))
+	req.Header.Set("X-Amz-Content-Sha256", s3UnsignedBody)
+
+	if c.cfg.AccessKey != "" {
+		signedHeaders, signature := c.signature(method, u.EscapedPath(), u.RawQuery, req.Header)
+		scope := req.Header.Get("X-Amz-Date")[:8] + "/" + c.cfg.Region + "/s3/aws4_request"
+		req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+			s3Algorithm, c.cfg.AccessKey, scope, signedHeaders, signature))
+	}
+	req.Header.Del("Host")
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		if resp.StatusCode == http.StatusNotFound {
+			return nil, notExist(strings.ToLower(method), key)
+		}
+
+		apiErr := s3Error{Code: resp.Status}
+		_ = xml.NewDecoder(resp.Body).Decode(&apiErr)
+		return resp, fmt.Errorf("s3 %s %q: %s %s", method, key, apiErr.Code, apiErr.Message)
+	}
+
+	return resp, nil
+}
+
+func (c *s3Client) head(ctx context.Context, key string) (int64, time.Time, error) {
+	resp, err := c.do(ctx, http.MethodHead, c.objectKey(key), nil, nil, nil, 0)
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+	defer resp.Body.Close()
+
+	modTime, _ := http.ParseTime(resp.Header.Get("Last-Modified"))
+	return resp.ContentLength, modTime, nil
+}
+
+func (c *s3Client) get(ctx context.Context, key string, offset int64) (io.ReadCloser, error) {
+	header := http.Header{}
+	if offset > 0 {
+		header.Set("Range", "bytes="+strconv.FormatInt(offset, 10)+"-")
+	}
+
+	resp, err := c.do(ctx, http.MethodGet, c.objectKey(key), nil, header, nil, 0)
+	if err != nil {
+		if resp != nil && resp.StatusCode == http.StatusRequestedRangeNotSatisfiable {
+			return io.NopCloser(strings.NewReader("")), nil
+		}
+		return nil, err
+	}
+
+	return resp.Body, nil
+}
+
+func (c *s3Client) put(ctx context.Context, key string, body io.Reader, size int64) error {
+	resp, err := c.do(ctx, http.MethodPut, c.objectKey(key), nil, nil, body, size)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+func (c *s3Client) copy(ctx context.Context, srcKey, dstKey string) error {
+	header := http.Header{}
+	header.Set("X-Amz-Copy-Source", s3Escape("/"+c.cfg.Bucket+"/"+c.objectKey(srcKey), true))
+
+	resp, err := c.do(ctx, http.MethodPut, c.objectKey(dstKey), nil, header, nil, 0)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// CopyObject may report a failure with status 200 and an error body.
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	apiErr := s3Error{}
+	if xml.Unmarshal(data, &apiErr) == nil && apiErr.Code != "" {
+		return fmt.Errorf("s3 copy %q: %s %s", srcKey, apiErr.Code, apiErr.Message)
+	}
+
+	return nil
+}
+
+func (c *s3Client) delete(ctx context.Context, key string) error {
+	resp, err := c.do(ctx, http.MethodDelete, c.objectKey(key), nil, nil, nil, 0)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+// list returns the objects and common prefixes under prefix. Keys and prefixes
+// are returned relative to the configured key prefix.
+func (c *s3Client) list(ctx context.Context, prefix, delimiter string, limit int) ([]s3Object, []string, error) {
+	var objects []s3Object
+	var prefixes []string
+
+	base := ""
+	if c.cfg.Prefix != "" {
+		base = strings.TrimSuffix(c.cfg.Prefix, "/") + "/"
+	}
+
+	query := url.Values{}
+	query.Set("list-type", "2")
+	query.Set("prefix", base+prefix)
+	if delimiter != "" {
+		query.Set("delimiter", delimiter)
+	}
+	if limit > 0 {
+		query.Set("max-keys", strconv.Itoa(limit))
+	}
+
+	for {
+		resp, err := c.do(ctx, http.MethodGet, "", query, nil, nil, 0)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		result := s3ListResult{}
+		err = xml.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+		if err != nil {
+			return nil, nil, err
+		}
+
+		for _, object := range result.Contents {
+			object.Key = strings.TrimPrefix(object.Key, base)
+			objects = append(objects, object)
+		}
+		for _, p := range result.CommonPrefixes {
+			prefixes = append(prefixes, strings.TrimPrefix(p, base))
+		}
+
+		if !result.IsTruncated || (limit > 0 && len(objects)+len(prefixes) >= limit) {
+			return objects, prefixes, nil
+		}
+		query.Set("continuation-token", result.NextContinuationToken)
+	}
+}
@@ -0,0 +1,408 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"github.com/JunBSer/FileManager/pkg/logger"
+	"go.uber.org/zap"
+	"io"
+	"io/fs"
+	"os"
+	pathpkg "path"
+	"strings"
+	"sync"
+	"time"
+)
+
+// S3Repo stores files as objects of an S3-compatible bucket. Directories are
+// key prefixes; empty directories are kept by an empty marker object whose key
+// ends with a slash. Files opened for writing are spooled to a local temp file and
+// uploaded when they are closed or committed.
+type S3Repo struct {
+	handleIO
+	client *s3Client
+}
+
+// s3ReadHandle reads an object through a ranged GET that is reopened after
+// every seek.
+type s3ReadHandle struct {
+	mu      sync.Mutex
+	client  *s3Client
+	key     string
+	size    int64
+	modTime time.Time
+	off     int64
+	body    io.ReadCloser
+	closed  bool
+}
+
+func init() {
+	Register("s3", func(ctx context.Context, cfg FileStorageConfig) (FileRepository, error) {
+		return NewS3(cfg.S3, cfg.ReadSize)
+	})
+}
+
+func NewS3(cfg S3Config, readSize int64) (*S3Repo, error) {
+	client, err := newS3Client(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return &S3Repo{handleIO: handleIO{readSize: readSize}, client: client}, nil
+}
+
+func (repo *S3Repo) spool(ctx context.Context, key string, temp bool) (*spoolHandle, error) {
+	handle, err := newSpool(ctx, key, temp, repo.client.put)
+	if err != nil {
+		logger.GetLoggerFromContext(ctx).Error(ctx, "Error creating spool file", zap.Error(err))
+		return nil, err
+	}
+
+	return handle, nil
+}
+
+func (repo *S3Repo) hasChildren(ctx context.Context, key string) (bool, error) {
+	objects, prefixes, err := repo.client.list(ctx, key+"/", "", 1)
+	if err != nil {
+		return false, err
+	}
+	return len(objects)+len(prefixes) > 0, nil
+}
+
+func (repo *S3Repo) GetFileHandle(ctx context.Context, path string, openOption int) (FileHandle, error) {
+	lg := logger.GetLoggerFromContext(ctx)
+
+	key, err := CleanKey(path)
+	if err != nil {
+		lg.Debug(ctx, "Error to open file: path is invalid", zap.String("path", path))
+		return nil, err
+	}
+
+	size, modTime, err := repo.client.head(ctx, key)
+	exists := err == nil
+	if err != nil && !os.IsNotExist(err) {
+		lg.Error(ctx, "Error getting object info", zap.String("key", key), zap.Error(err))
+		return nil, err
+	}
+
+	if openOption == Read {
+		if !exists {
+			return nil, notExist("open", path)
+		}
+		return &s3ReadHandle{client: repo.client, key: key, size: size, modTime: modTime}, nil
+	}
+
+	handle, err := repo.spool(ctx, key, false)
+	if err != nil {
+		return nil, err
+	}
+
+	if exists {
+		body, err := repo.client.get(ctx, key, 0)
+		if err == nil {
+			err = handle.fill(body)
+			body.Close()
+		}
+		if err != nil {
+			lg.Error(ctx, "Error downloading object", zap.String("key", key), zap.Error(err))
+			handle.discard()
+			return nil, err
+		}
+		return handle, nil
+	}
+
+	if err = repo.client.put(ctx, key, nil, 0); err != nil {
+		lg.Error(ctx, "Error creating object", zap.String("key", key), zap.Error(err))
+		handle.discard()
+		return nil, err
+	}
+	lg.Info(ctx, "File was created", zap.String("path", key))
+
+	return handle, nil
+}
+
+func (repo *S3Repo) MoveFile(ctx context.Context, srcPath, dstPath string) error {
+	lg := logger.GetLoggerFromContext(ctx)
+
+	srcKey, err := CleanKey(srcPath)
+	if err != nil {
+		return err
+	}
+	dstKey, err := CleanKey(dstPath)
+	if err != nil {
+		return err
+	}
+
+	if _, _, err = repo.client.head(ctx, srcKey); err == nil {
+		if err = repo.client.copy(ctx, srcKey, dstKey); err != nil {
+			lg.Error(ctx, "Error copying object", zap.String("src", srcKey), zap.Error(err))
+			return err
+		}
+		return repo.client.delete(ctx, srcKey)
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+
+	objects, _, err := repo.client.list(ctx, srcKey+"/", "", 0)
+	if err != nil {
+		return err
+	}
+	if len(objects) == 0 {
+		return notExist("rename", srcPath)
+	}
+
+	for _, object := range objects {
+		target := dstKey + "/" + strings.TrimPrefix(object.Key, srcKey+"/")
+		if err = repo.client.copy(ctx, object.Key, target); err != nil {
+			lg.Error(ctx, "Error copying object", zap.String("src", object.Key), zap.Error(err))
+			return err
+		}
+		if err = repo.client.delete(ctx, object.Key); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (repo *S3Repo) DeleteFile(ctx context.Context, path string) error {
+	key, err := CleanKey(path)
+	if err != nil {
+		return err
+	}
+
+	if _, _, err = repo.client.head(ctx, key); err == nil {
+		return repo.client.delete(ctx, key)
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+
+	objects, prefixes, err := repo.client.list(ctx, key+"/", "", 2)
+	if err != nil {
+		return err
+	}
+	if len(objects) == 1 && len(prefixes) == 0 && objects[0].Key == key+"/" {
+		return repo.client.delete(ctx, key+"/")
+	}
+	if len(objects)+len(prefixes) > 0 {
+		return &fs.PathError{Op: "remove", Path: path, Err: errNotEmpty}
+	}
+
+	return notExist("remove", path)
+}
+
+func (repo *S3Repo) ListDir(ctx context.Context, path string) ([]DirectoryEntry, error) {
+	var key, prefix string
+	if !isRoot(path) {
+		var err error
+		if key, err = CleanKey(path); err != nil {
+			logger.GetLoggerFromContext(ctx).Debug(ctx, "Error to list dir: path is invalid")
+			return nil, err
+		}
+		prefix = key + "/"
+	}
+
+	objects, prefixes, err := repo.client.list(ctx, prefix, "/", 0)
+	if err != nil {
+		logger.GetLoggerFromContext(ctx).Error(ctx, "Error listing objects", zap.String("prefix", key), zap.Error(err))
+		return nil, err
+	}
+
+	if len(objects)+len(prefixes) == 0 {
+		if key == "" {
+			return []DirectoryEntry{}, nil
+		}
+		if _, _, err = repo.client.head(ctx, key); err == nil {
+			return nil, fmt.Errorf("%s is not a directory", path)
+		}
+		return nil, notExist("readdir", path)
+	}
+
+	result := make([]DirectoryEntry, 0, len(objects)+len(prefixes))
+	for _, p := range prefixes {
+		result = append(result, DirectoryEntry{Name: strings.TrimSuffix(strings.TrimPrefix(p, prefix), "/"), IsDir: true})
+	}
+	for _, object := range objects {
+		if object.Key == prefix {
+			continue
+		}
+		result = append(result, DirectoryEntry{Name: strings.TrimPrefix(object.Key, prefix)})
+	}
+
+	return result, nil
+}
+
+func (repo *S3Repo) Stat(ctx context.Context, path string) (fs.FileInfo, error) {
+	key, err := CleanKey(path)
+	if err != nil {
+		logger.GetLoggerFromContext(ctx).Debug(ctx, "Error to stat: path is invalid")
+		return nil, err
+	}
+
+	size, modTime, err := repo.client.head(ctx, key)
+	if err == nil {
+		return memFileInfo{name: pathpkg.Base(key), size: size, modTime: modTime}, nil
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	children, err := repo.hasChildren(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if !children {
+		return nil, notExist("stat", path)
+	}
+	return memFileInfo{name: pathpkg.Base(key), isDir: true}, nil
+}
+
+func (repo *S3Repo) CreateDir(ctx context.Context, path string) error {
+	key, err := CleanKey(path)
+	if err != nil {
+		logger.GetLoggerFromContext(ctx).Debug(ctx, "Error to create dir: path is invalid")
+		return err
+	}
+
+	for parent := key; ; parent = parent[:strings.LastIndex(parent, "/")] {
+		if _, _, err = repo.client.head(ctx, parent); err == nil {
+			return &fs.PathError{Op: "mkdir", Path: path, Err: fmt.Errorf("%s is not a directory", parent)}
+		} else if !os.IsNotExist(err) {
+			return err
+		}
+		if !strings.Contains(parent, "/") {
+			break
+		}
+	}
+
+	if err = repo.client.put(ctx, key+"/", strings.NewReader(""), 0); err != nil {
+		logger.GetLoggerFromContext(ctx).Error(ctx, "Error creating directory marker", zap.String("key", key), zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+func (repo *S3Repo) CreateTempFile(ctx context.Context, path string) (FileHandle, error) {
+	key, err := CleanKey(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return repo.spool(ctx, key, true)
+}
+
+func (repo *S3Repo) CommitTempFile(ctx context.Context, file FileHandle, path string) error {
+	key, err := CleanKey(path)
+	if err != nil {
+		return err
+	}
+
+	h, ok := file.(*spoolHandle)
+	if !ok || !h.temp {
+		return fmt.Errorf("file handle was not created by CreateTempFile")
+	}
+	defer h.discard()
+
+	h.key = key
+	if err = h.upload(ctx); err != nil {
+		logger.GetLoggerFromContext(ctx).Error(ctx, "Error uploading object", zap.String("key", key), zap.Error(err))
+		return err
+	}
+
+	logger.GetLoggerFromContext(ctx).Info(ctx, "File was committed", zap.String("path", key))
+	return nil
+}
+
+func (repo *S3Repo) DiscardTempFile(ctx context.Context, file FileHandle) error {
+	h, ok := file.(*spoolHandle)
+	if !ok || !h.temp {
+		return fmt.Errorf("file handle was not created by CreateTempFile")
+	}
+
+	h.discard()
+	return nil
+}
+
+func (h *s3ReadHandle) Read(b []byte) (int, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		return 0, os.ErrClosed
+	}
+	if h.off >= h.size {
+		return 0, io.EOF
+	}
+
+	if h.body == nil {
+		body, err := h.client.get(context.Background(), h.key, h.off)
+		if err != nil {
+			return 0, err
+		}
+		h.body = body
+	}
+
+	n, err := io.ReadFull(h.body, b)
+	h.off += int64(n)
+	if n > 0 && (err == io.EOF || err == io.ErrUnexpectedEOF) {
+		return n, nil
+	}
+
+	return n, err
+}
+
+func (h *s3ReadHandle) Seek(offset int64, whence int) (int64, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		return 0, os.ErrClosed
+	}
+
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += h.off
+	case io.SeekEnd:
+		offset += h.size
+	default:
+		return 0, fmt.Errorf("invalid whence %d", whence)
+	}
+
+	if offset < 0 {
+		return 0, fmt.Errorf("negative position %d", offset)
+	}
+
+	if offset != h.off && h.body != nil {
+		h.body.Close()
+		h.body = nil
+	}
+	h.off = offset
+
+	return offset, nil
+}
+
+func (h *s3ReadHandle) Write(b []byte) (int, error) {
+	return 0, fmt.Errorf("%s is opened for reading only", h.key)
+}
+
+func (h *s3ReadHandle) Stat() (fs.FileInfo, error) {
+	if h.closed {
+		return nil, os.ErrClosed
+	}
+	return memFileInfo{name: pathpkg.Base(h.key), size: h.size, modTime: h.modTime}, nil
+}
+
+func (h *s3ReadHandle) Close() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		return os.ErrClosed
+	}
+	h.closed = true
+
+	if h.body != nil {
+		return h.body.Close()
+	}
+	return nil
+}
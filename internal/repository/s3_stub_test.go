@@ -0,0 +1,177 @@
+package repository
+
+import (
+	"encoding/xml"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// s3Stub is an in-memory stand-in for an S3-compatible server. It supports
+// the subset of the API used by s3Client and checks request signatures.
+type s3Stub struct {
+	mu      sync.Mutex
+	client  *s3Client
+	bucket  string
+	objects map[string][]byte
+}
+
+func newS3Stub(t *testing.T, cfg S3Config) S3Config {
+	stub := &s3Stub{bucket: cfg.Bucket, objects: map[string][]byte{}}
+
+	server := httptest.NewServer(stub)
+	t.Cleanup(server.Close)
+
+	cfg.Endpoint = server.URL
+	client, err := newS3Client(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stub.client = client
+
+	return cfg
+}
+
+func (s *s3Stub) fail(w http.ResponseWriter, status int, code string) {
+	w.WriteHeader(status)
+	_ = xml.NewEncoder(w).Encode(struct {
+		XMLName xml.Name `xml:"Error"`
+		Code    string   `xml:"Code"`
+	}{Code: code})
+}
+
+func (s *s3Stub) authorized(r *http.Request) bool {
+	auth := r.Header.Get("Authorization")
+	_, sig, ok := strings.Cut(auth, "Signature=")
+	if !ok {
+		return false
+	}
+
+	header := r.Header.Clone()
+	header.Del("Authorization")
+	header.Set("Host", r.Host)
+
+	_, expected := s.client.signature(r.Method, r.URL.EscapedPath(), r.URL.RawQuery, header)
+	return sig == expected
+}
+
+func (s *s3Stub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !s.authorized(r) {
+		s.fail(w, http.StatusForbidden, "SignatureDoesNotMatch")
+		return
+	}
+
+	bucket, key, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	if bucket != s.bucket {
+		s.fail(w, http.StatusNotFound, "NoSuchBucket")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if key == "" && r.Method == http.MethodGet {
+		s.list(w, r.URL.Query())
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPut:
+		if src := r.Header.Get("X-Amz-Copy-Source"); src != "" {
+			src, _ = url.PathUnescape(src)
+			data, ok := s.objects[strings.TrimPrefix(src, "/"+s.bucket+"/")]
+			if !ok {
+				s.fail(w, http.StatusNotFound, "NoSuchKey")
+				return
+			}
+			s.objects[key] = append([]byte(nil), data...)
+			_, _ = io.WriteString(w, "<CopyObjectResult></CopyObjectResult>")
+			return
+		}
+
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			s.fail(w, http.StatusBadRequest, "IncompleteBody")
+			return
+		}
+		s.objects[key] = data
+
+	case http.MethodGet, http.MethodHead:
+		data, ok := s.objects[key]
+		if !ok {
+			s.fail(w, http.StatusNotFound, "NoSuchKey")
+			return
+		}
+
+		w.Header().Set("Last-Modified", time.Now().UTC().Format(http.TimeFormat))
+		status := http.StatusOK
+		if spec := r.Header.Get("Range"); spec != "" {
+			offset, _ := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(spec, "bytes="), "-"))
+			if offset >= len(data) {
+				s.fail(w, http.StatusRequestedRangeNotSatisfiable, "InvalidRange")
+				return
+			}
+			data = data[offset:]
+			status = http.StatusPartialContent
+		}
+
+		w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+		w.WriteHeader(status)
+		if r.Method == http.MethodGet {
+			_, _ = w.Write(data)
+		}
+
+	case http.MethodDelete:
+		delete(s.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		s.fail(w, http.StatusMethodNotAllowed, "MethodNotAllowed")
+	}
+}
+
+func (s *s3Stub) list(w http.ResponseWriter, query url.Values) {
+	prefix := query.Get("prefix")
+	delimiter := query.Get("delimiter")
+
+	result := s3ListResult{}
+	seen := map[string]bool{}
+
+	keys := make([]string, 0, len(s.objects))
+	for key := range s.objects {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+
+		rest := strings.TrimPrefix(key, prefix)
+		if delimiter != "" {
+			if i := strings.Index(rest, delimiter); i >= 0 {
+				common := prefix + rest[:i+len(delimiter)]
+				if !seen[common] {
+					seen[common] = true
+					result.CommonPrefixes = append(result.CommonPrefixes, common)
+				}
+				continue
+			}
+		}
+
+		result.Contents = append(result.Contents, s3Object{Key: key, Size: int64(len(s.objects[key]))})
+	}
+
+	_ = xml.NewEncoder(w).Encode(struct {
+		XMLName xml.Name `xml:"ListBucketResult"`
+		s3ListResult
+	}{s3ListResult: result})
+}
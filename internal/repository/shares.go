@@ -0,0 +1,229 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/JunBSer/FileManager/pkg/logger"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+var sharesDir = filepath.Join(SystemDir, "shares")
+
+const (
+	ShareDownload = "download"
+	ShareUpload   = "upload"
+)
+
+var (
+	ErrInvalidShare  = errors.New("invalid share")
+	ErrShareNotFound = errors.New("share not found")
+	ErrShareExpired  = errors.New("share has expired")
+	ErrShareUsedUp   = errors.New("share has no uses or bytes left")
+)
+
+type ShareConfig struct {
+	// Secret is the HMAC key share tokens are signed with. Sharing is
+	// disabled without it.
+	Secret string `env:"SHARE_SECRET"`
+	// MaxTTL is the longest lifetime a share may be created with.
+	MaxTTL time.Duration `env:"SHARE_MAX_TTL" envDefault:"168h"`
+}
+
+// Share allows whoever holds its token to run Operation on Path until
+// ExpiresAt. MaxUses and MaxBytes are unlimited when zero.
+type Share struct {
+	ID        string    `json:"id"`
+	Path      string    `json:"path"`
+	Operation string    `json:"operation"`
+	ExpiresAt time.Time `json:"expires_at"`
+	MaxUses   int64     `json:"max_uses,omitempty"`
+	MaxBytes  int64     `json:"max_bytes,omitempty"`
+	Uses      int64     `json:"uses"`
+	Bytes     int64     `json:"bytes"`
+	CreatedBy string    `json:"created_by,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// RemainingBytes returns how many bytes may still be transferred, or -1 if
+// there is no limit.
+func (s Share) RemainingBytes() int64 {
+	if s.MaxBytes == 0 {
+		return -1
+	}
+	return max(s.MaxBytes-s.Bytes, 0)
+}
+
+// ShareStore keeps one JSON file per share under SystemDir. Expired shares
+// are removed when the shares are listed.
+type ShareStore struct {
+	repo FileRepository
+	mu   sync.Mutex
+}
+
+func NewShareStore(repo FileRepository) *ShareStore {
+	return &ShareStore{repo: repo}
+}
+
+func sharePath(id string) string {
+	return filepath.Join(sharesDir, id+".json")
+}
+
+// Create stores share under a new ID.
+func (s *ShareStore) Create(ctx context.Context, share Share) (Share, error) {
+	switch share.Operation {
+	case ShareDownload, ShareUpload:
+	default:
+		return Share{}, fmt.Errorf("%w: unknown operation %q, want %s or %s", ErrInvalidShare, share.Operation, ShareDownload, ShareUpload)
+	}
+	if share.MaxUses < 0 || share.MaxBytes < 0 {
+		return Share{}, fmt.Errorf("%w: limits must not be negative", ErrInvalidShare)
+	}
+
+	share.ID = uuid.NewString()
+	share.Uses, share.Bytes = 0, 0
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := writeJSON(ctx, s.repo, sharePath(share.ID), share); err != nil {
+		logger.GetLoggerFromContext(ctx).Error(ctx, "Error storing share", zap.String("path", share.Path), zap.Error(err))
+		return Share{}, err
+	}
+	return share, nil
+}
+
+func (s *ShareStore) read(ctx context.Context, id string) (Share, error) {
+	if id == "" || strings.ContainsAny(id, `/\.`) {
+		return Share{}, fmt.Errorf("%w: %s", ErrShareNotFound, id)
+	}
+
+	file, err := s.repo.GetFileHandle(ctx, sharePath(id), Read)
+	if os.IsNotExist(err) {
+		return Share{}, fmt.Errorf("%w: %s", ErrShareNotFound, id)
+	}
+	if err != nil {
+		return Share{}, err
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return Share{}, err
+	}
+
+	var share Share
+	if err = json.Unmarshal(data, &share); err != nil {
+		return Share{}, fmt.Errorf("corrupted share %s: %w", id, err)
+	}
+	return share, nil
+}
+
+func (s *ShareStore) Get(ctx context.Context, id string) (Share, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.read(ctx, id)
+}
+
+// List returns the shares that have not expired, the ones expiring first
+// first.
+func (s *ShareStore) List(ctx context.Context) ([]Share, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entries, err := s.repo.ListDir(ctx, sharesDir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	lg := logger.GetLoggerFromContext(ctx)
+	now := time.Now()
+
+	var shares []Share
+	for _, entry := range entries {
+		id, ok := strings.CutSuffix(entry.Name, ".json")
+		if entry.IsDir || !ok {
+			continue
+		}
+
+		share, err := s.read(ctx, id)
+		if err != nil {
+			lg.Error(ctx, "Error reading share", zap.String("id", id), zap.Error(err))
+			continue
+		}
+		if !now.Before(share.ExpiresAt) {
+			if err = s.repo.DeleteFile(ctx, sharePath(id)); err != nil && !os.IsNotExist(err) {
+				lg.Error(ctx, "Error removing expired share", zap.String("id", id), zap.Error(err))
+			}
+			continue
+		}
+		shares = append(shares, share)
+	}
+
+	sort.Slice(shares, func(i, j int) bool { return shares[i].ExpiresAt.Before(shares[j].ExpiresAt) })
+	return shares, nil
+}
+
+// Use counts one use of the share id. It fails if the share has expired or
+// has no uses or bytes left.
+func (s *ShareStore) Use(ctx context.Context, id string) (Share, error) {
+	return s.update(ctx, id, func(share *Share) error {
+		if !time.Now().Before(share.ExpiresAt) {
+			return fmt.Errorf("%w: %s", ErrShareExpired, id)
+		}
+		if (share.MaxUses > 0 && share.Uses >= share.MaxUses) || share.RemainingBytes() == 0 {
+			return fmt.Errorf("%w: %s", ErrShareUsedUp, id)
+		}
+		share.Uses++
+		return nil
+	})
+}
+
+// AddBytes counts n transferred bytes for the share id.
+func (s *ShareStore) AddBytes(ctx context.Context, id string, n int64) (Share, error) {
+	return s.update(ctx, id, func(share *Share) error {
+		share.Bytes += n
+		return nil
+	})
+}
+
+func (s *ShareStore) update(ctx context.Context, id string, change func(*Share) error) (Share, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	share, err := s.read(ctx, id)
+	if err != nil {
+		return Share{}, err
+	}
+	if err = change(&share); err != nil {
+		return Share{}, err
+	}
+	if err = writeJSON(ctx, s.repo, sharePath(id), share); err != nil {
+		return Share{}, err
+	}
+	return share, nil
+}
+
+// Remove revokes the share id.
+func (s *ShareStore) Remove(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.read(ctx, id); err != nil {
+		return err
+	}
+	return s.repo.DeleteFile(ctx, sharePath(id))
+}
@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"context"
+	"github.com/JunBSer/FileManager/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestShareStore(t *testing.T) {
+	ctx := context.WithValue(context.Background(), logger.Key, logger.New("test", "debug"))
+	store := NewShareStore(NewMemory(2048))
+
+	share, err := store.Create(ctx, Share{Path: "reports/q3.pdf", Operation: ShareDownload, ExpiresAt: time.Now().Add(time.Hour), MaxUses: 2, MaxBytes: 10})
+	require.NoError(t, err)
+	assert.NotEmpty(t, share.ID)
+
+	t.Run("Invalid shares", func(t *testing.T) {
+		_, err := store.Create(ctx, Share{Path: "a.txt", Operation: "delete", ExpiresAt: time.Now().Add(time.Hour)})
+		assert.ErrorIs(t, err, ErrInvalidShare)
+		_, err = store.Create(ctx, Share{Path: "a.txt", Operation: ShareUpload, ExpiresAt: time.Now().Add(time.Hour), MaxUses: -1})
+		assert.ErrorIs(t, err, ErrInvalidShare)
+	})
+
+	t.Run("Uses and bytes are limited", func(t *testing.T) {
+		used, err := store.Use(ctx, share.ID)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), used.Uses)
+		assert.Equal(t, int64(10), used.RemainingBytes())
+
+		used, err = store.AddBytes(ctx, share.ID, 4)
+		require.NoError(t, err)
+		assert.Equal(t, int64(6), used.RemainingBytes())
+
+		_, err = store.Use(ctx, share.ID)
+		require.NoError(t, err)
+		_, err = store.Use(ctx, share.ID)
+		assert.ErrorIs(t, err, ErrShareUsedUp)
+
+		stored, err := store.Get(ctx, share.ID)
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), stored.Uses)
+		assert.Equal(t, int64(4), stored.Bytes)
+	})
+
+	t.Run("Expired shares", func(t *testing.T) {
+		expired, err := store.Create(ctx, Share{Path: "old.txt", Operation: ShareDownload, ExpiresAt: time.Now().Add(-time.Second)})
+		require.NoError(t, err)
+
+		_, err = store.Use(ctx, expired.ID)
+		assert.ErrorIs(t, err, ErrShareExpired)
+
+		shares, err := store.List(ctx)
+		require.NoError(t, err)
+		require.Len(t, shares, 1)
+		assert.Equal(t, share.ID, shares[0].ID)
+
+		_, err = store.Get(ctx, expired.ID)
+		assert.ErrorIs(t, err, ErrShareNotFound)
+	})
+
+	t.Run("Remove", func(t *testing.T) {
+		require.NoError(t, store.Remove(ctx, share.ID))
+		assert.ErrorIs(t, store.Remove(ctx, share.ID), ErrShareNotFound)
+		_, err := store.Use(ctx, share.ID)
+		assert.ErrorIs(t, err, ErrShareNotFound)
+		_, err = store.Get(ctx, "../acl")
+		assert.ErrorIs(t, err, ErrShareNotFound)
+	})
+}
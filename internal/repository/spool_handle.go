@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"context"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+)
+
+// spoolFlush stores the spooled content of size bytes under key.
+type spoolFlush func(ctx context.Context, key string, r io.Reader, size int64) error
+
+// spoolHandle is a local temp file standing in for a file of a backend that
+// cannot be written in place. The content is flushed to key when the handle
+// is closed, or by CommitTempFile for temp files.
+type spoolHandle struct {
+	*os.File
+	ctx   context.Context
+	key   string
+	temp  bool
+	dirty bool
+	flush spoolFlush
+}
+
+func newSpool(ctx context.Context, key string, temp bool, flush spoolFlush) (*spoolHandle, error) {
+	file, err := os.CreateTemp("", "fm-spool-*")
+	if err != nil {
+		return nil, err
+	}
+
+	return &spoolHandle{File: file, ctx: ctx, key: key, temp: temp, flush: flush}, nil
+}
+
+// fill copies the current content of the file into the spool and rewinds it.
+func (h *spoolHandle) fill(r io.Reader) error {
+	if _, err := io.Copy(h.File, r); err != nil {
+		return err
+	}
+	_, err := h.File.Seek(0, io.SeekStart)
+	return err
+}
+
+func (h *spoolHandle) Write(b []byte) (int, error) {
+	h.dirty = true
+	return h.File.Write(b)
+}
+
+func (h *spoolHandle) Stat() (fs.FileInfo, error) {
+	info, err := h.File.Stat()
+	if err != nil {
+		return nil, err
+	}
+	return memFileInfo{name: path.Base(h.key), size: info.Size(), modTime: info.ModTime()}, nil
+}
+
+func (h *spoolHandle) upload(ctx context.Context) error {
+	info, err := h.File.Stat()
+	if err != nil {
+		return err
+	}
+	if _, err = h.File.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	return h.flush(ctx, h.key, h.File, info.Size())
+}
+
+// Close flushes the spooled data if it was changed. Temp files are flushed
+// only by CommitTempFile.
+func (h *spoolHandle) Close() error {
+	if h.temp {
+		return h.File.Close()
+	}
+
+	var err error
+	if h.dirty {
+		err = h.upload(h.ctx)
+	}
+
+	if closeErr := h.discard(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+func (h *spoolHandle) discard() error {
+	err := h.File.Close()
+	_ = os.Remove(h.File.Name())
+	return err
+}
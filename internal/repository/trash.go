@@ -0,0 +1,341 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/JunBSer/FileManager/pkg/logger"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+var trashDir = filepath.Join(SystemDir, "trash")
+
+var (
+	ErrTrashNotFound   = errors.New("trash entry not found")
+	ErrRestoreConflict = errors.New("restore target already exists")
+)
+
+const (
+	trashDataName  = "data"
+	trashEntryName = "entry.json"
+)
+
+type TrashConfig struct {
+	Enabled       bool          `env:"FILE_TRASH" envDefault:"true"`
+	MaxAge        time.Duration `env:"FILE_TRASH_MAX_AGE" envDefault:"720h"`
+	PurgeInterval time.Duration `env:"FILE_TRASH_PURGE_INTERVAL" envDefault:"1h"`
+}
+
+// TrashEntry is a deleted file waiting to be restored or purged.
+type TrashEntry struct {
+	ID        string    `json:"id"`
+	Path      string    `json:"path"`
+	Size      int64     `json:"size"`
+	DeletedAt time.Time `json:"deleted_at"`
+}
+
+// Trash keeps deleted files under SystemDir. Every namespace, the top level
+// directory of a path, has its own area; an entry is a directory holding the
+// file content and a JSON description with its original path.
+type Trash struct {
+	repo FileRepository
+}
+
+func NewTrash(repo FileRepository) *Trash {
+	return &Trash{repo: repo}
+}
+
+// Namespace returns the top level directory of path, or "" for files stored
+// in the root.
+func Namespace(path string) string {
+	clean := strings.TrimPrefix(filepath.Clean(string(filepath.Separator)+path), string(filepath.Separator))
+	ns, _, ok := strings.Cut(clean, string(filepath.Separator))
+	if !ok {
+		return ""
+	}
+	return ns
+}
+
+func namespaceTrash(ns string) string {
+	if ns == "" {
+		return filepath.Join(trashDir, "top")
+	}
+	return filepath.Join(trashDir, "ns", ns)
+}
+
+// namespaces returns the trash areas that hold entries.
+func (t *Trash) namespaces(ctx context.Context) ([]string, error) {
+	dirs := []string{namespaceTrash("")}
+
+	entries, err := t.repo.ListDir(ctx, filepath.Join(trashDir, "ns"))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	for _, entry := range entries {
+		if entry.IsDir {
+			dirs = append(dirs, namespaceTrash(entry.Name))
+		}
+	}
+	return dirs, nil
+}
+
+// Move moves path into the trash of its namespace. Directories are removed
+// without keeping an entry.
+func (t *Trash) Move(ctx context.Context, path string) (*TrashEntry, error) {
+	lg := logger.GetLoggerFromContext(ctx)
+
+	info, err := t.repo.Stat(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return nil, t.repo.DeleteFile(ctx, path)
+	}
+
+	entry := &TrashEntry{
+		ID:        uuid.NewString(),
+		Path:      cleanDir(path),
+		Size:      info.Size(),
+		DeletedAt: time.Now().UTC(),
+	}
+	dir := filepath.Join(namespaceTrash(Namespace(path)), entry.ID)
+
+	if err = t.repo.MoveFile(ctx, path, filepath.Join(dir, trashDataName)); err != nil {
+		lg.Error(ctx, "Error moving file to trash", zap.String("path", path), zap.Error(err))
+		return nil, err
+	}
+
+	if err = t.writeEntry(ctx, dir, entry); err != nil {
+		lg.Error(ctx, "Error writing trash entry", zap.String("path", path), zap.Error(err))
+		if moveErr := t.repo.MoveFile(ctx, filepath.Join(dir, trashDataName), path); moveErr != nil {
+			lg.Error(ctx, "Error moving file back from trash", zap.String("path", path), zap.Error(moveErr))
+		}
+		return nil, err
+	}
+
+	lg.Info(ctx, "File moved to trash", zap.String("path", path), zap.String("entryID", entry.ID))
+	return entry, nil
+}
+
+func (t *Trash) writeEntry(ctx context.Context, dir string, entry *TrashEntry) error {
+	return writeJSON(ctx, t.repo, filepath.Join(dir, trashEntryName), entry)
+}
+
+func (t *Trash) readEntry(ctx context.Context, dir string) (*TrashEntry, error) {
+	file, err := t.repo.GetFileHandle(ctx, filepath.Join(dir, trashEntryName), Read)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return nil, err
+	}
+
+	entry := &TrashEntry{}
+	if err = json.Unmarshal(data, entry); err != nil {
+		return nil, fmt.Errorf("corrupted trash entry %s: %w", dir, err)
+	}
+	return entry, nil
+}
+
+type trashItem struct {
+	TrashEntry
+	dir string
+}
+
+// areas returns the trash areas that can hold entries deleted from under.
+func (t *Trash) areas(ctx context.Context, under string) ([]string, error) {
+	if under == string(filepath.Separator) {
+		return t.namespaces(ctx)
+	}
+	if ns := Namespace(under); ns != "" {
+		return []string{namespaceTrash(ns)}, nil
+	}
+
+	// A top level path is a file of the root or a namespace of its own.
+	return []string{namespaceTrash(""), namespaceTrash(strings.TrimPrefix(under, string(filepath.Separator)))}, nil
+}
+
+func (t *Trash) list(ctx context.Context, path string) ([]trashItem, error) {
+	under := cleanDir(path)
+
+	areas, err := t.areas(ctx, under)
+	if err != nil {
+		return nil, err
+	}
+
+	prefix := strings.TrimSuffix(under, string(filepath.Separator)) + string(filepath.Separator)
+
+	var items []trashItem
+	for _, area := range areas {
+		children, err := t.repo.ListDir(ctx, area)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, err
+		}
+
+		for _, child := range children {
+			if !child.IsDir {
+				continue
+			}
+
+			dir := filepath.Join(area, child.Name)
+			entry, err := t.readEntry(ctx, dir)
+			if err != nil {
+				if os.IsNotExist(err) {
+					continue
+				}
+				return nil, err
+			}
+			if entry.Path == under || strings.HasPrefix(entry.Path, prefix) {
+				items = append(items, trashItem{TrashEntry: *entry, dir: dir})
+			}
+		}
+	}
+
+	sort.Slice(items, func(i, j int) bool { return items[i].DeletedAt.After(items[j].DeletedAt) })
+	return items, nil
+}
+
+// List returns the entries deleted from under path, newest first. The root
+// path lists the entries of every namespace.
+func (t *Trash) List(ctx context.Context, path string) ([]TrashEntry, error) {
+	items, err := t.list(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]TrashEntry, 0, len(items))
+	for _, item := range items {
+		entries = append(entries, item.TrashEntry)
+	}
+	return entries, nil
+}
+
+// find returns entry id and the directory that holds it.
+func (t *Trash) find(ctx context.Context, id string) (*TrashEntry, string, error) {
+	if uuid.Validate(id) != nil {
+		return nil, "", ErrTrashNotFound
+	}
+
+	dirs, err := t.namespaces(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+
+	for _, dir := range dirs {
+		entry, err := t.readEntry(ctx, filepath.Join(dir, id))
+		if err == nil {
+			return entry, filepath.Join(dir, id), nil
+		}
+		if !os.IsNotExist(err) {
+			return nil, "", err
+		}
+	}
+	return nil, "", ErrTrashNotFound
+}
+
+// Get returns entry id.
+func (t *Trash) Get(ctx context.Context, id string) (*TrashEntry, error) {
+	entry, _, err := t.find(ctx, id)
+	return entry, err
+}
+
+// Restore moves entry id back to its original path, or to destination if it
+// is not empty. An existing file at the target is never replaced.
+func (t *Trash) Restore(ctx context.Context, id, destination string) (*TrashEntry, error) {
+	entry, dir, err := t.find(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	target := entry.Path
+	if destination != "" {
+		target = destination
+	}
+
+	err = moveExclusive(ctx, t.repo, filepath.Join(dir, trashDataName), target)
+	if os.IsExist(err) {
+		return nil, fmt.Errorf("%w: %s", ErrRestoreConflict, target)
+	}
+	if err != nil {
+		logger.GetLoggerFromContext(ctx).Error(ctx, "Error restoring from trash", zap.String("entryID", id), zap.Error(err))
+		return nil, err
+	}
+	if err = t.remove(ctx, dir); err != nil {
+		logger.GetLoggerFromContext(ctx).Error(ctx, "Error removing trash entry", zap.String("entryID", id), zap.Error(err))
+	}
+
+	logger.GetLoggerFromContext(ctx).Info(ctx, "File restored from trash", zap.String("path", target), zap.String("entryID", id))
+	entry.Path = cleanDir(target)
+	return entry, nil
+}
+
+// remove deletes an entry directory. Backends without real directories drop
+// it together with its last file.
+func (t *Trash) remove(ctx context.Context, dir string) error {
+	for _, name := range []string{trashDataName, trashEntryName, ""} {
+		if err := t.repo.DeleteFile(ctx, filepath.Join(dir, name)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// Empty deletes the entries deleted from under path more than olderThan ago
+// and returns how many were deleted. A zero olderThan deletes all of them.
+func (t *Trash) Empty(ctx context.Context, path string, olderThan time.Duration) (int, error) {
+	items, err := t.list(ctx, path)
+	if err != nil {
+		return 0, err
+	}
+
+	removed := 0
+	for _, item := range items {
+		if olderThan > 0 && time.Since(item.DeletedAt) <= olderThan {
+			continue
+		}
+		if err = t.remove(ctx, item.dir); err != nil {
+			return removed, err
+		}
+		removed++
+	}
+
+	if removed > 0 {
+		logger.GetLoggerFromContext(ctx).Info(ctx, "Trash emptied", zap.String("path", path), zap.Int("removed", removed))
+	}
+	return removed, nil
+}
+
+// RunPurge deletes entries older than maxAge every interval until ctx is done.
+func (t *Trash) RunPurge(ctx context.Context, interval, maxAge time.Duration) {
+	lg := logger.GetLoggerFromContext(ctx)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			removed, err := t.Empty(ctx, "", maxAge)
+			if err != nil {
+				lg.Error(ctx, "Error purging trash", zap.Error(err))
+				continue
+			}
+			lg.Info(ctx, "Trash purged", zap.Int("removed", removed))
+		}
+	}
+}
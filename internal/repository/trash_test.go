@@ -0,0 +1,130 @@
+package repository
+
+import (
+	"context"
+	"github.com/JunBSer/FileManager/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestTrash(t *testing.T) {
+	t.Run("Local", func(t *testing.T) {
+		fullPath := CreateTempDir(t)
+		t.Cleanup(func() { os.RemoveAll(fullPath) })
+
+		runTrash(t, New(relPath, 1024*1024, 2048))
+	})
+	t.Run("Memory", func(t *testing.T) {
+		runTrash(t, NewMemory(2048))
+	})
+}
+
+func runTrash(t *testing.T, repo FileRepository) {
+	ctx := context.Background()
+	lg := logger.New("test", "debug")
+	ctx = context.WithValue(ctx, logger.Key, lg)
+
+	trash := NewTrash(repo)
+
+	writeFile := func(t *testing.T, path, data string) {
+		f, err := repo.CreateTempFile(ctx, path)
+		require.NoError(t, err)
+		_, err = repo.AppendData(ctx, f, []byte(data), 0)
+		require.NoError(t, err)
+		require.NoError(t, repo.CommitTempFile(ctx, f, path))
+	}
+
+	readFile := func(t *testing.T, path string) string {
+		f, err := repo.GetFileHandle(ctx, path, Read)
+		require.NoError(t, err)
+		defer f.Close()
+
+		data, err := io.ReadAll(f)
+		require.NoError(t, err)
+		return string(data)
+	}
+
+	t.Run("Namespace", func(t *testing.T) {
+		assert.Equal(t, "docs", Namespace("docs/a/b.txt"))
+		assert.Equal(t, "docs", Namespace("/docs/b.txt"))
+		assert.Equal(t, "", Namespace("b.txt"))
+	})
+
+	t.Run("Move keeps path and deletion time", func(t *testing.T) {
+		writeFile(t, "docs/report.txt", "report")
+		writeFile(t, "notes.txt", "notes")
+
+		entry, err := trash.Move(ctx, "docs/report.txt")
+		require.NoError(t, err)
+		assert.Equal(t, "/docs/report.txt", entry.Path)
+		assert.Equal(t, int64(6), entry.Size)
+		assert.WithinDuration(t, time.Now(), entry.DeletedAt, time.Minute)
+
+		_, err = repo.GetFileHandle(ctx, "docs/report.txt", Read)
+		assert.True(t, os.IsNotExist(err))
+
+		_, err = trash.Move(ctx, "notes.txt")
+		require.NoError(t, err)
+
+		_, err = trash.Move(ctx, "missing.txt")
+		assert.True(t, os.IsNotExist(err))
+	})
+
+	t.Run("List filters by path", func(t *testing.T) {
+		all, err := trash.List(ctx, "")
+		require.NoError(t, err)
+		require.Len(t, all, 2)
+		assert.Equal(t, "/notes.txt", all[0].Path)
+
+		docs, err := trash.List(ctx, "docs")
+		require.NoError(t, err)
+		require.Len(t, docs, 1)
+		assert.Equal(t, "/docs/report.txt", docs[0].Path)
+
+		root, err := trash.List(ctx, "notes.txt")
+		require.NoError(t, err)
+		assert.Len(t, root, 1)
+
+		other, err := trash.List(ctx, "docs/other")
+		require.NoError(t, err)
+		assert.Empty(t, other)
+	})
+
+	t.Run("Restore never replaces a file", func(t *testing.T) {
+		docs, err := trash.List(ctx, "docs")
+		require.NoError(t, err)
+		require.Len(t, docs, 1)
+
+		writeFile(t, "docs/report.txt", "newer")
+		_, err = trash.Restore(ctx, docs[0].ID, "")
+		assert.ErrorIs(t, err, ErrRestoreConflict)
+
+		entry, err := trash.Restore(ctx, docs[0].ID, "docs/report.old.txt")
+		require.NoError(t, err)
+		assert.Equal(t, "/docs/report.old.txt", entry.Path)
+		assert.Equal(t, "report", readFile(t, "docs/report.old.txt"))
+
+		_, err = trash.Restore(ctx, docs[0].ID, "")
+		assert.ErrorIs(t, err, ErrTrashNotFound)
+		_, err = trash.Restore(ctx, "../../etc", "")
+		assert.ErrorIs(t, err, ErrTrashNotFound)
+	})
+
+	t.Run("Empty honours the age", func(t *testing.T) {
+		removed, err := trash.Empty(ctx, "", time.Hour)
+		require.NoError(t, err)
+		assert.Equal(t, 0, removed)
+
+		removed, err = trash.Empty(ctx, "", 0)
+		require.NoError(t, err)
+		assert.Equal(t, 1, removed)
+
+		all, err := trash.List(ctx, "")
+		require.NoError(t, err)
+		assert.Empty(t, all)
+	})
+}
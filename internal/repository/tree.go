@@ -0,0 +1,336 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/JunBSer/FileManager/pkg/logger"
+	"go.uber.org/zap"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var (
+	ErrPathExists   = errors.New("path already exists")
+	ErrNotDirectory = errors.New("not a directory")
+	ErrIsDirectory  = errors.New("is a directory")
+	ErrDirNotEmpty  = errors.New("directory not empty")
+	ErrTreeLoop     = errors.New("destination is inside the source")
+)
+
+// TreeResult is the outcome of one entry of a tree operation. Target is empty
+// for deletes.
+type TreeResult struct {
+	Path   string
+	Target string
+	IsDir  bool
+	Err    error
+}
+
+// TreeSummary counts the entries a tree operation went through.
+type TreeSummary struct {
+	Done   int
+	Failed int
+}
+
+// TreeOptions tunes a tree operation. Zero values give plain behaviour.
+type TreeOptions struct {
+	// Recursive allows deleting and copying directories with their content.
+	Recursive bool
+	// Overwrite allows replacing existing files at the destination.
+	Overwrite bool
+	// RemoveFile deletes one file. Defaults to FileRepository.DeleteFile.
+	RemoveFile func(ctx context.Context, path string) error
+	// BeforeReplace is called before an existing file is replaced.
+	BeforeReplace func(ctx context.Context, path string) error
+	// Progress receives every entry once it is processed. An error stops the
+	// operation.
+	Progress func(TreeResult) error
+}
+
+// Tree runs directory operations on top of the primitives of a
+// FileRepository. Operations go on after a failed entry and report every
+// entry through TreeOptions.Progress.
+type Tree struct {
+	repo FileRepository
+}
+
+func NewTree(repo FileRepository) *Tree {
+	return &Tree{repo: repo}
+}
+
+type treeEntry struct {
+	path  string
+	isDir bool
+}
+
+// stat reports whether path exists and whether it is a directory.
+func (t *Tree) stat(ctx context.Context, path string) (exists, isDir bool, err error) {
+	info, err := t.repo.Stat(ctx, path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, false, nil
+		}
+		return false, false, err
+	}
+	return true, info.IsDir(), nil
+}
+
+// collect returns the entries of the tree at root, every directory before
+// its content. SystemDir and temp files are skipped.
+func (t *Tree) collect(ctx context.Context, root string) ([]treeEntry, error) {
+	exists, isDir, err := t.stat(ctx, root)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, notExist("stat", root)
+	}
+
+	entries := []treeEntry{{path: root, isDir: isDir}}
+	for i := 0; i < len(entries); i++ {
+		if !entries[i].isDir {
+			continue
+		}
+
+		children, err := t.repo.ListDir(ctx, entries[i].path)
+		if err != nil {
+			return nil, err
+		}
+		for _, child := range children {
+			path := filepath.Join(entries[i].path, child.Name)
+			if IsSystemPath(path) || IsTempName(child.Name) {
+				continue
+			}
+			entries = append(entries, treeEntry{path: path, isDir: child.IsDir})
+		}
+	}
+
+	return entries, nil
+}
+
+func (opts TreeOptions) report(summary *TreeSummary, result TreeResult) error {
+	if result.Err != nil {
+		summary.Failed++
+	} else {
+		summary.Done++
+	}
+
+	if opts.Progress == nil {
+		return nil
+	}
+	return opts.Progress(result)
+}
+
+// target maps path below src to the same place below dst.
+func target(src, dst, path string) string {
+	rel, _ := filepath.Rel(src, path)
+	return filepath.Join(dst, rel)
+}
+
+func checkLoop(src, dst string) error {
+	src, dst = cleanDir(src), cleanDir(dst)
+	if dst == src || strings.HasPrefix(dst, strings.TrimSuffix(src, string(filepath.Separator))+string(filepath.Separator)) {
+		return fmt.Errorf("%w: %s", ErrTreeLoop, dst)
+	}
+	return nil
+}
+
+// CreateDirectory creates path and any missing parents.
+func (t *Tree) CreateDirectory(ctx context.Context, path string) error {
+	exists, isDir, err := t.stat(ctx, path)
+	if err != nil {
+		return err
+	}
+	if exists && !isDir {
+		return fmt.Errorf("%w: %s", ErrPathExists, path)
+	}
+
+	if err = t.repo.CreateDir(ctx, path); err != nil {
+		logger.GetLoggerFromContext(ctx).Error(ctx, "Error creating directory", zap.String("path", path), zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// DeleteDirectory deletes the directory path. Without opts.Recursive only an
+// empty directory is deleted. Files are deleted before the directories that
+// hold them; a directory with a failed entry is kept.
+func (t *Tree) DeleteDirectory(ctx context.Context, path string, opts TreeOptions) (TreeSummary, error) {
+	var summary TreeSummary
+
+	entries, err := t.collect(ctx, path)
+	if err != nil {
+		return summary, err
+	}
+	if !entries[0].isDir {
+		return summary, fmt.Errorf("%w: %s", ErrNotDirectory, path)
+	}
+	if !opts.Recursive && len(entries) > 1 {
+		return summary, fmt.Errorf("%w: %s", ErrDirNotEmpty, path)
+	}
+
+	removeFile := opts.RemoveFile
+	if removeFile == nil {
+		removeFile = t.repo.DeleteFile
+	}
+
+	for _, entry := range entries {
+		if entry.isDir {
+			continue
+		}
+		result := TreeResult{Path: entry.path, Err: removeFile(ctx, entry.path)}
+		if err = opts.report(&summary, result); err != nil {
+			return summary, err
+		}
+	}
+
+	for i := len(entries) - 1; i >= 0; i-- {
+		if !entries[i].isDir {
+			continue
+		}
+
+		err := t.repo.DeleteFile(ctx, entries[i].path)
+		if os.IsNotExist(err) {
+			// Implicit directories vanish together with their last file.
+			err = nil
+		}
+		result := TreeResult{Path: entries[i].path, IsDir: true, Err: err}
+		if err = opts.report(&summary, result); err != nil {
+			return summary, err
+		}
+	}
+
+	logger.GetLoggerFromContext(ctx).Info(ctx, "Directory deleted",
+		zap.String("path", path), zap.Int("done", summary.Done), zap.Int("failed", summary.Failed))
+	return summary, nil
+}
+
+// place checks that a file may be written to dst and prepares replacing an
+// existing one.
+func (t *Tree) place(ctx context.Context, dst string, opts TreeOptions) error {
+	exists, isDir, err := t.stat(ctx, dst)
+	if err != nil || !exists {
+		return err
+	}
+	if isDir {
+		return fmt.Errorf("%w: %s", ErrIsDirectory, dst)
+	}
+	if !opts.Overwrite {
+		return fmt.Errorf("%w: %s", ErrPathExists, dst)
+	}
+	if opts.BeforeReplace != nil {
+		return opts.BeforeReplace(ctx, dst)
+	}
+	return nil
+}
+
+// Copy copies the file or, with opts.Recursive, the directory src to dst.
+// A directory is merged into an existing directory at dst.
+func (t *Tree) Copy(ctx context.Context, src, dst string, opts TreeOptions) (TreeSummary, error) {
+	var summary TreeSummary
+
+	if err := checkLoop(src, dst); err != nil {
+		return summary, err
+	}
+
+	entries, err := t.collect(ctx, src)
+	if err != nil {
+		return summary, err
+	}
+	if entries[0].isDir && !opts.Recursive {
+		return summary, fmt.Errorf("%w: %s", ErrIsDirectory, src)
+	}
+
+	for _, entry := range entries {
+		result := TreeResult{Path: entry.path, Target: target(src, dst, entry.path), IsDir: entry.isDir}
+		if entry.isDir {
+			result.Err = t.CreateDirectory(ctx, result.Target)
+		} else if result.Err = t.place(ctx, result.Target, opts); result.Err == nil {
+			result.Err = copyFile(ctx, t.repo, entry.path, result.Target)
+		}
+
+		if err = opts.report(&summary, result); err != nil {
+			return summary, err
+		}
+	}
+
+	logger.GetLoggerFromContext(ctx).Info(ctx, "Path copied",
+		zap.String("src", src), zap.String("dst", dst), zap.Int("done", summary.Done), zap.Int("failed", summary.Failed))
+	return summary, nil
+}
+
+// Move moves the file or directory src to dst. A directory is renamed at once
+// if nothing exists at dst, otherwise it is merged entry by entry and the
+// source directories that end up empty are deleted.
+func (t *Tree) Move(ctx context.Context, src, dst string, opts TreeOptions) (TreeSummary, error) {
+	lg := logger.GetLoggerFromContext(ctx)
+	var summary TreeSummary
+
+	if err := checkLoop(src, dst); err != nil {
+		return summary, err
+	}
+
+	entries, err := t.collect(ctx, src)
+	if err != nil {
+		return summary, err
+	}
+
+	if entries[0].isDir {
+		exists, _, err := t.stat(ctx, dst)
+		if err != nil {
+			return summary, err
+		}
+		if !exists {
+			if err = t.repo.MoveFile(ctx, src, dst); err == nil {
+				for _, entry := range entries {
+					result := TreeResult{Path: entry.path, Target: target(src, dst, entry.path), IsDir: entry.isDir}
+					if err = opts.report(&summary, result); err != nil {
+						return summary, err
+					}
+				}
+				lg.Info(ctx, "Directory moved", zap.String("src", src), zap.String("dst", dst))
+				return summary, nil
+			}
+			lg.Debug(ctx, "Error moving directory at once, moving entries", zap.Error(err))
+		}
+	}
+
+	dirs := map[string]error{}
+	for _, entry := range entries {
+		if entry.isDir {
+			dirs[entry.path] = t.CreateDirectory(ctx, target(src, dst, entry.path))
+			continue
+		}
+
+		result := TreeResult{Path: entry.path, Target: target(src, dst, entry.path)}
+		if result.Err = t.place(ctx, result.Target, opts); result.Err == nil {
+			result.Err = t.repo.MoveFile(ctx, entry.path, result.Target)
+		}
+		if err = opts.report(&summary, result); err != nil {
+			return summary, err
+		}
+	}
+
+	for i := len(entries) - 1; i >= 0; i-- {
+		if !entries[i].isDir {
+			continue
+		}
+
+		err := dirs[entries[i].path]
+		if err == nil {
+			if err = t.repo.DeleteFile(ctx, entries[i].path); os.IsNotExist(err) {
+				err = nil
+			}
+		}
+		result := TreeResult{Path: entries[i].path, Target: target(src, dst, entries[i].path), IsDir: true, Err: err}
+		if err = opts.report(&summary, result); err != nil {
+			return summary, err
+		}
+	}
+
+	lg.Info(ctx, "Path moved",
+		zap.String("src", src), zap.String("dst", dst), zap.Int("done", summary.Done), zap.Int("failed", summary.Failed))
+	return summary, nil
+}
@@ -0,0 +1,161 @@
+package repository
+
+import (
+	"context"
+	"github.com/JunBSer/FileManager/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"os"
+	"testing"
+)
+
+func TestTree(t *testing.T) {
+	t.Run("Local", func(t *testing.T) {
+		fullPath := CreateTempDir(t)
+		t.Cleanup(func() { os.RemoveAll(fullPath) })
+
+		runTree(t, New(relPath, 1024*1024, 2048))
+	})
+	t.Run("Memory", func(t *testing.T) {
+		runTree(t, NewMemory(2048))
+	})
+	t.Run("Dedup", func(t *testing.T) {
+		runTree(t, NewDedup(NewMemory(2048), 4))
+	})
+}
+
+func runTree(t *testing.T, repo FileRepository) {
+	ctx := context.Background()
+	lg := logger.New("test", "debug")
+	ctx = context.WithValue(ctx, logger.Key, lg)
+
+	tree := NewTree(repo)
+
+	writeFile := func(t *testing.T, path, data string) {
+		f, err := repo.CreateTempFile(ctx, path)
+		require.NoError(t, err)
+		_, err = repo.AppendData(ctx, f, []byte(data), 0)
+		require.NoError(t, err)
+		require.NoError(t, repo.CommitTempFile(ctx, f, path))
+	}
+
+	readFile := func(t *testing.T, path string) string {
+		f, err := repo.GetFileHandle(ctx, path, Read)
+		require.NoError(t, err)
+		defer f.Close()
+
+		data, err := io.ReadAll(f)
+		require.NoError(t, err)
+		return string(data)
+	}
+
+	collect := func(results *[]TreeResult) TreeOptions {
+		return TreeOptions{Progress: func(result TreeResult) error {
+			*results = append(*results, result)
+			return nil
+		}}
+	}
+
+	t.Run("CreateDirectory", func(t *testing.T) {
+		require.NoError(t, tree.CreateDirectory(ctx, "project/empty"))
+		entries, err := repo.ListDir(ctx, "project")
+		require.NoError(t, err)
+		assert.Equal(t, []DirectoryEntry{{Name: "empty", IsDir: true}}, entries)
+
+		writeFile(t, "project/readme.md", "readme")
+		assert.ErrorIs(t, tree.CreateDirectory(ctx, "project/readme.md"), ErrPathExists)
+	})
+
+	t.Run("Copy reports every entry", func(t *testing.T) {
+		writeFile(t, "project/src/main.go", "package main")
+		writeFile(t, "project/src/util/util.go", "package util")
+
+		_, err := tree.Copy(ctx, "project", "backup", TreeOptions{})
+		assert.ErrorIs(t, err, ErrIsDirectory)
+
+		var results []TreeResult
+		opts := collect(&results)
+		opts.Recursive = true
+		summary, err := tree.Copy(ctx, "project", "backup", opts)
+		require.NoError(t, err)
+		assert.Equal(t, TreeSummary{Done: 7}, summary)
+		assert.Len(t, results, 7)
+		assert.Equal(t, "package util", readFile(t, "backup/src/util/util.go"))
+		assert.Equal(t, "package main", readFile(t, "project/src/main.go"))
+
+		_, err = tree.Copy(ctx, "project", "project/nested", opts)
+		assert.ErrorIs(t, err, ErrTreeLoop)
+	})
+
+	t.Run("Copy keeps existing files unless overwriting", func(t *testing.T) {
+		writeFile(t, "project/readme.md", "new readme")
+
+		var results []TreeResult
+		opts := collect(&results)
+		opts.Recursive = true
+		summary, err := tree.Copy(ctx, "project", "backup", opts)
+		require.NoError(t, err)
+		assert.Equal(t, 4, summary.Done)
+		assert.Equal(t, 3, summary.Failed)
+
+		var replaced []string
+		opts.Overwrite = true
+		opts.BeforeReplace = func(ctx context.Context, path string) error {
+			replaced = append(replaced, path)
+			return nil
+		}
+		summary, err = tree.Copy(ctx, "project/readme.md", "backup/readme.md", opts)
+		require.NoError(t, err)
+		assert.Equal(t, TreeSummary{Done: 1}, summary)
+		assert.Equal(t, []string{"backup/readme.md"}, replaced)
+		assert.Equal(t, "new readme", readFile(t, "backup/readme.md"))
+	})
+
+	t.Run("Move merges into an existing directory", func(t *testing.T) {
+		writeFile(t, "incoming/src/extra.go", "package extra")
+		writeFile(t, "incoming/readme.md", "conflict")
+
+		var results []TreeResult
+		summary, err := tree.Move(ctx, "incoming", "backup", collect(&results))
+		require.NoError(t, err)
+		assert.Equal(t, 2, summary.Failed)
+		assert.Equal(t, "package extra", readFile(t, "backup/src/extra.go"))
+		assert.Equal(t, "conflict", readFile(t, "incoming/readme.md"))
+
+		_, err = repo.GetFileHandle(ctx, "incoming/src/extra.go", Read)
+		assert.True(t, os.IsNotExist(err))
+	})
+
+	t.Run("Move renames a directory at once", func(t *testing.T) {
+		summary, err := tree.Move(ctx, "backup", "archive/backup", TreeOptions{})
+		require.NoError(t, err)
+		assert.Zero(t, summary.Failed)
+		assert.Equal(t, "package util", readFile(t, "archive/backup/src/util/util.go"))
+
+		_, err = repo.ListDir(ctx, "backup")
+		assert.True(t, os.IsNotExist(err))
+	})
+
+	t.Run("DeleteDirectory", func(t *testing.T) {
+		_, err := tree.DeleteDirectory(ctx, "archive", TreeOptions{})
+		assert.ErrorIs(t, err, ErrDirNotEmpty)
+		_, err = tree.DeleteDirectory(ctx, "project/readme.md", TreeOptions{Recursive: true})
+		assert.ErrorIs(t, err, ErrNotDirectory)
+
+		var removed []string
+		summary, err := tree.DeleteDirectory(ctx, "archive", TreeOptions{
+			Recursive: true,
+			RemoveFile: func(ctx context.Context, path string) error {
+				removed = append(removed, path)
+				return repo.DeleteFile(ctx, path)
+			},
+		})
+		require.NoError(t, err)
+		assert.Zero(t, summary.Failed)
+		assert.Len(t, removed, 4)
+
+		_, err = repo.ListDir(ctx, "archive")
+		assert.True(t, os.IsNotExist(err))
+	})
+}
@@ -0,0 +1,335 @@
+package repository
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/JunBSer/FileManager/pkg/logger"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SystemDir is the top-level directory reserved for service data (upload
+// staging etc.). It is not part of the namespace visible to clients.
+const SystemDir = ".fm"
+
+var uploadsDir = filepath.Join(SystemDir, "uploads")
+
+var (
+	ErrSessionNotFound = errors.New("upload session not found")
+	ErrSessionBusy     = errors.New("upload session is used by another stream")
+	ErrOffsetMismatch  = errors.New("chunk offset does not match committed offset")
+	ErrSizeMismatch    = errors.New("uploaded size does not match declared size")
+)
+
+// IsSystemPath reports whether path points into SystemDir.
+func IsSystemPath(path string) bool {
+	sep := string(filepath.Separator)
+	clean := strings.TrimPrefix(filepath.Clean(sep+path), sep)
+	return strings.SplitN(clean, sep, 2)[0] == SystemDir
+}
+
+type UploadSession struct {
+	ID        string    `json:"id"`
+	FileName  string    `json:"file_name"`
+	TotalSize int64     `json:"total_size"`
+	SHA256    string    `json:"sha256,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	Offset    int64     `json:"-"`
+}
+
+// UploadSessionStore keeps partial uploads under SystemDir until they are
+// finalized. The committed offset of a session is the size of its part file,
+// so it survives restarts of the service.
+type UploadSessionStore struct {
+	repo    FileRepository
+	digests *DigestStore
+	locks   sync.Map
+}
+
+// SessionWriter appends chunks to one session. It holds the session lock until
+// Close is called.
+type SessionWriter struct {
+	store   *UploadSessionStore
+	session *UploadSession
+	file    FileHandle
+	unlock  func()
+}
+
+func NewUploadSessionStore(repo FileRepository) *UploadSessionStore {
+	return &UploadSessionStore{repo: repo, digests: NewDigestStore(repo)}
+}
+
+func partPath(id string) string {
+	return filepath.Join(uploadsDir, id+".part")
+}
+
+func metaPath(id string) string {
+	return filepath.Join(uploadsDir, id+".json")
+}
+
+// tryLock locks session id. Locks are only made for sessions that exist, so
+// bogus IDs do not leave entries behind.
+func (s *UploadSessionStore) tryLock(ctx context.Context, id string) (func(), error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, ErrSessionNotFound
+	}
+	if _, err := s.repo.Stat(ctx, metaPath(id)); err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrSessionNotFound
+		}
+		return nil, err
+	}
+
+	mu, _ := s.locks.LoadOrStore(id, &sync.Mutex{})
+	if !mu.(*sync.Mutex).TryLock() {
+		return nil, ErrSessionBusy
+	}
+	return mu.(*sync.Mutex).Unlock, nil
+}
+
+// Create starts a session for fileName. totalSize and sha256, a lowercase hex
+// SHA-256, are checked by Finalize unless they are empty.
+func (s *UploadSessionStore) Create(ctx context.Context, fileName string, totalSize int64, sha256 string) (*UploadSession, error) {
+	lg := logger.GetLoggerFromContext(ctx)
+
+	if IsSystemPath(fileName) {
+		return nil, fmt.Errorf("path %q is reserved", fileName)
+	}
+
+	session := &UploadSession{
+		ID:        uuid.NewString(),
+		FileName:  fileName,
+		TotalSize: totalSize,
+		SHA256:    sha256,
+		CreatedAt: time.Now().UTC(),
+	}
+
+	data, err := json.Marshal(session)
+	if err != nil {
+		return nil, err
+	}
+
+	meta, err := s.repo.GetFileHandle(ctx, metaPath(session.ID), CreateAndW)
+	if err != nil {
+		lg.Error(ctx, "Error creating session metadata", zap.String("uploadID", session.ID), zap.Error(err))
+		return nil, err
+	}
+	defer meta.Close()
+
+	if _, err = s.repo.AppendData(ctx, meta, data, 0); err != nil {
+		return nil, err
+	}
+
+	part, err := s.repo.GetFileHandle(ctx, partPath(session.ID), CreateAndW)
+	if err != nil {
+		lg.Error(ctx, "Error creating session part file", zap.String("uploadID", session.ID), zap.Error(err))
+		return nil, err
+	}
+
+	lg.Info(ctx, "Upload session created", zap.String("uploadID", session.ID), zap.String("fileName", fileName))
+	return session, part.Close()
+}
+
+func (s *UploadSessionStore) Get(ctx context.Context, id string) (*UploadSession, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, ErrSessionNotFound
+	}
+
+	meta, err := s.repo.GetFileHandle(ctx, metaPath(id), Read)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrSessionNotFound
+		}
+		return nil, err
+	}
+	defer meta.Close()
+
+	data, err := io.ReadAll(meta)
+	if err != nil {
+		return nil, err
+	}
+
+	session := &UploadSession{}
+	if err = json.Unmarshal(data, session); err != nil {
+		return nil, err
+	}
+
+	part, err := s.repo.GetFileHandle(ctx, partPath(id), Read)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrSessionNotFound
+		}
+		return nil, err
+	}
+	defer part.Close()
+
+	info, err := part.Stat()
+	if err != nil {
+		return nil, err
+	}
+	session.Offset = info.Size()
+
+	return session, nil
+}
+
+// Open locks the session and returns a writer for its part file.
+func (s *UploadSessionStore) Open(ctx context.Context, id string) (*SessionWriter, error) {
+	unlock, err := s.tryLock(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	session, err := s.Get(ctx, id)
+	if err != nil {
+		s.locks.Delete(id)
+		unlock()
+		return nil, err
+	}
+
+	file, err := s.repo.GetFileHandle(ctx, partPath(id), Write)
+	if err != nil {
+		s.locks.Delete(id)
+		unlock()
+		return nil, err
+	}
+
+	return &SessionWriter{store: s, session: session, file: file, unlock: unlock}, nil
+}
+
+// Write stores a chunk that starts at offset. Bytes before the committed
+// offset are skipped, so a client may resend the tail of a chunk it is not
+// sure about. A chunk that starts after the committed offset is rejected.
+func (w *SessionWriter) Write(ctx context.Context, offset int64, data []byte) error {
+	committed := w.session.Offset
+	if offset > committed {
+		return fmt.Errorf("%w: committed %d, got %d", ErrOffsetMismatch, committed, offset)
+	}
+
+	skip := committed - offset
+	if skip >= int64(len(data)) {
+		return nil
+	}
+
+	n, err := w.store.repo.AppendData(ctx, w.file, data[skip:], committed)
+	if err != nil {
+		return err
+	}
+	w.session.Offset += n
+
+	return nil
+}
+
+func (w *SessionWriter) Session() *UploadSession {
+	return w.session
+}
+
+func (w *SessionWriter) Close() error {
+	defer w.unlock()
+	return w.file.Close()
+}
+
+// partSHA256 returns the SHA-256 of the staged data of session id.
+func (s *UploadSessionStore) partSHA256(ctx context.Context, id string) (string, error) {
+	part, err := s.repo.GetFileHandle(ctx, partPath(id), Read)
+	if err != nil {
+		return "", err
+	}
+	defer part.Close()
+
+	hash := sha256.New()
+	if _, err = io.Copy(hash, part); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// Finalize moves the staged file to its destination and forgets the session.
+// The returned session holds the SHA-256 of the file. replace, if not nil, is
+// called once the staged file has been checked, right before it replaces the
+// destination; an error of replace keeps the session.
+func (s *UploadSessionStore) Finalize(ctx context.Context, id string, replace func(session *UploadSession) error) (*UploadSession, error) {
+	lg := logger.GetLoggerFromContext(ctx)
+
+	unlock, err := s.tryLock(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	session, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if session.TotalSize > 0 && session.Offset != session.TotalSize {
+		return session, fmt.Errorf("%w: declared %d, uploaded %d", ErrSizeMismatch, session.TotalSize, session.Offset)
+	}
+
+	sum, err := s.partSHA256(ctx, id)
+	if err != nil {
+		lg.Error(ctx, "Error hashing staged file", zap.String("uploadID", id), zap.Error(err))
+		return session, err
+	}
+	if session.SHA256 != "" && session.SHA256 != sum {
+		return session, fmt.Errorf("%w: sha256 declared %s, uploaded %s", ErrChecksumMismatch, session.SHA256, sum)
+	}
+
+	if replace != nil {
+		if err = replace(session); err != nil {
+			return session, err
+		}
+	}
+
+	if err = s.repo.MoveFile(ctx, partPath(id), session.FileName); err != nil {
+		lg.Error(ctx, "Error moving staged file", zap.String("uploadID", id), zap.Error(err))
+		return session, err
+	}
+
+	if err = s.repo.DeleteFile(ctx, metaPath(id)); err != nil {
+		lg.Error(ctx, "Error deleting session metadata", zap.String("uploadID", id), zap.Error(err))
+	}
+	s.locks.Delete(id)
+
+	session.SHA256 = sum
+	if err = s.digests.Put(ctx, session.FileName, sum); err != nil {
+		lg.Error(ctx, "Error storing digest", zap.String("uploadID", id), zap.Error(err))
+	}
+
+	lg.Info(ctx, "Upload session finalized", zap.String("uploadID", id), zap.String("fileName", session.FileName))
+	return session, nil
+}
+
+// Abort drops the staged data of the session.
+func (s *UploadSessionStore) Abort(ctx context.Context, id string) (*UploadSession, error) {
+	unlock, err := s.tryLock(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	session, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = s.repo.DeleteFile(ctx, partPath(id)); err != nil {
+		return session, err
+	}
+	if err = s.repo.DeleteFile(ctx, metaPath(id)); err != nil {
+		return session, err
+	}
+	s.locks.Delete(id)
+
+	logger.GetLoggerFromContext(ctx).Info(ctx, "Upload session aborted", zap.String("uploadID", id))
+	return session, nil
+}
@@ -0,0 +1,166 @@
+package repository
+
+import (
+	"context"
+	"github.com/JunBSer/FileManager/pkg/logger"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestIsSystemPath(t *testing.T) {
+	tests := []struct {
+		path     string
+		isSystem bool
+	}{
+		{".fm", true},
+		{"/.fm/uploads/x.part", true},
+		{"dir/../.fm/x", true},
+		{"dir/.fm", false},
+		{".fmx/file", false},
+		{"", false},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.isSystem, IsSystemPath(test.path), test.path)
+	}
+}
+
+func TestUploadSessionStore(t *testing.T) {
+	fullPath := CreateTempDir(t)
+	repo := New(relPath, 1024*1024, 2048)
+	store := NewUploadSessionStore(repo)
+
+	ctx := context.Background()
+	lg := logger.New("test", "debug")
+	ctx = context.WithValue(ctx, logger.Key, lg)
+
+	t.Run("Resume and finalize", func(t *testing.T) {
+		session, err := store.Create(ctx, "builds/artifact.bin", 11, "")
+		require.NoError(t, err)
+
+		w, err := store.Open(ctx, session.ID)
+		require.NoError(t, err)
+		require.NoError(t, w.Write(ctx, 0, []byte("hello ")))
+		require.NoError(t, w.Close())
+
+		_, err = os.Stat(filepath.Join(fullPath, "builds/artifact.bin"))
+		assert.True(t, os.IsNotExist(err))
+
+		got, err := store.Get(ctx, session.ID)
+		require.NoError(t, err)
+		assert.Equal(t, int64(6), got.Offset)
+
+		w, err = store.Open(ctx, session.ID)
+		require.NoError(t, err)
+		require.NoError(t, w.Write(ctx, 4, []byte("o world")))
+		require.NoError(t, w.Close())
+
+		finalized, err := store.Finalize(ctx, session.ID, nil)
+		require.NoError(t, err)
+		assert.Equal(t, int64(11), finalized.Offset)
+
+		data, err := os.ReadFile(filepath.Join(fullPath, "builds/artifact.bin"))
+		require.NoError(t, err)
+		assert.Equal(t, "hello world", string(data))
+
+		_, err = store.Get(ctx, session.ID)
+		assert.ErrorIs(t, err, ErrSessionNotFound)
+	})
+
+	t.Run("Gap in offsets is rejected", func(t *testing.T) {
+		session, err := store.Create(ctx, "gap.bin", 0, "")
+		require.NoError(t, err)
+
+		w, err := store.Open(ctx, session.ID)
+		require.NoError(t, err)
+		defer w.Close()
+
+		err = w.Write(ctx, 10, []byte("data"))
+		assert.ErrorIs(t, err, ErrOffsetMismatch)
+	})
+
+	t.Run("Concurrent writers are rejected", func(t *testing.T) {
+		session, err := store.Create(ctx, "busy.bin", 0, "")
+		require.NoError(t, err)
+
+		w, err := store.Open(ctx, session.ID)
+		require.NoError(t, err)
+
+		_, err = store.Open(ctx, session.ID)
+		assert.ErrorIs(t, err, ErrSessionBusy)
+		require.NoError(t, w.Close())
+	})
+
+	t.Run("Finalize with wrong size fails", func(t *testing.T) {
+		session, err := store.Create(ctx, "short.bin", 100, "")
+		require.NoError(t, err)
+
+		_, err = store.Finalize(ctx, session.ID, nil)
+		assert.ErrorIs(t, err, ErrSizeMismatch)
+	})
+
+	t.Run("Finalize checks and stores the SHA-256", func(t *testing.T) {
+		const helloSHA256 = "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"
+
+		session, err := store.Create(ctx, "hashed.bin", 0, "0000000000000000000000000000000000000000000000000000000000000000")
+		require.NoError(t, err)
+		w, err := store.Open(ctx, session.ID)
+		require.NoError(t, err)
+		require.NoError(t, w.Write(ctx, 0, []byte("hello")))
+		require.NoError(t, w.Close())
+
+		_, err = store.Finalize(ctx, session.ID, nil)
+		assert.ErrorIs(t, err, ErrChecksumMismatch)
+		_, err = store.Abort(ctx, session.ID)
+		require.NoError(t, err)
+
+		session, err = store.Create(ctx, "hashed.bin", 0, helloSHA256)
+		require.NoError(t, err)
+		w, err = store.Open(ctx, session.ID)
+		require.NoError(t, err)
+		require.NoError(t, w.Write(ctx, 0, []byte("hello")))
+		require.NoError(t, w.Close())
+
+		finalized, err := store.Finalize(ctx, session.ID, nil)
+		require.NoError(t, err)
+		assert.Equal(t, helloSHA256, finalized.SHA256)
+
+		sum, err := NewDigestStore(repo).Get(ctx, "hashed.bin")
+		require.NoError(t, err)
+		assert.Equal(t, helloSHA256, sum)
+	})
+
+	t.Run("Abort removes staged data", func(t *testing.T) {
+		session, err := store.Create(ctx, "aborted.bin", 0, "")
+		require.NoError(t, err)
+
+		_, err = store.Abort(ctx, session.ID)
+		require.NoError(t, err)
+
+		_, err = os.Stat(filepath.Join(fullPath, partPath(session.ID)))
+		assert.True(t, os.IsNotExist(err))
+
+		_, err = store.Get(ctx, session.ID)
+		assert.ErrorIs(t, err, ErrSessionNotFound)
+	})
+
+	t.Run("Unknown sessions are not locked", func(t *testing.T) {
+		for _, id := range []string{"not-a-uuid", "../../etc/passwd", uuid.NewString()} {
+			_, err := store.Open(ctx, id)
+			assert.ErrorIs(t, err, ErrSessionNotFound, id)
+			_, loaded := store.locks.Load(id)
+			assert.False(t, loaded, id)
+		}
+	})
+
+	t.Run("Reserved path is rejected", func(t *testing.T) {
+		_, err := store.Create(ctx, ".fm/uploads/evil", 0, "")
+		assert.Error(t, err)
+	})
+
+	defer os.RemoveAll(fullPath)
+}
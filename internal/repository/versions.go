@@ -0,0 +1,416 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/JunBSer/FileManager/pkg/logger"
+	"go.uber.org/zap"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	versionsDir   = filepath.Join(SystemDir, "versions")
+	retentionPath = filepath.Join(SystemDir, "retention.json")
+)
+
+var (
+	ErrVersionNotFound  = errors.New("file version not found")
+	ErrInvalidRetention = errors.New("invalid retention rule")
+)
+
+type VersionConfig struct {
+	Enabled bool          `env:"FILE_VERSIONING" envDefault:"true"`
+	Keep    int           `env:"FILE_VERSION_KEEP" envDefault:"10"`
+	MaxAge  time.Duration `env:"FILE_VERSION_MAX_AGE" envDefault:"0"`
+}
+
+// Version is a previous content of a file. IDs sort in the order the
+// versions were created.
+type Version struct {
+	ID        string
+	Path      string
+	Size      int64
+	CreatedAt time.Time
+	Reason    string
+}
+
+// RetentionRule limits the versions kept for the files of Dir and its
+// subdirectories. Zero values do not limit.
+type RetentionRule struct {
+	Dir    string        `json:"dir"`
+	Keep   int           `json:"keep"`
+	MaxAge time.Duration `json:"max_age"`
+}
+
+// VersionStore keeps previous contents of files under SystemDir. Every
+// version of path is a file named by its ID in the directory path of
+// versionsDir. Retention rules are stored as JSON next to it.
+type VersionStore struct {
+	repo     FileRepository
+	fallback RetentionRule
+
+	mu    sync.Mutex
+	last  int64
+	rules map[string]RetentionRule
+}
+
+func NewVersionStore(repo FileRepository, cfg VersionConfig) *VersionStore {
+	return &VersionStore{repo: repo, fallback: RetentionRule{Keep: cfg.Keep, MaxAge: cfg.MaxAge}}
+}
+
+func versionDir(path string) string {
+	return filepath.Join(versionsDir, filepath.Clean(string(filepath.Separator)+path))
+}
+
+// newID returns a unique ID that is greater than every ID returned before.
+func (s *VersionStore) newID(reason string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now().UTC()
+	nanos := now.UnixNano()
+	if nanos <= s.last {
+		nanos = s.last + 1
+	}
+	s.last = nanos
+
+	return fmt.Sprintf("%020d-%s", nanos, reason)
+}
+
+func parseVersionID(id string) (time.Time, string, bool) {
+	raw, reason, ok := strings.Cut(id, "-")
+	if !ok || len(raw) != 20 || reason == "" || strings.ContainsAny(reason, `/\`) {
+		return time.Time{}, "", false
+	}
+
+	nanos, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil {
+		return time.Time{}, "", false
+	}
+	return time.Unix(0, nanos).UTC(), reason, true
+}
+
+// copyFile copies src to dst, without moving data if the backend supports it.
+func copyFile(ctx context.Context, repo FileRepository, src, dst string) error {
+	if copier, ok := repo.(Copier); ok {
+		return copier.CopyFile(ctx, src, dst)
+	}
+
+	in, err := repo.GetFileHandle(ctx, src, Read)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := repo.CreateTempFile(ctx, dst)
+	if err != nil {
+		return err
+	}
+
+	if _, err = io.Copy(out, in); err == nil {
+		err = repo.CommitTempFile(ctx, out, dst)
+	}
+	if err != nil {
+		_ = repo.DiscardTempFile(ctx, out)
+		return err
+	}
+
+	return nil
+}
+
+// isFile reports whether path is an existing regular file.
+func isFile(ctx context.Context, repo FileRepository, path string) (bool, error) {
+	info, err := repo.Stat(ctx, path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return !info.IsDir(), nil
+}
+
+// Save copies the current content of path into its history. Nothing is saved
+// if path is not a file.
+func (s *VersionStore) Save(ctx context.Context, path, reason string) (*Version, error) {
+	ok, err := isFile(ctx, s.repo, path)
+	if err != nil || !ok {
+		return nil, err
+	}
+
+	id := s.newID(reason)
+	if err = copyFile(ctx, s.repo, path, filepath.Join(versionDir(path), id)); err != nil {
+		logger.GetLoggerFromContext(ctx).Error(ctx, "Error saving version", zap.String("path", path), zap.Error(err))
+		return nil, err
+	}
+
+	return s.saved(ctx, path, id)
+}
+
+// Retire moves path into its history, removing it from the namespace.
+// Directories are removed without keeping a version.
+func (s *VersionStore) Retire(ctx context.Context, path, reason string) (*Version, error) {
+	ok, err := isFile(ctx, s.repo, path)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, s.repo.DeleteFile(ctx, path)
+	}
+
+	id := s.newID(reason)
+	if err = s.repo.MoveFile(ctx, path, filepath.Join(versionDir(path), id)); err != nil {
+		logger.GetLoggerFromContext(ctx).Error(ctx, "Error retiring file", zap.String("path", path), zap.Error(err))
+		return nil, err
+	}
+
+	return s.saved(ctx, path, id)
+}
+
+func (s *VersionStore) saved(ctx context.Context, path, id string) (*Version, error) {
+	version, err := s.Get(ctx, path, id)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.GetLoggerFromContext(ctx).Info(ctx, "Version saved",
+		zap.String("path", path), zap.String("versionID", id), zap.String("reason", version.Reason))
+
+	if err = s.Prune(ctx, path); err != nil {
+		logger.GetLoggerFromContext(ctx).Error(ctx, "Error pruning versions", zap.String("path", path), zap.Error(err))
+	}
+	return version, nil
+}
+
+// Get returns one version of path.
+func (s *VersionStore) Get(ctx context.Context, path, id string) (*Version, error) {
+	created, reason, ok := parseVersionID(id)
+	if !ok {
+		return nil, ErrVersionNotFound
+	}
+
+	file, err := s.Open(ctx, path, id)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Version{ID: id, Path: path, Size: info.Size(), CreatedAt: created, Reason: reason}, nil
+}
+
+// List returns the versions of path, newest first.
+func (s *VersionStore) List(ctx context.Context, path string) ([]Version, error) {
+	entries, err := s.repo.ListDir(ctx, versionDir(path))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	versions := make([]Version, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir {
+			continue
+		}
+		if _, _, ok := parseVersionID(entry.Name); !ok {
+			continue
+		}
+
+		version, err := s.Get(ctx, path, entry.Name)
+		if err != nil {
+			if errors.Is(err, ErrVersionNotFound) {
+				continue
+			}
+			return nil, err
+		}
+		versions = append(versions, *version)
+	}
+
+	sort.Slice(versions, func(i, j int) bool { return versions[i].ID > versions[j].ID })
+	return versions, nil
+}
+
+// Open returns a handle to read one version of path.
+func (s *VersionStore) Open(ctx context.Context, path, id string) (FileHandle, error) {
+	if _, _, ok := parseVersionID(id); !ok {
+		return nil, ErrVersionNotFound
+	}
+
+	file, err := s.repo.GetFileHandle(ctx, filepath.Join(versionDir(path), id), Read)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrVersionNotFound
+		}
+		return nil, err
+	}
+	return file, nil
+}
+
+// Restore makes version id the current content of path. The content it
+// replaces is saved as a new version first.
+func (s *VersionStore) Restore(ctx context.Context, path, id string) (*Version, error) {
+	version, err := s.Get(ctx, path, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err = s.Save(ctx, path, "restore"); err != nil {
+		return nil, err
+	}
+
+	if err = copyFile(ctx, s.repo, filepath.Join(versionDir(path), id), path); err != nil {
+		logger.GetLoggerFromContext(ctx).Error(ctx, "Error restoring version", zap.String("path", path), zap.Error(err))
+		return nil, err
+	}
+
+	logger.GetLoggerFromContext(ctx).Info(ctx, "Version restored", zap.String("path", path), zap.String("versionID", id))
+	return version, nil
+}
+
+// Prune drops the versions of path that the retention rule of its directory
+// does not keep.
+func (s *VersionStore) Prune(ctx context.Context, path string) error {
+	rule, err := s.Rule(ctx, filepath.Dir(filepath.Clean(string(filepath.Separator)+path)))
+	if err != nil {
+		return err
+	}
+
+	versions, err := s.List(ctx, path)
+	if err != nil {
+		return err
+	}
+
+	for i, version := range versions {
+		expired := rule.MaxAge > 0 && time.Since(version.CreatedAt) > rule.MaxAge
+		if (rule.Keep > 0 && i >= rule.Keep) || expired {
+			err = s.repo.DeleteFile(ctx, filepath.Join(versionDir(path), version.ID))
+			if err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func cleanDir(dir string) string {
+	return filepath.Clean(string(filepath.Separator) + dir)
+}
+
+// loadRules must be called with s.mu held.
+func (s *VersionStore) loadRules(ctx context.Context) error {
+	if s.rules != nil {
+		return nil
+	}
+
+	rules := map[string]RetentionRule{}
+
+	file, err := s.repo.GetFileHandle(ctx, retentionPath, Read)
+	if err == nil {
+		defer file.Close()
+
+		var data []byte
+		if data, err = io.ReadAll(file); err != nil {
+			return err
+		}
+		if err = json.Unmarshal(data, &rules); err != nil {
+			return fmt.Errorf("corrupted retention rules: %w", err)
+		}
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+
+	s.rules = rules
+	return nil
+}
+
+// Rule returns the retention rule that applies to the files of dir: the rule
+// of the nearest directory above it, or the configured default.
+func (s *VersionStore) Rule(ctx context.Context, dir string) (RetentionRule, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.loadRules(ctx); err != nil {
+		return RetentionRule{}, err
+	}
+
+	for current := cleanDir(dir); ; current = filepath.Dir(current) {
+		if rule, ok := s.rules[current]; ok {
+			return rule, nil
+		}
+		if current == filepath.Dir(current) {
+			break
+		}
+	}
+
+	rule := s.fallback
+	rule.Dir = cleanDir(dir)
+	return rule, nil
+}
+
+// SetRule stores the retention rule of rule.Dir. A rule without limits
+// removes the rule, so the directory inherits again.
+func (s *VersionStore) SetRule(ctx context.Context, rule RetentionRule) error {
+	if rule.Keep < 0 || rule.MaxAge < 0 {
+		return fmt.Errorf("%w: limits must not be negative", ErrInvalidRetention)
+	}
+	if IsSystemPath(rule.Dir) {
+		return fmt.Errorf("path %q is reserved", rule.Dir)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.loadRules(ctx); err != nil {
+		return err
+	}
+
+	rules := make(map[string]RetentionRule, len(s.rules)+1)
+	for dir, r := range s.rules {
+		rules[dir] = r
+	}
+
+	rule.Dir = cleanDir(rule.Dir)
+	if rule.Keep == 0 && rule.MaxAge == 0 {
+		delete(rules, rule.Dir)
+	} else {
+		rules[rule.Dir] = rule
+	}
+
+	data, err := json.Marshal(rules)
+	if err != nil {
+		return err
+	}
+
+	file, err := s.repo.CreateTempFile(ctx, retentionPath)
+	if err != nil {
+		return err
+	}
+	if _, err = s.repo.AppendData(ctx, file, data, 0); err == nil {
+		err = s.repo.CommitTempFile(ctx, file, retentionPath)
+	}
+	if err != nil {
+		_ = s.repo.DiscardTempFile(ctx, file)
+		return err
+	}
+
+	s.rules = rules
+	logger.GetLoggerFromContext(ctx).Info(ctx, "Retention rule set",
+		zap.String("dir", rule.Dir), zap.Int("keep", rule.Keep), zap.Duration("maxAge", rule.MaxAge))
+	return nil
+}
@@ -0,0 +1,133 @@
+package repository
+
+import (
+	"context"
+	"github.com/JunBSer/FileManager/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"testing"
+	"time"
+)
+
+func TestVersionStore(t *testing.T) {
+	ctx := context.Background()
+	lg := logger.New("test", "debug")
+	ctx = context.WithValue(ctx, logger.Key, lg)
+
+	repo := NewMemory(2048)
+	store := NewVersionStore(repo, VersionConfig{Enabled: true, Keep: 3})
+
+	writeFile := func(t *testing.T, path, data string) {
+		f, err := repo.CreateTempFile(ctx, path)
+		require.NoError(t, err)
+		_, err = repo.AppendData(ctx, f, []byte(data), 0)
+		require.NoError(t, err)
+		require.NoError(t, repo.CommitTempFile(ctx, f, path))
+	}
+
+	readAll := func(t *testing.T, f FileHandle) string {
+		defer f.Close()
+		data, err := io.ReadAll(f)
+		require.NoError(t, err)
+		return string(data)
+	}
+
+	t.Run("Save keeps the current content", func(t *testing.T) {
+		writeFile(t, "docs/a.txt", "first")
+		version, err := store.Save(ctx, "docs/a.txt", "overwrite")
+		require.NoError(t, err)
+		require.NotNil(t, version)
+		assert.Equal(t, int64(5), version.Size)
+		assert.Equal(t, "overwrite", version.Reason)
+
+		f, err := store.Open(ctx, "docs/a.txt", version.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "first", readAll(t, f))
+	})
+
+	t.Run("Save of a missing file keeps nothing", func(t *testing.T) {
+		version, err := store.Save(ctx, "docs/missing.txt", "upload")
+		require.NoError(t, err)
+		assert.Nil(t, version)
+	})
+
+	t.Run("Restore saves the replaced content", func(t *testing.T) {
+		versions, err := store.List(ctx, "docs/a.txt")
+		require.NoError(t, err)
+		require.Len(t, versions, 1)
+
+		writeFile(t, "docs/a.txt", "second")
+		_, err = store.Restore(ctx, "docs/a.txt", versions[0].ID)
+		require.NoError(t, err)
+
+		f, err := repo.GetFileHandle(ctx, "docs/a.txt", Read)
+		require.NoError(t, err)
+		assert.Equal(t, "first", readAll(t, f))
+
+		versions, err = store.List(ctx, "docs/a.txt")
+		require.NoError(t, err)
+		require.Len(t, versions, 2)
+		assert.Equal(t, "restore", versions[0].Reason)
+	})
+
+	t.Run("Unknown version is not found", func(t *testing.T) {
+		_, err := store.Get(ctx, "docs/a.txt", "00000000000000000001-upload")
+		assert.ErrorIs(t, err, ErrVersionNotFound)
+		_, err = store.Open(ctx, "docs/a.txt", "../../a.txt")
+		assert.ErrorIs(t, err, ErrVersionNotFound)
+	})
+
+	t.Run("Prune keeps the configured number", func(t *testing.T) {
+		for i := 0; i < 5; i++ {
+			_, err := store.Save(ctx, "docs/a.txt", "overwrite")
+			require.NoError(t, err)
+		}
+
+		versions, err := store.List(ctx, "docs/a.txt")
+		require.NoError(t, err)
+		assert.Len(t, versions, 3)
+	})
+
+	t.Run("Retire moves the file into its history", func(t *testing.T) {
+		writeFile(t, "docs/b.txt", "gone")
+		version, err := store.Retire(ctx, "docs/b.txt", "delete")
+		require.NoError(t, err)
+
+		_, err = repo.GetFileHandle(ctx, "docs/b.txt", Read)
+		assert.Error(t, err)
+
+		f, err := store.Open(ctx, "docs/b.txt", version.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "gone", readAll(t, f))
+	})
+
+	t.Run("Rules are inherited by subdirectories", func(t *testing.T) {
+		require.NoError(t, store.SetRule(ctx, RetentionRule{Dir: "docs", Keep: 1, MaxAge: time.Hour}))
+
+		rule, err := store.Rule(ctx, "docs/nested/deep")
+		require.NoError(t, err)
+		assert.Equal(t, RetentionRule{Dir: "/docs", Keep: 1, MaxAge: time.Hour}, rule)
+
+		_, err = store.Save(ctx, "docs/a.txt", "overwrite")
+		require.NoError(t, err)
+		versions, err := store.List(ctx, "docs/a.txt")
+		require.NoError(t, err)
+		assert.Len(t, versions, 1)
+
+		reloaded := NewVersionStore(repo, VersionConfig{Keep: 3})
+		rule, err = reloaded.Rule(ctx, "docs")
+		require.NoError(t, err)
+		assert.Equal(t, 1, rule.Keep)
+
+		require.NoError(t, store.SetRule(ctx, RetentionRule{Dir: "docs"}))
+		rule, err = store.Rule(ctx, "docs")
+		require.NoError(t, err)
+		assert.Equal(t, 3, rule.Keep)
+	})
+
+	t.Run("Invalid rules are rejected", func(t *testing.T) {
+		err := store.SetRule(ctx, RetentionRule{Dir: "docs", Keep: -1})
+		assert.ErrorIs(t, err, ErrInvalidRetention)
+	})
+}
@@ -0,0 +1,411 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Types of change events.
+const (
+	EventCreate = "create"
+	EventModify = "modify"
+	EventDelete = "delete"
+	EventMove   = "move"
+)
+
+// ErrEventsLost is returned when events a watcher asked for are no longer
+// buffered, or when a watcher fell so far behind that events were dropped.
+var ErrEventsLost = errors.New("events were lost")
+
+// echoWindow is how long a change made through the repository suppresses
+// the same change reported by the disk watcher.
+const echoWindow = 2 * time.Second
+
+type WatchConfig struct {
+	Enabled bool `env:"FILE_WATCH" envDefault:"true"`
+	// Buffer is the number of events kept for watchers that resume, and the
+	// number of events a watcher may lag behind before it is dropped.
+	Buffer int `env:"FILE_WATCH_BUFFER" envDefault:"4096"`
+	// Disk enables watching the storage directory of the local backend for
+	// changes made directly on disk.
+	Disk bool `env:"FILE_WATCH_DISK" envDefault:"true"`
+}
+
+// Event is a change of a file or directory. Paths are slash separated and
+// relative to the storage root. OldPath is only set for moves.
+type Event struct {
+	Seq     uint64    `json:"seq"`
+	Type    string    `json:"type"`
+	Path    string    `json:"path"`
+	OldPath string    `json:"old_path,omitempty"`
+	IsDir   bool      `json:"is_dir"`
+	Time    time.Time `json:"time"`
+}
+
+// EventHub numbers change events, keeps the latest of them and hands them to
+// subscribers. Sequence numbers start at the time the hub was created in
+// microseconds, so they keep growing across restarts and a watcher resuming
+// with a number of an earlier run gets ErrEventsLost.
+type EventHub struct {
+	mu     sync.Mutex
+	size   int
+	seq    uint64
+	events []Event
+	subs   map[*Subscription]struct{}
+	// recent holds the paths changed in the last moments, so the disk
+	// watcher can skip its reports of changes that were already published.
+	recent map[string]echo
+}
+
+type echo struct {
+	exists bool
+	at     time.Time
+}
+
+// Subscription receives the events of an EventHub until it is closed.
+type Subscription struct {
+	hub *EventHub
+	ch  chan Event
+	err error
+}
+
+func NewEventHub(size int) *EventHub {
+	return &EventHub{
+		size:   size,
+		seq:    uint64(time.Now().UnixMicro()),
+		subs:   map[*Subscription]struct{}{},
+		recent: map[string]echo{},
+	}
+}
+
+// EventPath turns a repository path into the path of an event.
+func EventPath(path string) string {
+	return strings.TrimPrefix(filepath.ToSlash(filepath.Clean("/"+path)), "/")
+}
+
+// Subscribe returns a subscription to the events after since, or to new
+// events only if since is zero. It fails with ErrEventsLost if some of the
+// events after since are no longer buffered.
+func (h *EventHub) Subscribe(since uint64) (*Subscription, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	var replay []Event
+	if since != 0 {
+		first := h.seq + 1 - uint64(len(h.events))
+		if since+1 < first || since > h.seq {
+			return nil, fmt.Errorf("%w: %d is not buffered, oldest available is %d", ErrEventsLost, since, first)
+		}
+		replay = h.events[len(h.events)-int(h.seq-since):]
+	}
+
+	sub := &Subscription{hub: h, ch: make(chan Event, h.size+len(replay))}
+	for _, e := range replay {
+		sub.ch <- e
+	}
+	h.subs[sub] = struct{}{}
+	return sub, nil
+}
+
+// Publish numbers e and sends it to every subscriber. Subscribers whose
+// buffer is full are dropped with ErrEventsLost.
+func (h *EventHub) Publish(e Event) Event {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return h.publish(e)
+}
+
+func (h *EventHub) publish(e Event) Event {
+	h.seq++
+	e.Seq = h.seq
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+
+	if h.size > 0 {
+		if len(h.events) == h.size {
+			h.events = append(h.events[1:], e)
+		} else {
+			h.events = append(h.events, e)
+		}
+	}
+
+	for sub := range h.subs {
+		select {
+		case sub.ch <- e:
+		default:
+			sub.err = fmt.Errorf("%w: watcher fell more than %d events behind", ErrEventsLost, h.size)
+			delete(h.subs, sub)
+			close(sub.ch)
+		}
+	}
+	return e
+}
+
+// publishChange publishes an event of a change made through the repository.
+func (h *EventHub) publishChange(e Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.remember(e)
+	h.publish(e)
+}
+
+// publishDisk publishes an event seen on disk unless it echoes a change made
+// a moment ago, and reports whether it did. That skips the reports of
+// changes made through the repository and coalesces the many writes of a
+// file into one event.
+func (h *EventHub) publishDisk(e Event) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	seen, ok := h.recent[e.Path]
+	if ok && time.Since(seen.at) < echoWindow && seen.exists == (e.Type != EventDelete) {
+		return false
+	}
+	h.remember(e)
+	h.publish(e)
+	return true
+}
+
+// remember records the paths of e in recent.
+func (h *EventHub) remember(e Event) {
+	now := time.Now()
+	if len(h.recent) > 1024 {
+		for path, seen := range h.recent {
+			if now.Sub(seen.at) > echoWindow {
+				delete(h.recent, path)
+			}
+		}
+	}
+
+	h.recent[e.Path] = echo{exists: e.Type != EventDelete, at: now}
+	if e.OldPath != "" {
+		h.recent[e.OldPath] = echo{exists: false, at: now}
+	}
+}
+
+// Seq returns the sequence number of the latest event.
+func (h *EventHub) Seq() uint64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return h.seq
+}
+
+// Oldest returns the sequence number of the oldest buffered event, or of the
+// next event if none is buffered.
+func (h *EventHub) Oldest() uint64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return h.seq + 1 - uint64(len(h.events))
+}
+
+// Events returns the channel the events of s arrive on. It is closed when s
+// is closed or dropped, see Err.
+func (s *Subscription) Events() <-chan Event {
+	return s.ch
+}
+
+// Err returns ErrEventsLost if s was dropped because it fell behind.
+func (s *Subscription) Err() error {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+
+	return s.err
+}
+
+// Close stops the delivery of events to s.
+func (s *Subscription) Close() {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+
+	if _, ok := s.hub.subs[s]; ok {
+		delete(s.hub.subs, s)
+		close(s.ch)
+	}
+}
+
+// WatchRepo publishes an event for every change made through it. Changes of
+// SystemDir are not reported; moving a file into or out of it, as the trash
+// does, is reported as a delete or a create.
+type WatchRepo struct {
+	handleIO
+	inner  FileRepository
+	events *EventHub
+}
+
+// watchHandle reports a file opened for writing as modified when it is
+// closed.
+type watchHandle struct {
+	FileHandle
+	repo    *WatchRepo
+	path    string
+	created bool
+	written bool
+}
+
+func NewWatch(inner FileRepository, events *EventHub) *WatchRepo {
+	return &WatchRepo{handleIO: handleIO{readSize: inner.GetReadSize()}, inner: inner, events: events}
+}
+
+// Unwrap returns the backend below repo.
+func (repo *WatchRepo) Unwrap() FileRepository {
+	return repo.inner
+}
+
+// Events returns the hub the events of repo are published to.
+func (repo *WatchRepo) Events() *EventHub {
+	return repo.events
+}
+
+func watched(path string) bool {
+	return !IsSystemPath(path) && !IsTempName(filepath.Base(path))
+}
+
+func (repo *WatchRepo) publish(typ, path string, isDir bool) {
+	if watched(path) {
+		repo.events.publishChange(Event{Type: typ, Path: EventPath(path), IsDir: isDir})
+	}
+}
+
+// exists reports whether path exists and is a directory.
+func (repo *WatchRepo) exists(ctx context.Context, path string) (bool, bool) {
+	info, err := repo.inner.Stat(ctx, path)
+	if err != nil {
+		return false, false
+	}
+	return true, info.IsDir()
+}
+
+func (repo *WatchRepo) GetFileHandle(ctx context.Context, path string, openOption int) (FileHandle, error) {
+	if openOption == Read || !watched(path) {
+		return repo.inner.GetFileHandle(ctx, path, openOption)
+	}
+
+	existed, _ := repo.exists(ctx, path)
+	file, err := repo.inner.GetFileHandle(ctx, path, openOption)
+	if err != nil {
+		return nil, err
+	}
+	return &watchHandle{FileHandle: file, repo: repo, path: path, created: !existed}, nil
+}
+
+func (h *watchHandle) Write(b []byte) (int, error) {
+	n, err := h.FileHandle.Write(b)
+	if n > 0 {
+		h.written = true
+	}
+	return n, err
+}
+
+func (h *watchHandle) Close() error {
+	err := h.FileHandle.Close()
+	switch {
+	case h.created:
+		h.repo.publish(EventCreate, h.path, false)
+	case h.written:
+		h.repo.publish(EventModify, h.path, false)
+	}
+	h.created, h.written = false, false
+	return err
+}
+
+func (repo *WatchRepo) MoveFile(ctx context.Context, srcPath, dstPath string) error {
+	return repo.move(ctx, srcPath, dstPath, false)
+}
+
+func (repo *WatchRepo) MoveFileExclusive(ctx context.Context, srcPath, dstPath string) error {
+	return repo.move(ctx, srcPath, dstPath, true)
+}
+
+func (repo *WatchRepo) move(ctx context.Context, srcPath, dstPath string, exclusive bool) error {
+	_, isDir := repo.exists(ctx, srcPath)
+	if err := moveWith(repo.inner, exclusive)(ctx, srcPath, dstPath); err != nil {
+		return err
+	}
+
+	switch {
+	case watched(srcPath) && watched(dstPath):
+		repo.events.publishChange(Event{Type: EventMove, Path: EventPath(dstPath), OldPath: EventPath(srcPath), IsDir: isDir})
+	case watched(srcPath):
+		repo.publish(EventDelete, srcPath, isDir)
+	default:
+		repo.publish(EventCreate, dstPath, isDir)
+	}
+	return nil
+}
+
+func (repo *WatchRepo) CopyFile(ctx context.Context, srcPath, dstPath string) error {
+	existed, _ := repo.exists(ctx, dstPath)
+	if err := copyFile(ctx, repo.inner, srcPath, dstPath); err != nil {
+		return err
+	}
+
+	if existed {
+		repo.publish(EventModify, dstPath, false)
+	} else {
+		repo.publish(EventCreate, dstPath, false)
+	}
+	return nil
+}
+
+func (repo *WatchRepo) DeleteFile(ctx context.Context, path string) error {
+	_, isDir := repo.exists(ctx, path)
+	if err := repo.inner.DeleteFile(ctx, path); err != nil {
+		return err
+	}
+
+	repo.publish(EventDelete, path, isDir)
+	return nil
+}
+
+func (repo *WatchRepo) ListDir(ctx context.Context, path string) ([]DirectoryEntry, error) {
+	return repo.inner.ListDir(ctx, path)
+}
+
+func (repo *WatchRepo) CreateDir(ctx context.Context, path string) error {
+	existed, _ := repo.exists(ctx, path)
+	if err := repo.inner.CreateDir(ctx, path); err != nil {
+		return err
+	}
+
+	if !existed {
+		repo.publish(EventCreate, path, true)
+	}
+	return nil
+}
+
+func (repo *WatchRepo) Stat(ctx context.Context, path string) (fs.FileInfo, error) {
+	return repo.inner.Stat(ctx, path)
+}
+
+func (repo *WatchRepo) CreateTempFile(ctx context.Context, path string) (FileHandle, error) {
+	return repo.inner.CreateTempFile(ctx, path)
+}
+
+func (repo *WatchRepo) CommitTempFile(ctx context.Context, file FileHandle, path string) error {
+	existed, _ := repo.exists(ctx, path)
+	if err := repo.inner.CommitTempFile(ctx, file, path); err != nil {
+		return err
+	}
+
+	if existed {
+		repo.publish(EventModify, path, false)
+	} else {
+		repo.publish(EventCreate, path, false)
+	}
+	return nil
+}
+
+func (repo *WatchRepo) DiscardTempFile(ctx context.Context, file FileHandle) error {
+	return repo.inner.DiscardTempFile(ctx, file)
+}
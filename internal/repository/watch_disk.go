@@ -0,0 +1,150 @@
+package repository
+
+import (
+	"context"
+	"github.com/JunBSer/FileManager/pkg/logger"
+	"github.com/fsnotify/fsnotify"
+	"go.uber.org/zap"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// diskWatcher turns the inotify events of a storage directory into change
+// events. Every directory of the tree is watched on its own; directories
+// that appear later are added as they are created.
+type diskWatcher struct {
+	repo *WatchRepo
+	w    *fsnotify.Watcher
+	root string
+	// dirs are the watched directories, to tell whether a removed path was
+	// a directory.
+	dirs map[string]bool
+}
+
+// RunDiskWatch publishes the changes made directly in root, the storage
+// directory of the local backend, until ctx is done. Changes already
+// published by repo are skipped. A move on disk is reported as a delete and
+// a create, since inotify does not pair them.
+func (repo *WatchRepo) RunDiskWatch(ctx context.Context, root string) {
+	lg := logger.GetLoggerFromContext(ctx)
+
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		lg.Error(ctx, "Error creating storage directory watcher", zap.Error(err))
+		return
+	}
+	defer w.Close()
+
+	dw := &diskWatcher{repo: repo, w: w, root: root, dirs: map[string]bool{}}
+	if err = dw.add(root, false); err != nil {
+		lg.Error(ctx, "Error watching storage directory", zap.String("path", root), zap.Error(err))
+		return
+	}
+	lg.Info(ctx, "Watching storage directory", zap.String("path", root), zap.Int("directories", len(dw.dirs)))
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case err, ok := <-w.Errors:
+			if !ok {
+				return
+			}
+			lg.Error(ctx, "Error watching storage directory", zap.Error(err))
+		case event, ok := <-w.Events:
+			if !ok {
+				return
+			}
+			if err = dw.handle(event); err != nil {
+				lg.Error(ctx, "Error handling storage directory event", zap.String("path", event.Name), zap.Error(err))
+			}
+		}
+	}
+}
+
+// relative returns the repository path of the file name on disk, and false
+// for files that are not watched.
+func (dw *diskWatcher) relative(name string) (string, bool) {
+	rel, err := filepath.Rel(dw.root, name)
+	if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+		return "", false
+	}
+	return rel, watched(rel)
+}
+
+// add watches dir and the directories below it. With publish, the entries
+// found below dir are reported as created, since they were moved in or
+// written before the watch was in place.
+func (dw *diskWatcher) add(dir string, publish bool) error {
+	return filepath.WalkDir(dir, func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+
+		if name != dw.root {
+			rel, ok := dw.relative(name)
+			if !ok {
+				if d.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if publish && name != dir {
+				dw.repo.events.publishDisk(Event{Type: EventCreate, Path: EventPath(rel), IsDir: d.IsDir()})
+			}
+		}
+
+		if !d.IsDir() {
+			return nil
+		}
+		if err = dw.w.Add(name); err != nil {
+			return err
+		}
+		dw.dirs[name] = true
+		return nil
+	})
+}
+
+func (dw *diskWatcher) handle(event fsnotify.Event) error {
+	rel, ok := dw.relative(event.Name)
+	if !ok {
+		return nil
+	}
+
+	switch {
+	case event.Has(fsnotify.Create):
+		info, err := os.Lstat(event.Name)
+		if os.IsNotExist(err) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		published := dw.repo.events.publishDisk(Event{Type: EventCreate, Path: EventPath(rel), IsDir: info.IsDir()})
+		if info.IsDir() {
+			// The content of a directory moved through the repository was
+			// reported with the move.
+			return dw.add(event.Name, published)
+		}
+	case event.Has(fsnotify.Write):
+		dw.repo.events.publishDisk(Event{Type: EventModify, Path: EventPath(rel)})
+	case event.Has(fsnotify.Remove), event.Has(fsnotify.Rename):
+		isDir := dw.dirs[event.Name]
+		if isDir {
+			prefix := event.Name + string(filepath.Separator)
+			for name := range dw.dirs {
+				if name == event.Name || strings.HasPrefix(name, prefix) {
+					delete(dw.dirs, name)
+					_ = dw.w.Remove(name)
+				}
+			}
+		}
+		dw.repo.events.publishDisk(Event{Type: EventDelete, Path: EventPath(rel), IsDir: isDir})
+	}
+	return nil
+}
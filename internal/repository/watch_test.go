@@ -0,0 +1,241 @@
+package repository
+
+import (
+	"context"
+	"github.com/JunBSer/FileManager/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestConformance_Watch(t *testing.T) {
+	runConformance(t, func(t *testing.T) FileRepository {
+		return NewWatch(NewMemory(2048), NewEventHub(16))
+	})
+}
+
+// drain returns the events of sub that are already delivered.
+func drain(sub *Subscription) []Event {
+	var events []Event
+	for {
+		select {
+		case e, ok := <-sub.Events():
+			if !ok {
+				return events
+			}
+			events = append(events, e)
+		default:
+			return events
+		}
+	}
+}
+
+func TestEventHub(t *testing.T) {
+	hub := NewEventHub(3)
+
+	live, err := hub.Subscribe(0)
+	require.NoError(t, err)
+
+	var seqs []uint64
+	var events []Event
+	for _, path := range []string{"a", "b", "c", "d"} {
+		seqs = append(seqs, hub.Publish(Event{Type: EventCreate, Path: path}).Seq)
+		events = append(events, drain(live)...)
+	}
+	assert.Equal(t, seqs[3], hub.Seq())
+	for i := 1; i < len(seqs); i++ {
+		assert.Equal(t, seqs[i-1]+1, seqs[i])
+	}
+
+	require.Len(t, events, 4)
+	assert.Equal(t, "d", events[3].Path)
+	assert.Equal(t, seqs[3], events[3].Seq)
+	assert.False(t, events[0].Time.IsZero())
+
+	live.Close()
+	_, ok := <-live.Events()
+	assert.False(t, ok)
+	assert.NoError(t, live.Err())
+
+	t.Run("resume", func(t *testing.T) {
+		sub, err := hub.Subscribe(seqs[1])
+		require.NoError(t, err)
+		defer sub.Close()
+
+		events := drain(sub)
+		require.Len(t, events, 2)
+		assert.Equal(t, "c", events[0].Path)
+		assert.Equal(t, "d", events[1].Path)
+
+		// The oldest buffered event is b, so resuming after a works too.
+		sub, err = hub.Subscribe(seqs[0])
+		require.NoError(t, err)
+		assert.Len(t, drain(sub), 3)
+		sub.Close()
+
+		sub, err = hub.Subscribe(seqs[3])
+		require.NoError(t, err)
+		assert.Empty(t, drain(sub))
+		sub.Close()
+	})
+
+	t.Run("lost", func(t *testing.T) {
+		_, err := hub.Subscribe(seqs[0] - 1)
+		assert.ErrorIs(t, err, ErrEventsLost)
+		_, err = hub.Subscribe(seqs[3] + 1)
+		assert.ErrorIs(t, err, ErrEventsLost)
+
+		// An earlier run numbered its events below the start of this one.
+		_, err = NewEventHub(3).Subscribe(seqs[3])
+		assert.ErrorIs(t, err, ErrEventsLost)
+	})
+
+	t.Run("slow watcher", func(t *testing.T) {
+		sub, err := hub.Subscribe(0)
+		require.NoError(t, err)
+
+		for i := 0; i < 4; i++ {
+			hub.Publish(Event{Type: EventModify, Path: "a"})
+		}
+		assert.Len(t, drain(sub), 3)
+		_, ok := <-sub.Events()
+		assert.False(t, ok)
+		assert.ErrorIs(t, sub.Err(), ErrEventsLost)
+		sub.Close()
+	})
+
+}
+
+func TestWatchRepo(t *testing.T) {
+	ctx := context.WithValue(context.Background(), logger.Key, logger.New("test", "debug"))
+	repo := NewWatch(NewMemory(2048), NewEventHub(64))
+
+	sub, err := repo.Events().Subscribe(0)
+	require.NoError(t, err)
+	defer sub.Close()
+
+	write := func(path, content string) {
+		f, err := repo.CreateTempFile(ctx, path)
+		require.NoError(t, err)
+		_, err = f.Write([]byte(content))
+		require.NoError(t, err)
+		require.NoError(t, repo.CommitTempFile(ctx, f, path))
+	}
+
+	require.NoError(t, repo.CreateDir(ctx, "docs"))
+	write("docs/a.txt", "one")
+	write("docs/a.txt", "two")
+	require.NoError(t, repo.MoveFile(ctx, "docs/a.txt", "docs/b.txt"))
+	require.NoError(t, repo.CopyFile(ctx, "docs/b.txt", "docs/c.txt"))
+
+	f, err := repo.GetFileHandle(ctx, "docs/c.txt", Write)
+	require.NoError(t, err)
+	_, err = repo.AppendData(ctx, f, []byte("!"), 3)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	require.NoError(t, repo.DeleteFile(ctx, "docs/c.txt"))
+
+	// Moves into and out of SystemDir look like deletes and creates, and
+	// changes inside it are not reported.
+	require.NoError(t, repo.MoveFile(ctx, "docs/b.txt", filepath.Join(SystemDir, "trash", "b.txt")))
+	write(filepath.Join(SystemDir, "state.json"), "{}")
+	require.NoError(t, repo.MoveFile(ctx, filepath.Join(SystemDir, "trash", "b.txt"), "docs/b.txt"))
+
+	type change struct {
+		Type, Path, OldPath string
+		IsDir               bool
+	}
+	var changes []change
+	for _, e := range drain(sub) {
+		changes = append(changes, change{e.Type, e.Path, e.OldPath, e.IsDir})
+	}
+	assert.Equal(t, []change{
+		{EventCreate, "docs", "", true},
+		{EventCreate, "docs/a.txt", "", false},
+		{EventModify, "docs/a.txt", "", false},
+		{EventMove, "docs/b.txt", "docs/a.txt", false},
+		{EventCreate, "docs/c.txt", "", false},
+		{EventModify, "docs/c.txt", "", false},
+		{EventDelete, "docs/c.txt", "", false},
+		{EventDelete, "docs/b.txt", "", false},
+		{EventCreate, "docs/b.txt", "", false},
+	}, changes)
+
+	layer, ok := Layer[*WatchRepo](NewQuota(repo, QuotaLimit{}, nil))
+	assert.True(t, ok)
+	assert.Same(t, repo, layer)
+}
+
+func TestWatchRepo_Disk(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.WithValue(context.Background(), logger.Key, logger.New("test", "debug")))
+	defer cancel()
+
+	root := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "docs"), 0o755))
+	require.NoError(t, os.MkdirAll(filepath.Join(root, SystemDir), 0o755))
+
+	repo := NewWatch(NewMemory(2048), NewEventHub(64))
+	sub, err := repo.Events().Subscribe(0)
+	require.NoError(t, err)
+	defer sub.Close()
+
+	go repo.RunDiskWatch(ctx, root)
+
+	next := func() Event {
+		select {
+		case e := <-sub.Events():
+			return e
+		case <-time.After(5 * time.Second):
+			t.Fatal("no event")
+			return Event{}
+		}
+	}
+
+	// The watcher is in place once a change of the root is reported.
+	var e Event
+	require.Eventually(t, func() bool {
+		_ = os.WriteFile(filepath.Join(root, "ready"), nil, 0o644)
+		select {
+		case e = <-sub.Events():
+			return true
+		default:
+			return false
+		}
+	}, 5*time.Second, 50*time.Millisecond)
+	assert.Equal(t, "ready", e.Path)
+
+	require.NoError(t, os.WriteFile(filepath.Join(root, SystemDir, "state.json"), []byte("{}"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "docs", "a.txt"), []byte("a"), 0o644))
+	e = next()
+	assert.Equal(t, EventCreate, e.Type)
+	assert.Equal(t, "docs/a.txt", e.Path)
+
+	// Writes right after the create are not reported again.
+	require.NoError(t, os.Mkdir(filepath.Join(root, "docs", "sub"), 0o755))
+	e = next()
+	assert.Equal(t, Event{Type: EventCreate, Path: "docs/sub", IsDir: true}, Event{Type: e.Type, Path: e.Path, IsDir: e.IsDir})
+
+	require.NoError(t, os.WriteFile(filepath.Join(root, "docs", "sub", "b.txt"), []byte("b"), 0o644))
+	e = next()
+	assert.Equal(t, "docs/sub/b.txt", e.Path)
+
+	require.NoError(t, os.Remove(filepath.Join(root, "docs", "sub", "b.txt")))
+	e = next()
+	assert.Equal(t, EventDelete, e.Type)
+	assert.Equal(t, "docs/sub/b.txt", e.Path)
+
+	// A change made through the repository is not reported a second time
+	// when it shows up on disk.
+	repo.publish(EventCreate, "docs/c.txt", false)
+	assert.Equal(t, "docs/c.txt", next().Path)
+	require.NoError(t, os.WriteFile(filepath.Join(root, "docs", "c.txt"), []byte("c"), 0o644))
+	require.NoError(t, os.Remove(filepath.Join(root, "docs", "sub")))
+	e = next()
+	assert.Equal(t, EventDelete, e.Type)
+	assert.Equal(t, "docs/sub", e.Path)
+	assert.True(t, e.IsDir)
+}
@@ -0,0 +1,261 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/JunBSer/FileManager/pkg/logger"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	webhooksDir    = filepath.Join(SystemDir, "webhooks", "hooks")
+	deadLettersDir = filepath.Join(SystemDir, "webhooks", "dead")
+)
+
+// Statuses of webhook deliveries.
+const (
+	DeliveryPending   = "pending"
+	DeliveryDelivered = "delivered"
+	DeliveryFailed    = "failed"
+)
+
+var (
+	ErrInvalidWebhook     = errors.New("invalid webhook")
+	ErrWebhookNotFound    = errors.New("webhook not found")
+	ErrDeadLetterNotFound = errors.New("dead letter not found")
+)
+
+type WebhookConfig struct {
+	Enabled bool `env:"WEBHOOK_ENABLED" envDefault:"true"`
+	// MaxAttempts is how often a delivery is tried before it goes to the
+	// dead-letter log.
+	MaxAttempts int `env:"WEBHOOK_MAX_ATTEMPTS" envDefault:"8"`
+	// Backoff is the wait before the first retry. It doubles with every
+	// further attempt up to MaxBackoff.
+	Backoff    time.Duration `env:"WEBHOOK_BACKOFF" envDefault:"1s"`
+	MaxBackoff time.Duration `env:"WEBHOOK_MAX_BACKOFF" envDefault:"5m"`
+	Timeout    time.Duration `env:"WEBHOOK_TIMEOUT" envDefault:"10s"`
+	Workers    int           `env:"WEBHOOK_WORKERS" envDefault:"4"`
+	// QueueSize is the number of deliveries that may wait for a webhook.
+	// Further ones go to the dead-letter log right away.
+	QueueSize int `env:"WEBHOOK_QUEUE_SIZE" envDefault:"1000"`
+	// History is the number of recent deliveries kept in memory for the
+	// delivery history.
+	History int `env:"WEBHOOK_HISTORY" envDefault:"1000"`
+}
+
+// Webhook asks for the events of Types, all if empty, below PathPrefix to be
+// posted to URL, signed with Secret.
+type Webhook struct {
+	ID         string    `json:"id"`
+	PathPrefix string    `json:"path_prefix"`
+	Types      []string  `json:"types,omitempty"`
+	URL        string    `json:"url"`
+	Secret     string    `json:"secret"`
+	CreatedBy  string    `json:"created_by,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// Matches reports whether e is one of the events of w. A move matches if
+// either of its paths is below the prefix.
+func (w Webhook) Matches(e Event) bool {
+	if len(w.Types) > 0 && !slices.Contains(w.Types, e.Type) {
+		return false
+	}
+	return underPrefix(e.Path, w.PathPrefix) || (e.OldPath != "" && underPrefix(e.OldPath, w.PathPrefix))
+}
+
+func underPrefix(path, prefix string) bool {
+	return prefix == "" || path == prefix || strings.HasPrefix(path, prefix+"/")
+}
+
+// Delivery is the posting of one event to one webhook. Its ID stays the
+// same across attempts, so receivers can drop duplicates.
+type Delivery struct {
+	ID           string    `json:"id"`
+	WebhookID    string    `json:"webhook_id"`
+	URL          string    `json:"url"`
+	Event        Event     `json:"event"`
+	Status       string    `json:"status"`
+	Attempts     int       `json:"attempts"`
+	ResponseCode int       `json:"response_code,omitempty"`
+	LastError    string    `json:"last_error,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+	NextAttempt  time.Time `json:"next_attempt,omitempty"`
+}
+
+// WebhookStore keeps one JSON file per webhook and per dead letter, a
+// delivery that failed for good, under SystemDir.
+type WebhookStore struct {
+	repo FileRepository
+	mu   sync.Mutex
+}
+
+func NewWebhookStore(repo FileRepository) *WebhookStore {
+	return &WebhookStore{repo: repo}
+}
+
+func webhookPath(id string) string {
+	return filepath.Join(webhooksDir, id+".json")
+}
+
+func deadLetterPath(id string) string {
+	return filepath.Join(deadLettersDir, id+".json")
+}
+
+func validID(id string) bool {
+	return id != "" && !strings.ContainsAny(id, `/\.`)
+}
+
+// Create stores hook under a new ID.
+func (s *WebhookStore) Create(ctx context.Context, hook Webhook) (Webhook, error) {
+	target, err := url.Parse(hook.URL)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		return Webhook{}, fmt.Errorf("%w: url %q is not an absolute http or https URL", ErrInvalidWebhook, hook.URL)
+	}
+	for _, typ := range hook.Types {
+		switch typ {
+		case EventCreate, EventModify, EventDelete, EventMove:
+		default:
+			return Webhook{}, fmt.Errorf("%w: unknown event type %q", ErrInvalidWebhook, typ)
+		}
+	}
+	if hook.Secret == "" {
+		return Webhook{}, fmt.Errorf("%w: secret is empty", ErrInvalidWebhook)
+	}
+
+	hook.ID = uuid.NewString()
+	hook.PathPrefix = EventPath(hook.PathPrefix)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err = writeJSON(ctx, s.repo, webhookPath(hook.ID), hook); err != nil {
+		logger.GetLoggerFromContext(ctx).Error(ctx, "Error storing webhook", zap.String("url", hook.URL), zap.Error(err))
+		return Webhook{}, err
+	}
+	return hook, nil
+}
+
+func (s *WebhookStore) Get(ctx context.Context, id string) (Webhook, error) {
+	if !validID(id) {
+		return Webhook{}, fmt.Errorf("%w: %s", ErrWebhookNotFound, id)
+	}
+
+	var hook Webhook
+	err := readJSON(ctx, s.repo, webhookPath(id), &hook)
+	if os.IsNotExist(err) {
+		return Webhook{}, fmt.Errorf("%w: %s", ErrWebhookNotFound, id)
+	}
+	return hook, err
+}
+
+// List returns the webhooks, the oldest first.
+func (s *WebhookStore) List(ctx context.Context) ([]Webhook, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var hooks []Webhook
+	err := s.list(ctx, webhooksDir, func(id string) error {
+		hook, err := s.Get(ctx, id)
+		if err == nil {
+			hooks = append(hooks, hook)
+		}
+		return err
+	})
+
+	sort.Slice(hooks, func(i, j int) bool { return hooks[i].CreatedAt.Before(hooks[j].CreatedAt) })
+	return hooks, err
+}
+
+// list calls read for the ID of every record in dir. Records that cannot be
+// read are logged and skipped.
+func (s *WebhookStore) list(ctx context.Context, dir string, read func(id string) error) error {
+	entries, err := s.repo.ListDir(ctx, dir)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		id, ok := strings.CutSuffix(entry.Name, ".json")
+		if entry.IsDir || !ok {
+			continue
+		}
+		if err = read(id); err != nil {
+			logger.GetLoggerFromContext(ctx).Error(ctx, "Error reading webhook record", zap.String("dir", dir), zap.String("id", id), zap.Error(err))
+		}
+	}
+	return nil
+}
+
+func (s *WebhookStore) Remove(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.Get(ctx, id); err != nil {
+		return err
+	}
+	return s.repo.DeleteFile(ctx, webhookPath(id))
+}
+
+// AddDeadLetter stores a delivery that failed for good.
+func (s *WebhookStore) AddDeadLetter(ctx context.Context, delivery Delivery) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return writeJSON(ctx, s.repo, deadLetterPath(delivery.ID), delivery)
+}
+
+// DeadLetters returns the deliveries that failed for good, the latest
+// first.
+func (s *WebhookStore) DeadLetters(ctx context.Context) ([]Delivery, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var letters []Delivery
+	err := s.list(ctx, deadLettersDir, func(id string) error {
+		var delivery Delivery
+		err := readJSON(ctx, s.repo, deadLetterPath(id), &delivery)
+		if err == nil {
+			letters = append(letters, delivery)
+		}
+		return err
+	})
+
+	sort.Slice(letters, func(i, j int) bool { return letters[i].UpdatedAt.After(letters[j].UpdatedAt) })
+	return letters, err
+}
+
+// TakeDeadLetter removes the dead letter id and returns it.
+func (s *WebhookStore) TakeDeadLetter(ctx context.Context, id string) (Delivery, error) {
+	if !validID(id) {
+		return Delivery{}, fmt.Errorf("%w: %s", ErrDeadLetterNotFound, id)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var delivery Delivery
+	err := readJSON(ctx, s.repo, deadLetterPath(id), &delivery)
+	if os.IsNotExist(err) {
+		return Delivery{}, fmt.Errorf("%w: %s", ErrDeadLetterNotFound, id)
+	}
+	if err != nil {
+		return Delivery{}, err
+	}
+	return delivery, s.repo.DeleteFile(ctx, deadLetterPath(id))
+}
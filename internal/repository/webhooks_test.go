@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"context"
+	"github.com/JunBSer/FileManager/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestWebhookStore(t *testing.T) {
+	ctx := context.WithValue(context.Background(), logger.Key, logger.New("test", "debug"))
+	store := NewWebhookStore(NewMemory(2048))
+
+	for _, hook := range []Webhook{
+		{URL: "ftp://example.com/hook", Secret: "s"},
+		{URL: "/hook", Secret: "s"},
+		{URL: "https://example.com/hook", Secret: "s", Types: []string{"rename"}},
+		{URL: "https://example.com/hook"},
+	} {
+		_, err := store.Create(ctx, hook)
+		assert.ErrorIs(t, err, ErrInvalidWebhook, hook.URL)
+	}
+
+	first, err := store.Create(ctx, Webhook{PathPrefix: "/incoming/", URL: "https://example.com/a", Secret: "s", CreatedAt: time.Now()})
+	require.NoError(t, err)
+	assert.Equal(t, "incoming", first.PathPrefix)
+	second, err := store.Create(ctx, Webhook{URL: "http://localhost:9000/b", Secret: "s", Types: []string{EventDelete}, CreatedAt: time.Now().Add(time.Second)})
+	require.NoError(t, err)
+
+	hooks, err := store.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, hooks, 2)
+	assert.Equal(t, first.ID, hooks[0].ID)
+	assert.Equal(t, second.ID, hooks[1].ID)
+
+	require.NoError(t, store.Remove(ctx, first.ID))
+	assert.ErrorIs(t, store.Remove(ctx, first.ID), ErrWebhookNotFound)
+	_, err = store.Get(ctx, "../acl")
+	assert.ErrorIs(t, err, ErrWebhookNotFound)
+
+	t.Run("Dead letters", func(t *testing.T) {
+		delivery := Delivery{ID: "d1", WebhookID: second.ID, Event: Event{Seq: 7, Type: EventDelete, Path: "a.txt"}, Status: DeliveryFailed, Attempts: 3}
+		require.NoError(t, store.AddDeadLetter(ctx, delivery))
+
+		letters, err := store.DeadLetters(ctx)
+		require.NoError(t, err)
+		require.Len(t, letters, 1)
+		assert.Equal(t, uint64(7), letters[0].Event.Seq)
+
+		taken, err := store.TakeDeadLetter(ctx, "d1")
+		require.NoError(t, err)
+		assert.Equal(t, 3, taken.Attempts)
+		_, err = store.TakeDeadLetter(ctx, "d1")
+		assert.ErrorIs(t, err, ErrDeadLetterNotFound)
+	})
+}
+
+func TestWebhook_Matches(t *testing.T) {
+	hook := Webhook{PathPrefix: "incoming", Types: []string{EventCreate, EventMove}}
+
+	assert.True(t, hook.Matches(Event{Type: EventCreate, Path: "incoming/a.csv"}))
+	assert.True(t, hook.Matches(Event{Type: EventCreate, Path: "incoming"}))
+	assert.False(t, hook.Matches(Event{Type: EventCreate, Path: "incoming-old/a.csv"}))
+	assert.False(t, hook.Matches(Event{Type: EventDelete, Path: "incoming/a.csv"}))
+	assert.True(t, hook.Matches(Event{Type: EventMove, Path: "done/a.csv", OldPath: "incoming/a.csv"}))
+	assert.True(t, Webhook{}.Matches(Event{Type: EventModify, Path: "x"}))
+}
@@ -0,0 +1,258 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/JunBSer/FileManager/internal/auth"
+	"github.com/JunBSer/FileManager/internal/repository"
+	"github.com/JunBSer/FileManager/pkg/api/fmpb"
+	"github.com/JunBSer/FileManager/pkg/logger"
+	"go.uber.org/zap"
+	"slices"
+	"sort"
+	"strings"
+)
+
+var (
+	ErrPermissionDenied = errors.New("permission denied")
+	ErrACLDisabled      = errors.New("access control is disabled")
+)
+
+// ACL decides which paths the callers of the services may access. Callers
+// are identified by the identity in their context; callers without one are
+// anonymous and only get what is granted to everybody. A nil ACL allows
+// everything.
+type ACL struct {
+	store  *repository.ACLStore
+	admins []string
+}
+
+// NewACL creates the access checks of cfg on the grants of store. It returns
+// nil if access control is disabled.
+func NewACL(store *repository.ACLStore, cfg repository.ACLConfig) (*ACL, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+
+	acl := &ACL{store: store}
+	for _, admin := range strings.Split(cfg.Admins, ",") {
+		if strings.TrimSpace(admin) == "" {
+			continue
+		}
+		principal, err := repository.ParsePrincipal(admin)
+		if err != nil {
+			return nil, fmt.Errorf("ACL_ADMINS: %w", err)
+		}
+		acl.admins = append(acl.admins, principal)
+	}
+	return acl, nil
+}
+
+func callerPrincipals(ctx context.Context) (string, []string) {
+	id, ok := auth.FromContext(ctx)
+	if !ok {
+		return "", repository.Principals("", nil)
+	}
+	return id.Subject, repository.Principals(id.Subject, id.Groups)
+}
+
+func (a *ACL) isAdmin(principals []string) bool {
+	for _, principal := range principals {
+		if slices.Contains(a.admins, principal) {
+			return true
+		}
+	}
+	return false
+}
+
+// Check fails with ErrPermissionDenied unless the caller of ctx has perm on
+// every path. Calls authorized by a share token may only do what the share
+// allows, whether access control is enabled or not.
+func (a *ACL) Check(ctx context.Context, perm repository.Permission, paths ...string) error {
+	if share, ok := shareFromContext(ctx); ok {
+		return checkShare(share, perm, paths)
+	}
+	if a == nil {
+		return nil
+	}
+
+	subject, principals := callerPrincipals(ctx)
+	if a.isAdmin(principals) {
+		return nil
+	}
+
+	for _, path := range paths {
+		granted, err := a.store.Effective(ctx, path, principals)
+		if err != nil {
+			return err
+		}
+		if missing := perm &^ granted; missing != 0 {
+			logger.GetLoggerFromContext(ctx).Info(ctx, "Access denied",
+				zap.String("path", path), zap.Stringer("missing", missing))
+			return fmt.Errorf("%w: %s may not %s %s", ErrPermissionDenied, describeCaller(subject), missing, path)
+		}
+	}
+	return nil
+}
+
+// Allowed reports whether the caller of ctx has perm on path. Other errors
+// than a denial are returned.
+func (a *ACL) Allowed(ctx context.Context, perm repository.Permission, path string) (bool, error) {
+	err := a.Check(ctx, perm, path)
+	if errors.Is(err, ErrPermissionDenied) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// checkAdmin fails unless the caller of ctx is an admin.
+func (a *ACL) checkAdmin(ctx context.Context) error {
+	if a == nil {
+		return ErrACLDisabled
+	}
+
+	subject, principals := callerPrincipals(ctx)
+	if !a.isAdmin(principals) {
+		return fmt.Errorf("%w: %s is not an admin", ErrPermissionDenied, describeCaller(subject))
+	}
+	return nil
+}
+
+func describeCaller(subject string) string {
+	if subject == "" {
+		return "anonymous caller"
+	}
+	return subject
+}
+
+type ACLService struct {
+	acl *ACL
+}
+
+// NewACLService creates the grant management service. acl may be nil, then
+// every request fails with ErrACLDisabled.
+func NewACLService(acl *ACL) *ACLService {
+	return &ACLService{acl: acl}
+}
+
+func toProtoGrant(entry repository.ACLEntry) *fmpb.Grant {
+	return &fmpb.Grant{
+		Path:        entry.Path,
+		Principal:   entry.Principal,
+		Role:        entry.Role,
+		Permissions: entry.Permissions.Names(),
+	}
+}
+
+func (srv *ACLService) ListGrants(ctx context.Context, req *fmpb.ListGrantsRequest) (*fmpb.ListGrantsResponse, error) {
+	lg := logger.GetLoggerFromContext(ctx)
+
+	lg.Info(ctx, "ListGrants is in process")
+	if err := srv.acl.checkAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	entries, err := srv.acl.store.List(ctx, req.Path)
+	if err != nil {
+		lg.Error(ctx, "Error to list grants", zap.String("path", req.Path), zap.Error(err))
+		return nil, err
+	}
+
+	res := &fmpb.ListGrantsResponse{}
+	for _, entry := range entries {
+		res.Grants = append(res.Grants, toProtoGrant(entry))
+	}
+	return res, nil
+}
+
+func (srv *ACLService) SetGrant(ctx context.Context, req *fmpb.Grant) (*fmpb.Grant, error) {
+	lg := logger.GetLoggerFromContext(ctx)
+
+	lg.Info(ctx, "SetGrant is in process")
+	if err := srv.acl.checkAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	perm, err := repository.ParsePermissions(strings.Join(req.Permissions, ","))
+	if err != nil {
+		return nil, err
+	}
+
+	entry, err := srv.acl.store.Set(ctx, repository.ACLEntry{
+		Path:        req.Path,
+		Principal:   req.Principal,
+		Role:        req.Role,
+		Permissions: perm,
+	})
+	if err != nil {
+		lg.Error(ctx, "Error to set grant", zap.String("path", req.Path), zap.Error(err))
+		return nil, err
+	}
+
+	return toProtoGrant(entry), nil
+}
+
+func (srv *ACLService) RemoveGrant(ctx context.Context, req *fmpb.RemoveGrantRequest) error {
+	lg := logger.GetLoggerFromContext(ctx)
+
+	lg.Info(ctx, "RemoveGrant is in process")
+	if err := srv.acl.checkAdmin(ctx); err != nil {
+		return err
+	}
+
+	if err := srv.acl.store.Remove(ctx, req.Path, req.Principal); err != nil {
+		lg.Error(ctx, "Error to remove grant", zap.String("path", req.Path), zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// GetAccess returns the effective permissions of the caller, or of another
+// identity for admins, on a path.
+func (srv *ACLService) GetAccess(ctx context.Context, req *fmpb.GetAccessRequest) (*fmpb.Access, error) {
+	lg := logger.GetLoggerFromContext(ctx)
+
+	lg.Info(ctx, "GetAccess is in process")
+	if srv.acl == nil {
+		return nil, ErrACLDisabled
+	}
+	if err := checkPath(req.Path); err != nil {
+		return nil, err
+	}
+
+	subject, principals := callerPrincipals(ctx)
+	if req.Subject != "" || len(req.Groups) > 0 {
+		if err := srv.acl.checkAdmin(ctx); err != nil {
+			return nil, err
+		}
+		subject, principals = req.Subject, repository.Principals(req.Subject, req.Groups)
+	}
+
+	res := &fmpb.Access{Path: req.Path, Subject: subject, Admin: srv.acl.isAdmin(principals)}
+	if res.Admin {
+		res.Permissions = repository.PermAll.Names()
+		return res, nil
+	}
+
+	granted, err := srv.acl.store.Effective(ctx, req.Path, principals)
+	if err != nil {
+		lg.Error(ctx, "Error to get access", zap.String("path", req.Path), zap.Error(err))
+		return nil, err
+	}
+	res.Permissions = granted.Names()
+	return res, nil
+}
+
+func (srv *ACLService) ListRoles(ctx context.Context, _ *fmpb.ListRolesRequest) (*fmpb.ListRolesResponse, error) {
+	if srv.acl == nil {
+		return nil, ErrACLDisabled
+	}
+
+	res := &fmpb.ListRolesResponse{}
+	for name, perm := range srv.acl.store.Roles() {
+		res.Roles = append(res.Roles, &fmpb.Role{Name: name, Permissions: perm.Names()})
+	}
+	sort.Slice(res.Roles, func(i, j int) bool { return res.Roles[i].Name < res.Roles[j].Name })
+	return res, nil
+}
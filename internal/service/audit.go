@@ -0,0 +1,126 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/JunBSer/FileManager/internal/repository"
+	"github.com/JunBSer/FileManager/pkg/api/fmpb"
+	"github.com/JunBSer/FileManager/pkg/logger"
+	"go.uber.org/zap"
+	"time"
+)
+
+// defaultAuditLimit is the number of records a query returns if it sets no
+// limit.
+const defaultAuditLimit = 1000
+
+var ErrAuditDisabled = errors.New("audit log is disabled")
+
+type AuditService struct {
+	log *repository.AuditLog
+	acl *ACL
+}
+
+// NewAuditService creates the audit service. Auditing is disabled if log is
+// nil.
+func NewAuditService(log *repository.AuditLog, acl *ACL) *AuditService {
+	return &AuditService{log: log, acl: acl}
+}
+
+// Enabled reports whether calls are recorded.
+func (srv *AuditService) Enabled() bool {
+	return srv.log != nil
+}
+
+// Record appends rec to the audit log. The call rec is about has been made
+// already, so a failure is only logged.
+func (srv *AuditService) Record(ctx context.Context, rec repository.AuditRecord) {
+	if srv.log == nil {
+		return
+	}
+	if _, err := srv.log.Append(rec); err != nil {
+		logger.GetLoggerFromContext(ctx).Error(ctx, "Error writing audit record", zap.String("operation", rec.Operation), zap.String("path", rec.Path), zap.Error(err))
+	}
+}
+
+func (srv *AuditService) checkEnabled(ctx context.Context) error {
+	if srv.log == nil {
+		return ErrAuditDisabled
+	}
+	if srv.acl == nil {
+		return nil
+	}
+	return srv.acl.checkAdmin(ctx)
+}
+
+func toProtoAuditRecord(rec repository.AuditRecord) *fmpb.AuditRecord {
+	return &fmpb.AuditRecord{
+		Seq:         rec.Seq,
+		Time:        rec.Time.Unix(),
+		Caller:      rec.Caller,
+		AuthMethod:  rec.AuthMethod,
+		Remote:      rec.Remote,
+		Operation:   rec.Operation,
+		Path:        rec.Path,
+		Destination: rec.Destination,
+		Bytes:       rec.Bytes,
+		Status:      rec.Status,
+		Error:       rec.Error,
+		DurationMs:  rec.Duration.Milliseconds(),
+		PrevHash:    rec.Prev,
+		Hash:        rec.Hash,
+	}
+}
+
+func (srv *AuditService) QueryAudit(ctx context.Context, req *fmpb.QueryAuditRequest) (*fmpb.QueryAuditResponse, error) {
+	lg := logger.GetLoggerFromContext(ctx)
+
+	lg.Info(ctx, "Audit query is in process", zap.String("path_prefix", req.PathPrefix), zap.String("user", req.User))
+	if err := srv.checkEnabled(ctx); err != nil {
+		return nil, err
+	}
+
+	filter := repository.AuditFilter{PathPrefix: req.PathPrefix, Caller: req.User, Limit: int(req.Limit)}
+	if req.Since > 0 {
+		filter.Since = time.Unix(req.Since, 0)
+	}
+	if req.Until > 0 {
+		// The whole second of until is included.
+		filter.Until = time.Unix(req.Until+1, 0).Add(-time.Nanosecond)
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = defaultAuditLimit
+	}
+
+	records, err := srv.log.Query(filter)
+	if err != nil {
+		lg.Error(ctx, "Error to query audit log", zap.Error(err))
+		return nil, err
+	}
+
+	res := &fmpb.QueryAuditResponse{Records: make([]*fmpb.AuditRecord, 0, len(records))}
+	for _, rec := range records {
+		res.Records = append(res.Records, toProtoAuditRecord(rec))
+	}
+	return res, nil
+}
+
+func (srv *AuditService) VerifyAudit(ctx context.Context, req *fmpb.VerifyAuditRequest) (*fmpb.VerifyAuditResponse, error) {
+	lg := logger.GetLoggerFromContext(ctx)
+
+	lg.Info(ctx, "Audit verification is in process")
+	if err := srv.checkEnabled(ctx); err != nil {
+		return nil, err
+	}
+
+	n, err := srv.log.Verify()
+	if errors.Is(err, repository.ErrAuditChainBroken) {
+		lg.Error(ctx, "Audit log chain is broken", zap.Uint64("records", n), zap.Error(err))
+		return &fmpb.VerifyAuditResponse{Records: n, Error: err.Error()}, nil
+	}
+	if err != nil {
+		lg.Error(ctx, "Error to verify audit log", zap.Error(err))
+		return nil, err
+	}
+	return &fmpb.VerifyAuditResponse{Valid: true, Records: n}, nil
+}
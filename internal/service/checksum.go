@@ -0,0 +1,101 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/JunBSer/FileManager/internal/repository"
+	"github.com/JunBSer/FileManager/pkg/api/fmpb"
+	"google.golang.org/grpc/metadata"
+	"hash"
+	"hash/crc32"
+	"strconv"
+)
+
+var ErrInvalidChecksum = errors.New("invalid checksum")
+
+var castagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+// CRC32C returns the CRC32C (Castagnoli) of data.
+func CRC32C(data []byte) uint32 {
+	return crc32.Checksum(data, castagnoli)
+}
+
+// contentDigest hashes the content of a file while it is written or read.
+type contentDigest struct {
+	sha256 hash.Hash
+	crc32c hash.Hash32
+}
+
+func newContentDigest() *contentDigest {
+	return &contentDigest{sha256: sha256.New(), crc32c: crc32.New(castagnoli)}
+}
+
+func (d *contentDigest) Write(p []byte) (int, error) {
+	d.sha256.Write(p)
+	d.crc32c.Write(p)
+	return len(p), nil
+}
+
+func (d *contentDigest) SHA256() string {
+	return hex.EncodeToString(d.sha256.Sum(nil))
+}
+
+// expectedChecksums holds the checksums a client sent for an upload. Empty
+// fields are not checked.
+type expectedChecksums struct {
+	sha256 string
+	crc32c string
+}
+
+// parseSHA256 validates a hex SHA-256 and returns it in lowercase.
+func parseSHA256(raw string) (string, error) {
+	sum, err := hex.DecodeString(raw)
+	if err != nil || len(sum) != sha256.Size {
+		return "", fmt.Errorf("%w: sha256 %q", ErrInvalidChecksum, raw)
+	}
+	return hex.EncodeToString(sum), nil
+}
+
+// checksumsFromContext reads the expected checksums from the request metadata
+// of ctx.
+func checksumsFromContext(ctx context.Context) (expectedChecksums, error) {
+	var want expectedChecksums
+
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return want, nil
+	}
+
+	if values := md.Get(fmpb.SHA256MetadataKey); len(values) > 0 {
+		sum, err := parseSHA256(values[0])
+		if err != nil {
+			return want, err
+		}
+		want.sha256 = sum
+	}
+
+	if values := md.Get(fmpb.CRC32CMetadataKey); len(values) > 0 {
+		crc, err := strconv.ParseUint(values[0], 16, 32)
+		if err != nil {
+			return want, fmt.Errorf("%w: crc32c %q", ErrInvalidChecksum, values[0])
+		}
+		want.crc32c = fmt.Sprintf("%08x", crc)
+	}
+
+	return want, nil
+}
+
+// verify compares the checksums of the received content with the expected
+// ones.
+func (want expectedChecksums) verify(got *contentDigest) error {
+	if want.sha256 != "" && want.sha256 != got.SHA256() {
+		return fmt.Errorf("%w: sha256 expected %s, got %s", repository.ErrChecksumMismatch, want.sha256, got.SHA256())
+	}
+	if crc := fmt.Sprintf("%08x", got.crc32c.Sum32()); want.crc32c != "" && want.crc32c != crc {
+		return fmt.Errorf("%w: crc32c expected %s, got %s", repository.ErrChecksumMismatch, want.crc32c, crc)
+	}
+	return nil
+}
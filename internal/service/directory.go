@@ -0,0 +1,115 @@
+package service
+
+import (
+	"context"
+	"github.com/JunBSer/FileManager/internal/repository"
+	"github.com/JunBSer/FileManager/pkg/api/fmpb"
+	"github.com/JunBSer/FileManager/pkg/logger"
+	"go.uber.org/zap"
+)
+
+func (srv *FileService) CreateDirectory(ctx context.Context, req *fmpb.CreateDirectoryRequest) error {
+	lg := logger.GetLoggerFromContext(ctx)
+
+	lg.Info(ctx, "CreateDirectory is in process")
+	if err := checkPath(req.Path); err != nil {
+		return err
+	}
+	if err := srv.acl.Check(ctx, repository.PermWrite, req.Path); err != nil {
+		return err
+	}
+
+	if err := srv.tree.CreateDirectory(ctx, req.Path); err != nil {
+		lg.Error(ctx, "Error to create directory", zap.String("path", req.Path), zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// DeleteDirectory deletes a directory tree. Files go the same way as single
+// deletes, so they end up in the trash when it is enabled.
+func (srv *FileService) DeleteDirectory(
+	ctx context.Context,
+	req *fmpb.DeleteDirectoryRequest,
+	progress func(repository.TreeResult) error) (repository.TreeSummary, error) {
+
+	lg := logger.GetLoggerFromContext(ctx)
+
+	lg.Info(ctx, "DeleteDirectory is in process")
+	if err := checkPath(req.Path); err != nil {
+		return repository.TreeSummary{}, err
+	}
+	if err := srv.acl.Check(ctx, repository.PermDelete, req.Path); err != nil {
+		return repository.TreeSummary{}, err
+	}
+
+	summary, err := srv.tree.DeleteDirectory(ctx, req.Path, repository.TreeOptions{
+		Recursive:  req.Recursive,
+		RemoveFile: srv.deleteFile,
+		Progress:   progress,
+	})
+	if err != nil {
+		lg.Error(ctx, "Error to delete directory", zap.String("path", req.Path), zap.Error(err))
+	}
+	return summary, err
+}
+
+func (srv *FileService) CopyPath(
+	ctx context.Context,
+	req *fmpb.TreeRequest,
+	progress func(repository.TreeResult) error) (repository.TreeSummary, error) {
+
+	lg := logger.GetLoggerFromContext(ctx)
+
+	lg.Info(ctx, "CopyPath is in process")
+	if err := checkPath(req.Source, req.Destination); err != nil {
+		return repository.TreeSummary{}, err
+	}
+	if err := srv.acl.Check(ctx, repository.PermRead, req.Source); err != nil {
+		return repository.TreeSummary{}, err
+	}
+	if err := srv.acl.Check(ctx, repository.PermWrite, req.Destination); err != nil {
+		return repository.TreeSummary{}, err
+	}
+
+	summary, err := srv.tree.Copy(ctx, req.Source, req.Destination, repository.TreeOptions{
+		Recursive: req.Recursive,
+		Overwrite: req.Overwrite,
+		BeforeReplace: func(ctx context.Context, path string) error {
+			return srv.saveVersion(ctx, path, "copy")
+		},
+		Progress: progress,
+	})
+	if err != nil {
+		lg.Error(ctx, "Error to copy path", zap.String("source", req.Source), zap.Error(err))
+	}
+	return summary, err
+}
+
+func (srv *FileService) MovePath(
+	ctx context.Context,
+	req *fmpb.TreeRequest,
+	progress func(repository.TreeResult) error) (repository.TreeSummary, error) {
+
+	lg := logger.GetLoggerFromContext(ctx)
+
+	lg.Info(ctx, "MovePath is in process")
+	if err := checkPath(req.Source, req.Destination); err != nil {
+		return repository.TreeSummary{}, err
+	}
+	if err := srv.checkMove(ctx, req.Source, req.Destination); err != nil {
+		return repository.TreeSummary{}, err
+	}
+
+	summary, err := srv.tree.Move(ctx, req.Source, req.Destination, repository.TreeOptions{
+		Overwrite: req.Overwrite,
+		BeforeReplace: func(ctx context.Context, path string) error {
+			return srv.saveVersion(ctx, path, "move")
+		},
+		Progress: progress,
+	})
+	if err != nil {
+		lg.Error(ctx, "Error to move path", zap.String("source", req.Source), zap.Error(err))
+	}
+	return summary, err
+}
@@ -2,19 +2,69 @@ package service
 
 import (
 	"context"
+	"fmt"
 	"github.com/JunBSer/FileManager/internal/repository"
+	"github.com/JunBSer/FileManager/pkg/api/fmpb"
 	"github.com/JunBSer/FileManager/pkg/logger"
 	"github.com/JunBSer/proto_fileManager/pkg/api/proto"
 	"go.uber.org/zap"
+	"google.golang.org/grpc/metadata"
 	"io"
+	"os"
+	"path/filepath"
 )
 
 type FileService struct {
-	repo repository.FileRepository
+	repo     repository.FileRepository
+	versions *repository.VersionStore
+	trash    *repository.Trash
+	tree     *repository.Tree
+	digests  *repository.DigestStore
+	acl      *ACL
 }
 
-func New(repo repository.FileRepository) *FileService {
-	return &FileService{repo: repo}
+// New creates the file service. versions may be nil, then no previous
+// contents are kept. trash may be nil, then deleted files are not kept
+// for restore. acl may be nil, then every caller may access every path.
+func New(repo repository.FileRepository, versions *repository.VersionStore, trash *repository.Trash, acl *ACL) *FileService {
+	return &FileService{
+		repo:     repo,
+		versions: versions,
+		trash:    trash,
+		tree:     repository.NewTree(repo),
+		digests:  repository.NewDigestStore(repo),
+		acl:      acl,
+	}
+}
+
+// checkPath rejects paths that point into the service's own data.
+func checkPath(paths ...string) error {
+	for _, path := range paths {
+		if repository.IsSystemPath(path) {
+			return fmt.Errorf("path %q is reserved", path)
+		}
+	}
+	return nil
+}
+
+// checkMove checks that the caller of ctx may take src away and put it at
+// dst.
+func (srv *FileService) checkMove(ctx context.Context, src, dst string) error {
+	if err := srv.acl.Check(ctx, repository.PermRead|repository.PermDelete, src); err != nil {
+		return err
+	}
+	return srv.acl.Check(ctx, repository.PermWrite, dst)
+}
+
+// saveVersion keeps the current content of path before an operation of kind
+// reason replaces it.
+func (srv *FileService) saveVersion(ctx context.Context, path, reason string) error {
+	if srv.versions == nil {
+		return nil
+	}
+
+	_, err := srv.versions.Save(ctx, path, reason)
+	return err
 }
 
 func (srv *FileService) ProcessUpload(
@@ -22,7 +72,8 @@ func (srv *FileService) ProcessUpload(
 	stream proto.FileService_UploadServer,
 	file repository.FileHandle,
 	lg logger.Logger,
-	pos int64) error {
+	pos int64,
+	digest io.Writer) error {
 
 	for {
 		data, err := stream.Recv()
@@ -40,11 +91,12 @@ func (srv *FileService) ProcessUpload(
 			lg.Error(ctx, "Error to append data", zap.Error(err))
 			return err
 		}
+		digest.Write(data.Content)
 		pos += n
 	}
 }
 
-func (srv *FileService) ProcessDownload(file repository.FileHandle, stream proto.FileService_DownloadServer, fileName string) error {
+func (srv *FileService) ProcessDownload(file io.Reader, stream proto.FileService_DownloadServer, fileName string) error {
 	bufLen := srv.repo.GetReadSize()
 	for {
 		buf := make([]byte, bufLen)
@@ -63,6 +115,66 @@ func (srv *FileService) ProcessDownload(file repository.FileHandle, stream proto
 	}
 }
 
+// commitUpload streams the rest of the upload into the temp file and renames it
+// over fileName once the client has closed the stream and the content matches
+// the checksums in want. The replaced content is kept as a version of kind
+// reason. If anything fails the temp file is dropped and the previous version
+// of the file stays untouched.
+func (srv *FileService) commitUpload(
+	ctx context.Context,
+	stream proto.FileService_UploadServer,
+	file repository.FileHandle,
+	lg logger.Logger,
+	fileName string,
+	pos int64,
+	reason string,
+	digest *contentDigest,
+	want expectedChecksums) error {
+
+	err := srv.ProcessUpload(ctx, stream, file, lg, pos, digest)
+	if err == nil {
+		err = want.verify(digest)
+	}
+	if err == nil {
+		err = srv.saveVersion(ctx, fileName, reason)
+	}
+	if err == nil {
+		err = srv.repo.CommitTempFile(ctx, file, fileName)
+	}
+
+	if err != nil {
+		lg.Error(ctx, "Error to commit file", zap.String("fileName", fileName), zap.Error(err))
+		srv.discardTemp(ctx, file, lg)
+		return err
+	}
+
+	if err = srv.digests.Put(ctx, fileName, digest.SHA256()); err != nil {
+		lg.Error(ctx, "Error to store digest", zap.String("fileName", fileName), zap.Error(err))
+	}
+	return nil
+}
+
+func (srv *FileService) discardTemp(ctx context.Context, file repository.FileHandle, lg logger.Logger) {
+	if err := srv.repo.DiscardTempFile(ctx, file); err != nil {
+		lg.Error(ctx, "Error to discard temp file", zap.Error(err))
+	}
+}
+
+// copyCurrent copies the committed contents of fileName into file and returns
+// the number of bytes copied. A missing file is treated as empty.
+func (srv *FileService) copyCurrent(ctx context.Context, fileName string, file io.Writer) (int64, error) {
+	src, err := srv.repo.GetFileHandle(ctx, fileName, repository.Read)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	defer src.Close()
+
+	return io.Copy(file, src)
+}
+
 func (srv *FileService) Upload(stream proto.FileService_UploadServer) error {
 	ctx := stream.Context()
 	lg := logger.GetLoggerFromContext(ctx)
@@ -75,21 +187,33 @@ func (srv *FileService) Upload(stream proto.FileService_UploadServer) error {
 		return err
 	}
 
-	file, err := srv.repo.GetFileHandle(ctx, data.FileName, repository.CreateAndW)
+	if err = checkPath(data.FileName); err != nil {
+		return err
+	}
+	if err = srv.acl.Check(ctx, repository.PermWrite, data.FileName); err != nil {
+		return err
+	}
+	want, err := checksumsFromContext(ctx)
 	if err != nil {
-		lg.Error(ctx, "Error to open file", zap.Error(err))
 		return err
 	}
 
-	defer file.Close()
+	file, err := srv.repo.CreateTempFile(ctx, data.FileName)
+	if err != nil {
+		lg.Error(ctx, "Error to open file", zap.Error(err))
+		return err
+	}
 
 	pos, err := srv.repo.AppendData(ctx, file, data.Content, 0)
 	if err != nil {
 		lg.Error(ctx, "Error to append data", zap.Error(err))
+		srv.discardTemp(ctx, file, lg)
 		return err
 	}
 
-	return srv.ProcessUpload(ctx, stream, file, lg, pos)
+	digest := newContentDigest()
+	digest.Write(data.Content)
+	return srv.commitUpload(ctx, stream, file, lg, data.FileName, pos, "upload", digest, want)
 }
 
 func (srv *FileService) Append(stream proto.FileService_AppendServer) error {
@@ -104,27 +228,40 @@ func (srv *FileService) Append(stream proto.FileService_AppendServer) error {
 		return err
 	}
 
-	file, err := srv.repo.GetFileHandle(ctx, data.FileName, repository.Write)
+	if err = checkPath(data.FileName); err != nil {
+		return err
+	}
+	if err = srv.acl.Check(ctx, repository.PermWrite, data.FileName); err != nil {
+		return err
+	}
+	want, err := checksumsFromContext(ctx)
 	if err != nil {
-		lg.Error(ctx, "Error to open file", zap.Error(err))
 		return err
 	}
 
-	defer file.Close()
+	file, err := srv.repo.CreateTempFile(ctx, data.FileName)
+	if err != nil {
+		lg.Error(ctx, "Error to open file", zap.Error(err))
+		return err
+	}
 
-	_, err = srv.repo.AppendData(ctx, file, data.Content, 0)
+	digest := newContentDigest()
+	pos, err := srv.copyCurrent(ctx, data.FileName, io.MultiWriter(file, digest))
 	if err != nil {
-		lg.Error(ctx, "Error to append data", zap.Error(err))
+		lg.Error(ctx, "Error to copy current file", zap.Error(err))
+		srv.discardTemp(ctx, file, lg)
 		return err
 	}
 
-	info, err := file.Stat()
+	n, err := srv.repo.AppendData(ctx, file, data.Content, pos)
 	if err != nil {
-		lg.Error(ctx, "Error to get file info", zap.Error(err))
+		lg.Error(ctx, "Error to append data", zap.Error(err))
+		srv.discardTemp(ctx, file, lg)
 		return err
 	}
 
-	return srv.ProcessUpload(ctx, stream, file, lg, info.Size())
+	digest.Write(data.Content)
+	return srv.commitUpload(ctx, stream, file, lg, data.FileName, pos+n, "append", digest, want)
 }
 
 func (srv *FileService) Overwrite(stream proto.FileService_OverwriteFileServer) error {
@@ -139,21 +276,65 @@ func (srv *FileService) Overwrite(stream proto.FileService_OverwriteFileServer)
 		return err
 	}
 
-	file, err := srv.repo.GetFileHandle(ctx, data.FileName, repository.Write)
+	if err = checkPath(data.FileName); err != nil {
+		return err
+	}
+	if err = srv.acl.Check(ctx, repository.PermWrite, data.FileName); err != nil {
+		return err
+	}
+	want, err := checksumsFromContext(ctx)
 	if err != nil {
-		lg.Error(ctx, "Error to open file", zap.Error(err))
 		return err
 	}
 
-	defer file.Close()
+	file, err := srv.repo.CreateTempFile(ctx, data.FileName)
+	if err != nil {
+		lg.Error(ctx, "Error to open file", zap.Error(err))
+		return err
+	}
 
 	pos, err := srv.repo.AppendData(ctx, file, data.Content, 0)
 	if err != nil {
 		lg.Error(ctx, "Error to append data", zap.Error(err))
+		srv.discardTemp(ctx, file, lg)
 		return err
 	}
 
-	return srv.ProcessUpload(ctx, stream, file, lg, pos)
+	digest := newContentDigest()
+	digest.Write(data.Content)
+	return srv.commitUpload(ctx, stream, file, lg, data.FileName, pos, "overwrite", digest, want)
+}
+
+// sendFile streams file to the client. The stored SHA-256 of the file goes
+// into the header when it is known, and the SHA-256 the client should get into
+// the trailer.
+func (srv *FileService) sendFile(ctx context.Context, file io.Reader, stream proto.FileService_DownloadServer, fileName string) error {
+	lg := logger.GetLoggerFromContext(ctx)
+
+	stored, err := srv.digests.Get(ctx, fileName)
+	if err != nil {
+		lg.Error(ctx, "Error to get digest", zap.String("fileName", fileName), zap.Error(err))
+	}
+	if stored != "" {
+		if err = stream.SetHeader(metadata.Pairs(fmpb.SHA256MetadataKey, stored)); err != nil {
+			lg.Error(ctx, "Error to set header", zap.Error(err))
+		}
+	}
+
+	digest := newContentDigest()
+	if err = srv.ProcessDownload(io.TeeReader(file, digest), stream, fileName); err != nil {
+		lg.Error(ctx, "Error to send file", zap.String("fileName", fileName), zap.Error(err))
+		return err
+	}
+
+	sum := digest.SHA256()
+	if stored != "" && stored != sum {
+		lg.Error(ctx, "Stored file does not match its digest",
+			zap.String("fileName", fileName), zap.String("stored", stored), zap.String("read", sum))
+		sum = stored
+	}
+	stream.SetTrailer(metadata.Pairs(fmpb.SHA256MetadataKey, sum))
+	return nil
 }
 
 func (srv *FileService) Download(req *proto.FileRequest, stream proto.FileService_DownloadServer) error {
@@ -163,6 +344,16 @@ func (srv *FileService) Download(req *proto.FileRequest, stream proto.FileServic
 	lg.Info(ctx, "Download is in process")
 
 	fileName := req.FileName
+	if err := checkPath(fileName); err != nil {
+		return err
+	}
+	if err := srv.acl.Check(ctx, repository.PermRead, fileName); err != nil {
+		return err
+	}
+	if err := srv.checkShareSize(ctx, fileName); err != nil {
+		return err
+	}
+
 	file, err := srv.repo.GetFileHandle(ctx, fileName, repository.Read)
 	if err != nil {
 		lg.Error(ctx, "Error to open file", zap.Error(err))
@@ -171,13 +362,7 @@ func (srv *FileService) Download(req *proto.FileRequest, stream proto.FileServic
 
 	defer file.Close()
 
-	err = srv.ProcessDownload(file, stream, req.FileName)
-	if err != nil {
-		lg.Error(ctx, "Error to download file", zap.Error(err))
-		return err
-	}
-
-	return nil
+	return srv.sendFile(ctx, file, stream, req.FileName)
 }
 
 func (srv *FileService) Read(req *proto.FileRequest, stream proto.FileService_ReadServer) error {
@@ -187,6 +372,12 @@ func (srv *FileService) Read(req *proto.FileRequest, stream proto.FileService_Re
 	lg.Info(ctx, "Read is in process")
 
 	fileName := req.FileName
+	if err := checkPath(fileName); err != nil {
+		return err
+	}
+	if err := srv.acl.Check(ctx, repository.PermRead, fileName); err != nil {
+		return err
+	}
 
 	file, err := srv.repo.GetFileHandle(ctx, fileName, repository.Read)
 	if err != nil {
@@ -195,13 +386,7 @@ func (srv *FileService) Read(req *proto.FileRequest, stream proto.FileService_Re
 	}
 	defer file.Close()
 
-	err = srv.ProcessDownload(file, stream, req.FileName)
-	if err != nil {
-		lg.Error(ctx, "Error to read file", zap.Error(err))
-		return err
-	}
-
-	return nil
+	return srv.sendFile(ctx, file, stream, req.FileName)
 }
 
 func (srv *FileService) Delete(ctx context.Context, req *proto.FileRequest) error {
@@ -209,8 +394,14 @@ func (srv *FileService) Delete(ctx context.Context, req *proto.FileRequest) erro
 
 	lg.Info(ctx, "Delete is in process")
 	fileName := req.FileName
+	if err := checkPath(fileName); err != nil {
+		return err
+	}
+	if err := srv.acl.Check(ctx, repository.PermDelete, fileName); err != nil {
+		return err
+	}
 
-	err := srv.repo.DeleteFile(ctx, fileName)
+	err := srv.deleteFile(ctx, fileName)
 	if err != nil {
 		lg.Error(ctx, "Error to delete file", zap.Error(err))
 		return err
@@ -218,11 +409,44 @@ func (srv *FileService) Delete(ctx context.Context, req *proto.FileRequest) erro
 	return nil
 }
 
+// deleteFile moves path to the trash or into its history when they are
+// enabled, and deletes it otherwise.
+func (srv *FileService) deleteFile(ctx context.Context, path string) error {
+	var err error
+	switch {
+	case srv.trash != nil:
+		_, err = srv.trash.Move(ctx, path)
+	case srv.versions != nil:
+		_, err = srv.versions.Retire(ctx, path, "delete")
+	default:
+		err = srv.repo.DeleteFile(ctx, path)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err = srv.digests.Forget(ctx, path); err != nil {
+		logger.GetLoggerFromContext(ctx).Error(ctx, "Error to forget digest", zap.String("path", path), zap.Error(err))
+	}
+	return nil
+}
+
 func (srv *FileService) MoveFile(ctx context.Context, req *proto.OperationRequest) error {
 	lg := logger.GetLoggerFromContext(ctx)
 
 	lg.Info(ctx, "MoveFile is in process")
 	destPath, srcPath := req.Destination, req.Source
+	if err := checkPath(srcPath, destPath); err != nil {
+		return err
+	}
+	if err := srv.checkMove(ctx, srcPath, destPath); err != nil {
+		return err
+	}
+
+	if err := srv.saveVersion(ctx, destPath, "move"); err != nil {
+		lg.Error(ctx, "Error to save replaced file", zap.Error(err))
+		return err
+	}
 
 	err := srv.repo.MoveFile(ctx, srcPath, destPath)
 	if err != nil {
@@ -230,6 +454,9 @@ func (srv *FileService) MoveFile(ctx context.Context, req *proto.OperationReques
 		return err
 	}
 
+	if err = srv.digests.Move(ctx, srcPath, destPath); err != nil {
+		lg.Error(ctx, "Error to move digest", zap.Error(err))
+	}
 	return nil
 }
 
@@ -237,6 +464,13 @@ func (srv *FileService) ListDirectory(ctx context.Context, r *proto.DirectoryReq
 	lg := logger.GetLoggerFromContext(ctx)
 
 	lg.Info(ctx, "ListDirectory is in process")
+	if err := checkPath(r.Path); err != nil {
+		return nil, err
+	}
+	if err := srv.acl.Check(ctx, repository.PermList, r.Path); err != nil {
+		return nil, err
+	}
+
 	res, err := srv.repo.ListDir(ctx, r.Path)
 	if err != nil {
 		lg.Error(ctx, "Error to list dir")
@@ -245,6 +479,9 @@ func (srv *FileService) ListDirectory(ctx context.Context, r *proto.DirectoryReq
 
 	var protoRes []*proto.DirectoryEntry
 	for _, entry := range res {
+		if checkPath(filepath.Join(r.Path, entry.Name)) != nil {
+			continue
+		}
 		protoRes = append(protoRes, &proto.DirectoryEntry{Name: entry.Name, IsDir: entry.IsDir})
 	}
 
@@ -2,16 +2,33 @@ package service
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"github.com/JunBSer/FileManager/internal/auth"
 	"github.com/JunBSer/FileManager/internal/repository"
 	"github.com/JunBSer/FileManager/mocks"
+	"github.com/JunBSer/FileManager/pkg/api/fmpb"
 	"github.com/JunBSer/FileManager/pkg/logger"
 	"github.com/JunBSer/proto_fileManager/pkg/api/proto"
 	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"google.golang.org/grpc/metadata"
 	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
 func TestFileService_Upload(t *testing.T) {
@@ -23,14 +40,14 @@ func TestFileService_Upload(t *testing.T) {
 	ctx = context.WithValue(ctx, logger.Key, lg)
 
 	mockRepo := mocks.NewMockFileRepository(ctrl)
-	svc := New(mockRepo)
+	svc := New(mockRepo, nil, nil, nil)
 
 	t.Run("successful upload", func(t *testing.T) {
 		mockStream := mocks.NewMockUploadStream(ctx)
 		fileMock := mocks.NewMockFileHandle(ctrl)
 
 		mockRepo.EXPECT().
-			GetFileHandle(gomock.Any(), "test.txt", repository.CreateAndW).
+			CreateTempFile(gomock.Any(), "test.txt").
 			Return(fileMock, nil).
 			Times(1)
 
@@ -39,11 +56,16 @@ func TestFileService_Upload(t *testing.T) {
 			Return(int64(6), nil).
 			Times(1)
 
-		fileMock.EXPECT().
-			Close().
+		mockRepo.EXPECT().
+			CommitTempFile(gomock.Any(), fileMock, "test.txt").
 			Return(nil).
 			Times(1)
 
+		mockRepo.EXPECT().
+			Stat(gomock.Any(), "test.txt").
+			Return(nil, os.ErrNotExist).
+			Times(1)
+
 		mockStream.On("Recv").Return(&proto.FileChunk{
 			FileName: "test.txt",
 			Content:  []byte("chunk1"),
@@ -60,7 +82,7 @@ func TestFileService_Upload(t *testing.T) {
 		mockStream := mocks.NewMockUploadStream(ctx)
 
 		mockRepo.EXPECT().
-			GetFileHandle(gomock.Any(), "error.txt", repository.CreateAndW).
+			CreateTempFile(gomock.Any(), "error.txt").
 			Return(nil, errors.New("permission denied")).
 			Times(1)
 
@@ -74,6 +96,125 @@ func TestFileService_Upload(t *testing.T) {
 
 		mockStream.AssertExpectations(t)
 	})
+
+	t.Run("broken stream keeps previous version", func(t *testing.T) {
+		mockStream := mocks.NewMockUploadStream(ctx)
+		fileMock := mocks.NewMockFileHandle(ctrl)
+
+		mockRepo.EXPECT().
+			CreateTempFile(gomock.Any(), "test.txt").
+			Return(fileMock, nil).
+			Times(1)
+
+		mockRepo.EXPECT().
+			AppendData(gomock.Any(), fileMock, []byte("chunk1"), int64(0)).
+			Return(int64(6), nil).
+			Times(1)
+
+		mockRepo.EXPECT().
+			DiscardTempFile(gomock.Any(), fileMock).
+			Return(nil).
+			Times(1)
+
+		mockStream.On("Recv").Return(&proto.FileChunk{
+			FileName: "test.txt",
+			Content:  []byte("chunk1"),
+		}, nil).Once()
+		mockStream.On("Recv").Return((*proto.FileChunk)(nil), errors.New("context canceled")).Once()
+
+		err := svc.Upload(mockStream)
+
+		assert.Error(t, err)
+		mockStream.AssertExpectations(t)
+	})
+}
+
+func TestFileService_UploadChecksum(t *testing.T) {
+	const helloSHA256 = "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"
+
+	lg := logger.New("test_service", "debug")
+	ctx := context.WithValue(context.Background(), logger.Key, lg)
+
+	repo := repository.NewMemory(2048)
+	svc := New(repo, nil, nil, nil)
+
+	upload := func(md metadata.MD) error {
+		stream := mocks.NewMockUploadStream(metadata.NewIncomingContext(ctx, md))
+		stream.On("Recv").Return(&proto.FileChunk{FileName: "hello.txt", Content: []byte("hel")}, nil).Once()
+		stream.On("Recv").Return(&proto.FileChunk{FileName: "hello.txt", Content: []byte("lo")}, nil).Once()
+		stream.On("Recv").Return((*proto.FileChunk)(nil), io.EOF).Once()
+		return svc.Upload(stream)
+	}
+
+	t.Run("mismatch is rejected", func(t *testing.T) {
+		err := upload(metadata.Pairs(fmpb.CRC32CMetadataKey, "00000000"))
+		assert.ErrorIs(t, err, repository.ErrChecksumMismatch)
+
+		_, err = repo.Stat(ctx, "hello.txt")
+		assert.True(t, os.IsNotExist(err))
+	})
+
+	t.Run("malformed checksum", func(t *testing.T) {
+		err := upload(metadata.Pairs(fmpb.SHA256MetadataKey, "xyz"))
+		assert.ErrorIs(t, err, ErrInvalidChecksum)
+	})
+
+	t.Run("match is committed", func(t *testing.T) {
+		err := upload(metadata.Pairs(fmpb.SHA256MetadataKey, helloSHA256, fmpb.CRC32CMetadataKey, "9a71bb4c"))
+		assert.NoError(t, err)
+	})
+
+	t.Run("download returns the stored digest", func(t *testing.T) {
+		stream := mocks.NewMockDownloadStream(ctx)
+		stream.On("SetHeader", metadata.Pairs(fmpb.SHA256MetadataKey, helloSHA256)).Return(nil).Once()
+		stream.On("Send", mock.Anything).Return(nil).Once()
+		stream.On("SetTrailer", metadata.Pairs(fmpb.SHA256MetadataKey, helloSHA256)).Once()
+
+		err := svc.Download(&proto.FileRequest{FileName: "hello.txt"}, stream)
+		assert.NoError(t, err)
+		stream.AssertExpectations(t)
+	})
+}
+
+func TestFileService_UploadQuota(t *testing.T) {
+	lg := logger.New("test_service", "debug")
+	ctx := context.WithValue(context.Background(), logger.Key, lg)
+
+	repo := repository.NewQuota(repository.NewMemory(2048), repository.QuotaLimit{Bytes: 8}, nil)
+	svc := New(repo, nil, nil, nil)
+
+	t.Run("stream fails when the quota is exceeded", func(t *testing.T) {
+		stream := mocks.NewMockUploadStream(ctx)
+		stream.On("Recv").Return(&proto.FileChunk{FileName: "team/a.txt", Content: []byte("hello")}, nil).Once()
+		stream.On("Recv").Return(&proto.FileChunk{FileName: "team/a.txt", Content: []byte("world")}, nil).Once()
+
+		err := svc.Upload(stream)
+		assert.ErrorIs(t, err, repository.ErrQuotaExceeded)
+		stream.AssertExpectations(t)
+
+		_, err = repo.Stat(ctx, "team/a.txt")
+		assert.True(t, os.IsNotExist(err))
+	})
+
+	t.Run("append within the quota", func(t *testing.T) {
+		stream := mocks.NewMockUploadStream(ctx)
+		stream.On("Recv").Return(&proto.FileChunk{FileName: "team/a.txt", Content: []byte("hello")}, nil).Once()
+		stream.On("Recv").Return((*proto.FileChunk)(nil), io.EOF).Once()
+		assert.NoError(t, svc.Upload(stream))
+
+		stream = mocks.NewMockUploadStream(ctx)
+		stream.On("Recv").Return(&proto.FileChunk{FileName: "team/a.txt", Content: []byte("!!!")}, nil).Once()
+		stream.On("Recv").Return((*proto.FileChunk)(nil), io.EOF).Once()
+		assert.NoError(t, svc.Append(stream))
+
+		stream = mocks.NewMockUploadStream(ctx)
+		stream.On("Recv").Return(&proto.FileChunk{FileName: "team/a.txt", Content: []byte("!")}, nil).Once()
+		assert.ErrorIs(t, svc.Append(stream), repository.ErrQuotaExceeded)
+
+		usage, err := repo.Usage(ctx, "team")
+		assert.NoError(t, err)
+		assert.Equal(t, int64(8), usage.Bytes)
+	})
 }
 
 func TestFileService_Download(t *testing.T) {
@@ -84,16 +225,18 @@ func TestFileService_Download(t *testing.T) {
 
 	ctx := context.WithValue(context.Background(), logger.Key, lg)
 	repo := mocks.NewMockFileRepository(ctrl)
-	svc := New(repo)
+	svc := New(repo, nil, nil, nil)
 
 	t.Run("success download", func(t *testing.T) {
 		stream := mocks.NewMockDownloadStream(ctx)
 		file := mocks.NewMockFileHandle(ctrl)
 
 		repo.EXPECT().GetFileHandle(gomock.Any(), "test.txt", repository.Read).Return(file, nil)
+		repo.EXPECT().GetFileHandle(gomock.Any(), ".fm/digests/test.txt.json", repository.Read).Return(nil, os.ErrNotExist)
 		repo.EXPECT().GetReadSize().Return(int64(4096))
 		file.EXPECT().Read(gomock.Any()).Return(1024, io.EOF)
 		file.EXPECT().Close().Return(nil)
+		stream.On("SetTrailer", mock.Anything).Once()
 
 		err := svc.Download(&proto.FileRequest{FileName: "test.txt"}, stream)
 		assert.NoError(t, err)
@@ -109,10 +252,11 @@ func TestFileService_Delete(t *testing.T) {
 	ctx := context.WithValue(context.Background(), logger.Key, lg)
 
 	repo := mocks.NewMockFileRepository(ctrl)
-	svc := New(repo)
+	svc := New(repo, nil, nil, nil)
 
 	t.Run("success delete", func(t *testing.T) {
 		repo.EXPECT().DeleteFile(gomock.Any(), "test.txt").Return(nil)
+		repo.EXPECT().DeleteFile(gomock.Any(), ".fm/digests/test.txt.json").Return(os.ErrNotExist)
 		err := svc.Delete(ctx, &proto.FileRequest{FileName: "test.txt"})
 		assert.NoError(t, err)
 	})
@@ -132,10 +276,11 @@ func TestFileService_MoveFile(t *testing.T) {
 	ctx := context.WithValue(context.Background(), logger.Key, lg)
 
 	repo := mocks.NewMockFileRepository(ctrl)
-	svc := New(repo)
+	svc := New(repo, nil, nil, nil)
 
 	t.Run("success move", func(t *testing.T) {
 		repo.EXPECT().MoveFile(gomock.Any(), "/old.txt", "/new.txt").Return(nil)
+		repo.EXPECT().MoveFile(gomock.Any(), ".fm/digests/old.txt.json", ".fm/digests/new.txt.json").Return(nil)
 		err := svc.MoveFile(ctx, &proto.OperationRequest{
 			Source:      "/old.txt",
 			Destination: "/new.txt",
@@ -144,6 +289,47 @@ func TestFileService_MoveFile(t *testing.T) {
 	})
 }
 
+func TestFileService_DeleteDirectory(t *testing.T) {
+	lg := logger.New("test_service", "debug")
+	ctx := context.WithValue(context.Background(), logger.Key, lg)
+
+	repo := repository.NewMemory(2048)
+	trash := repository.NewTrash(repo)
+	svc := New(repo, nil, trash, nil)
+
+	for _, name := range []string{"docs/a.txt", "docs/sub/b.txt"} {
+		f, err := repo.CreateTempFile(ctx, name)
+		assert.NoError(t, err)
+		assert.NoError(t, repo.CommitTempFile(ctx, f, name))
+	}
+
+	t.Run("not empty", func(t *testing.T) {
+		_, err := svc.DeleteDirectory(ctx, &fmpb.DeleteDirectoryRequest{Path: "docs"}, nil)
+		assert.ErrorIs(t, err, repository.ErrDirNotEmpty)
+	})
+
+	t.Run("files go to the trash", func(t *testing.T) {
+		var results []repository.TreeResult
+		summary, err := svc.DeleteDirectory(ctx, &fmpb.DeleteDirectoryRequest{Path: "docs", Recursive: true},
+			func(result repository.TreeResult) error {
+				results = append(results, result)
+				return nil
+			})
+		assert.NoError(t, err)
+		assert.Equal(t, repository.TreeSummary{Done: 4}, summary)
+		assert.Len(t, results, 4)
+
+		entries, err := trash.List(ctx, "docs")
+		assert.NoError(t, err)
+		assert.Len(t, entries, 2)
+	})
+
+	t.Run("reserved path", func(t *testing.T) {
+		_, err := svc.DeleteDirectory(ctx, &fmpb.DeleteDirectoryRequest{Path: ".fm", Recursive: true}, nil)
+		assert.Error(t, err)
+	})
+}
+
 func TestFileService_ListDirectory(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -152,7 +338,7 @@ func TestFileService_ListDirectory(t *testing.T) {
 	ctx := context.WithValue(context.Background(), logger.Key, lg)
 
 	repo := mocks.NewMockFileRepository(ctrl)
-	svc := New(repo)
+	svc := New(repo, nil, nil, nil)
 
 	t.Run("success list", func(t *testing.T) {
 		entries := []repository.DirectoryEntry{
@@ -175,16 +361,21 @@ func TestFileService_Append(t *testing.T) {
 	ctx := context.WithValue(context.Background(), logger.Key, lg)
 
 	repo := mocks.NewMockFileRepository(ctrl)
-	svc := New(repo)
+	svc := New(repo, nil, nil, nil)
 
 	t.Run("success append", func(t *testing.T) {
 		stream := mocks.NewMockUploadStream(ctx)
 		file := mocks.NewMockFileHandle(ctrl)
 
-		repo.EXPECT().GetFileHandle(gomock.Any(), "test.txt", repository.Write).Return(file, nil)
+		current := mocks.NewMockFileHandle(ctrl)
+
+		repo.EXPECT().CreateTempFile(gomock.Any(), "test.txt").Return(file, nil)
+		repo.EXPECT().GetFileHandle(gomock.Any(), "test.txt", repository.Read).Return(current, nil)
+		current.EXPECT().Read(gomock.Any()).Return(0, io.EOF)
+		current.EXPECT().Close().Return(nil)
 		repo.EXPECT().AppendData(gomock.Any(), file, []byte("data"), int64(0)).Return(int64(4), nil)
-		file.EXPECT().Stat().Return(mocks.MockFileInfo{SizeVal: 100}, nil)
-		file.EXPECT().Close().Return(nil)
+		repo.EXPECT().CommitTempFile(gomock.Any(), file, "test.txt").Return(nil)
+		repo.EXPECT().Stat(gomock.Any(), "test.txt").Return(nil, os.ErrNotExist)
 
 		stream.On("Recv").Return(&proto.FileChunk{FileName: "test.txt", Content: []byte("data")}, nil).Once()
 		stream.On("Recv").Return((*proto.FileChunk)(nil), io.EOF).Once()
@@ -194,3 +385,682 @@ func TestFileService_Append(t *testing.T) {
 		stream.AssertExpectations(t)
 	})
 }
+
+func TestResolveRanges(t *testing.T) {
+	const size = 100
+
+	tests := []struct {
+		testName string
+		ranges   []*fmpb.ByteRange
+		expected [][2]int64
+	}{
+		{"Whole file", nil, [][2]int64{{0, 100}}},
+		{"Closed range", []*fmpb.ByteRange{{Offset: 10, Length: 20}}, [][2]int64{{10, 20}}},
+		{"Open range", []*fmpb.ByteRange{{Offset: 90}}, [][2]int64{{90, 10}}},
+		{"Range past the end is cut", []*fmpb.ByteRange{{Offset: 95, Length: 50}}, [][2]int64{{95, 5}}},
+		{"Suffix range", []*fmpb.ByteRange{{Offset: -30}}, [][2]int64{{70, 30}}},
+		{"Suffix longer than file", []*fmpb.ByteRange{{Offset: -300}}, [][2]int64{{0, 100}}},
+		{"Unsatisfiable range is dropped", []*fmpb.ByteRange{{Offset: 100}, {Offset: 0, Length: 1}}, [][2]int64{{0, 1}}},
+		{"Nothing satisfiable", []*fmpb.ByteRange{{Offset: 200, Length: 1}}, [][2]int64{}},
+		{"Huge length does not overflow", []*fmpb.ByteRange{{Offset: 10, Length: math.MaxInt64}}, [][2]int64{{10, 90}}},
+		{"Smallest suffix", []*fmpb.ByteRange{{Offset: math.MinInt64}}, [][2]int64{{0, 100}}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			resolved := ResolveRanges(test.ranges, size)
+			assert.Len(t, resolved, len(test.expected))
+			for i, r := range resolved {
+				assert.Equal(t, test.expected[i][0], r.Offset)
+				assert.Equal(t, test.expected[i][1], r.Length)
+			}
+		})
+	}
+}
+
+func TestFileService_ListEntries(t *testing.T) {
+	lg := logger.New("test_service", "debug")
+	ctx := context.WithValue(context.Background(), logger.Key, lg)
+
+	repo := repository.NewMemory(2048)
+	svc := New(repo, nil, nil, nil)
+
+	files := map[string]string{"dir/b.log": "bb", "dir/a.txt": "aaa", "dir/c.log": "c"}
+	for name, content := range files {
+		f, err := repo.CreateTempFile(ctx, name)
+		assert.NoError(t, err)
+		_, err = repo.AppendData(ctx, f, []byte(content), 0)
+		assert.NoError(t, err)
+		assert.NoError(t, repo.CommitTempFile(ctx, f, name))
+	}
+	assert.NoError(t, repo.CreateDir(ctx, "dir/sub"))
+
+	names := func(res *fmpb.ListDirectoryResponse) []string {
+		var names []string
+		for _, e := range res.Entries {
+			names = append(names, e.Name)
+		}
+		return names
+	}
+
+	t.Run("sorted by name", func(t *testing.T) {
+		res, err := svc.ListEntries(ctx, &fmpb.ListDirectoryRequest{Path: "dir"})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"a.txt", "b.log", "c.log", "sub"}, names(res))
+		assert.Empty(t, res.NextCursor)
+		assert.Equal(t, "text/plain; charset=utf-8", res.Entries[0].ContentType)
+	})
+
+	t.Run("sorted by size descending", func(t *testing.T) {
+		res, err := svc.ListEntries(ctx, &fmpb.ListDirectoryRequest{
+			Path: "dir", SortBy: fmpb.SortField_SORT_FIELD_SIZE, Descending: true,
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"a.txt", "b.log", "c.log", "sub"}, names(res))
+	})
+
+	t.Run("pattern", func(t *testing.T) {
+		res, err := svc.ListEntries(ctx, &fmpb.ListDirectoryRequest{Path: "dir", Pattern: "*.log"})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"b.log", "c.log"}, names(res))
+
+		_, err = svc.ListEntries(ctx, &fmpb.ListDirectoryRequest{Path: "dir", Pattern: "["})
+		assert.ErrorIs(t, err, ErrInvalidPattern)
+	})
+
+	t.Run("pages", func(t *testing.T) {
+		req := &fmpb.ListDirectoryRequest{Path: "dir", SortBy: fmpb.SortField_SORT_FIELD_SIZE, PageSize: 3}
+		first, err := svc.ListEntries(ctx, req)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"sub", "c.log", "b.log"}, names(first))
+		assert.NotEmpty(t, first.NextCursor)
+
+		req.Cursor = first.NextCursor
+		second, err := svc.ListEntries(ctx, req)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"a.txt"}, names(second))
+		assert.Empty(t, second.NextCursor)
+
+		req.SortBy = fmpb.SortField_SORT_FIELD_NAME
+		_, err = svc.ListEntries(ctx, req)
+		assert.ErrorIs(t, err, ErrInvalidCursor)
+	})
+
+	t.Run("stat with checksum", func(t *testing.T) {
+		stat, err := svc.Stat(ctx, &fmpb.StatRequest{Path: "dir/a.txt", Checksum: true})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(3), stat.Size)
+		assert.Equal(t, int64(3), stat.PhysicalSize)
+		assert.Equal(t, "sha256:9834876dcfb05cb167a5c24953eba58c4ac89b1adf57f28f2f9d09af107ee8f0", stat.Checksum)
+
+		_, err = svc.Stat(ctx, &fmpb.StatRequest{Path: "dir/missing"})
+		assert.Error(t, err)
+	})
+}
+
+func TestFileService_AccessControl(t *testing.T) {
+	lg := logger.New("test_service", "debug")
+	ctx := context.WithValue(context.Background(), logger.Key, lg)
+
+	repo := repository.NewMemory(2048)
+	store := repository.NewACLStore(repo, repository.DefaultRoles)
+	acl, err := NewACL(store, repository.ACLConfig{Enabled: true, Admins: "group:admins"})
+	assert.NoError(t, err)
+	svc := New(repo, nil, nil, acl)
+
+	alice := auth.NewContext(ctx, &auth.Identity{Subject: "alice"})
+	bob := auth.NewContext(ctx, &auth.Identity{Subject: "bob"})
+	admin := auth.NewContext(ctx, &auth.Identity{Subject: "root", Groups: []string{"admins"}})
+
+	_, err = store.Set(ctx, repository.ACLEntry{Path: "team", Principal: "user:alice", Role: "editor"})
+	assert.NoError(t, err)
+	_, err = store.Set(ctx, repository.ACLEntry{Path: "team", Principal: "user:bob", Role: "viewer"})
+	assert.NoError(t, err)
+
+	upload := func(ctx context.Context, name string) error {
+		stream := mocks.NewMockUploadStream(ctx)
+		stream.On("Recv").Return(&proto.FileChunk{FileName: name, Content: []byte("hello")}, nil).Once()
+		stream.On("Recv").Return((*proto.FileChunk)(nil), io.EOF).Maybe()
+		return svc.Upload(stream)
+	}
+
+	t.Run("granted upload", func(t *testing.T) {
+		assert.NoError(t, upload(alice, "team/a.txt"))
+	})
+
+	t.Run("denied upload", func(t *testing.T) {
+		assert.ErrorIs(t, upload(bob, "team/b.txt"), ErrPermissionDenied)
+		assert.ErrorIs(t, upload(alice, "other/a.txt"), ErrPermissionDenied)
+		assert.ErrorIs(t, upload(ctx, "team/c.txt"), ErrPermissionDenied)
+	})
+
+	t.Run("read only grant", func(t *testing.T) {
+		_, err := svc.Stat(bob, &fmpb.StatRequest{Path: "team/a.txt"})
+		assert.NoError(t, err)
+		assert.ErrorIs(t, svc.Delete(bob, &proto.FileRequest{FileName: "team/a.txt"}), ErrPermissionDenied)
+	})
+
+	t.Run("list only grant hides content", func(t *testing.T) {
+		carol := auth.NewContext(ctx, &auth.Identity{Subject: "carol"})
+		_, err := store.Set(ctx, repository.ACLEntry{Path: "team", Principal: "user:carol", Permissions: repository.PermList})
+		assert.NoError(t, err)
+
+		stat, err := svc.Stat(carol, &fmpb.StatRequest{Path: "team/a.txt"})
+		assert.NoError(t, err)
+		assert.Empty(t, stat.Checksum)
+		_, err = svc.Stat(carol, &fmpb.StatRequest{Path: "team/a.txt", Checksum: true})
+		assert.ErrorIs(t, err, ErrPermissionDenied)
+
+		list, err := svc.ListEntries(carol, &fmpb.ListDirectoryRequest{Path: "team", Checksum: true})
+		assert.NoError(t, err)
+		if assert.Len(t, list.Entries, 1) {
+			assert.Empty(t, list.Entries[0].Checksum)
+		}
+
+		list, err = svc.ListEntries(bob, &fmpb.ListDirectoryRequest{Path: "team", Checksum: true})
+		assert.NoError(t, err)
+		if assert.Len(t, list.Entries, 1) {
+			assert.NotEmpty(t, list.Entries[0].Checksum)
+		}
+	})
+
+	t.Run("admins bypass grants", func(t *testing.T) {
+		assert.NoError(t, upload(admin, "other/a.txt"))
+		assert.NoError(t, svc.Delete(admin, &proto.FileRequest{FileName: "team/a.txt"}))
+	})
+
+	t.Run("grant management is for admins", func(t *testing.T) {
+		acls := NewACLService(acl)
+
+		_, err := acls.SetGrant(alice, &fmpb.Grant{Path: "team", Principal: "user:alice", Role: "owner"})
+		assert.ErrorIs(t, err, ErrPermissionDenied)
+
+		_, err = acls.SetGrant(admin, &fmpb.Grant{Path: "team", Principal: "user:bob", Permissions: []string{"delete"}})
+		assert.NoError(t, err)
+
+		access, err := acls.GetAccess(bob, &fmpb.GetAccessRequest{Path: "team/x"})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"delete"}, access.Permissions)
+
+		_, err = NewACLService(nil).ListRoles(ctx, &fmpb.ListRolesRequest{})
+		assert.ErrorIs(t, err, ErrACLDisabled)
+	})
+}
+
+func TestUploadSessionService_Finalize(t *testing.T) {
+	ctx := context.WithValue(context.Background(), logger.Key, logger.New("test", "debug"))
+
+	repo := repository.NewMemory(2048)
+	versions := repository.NewVersionStore(repo, repository.VersionConfig{Keep: 10})
+	svc := NewUploadSessionService(repository.NewUploadSessionStore(repo), versions, nil)
+
+	file, err := repo.GetFileHandle(ctx, "docs/a.txt", repository.CreateAndW)
+	assert.NoError(t, err)
+	_, err = repo.AppendData(ctx, file, []byte("old"), 0)
+	assert.NoError(t, err)
+	assert.NoError(t, file.Close())
+
+	stage := func(data, sha string) string {
+		session, err := svc.Create(ctx, &fmpb.CreateUploadSessionRequest{FileName: "docs/a.txt", Sha256: sha})
+		assert.NoError(t, err)
+		writer, err := svc.store.Open(ctx, session.UploadId)
+		assert.NoError(t, err)
+		assert.NoError(t, writer.Write(ctx, 0, []byte(data)))
+		assert.NoError(t, writer.Close())
+		return session.UploadId
+	}
+	sum := sha256.Sum256([]byte("new"))
+
+	t.Run("rejected upload keeps no version", func(t *testing.T) {
+		id := stage("bad", hex.EncodeToString(sum[:]))
+		_, err := svc.Finalize(ctx, &fmpb.UploadSessionRequest{UploadId: id})
+		assert.ErrorIs(t, err, repository.ErrChecksumMismatch)
+
+		list, err := versions.List(ctx, "docs/a.txt")
+		assert.NoError(t, err)
+		assert.Empty(t, list)
+	})
+
+	t.Run("finalized upload keeps the replaced file", func(t *testing.T) {
+		id := stage("new", hex.EncodeToString(sum[:]))
+		_, err := svc.Finalize(ctx, &fmpb.UploadSessionRequest{UploadId: id})
+		assert.NoError(t, err)
+
+		list, err := versions.List(ctx, "docs/a.txt")
+		assert.NoError(t, err)
+		assert.Len(t, list, 1)
+	})
+}
+
+func TestShareService(t *testing.T) {
+	lg := logger.New("test_service", "debug")
+	ctx := context.WithValue(context.Background(), logger.Key, lg)
+
+	repo := repository.NewMemory(2048)
+	acl, err := NewACL(repository.NewACLStore(repo, repository.DefaultRoles), repository.ACLConfig{Enabled: true, Admins: "user:root"})
+	assert.NoError(t, err)
+	_, err = acl.store.Set(ctx, repository.ACLEntry{Path: "reports", Principal: "user:alice", Role: "editor"})
+	assert.NoError(t, err)
+
+	store := repository.NewShareStore(repo)
+	shares := NewShareService(store, repository.ShareConfig{Secret: "secret", MaxTTL: time.Hour}, acl)
+	svc := New(repo, nil, nil, acl)
+
+	alice := auth.NewContext(ctx, &auth.Identity{Subject: "alice"})
+	bob := auth.NewContext(ctx, &auth.Identity{Subject: "bob"})
+
+	f, err := repo.CreateTempFile(ctx, "reports/q3.pdf")
+	assert.NoError(t, err)
+	_, err = repo.AppendData(ctx, f, []byte("report"), 0)
+	assert.NoError(t, err)
+	assert.NoError(t, repo.CommitTempFile(ctx, f, "reports/q3.pdf"))
+
+	share, err := shares.CreateShare(alice, &fmpb.CreateShareRequest{Path: "reports/q3.pdf", Operation: "download", MaxUses: 2})
+	assert.NoError(t, err)
+	assert.NotEmpty(t, share.Token)
+	assert.Equal(t, "alice", share.CreatedBy)
+
+	t.Run("create needs the shared permission", func(t *testing.T) {
+		_, err := shares.CreateShare(bob, &fmpb.CreateShareRequest{Path: "reports/q3.pdf", Operation: "download"})
+		assert.ErrorIs(t, err, ErrPermissionDenied)
+
+		_, err = shares.CreateShare(alice, &fmpb.CreateShareRequest{Path: "reports/q3.pdf", Operation: "download", TtlSeconds: 7200})
+		assert.ErrorIs(t, err, repository.ErrInvalidShare)
+
+		_, err = NewShareService(store, repository.ShareConfig{}, acl).CreateShare(alice, &fmpb.CreateShareRequest{Path: "reports/q3.pdf", Operation: "download"})
+		assert.ErrorIs(t, err, ErrSharingDisabled)
+	})
+
+	t.Run("redeem checks the signature", func(t *testing.T) {
+		_, err := shares.Redeem(ctx, share.Token, "download", "reports/other.pdf")
+		assert.ErrorIs(t, err, ErrInvalidShareToken)
+		_, err = shares.Redeem(ctx, share.Token, "upload", "reports/q3.pdf")
+		assert.ErrorIs(t, err, ErrInvalidShareToken)
+		_, err = shares.Redeem(ctx, share.Token[:len(share.Token)-2]+"xx", "download", "reports/q3.pdf")
+		assert.ErrorIs(t, err, ErrInvalidShareToken)
+	})
+
+	t.Run("shared calls only reach the shared file", func(t *testing.T) {
+		shared, holder := NewShareContext(ctx)
+		assert.ErrorIs(t, svc.acl.Check(shared, repository.PermRead, "reports/q3.pdf"), ErrPermissionDenied)
+
+		redeemed, err := shares.Redeem(ctx, share.Token, "download", "/reports/q3.pdf")
+		assert.NoError(t, err)
+		*holder = redeemed
+
+		assert.NoError(t, svc.acl.Check(shared, repository.PermRead, "reports/q3.pdf"))
+		assert.ErrorIs(t, svc.acl.Check(shared, repository.PermWrite, "reports/q3.pdf"), ErrPermissionDenied)
+		assert.ErrorIs(t, svc.acl.Check(shared, repository.PermRead, "reports/q4.pdf"), ErrPermissionDenied)
+		assert.ErrorIs(t, svc.Delete(shared, &proto.FileRequest{FileName: "reports/q3.pdf"}), ErrPermissionDenied)
+	})
+
+	t.Run("uses are limited", func(t *testing.T) {
+		_, err := shares.Redeem(ctx, share.Token, "download", "reports/q3.pdf")
+		assert.NoError(t, err)
+		_, err = shares.Redeem(ctx, share.Token, "download", "reports/q3.pdf")
+		assert.ErrorIs(t, err, repository.ErrShareUsedUp)
+	})
+
+	t.Run("list and revoke", func(t *testing.T) {
+		res, err := shares.ListShares(bob, &fmpb.ListSharesRequest{})
+		assert.NoError(t, err)
+		assert.Empty(t, res.Shares)
+
+		res, err = shares.ListShares(alice, &fmpb.ListSharesRequest{})
+		assert.NoError(t, err)
+		assert.Len(t, res.Shares, 1)
+		assert.Empty(t, res.Shares[0].Token)
+
+		assert.ErrorIs(t, shares.RevokeShare(bob, &fmpb.RevokeShareRequest{Id: share.Id}), ErrPermissionDenied)
+		assert.NoError(t, shares.RevokeShare(alice, &fmpb.RevokeShareRequest{Id: share.Id}))
+		_, err = shares.Redeem(ctx, share.Token, "download", "reports/q3.pdf")
+		assert.ErrorIs(t, err, repository.ErrShareNotFound)
+	})
+}
+
+// watchStream collects the events a watch sends.
+type watchStream struct {
+	fmpb.WatchService_WatchServer
+	ctx    context.Context
+	events chan *fmpb.WatchEvent
+}
+
+func (s *watchStream) Context() context.Context          { return s.ctx }
+func (s *watchStream) SendHeader(metadata.MD) error      { return nil }
+func (s *watchStream) Send(event *fmpb.WatchEvent) error { s.events <- event; return nil }
+
+func TestWatchService(t *testing.T) {
+	ctx := context.WithValue(context.Background(), logger.Key, logger.New("test", "debug"))
+	hub := repository.NewEventHub(16)
+	srv := NewWatchService(hub, nil)
+
+	t.Run("filters by directory", func(t *testing.T) {
+		for _, tc := range []struct {
+			event     repository.Event
+			recursive bool
+			want      *fmpb.WatchEvent
+		}{
+			{repository.Event{Type: "create", Path: "docs/a.txt"}, false, &fmpb.WatchEvent{Type: "create", Path: "docs/a.txt"}},
+			{repository.Event{Type: "create", Path: "docs/sub/a.txt"}, false, nil},
+			{repository.Event{Type: "create", Path: "docs/sub/a.txt"}, true, &fmpb.WatchEvent{Type: "create", Path: "docs/sub/a.txt"}},
+			{repository.Event{Type: "delete", Path: "docs"}, true, nil},
+			{repository.Event{Type: "modify", Path: "docsx/a.txt"}, true, nil},
+			{repository.Event{Type: "move", Path: "docs/b.txt", OldPath: "docs/a.txt"}, false, &fmpb.WatchEvent{Type: "move", Path: "docs/b.txt", OldPath: "docs/a.txt"}},
+			{repository.Event{Type: "move", Path: "docs/b.txt", OldPath: "tmp/a.txt"}, false, &fmpb.WatchEvent{Type: "create", Path: "docs/b.txt"}},
+			{repository.Event{Type: "move", Path: "tmp/b.txt", OldPath: "docs/a.txt"}, false, &fmpb.WatchEvent{Type: "delete", Path: "docs/a.txt"}},
+		} {
+			got := watchEvent(tc.event, "docs", tc.recursive)
+			if got != nil {
+				got.Time = 0
+			}
+			assert.Equal(t, tc.want, got, "%+v", tc.event)
+		}
+		assert.NotNil(t, watchEvent(repository.Event{Type: "create", Path: "a.txt"}, "", false))
+	})
+
+	t.Run("streams and resumes", func(t *testing.T) {
+		watchCtx, cancel := context.WithCancel(ctx)
+		stream := &watchStream{ctx: watchCtx, events: make(chan *fmpb.WatchEvent, 16)}
+		first := hub.Publish(repository.Event{Type: "create", Path: "docs/a.txt"})
+		hub.Publish(repository.Event{Type: "create", Path: "other/b.txt"})
+		hub.Publish(repository.Event{Type: "modify", Path: "docs/a.txt"})
+
+		done := make(chan error)
+		go func() { done <- srv.Watch(&fmpb.WatchRequest{Path: "/docs", Since: first.Seq}, stream) }()
+
+		event := <-stream.events
+		assert.Equal(t, "modify", event.Type)
+		assert.Equal(t, first.Seq+2, event.Seq)
+
+		hub.Publish(repository.Event{Type: "delete", Path: "docs/a.txt"})
+		assert.Equal(t, "delete", (<-stream.events).Type)
+
+		cancel()
+		assert.NoError(t, <-done)
+	})
+
+	t.Run("lost events", func(t *testing.T) {
+		err := srv.Watch(&fmpb.WatchRequest{Since: 1}, &watchStream{ctx: ctx})
+		assert.ErrorIs(t, err, repository.ErrEventsLost)
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		err := NewWatchService(nil, nil).Watch(&fmpb.WatchRequest{}, &watchStream{ctx: ctx})
+		assert.ErrorIs(t, err, ErrWatchDisabled)
+	})
+}
+
+func TestWebhookService(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.WithValue(context.Background(), logger.Key, logger.New("test", "debug")))
+	defer cancel()
+
+	type received struct {
+		payload   WebhookPayload
+		signature string
+		body      []byte
+	}
+	got := make(chan received, 16)
+	var failures atomic.Int32
+	failures.Store(2)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if strings.HasSuffix(r.URL.Path, "/down") || failures.Add(-1) >= 0 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		var payload WebhookPayload
+		assert.NoError(t, json.Unmarshal(body, &payload))
+		assert.Equal(t, payload.ID, r.Header.Get(WebhookDeliveryHeader))
+		got <- received{payload: payload, signature: r.Header.Get(WebhookSignatureHeader), body: body}
+	}))
+	defer receiver.Close()
+
+	hub := repository.NewEventHub(16)
+	store := repository.NewWebhookStore(repository.NewMemory(2048))
+	srv := NewWebhookService(store, hub, repository.WebhookConfig{
+		Enabled: true, MaxAttempts: 3, Backoff: time.Millisecond, MaxBackoff: 4 * time.Millisecond, Timeout: time.Second, Workers: 2, History: 10,
+	}, nil)
+	go srv.Run(ctx)
+
+	hook, err := srv.CreateWebhook(ctx, &fmpb.CreateWebhookRequest{PathPrefix: "incoming", Types: []string{"create"}, Url: receiver.URL + "/up", Secret: "s3cret"})
+	assert.NoError(t, err)
+	assert.Equal(t, "s3cret", hook.Secret)
+	down, err := srv.CreateWebhook(ctx, &fmpb.CreateWebhookRequest{PathPrefix: "outgoing", Url: receiver.URL + "/down"})
+	assert.NoError(t, err)
+	assert.NotEmpty(t, down.Secret)
+
+	hub.Publish(repository.Event{Type: "modify", Path: "incoming/skipped.csv"})
+	hub.Publish(repository.Event{Type: "create", Path: "incoming/a.csv"})
+	hub.Publish(repository.Event{Type: "create", Path: "outgoing/b.csv"})
+
+	select {
+	case r := <-got:
+		assert.Equal(t, hook.Id, r.payload.WebhookID)
+		assert.Equal(t, "incoming/a.csv", r.payload.Event.Path)
+		timestamp, _, _ := strings.Cut(strings.TrimPrefix(r.signature, "t="), ",")
+		ts, err := strconv.ParseInt(timestamp, 10, 64)
+		assert.NoError(t, err)
+		assert.Equal(t, SignWebhook("s3cret", ts, r.body), r.signature)
+	case <-time.After(5 * time.Second):
+		t.Fatal("webhook was not delivered")
+	}
+
+	var letters *fmpb.ListDeliveriesResponse
+	assert.Eventually(t, func() bool {
+		letters, err = srv.ListDeadLetters(ctx, &fmpb.ListDeadLettersRequest{})
+		return err == nil && len(letters.Deliveries) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, down.Id, letters.Deliveries[0].WebhookId)
+	assert.Equal(t, int32(3), letters.Deliveries[0].Attempts)
+	assert.Equal(t, int32(http.StatusServiceUnavailable), letters.Deliveries[0].ResponseCode)
+
+	res, err := srv.ListDeliveries(ctx, &fmpb.ListDeliveriesRequest{WebhookId: hook.Id})
+	assert.NoError(t, err)
+	if assert.Len(t, res.Deliveries, 1) {
+		assert.Equal(t, repository.DeliveryDelivered, res.Deliveries[0].Status)
+		assert.Equal(t, int32(3), res.Deliveries[0].Attempts)
+	}
+
+	t.Run("redeliver", func(t *testing.T) {
+		queued, err := srv.Redeliver(ctx, &fmpb.RedeliverRequest{Id: letters.Deliveries[0].Id})
+		assert.NoError(t, err)
+		assert.Equal(t, repository.DeliveryPending, queued.Status)
+		assert.Equal(t, int32(0), queued.Attempts)
+
+		_, err = srv.Redeliver(ctx, &fmpb.RedeliverRequest{Id: letters.Deliveries[0].Id})
+		assert.ErrorIs(t, err, repository.ErrDeadLetterNotFound)
+		assert.Eventually(t, func() bool {
+			letters, err := srv.ListDeadLetters(ctx, &fmpb.ListDeadLettersRequest{})
+			return err == nil && len(letters.Deliveries) == 1
+		}, 5*time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("admins only", func(t *testing.T) {
+		acl, err := NewACL(repository.NewACLStore(repository.NewMemory(2048), nil), repository.ACLConfig{Enabled: true, Admins: "user:root"})
+		assert.NoError(t, err)
+		guarded := NewWebhookService(store, hub, repository.WebhookConfig{Enabled: true}, acl)
+
+		alice := auth.NewContext(ctx, &auth.Identity{Subject: "alice"})
+		_, err = guarded.ListWebhooks(alice, &fmpb.ListWebhooksRequest{})
+		assert.ErrorIs(t, err, ErrPermissionDenied)
+		root := auth.NewContext(ctx, &auth.Identity{Subject: "root"})
+		hooks, err := guarded.ListWebhooks(root, &fmpb.ListWebhooksRequest{})
+		assert.NoError(t, err)
+		assert.Len(t, hooks.Webhooks, 2)
+		assert.Empty(t, hooks.Webhooks[0].Secret)
+
+		_, err = NewWebhookService(store, hub, repository.WebhookConfig{}, nil).ListWebhooks(ctx, &fmpb.ListWebhooksRequest{})
+		assert.ErrorIs(t, err, ErrWebhooksDisabled)
+	})
+}
+
+func TestWebhookService_Backlog(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.WithValue(context.Background(), logger.Key, logger.New("test", "debug")))
+	defer cancel()
+
+	release := make(chan struct{})
+	fast := make(chan string, 16)
+	var failed atomic.Bool
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/slow") {
+			<-release
+			return
+		}
+		if strings.HasSuffix(r.URL.Path, "/ordered") && !failed.Swap(true) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		var payload WebhookPayload
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+		fast <- payload.Event.Path
+	}))
+	defer receiver.Close()
+	defer close(release)
+
+	hub := repository.NewEventHub(2)
+	store := repository.NewWebhookStore(repository.NewMemory(2048))
+	srv := NewWebhookService(store, hub, repository.WebhookConfig{
+		Enabled: true, MaxAttempts: 2, Backoff: 10 * time.Millisecond, Timeout: 5 * time.Second, Workers: 2, QueueSize: 2, History: 20,
+	}, nil)
+
+	_, err := srv.Redeliver(ctx, &fmpb.RedeliverRequest{Id: uuid.NewString()})
+	assert.ErrorIs(t, err, ErrWebhooksStopped)
+
+	slow, err := srv.CreateWebhook(ctx, &fmpb.CreateWebhookRequest{PathPrefix: "slow", Url: receiver.URL + "/slow"})
+	assert.NoError(t, err)
+	_, err = srv.CreateWebhook(ctx, &fmpb.CreateWebhookRequest{PathPrefix: "fast", Url: receiver.URL + "/fast"})
+	assert.NoError(t, err)
+	_, err = srv.CreateWebhook(ctx, &fmpb.CreateWebhookRequest{PathPrefix: "ordered", Url: receiver.URL + "/ordered"})
+	assert.NoError(t, err)
+
+	t.Run("lost events are reported", func(t *testing.T) {
+		// The hub buffers 2 events, so the first of these is gone when
+		// Run starts.
+		for _, path := range []string{"fast/0", "fast/1", "fast/2"} {
+			hub.Publish(repository.Event{Type: "create", Path: path})
+		}
+		go srv.Run(ctx)
+
+		var res *fmpb.ListDeliveriesResponse
+		assert.Eventually(t, func() bool {
+			res, err = srv.ListDeliveries(ctx, &fmpb.ListDeliveriesRequest{WebhookId: slow.Id, Status: repository.DeliveryFailed})
+			return err == nil && len(res.Deliveries) == 1
+		}, 5*time.Second, 10*time.Millisecond)
+		assert.Contains(t, res.Deliveries[0].LastError, "were not delivered")
+		assert.Equal(t, "fast/1", <-fast)
+		assert.Equal(t, "fast/2", <-fast)
+	})
+
+	t.Run("slow receiver does not hold up others", func(t *testing.T) {
+		for _, path := range []string{"slow/a", "slow/b", "slow/c", "fast/d"} {
+			hub.Publish(repository.Event{Type: "create", Path: path})
+		}
+
+		for path := ""; path != "fast/d"; {
+			select {
+			case path = <-fast:
+			case <-time.After(5 * time.Second):
+				t.Fatal("webhook was not delivered")
+			}
+		}
+	})
+
+	t.Run("full queue goes to dead letters", func(t *testing.T) {
+		// slow/a is posted, slow/b and slow/c fill the queue.
+		for _, path := range []string{"slow/e", "slow/f"} {
+			hub.Publish(repository.Event{Type: "create", Path: path})
+		}
+
+		var letters *fmpb.ListDeliveriesResponse
+		assert.Eventually(t, func() bool {
+			letters, err = srv.ListDeadLetters(ctx, &fmpb.ListDeadLettersRequest{})
+			return err == nil && len(letters.Deliveries) == 2
+		}, 5*time.Second, 10*time.Millisecond)
+		for _, letter := range letters.Deliveries {
+			assert.Equal(t, slow.Id, letter.WebhookId)
+			assert.Contains(t, letter.LastError, "queue is full")
+		}
+	})
+
+	t.Run("retries keep the order of events", func(t *testing.T) {
+		for _, path := range []string{"ordered/1", "ordered/2"} {
+			hub.Publish(repository.Event{Type: "create", Path: path})
+		}
+
+		var got []string
+		for len(got) < 2 {
+			select {
+			case path := <-fast:
+				if strings.HasPrefix(path, "ordered/") {
+					got = append(got, path)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("webhook was not delivered")
+			}
+		}
+		assert.Equal(t, []string{"ordered/1", "ordered/2"}, got)
+	})
+}
+
+func TestAuditService(t *testing.T) {
+	ctx := context.WithValue(context.Background(), logger.Key, logger.New("test", "debug"))
+
+	log, err := repository.OpenAuditLog(t.TempDir() + "/audit.jsonl")
+	assert.NoError(t, err)
+	defer log.Close()
+	acl, err := NewACL(repository.NewACLStore(repository.NewMemory(2048), nil), repository.ACLConfig{Enabled: true, Admins: "user:root"})
+	assert.NoError(t, err)
+	srv := NewAuditService(log, acl)
+	assert.True(t, srv.Enabled())
+
+	now := time.Now()
+	srv.Record(ctx, repository.AuditRecord{Caller: "alice", Operation: "/file_manager.FileService/Upload", Path: "docs/a.txt", Bytes: 5, Status: "OK", Duration: 1500 * time.Microsecond, Time: now.Add(-time.Hour)})
+	srv.Record(ctx, repository.AuditRecord{Caller: "bob", Operation: "/file_manager.FileService/Download", Path: "docs/a.txt", Status: "PermissionDenied", Time: now})
+
+	root := auth.NewContext(ctx, &auth.Identity{Subject: "root"})
+	res, err := srv.QueryAudit(root, &fmpb.QueryAuditRequest{PathPrefix: "docs"})
+	assert.NoError(t, err)
+	if assert.Len(t, res.Records, 2) {
+		assert.Equal(t, "alice", res.Records[0].Caller)
+		assert.Equal(t, int64(1), res.Records[0].DurationMs)
+		assert.Equal(t, res.Records[0].Hash, res.Records[1].PrevHash)
+	}
+
+	res, err = srv.QueryAudit(root, &fmpb.QueryAuditRequest{Since: now.Add(-time.Minute).Unix()})
+	assert.NoError(t, err)
+	if assert.Len(t, res.Records, 1) {
+		assert.Equal(t, "bob", res.Records[0].Caller)
+	}
+	res, err = srv.QueryAudit(root, &fmpb.QueryAuditRequest{User: "alice", Until: now.Add(-time.Hour).Unix()})
+	assert.NoError(t, err)
+	assert.Len(t, res.Records, 1)
+
+	verified, err := srv.VerifyAudit(root, &fmpb.VerifyAuditRequest{})
+	assert.NoError(t, err)
+	assert.True(t, verified.Valid)
+	assert.Equal(t, uint64(2), verified.Records)
+
+	alice := auth.NewContext(ctx, &auth.Identity{Subject: "alice"})
+	_, err = srv.QueryAudit(alice, &fmpb.QueryAuditRequest{})
+	assert.ErrorIs(t, err, ErrPermissionDenied)
+
+	disabled := NewAuditService(nil, nil)
+	assert.False(t, disabled.Enabled())
+	disabled.Record(ctx, repository.AuditRecord{Operation: "/file_manager.FileService/Upload"})
+	_, err = disabled.VerifyAudit(ctx, &fmpb.VerifyAuditRequest{})
+	assert.ErrorIs(t, err, ErrAuditDisabled)
+}
+
+func TestHealthService(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	srv := NewHealthService(dir, repository.HealthConfig{Interval: time.Second})
+	assert.Equal(t, time.Second, srv.Interval())
+	assert.NoError(t, srv.Check(ctx))
+
+	missing := NewHealthService(filepath.Join(dir, "missing"), repository.HealthConfig{})
+	assert.Error(t, missing.Check(ctx))
+
+	assert.NoError(t, NewHealthService("", repository.HealthConfig{}).Check(ctx))
+}
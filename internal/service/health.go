@@ -0,0 +1,32 @@
+package service
+
+import (
+	"context"
+	"github.com/JunBSer/FileManager/internal/repository"
+	"time"
+)
+
+// HealthService tells whether the service can store files.
+type HealthService struct {
+	storagePath string
+	cfg         repository.HealthConfig
+}
+
+// NewHealthService creates the health service checking the storage directory
+// storagePath. Backends without one leave it empty and are always healthy.
+func NewHealthService(storagePath string, cfg repository.HealthConfig) *HealthService {
+	return &HealthService{storagePath: storagePath, cfg: cfg}
+}
+
+// Interval is how often the health is checked.
+func (srv *HealthService) Interval() time.Duration {
+	return srv.cfg.Interval
+}
+
+// Check returns why files cannot be stored, or nil if they can.
+func (srv *HealthService) Check(ctx context.Context) error {
+	if srv.storagePath == "" {
+		return ctx.Err()
+	}
+	return repository.CheckStorage(srv.storagePath, srv.cfg.MinFreeBytes)
+}
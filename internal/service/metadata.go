@@ -0,0 +1,325 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/JunBSer/FileManager/internal/repository"
+	"github.com/JunBSer/FileManager/pkg/api/fmpb"
+	"github.com/JunBSer/FileManager/pkg/logger"
+	"go.uber.org/zap"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+const (
+	defaultPageSize = 1000
+	maxPageSize     = 10000
+)
+
+var (
+	ErrInvalidCursor  = errors.New("invalid cursor")
+	ErrInvalidPattern = errors.New("invalid pattern")
+)
+
+// listCursor is the position after the last entry of a page. It holds the
+// sort key instead of an offset, so pages stay consistent while the
+// directory changes.
+type listCursor struct {
+	Sort    fmpb.SortField `json:"s"`
+	Desc    bool           `json:"d"`
+	Size    int64          `json:"z,omitempty"`
+	ModTime int64          `json:"t,omitempty"`
+	Name    string         `json:"n"`
+}
+
+func (c listCursor) encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(raw string) (*listCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	c := &listCursor{}
+	if err = json.Unmarshal(data, c); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	return c, nil
+}
+
+type listItem struct {
+	entry repository.DirectoryEntry
+	info  fs.FileInfo
+}
+
+func (item listItem) cursor(sortBy fmpb.SortField, desc bool) listCursor {
+	c := listCursor{Sort: sortBy, Desc: desc, Name: item.entry.Name}
+	switch sortBy {
+	case fmpb.SortField_SORT_FIELD_SIZE:
+		c.Size = item.info.Size()
+	case fmpb.SortField_SORT_FIELD_MOD_TIME:
+		c.ModTime = item.info.ModTime().UnixNano()
+	}
+	return c
+}
+
+// before reports whether c sorts before other in ascending order.
+func (c listCursor) before(other listCursor) bool {
+	switch {
+	case c.Sort == fmpb.SortField_SORT_FIELD_SIZE && c.Size != other.Size:
+		return c.Size < other.Size
+	case c.Sort == fmpb.SortField_SORT_FIELD_MOD_TIME && c.ModTime != other.ModTime:
+		return c.ModTime < other.ModTime
+	}
+	return c.Name < other.Name
+}
+
+// contentType guesses the MIME type of a file from its name.
+func contentType(name string) string {
+	if ct := mime.TypeByExtension(filepath.Ext(name)); ct != "" {
+		return ct
+	}
+	return "application/octet-stream"
+}
+
+// sniffContentType guesses the MIME type of a file from its name, and from
+// its first bytes if the name does not tell.
+func (srv *FileService) sniffContentType(ctx context.Context, path string) string {
+	if ct := mime.TypeByExtension(filepath.Ext(path)); ct != "" {
+		return ct
+	}
+
+	file, err := srv.repo.GetFileHandle(ctx, path, repository.Read)
+	if err != nil {
+		return "application/octet-stream"
+	}
+	defer file.Close()
+
+	head := make([]byte, 512)
+	n, _ := io.ReadFull(file, head)
+	return http.DetectContentType(head[:n])
+}
+
+// checksum returns the SHA-256 of the content of path.
+func (srv *FileService) checksum(ctx context.Context, path string) (string, error) {
+	file, err := srv.repo.GetFileHandle(ctx, path, repository.Read)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err = io.Copy(hash, file); err != nil {
+		return "", err
+	}
+	return "sha256:" + hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+func (srv *FileService) toFileStat(ctx context.Context, path string, info fs.FileInfo, withChecksum bool) (*fmpb.FileStat, error) {
+	stat := &fmpb.FileStat{
+		Name:    filepath.Base(path),
+		Path:    path,
+		IsDir:   info.IsDir(),
+		ModTime: info.ModTime().Unix(),
+		Mode:    uint32(info.Mode()),
+	}
+	if info.IsDir() {
+		return stat, nil
+	}
+
+	stat.Size = info.Size()
+	stat.PhysicalSize = repository.PhysicalSize(info)
+	stat.ContentType = contentType(stat.Name)
+	if withChecksum {
+		sum, err := srv.checksum(ctx, path)
+		if err != nil {
+			return nil, err
+		}
+		stat.Checksum = sum
+	}
+	return stat, nil
+}
+
+func (srv *FileService) Stat(ctx context.Context, req *fmpb.StatRequest) (*fmpb.FileStat, error) {
+	lg := logger.GetLoggerFromContext(ctx)
+
+	lg.Info(ctx, "Stat is in process")
+	if err := checkPath(req.Path); err != nil {
+		return nil, err
+	}
+	if err := srv.acl.Check(ctx, repository.PermList, req.Path); err != nil {
+		return nil, err
+	}
+	// The checksum and the sniffed type tell about the content of the file.
+	if req.Checksum {
+		if err := srv.acl.Check(ctx, repository.PermRead, req.Path); err != nil {
+			return nil, err
+		}
+	}
+
+	info, err := srv.repo.Stat(ctx, req.Path)
+	if err != nil {
+		lg.Error(ctx, "Error to stat path", zap.String("path", req.Path), zap.Error(err))
+		return nil, err
+	}
+
+	stat, err := srv.toFileStat(ctx, req.Path, info, req.Checksum)
+	if err != nil {
+		lg.Error(ctx, "Error to describe path", zap.String("path", req.Path), zap.Error(err))
+		return nil, err
+	}
+	if !stat.IsDir {
+		if readable, _ := srv.acl.Allowed(ctx, repository.PermRead, req.Path); readable {
+			stat.ContentType = srv.sniffContentType(ctx, req.Path)
+		}
+	}
+	return stat, nil
+}
+
+// ListEntries lists one page of a directory. Entries are only stat'ed when
+// the sort order needs it or they are on the returned page.
+func (srv *FileService) ListEntries(ctx context.Context, req *fmpb.ListDirectoryRequest) (*fmpb.ListDirectoryResponse, error) {
+	lg := logger.GetLoggerFromContext(ctx)
+
+	lg.Info(ctx, "ListEntries is in process")
+	if err := checkPath(req.Path); err != nil {
+		return nil, err
+	}
+	if err := srv.acl.Check(ctx, repository.PermList, req.Path); err != nil {
+		return nil, err
+	}
+	if _, err := filepath.Match(req.Pattern, ""); err != nil {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidPattern, req.Pattern)
+	}
+
+	pageSize := int(req.PageSize)
+	if pageSize <= 0 {
+		pageSize = defaultPageSize
+	}
+	pageSize = min(pageSize, maxPageSize)
+
+	var after *listCursor
+	if req.Cursor != "" {
+		var err error
+		if after, err = decodeCursor(req.Cursor); err != nil {
+			return nil, err
+		}
+		if after.Sort != req.SortBy || after.Desc != req.Descending {
+			return nil, fmt.Errorf("%w: sort order changed", ErrInvalidCursor)
+		}
+	}
+
+	entries, err := srv.repo.ListDir(ctx, req.Path)
+	if err != nil {
+		lg.Error(ctx, "Error to list dir", zap.String("path", req.Path), zap.Error(err))
+		return nil, err
+	}
+
+	statItem := func(item *listItem) (bool, error) {
+		info, err := srv.repo.Stat(ctx, filepath.Join(req.Path, item.entry.Name))
+		if err != nil {
+			if os.IsNotExist(err) {
+				// Removed since the listing.
+				return false, nil
+			}
+			return false, err
+		}
+		item.info = info
+		return true, nil
+	}
+
+	items := make([]listItem, 0, len(entries))
+	for _, entry := range entries {
+		if checkPath(filepath.Join(req.Path, entry.Name)) != nil {
+			continue
+		}
+		if req.Pattern != "" {
+			if ok, _ := filepath.Match(req.Pattern, entry.Name); !ok {
+				continue
+			}
+		}
+
+		item := listItem{entry: entry}
+		if req.SortBy != fmpb.SortField_SORT_FIELD_NAME {
+			ok, err := statItem(&item)
+			if err != nil {
+				lg.Error(ctx, "Error to stat entry", zap.String("name", entry.Name), zap.Error(err))
+				return nil, err
+			}
+			if !ok {
+				continue
+			}
+		}
+		items = append(items, item)
+	}
+
+	less := func(a, b listItem) bool {
+		ka, kb := a.cursor(req.SortBy, req.Descending), b.cursor(req.SortBy, req.Descending)
+		if req.Descending {
+			return kb.before(ka)
+		}
+		return ka.before(kb)
+	}
+	sort.Slice(items, func(i, j int) bool { return less(items[i], items[j]) })
+
+	start := 0
+	if after != nil {
+		start = sort.Search(len(items), func(i int) bool {
+			key := items[i].cursor(req.SortBy, req.Descending)
+			if req.Descending {
+				return key.before(*after)
+			}
+			return after.before(key)
+		})
+	}
+
+	res := &fmpb.ListDirectoryResponse{}
+	for i := start; i < len(items) && len(res.Entries) < pageSize; i++ {
+		item := items[i]
+		if item.info == nil {
+			ok, err := statItem(&item)
+			if err != nil {
+				lg.Error(ctx, "Error to stat entry", zap.String("name", item.entry.Name), zap.Error(err))
+				return nil, err
+			}
+			if !ok {
+				continue
+			}
+		}
+
+		entryPath := filepath.Join(req.Path, item.entry.Name)
+		// Checksums are only given for entries the caller may read.
+		withChecksum := req.Checksum
+		if withChecksum {
+			if withChecksum, err = srv.acl.Allowed(ctx, repository.PermRead, entryPath); err != nil {
+				return nil, err
+			}
+		}
+
+		stat, err := srv.toFileStat(ctx, entryPath, item.info, withChecksum)
+		if err != nil {
+			lg.Error(ctx, "Error to describe entry", zap.String("name", item.entry.Name), zap.Error(err))
+			return nil, err
+		}
+		res.Entries = append(res.Entries, stat)
+
+		if len(res.Entries) == pageSize && i+1 < len(items) {
+			res.NextCursor = item.cursor(req.SortBy, req.Descending).encode()
+		}
+	}
+
+	return res, nil
+}
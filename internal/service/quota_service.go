@@ -0,0 +1,91 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/JunBSer/FileManager/internal/repository"
+	"github.com/JunBSer/FileManager/pkg/api/fmpb"
+	"github.com/JunBSer/FileManager/pkg/logger"
+	"go.uber.org/zap"
+)
+
+var ErrQuotasDisabled = errors.New("quotas are disabled")
+
+type QuotaService struct {
+	quotas *repository.QuotaRepo
+	acl    *ACL
+}
+
+// NewQuotaService creates the usage report service. quotas may be nil, then
+// every request fails with ErrQuotasDisabled. acl may be nil, then every
+// caller sees the usage of every namespace.
+func NewQuotaService(quotas *repository.QuotaRepo, acl *ACL) *QuotaService {
+	return &QuotaService{quotas: quotas, acl: acl}
+}
+
+func toProtoUsage(usage repository.Usage) *fmpb.NamespaceUsage {
+	return &fmpb.NamespaceUsage{
+		Namespace: repository.NamespaceName(usage.Namespace),
+		Bytes:     usage.Bytes,
+		Files:     usage.Files,
+		MaxBytes:  usage.Limit.Bytes,
+		MaxFiles:  usage.Limit.Files,
+	}
+}
+
+func (srv *QuotaService) GetUsage(ctx context.Context, req *fmpb.GetUsageRequest) (*fmpb.NamespaceUsage, error) {
+	lg := logger.GetLoggerFromContext(ctx)
+
+	lg.Info(ctx, "GetUsage is in process")
+	if srv.quotas == nil {
+		return nil, ErrQuotasDisabled
+	}
+
+	ns, err := repository.ParseNamespace(req.Namespace)
+	if err != nil {
+		return nil, err
+	}
+	if err = srv.acl.Check(ctx, repository.PermList, ns); err != nil {
+		return nil, err
+	}
+
+	var usage repository.Usage
+	if req.Recount {
+		usage, err = srv.quotas.Recount(ctx, ns)
+	} else {
+		usage, err = srv.quotas.Usage(ctx, ns)
+	}
+	if err != nil {
+		lg.Error(ctx, "Error to get usage", zap.String("namespace", req.Namespace), zap.Error(err))
+		return nil, err
+	}
+
+	return toProtoUsage(usage), nil
+}
+
+func (srv *QuotaService) ListUsage(ctx context.Context, req *fmpb.ListUsageRequest) (*fmpb.ListUsageResponse, error) {
+	lg := logger.GetLoggerFromContext(ctx)
+
+	lg.Info(ctx, "ListUsage is in process")
+	if srv.quotas == nil {
+		return nil, ErrQuotasDisabled
+	}
+
+	usage, err := srv.quotas.List(ctx)
+	if err != nil {
+		lg.Error(ctx, "Error to list usage", zap.Error(err))
+		return nil, err
+	}
+
+	res := &fmpb.ListUsageResponse{}
+	for _, u := range usage {
+		ok, err := srv.acl.Allowed(ctx, repository.PermList, u.Namespace)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			res.Namespaces = append(res.Namespaces, toProtoUsage(u))
+		}
+	}
+	return res, nil
+}
@@ -0,0 +1,108 @@
+package service
+
+import (
+	"github.com/JunBSer/FileManager/internal/repository"
+	"github.com/JunBSer/FileManager/pkg/api/fmpb"
+	"github.com/JunBSer/FileManager/pkg/logger"
+	"go.uber.org/zap"
+	"io"
+)
+
+// ResolveRanges turns requested ranges into absolute ranges of a file with the
+// given size. Ranges that start beyond the end of the file are dropped, so an
+// empty result for a non-empty request means that nothing can be satisfied.
+func ResolveRanges(ranges []*fmpb.ByteRange, size int64) []*fmpb.ByteRange {
+	if len(ranges) == 0 {
+		ranges = []*fmpb.ByteRange{{Offset: 0}}
+	}
+
+	resolved := make([]*fmpb.ByteRange, 0, len(ranges))
+	for _, r := range ranges {
+		offset, length := r.Offset, r.Length
+
+		if offset < 0 {
+			length = -offset
+			// -offset overflows for the smallest int64.
+			if length > size || length < 0 {
+				length = size
+			}
+			offset = size - length
+		} else if length <= 0 || length > size-offset {
+			length = size - offset
+		}
+
+		if offset >= size || length <= 0 {
+			continue
+		}
+		resolved = append(resolved, &fmpb.ByteRange{Offset: offset, Length: length})
+	}
+
+	return resolved
+}
+
+func (srv *FileService) ProcessRange(file repository.FileHandle, stream fmpb.RangeReadService_ReadRangeServer, index int, r *fmpb.ByteRange) error {
+	if _, err := file.Seek(r.Offset, io.SeekStart); err != nil {
+		return err
+	}
+
+	reader := io.LimitReader(file, r.Length)
+	bufLen := srv.repo.GetReadSize()
+	for {
+		buf := make([]byte, bufLen)
+		n, err := reader.Read(buf)
+		if n > 0 {
+			sendErr := stream.Send(&fmpb.RangeChunk{RangeIndex: int32(index), Content: buf[:n]})
+			if sendErr != nil {
+				return sendErr
+			}
+		}
+		if err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+	}
+}
+
+func (srv *FileService) ReadRange(req *fmpb.ReadRangeRequest, stream fmpb.RangeReadService_ReadRangeServer) error {
+	ctx := stream.Context()
+	lg := logger.GetLoggerFromContext(ctx)
+
+	lg.Info(ctx, "ReadRange is in process")
+
+	if err := checkPath(req.FileName); err != nil {
+		return err
+	}
+	if err := srv.acl.Check(ctx, repository.PermRead, req.FileName); err != nil {
+		return err
+	}
+
+	file, err := srv.repo.GetFileHandle(ctx, req.FileName, repository.Read)
+	if err != nil {
+		lg.Error(ctx, "Error to open file", zap.Error(err))
+		return err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		lg.Error(ctx, "Error to get file info", zap.Error(err))
+		return err
+	}
+
+	ranges := ResolveRanges(req.Ranges, info.Size())
+	err = stream.Send(&fmpb.RangeChunk{FileSize: info.Size(), Ranges: ranges})
+	if err != nil {
+		return err
+	}
+
+	for i, r := range ranges {
+		if err = srv.ProcessRange(file, stream, i, r); err != nil {
+			lg.Error(ctx, "Error to read range", zap.Int64("offset", r.Offset), zap.Int64("length", r.Length), zap.Error(err))
+			return err
+		}
+	}
+
+	return nil
+}
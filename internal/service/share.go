@@ -0,0 +1,289 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"github.com/JunBSer/FileManager/internal/repository"
+	"github.com/JunBSer/FileManager/pkg/api/fmpb"
+	"github.com/JunBSer/FileManager/pkg/logger"
+	"go.uber.org/zap"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultShareTTL is the lifetime of shares created without one.
+const defaultShareTTL = 24 * time.Hour
+
+var (
+	ErrSharingDisabled   = errors.New("sharing is disabled")
+	ErrInvalidShareToken = errors.New("invalid share token")
+)
+
+type shareKey struct{}
+
+// NewShareContext returns a context for a call authorized by a share token.
+// The share is stored in the returned share once the path of the call is
+// known; until then the caller may access nothing.
+func NewShareContext(ctx context.Context) (context.Context, *repository.Share) {
+	share := &repository.Share{}
+	return context.WithValue(ctx, shareKey{}, share), share
+}
+
+func shareFromContext(ctx context.Context) (*repository.Share, bool) {
+	share, ok := ctx.Value(shareKey{}).(*repository.Share)
+	return share, ok
+}
+
+func sharePermission(operation string) (repository.Permission, error) {
+	switch operation {
+	case repository.ShareDownload:
+		return repository.PermRead, nil
+	case repository.ShareUpload:
+		return repository.PermWrite, nil
+	}
+	return 0, fmt.Errorf("%w: unknown operation %q, want %s or %s",
+		repository.ErrInvalidShare, operation, repository.ShareDownload, repository.ShareUpload)
+}
+
+// checkShare fails unless share allows perm on every path.
+func checkShare(share *repository.Share, perm repository.Permission, paths []string) error {
+	allowed, err := sharePermission(share.Operation)
+	if err != nil || perm&^allowed != 0 {
+		return fmt.Errorf("%w: share may not %s", ErrPermissionDenied, perm)
+	}
+	for _, path := range paths {
+		if cleanSharePath(path) != share.Path {
+			return fmt.Errorf("%w: share does not cover %s", ErrPermissionDenied, path)
+		}
+	}
+	return nil
+}
+
+func cleanSharePath(path string) string {
+	return strings.TrimPrefix(filepath.Clean("/"+path), "/")
+}
+
+// checkShareSize fails if the file at path is larger than the bytes the share
+// of ctx has left.
+func (srv *FileService) checkShareSize(ctx context.Context, path string) error {
+	share, ok := shareFromContext(ctx)
+	if !ok || share.RemainingBytes() < 0 {
+		return nil
+	}
+
+	info, err := srv.repo.Stat(ctx, path)
+	if err != nil {
+		return err
+	}
+	if info.Size() > share.RemainingBytes() {
+		return fmt.Errorf("%w: %d bytes left, file has %d", repository.ErrShareUsedUp, share.RemainingBytes(), info.Size())
+	}
+	return nil
+}
+
+type ShareService struct {
+	store  *repository.ShareStore
+	secret []byte
+	maxTTL time.Duration
+	acl    *ACL
+}
+
+// NewShareService creates the share service. Sharing is disabled if cfg has
+// no secret.
+func NewShareService(store *repository.ShareStore, cfg repository.ShareConfig, acl *ACL) *ShareService {
+	srv := &ShareService{store: store, maxTTL: cfg.MaxTTL, acl: acl}
+	if cfg.Secret != "" {
+		srv.secret = []byte(cfg.Secret)
+	}
+	return srv
+}
+
+// sign returns the signature of a token for the share id. It covers the
+// path, so a token cannot be used for another file.
+func (srv *ShareService) sign(id, operation string, expires int64, path string) string {
+	mac := hmac.New(sha256.New, srv.secret)
+	fmt.Fprintf(mac, "%s\n%s\n%d\n%s", id, operation, expires, path)
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// token returns the token of share: its ID, operation and expiry followed by
+// the signature.
+func (srv *ShareService) token(share repository.Share) string {
+	expires := share.ExpiresAt.Unix()
+	return fmt.Sprintf("%s.%s.%d.%s", share.ID, share.Operation, expires, srv.sign(share.ID, share.Operation, expires, share.Path))
+}
+
+// Redeem checks that token allows operation on path and counts one use of
+// its share.
+func (srv *ShareService) Redeem(ctx context.Context, token, operation, path string) (repository.Share, error) {
+	if srv.secret == nil {
+		return repository.Share{}, ErrSharingDisabled
+	}
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 4 || parts[1] != operation {
+		return repository.Share{}, ErrInvalidShareToken
+	}
+	expires, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil {
+		return repository.Share{}, ErrInvalidShareToken
+	}
+
+	path = cleanSharePath(path)
+	if !hmac.Equal([]byte(parts[3]), []byte(srv.sign(parts[0], operation, expires, path))) {
+		return repository.Share{}, ErrInvalidShareToken
+	}
+	if time.Now().Unix() >= expires {
+		return repository.Share{}, fmt.Errorf("%w: %s", repository.ErrShareExpired, parts[0])
+	}
+
+	share, err := srv.store.Use(ctx, parts[0])
+	if err != nil {
+		return repository.Share{}, err
+	}
+	if share.Path != path || share.Operation != operation {
+		return repository.Share{}, ErrInvalidShareToken
+	}
+
+	logger.GetLoggerFromContext(ctx).Info(ctx, "Share used", zap.String("id", share.ID),
+		zap.String("path", share.Path), zap.String("operation", share.Operation), zap.Int64("uses", share.Uses))
+	return share, nil
+}
+
+// AddBytes counts n bytes transferred with the share id.
+func (srv *ShareService) AddBytes(ctx context.Context, id string, n int64) error {
+	if n == 0 {
+		return nil
+	}
+	_, err := srv.store.AddBytes(ctx, id, n)
+	return err
+}
+
+func toProtoShare(share repository.Share) *fmpb.Share {
+	return &fmpb.Share{
+		Id:        share.ID,
+		Path:      share.Path,
+		Operation: share.Operation,
+		ExpiresAt: share.ExpiresAt.Unix(),
+		MaxUses:   share.MaxUses,
+		MaxBytes:  share.MaxBytes,
+		Uses:      share.Uses,
+		Bytes:     share.Bytes,
+		CreatedBy: share.CreatedBy,
+		CreatedAt: share.CreatedAt.Unix(),
+	}
+}
+
+// CreateShare creates a share of a path the caller has the permission of the
+// operation on.
+func (srv *ShareService) CreateShare(ctx context.Context, req *fmpb.CreateShareRequest) (*fmpb.Share, error) {
+	lg := logger.GetLoggerFromContext(ctx)
+
+	lg.Info(ctx, "CreateShare is in process")
+	if srv.secret == nil {
+		return nil, ErrSharingDisabled
+	}
+	if err := checkPath(req.Path); err != nil {
+		return nil, err
+	}
+	perm, err := sharePermission(req.Operation)
+	if err != nil {
+		return nil, err
+	}
+	if err = srv.acl.Check(ctx, perm, req.Path); err != nil {
+		return nil, err
+	}
+
+	ttl := time.Duration(req.TtlSeconds) * time.Second
+	switch {
+	case ttl < 0:
+		return nil, fmt.Errorf("%w: negative lifetime", repository.ErrInvalidShare)
+	case ttl == 0:
+		ttl = defaultShareTTL
+		if srv.maxTTL > 0 {
+			ttl = min(ttl, srv.maxTTL)
+		}
+	case srv.maxTTL > 0 && ttl > srv.maxTTL:
+		return nil, fmt.Errorf("%w: lifetime %s exceeds %s", repository.ErrInvalidShare, ttl, srv.maxTTL)
+	}
+
+	subject, _ := callerPrincipals(ctx)
+	now := time.Now()
+	share, err := srv.store.Create(ctx, repository.Share{
+		Path:      cleanSharePath(req.Path),
+		Operation: req.Operation,
+		ExpiresAt: now.Add(ttl).Truncate(time.Second),
+		MaxUses:   req.MaxUses,
+		MaxBytes:  req.MaxBytes,
+		CreatedBy: subject,
+		CreatedAt: now,
+	})
+	if err != nil {
+		lg.Error(ctx, "Error to create share", zap.String("path", req.Path), zap.Error(err))
+		return nil, err
+	}
+
+	res := toProtoShare(share)
+	res.Token = srv.token(share)
+	return res, nil
+}
+
+// owns reports whether the caller of ctx may see and revoke share. Admins
+// and, without access control, everybody own every share.
+func (srv *ShareService) owns(ctx context.Context, share repository.Share) bool {
+	subject, principals := callerPrincipals(ctx)
+	return srv.acl == nil || srv.acl.isAdmin(principals) || share.CreatedBy == subject
+}
+
+func (srv *ShareService) ListShares(ctx context.Context, _ *fmpb.ListSharesRequest) (*fmpb.ListSharesResponse, error) {
+	lg := logger.GetLoggerFromContext(ctx)
+
+	lg.Info(ctx, "ListShares is in process")
+	if srv.secret == nil {
+		return nil, ErrSharingDisabled
+	}
+
+	shares, err := srv.store.List(ctx)
+	if err != nil {
+		lg.Error(ctx, "Error to list shares", zap.Error(err))
+		return nil, err
+	}
+
+	res := &fmpb.ListSharesResponse{}
+	for _, share := range shares {
+		if srv.owns(ctx, share) {
+			res.Shares = append(res.Shares, toProtoShare(share))
+		}
+	}
+	return res, nil
+}
+
+func (srv *ShareService) RevokeShare(ctx context.Context, req *fmpb.RevokeShareRequest) error {
+	lg := logger.GetLoggerFromContext(ctx)
+
+	lg.Info(ctx, "RevokeShare is in process")
+	if srv.secret == nil {
+		return ErrSharingDisabled
+	}
+
+	share, err := srv.store.Get(ctx, req.Id)
+	if err != nil {
+		return err
+	}
+	if !srv.owns(ctx, share) {
+		return fmt.Errorf("%w: share %s belongs to another caller", ErrPermissionDenied, req.Id)
+	}
+
+	if err = srv.store.Remove(ctx, req.Id); err != nil {
+		lg.Error(ctx, "Error to revoke share", zap.String("id", req.Id), zap.Error(err))
+		return err
+	}
+	lg.Info(ctx, "Share revoked", zap.String("id", req.Id), zap.String("path", share.Path))
+	return nil
+}
@@ -0,0 +1,110 @@
+package service
+
+import (
+	"context"
+	"github.com/JunBSer/FileManager/internal/repository"
+	"github.com/JunBSer/FileManager/pkg/api/fmpb"
+	"github.com/JunBSer/FileManager/pkg/logger"
+	"go.uber.org/zap"
+)
+
+type TrashService struct {
+	trash *repository.Trash
+	acl   *ACL
+}
+
+// NewTrashService creates the trash service. acl may be nil, then every
+// caller may see and restore every deleted file.
+func NewTrashService(trash *repository.Trash, acl *ACL) *TrashService {
+	return &TrashService{trash: trash, acl: acl}
+}
+
+func toProtoTrashEntry(entry *repository.TrashEntry) *fmpb.TrashEntry {
+	return &fmpb.TrashEntry{
+		EntryId:   entry.ID,
+		FileName:  entry.Path,
+		Size:      entry.Size,
+		DeletedAt: entry.DeletedAt.Unix(),
+	}
+}
+
+func (srv *TrashService) List(ctx context.Context, req *fmpb.ListTrashRequest) (*fmpb.ListTrashResponse, error) {
+	lg := logger.GetLoggerFromContext(ctx)
+
+	lg.Info(ctx, "ListTrash is in process")
+	if err := checkPath(req.Path); err != nil {
+		return nil, err
+	}
+
+	entries, err := srv.trash.List(ctx, req.Path)
+	if err != nil {
+		lg.Error(ctx, "Error to list trash", zap.String("path", req.Path), zap.Error(err))
+		return nil, err
+	}
+
+	res := &fmpb.ListTrashResponse{}
+	for i := range entries {
+		// Deleted files are only listed to callers who may list their path.
+		ok, err := srv.acl.Allowed(ctx, repository.PermList, entries[i].Path)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			res.Entries = append(res.Entries, toProtoTrashEntry(&entries[i]))
+		}
+	}
+	return res, nil
+}
+
+func (srv *TrashService) Restore(ctx context.Context, req *fmpb.RestoreRequest) (*fmpb.TrashEntry, error) {
+	lg := logger.GetLoggerFromContext(ctx)
+
+	lg.Info(ctx, "Restore is in process")
+	if err := checkPath(req.Destination); err != nil {
+		return nil, err
+	}
+	if srv.acl != nil {
+		entry, err := srv.trash.Get(ctx, req.EntryId)
+		if err != nil {
+			return nil, err
+		}
+		target := entry.Path
+		if req.Destination != "" {
+			target = req.Destination
+		}
+		if err = srv.acl.Check(ctx, repository.PermRead, entry.Path); err != nil {
+			return nil, err
+		}
+		if err = srv.acl.Check(ctx, repository.PermWrite, target); err != nil {
+			return nil, err
+		}
+	}
+
+	entry, err := srv.trash.Restore(ctx, req.EntryId, req.Destination)
+	if err != nil {
+		lg.Error(ctx, "Error to restore from trash", zap.String("entryID", req.EntryId), zap.Error(err))
+		return nil, err
+	}
+
+	return toProtoTrashEntry(entry), nil
+}
+
+func (srv *TrashService) Empty(ctx context.Context, req *fmpb.EmptyTrashRequest) (*fmpb.EmptyTrashResponse, error) {
+	lg := logger.GetLoggerFromContext(ctx)
+
+	lg.Info(ctx, "EmptyTrash is in process")
+	if err := checkPath(req.Path); err != nil {
+		return nil, err
+	}
+	if err := srv.acl.Check(ctx, repository.PermDelete, req.Path); err != nil {
+		return nil, err
+	}
+
+	removed, err := srv.trash.Empty(ctx, req.Path, 0)
+	if err != nil {
+		lg.Error(ctx, "Error to empty trash", zap.String("path", req.Path), zap.Error(err))
+		return nil, err
+	}
+
+	return &fmpb.EmptyTrashResponse{Removed: int32(removed)}, nil
+}
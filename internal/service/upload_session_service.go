@@ -0,0 +1,180 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"github.com/JunBSer/FileManager/internal/repository"
+	"github.com/JunBSer/FileManager/pkg/api/fmpb"
+	"github.com/JunBSer/FileManager/pkg/logger"
+	"go.uber.org/zap"
+	"io"
+)
+
+type UploadSessionService struct {
+	store    *repository.UploadSessionStore
+	versions *repository.VersionStore
+	acl      *ACL
+}
+
+// NewUploadSessionService creates the upload session service. versions may be
+// nil, then files replaced by finalized sessions are not kept. acl may be nil,
+// then every caller may upload everywhere.
+func NewUploadSessionService(store *repository.UploadSessionStore, versions *repository.VersionStore, acl *ACL) *UploadSessionService {
+	return &UploadSessionService{store: store, versions: versions, acl: acl}
+}
+
+func toProtoSession(session *repository.UploadSession) *fmpb.UploadSession {
+	return &fmpb.UploadSession{
+		UploadId:        session.ID,
+		FileName:        session.FileName,
+		CommittedOffset: session.Offset,
+		TotalSize:       session.TotalSize,
+		CreatedAt:       session.CreatedAt.Unix(),
+		Sha256:          session.SHA256,
+	}
+}
+
+func (srv *UploadSessionService) Create(ctx context.Context, req *fmpb.CreateUploadSessionRequest) (*fmpb.UploadSession, error) {
+	lg := logger.GetLoggerFromContext(ctx)
+
+	lg.Info(ctx, "CreateUploadSession is in process")
+	if err := srv.acl.Check(ctx, repository.PermWrite, req.FileName); err != nil {
+		return nil, err
+	}
+
+	var sum string
+	if req.Sha256 != "" {
+		var err error
+		if sum, err = parseSHA256(req.Sha256); err != nil {
+			return nil, err
+		}
+	}
+
+	session, err := srv.store.Create(ctx, req.FileName, req.TotalSize, sum)
+	if err != nil {
+		lg.Error(ctx, "Error to create upload session", zap.Error(err))
+		return nil, err
+	}
+
+	return toProtoSession(session), nil
+}
+
+func (srv *UploadSessionService) UploadChunks(stream fmpb.UploadSessionService_UploadChunksServer) (*fmpb.UploadSession, error) {
+	ctx := stream.Context()
+	lg := logger.GetLoggerFromContext(ctx)
+
+	lg.Info(ctx, "UploadChunks is in process")
+
+	chunk, err := stream.Recv()
+	if err != nil {
+		lg.Error(ctx, "Error to request data", zap.Error(err))
+		return nil, err
+	}
+
+	writer, err := srv.store.Open(ctx, chunk.UploadId)
+	if err != nil {
+		lg.Error(ctx, "Error to open upload session", zap.String("uploadID", chunk.UploadId), zap.Error(err))
+		return nil, err
+	}
+	defer writer.Close()
+
+	if err = srv.acl.Check(ctx, repository.PermWrite, writer.Session().FileName); err != nil {
+		return nil, err
+	}
+
+	uploadID := chunk.UploadId
+	for {
+		if chunk.UploadId != uploadID {
+			return toProtoSession(writer.Session()), fmt.Errorf("chunk belongs to another upload session %q", chunk.UploadId)
+		}
+		if chunk.Crc32C != nil && CRC32C(chunk.Content) != *chunk.Crc32C {
+			return toProtoSession(writer.Session()), fmt.Errorf("%w: crc32c of chunk at offset %d", repository.ErrChecksumMismatch, chunk.Offset)
+		}
+
+		if err = writer.Write(ctx, chunk.Offset, chunk.Content); err != nil {
+			lg.Error(ctx, "Error to write chunk", zap.String("uploadID", uploadID), zap.Error(err))
+			return toProtoSession(writer.Session()), err
+		}
+
+		chunk, err = stream.Recv()
+		if err != nil {
+			if err == io.EOF {
+				lg.Info(ctx, "EOF received")
+				return toProtoSession(writer.Session()), nil
+			}
+			lg.Error(ctx, "Error to read data", zap.Error(err))
+			return toProtoSession(writer.Session()), err
+		}
+	}
+}
+
+// session returns upload session id if the caller of ctx may write its file.
+func (srv *UploadSessionService) session(ctx context.Context, id string) (*repository.UploadSession, error) {
+	session, err := srv.store.Get(ctx, id)
+	if err != nil {
+		logger.GetLoggerFromContext(ctx).Error(ctx, "Error to get upload session", zap.Error(err))
+		return nil, err
+	}
+	if err = srv.acl.Check(ctx, repository.PermWrite, session.FileName); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+func (srv *UploadSessionService) Get(ctx context.Context, req *fmpb.UploadSessionRequest) (*fmpb.UploadSession, error) {
+	session, err := srv.session(ctx, req.UploadId)
+	if err != nil {
+		return nil, err
+	}
+
+	return toProtoSession(session), nil
+}
+
+func (srv *UploadSessionService) Finalize(ctx context.Context, req *fmpb.UploadSessionRequest) (*fmpb.UploadSession, error) {
+	lg := logger.GetLoggerFromContext(ctx)
+
+	lg.Info(ctx, "FinalizeUploadSession is in process")
+
+	session, err := srv.session(ctx, req.UploadId)
+	if err != nil {
+		return nil, err
+	}
+
+	// The replaced file is kept only once the upload has been checked.
+	var replace func(*repository.UploadSession) error
+	if srv.versions != nil {
+		replace = func(session *repository.UploadSession) error {
+			if _, err := srv.versions.Save(ctx, session.FileName, "upload"); err != nil {
+				lg.Error(ctx, "Error to save replaced file", zap.Error(err))
+				return err
+			}
+			return nil
+		}
+	}
+
+	session, err = srv.store.Finalize(ctx, req.UploadId, replace)
+	if err != nil {
+		lg.Error(ctx, "Error to finalize upload session", zap.Error(err))
+		return nil, err
+	}
+
+	return toProtoSession(session), nil
+}
+
+func (srv *UploadSessionService) Abort(ctx context.Context, req *fmpb.UploadSessionRequest) (*fmpb.UploadSession, error) {
+	lg := logger.GetLoggerFromContext(ctx)
+
+	lg.Info(ctx, "AbortUploadSession is in process")
+
+	if _, err := srv.session(ctx, req.UploadId); err != nil {
+		return nil, err
+	}
+
+	session, err := srv.store.Abort(ctx, req.UploadId)
+	if err != nil {
+		lg.Error(ctx, "Error to abort upload session", zap.Error(err))
+		return nil, err
+	}
+
+	return toProtoSession(session), nil
+}
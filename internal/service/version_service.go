@@ -0,0 +1,163 @@
+package service
+
+import (
+	"context"
+	"github.com/JunBSer/FileManager/internal/repository"
+	"github.com/JunBSer/FileManager/pkg/api/fmpb"
+	"github.com/JunBSer/FileManager/pkg/logger"
+	"go.uber.org/zap"
+	"io"
+	"time"
+)
+
+type VersionService struct {
+	store *repository.VersionStore
+	repo  repository.FileRepository
+	acl   *ACL
+}
+
+// NewVersionService creates the version service. acl may be nil, then every
+// caller may access every version.
+func NewVersionService(store *repository.VersionStore, repo repository.FileRepository, acl *ACL) *VersionService {
+	return &VersionService{store: store, repo: repo, acl: acl}
+}
+
+func toProtoVersion(version *repository.Version) *fmpb.FileVersion {
+	return &fmpb.FileVersion{
+		VersionId: version.ID,
+		FileName:  version.Path,
+		Size:      version.Size,
+		CreatedAt: version.CreatedAt.Unix(),
+		Reason:    version.Reason,
+	}
+}
+
+func toProtoRule(path string, rule repository.RetentionRule) *fmpb.RetentionRule {
+	return &fmpb.RetentionRule{
+		Path:          path,
+		KeepVersions:  int32(rule.Keep),
+		MaxAgeSeconds: int64(rule.MaxAge / time.Second),
+	}
+}
+
+func (srv *VersionService) List(ctx context.Context, req *fmpb.ListVersionsRequest) (*fmpb.ListVersionsResponse, error) {
+	lg := logger.GetLoggerFromContext(ctx)
+
+	lg.Info(ctx, "ListVersions is in process")
+	if err := checkPath(req.FileName); err != nil {
+		return nil, err
+	}
+	if err := srv.acl.Check(ctx, repository.PermList, req.FileName); err != nil {
+		return nil, err
+	}
+
+	versions, err := srv.store.List(ctx, req.FileName)
+	if err != nil {
+		lg.Error(ctx, "Error to list versions", zap.String("fileName", req.FileName), zap.Error(err))
+		return nil, err
+	}
+
+	res := &fmpb.ListVersionsResponse{}
+	for i := range versions {
+		res.Versions = append(res.Versions, toProtoVersion(&versions[i]))
+	}
+	return res, nil
+}
+
+func (srv *VersionService) Download(req *fmpb.VersionRequest, stream fmpb.VersionService_DownloadVersionServer) error {
+	ctx := stream.Context()
+	lg := logger.GetLoggerFromContext(ctx)
+
+	lg.Info(ctx, "DownloadVersion is in process")
+	if err := checkPath(req.FileName); err != nil {
+		return err
+	}
+	if err := srv.acl.Check(ctx, repository.PermRead, req.FileName); err != nil {
+		return err
+	}
+
+	file, err := srv.store.Open(ctx, req.FileName, req.VersionId)
+	if err != nil {
+		lg.Error(ctx, "Error to open version", zap.String("versionID", req.VersionId), zap.Error(err))
+		return err
+	}
+	defer file.Close()
+
+	buf := make([]byte, srv.repo.GetReadSize())
+	for {
+		n, err := file.Read(buf)
+		if n > 0 {
+			if sendErr := stream.Send(&fmpb.VersionChunk{Content: buf[:n]}); sendErr != nil {
+				return sendErr
+			}
+		}
+		if err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			lg.Error(ctx, "Error to read version", zap.String("versionID", req.VersionId), zap.Error(err))
+			return err
+		}
+	}
+}
+
+func (srv *VersionService) Restore(ctx context.Context, req *fmpb.VersionRequest) (*fmpb.FileVersion, error) {
+	lg := logger.GetLoggerFromContext(ctx)
+
+	lg.Info(ctx, "RestoreVersion is in process")
+	if err := checkPath(req.FileName); err != nil {
+		return nil, err
+	}
+	if err := srv.acl.Check(ctx, repository.PermWrite, req.FileName); err != nil {
+		return nil, err
+	}
+
+	version, err := srv.store.Restore(ctx, req.FileName, req.VersionId)
+	if err != nil {
+		lg.Error(ctx, "Error to restore version", zap.String("versionID", req.VersionId), zap.Error(err))
+		return nil, err
+	}
+
+	return toProtoVersion(version), nil
+}
+
+func (srv *VersionService) GetRetention(ctx context.Context, req *fmpb.RetentionRequest) (*fmpb.RetentionRule, error) {
+	if err := checkPath(req.Path); err != nil {
+		return nil, err
+	}
+	if err := srv.acl.Check(ctx, repository.PermList, req.Path); err != nil {
+		return nil, err
+	}
+
+	rule, err := srv.store.Rule(ctx, req.Path)
+	if err != nil {
+		logger.GetLoggerFromContext(ctx).Error(ctx, "Error to get retention rule", zap.Error(err))
+		return nil, err
+	}
+
+	return toProtoRule(req.Path, rule), nil
+}
+
+func (srv *VersionService) SetRetention(ctx context.Context, req *fmpb.RetentionRule) (*fmpb.RetentionRule, error) {
+	lg := logger.GetLoggerFromContext(ctx)
+
+	lg.Info(ctx, "SetRetention is in process")
+	if err := checkPath(req.Path); err != nil {
+		return nil, err
+	}
+	if err := srv.acl.Check(ctx, repository.PermWrite, req.Path); err != nil {
+		return nil, err
+	}
+
+	err := srv.store.SetRule(ctx, repository.RetentionRule{
+		Dir:    req.Path,
+		Keep:   int(req.KeepVersions),
+		MaxAge: time.Duration(req.MaxAgeSeconds) * time.Second,
+	})
+	if err != nil {
+		lg.Error(ctx, "Error to set retention rule", zap.Error(err))
+		return nil, err
+	}
+
+	return srv.GetRetention(ctx, &fmpb.RetentionRequest{Path: req.Path})
+}
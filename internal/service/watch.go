@@ -0,0 +1,112 @@
+package service
+
+import (
+	"errors"
+	"github.com/JunBSer/FileManager/internal/repository"
+	"github.com/JunBSer/FileManager/pkg/api/fmpb"
+	"github.com/JunBSer/FileManager/pkg/logger"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/metadata"
+	"path"
+	"strings"
+)
+
+var ErrWatchDisabled = errors.New("watching is disabled")
+
+type WatchService struct {
+	events *repository.EventHub
+	acl    *ACL
+}
+
+// NewWatchService creates the watch service. Watching is disabled if events
+// is nil.
+func NewWatchService(events *repository.EventHub, acl *ACL) *WatchService {
+	return &WatchService{events: events, acl: acl}
+}
+
+// below reports whether the event path p is in the watched directory root,
+// or anywhere below it if recursive.
+func below(root, p string, recursive bool) bool {
+	if p == "" {
+		return false
+	}
+	if recursive {
+		return root == "" || strings.HasPrefix(p, root+"/")
+	}
+	dir := path.Dir(p)
+	if dir == "." {
+		dir = ""
+	}
+	return dir == root
+}
+
+// watchEvent returns the event a watcher of root sees for e, or nil if e is
+// not in root. A move across the border of root is a create or a delete.
+func watchEvent(e repository.Event, root string, recursive bool) *fmpb.WatchEvent {
+	res := &fmpb.WatchEvent{Seq: e.Seq, Type: e.Type, Path: e.Path, OldPath: e.OldPath, IsDir: e.IsDir, Time: e.Time.Unix()}
+	if e.Type != repository.EventMove {
+		if !below(root, e.Path, recursive) {
+			return nil
+		}
+		return res
+	}
+
+	to, from := below(root, e.Path, recursive), below(root, e.OldPath, recursive)
+	switch {
+	case to && from:
+	case to:
+		res.Type, res.OldPath = repository.EventCreate, ""
+	case from:
+		res.Type, res.Path, res.OldPath = repository.EventDelete, e.OldPath, ""
+	default:
+		return nil
+	}
+	return res
+}
+
+// Watch sends the changes in the directory of req to stream until the
+// client goes away or falls too far behind.
+func (srv *WatchService) Watch(req *fmpb.WatchRequest, stream fmpb.WatchService_WatchServer) error {
+	ctx := stream.Context()
+	lg := logger.GetLoggerFromContext(ctx)
+
+	lg.Info(ctx, "Watch is in process", zap.String("path", req.Path), zap.Bool("recursive", req.Recursive), zap.Uint64("since", req.Since))
+	if srv.events == nil {
+		return ErrWatchDisabled
+	}
+	if err := checkPath(req.Path); err != nil {
+		return err
+	}
+	if err := srv.acl.Check(ctx, repository.PermList, req.Path); err != nil {
+		return err
+	}
+
+	sub, err := srv.events.Subscribe(req.Since)
+	if err != nil {
+		return err
+	}
+	defer sub.Close()
+	// The headers tell the client that the watch started, before there is
+	// an event to send.
+	if err = stream.SendHeader(metadata.MD{}); err != nil {
+		return err
+	}
+
+	root := repository.EventPath(req.Path)
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case e, ok := <-sub.Events():
+			if !ok {
+				lg.Info(ctx, "Watcher dropped", zap.String("path", req.Path), zap.Error(sub.Err()))
+				return sub.Err()
+			}
+			if res := watchEvent(e, root, req.Recursive); res != nil {
+				if err = stream.Send(res); err != nil {
+					return err
+				}
+			}
+		}
+	}
+}
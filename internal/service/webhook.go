@@ -0,0 +1,686 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/JunBSer/FileManager/internal/repository"
+	"github.com/JunBSer/FileManager/pkg/api/fmpb"
+	"github.com/JunBSer/FileManager/pkg/logger"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Headers of webhook requests.
+const (
+	WebhookSignatureHeader = "X-FM-Signature"
+	WebhookDeliveryHeader  = "X-FM-Delivery"
+	WebhookEventHeader     = "X-FM-Event"
+)
+
+var (
+	ErrWebhooksDisabled = errors.New("webhooks are disabled")
+	ErrWebhooksStopped  = errors.New("webhook delivery is not running")
+	ErrWebhookQueueFull = errors.New("webhook queue is full")
+)
+
+// WebhookPayload is the body posted to a webhook.
+type WebhookPayload struct {
+	ID        string           `json:"id"`
+	WebhookID string           `json:"webhook_id"`
+	Event     repository.Event `json:"event"`
+}
+
+// SignWebhook returns the X-FM-Signature header of a payload sent at
+// timestamp: the timestamp and the hex HMAC-SHA256 of the timestamp, a dot
+// and the body. Receivers should reject old timestamps to stop replays.
+func SignWebhook(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "%d.", timestamp)
+	mac.Write(body)
+	return fmt.Sprintf("t=%d,v1=%s", timestamp, hex.EncodeToString(mac.Sum(nil)))
+}
+
+// WebhookService posts the change events of an EventHub to the webhooks
+// they match. Deliveries are retried with exponential backoff and go to the
+// dead-letter log of the store after the last attempt. Each webhook has its
+// own queue of pending deliveries and is posted to by one worker at a time,
+// so a slow receiver does not hold up the others. A delivery waiting for a
+// retry stays at the head of its queue, so every webhook gets its events in
+// order. Deliveries beyond the size of a queue go to the dead-letter log.
+// Deliveries that are still pending are lost on shutdown.
+type WebhookService struct {
+	store  *repository.WebhookStore
+	events *repository.EventHub
+	cfg    repository.WebhookConfig
+	acl    *ACL
+	client *http.Client
+	// wake tells idle workers that a delivery is pending.
+	wake chan struct{}
+	// since is the last event before the service was created; Run starts
+	// after it.
+	since uint64
+
+	mu sync.Mutex
+	// run is the context of Run; nil until it is called.
+	run context.Context
+	// hooks caches the webhooks of the store; nil until they are loaded.
+	hooks []repository.Webhook
+	// pending holds the queued deliveries of every webhook, busy the
+	// webhooks a worker is posting to or that wait for a retry.
+	pending map[string][]*repository.Delivery
+	busy    map[string]bool
+	history []*repository.Delivery
+}
+
+// NewWebhookService creates the webhook service. Webhooks are disabled if
+// cfg disables them or events is nil.
+func NewWebhookService(store *repository.WebhookStore, events *repository.EventHub, cfg repository.WebhookConfig, acl *ACL) *WebhookService {
+	if !cfg.Enabled {
+		events = nil
+	}
+	cfg.Workers = max(cfg.Workers, 1)
+	cfg.MaxAttempts = max(cfg.MaxAttempts, 1)
+	cfg.QueueSize = max(cfg.QueueSize, 1)
+
+	var since uint64
+	if events != nil {
+		since = events.Seq()
+	}
+
+	return &WebhookService{
+		store:  store,
+		events: events,
+		cfg:    cfg,
+		acl:    acl,
+		client: &http.Client{
+			Timeout: cfg.Timeout,
+			// A redirect could point the request anywhere; it counts as a
+			// failed attempt.
+			CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+		},
+		wake:    make(chan struct{}, cfg.Workers),
+		since:   since,
+		pending: map[string][]*repository.Delivery{},
+		busy:    map[string]bool{},
+	}
+}
+
+// Run delivers events until ctx is done.
+func (srv *WebhookService) Run(ctx context.Context) {
+	if srv.events == nil {
+		return
+	}
+	lg := logger.GetLoggerFromContext(ctx)
+
+	srv.mu.Lock()
+	srv.run = ctx
+	srv.mu.Unlock()
+
+	for i := 0; i < srv.cfg.Workers; i++ {
+		go srv.work(ctx)
+	}
+	lg.Info(ctx, "Webhook delivery started", zap.Int("workers", srv.cfg.Workers))
+
+	since := srv.since
+	for ctx.Err() == nil {
+		sub, err := srv.events.Subscribe(since)
+		if err != nil {
+			// Go on with the oldest event still buffered.
+			oldest := srv.events.Oldest() - 1
+			lg.Error(ctx, "Events for webhooks were lost", zap.Uint64("since", since), zap.Uint64("until", oldest), zap.Error(err))
+			if oldest > since {
+				srv.lost(ctx, since, oldest, err)
+			}
+			since = oldest
+			continue
+		}
+		since = srv.dispatch(ctx, sub, since)
+		sub.Close()
+	}
+}
+
+// dispatch queues a delivery for every webhook each event of sub matches. It
+// returns the sequence number of the last event it handled once sub ends
+// or ctx is done.
+func (srv *WebhookService) dispatch(ctx context.Context, sub *repository.Subscription, since uint64) uint64 {
+	lg := logger.GetLoggerFromContext(ctx)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return since
+		case e, ok := <-sub.Events():
+			if !ok {
+				lg.Error(ctx, "Webhook dispatcher fell behind", zap.Error(sub.Err()))
+				return since
+			}
+			since = e.Seq
+
+			hooks, err := srv.webhooks(ctx)
+			if err != nil {
+				lg.Error(ctx, "Error loading webhooks", zap.Uint64("seq", e.Seq), zap.Error(err))
+				continue
+			}
+			for _, hook := range hooks {
+				if hook.Matches(e) {
+					srv.enqueue(ctx, srv.newDelivery(hook, e))
+				}
+			}
+		}
+	}
+}
+
+// lost records a failed delivery to every webhook for the events after since
+// up to last, which were dropped before they could be dispatched. Which
+// webhooks they matched is unknown. The deliveries show the loss in
+// ListDeliveries; they cannot be retried.
+func (srv *WebhookService) lost(ctx context.Context, since, last uint64, err error) {
+	hooks, herr := srv.webhooks(ctx)
+	if herr != nil {
+		logger.GetLoggerFromContext(ctx).Error(ctx, "Error loading webhooks", zap.Error(herr))
+		return
+	}
+
+	for _, hook := range hooks {
+		d := srv.newDelivery(hook, repository.Event{Seq: since + 1, Time: time.Now()})
+		srv.update(d, 0, fmt.Errorf("events %d to %d were not delivered: %w", since+1, last, err), false)
+	}
+}
+
+func (srv *WebhookService) newDelivery(hook repository.Webhook, e repository.Event) *repository.Delivery {
+	now := time.Now()
+	d := &repository.Delivery{
+		ID:        uuid.NewString(),
+		WebhookID: hook.ID,
+		URL:       hook.URL,
+		Event:     e,
+		Status:    repository.DeliveryPending,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+
+	srv.record(d)
+	return d
+}
+
+// record adds d to the history, dropping the oldest deliveries beyond its
+// size.
+func (srv *WebhookService) record(d *repository.Delivery) {
+	srv.history = append(srv.history, d)
+	if over := len(srv.history) - srv.cfg.History; over > 0 {
+		srv.history = append(srv.history[:0:0], srv.history[over:]...)
+	}
+}
+
+// enqueue adds d to the pending deliveries of its webhook and reports
+// whether it did. It does not block: if the queue of the webhook is full, d
+// fails and goes to the dead-letter log.
+func (srv *WebhookService) enqueue(ctx context.Context, d *repository.Delivery) bool {
+	srv.mu.Lock()
+	queued := len(srv.pending[d.WebhookID]) < srv.cfg.QueueSize
+	if queued {
+		srv.pending[d.WebhookID] = append(srv.pending[d.WebhookID], d)
+	}
+	srv.mu.Unlock()
+
+	if !queued {
+		srv.update(d, 0, fmt.Errorf("%w: %d deliveries are waiting", ErrWebhookQueueFull, srv.cfg.QueueSize), false)
+		srv.deadLetter(ctx, d)
+		return false
+	}
+
+	srv.signal()
+	return true
+}
+
+// retry puts d back at the head of the queue of its webhook and lets the
+// webhook be posted to again.
+func (srv *WebhookService) retry(d *repository.Delivery) {
+	srv.mu.Lock()
+	srv.pending[d.WebhookID] = append([]*repository.Delivery{d}, srv.pending[d.WebhookID]...)
+	delete(srv.busy, d.WebhookID)
+	srv.mu.Unlock()
+
+	srv.signal()
+}
+
+// signal wakes an idle worker, if there is one.
+func (srv *WebhookService) signal() {
+	select {
+	case srv.wake <- struct{}{}:
+	default:
+	}
+}
+
+// next takes the oldest pending delivery of a webhook no worker is posting
+// to, or returns nil if there is none. done must be called once it is
+// handled, unless it waits for a retry.
+func (srv *WebhookService) next() *repository.Delivery {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+
+	for id, queue := range srv.pending {
+		if srv.busy[id] {
+			continue
+		}
+		if len(queue) == 1 {
+			delete(srv.pending, id)
+		} else {
+			srv.pending[id] = queue[1:]
+		}
+		srv.busy[id] = true
+		return queue[0]
+	}
+	return nil
+}
+
+func (srv *WebhookService) done(d *repository.Delivery) {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+
+	delete(srv.busy, d.WebhookID)
+}
+
+// webhooks returns the webhooks, loading them from the store if they are
+// not cached.
+func (srv *WebhookService) webhooks(ctx context.Context) ([]repository.Webhook, error) {
+	srv.mu.Lock()
+	hooks := srv.hooks
+	srv.mu.Unlock()
+	if hooks != nil {
+		return hooks, nil
+	}
+
+	hooks, err := srv.store.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if hooks == nil {
+		hooks = []repository.Webhook{}
+	}
+
+	srv.mu.Lock()
+	srv.hooks = hooks
+	srv.mu.Unlock()
+	return hooks, nil
+}
+
+func (srv *WebhookService) invalidate() {
+	srv.mu.Lock()
+	srv.hooks = nil
+	srv.mu.Unlock()
+}
+
+func (srv *WebhookService) webhook(ctx context.Context, id string) (repository.Webhook, bool) {
+	hooks, err := srv.webhooks(ctx)
+	if err != nil {
+		return repository.Webhook{}, false
+	}
+	for _, hook := range hooks {
+		if hook.ID == id {
+			return hook, true
+		}
+	}
+	return repository.Webhook{}, false
+}
+
+func (srv *WebhookService) work(ctx context.Context) {
+	for ctx.Err() == nil {
+		if d := srv.next(); d != nil {
+			if !srv.deliver(ctx, d) {
+				srv.done(d)
+			}
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+		case <-srv.wake:
+		}
+	}
+}
+
+// backoff returns the wait after the given number of failed attempts.
+func (srv *WebhookService) backoff(attempts int) time.Duration {
+	wait := srv.cfg.Backoff
+	for i := 1; i < attempts && wait < srv.cfg.MaxBackoff; i++ {
+		wait *= 2
+	}
+	if srv.cfg.MaxBackoff > 0 {
+		wait = min(wait, srv.cfg.MaxBackoff)
+	}
+	return wait
+}
+
+// deliver makes one attempt of d and reports whether it schedules another
+// one. The webhook stays busy until the retry is due.
+func (srv *WebhookService) deliver(ctx context.Context, d *repository.Delivery) bool {
+	lg := logger.GetLoggerFromContext(ctx)
+
+	srv.mu.Lock()
+	payload := WebhookPayload{ID: d.ID, WebhookID: d.WebhookID, Event: d.Event}
+	srv.mu.Unlock()
+
+	hook, ok := srv.webhook(ctx, payload.WebhookID)
+	if !ok {
+		srv.update(d, 0, errors.New("webhook was deleted"), false)
+		return false
+	}
+
+	code, err := srv.post(ctx, hook, payload)
+	attempts := srv.update(d, code, err, true)
+	if err == nil {
+		lg.Debug(ctx, "Webhook delivered", zap.String("delivery", d.ID), zap.String("url", hook.URL), zap.Int("attempts", attempts))
+		return false
+	}
+	if ctx.Err() != nil {
+		return false
+	}
+
+	if attempts < srv.cfg.MaxAttempts {
+		wait := srv.backoff(attempts)
+		srv.mu.Lock()
+		d.NextAttempt = time.Now().Add(wait)
+		srv.mu.Unlock()
+
+		lg.Info(ctx, "Webhook delivery failed, retrying", zap.String("delivery", d.ID), zap.String("url", hook.URL),
+			zap.Int("attempts", attempts), zap.Duration("backoff", wait), zap.Error(err))
+		time.AfterFunc(wait, func() { srv.retry(d) })
+		return true
+	}
+
+	srv.mu.Lock()
+	d.Status = repository.DeliveryFailed
+	srv.mu.Unlock()
+
+	lg.Error(ctx, "Webhook delivery failed for good", zap.String("delivery", d.ID), zap.String("url", hook.URL),
+		zap.Int("attempts", attempts), zap.Error(err))
+	srv.deadLetter(ctx, d)
+	return false
+}
+
+// deadLetter stores a copy of the failed delivery d in the dead-letter log.
+func (srv *WebhookService) deadLetter(ctx context.Context, d *repository.Delivery) {
+	srv.mu.Lock()
+	letter := *d
+	srv.mu.Unlock()
+
+	if err := srv.store.AddDeadLetter(ctx, letter); err != nil {
+		logger.GetLoggerFromContext(ctx).Error(ctx, "Error storing dead letter", zap.String("delivery", d.ID), zap.Error(err))
+	}
+}
+
+// update records the outcome of an attempt of d and returns the number of
+// attempts made. A delivery that is not retried is failed.
+func (srv *WebhookService) update(d *repository.Delivery, code int, err error, retry bool) int {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+
+	if retry {
+		d.Attempts++
+	}
+	d.ResponseCode = code
+	d.UpdatedAt = time.Now()
+	d.NextAttempt = time.Time{}
+	switch {
+	case err == nil:
+		d.Status, d.LastError = repository.DeliveryDelivered, ""
+	case !retry:
+		d.Status, d.LastError = repository.DeliveryFailed, err.Error()
+	default:
+		d.LastError = err.Error()
+	}
+	return d.Attempts
+}
+
+// post sends payload to hook and returns the status of the response. Any
+// status but 2xx is an error.
+func (srv *WebhookService) post(ctx context.Context, hook repository.Webhook, payload WebhookPayload) (int, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return 0, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "FileManager-Webhook/1")
+	req.Header.Set(WebhookDeliveryHeader, payload.ID)
+	req.Header.Set(WebhookEventHeader, payload.Event.Type)
+	req.Header.Set(WebhookSignatureHeader, SignWebhook(hook.Secret, time.Now().Unix(), body))
+
+	res, err := srv.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(res.Body, 64<<10))
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return res.StatusCode, fmt.Errorf("receiver answered %s", res.Status)
+	}
+	return res.StatusCode, nil
+}
+
+func (srv *WebhookService) checkEnabled(ctx context.Context) error {
+	if srv.events == nil {
+		return ErrWebhooksDisabled
+	}
+	if srv.acl == nil {
+		return nil
+	}
+	return srv.acl.checkAdmin(ctx)
+}
+
+func toProtoWebhook(hook repository.Webhook) *fmpb.Webhook {
+	return &fmpb.Webhook{
+		Id:         hook.ID,
+		PathPrefix: hook.PathPrefix,
+		Types:      hook.Types,
+		Url:        hook.URL,
+		CreatedBy:  hook.CreatedBy,
+		CreatedAt:  hook.CreatedAt.Unix(),
+	}
+}
+
+func toProtoDelivery(d repository.Delivery) *fmpb.Delivery {
+	res := &fmpb.Delivery{
+		Id:           d.ID,
+		WebhookId:    d.WebhookID,
+		Url:          d.URL,
+		Seq:          d.Event.Seq,
+		Type:         d.Event.Type,
+		Path:         d.Event.Path,
+		OldPath:      d.Event.OldPath,
+		IsDir:        d.Event.IsDir,
+		Status:       d.Status,
+		Attempts:     int32(d.Attempts),
+		ResponseCode: int32(d.ResponseCode),
+		LastError:    d.LastError,
+		CreatedAt:    d.CreatedAt.Unix(),
+		UpdatedAt:    d.UpdatedAt.Unix(),
+	}
+	if !d.NextAttempt.IsZero() {
+		res.NextAttempt = d.NextAttempt.Unix()
+	}
+	return res
+}
+
+func (srv *WebhookService) CreateWebhook(ctx context.Context, req *fmpb.CreateWebhookRequest) (*fmpb.Webhook, error) {
+	lg := logger.GetLoggerFromContext(ctx)
+
+	lg.Info(ctx, "CreateWebhook is in process")
+	if err := srv.checkEnabled(ctx); err != nil {
+		return nil, err
+	}
+	if err := checkPath(req.PathPrefix); err != nil {
+		return nil, err
+	}
+
+	secret := req.Secret
+	if secret == "" {
+		key := make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			return nil, err
+		}
+		secret = base64.RawURLEncoding.EncodeToString(key)
+	}
+
+	subject, _ := callerPrincipals(ctx)
+	hook, err := srv.store.Create(ctx, repository.Webhook{
+		PathPrefix: req.PathPrefix,
+		Types:      req.Types,
+		URL:        req.Url,
+		Secret:     secret,
+		CreatedBy:  subject,
+		CreatedAt:  time.Now(),
+	})
+	if err != nil {
+		lg.Error(ctx, "Error to create webhook", zap.String("url", req.Url), zap.Error(err))
+		return nil, err
+	}
+	srv.invalidate()
+
+	lg.Info(ctx, "Webhook created", zap.String("id", hook.ID), zap.String("url", hook.URL), zap.String("prefix", hook.PathPrefix))
+	res := toProtoWebhook(hook)
+	res.Secret = hook.Secret
+	return res, nil
+}
+
+func (srv *WebhookService) ListWebhooks(ctx context.Context, _ *fmpb.ListWebhooksRequest) (*fmpb.ListWebhooksResponse, error) {
+	lg := logger.GetLoggerFromContext(ctx)
+
+	lg.Info(ctx, "ListWebhooks is in process")
+	if err := srv.checkEnabled(ctx); err != nil {
+		return nil, err
+	}
+
+	hooks, err := srv.store.List(ctx)
+	if err != nil {
+		lg.Error(ctx, "Error to list webhooks", zap.Error(err))
+		return nil, err
+	}
+
+	res := &fmpb.ListWebhooksResponse{}
+	for _, hook := range hooks {
+		res.Webhooks = append(res.Webhooks, toProtoWebhook(hook))
+	}
+	return res, nil
+}
+
+func (srv *WebhookService) DeleteWebhook(ctx context.Context, req *fmpb.DeleteWebhookRequest) error {
+	lg := logger.GetLoggerFromContext(ctx)
+
+	lg.Info(ctx, "DeleteWebhook is in process")
+	if err := srv.checkEnabled(ctx); err != nil {
+		return err
+	}
+
+	if err := srv.store.Remove(ctx, req.Id); err != nil {
+		lg.Error(ctx, "Error to delete webhook", zap.String("id", req.Id), zap.Error(err))
+		return err
+	}
+	srv.invalidate()
+
+	lg.Info(ctx, "Webhook deleted", zap.String("id", req.Id))
+	return nil
+}
+
+func (srv *WebhookService) ListDeliveries(ctx context.Context, req *fmpb.ListDeliveriesRequest) (*fmpb.ListDeliveriesResponse, error) {
+	logger.GetLoggerFromContext(ctx).Info(ctx, "ListDeliveries is in process")
+	if err := srv.checkEnabled(ctx); err != nil {
+		return nil, err
+	}
+
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+
+	res := &fmpb.ListDeliveriesResponse{}
+	for i := len(srv.history) - 1; i >= 0; i-- {
+		d := srv.history[i]
+		if (req.WebhookId == "" || d.WebhookID == req.WebhookId) && (req.Status == "" || d.Status == req.Status) {
+			res.Deliveries = append(res.Deliveries, toProtoDelivery(*d))
+		}
+	}
+	return res, nil
+}
+
+func (srv *WebhookService) ListDeadLetters(ctx context.Context, _ *fmpb.ListDeadLettersRequest) (*fmpb.ListDeliveriesResponse, error) {
+	lg := logger.GetLoggerFromContext(ctx)
+
+	lg.Info(ctx, "ListDeadLetters is in process")
+	if err := srv.checkEnabled(ctx); err != nil {
+		return nil, err
+	}
+
+	letters, err := srv.store.DeadLetters(ctx)
+	if err != nil {
+		lg.Error(ctx, "Error to list dead letters", zap.Error(err))
+		return nil, err
+	}
+
+	res := &fmpb.ListDeliveriesResponse{}
+	for _, d := range letters {
+		res.Deliveries = append(res.Deliveries, toProtoDelivery(d))
+	}
+	return res, nil
+}
+
+// Redeliver queues a dead letter again. It keeps its ID and starts over
+// with its attempts.
+func (srv *WebhookService) Redeliver(ctx context.Context, req *fmpb.RedeliverRequest) (*fmpb.Delivery, error) {
+	lg := logger.GetLoggerFromContext(ctx)
+
+	lg.Info(ctx, "Redeliver is in process")
+	if err := srv.checkEnabled(ctx); err != nil {
+		return nil, err
+	}
+
+	srv.mu.Lock()
+	run := srv.run
+	srv.mu.Unlock()
+	if run == nil || run.Err() != nil {
+		return nil, ErrWebhooksStopped
+	}
+
+	letter, err := srv.store.TakeDeadLetter(ctx, req.Id)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := srv.webhook(ctx, letter.WebhookID); !ok {
+		_ = srv.store.AddDeadLetter(ctx, letter)
+		return nil, fmt.Errorf("%w: %s", repository.ErrWebhookNotFound, letter.WebhookID)
+	}
+
+	d := &letter
+	d.Status, d.Attempts, d.UpdatedAt = repository.DeliveryPending, 0, time.Now()
+
+	srv.mu.Lock()
+	srv.record(d)
+	res := toProtoDelivery(*d)
+	srv.mu.Unlock()
+
+	// The delivery runs in the background, after the call is done.
+	if !srv.enqueue(ctx, d) {
+		return nil, ErrWebhookQueueFull
+	}
+	lg.Info(ctx, "Dead letter queued again", zap.String("id", d.ID), zap.String("webhook", d.WebhookID))
+	return res, nil
+}
@@ -0,0 +1,78 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"github.com/JunBSer/FileManager/internal/repository"
+	"github.com/JunBSer/FileManager/internal/service"
+	"github.com/JunBSer/FileManager/pkg/api/fmpb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type ACLService struct {
+	srv *service.ACLService
+	fmpb.UnimplementedAccessControlServiceServer
+}
+
+func NewACLService(srv *service.ACLService) *ACLService {
+	return &ACLService{srv: srv}
+}
+
+// accessError maps access control errors to gRPC status errors. It is the
+// last fallback of every error mapper, so denials of all services end up as
+// PERMISSION_DENIED.
+func accessError(err error) error {
+	switch {
+	case errors.Is(err, service.ErrPermissionDenied):
+		return status.Error(codes.PermissionDenied, err.Error())
+	case errors.Is(err, service.ErrACLDisabled):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, repository.ErrGrantNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, repository.ErrInvalidPermission),
+		errors.Is(err, repository.ErrInvalidPrincipal),
+		errors.Is(err, repository.ErrUnknownRole):
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	return err
+}
+
+func (srv *ACLService) ListGrants(ctx context.Context, req *fmpb.ListGrantsRequest) (*fmpb.ListGrantsResponse, error) {
+	res, err := srv.srv.ListGrants(ctx, req)
+	if err != nil {
+		return nil, accessError(err)
+	}
+	return res, nil
+}
+
+func (srv *ACLService) SetGrant(ctx context.Context, req *fmpb.Grant) (*fmpb.Grant, error) {
+	res, err := srv.srv.SetGrant(ctx, req)
+	if err != nil {
+		return nil, accessError(err)
+	}
+	return res, nil
+}
+
+func (srv *ACLService) RemoveGrant(ctx context.Context, req *fmpb.RemoveGrantRequest) (*fmpb.RemoveGrantResponse, error) {
+	if err := srv.srv.RemoveGrant(ctx, req); err != nil {
+		return nil, accessError(err)
+	}
+	return &fmpb.RemoveGrantResponse{}, nil
+}
+
+func (srv *ACLService) GetAccess(ctx context.Context, req *fmpb.GetAccessRequest) (*fmpb.Access, error) {
+	res, err := srv.srv.GetAccess(ctx, req)
+	if err != nil {
+		return nil, accessError(err)
+	}
+	return res, nil
+}
+
+func (srv *ACLService) ListRoles(ctx context.Context, req *fmpb.ListRolesRequest) (*fmpb.ListRolesResponse, error) {
+	res, err := srv.srv.ListRoles(ctx, req)
+	if err != nil {
+		return nil, accessError(err)
+	}
+	return res, nil
+}
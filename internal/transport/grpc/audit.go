@@ -0,0 +1,170 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"github.com/JunBSer/FileManager/internal/auth"
+	"github.com/JunBSer/FileManager/internal/repository"
+	"github.com/JunBSer/FileManager/internal/service"
+	"github.com/JunBSer/FileManager/pkg/api/fmpb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"time"
+)
+
+// auditPathFields are the request fields naming the path a call is about,
+// and auditDestinationFields the ones naming where it copies or moves to.
+var (
+	auditPathFields        = []protoreflect.Name{"file_name", "path", "source", "path_prefix"}
+	auditDestinationFields = []protoreflect.Name{"destination"}
+)
+
+type AuditService struct {
+	srv *service.AuditService
+	fmpb.UnimplementedAuditServiceServer
+}
+
+func NewAuditService(srv *service.AuditService) *AuditService {
+	return &AuditService{srv: srv}
+}
+
+func auditError(err error) error {
+	if errors.Is(err, service.ErrAuditDisabled) {
+		return status.Error(codes.FailedPrecondition, err.Error())
+	}
+	return accessError(err)
+}
+
+func (srv *AuditService) QueryAudit(ctx context.Context, req *fmpb.QueryAuditRequest) (*fmpb.QueryAuditResponse, error) {
+	res, err := srv.srv.QueryAudit(ctx, req)
+	if err != nil {
+		return nil, auditError(err)
+	}
+	return res, nil
+}
+
+func (srv *AuditService) VerifyAudit(ctx context.Context, req *fmpb.VerifyAuditRequest) (*fmpb.VerifyAuditResponse, error) {
+	res, err := srv.srv.VerifyAudit(ctx, req)
+	if err != nil {
+		return nil, auditError(err)
+	}
+	return res, nil
+}
+
+// auditCall is the record of a call that is in progress.
+type auditCall struct {
+	rec   repository.AuditRecord
+	start time.Time
+	paths bool
+}
+
+func newAuditCall(ctx context.Context, method string) *auditCall {
+	call := &auditCall{rec: repository.AuditRecord{Operation: method}, start: time.Now()}
+	if id, ok := auth.FromContext(ctx); ok {
+		call.rec.Caller, call.rec.AuthMethod = id.Subject, id.Method
+	}
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		call.rec.Remote = p.Addr.String()
+	}
+	return call
+}
+
+// message takes the paths of the call from the first request m, and counts
+// the file data in m.
+func (call *auditCall) message(m any, request bool) {
+	msg, ok := m.(proto.Message)
+	if !ok || msg == nil {
+		return
+	}
+	fields := msg.ProtoReflect()
+	if !fields.IsValid() {
+		return
+	}
+
+	if request && !call.paths {
+		call.paths = true
+		call.rec.Path = stringField(fields, auditPathFields)
+		call.rec.Destination = stringField(fields, auditDestinationFields)
+	}
+	call.rec.Bytes += fileBytes(m)
+}
+
+// stringField returns the first of the names that is a set string field of
+// fields.
+func stringField(fields protoreflect.Message, names []protoreflect.Name) string {
+	desc := fields.Descriptor().Fields()
+	for _, name := range names {
+		fd := desc.ByName(name)
+		if fd != nil && fd.Kind() == protoreflect.StringKind && !fd.IsList() && fields.Has(fd) {
+			return fields.Get(fd).String()
+		}
+	}
+	return ""
+}
+
+func (call *auditCall) done(err error) repository.AuditRecord {
+	call.rec.Duration = time.Since(call.start)
+	call.rec.Status = status.Code(err).String()
+	if err != nil {
+		call.rec.Error = status.Convert(err).Message()
+	}
+	return call.rec
+}
+
+type auditStream struct {
+	grpc.ServerStream
+	call *auditCall
+}
+
+func (s *auditStream) RecvMsg(m any) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+	s.call.message(m, true)
+	return nil
+}
+
+func (s *auditStream) SendMsg(m any) error {
+	if err := s.ServerStream.SendMsg(m); err != nil {
+		return err
+	}
+	s.call.message(m, false)
+	return nil
+}
+
+// unAudit records every call in the audit log, once it is done. It runs after
+// the authentication, so calls it rejects are only logged. Health checks are
+// not recorded.
+func unAudit(audit *service.AuditService) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+		if !audit.Enabled() || isPublic(info.FullMethod) {
+			return handler(ctx, req)
+		}
+
+		call := newAuditCall(ctx, info.FullMethod)
+		call.message(req, true)
+		resp, err = handler(ctx, req)
+		if err == nil {
+			call.message(resp, false)
+		}
+		audit.Record(ctx, call.done(err))
+		return resp, err
+	}
+}
+
+func srvStrAudit(audit *service.AuditService) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if !audit.Enabled() || isPublic(info.FullMethod) {
+			return handler(srv, ss)
+		}
+
+		call := newAuditCall(ss.Context(), info.FullMethod)
+		err := handler(srv, &auditStream{ServerStream: ss, call: call})
+		audit.Record(ss.Context(), call.done(err))
+		return err
+	}
+}
@@ -0,0 +1,131 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"github.com/JunBSer/FileManager/internal/auth"
+	"github.com/JunBSer/FileManager/pkg/logger"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"strings"
+)
+
+// Metadata keys of the credentials of a call and of the identity a trusted
+// proxy, like the gateway, makes the call for.
+const (
+	mdAuthorization = "authorization"
+	mdAPIKey        = "x-api-key"
+	mdSubject       = "x-fm-subject"
+	mdAuthMethod    = "x-fm-auth-method"
+	mdGroups        = "x-fm-groups"
+)
+
+func authError(err error) error {
+	if errors.Is(err, auth.ErrUntrustedProxy) {
+		return status.Error(codes.PermissionDenied, err.Error())
+	}
+	return status.Error(codes.Unauthenticated, err.Error())
+}
+
+// authenticate returns the identity of the caller of ctx. A caller passing on
+// the identity of its own caller must be a trusted proxy.
+func authenticate(ctx context.Context, authn *auth.Authenticator) (*auth.Identity, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	first := func(key string) string {
+		if values := md.Get(key); len(values) > 0 {
+			return values[0]
+		}
+		return ""
+	}
+
+	creds := auth.Credentials{APIKey: first(mdAPIKey)}
+	if scheme, token, ok := strings.Cut(first(mdAuthorization), " "); ok && strings.EqualFold(scheme, "Bearer") {
+		creds.Bearer = strings.TrimSpace(token)
+	}
+	if p, ok := peer.FromContext(ctx); ok {
+		if info, ok := p.AuthInfo.(credentials.TLSInfo); ok {
+			creds.Certificates = info.State.PeerCertificates
+		}
+	}
+
+	id, err := authn.Authenticate(creds)
+	if err != nil {
+		return nil, err
+	}
+
+	if subject := first(mdSubject); subject != "" {
+		return authn.Delegate(id, auth.Identity{Subject: subject, Groups: md.Get(mdGroups), Method: first(mdAuthMethod)})
+	}
+	return id, nil
+}
+
+func unAuthenticate(authn *auth.Authenticator, l logger.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+		if !authn.Enabled() || isPublic(info.FullMethod) {
+			return handler(ctx, req)
+		}
+
+		id, err := authenticate(ctx, authn)
+		if err != nil {
+			l.Info(ctx, "request rejected", zap.String("method", info.FullMethod), zap.Error(err))
+			return nil, authError(err)
+		}
+		return handler(auth.NewContext(ctx, id), req)
+	}
+}
+
+func srvStrAuthenticate(authn *auth.Authenticator, l logger.Logger) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if _, ok := auth.FromContext(ss.Context()); ok || !authn.Enabled() || isPublic(info.FullMethod) {
+			// Calls authorized by a share token already have an identity.
+			return handler(srv, ss)
+		}
+
+		id, err := authenticate(ss.Context(), authn)
+		if err != nil {
+			l.Info(ss.Context(), "request rejected", zap.String("method", info.FullMethod), zap.Error(err))
+			return authError(err)
+		}
+		return handler(srv, &wrappedStream{ServerStream: ss, ctx: auth.NewContext(ss.Context(), id)})
+	}
+}
+
+// forwardIdentity adds apiKey, the identity of the caller of ctx and its share
+// token to the outgoing metadata.
+func forwardIdentity(ctx context.Context, apiKey string) context.Context {
+	var kv []string
+	if apiKey != "" {
+		kv = append(kv, mdAPIKey, apiKey)
+	}
+	if token, ok := ShareToken(ctx); ok {
+		kv = append(kv, mdShareToken, token)
+	}
+	if id, ok := auth.FromContext(ctx); ok {
+		kv = append(kv, mdSubject, id.Subject, mdAuthMethod, id.Method)
+		for _, group := range id.Groups {
+			kv = append(kv, mdGroups, group)
+		}
+	}
+
+	if len(kv) == 0 {
+		return ctx
+	}
+	return metadata.AppendToOutgoingContext(ctx, kv...)
+}
+
+func clUnForwardIdentity(apiKey string) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		return invoker(forwardIdentity(ctx, apiKey), method, req, reply, cc, opts...)
+	}
+}
+
+func clStrForwardIdentity(apiKey string) grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		return streamer(forwardIdentity(ctx, apiKey), desc, cc, method, opts...)
+	}
+}
@@ -2,21 +2,50 @@ package grpc
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
+	"github.com/JunBSer/FileManager/pkg/api/fmpb"
 	"github.com/JunBSer/FileManager/pkg/logger"
 	"github.com/JunBSer/proto_fileManager/pkg/api/proto"
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 )
 
 type Client struct {
-	Conn *grpc.ClientConn
-	Cl   proto.FileServiceClient
+	Conn     *grpc.ClientConn
+	Cl       proto.FileServiceClient
+	Sessions fmpb.UploadSessionServiceClient
+	Ranges   fmpb.RangeReadServiceClient
+	Versions fmpb.VersionServiceClient
+	Trash    fmpb.TrashServiceClient
+	Dirs     fmpb.DirectoryServiceClient
+	Meta     fmpb.MetadataServiceClient
+	Quotas   fmpb.QuotaServiceClient
+	ACL      fmpb.AccessControlServiceClient
+	Shares   fmpb.ShareServiceClient
+	Watch    fmpb.WatchServiceClient
+	Webhooks fmpb.WebhookServiceClient
+	Audit    fmpb.AuditServiceClient
+	Health   healthpb.HealthClient
 }
 
-func NewClient(ctx context.Context, host string, port int) (*Client, error) {
-	var opts []grpc.DialOption = []grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())}
+// NewClient dials the gRPC server, over TLS if tlsConfig is set. Every call
+// carries apiKey, if set, and the identity of the caller, the trace context
+// and the request ID of its context.
+func NewClient(ctx context.Context, host string, port int, apiKey string, tlsConfig *tls.Config) (*Client, error) {
+	creds := insecure.NewCredentials()
+	if tlsConfig != nil {
+		creds = credentials.NewTLS(tlsConfig)
+	}
+
+	var opts []grpc.DialOption = []grpc.DialOption{
+		grpc.WithTransportCredentials(creds),
+		grpc.WithChainUnaryInterceptor(clUnPropagate(), clUnForwardIdentity(apiKey)),
+		grpc.WithChainStreamInterceptor(clStrPropagate(), clStrForwardIdentity(apiKey)),
+	}
 
 	conn, err := grpc.NewClient(fmt.Sprintf("%s:%d", host, port), opts...)
 	if err != nil {
@@ -24,11 +53,24 @@ func NewClient(ctx context.Context, host string, port int) (*Client, error) {
 		return nil, err
 	}
 
-	logger.GetLoggerFromContext(ctx).Info(ctx, "Grpc client connection has been created")
+	logger.GetLoggerFromContext(ctx).Info(ctx, "Grpc client connection has been created", zap.Bool("tls", tlsConfig != nil))
 
 	cl := proto.NewFileServiceClient(conn)
 	return &Client{Conn: conn,
-		Cl: cl}, nil
+		Cl:       cl,
+		Sessions: fmpb.NewUploadSessionServiceClient(conn),
+		Ranges:   fmpb.NewRangeReadServiceClient(conn),
+		Versions: fmpb.NewVersionServiceClient(conn),
+		Trash:    fmpb.NewTrashServiceClient(conn),
+		Dirs:     fmpb.NewDirectoryServiceClient(conn),
+		Meta:     fmpb.NewMetadataServiceClient(conn),
+		Quotas:   fmpb.NewQuotaServiceClient(conn),
+		ACL:      fmpb.NewAccessControlServiceClient(conn),
+		Shares:   fmpb.NewShareServiceClient(conn),
+		Watch:    fmpb.NewWatchServiceClient(conn),
+		Webhooks: fmpb.NewWebhookServiceClient(conn),
+		Audit:    fmpb.NewAuditServiceClient(conn),
+		Health:   healthpb.NewHealthClient(conn)}, nil
 }
 
 func (c *Client) Close(ctx context.Context) {
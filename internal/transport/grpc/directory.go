@@ -0,0 +1,77 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"github.com/JunBSer/FileManager/internal/repository"
+	"github.com/JunBSer/FileManager/internal/service"
+	"github.com/JunBSer/FileManager/pkg/api/fmpb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"os"
+)
+
+type DirectoryService struct {
+	srv *service.FileService
+	fmpb.UnimplementedDirectoryServiceServer
+}
+
+func NewDirectoryService(srv *service.FileService) *DirectoryService {
+	return &DirectoryService{srv: srv}
+}
+
+func treeError(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, repository.ErrPathExists):
+		return status.Error(codes.AlreadyExists, err.Error())
+	case errors.Is(err, repository.ErrTreeLoop):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, repository.ErrNotDirectory), errors.Is(err, repository.ErrIsDirectory),
+		errors.Is(err, repository.ErrDirNotEmpty):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case os.IsNotExist(err):
+		return status.Error(codes.NotFound, err.Error())
+	}
+	return quotaError(err)
+}
+
+func toPathResult(result repository.TreeResult) *fmpb.PathResult {
+	res := &fmpb.PathResult{Path: result.Path, Target: result.Target, IsDir: result.IsDir}
+	if result.Err != nil {
+		res.Code = int32(status.Code(treeError(result.Err)))
+		res.Error = result.Err.Error()
+	}
+	return res
+}
+
+// sendResults streams every processed entry to the client.
+func sendResults(stream grpc.ServerStreamingServer[fmpb.PathResult]) func(repository.TreeResult) error {
+	return func(result repository.TreeResult) error {
+		return stream.Send(toPathResult(result))
+	}
+}
+
+func (srv *DirectoryService) CreateDirectory(ctx context.Context, req *fmpb.CreateDirectoryRequest) (*fmpb.PathResult, error) {
+	if err := srv.srv.CreateDirectory(ctx, req); err != nil {
+		return nil, treeError(err)
+	}
+	return &fmpb.PathResult{Path: req.Path, IsDir: true}, nil
+}
+
+func (srv *DirectoryService) DeleteDirectory(req *fmpb.DeleteDirectoryRequest, stream fmpb.DirectoryService_DeleteDirectoryServer) error {
+	_, err := srv.srv.DeleteDirectory(stream.Context(), req, sendResults(stream))
+	return treeError(err)
+}
+
+func (srv *DirectoryService) CopyPath(req *fmpb.TreeRequest, stream fmpb.DirectoryService_CopyPathServer) error {
+	_, err := srv.srv.CopyPath(stream.Context(), req, sendResults(stream))
+	return treeError(err)
+}
+
+func (srv *DirectoryService) MovePath(req *fmpb.TreeRequest, stream fmpb.DirectoryService_MovePathServer) error {
+	_, err := srv.srv.MovePath(stream.Context(), req, sendResults(stream))
+	return treeError(err)
+}
@@ -2,10 +2,14 @@ package grpc
 
 import (
 	"context"
+	"errors"
+	"github.com/JunBSer/FileManager/internal/repository"
 	"github.com/JunBSer/FileManager/internal/service"
 	"github.com/JunBSer/FileManager/pkg/logger"
 	"github.com/JunBSer/proto_fileManager/pkg/api/proto"
 	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 type FileService struct {
@@ -17,20 +21,33 @@ func NewService(srv service.FileService) *FileService {
 	return &FileService{srv: srv}
 }
 
+// checksumError maps checksum errors of uploads to gRPC status errors. Both
+// mean the client sent data that does not match what it announced. Other
+// errors go through quotaError.
+func checksumError(err error) error {
+	if errors.Is(err, repository.ErrChecksumMismatch) || errors.Is(err, service.ErrInvalidChecksum) {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	return quotaError(err)
+}
+
 func (srv *FileService) Upload(stream proto.FileService_UploadServer) error {
 	err := srv.srv.Upload(stream)
 
-	status := proto.Status_STATUS_SUCCESS
+	res := proto.Status_STATUS_SUCCESS
 	if err != nil {
-		status = proto.Status_STATUS_ERROR
+		if mapped := checksumError(err); mapped != err {
+			return mapped
+		}
+		res = proto.Status_STATUS_ERROR
 		logger.GetLoggerFromContext(stream.Context()).Error(context.Background(), "Upload failed", zap.Error(err))
 	}
-	return stream.SendAndClose(&proto.StatusResponse{Status: status})
+	return stream.SendAndClose(&proto.StatusResponse{Status: res})
 }
 
 func (srv *FileService) Download(req *proto.FileRequest, stream proto.FileService_DownloadServer) error {
 	if err := srv.srv.Download(req, stream); err != nil {
-		return err
+		return accessError(err)
 	}
 	return nil
 }
@@ -38,7 +55,7 @@ func (srv *FileService) Download(req *proto.FileRequest, stream proto.FileServic
 func (srv *FileService) Delete(ctx context.Context, req *proto.FileRequest) (*proto.StatusResponse, error) {
 	err := srv.srv.Delete(ctx, req)
 	if err != nil {
-		return &proto.StatusResponse{Status: proto.Status_STATUS_ERROR}, err
+		return &proto.StatusResponse{Status: proto.Status_STATUS_ERROR}, accessError(err)
 	}
 	return &proto.StatusResponse{Status: proto.Status_STATUS_SUCCESS}, nil
 }
@@ -46,7 +63,7 @@ func (srv *FileService) Delete(ctx context.Context, req *proto.FileRequest) (*pr
 func (srv *FileService) Read(req *proto.FileRequest, stream proto.FileService_ReadServer) error {
 	err := srv.srv.Read(req, stream)
 	if err != nil {
-		return err
+		return accessError(err)
 	}
 	return nil
 }
@@ -54,7 +71,7 @@ func (srv *FileService) Read(req *proto.FileRequest, stream proto.FileService_Re
 func (srv *FileService) OverwriteFile(stream proto.FileService_OverwriteFileServer) error {
 	if err := srv.srv.Overwrite(stream); err != nil {
 		stream.SendAndClose(&proto.StatusResponse{Status: proto.Status_STATUS_ERROR})
-		return err
+		return checksumError(err)
 	}
 	stream.SendAndClose(&proto.StatusResponse{Status: proto.Status_STATUS_SUCCESS})
 	return nil
@@ -63,7 +80,7 @@ func (srv *FileService) OverwriteFile(stream proto.FileService_OverwriteFileServ
 func (srv *FileService) Append(stream proto.FileService_AppendServer) error {
 	if err := srv.srv.Append(stream); err != nil {
 		stream.SendAndClose(&proto.StatusResponse{Status: proto.Status_STATUS_ERROR})
-		return err
+		return checksumError(err)
 	}
 	stream.SendAndClose(&proto.StatusResponse{Status: proto.Status_STATUS_SUCCESS})
 	return nil
@@ -72,7 +89,7 @@ func (srv *FileService) Append(stream proto.FileService_AppendServer) error {
 func (srv *FileService) MoveFile(ctx context.Context, req *proto.OperationRequest) (*proto.StatusResponse, error) {
 	err := srv.srv.MoveFile(ctx, req)
 	if err != nil {
-		return &proto.StatusResponse{Status: proto.Status_STATUS_ERROR}, err
+		return &proto.StatusResponse{Status: proto.Status_STATUS_ERROR}, quotaError(err)
 	}
 	return &proto.StatusResponse{Status: proto.Status_STATUS_SUCCESS}, nil
 }
@@ -80,7 +97,7 @@ func (srv *FileService) MoveFile(ctx context.Context, req *proto.OperationReques
 func (srv *FileService) ListDirectory(ctx context.Context, r *proto.DirectoryRequest) (*proto.DirectoryResponse, error) {
 	res, err := srv.srv.ListDirectory(ctx, r)
 	if err != nil {
-		return nil, err
+		return nil, accessError(err)
 	}
 	return &proto.DirectoryResponse{Entries: res}, nil
 }
@@ -0,0 +1,77 @@
+package grpc
+
+import (
+	"context"
+	"github.com/JunBSer/FileManager/internal/service"
+	"github.com/JunBSer/FileManager/pkg/logger"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"strings"
+	"time"
+)
+
+// publicMethods are the methods that can be called without credentials, so
+// that orchestrators can probe the server.
+var publicMethods = []string{"/" + healthpb.Health_ServiceDesc.ServiceName + "/"}
+
+func isPublic(method string) bool {
+	for _, prefix := range publicMethods {
+		if strings.HasPrefix(method, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// healthStatus returns the status the health service reports for err, the
+// result of a health check.
+func healthStatus(err error) healthpb.HealthCheckResponse_ServingStatus {
+	if err != nil {
+		return healthpb.HealthCheckResponse_NOT_SERVING
+	}
+	return healthpb.HealthCheckResponse_SERVING
+}
+
+// watchHealth checks the health of the server every interval until done is
+// closed, and sets the status of the server and of every service registered
+// on it in hs.
+func watchHealth(ctx context.Context, checker *service.HealthService, hs *health.Server, srv *grpc.Server, done <-chan struct{}) {
+	lg := logger.GetLoggerFromContext(ctx)
+
+	var services []string
+	for name := range srv.GetServiceInfo() {
+		if name != healthpb.Health_ServiceDesc.ServiceName {
+			services = append(services, name)
+		}
+	}
+
+	ticker := time.NewTicker(checker.Interval())
+	defer ticker.Stop()
+
+	last := healthpb.HealthCheckResponse_UNKNOWN
+	for {
+		err := checker.Check(ctx)
+		st := healthStatus(err)
+		if st != last {
+			if err != nil {
+				lg.Error(ctx, "Server is not serving", zap.Error(err))
+			} else {
+				lg.Info(ctx, "Server is serving")
+			}
+			last = st
+		}
+
+		hs.SetServingStatus("", st)
+		for _, name := range services {
+			hs.SetServingStatus(name, st)
+		}
+
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+		}
+	}
+}
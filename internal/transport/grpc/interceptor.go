@@ -2,9 +2,15 @@ package grpc
 
 import (
 	"context"
+	"github.com/JunBSer/FileManager/internal/auth"
+	"github.com/JunBSer/FileManager/internal/metrics"
 	"github.com/JunBSer/FileManager/pkg/logger"
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"time"
 )
 
 type wrappedStream struct {
@@ -16,8 +22,17 @@ func (w *wrappedStream) Context() context.Context {
 	return w.ctx
 }
 
+// callerLogger adds the identity of the caller of ctx, if any, to l.
+func callerLogger(ctx context.Context, l logger.Logger) logger.Logger {
+	if id, ok := auth.FromContext(ctx); ok {
+		return l.CreateChildLogger(id.LogFields()...)
+	}
+	return l
+}
+
 func unContextWithLogger(l logger.Logger) grpc.UnaryServerInterceptor {
 	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+		l := callerLogger(ctx, l)
 		l.Info(ctx, "request started", zap.String("method", info.FullMethod))
 		return handler(context.WithValue(ctx, logger.Key, l), req)
 	}
@@ -32,6 +47,7 @@ func clStrContextWithLogger(l logger.Logger) grpc.StreamClientInterceptor {
 
 func srvStrContextWithLogger(l logger.Logger) grpc.StreamServerInterceptor {
 	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		l := callerLogger(ss.Context(), l)
 		l.Info(ss.Context(), "request started", zap.String("method", info.FullMethod))
 		ctx := context.WithValue(ss.Context(), logger.Key, l)
 		wrappedStream := &wrappedStream{ServerStream: ss, ctx: ctx}
@@ -39,3 +55,80 @@ func srvStrContextWithLogger(l logger.Logger) grpc.StreamServerInterceptor {
 		return handler(srv, wrappedStream)
 	}
 }
+
+// fileBytes returns the number of bytes in the bytes fields of the message m,
+// the file data it carries.
+func fileBytes(m any) int64 {
+	msg, ok := m.(proto.Message)
+	if !ok || msg == nil {
+		return 0
+	}
+	fields := msg.ProtoReflect()
+	if !fields.IsValid() {
+		return 0
+	}
+
+	var n int64
+	fields.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		if fd.Kind() == protoreflect.BytesKind && !fd.IsList() {
+			n += int64(len(v.Bytes()))
+		}
+		return true
+	})
+	return n
+}
+
+type metricsStream struct {
+	grpc.ServerStream
+	method string
+}
+
+func (s *metricsStream) RecvMsg(m any) error {
+	err := s.ServerStream.RecvMsg(m)
+	if err == nil {
+		metrics.RPCBytes.WithLabelValues(s.method, metrics.Received).Add(float64(fileBytes(m)))
+	}
+	return err
+}
+
+func (s *metricsStream) SendMsg(m any) error {
+	err := s.ServerStream.SendMsg(m)
+	if err == nil {
+		metrics.RPCBytes.WithLabelValues(s.method, metrics.Sent).Add(float64(fileBytes(m)))
+	}
+	return err
+}
+
+func observeCall(method string, start time.Time, err error) {
+	metrics.RPCRequests.WithLabelValues(method, status.Code(err).String()).Inc()
+	metrics.RPCDuration.WithLabelValues(method).Observe(time.Since(start).Seconds())
+}
+
+// unMetrics counts the calls, their status and the file bytes of their
+// messages. It runs first, so rejected calls are counted as well.
+func unMetrics() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+		start := time.Now()
+		metrics.RPCBytes.WithLabelValues(info.FullMethod, metrics.Received).Add(float64(fileBytes(req)))
+
+		resp, err = handler(ctx, req)
+		if err == nil {
+			metrics.RPCBytes.WithLabelValues(info.FullMethod, metrics.Sent).Add(float64(fileBytes(resp)))
+		}
+		observeCall(info.FullMethod, start, err)
+		return resp, err
+	}
+}
+
+func srvStrMetrics() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		inFlight := metrics.RPCStreamsInFlight.WithLabelValues(info.FullMethod)
+		inFlight.Inc()
+		defer inFlight.Dec()
+
+		err := handler(srv, &metricsStream{ServerStream: ss, method: info.FullMethod})
+		observeCall(info.FullMethod, start, err)
+		return err
+	}
+}
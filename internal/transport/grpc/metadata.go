@@ -0,0 +1,46 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"github.com/JunBSer/FileManager/internal/service"
+	"github.com/JunBSer/FileManager/pkg/api/fmpb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"os"
+)
+
+type MetadataService struct {
+	srv *service.FileService
+	fmpb.UnimplementedMetadataServiceServer
+}
+
+func NewMetadataService(srv *service.FileService) *MetadataService {
+	return &MetadataService{srv: srv}
+}
+
+func metadataError(err error) error {
+	switch {
+	case errors.Is(err, service.ErrInvalidCursor), errors.Is(err, service.ErrInvalidPattern):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case os.IsNotExist(err):
+		return status.Error(codes.NotFound, err.Error())
+	}
+	return accessError(err)
+}
+
+func (srv *MetadataService) Stat(ctx context.Context, req *fmpb.StatRequest) (*fmpb.FileStat, error) {
+	res, err := srv.srv.Stat(ctx, req)
+	if err != nil {
+		return nil, metadataError(err)
+	}
+	return res, nil
+}
+
+func (srv *MetadataService) ListDirectory(ctx context.Context, req *fmpb.ListDirectoryRequest) (*fmpb.ListDirectoryResponse, error) {
+	res, err := srv.srv.ListEntries(ctx, req)
+	if err != nil {
+		return nil, metadataError(err)
+	}
+	return res, nil
+}
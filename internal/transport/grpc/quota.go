@@ -0,0 +1,50 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"github.com/JunBSer/FileManager/internal/repository"
+	"github.com/JunBSer/FileManager/internal/service"
+	"github.com/JunBSer/FileManager/pkg/api/fmpb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type QuotaService struct {
+	srv *service.QuotaService
+	fmpb.UnimplementedQuotaServiceServer
+}
+
+func NewQuotaService(srv *service.QuotaService) *QuotaService {
+	return &QuotaService{srv: srv}
+}
+
+// quotaError maps quota errors to gRPC status errors. Every service that
+// writes files falls back to it. Other errors go through accessError.
+func quotaError(err error) error {
+	switch {
+	case errors.Is(err, repository.ErrQuotaExceeded):
+		return status.Error(codes.ResourceExhausted, err.Error())
+	case errors.Is(err, repository.ErrInvalidNamespace):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, service.ErrQuotasDisabled):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	}
+	return accessError(err)
+}
+
+func (srv *QuotaService) GetUsage(ctx context.Context, req *fmpb.GetUsageRequest) (*fmpb.NamespaceUsage, error) {
+	res, err := srv.srv.GetUsage(ctx, req)
+	if err != nil {
+		return nil, quotaError(err)
+	}
+	return res, nil
+}
+
+func (srv *QuotaService) ListUsage(ctx context.Context, req *fmpb.ListUsageRequest) (*fmpb.ListUsageResponse, error) {
+	res, err := srv.srv.ListUsage(ctx, req)
+	if err != nil {
+		return nil, quotaError(err)
+	}
+	return res, nil
+}
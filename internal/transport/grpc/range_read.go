@@ -0,0 +1,19 @@
+package grpc
+
+import (
+	"github.com/JunBSer/FileManager/internal/service"
+	"github.com/JunBSer/FileManager/pkg/api/fmpb"
+)
+
+type RangeReadService struct {
+	srv *service.FileService
+	fmpb.UnimplementedRangeReadServiceServer
+}
+
+func NewRangeReadService(srv *service.FileService) *RangeReadService {
+	return &RangeReadService{srv: srv}
+}
+
+func (srv *RangeReadService) ReadRange(req *fmpb.ReadRangeRequest, stream fmpb.RangeReadService_ReadRangeServer) error {
+	return accessError(srv.srv.ReadRange(req, stream))
+}
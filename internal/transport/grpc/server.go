@@ -3,27 +3,67 @@ package grpc
 import (
 	"context"
 	"fmt"
+	"github.com/JunBSer/FileManager/internal/auth"
+	"github.com/JunBSer/FileManager/internal/certs"
 	"github.com/JunBSer/FileManager/internal/service"
+	"github.com/JunBSer/FileManager/pkg/api/fmpb"
 	"github.com/JunBSer/FileManager/pkg/logger"
 	pb "github.com/JunBSer/proto_fileManager/pkg/api/proto"
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
 	"net"
 )
 
 type Config struct {
 	GRPCHost string `env:"GRPC_HOST" envDefault:"localhost"`
 	GRPCPort int    `env:"GRPC_PORT" envDefault:"50051"`
+	// TLS is the configuration of the server, ClientTLS the one the gateway
+	// connects with.
+	TLS       certs.ServerConfig `env-prefix:"GRPC_"`
+	ClientTLS certs.ClientConfig `env-prefix:"GRPC_CLIENT_"`
+	// Reflection lets tools like grpcurl list the services of the server.
+	Reflection bool `env:"GRPC_REFLECTION" envDefault:"false"`
 }
 
+// ServerName is the common name of the self-signed certificate of the server.
+const ServerName = "file-service"
+
 type Server struct {
 	Grpc     *grpc.Server
 	Listener net.Listener
+	health   *health.Server
+	checker  *service.HealthService
+	done     chan struct{}
+}
+
+// Services are the services the server exposes.
+type Services struct {
+	Files    *service.FileService
+	Sessions *service.UploadSessionService
+	Versions *service.VersionService
+	Trash    *service.TrashService
+	Quotas   *service.QuotaService
+	ACL      *service.ACLService
+	Shares   *service.ShareService
+	Watch    *service.WatchService
+	Webhooks *service.WebhookService
+	Audit    *service.AuditService
+	Health   *service.HealthService
 }
 
-func New(ctx context.Context, grpcConfig *Config, srv *service.FileService) (*Server, error) {
+func New(ctx context.Context, grpcConfig *Config, services Services, authn *auth.Authenticator) (*Server, error) {
 	lg := logger.GetLoggerFromContext(ctx)
 
+	tlsConfig, err := certs.Server(grpcConfig.TLS, ServerName, authn.Roots())
+	if err != nil {
+		lg.Error(ctx, "Grpc server: Failed to configure TLS", zap.Error(err))
+		return nil, err
+	}
+
 	lis, err := net.Listen("tcp", fmt.Sprintf("%s:%d", (*grpcConfig).GRPCHost, (*grpcConfig).GRPCPort))
 	if err != nil {
 		lg.Error(ctx, fmt.Sprintf("Grpc server: Failed to listen: %v", err))
@@ -31,20 +71,45 @@ func New(ctx context.Context, grpcConfig *Config, srv *service.FileService) (*Se
 	}
 	lg.Info(ctx, fmt.Sprintf("Created grpc server listening on %s:%d", (*grpcConfig).GRPCHost, (*grpcConfig).GRPCPort))
 
-	var opts []grpc.ServerOption = []grpc.ServerOption{grpc.ChainUnaryInterceptor(unContextWithLogger(lg)), grpc.ChainStreamInterceptor(srvStrContextWithLogger(lg))}
+	var opts []grpc.ServerOption = []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(unMetrics(), unTrace(), unAuthenticate(authn, lg), unContextWithLogger(lg), unAudit(services.Audit)),
+		grpc.ChainStreamInterceptor(srvStrMetrics(), srvStrTrace(), srvStrShare(services.Shares, lg), srvStrAuthenticate(authn, lg), srvStrContextWithLogger(lg), srvStrAudit(services.Audit)),
+	}
+	if tlsConfig != nil {
+		opts = append(opts, grpc.Creds(credentials.NewTLS(tlsConfig)))
+	}
 
 	grpcServer := grpc.NewServer(opts...)
 
-	lg.Info(ctx, "Created grpc server")
+	lg.Info(ctx, "Created grpc server", zap.Bool("tls", tlsConfig != nil))
 
-	pb.RegisterFileServiceServer(grpcServer, NewService(*srv))
-	lg.Info(ctx, "GRPC service has been registered")
+	pb.RegisterFileServiceServer(grpcServer, NewService(*services.Files))
+	fmpb.RegisterUploadSessionServiceServer(grpcServer, NewUploadSessionService(services.Sessions))
+	fmpb.RegisterRangeReadServiceServer(grpcServer, NewRangeReadService(services.Files))
+	fmpb.RegisterVersionServiceServer(grpcServer, NewVersionService(services.Versions))
+	fmpb.RegisterTrashServiceServer(grpcServer, NewTrashService(services.Trash))
+	fmpb.RegisterDirectoryServiceServer(grpcServer, NewDirectoryService(services.Files))
+	fmpb.RegisterMetadataServiceServer(grpcServer, NewMetadataService(services.Files))
+	fmpb.RegisterQuotaServiceServer(grpcServer, NewQuotaService(services.Quotas))
+	fmpb.RegisterAccessControlServiceServer(grpcServer, NewACLService(services.ACL))
+	fmpb.RegisterShareServiceServer(grpcServer, NewShareService(services.Shares))
+	fmpb.RegisterWatchServiceServer(grpcServer, NewWatchService(services.Watch))
+	fmpb.RegisterWebhookServiceServer(grpcServer, NewWebhookService(services.Webhooks))
+	fmpb.RegisterAuditServiceServer(grpcServer, NewAuditService(services.Audit))
 
-	return &Server{Grpc: grpcServer, Listener: lis}, nil
+	healthServer := health.NewServer()
+	healthpb.RegisterHealthServer(grpcServer, healthServer)
+	if grpcConfig.Reflection {
+		reflection.Register(grpcServer)
+	}
+	lg.Info(ctx, "GRPC service has been registered", zap.Bool("reflection", grpcConfig.Reflection))
+
+	return &Server{Grpc: grpcServer, Listener: lis, health: healthServer, checker: services.Health, done: make(chan struct{})}, nil
 }
 
 func (s *Server) Start(ctx context.Context) error {
 	logger.GetLoggerFromContext(ctx).Info(ctx, "Starting gRPC server", zap.String("host", s.Listener.Addr().(*net.TCPAddr).IP.String()), zap.Int("port", s.Listener.Addr().(*net.TCPAddr).Port))
+	go watchHealth(ctx, s.checker, s.health, s.Grpc, s.done)
 	return s.Grpc.Serve(s.Listener)
 }
 
@@ -52,6 +117,9 @@ func (s *Server) Stop(ctx context.Context) {
 	lg := logger.GetLoggerFromContext(ctx)
 	lg.Info(ctx, "Stopping gRPC server")
 
+	close(s.done)
+	s.health.Shutdown()
+
 	s.Grpc.GracefulStop()
 	lg.Info(ctx, "Grpc server stopped")
 }
@@ -0,0 +1,177 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/JunBSer/FileManager/internal/auth"
+	"github.com/JunBSer/FileManager/internal/repository"
+	"github.com/JunBSer/FileManager/internal/service"
+	"github.com/JunBSer/FileManager/pkg/api/fmpb"
+	"github.com/JunBSer/FileManager/pkg/logger"
+	pb "github.com/JunBSer/proto_fileManager/pkg/api/proto"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// mdShareToken is the metadata key of a share token.
+const mdShareToken = "x-fm-share-token"
+
+// sharedMethods are the methods a share token may be used for, with the
+// operation the share must allow.
+var sharedMethods = map[string]string{
+	pb.FileService_Download_FullMethodName: repository.ShareDownload,
+	pb.FileService_Upload_FullMethodName:   repository.ShareUpload,
+}
+
+type shareTokenKey struct{}
+
+// WithShareToken returns a context whose calls carry token in place of the
+// credentials of the caller.
+func WithShareToken(ctx context.Context, token string) context.Context {
+	return context.WithValue(ctx, shareTokenKey{}, token)
+}
+
+// ShareToken returns the share token calls of ctx carry.
+func ShareToken(ctx context.Context) (string, bool) {
+	token, ok := ctx.Value(shareTokenKey{}).(string)
+	return token, ok && token != ""
+}
+
+type ShareService struct {
+	srv *service.ShareService
+	fmpb.UnimplementedShareServiceServer
+}
+
+func NewShareService(srv *service.ShareService) *ShareService {
+	return &ShareService{srv: srv}
+}
+
+func shareError(err error) error {
+	switch {
+	case errors.Is(err, service.ErrInvalidShareToken):
+		return status.Error(codes.Unauthenticated, err.Error())
+	case errors.Is(err, repository.ErrShareExpired), errors.Is(err, repository.ErrShareUsedUp):
+		return status.Error(codes.PermissionDenied, err.Error())
+	case errors.Is(err, repository.ErrShareNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, repository.ErrInvalidShare):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, service.ErrSharingDisabled):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	}
+	return metadataError(err)
+}
+
+func (srv *ShareService) CreateShare(ctx context.Context, req *fmpb.CreateShareRequest) (*fmpb.Share, error) {
+	res, err := srv.srv.CreateShare(ctx, req)
+	if err != nil {
+		return nil, shareError(err)
+	}
+	return res, nil
+}
+
+func (srv *ShareService) ListShares(ctx context.Context, req *fmpb.ListSharesRequest) (*fmpb.ListSharesResponse, error) {
+	res, err := srv.srv.ListShares(ctx, req)
+	if err != nil {
+		return nil, shareError(err)
+	}
+	return res, nil
+}
+
+func (srv *ShareService) RevokeShare(ctx context.Context, req *fmpb.RevokeShareRequest) (*fmpb.RevokeShareResponse, error) {
+	if err := srv.srv.RevokeShare(ctx, req); err != nil {
+		return nil, shareError(err)
+	}
+	return &fmpb.RevokeShareResponse{}, nil
+}
+
+// shareStream is a call authorized by a share token. The token is checked
+// against the path of the first message, and the file data going through the
+// stream is counted against the byte limit of the share.
+type shareStream struct {
+	grpc.ServerStream
+	ctx       context.Context
+	shares    *service.ShareService
+	token     string
+	operation string
+	share     *repository.Share
+	bytes     int64
+}
+
+func (s *shareStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *shareStream) RecvMsg(m any) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+
+	var path string
+	var n int
+	switch msg := m.(type) {
+	case *pb.FileRequest:
+		path = msg.FileName
+	case *pb.FileChunk:
+		path, n = msg.FileName, len(msg.Content)
+	}
+
+	if s.share.ID == "" {
+		share, err := s.shares.Redeem(s.ctx, s.token, s.operation, path)
+		if err != nil {
+			return shareError(err)
+		}
+		*s.share = share
+	}
+	return s.count(n)
+}
+
+func (s *shareStream) SendMsg(m any) error {
+	if chunk, ok := m.(*pb.FileChunk); ok {
+		if err := s.count(len(chunk.Content)); err != nil {
+			return err
+		}
+	}
+	return s.ServerStream.SendMsg(m)
+}
+
+func (s *shareStream) count(n int) error {
+	s.bytes += int64(n)
+	if left := s.share.RemainingBytes(); left >= 0 && s.bytes > left {
+		return shareError(fmt.Errorf("%w: byte limit of %d exceeded", repository.ErrShareUsedUp, s.share.MaxBytes))
+	}
+	return nil
+}
+
+// srvStrShare authorizes calls that carry a share token in place of
+// credentials. It runs before srvStrAuthenticate, which leaves calls it
+// authorized alone.
+func srvStrShare(shares *service.ShareService, l logger.Logger) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		operation, ok := sharedMethods[info.FullMethod]
+		if !ok {
+			return handler(srv, ss)
+		}
+		md, _ := metadata.FromIncomingContext(ss.Context())
+		tokens := md.Get(mdShareToken)
+		if len(tokens) == 0 || tokens[0] == "" {
+			return handler(srv, ss)
+		}
+
+		ctx, share := service.NewShareContext(ss.Context())
+		ctx = auth.NewContext(ctx, &auth.Identity{Subject: "share", Method: auth.MethodShare})
+		stream := &shareStream{ServerStream: ss, ctx: ctx, shares: shares, token: tokens[0], operation: operation, share: share}
+
+		err := handler(srv, stream)
+		if share.ID != "" {
+			if addErr := shares.AddBytes(ctx, share.ID, stream.bytes); addErr != nil {
+				l.Error(ctx, "Error counting share bytes", zap.String("share", share.ID), zap.Error(addErr))
+			}
+		}
+		return err
+	}
+}
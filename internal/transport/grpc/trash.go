@@ -0,0 +1,54 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"github.com/JunBSer/FileManager/internal/repository"
+	"github.com/JunBSer/FileManager/internal/service"
+	"github.com/JunBSer/FileManager/pkg/api/fmpb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type TrashService struct {
+	srv *service.TrashService
+	fmpb.UnimplementedTrashServiceServer
+}
+
+func NewTrashService(srv *service.TrashService) *TrashService {
+	return &TrashService{srv: srv}
+}
+
+func trashError(err error) error {
+	switch {
+	case errors.Is(err, repository.ErrTrashNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, repository.ErrRestoreConflict):
+		return status.Error(codes.AlreadyExists, err.Error())
+	}
+	return quotaError(err)
+}
+
+func (srv *TrashService) ListTrash(ctx context.Context, req *fmpb.ListTrashRequest) (*fmpb.ListTrashResponse, error) {
+	res, err := srv.srv.List(ctx, req)
+	if err != nil {
+		return nil, trashError(err)
+	}
+	return res, nil
+}
+
+func (srv *TrashService) Restore(ctx context.Context, req *fmpb.RestoreRequest) (*fmpb.TrashEntry, error) {
+	res, err := srv.srv.Restore(ctx, req)
+	if err != nil {
+		return nil, trashError(err)
+	}
+	return res, nil
+}
+
+func (srv *TrashService) EmptyTrash(ctx context.Context, req *fmpb.EmptyTrashRequest) (*fmpb.EmptyTrashResponse, error) {
+	res, err := srv.srv.Empty(ctx, req)
+	if err != nil {
+		return nil, trashError(err)
+	}
+	return res, nil
+}
@@ -0,0 +1,74 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"github.com/JunBSer/FileManager/internal/repository"
+	"github.com/JunBSer/FileManager/internal/service"
+	"github.com/JunBSer/FileManager/pkg/api/fmpb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type UploadSessionService struct {
+	srv *service.UploadSessionService
+	fmpb.UnimplementedUploadSessionServiceServer
+}
+
+func NewUploadSessionService(srv *service.UploadSessionService) *UploadSessionService {
+	return &UploadSessionService{srv: srv}
+}
+
+func sessionError(err error) error {
+	switch {
+	case errors.Is(err, repository.ErrSessionNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, repository.ErrSessionBusy):
+		return status.Error(codes.Aborted, err.Error())
+	case errors.Is(err, repository.ErrOffsetMismatch):
+		return status.Error(codes.OutOfRange, err.Error())
+	case errors.Is(err, repository.ErrSizeMismatch):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	}
+	return checksumError(err)
+}
+
+func (srv *UploadSessionService) CreateUploadSession(ctx context.Context, req *fmpb.CreateUploadSessionRequest) (*fmpb.UploadSession, error) {
+	res, err := srv.srv.Create(ctx, req)
+	if err != nil {
+		return nil, sessionError(err)
+	}
+	return res, nil
+}
+
+func (srv *UploadSessionService) UploadChunks(stream fmpb.UploadSessionService_UploadChunksServer) error {
+	res, err := srv.srv.UploadChunks(stream)
+	if err != nil {
+		return sessionError(err)
+	}
+	return stream.SendAndClose(res)
+}
+
+func (srv *UploadSessionService) GetUploadSession(ctx context.Context, req *fmpb.UploadSessionRequest) (*fmpb.UploadSession, error) {
+	res, err := srv.srv.Get(ctx, req)
+	if err != nil {
+		return nil, sessionError(err)
+	}
+	return res, nil
+}
+
+func (srv *UploadSessionService) FinalizeUploadSession(ctx context.Context, req *fmpb.UploadSessionRequest) (*fmpb.UploadSession, error) {
+	res, err := srv.srv.Finalize(ctx, req)
+	if err != nil {
+		return nil, sessionError(err)
+	}
+	return res, nil
+}
+
+func (srv *UploadSessionService) AbortUploadSession(ctx context.Context, req *fmpb.UploadSessionRequest) (*fmpb.UploadSession, error) {
+	res, err := srv.srv.Abort(ctx, req)
+	if err != nil {
+		return nil, sessionError(err)
+	}
+	return res, nil
+}
@@ -0,0 +1,67 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"github.com/JunBSer/FileManager/internal/repository"
+	"github.com/JunBSer/FileManager/internal/service"
+	"github.com/JunBSer/FileManager/pkg/api/fmpb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"os"
+)
+
+type VersionService struct {
+	srv *service.VersionService
+	fmpb.UnimplementedVersionServiceServer
+}
+
+func NewVersionService(srv *service.VersionService) *VersionService {
+	return &VersionService{srv: srv}
+}
+
+func versionError(err error) error {
+	switch {
+	case errors.Is(err, repository.ErrVersionNotFound), os.IsNotExist(err):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, repository.ErrInvalidRetention):
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	return quotaError(err)
+}
+
+func (srv *VersionService) ListVersions(ctx context.Context, req *fmpb.ListVersionsRequest) (*fmpb.ListVersionsResponse, error) {
+	res, err := srv.srv.List(ctx, req)
+	if err != nil {
+		return nil, versionError(err)
+	}
+	return res, nil
+}
+
+func (srv *VersionService) DownloadVersion(req *fmpb.VersionRequest, stream fmpb.VersionService_DownloadVersionServer) error {
+	return versionError(srv.srv.Download(req, stream))
+}
+
+func (srv *VersionService) RestoreVersion(ctx context.Context, req *fmpb.VersionRequest) (*fmpb.FileVersion, error) {
+	res, err := srv.srv.Restore(ctx, req)
+	if err != nil {
+		return nil, versionError(err)
+	}
+	return res, nil
+}
+
+func (srv *VersionService) GetRetention(ctx context.Context, req *fmpb.RetentionRequest) (*fmpb.RetentionRule, error) {
+	res, err := srv.srv.GetRetention(ctx, req)
+	if err != nil {
+		return nil, versionError(err)
+	}
+	return res, nil
+}
+
+func (srv *VersionService) SetRetention(ctx context.Context, req *fmpb.RetentionRule) (*fmpb.RetentionRule, error) {
+	res, err := srv.srv.SetRetention(ctx, req)
+	if err != nil {
+		return nil, versionError(err)
+	}
+	return res, nil
+}
@@ -0,0 +1,33 @@
+package grpc
+
+import (
+	"errors"
+	"github.com/JunBSer/FileManager/internal/repository"
+	"github.com/JunBSer/FileManager/internal/service"
+	"github.com/JunBSer/FileManager/pkg/api/fmpb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type WatchService struct {
+	srv *service.WatchService
+	fmpb.UnimplementedWatchServiceServer
+}
+
+func NewWatchService(srv *service.WatchService) *WatchService {
+	return &WatchService{srv: srv}
+}
+
+func watchError(err error) error {
+	switch {
+	case errors.Is(err, repository.ErrEventsLost):
+		return status.Error(codes.OutOfRange, err.Error())
+	case errors.Is(err, service.ErrWatchDisabled):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	}
+	return accessError(err)
+}
+
+func (srv *WatchService) Watch(req *fmpb.WatchRequest, stream fmpb.WatchService_WatchServer) error {
+	return watchError(srv.srv.Watch(req, stream))
+}
@@ -0,0 +1,81 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"github.com/JunBSer/FileManager/internal/repository"
+	"github.com/JunBSer/FileManager/internal/service"
+	"github.com/JunBSer/FileManager/pkg/api/fmpb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type WebhookService struct {
+	srv *service.WebhookService
+	fmpb.UnimplementedWebhookServiceServer
+}
+
+func NewWebhookService(srv *service.WebhookService) *WebhookService {
+	return &WebhookService{srv: srv}
+}
+
+func webhookError(err error) error {
+	switch {
+	case errors.Is(err, repository.ErrInvalidWebhook):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, repository.ErrWebhookNotFound), errors.Is(err, repository.ErrDeadLetterNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, service.ErrWebhooksDisabled):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, service.ErrWebhooksStopped), errors.Is(err, service.ErrWebhookQueueFull):
+		return status.Error(codes.Unavailable, err.Error())
+	}
+	return accessError(err)
+}
+
+func (srv *WebhookService) CreateWebhook(ctx context.Context, req *fmpb.CreateWebhookRequest) (*fmpb.Webhook, error) {
+	res, err := srv.srv.CreateWebhook(ctx, req)
+	if err != nil {
+		return nil, webhookError(err)
+	}
+	return res, nil
+}
+
+func (srv *WebhookService) ListWebhooks(ctx context.Context, req *fmpb.ListWebhooksRequest) (*fmpb.ListWebhooksResponse, error) {
+	res, err := srv.srv.ListWebhooks(ctx, req)
+	if err != nil {
+		return nil, webhookError(err)
+	}
+	return res, nil
+}
+
+func (srv *WebhookService) DeleteWebhook(ctx context.Context, req *fmpb.DeleteWebhookRequest) (*fmpb.DeleteWebhookResponse, error) {
+	if err := srv.srv.DeleteWebhook(ctx, req); err != nil {
+		return nil, webhookError(err)
+	}
+	return &fmpb.DeleteWebhookResponse{}, nil
+}
+
+func (srv *WebhookService) ListDeliveries(ctx context.Context, req *fmpb.ListDeliveriesRequest) (*fmpb.ListDeliveriesResponse, error) {
+	res, err := srv.srv.ListDeliveries(ctx, req)
+	if err != nil {
+		return nil, webhookError(err)
+	}
+	return res, nil
+}
+
+func (srv *WebhookService) ListDeadLetters(ctx context.Context, req *fmpb.ListDeadLettersRequest) (*fmpb.ListDeliveriesResponse, error) {
+	res, err := srv.srv.ListDeadLetters(ctx, req)
+	if err != nil {
+		return nil, webhookError(err)
+	}
+	return res, nil
+}
+
+func (srv *WebhookService) Redeliver(ctx context.Context, req *fmpb.RedeliverRequest) (*fmpb.Delivery, error) {
+	res, err := srv.srv.Redeliver(ctx, req)
+	if err != nil {
+		return nil, webhookError(err)
+	}
+	return res, nil
+}
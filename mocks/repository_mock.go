@@ -1,5 +1,5 @@
 // Code generated by MockGen. DO NOT EDIT.
-// Source: D:\Education\LabWorks\Code\4Semester\CSnN\Computer-Networks\lab3\FileManager\internal\repository\file_repository.go
+// Source: internal/repository/file_repository.go
 
 // Package mocks is a generated GoMock package.
 package mocks
@@ -51,6 +51,49 @@ func (mr *MockFileRepositoryMockRecorder) AppendData(ctx, file, data, pos interf
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AppendData", reflect.TypeOf((*MockFileRepository)(nil).AppendData), ctx, file, data, pos)
 }
 
+// CommitTempFile mocks base method.
+func (m *MockFileRepository) CommitTempFile(ctx context.Context, file repository.FileHandle, path string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CommitTempFile", ctx, file, path)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CommitTempFile indicates an expected call of CommitTempFile.
+func (mr *MockFileRepositoryMockRecorder) CommitTempFile(ctx, file, path interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CommitTempFile", reflect.TypeOf((*MockFileRepository)(nil).CommitTempFile), ctx, file, path)
+}
+
+// CreateDir mocks base method.
+func (m *MockFileRepository) CreateDir(ctx context.Context, path string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateDir", ctx, path)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateDir indicates an expected call of CreateDir.
+func (mr *MockFileRepositoryMockRecorder) CreateDir(ctx, path interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateDir", reflect.TypeOf((*MockFileRepository)(nil).CreateDir), ctx, path)
+}
+
+// CreateTempFile mocks base method.
+func (m *MockFileRepository) CreateTempFile(ctx context.Context, path string) (repository.FileHandle, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateTempFile", ctx, path)
+	ret0, _ := ret[0].(repository.FileHandle)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateTempFile indicates an expected call of CreateTempFile.
+func (mr *MockFileRepositoryMockRecorder) CreateTempFile(ctx, path interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateTempFile", reflect.TypeOf((*MockFileRepository)(nil).CreateTempFile), ctx, path)
+}
+
 // DeleteFile mocks base method.
 func (m *MockFileRepository) DeleteFile(ctx context.Context, path string) error {
 	m.ctrl.T.Helper()
@@ -65,6 +108,20 @@ func (mr *MockFileRepositoryMockRecorder) DeleteFile(ctx, path interface{}) *gom
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteFile", reflect.TypeOf((*MockFileRepository)(nil).DeleteFile), ctx, path)
 }
 
+// DiscardTempFile mocks base method.
+func (m *MockFileRepository) DiscardTempFile(ctx context.Context, file repository.FileHandle) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DiscardTempFile", ctx, file)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DiscardTempFile indicates an expected call of DiscardTempFile.
+func (mr *MockFileRepositoryMockRecorder) DiscardTempFile(ctx, file interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DiscardTempFile", reflect.TypeOf((*MockFileRepository)(nil).DiscardTempFile), ctx, file)
+}
+
 // GetFileHandle mocks base method.
 func (m *MockFileRepository) GetFileHandle(ctx context.Context, path string, openOption int) (repository.FileHandle, error) {
 	m.ctrl.T.Helper()
@@ -139,6 +196,21 @@ func (mr *MockFileRepositoryMockRecorder) ReadFile(ctx, file, pos interface{}) *
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadFile", reflect.TypeOf((*MockFileRepository)(nil).ReadFile), ctx, file, pos)
 }
 
+// Stat mocks base method.
+func (m *MockFileRepository) Stat(ctx context.Context, path string) (fs.FileInfo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Stat", ctx, path)
+	ret0, _ := ret[0].(fs.FileInfo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Stat indicates an expected call of Stat.
+func (mr *MockFileRepositoryMockRecorder) Stat(ctx, path interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Stat", reflect.TypeOf((*MockFileRepository)(nil).Stat), ctx, path)
+}
+
 // MockFileHandle is a mock of FileHandle interface.
 type MockFileHandle struct {
 	ctrl     *gomock.Controller
@@ -0,0 +1,647 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        v5.29.3
+// source: pkg/api/fmpb/acl.proto
+
+package fmpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Grant struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Path the grant applies to, with everything below it.
+	Path string `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	// "*" for everybody, "user:<subject>" or "group:<name>".
+	Principal string `protobuf:"bytes,2,opt,name=principal,proto3" json:"principal,omitempty"`
+	// Role whose permissions are granted; optional if permissions are set.
+	Role string `protobuf:"bytes,3,opt,name=role,proto3" json:"role,omitempty"`
+	// Permissions granted in addition to the role: read, write, delete, list.
+	Permissions   []string `protobuf:"bytes,4,rep,name=permissions,proto3" json:"permissions,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Grant) Reset() {
+	*x = Grant{}
+	mi := &file_pkg_api_fmpb_acl_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Grant) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Grant) ProtoMessage() {}
+
+func (x *Grant) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_api_fmpb_acl_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Grant.ProtoReflect.Descriptor instead.
+func (*Grant) Descriptor() ([]byte, []int) {
+	return file_pkg_api_fmpb_acl_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Grant) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *Grant) GetPrincipal() string {
+	if x != nil {
+		return x.Principal
+	}
+	return ""
+}
+
+func (x *Grant) GetRole() string {
+	if x != nil {
+		return x.Role
+	}
+	return ""
+}
+
+func (x *Grant) GetPermissions() []string {
+	if x != nil {
+		return x.Permissions
+	}
+	return nil
+}
+
+type ListGrantsRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Lists the grants on path and below it; empty for all grants.
+	Path          string `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListGrantsRequest) Reset() {
+	*x = ListGrantsRequest{}
+	mi := &file_pkg_api_fmpb_acl_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListGrantsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListGrantsRequest) ProtoMessage() {}
+
+func (x *ListGrantsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_api_fmpb_acl_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListGrantsRequest.ProtoReflect.Descriptor instead.
+func (*ListGrantsRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_api_fmpb_acl_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *ListGrantsRequest) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+type ListGrantsResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Sorted by path and principal.
+	Grants        []*Grant `protobuf:"bytes,1,rep,name=grants,proto3" json:"grants,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListGrantsResponse) Reset() {
+	*x = ListGrantsResponse{}
+	mi := &file_pkg_api_fmpb_acl_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListGrantsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListGrantsResponse) ProtoMessage() {}
+
+func (x *ListGrantsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_api_fmpb_acl_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListGrantsResponse.ProtoReflect.Descriptor instead.
+func (*ListGrantsResponse) Descriptor() ([]byte, []int) {
+	return file_pkg_api_fmpb_acl_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *ListGrantsResponse) GetGrants() []*Grant {
+	if x != nil {
+		return x.Grants
+	}
+	return nil
+}
+
+type RemoveGrantRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Path          string                 `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	Principal     string                 `protobuf:"bytes,2,opt,name=principal,proto3" json:"principal,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RemoveGrantRequest) Reset() {
+	*x = RemoveGrantRequest{}
+	mi := &file_pkg_api_fmpb_acl_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RemoveGrantRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RemoveGrantRequest) ProtoMessage() {}
+
+func (x *RemoveGrantRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_api_fmpb_acl_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RemoveGrantRequest.ProtoReflect.Descriptor instead.
+func (*RemoveGrantRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_api_fmpb_acl_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *RemoveGrantRequest) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *RemoveGrantRequest) GetPrincipal() string {
+	if x != nil {
+		return x.Principal
+	}
+	return ""
+}
+
+type RemoveGrantResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RemoveGrantResponse) Reset() {
+	*x = RemoveGrantResponse{}
+	mi := &file_pkg_api_fmpb_acl_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RemoveGrantResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RemoveGrantResponse) ProtoMessage() {}
+
+func (x *RemoveGrantResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_api_fmpb_acl_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RemoveGrantResponse.ProtoReflect.Descriptor instead.
+func (*RemoveGrantResponse) Descriptor() ([]byte, []int) {
+	return file_pkg_api_fmpb_acl_proto_rawDescGZIP(), []int{4}
+}
+
+type GetAccessRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Path  string                 `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	// Subject and groups to check; empty for the caller. Only admins may
+	// check other identities.
+	Subject       string   `protobuf:"bytes,2,opt,name=subject,proto3" json:"subject,omitempty"`
+	Groups        []string `protobuf:"bytes,3,rep,name=groups,proto3" json:"groups,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetAccessRequest) Reset() {
+	*x = GetAccessRequest{}
+	mi := &file_pkg_api_fmpb_acl_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetAccessRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetAccessRequest) ProtoMessage() {}
+
+func (x *GetAccessRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_api_fmpb_acl_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetAccessRequest.ProtoReflect.Descriptor instead.
+func (*GetAccessRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_api_fmpb_acl_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *GetAccessRequest) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *GetAccessRequest) GetSubject() string {
+	if x != nil {
+		return x.Subject
+	}
+	return ""
+}
+
+func (x *GetAccessRequest) GetGroups() []string {
+	if x != nil {
+		return x.Groups
+	}
+	return nil
+}
+
+type Access struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Path    string                 `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	Subject string                 `protobuf:"bytes,2,opt,name=subject,proto3" json:"subject,omitempty"`
+	// Effective permissions on path.
+	Permissions []string `protobuf:"bytes,3,rep,name=permissions,proto3" json:"permissions,omitempty"`
+	// Admins pass every check.
+	Admin         bool `protobuf:"varint,4,opt,name=admin,proto3" json:"admin,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Access) Reset() {
+	*x = Access{}
+	mi := &file_pkg_api_fmpb_acl_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Access) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Access) ProtoMessage() {}
+
+func (x *Access) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_api_fmpb_acl_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Access.ProtoReflect.Descriptor instead.
+func (*Access) Descriptor() ([]byte, []int) {
+	return file_pkg_api_fmpb_acl_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *Access) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *Access) GetSubject() string {
+	if x != nil {
+		return x.Subject
+	}
+	return ""
+}
+
+func (x *Access) GetPermissions() []string {
+	if x != nil {
+		return x.Permissions
+	}
+	return nil
+}
+
+func (x *Access) GetAdmin() bool {
+	if x != nil {
+		return x.Admin
+	}
+	return false
+}
+
+type ListRolesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListRolesRequest) Reset() {
+	*x = ListRolesRequest{}
+	mi := &file_pkg_api_fmpb_acl_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListRolesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListRolesRequest) ProtoMessage() {}
+
+func (x *ListRolesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_api_fmpb_acl_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListRolesRequest.ProtoReflect.Descriptor instead.
+func (*ListRolesRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_api_fmpb_acl_proto_rawDescGZIP(), []int{7}
+}
+
+type Role struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Permissions   []string               `protobuf:"bytes,2,rep,name=permissions,proto3" json:"permissions,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Role) Reset() {
+	*x = Role{}
+	mi := &file_pkg_api_fmpb_acl_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Role) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Role) ProtoMessage() {}
+
+func (x *Role) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_api_fmpb_acl_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Role.ProtoReflect.Descriptor instead.
+func (*Role) Descriptor() ([]byte, []int) {
+	return file_pkg_api_fmpb_acl_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *Role) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Role) GetPermissions() []string {
+	if x != nil {
+		return x.Permissions
+	}
+	return nil
+}
+
+type ListRolesResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Sorted by name.
+	Roles         []*Role `protobuf:"bytes,1,rep,name=roles,proto3" json:"roles,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListRolesResponse) Reset() {
+	*x = ListRolesResponse{}
+	mi := &file_pkg_api_fmpb_acl_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListRolesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListRolesResponse) ProtoMessage() {}
+
+func (x *ListRolesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_api_fmpb_acl_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListRolesResponse.ProtoReflect.Descriptor instead.
+func (*ListRolesResponse) Descriptor() ([]byte, []int) {
+	return file_pkg_api_fmpb_acl_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *ListRolesResponse) GetRoles() []*Role {
+	if x != nil {
+		return x.Roles
+	}
+	return nil
+}
+
+var File_pkg_api_fmpb_acl_proto protoreflect.FileDescriptor
+
+const file_pkg_api_fmpb_acl_proto_rawDesc = "" +
+	"\n" +
+	"\x16pkg/api/fmpb/acl.proto\x12\x0ffile_manager.v1\"o\n" +
+	"\x05Grant\x12\x12\n" +
+	"\x04path\x18\x01 \x01(\tR\x04path\x12\x1c\n" +
+	"\tprincipal\x18\x02 \x01(\tR\tprincipal\x12\x12\n" +
+	"\x04role\x18\x03 \x01(\tR\x04role\x12 \n" +
+	"\vpermissions\x18\x04 \x03(\tR\vpermissions\"'\n" +
+	"\x11ListGrantsRequest\x12\x12\n" +
+	"\x04path\x18\x01 \x01(\tR\x04path\"D\n" +
+	"\x12ListGrantsResponse\x12.\n" +
+	"\x06grants\x18\x01 \x03(\v2\x16.file_manager.v1.GrantR\x06grants\"F\n" +
+	"\x12RemoveGrantRequest\x12\x12\n" +
+	"\x04path\x18\x01 \x01(\tR\x04path\x12\x1c\n" +
+	"\tprincipal\x18\x02 \x01(\tR\tprincipal\"\x15\n" +
+	"\x13RemoveGrantResponse\"X\n" +
+	"\x10GetAccessRequest\x12\x12\n" +
+	"\x04path\x18\x01 \x01(\tR\x04path\x12\x18\n" +
+	"\asubject\x18\x02 \x01(\tR\asubject\x12\x16\n" +
+	"\x06groups\x18\x03 \x03(\tR\x06groups\"n\n" +
+	"\x06Access\x12\x12\n" +
+	"\x04path\x18\x01 \x01(\tR\x04path\x12\x18\n" +
+	"\asubject\x18\x02 \x01(\tR\asubject\x12 \n" +
+	"\vpermissions\x18\x03 \x03(\tR\vpermissions\x12\x14\n" +
+	"\x05admin\x18\x04 \x01(\bR\x05admin\"\x12\n" +
+	"\x10ListRolesRequest\"<\n" +
+	"\x04Role\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12 \n" +
+	"\vpermissions\x18\x02 \x03(\tR\vpermissions\"@\n" +
+	"\x11ListRolesResponse\x12+\n" +
+	"\x05roles\x18\x01 \x03(\v2\x15.file_manager.v1.RoleR\x05roles2\xa0\x03\n" +
+	"\x14AccessControlService\x12U\n" +
+	"\n" +
+	"ListGrants\x12\".file_manager.v1.ListGrantsRequest\x1a#.file_manager.v1.ListGrantsResponse\x12:\n" +
+	"\bSetGrant\x12\x16.file_manager.v1.Grant\x1a\x16.file_manager.v1.Grant\x12X\n" +
+	"\vRemoveGrant\x12#.file_manager.v1.RemoveGrantRequest\x1a$.file_manager.v1.RemoveGrantResponse\x12G\n" +
+	"\tGetAccess\x12!.file_manager.v1.GetAccessRequest\x1a\x17.file_manager.v1.Access\x12R\n" +
+	"\tListRoles\x12!.file_manager.v1.ListRolesRequest\x1a\".file_manager.v1.ListRolesResponseB2Z0github.com/JunBSer/FileManager/pkg/api/fmpb;fmpbb\x06proto3"
+
+var (
+	file_pkg_api_fmpb_acl_proto_rawDescOnce sync.Once
+	file_pkg_api_fmpb_acl_proto_rawDescData []byte
+)
+
+func file_pkg_api_fmpb_acl_proto_rawDescGZIP() []byte {
+	file_pkg_api_fmpb_acl_proto_rawDescOnce.Do(func() {
+		file_pkg_api_fmpb_acl_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_pkg_api_fmpb_acl_proto_rawDesc), len(file_pkg_api_fmpb_acl_proto_rawDesc)))
+	})
+	return file_pkg_api_fmpb_acl_proto_rawDescData
+}
+
+var file_pkg_api_fmpb_acl_proto_msgTypes = make([]protoimpl.MessageInfo, 10)
+var file_pkg_api_fmpb_acl_proto_goTypes = []any{
+	(*Grant)(nil),               // 0: file_manager.v1.Grant
+	(*ListGrantsRequest)(nil),   // 1: file_manager.v1.ListGrantsRequest
+	(*ListGrantsResponse)(nil),  // 2: file_manager.v1.ListGrantsResponse
+	(*RemoveGrantRequest)(nil),  // 3: file_manager.v1.RemoveGrantRequest
+	(*RemoveGrantResponse)(nil), // 4: file_manager.v1.RemoveGrantResponse
+	(*GetAccessRequest)(nil),    // 5: file_manager.v1.GetAccessRequest
+	(*Access)(nil),              // 6: file_manager.v1.Access
+	(*ListRolesRequest)(nil),    // 7: file_manager.v1.ListRolesRequest
+	(*Role)(nil),                // 8: file_manager.v1.Role
+	(*ListRolesResponse)(nil),   // 9: file_manager.v1.ListRolesResponse
+}
+var file_pkg_api_fmpb_acl_proto_depIdxs = []int32{
+	0, // 0: file_manager.v1.ListGrantsResponse.grants:type_name -> file_manager.v1.Grant
+	8, // 1: file_manager.v1.ListRolesResponse.roles:type_name -> file_manager.v1.Role
+	1, // 2: file_manager.v1.AccessControlService.ListGrants:input_type -> file_manager.v1.ListGrantsRequest
+	0, // 3: file_manager.v1.AccessControlService.SetGrant:input_type -> file_manager.v1.Grant
+	3, // 4: file_manager.v1.AccessControlService.RemoveGrant:input_type -> file_manager.v1.RemoveGrantRequest
+	5, // 5: file_manager.v1.AccessControlService.GetAccess:input_type -> file_manager.v1.GetAccessRequest
+	7, // 6: file_manager.v1.AccessControlService.ListRoles:input_type -> file_manager.v1.ListRolesRequest
+	2, // 7: file_manager.v1.AccessControlService.ListGrants:output_type -> file_manager.v1.ListGrantsResponse
+	0, // 8: file_manager.v1.AccessControlService.SetGrant:output_type -> file_manager.v1.Grant
+	4, // 9: file_manager.v1.AccessControlService.RemoveGrant:output_type -> file_manager.v1.RemoveGrantResponse
+	6, // 10: file_manager.v1.AccessControlService.GetAccess:output_type -> file_manager.v1.Access
+	9, // 11: file_manager.v1.AccessControlService.ListRoles:output_type -> file_manager.v1.ListRolesResponse
+	7, // [7:12] is the sub-list for method output_type
+	2, // [2:7] is the sub-list for method input_type
+	2, // [2:2] is the sub-list for extension type_name
+	2, // [2:2] is the sub-list for extension extendee
+	0, // [0:2] is the sub-list for field type_name
+}
+
+func init() { file_pkg_api_fmpb_acl_proto_init() }
+func file_pkg_api_fmpb_acl_proto_init() {
+	if File_pkg_api_fmpb_acl_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_pkg_api_fmpb_acl_proto_rawDesc), len(file_pkg_api_fmpb_acl_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   10,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_pkg_api_fmpb_acl_proto_goTypes,
+		DependencyIndexes: file_pkg_api_fmpb_acl_proto_depIdxs,
+		MessageInfos:      file_pkg_api_fmpb_acl_proto_msgTypes,
+	}.Build()
+	File_pkg_api_fmpb_acl_proto = out.File
+	file_pkg_api_fmpb_acl_proto_goTypes = nil
+	file_pkg_api_fmpb_acl_proto_depIdxs = nil
+}
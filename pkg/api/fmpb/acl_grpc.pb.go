@@ -0,0 +1,283 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             v5.29.3
+// source: pkg/api/fmpb/acl.proto
+
+package fmpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	AccessControlService_ListGrants_FullMethodName  = "/file_manager.v1.AccessControlService/ListGrants"
+	AccessControlService_SetGrant_FullMethodName    = "/file_manager.v1.AccessControlService/SetGrant"
+	AccessControlService_RemoveGrant_FullMethodName = "/file_manager.v1.AccessControlService/RemoveGrant"
+	AccessControlService_GetAccess_FullMethodName   = "/file_manager.v1.AccessControlService/GetAccess"
+	AccessControlService_ListRoles_FullMethodName   = "/file_manager.v1.AccessControlService/ListRoles"
+)
+
+// AccessControlServiceClient is the client API for AccessControlService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// AccessControlService manages the grants that decide which paths callers
+// may read, write, delete and list. A grant on a directory covers its whole
+// tree. Denied operations of every service fail with PERMISSION_DENIED.
+// Only admins may change or list grants.
+type AccessControlServiceClient interface {
+	ListGrants(ctx context.Context, in *ListGrantsRequest, opts ...grpc.CallOption) (*ListGrantsResponse, error)
+	SetGrant(ctx context.Context, in *Grant, opts ...grpc.CallOption) (*Grant, error)
+	RemoveGrant(ctx context.Context, in *RemoveGrantRequest, opts ...grpc.CallOption) (*RemoveGrantResponse, error)
+	GetAccess(ctx context.Context, in *GetAccessRequest, opts ...grpc.CallOption) (*Access, error)
+	ListRoles(ctx context.Context, in *ListRolesRequest, opts ...grpc.CallOption) (*ListRolesResponse, error)
+}
+
+type accessControlServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewAccessControlServiceClient(cc grpc.ClientConnInterface) AccessControlServiceClient {
+	return &accessControlServiceClient{cc}
+}
+
+func (c *accessControlServiceClient) ListGrants(ctx context.Context, in *ListGrantsRequest, opts ...grpc.CallOption) (*ListGrantsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListGrantsResponse)
+	err := c.cc.Invoke(ctx, AccessControlService_ListGrants_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *accessControlServiceClient) SetGrant(ctx context.Context, in *Grant, opts ...grpc.CallOption) (*Grant, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Grant)
+	err := c.cc.Invoke(ctx, AccessControlService_SetGrant_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *accessControlServiceClient) RemoveGrant(ctx context.Context, in *RemoveGrantRequest, opts ...grpc.CallOption) (*RemoveGrantResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RemoveGrantResponse)
+	err := c.cc.Invoke(ctx, AccessControlService_RemoveGrant_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *accessControlServiceClient) GetAccess(ctx context.Context, in *GetAccessRequest, opts ...grpc.CallOption) (*Access, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Access)
+	err := c.cc.Invoke(ctx, AccessControlService_GetAccess_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *accessControlServiceClient) ListRoles(ctx context.Context, in *ListRolesRequest, opts ...grpc.CallOption) (*ListRolesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListRolesResponse)
+	err := c.cc.Invoke(ctx, AccessControlService_ListRoles_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// AccessControlServiceServer is the server API for AccessControlService service.
+// All implementations must embed UnimplementedAccessControlServiceServer
+// for forward compatibility.
+//
+// AccessControlService manages the grants that decide which paths callers
+// may read, write, delete and list. A grant on a directory covers its whole
+// tree. Denied operations of every service fail with PERMISSION_DENIED.
+// Only admins may change or list grants.
+type AccessControlServiceServer interface {
+	ListGrants(context.Context, *ListGrantsRequest) (*ListGrantsResponse, error)
+	SetGrant(context.Context, *Grant) (*Grant, error)
+	RemoveGrant(context.Context, *RemoveGrantRequest) (*RemoveGrantResponse, error)
+	GetAccess(context.Context, *GetAccessRequest) (*Access, error)
+	ListRoles(context.Context, *ListRolesRequest) (*ListRolesResponse, error)
+	mustEmbedUnimplementedAccessControlServiceServer()
+}
+
+// UnimplementedAccessControlServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedAccessControlServiceServer struct{}
+
+func (UnimplementedAccessControlServiceServer) ListGrants(context.Context, *ListGrantsRequest) (*ListGrantsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListGrants not implemented")
+}
+func (UnimplementedAccessControlServiceServer) SetGrant(context.Context, *Grant) (*Grant, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetGrant not implemented")
+}
+func (UnimplementedAccessControlServiceServer) RemoveGrant(context.Context, *RemoveGrantRequest) (*RemoveGrantResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RemoveGrant not implemented")
+}
+func (UnimplementedAccessControlServiceServer) GetAccess(context.Context, *GetAccessRequest) (*Access, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetAccess not implemented")
+}
+func (UnimplementedAccessControlServiceServer) ListRoles(context.Context, *ListRolesRequest) (*ListRolesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListRoles not implemented")
+}
+func (UnimplementedAccessControlServiceServer) mustEmbedUnimplementedAccessControlServiceServer() {}
+func (UnimplementedAccessControlServiceServer) testEmbeddedByValue()                              {}
+
+// UnsafeAccessControlServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to AccessControlServiceServer will
+// result in compilation errors.
+type UnsafeAccessControlServiceServer interface {
+	mustEmbedUnimplementedAccessControlServiceServer()
+}
+
+func RegisterAccessControlServiceServer(s grpc.ServiceRegistrar, srv AccessControlServiceServer) {
+	// If the following call pancis, it indicates UnimplementedAccessControlServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&AccessControlService_ServiceDesc, srv)
+}
+
+func _AccessControlService_ListGrants_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListGrantsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AccessControlServiceServer).ListGrants(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AccessControlService_ListGrants_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AccessControlServiceServer).ListGrants(ctx, req.(*ListGrantsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AccessControlService_SetGrant_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Grant)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AccessControlServiceServer).SetGrant(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AccessControlService_SetGrant_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AccessControlServiceServer).SetGrant(ctx, req.(*Grant))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AccessControlService_RemoveGrant_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RemoveGrantRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AccessControlServiceServer).RemoveGrant(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AccessControlService_RemoveGrant_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AccessControlServiceServer).RemoveGrant(ctx, req.(*RemoveGrantRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AccessControlService_GetAccess_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetAccessRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AccessControlServiceServer).GetAccess(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AccessControlService_GetAccess_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AccessControlServiceServer).GetAccess(ctx, req.(*GetAccessRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AccessControlService_ListRoles_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListRolesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AccessControlServiceServer).ListRoles(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AccessControlService_ListRoles_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AccessControlServiceServer).ListRoles(ctx, req.(*ListRolesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// AccessControlService_ServiceDesc is the grpc.ServiceDesc for AccessControlService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var AccessControlService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "file_manager.v1.AccessControlService",
+	HandlerType: (*AccessControlServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListGrants",
+			Handler:    _AccessControlService_ListGrants_Handler,
+		},
+		{
+			MethodName: "SetGrant",
+			Handler:    _AccessControlService_SetGrant_Handler,
+		},
+		{
+			MethodName: "RemoveGrant",
+			Handler:    _AccessControlService_RemoveGrant_Handler,
+		},
+		{
+			MethodName: "GetAccess",
+			Handler:    _AccessControlService_GetAccess_Handler,
+		},
+		{
+			MethodName: "ListRoles",
+			Handler:    _AccessControlService_ListRoles_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "pkg/api/fmpb/acl.proto",
+}
@@ -0,0 +1,11 @@
+package fmpb
+
+// Metadata keys that carry checksums of file contents, both as lowercase hex.
+// A client may send them with Upload, Append and OverwriteFile of the base
+// FileService; the checksums cover the whole file after the call. Download
+// and Read return the SHA-256 in the header when the server has a stored
+// digest of the file, and always in the trailer.
+const (
+	SHA256MetadataKey = "x-checksum-sha256"
+	CRC32CMetadataKey = "x-checksum-crc32c"
+)
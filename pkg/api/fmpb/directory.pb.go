@@ -0,0 +1,355 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        v5.29.3
+// source: pkg/api/fmpb/directory.proto
+
+package fmpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type CreateDirectoryRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Missing parents are created as well.
+	Path          string `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateDirectoryRequest) Reset() {
+	*x = CreateDirectoryRequest{}
+	mi := &file_pkg_api_fmpb_directory_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateDirectoryRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateDirectoryRequest) ProtoMessage() {}
+
+func (x *CreateDirectoryRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_api_fmpb_directory_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateDirectoryRequest.ProtoReflect.Descriptor instead.
+func (*CreateDirectoryRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_api_fmpb_directory_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *CreateDirectoryRequest) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+type DeleteDirectoryRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Path  string                 `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	// Deletes the content too; otherwise only an empty directory is deleted.
+	Recursive     bool `protobuf:"varint,2,opt,name=recursive,proto3" json:"recursive,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteDirectoryRequest) Reset() {
+	*x = DeleteDirectoryRequest{}
+	mi := &file_pkg_api_fmpb_directory_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteDirectoryRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteDirectoryRequest) ProtoMessage() {}
+
+func (x *DeleteDirectoryRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_api_fmpb_directory_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteDirectoryRequest.ProtoReflect.Descriptor instead.
+func (*DeleteDirectoryRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_api_fmpb_directory_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *DeleteDirectoryRequest) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *DeleteDirectoryRequest) GetRecursive() bool {
+	if x != nil {
+		return x.Recursive
+	}
+	return false
+}
+
+type TreeRequest struct {
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	Source      string                 `protobuf:"bytes,1,opt,name=source,proto3" json:"source,omitempty"`
+	Destination string                 `protobuf:"bytes,2,opt,name=destination,proto3" json:"destination,omitempty"`
+	// Copies directories with their content. Moves are always recursive.
+	Recursive bool `protobuf:"varint,3,opt,name=recursive,proto3" json:"recursive,omitempty"`
+	// Replaces existing files at the destination.
+	Overwrite     bool `protobuf:"varint,4,opt,name=overwrite,proto3" json:"overwrite,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TreeRequest) Reset() {
+	*x = TreeRequest{}
+	mi := &file_pkg_api_fmpb_directory_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TreeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TreeRequest) ProtoMessage() {}
+
+func (x *TreeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_api_fmpb_directory_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TreeRequest.ProtoReflect.Descriptor instead.
+func (*TreeRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_api_fmpb_directory_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *TreeRequest) GetSource() string {
+	if x != nil {
+		return x.Source
+	}
+	return ""
+}
+
+func (x *TreeRequest) GetDestination() string {
+	if x != nil {
+		return x.Destination
+	}
+	return ""
+}
+
+func (x *TreeRequest) GetRecursive() bool {
+	if x != nil {
+		return x.Recursive
+	}
+	return false
+}
+
+func (x *TreeRequest) GetOverwrite() bool {
+	if x != nil {
+		return x.Overwrite
+	}
+	return false
+}
+
+type PathResult struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Path  string                 `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	// Path the entry was copied or moved to; empty for deletes.
+	Target string `protobuf:"bytes,2,opt,name=target,proto3" json:"target,omitempty"`
+	IsDir  bool   `protobuf:"varint,3,opt,name=is_dir,json=isDir,proto3" json:"is_dir,omitempty"`
+	// gRPC status code of the entry, 0 if it succeeded.
+	Code          int32  `protobuf:"varint,4,opt,name=code,proto3" json:"code,omitempty"`
+	Error         string `protobuf:"bytes,5,opt,name=error,proto3" json:"error,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PathResult) Reset() {
+	*x = PathResult{}
+	mi := &file_pkg_api_fmpb_directory_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PathResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PathResult) ProtoMessage() {}
+
+func (x *PathResult) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_api_fmpb_directory_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PathResult.ProtoReflect.Descriptor instead.
+func (*PathResult) Descriptor() ([]byte, []int) {
+	return file_pkg_api_fmpb_directory_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *PathResult) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *PathResult) GetTarget() string {
+	if x != nil {
+		return x.Target
+	}
+	return ""
+}
+
+func (x *PathResult) GetIsDir() bool {
+	if x != nil {
+		return x.IsDir
+	}
+	return false
+}
+
+func (x *PathResult) GetCode() int32 {
+	if x != nil {
+		return x.Code
+	}
+	return 0
+}
+
+func (x *PathResult) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+var File_pkg_api_fmpb_directory_proto protoreflect.FileDescriptor
+
+const file_pkg_api_fmpb_directory_proto_rawDesc = "" +
+	"\n" +
+	"\x1cpkg/api/fmpb/directory.proto\x12\x0ffile_manager.v1\",\n" +
+	"\x16CreateDirectoryRequest\x12\x12\n" +
+	"\x04path\x18\x01 \x01(\tR\x04path\"J\n" +
+	"\x16DeleteDirectoryRequest\x12\x12\n" +
+	"\x04path\x18\x01 \x01(\tR\x04path\x12\x1c\n" +
+	"\trecursive\x18\x02 \x01(\bR\trecursive\"\x83\x01\n" +
+	"\vTreeRequest\x12\x16\n" +
+	"\x06source\x18\x01 \x01(\tR\x06source\x12 \n" +
+	"\vdestination\x18\x02 \x01(\tR\vdestination\x12\x1c\n" +
+	"\trecursive\x18\x03 \x01(\bR\trecursive\x12\x1c\n" +
+	"\toverwrite\x18\x04 \x01(\bR\toverwrite\"y\n" +
+	"\n" +
+	"PathResult\x12\x12\n" +
+	"\x04path\x18\x01 \x01(\tR\x04path\x12\x16\n" +
+	"\x06target\x18\x02 \x01(\tR\x06target\x12\x15\n" +
+	"\x06is_dir\x18\x03 \x01(\bR\x05isDir\x12\x12\n" +
+	"\x04code\x18\x04 \x01(\x05R\x04code\x12\x14\n" +
+	"\x05error\x18\x05 \x01(\tR\x05error2\xd8\x02\n" +
+	"\x10DirectoryService\x12W\n" +
+	"\x0fCreateDirectory\x12'.file_manager.v1.CreateDirectoryRequest\x1a\x1b.file_manager.v1.PathResult\x12Y\n" +
+	"\x0fDeleteDirectory\x12'.file_manager.v1.DeleteDirectoryRequest\x1a\x1b.file_manager.v1.PathResult0\x01\x12G\n" +
+	"\bCopyPath\x12\x1c.file_manager.v1.TreeRequest\x1a\x1b.file_manager.v1.PathResult0\x01\x12G\n" +
+	"\bMovePath\x12\x1c.file_manager.v1.TreeRequest\x1a\x1b.file_manager.v1.PathResult0\x01B2Z0github.com/JunBSer/FileManager/pkg/api/fmpb;fmpbb\x06proto3"
+
+var (
+	file_pkg_api_fmpb_directory_proto_rawDescOnce sync.Once
+	file_pkg_api_fmpb_directory_proto_rawDescData []byte
+)
+
+func file_pkg_api_fmpb_directory_proto_rawDescGZIP() []byte {
+	file_pkg_api_fmpb_directory_proto_rawDescOnce.Do(func() {
+		file_pkg_api_fmpb_directory_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_pkg_api_fmpb_directory_proto_rawDesc), len(file_pkg_api_fmpb_directory_proto_rawDesc)))
+	})
+	return file_pkg_api_fmpb_directory_proto_rawDescData
+}
+
+var file_pkg_api_fmpb_directory_proto_msgTypes = make([]protoimpl.MessageInfo, 4)
+var file_pkg_api_fmpb_directory_proto_goTypes = []any{
+	(*CreateDirectoryRequest)(nil), // 0: file_manager.v1.CreateDirectoryRequest
+	(*DeleteDirectoryRequest)(nil), // 1: file_manager.v1.DeleteDirectoryRequest
+	(*TreeRequest)(nil),            // 2: file_manager.v1.TreeRequest
+	(*PathResult)(nil),             // 3: file_manager.v1.PathResult
+}
+var file_pkg_api_fmpb_directory_proto_depIdxs = []int32{
+	0, // 0: file_manager.v1.DirectoryService.CreateDirectory:input_type -> file_manager.v1.CreateDirectoryRequest
+	1, // 1: file_manager.v1.DirectoryService.DeleteDirectory:input_type -> file_manager.v1.DeleteDirectoryRequest
+	2, // 2: file_manager.v1.DirectoryService.CopyPath:input_type -> file_manager.v1.TreeRequest
+	2, // 3: file_manager.v1.DirectoryService.MovePath:input_type -> file_manager.v1.TreeRequest
+	3, // 4: file_manager.v1.DirectoryService.CreateDirectory:output_type -> file_manager.v1.PathResult
+	3, // 5: file_manager.v1.DirectoryService.DeleteDirectory:output_type -> file_manager.v1.PathResult
+	3, // 6: file_manager.v1.DirectoryService.CopyPath:output_type -> file_manager.v1.PathResult
+	3, // 7: file_manager.v1.DirectoryService.MovePath:output_type -> file_manager.v1.PathResult
+	4, // [4:8] is the sub-list for method output_type
+	0, // [0:4] is the sub-list for method input_type
+	0, // [0:0] is the sub-list for extension type_name
+	0, // [0:0] is the sub-list for extension extendee
+	0, // [0:0] is the sub-list for field type_name
+}
+
+func init() { file_pkg_api_fmpb_directory_proto_init() }
+func file_pkg_api_fmpb_directory_proto_init() {
+	if File_pkg_api_fmpb_directory_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_pkg_api_fmpb_directory_proto_rawDesc), len(file_pkg_api_fmpb_directory_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   4,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_pkg_api_fmpb_directory_proto_goTypes,
+		DependencyIndexes: file_pkg_api_fmpb_directory_proto_depIdxs,
+		MessageInfos:      file_pkg_api_fmpb_directory_proto_msgTypes,
+	}.Build()
+	File_pkg_api_fmpb_directory_proto = out.File
+	file_pkg_api_fmpb_directory_proto_goTypes = nil
+	file_pkg_api_fmpb_directory_proto_depIdxs = nil
+}
@@ -0,0 +1,253 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             v5.29.3
+// source: pkg/api/fmpb/directory.proto
+
+package fmpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	DirectoryService_CreateDirectory_FullMethodName = "/file_manager.v1.DirectoryService/CreateDirectory"
+	DirectoryService_DeleteDirectory_FullMethodName = "/file_manager.v1.DirectoryService/DeleteDirectory"
+	DirectoryService_CopyPath_FullMethodName        = "/file_manager.v1.DirectoryService/CopyPath"
+	DirectoryService_MovePath_FullMethodName        = "/file_manager.v1.DirectoryService/MovePath"
+)
+
+// DirectoryServiceClient is the client API for DirectoryService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// DirectoryService works on whole directory trees. The streaming calls send
+// one PathResult per entry as it is processed and carry on after a failed
+// entry; the call itself only fails if it cannot start.
+type DirectoryServiceClient interface {
+	CreateDirectory(ctx context.Context, in *CreateDirectoryRequest, opts ...grpc.CallOption) (*PathResult, error)
+	DeleteDirectory(ctx context.Context, in *DeleteDirectoryRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[PathResult], error)
+	CopyPath(ctx context.Context, in *TreeRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[PathResult], error)
+	MovePath(ctx context.Context, in *TreeRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[PathResult], error)
+}
+
+type directoryServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewDirectoryServiceClient(cc grpc.ClientConnInterface) DirectoryServiceClient {
+	return &directoryServiceClient{cc}
+}
+
+func (c *directoryServiceClient) CreateDirectory(ctx context.Context, in *CreateDirectoryRequest, opts ...grpc.CallOption) (*PathResult, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(PathResult)
+	err := c.cc.Invoke(ctx, DirectoryService_CreateDirectory_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *directoryServiceClient) DeleteDirectory(ctx context.Context, in *DeleteDirectoryRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[PathResult], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &DirectoryService_ServiceDesc.Streams[0], DirectoryService_DeleteDirectory_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[DeleteDirectoryRequest, PathResult]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type DirectoryService_DeleteDirectoryClient = grpc.ServerStreamingClient[PathResult]
+
+func (c *directoryServiceClient) CopyPath(ctx context.Context, in *TreeRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[PathResult], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &DirectoryService_ServiceDesc.Streams[1], DirectoryService_CopyPath_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[TreeRequest, PathResult]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type DirectoryService_CopyPathClient = grpc.ServerStreamingClient[PathResult]
+
+func (c *directoryServiceClient) MovePath(ctx context.Context, in *TreeRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[PathResult], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &DirectoryService_ServiceDesc.Streams[2], DirectoryService_MovePath_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[TreeRequest, PathResult]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type DirectoryService_MovePathClient = grpc.ServerStreamingClient[PathResult]
+
+// DirectoryServiceServer is the server API for DirectoryService service.
+// All implementations must embed UnimplementedDirectoryServiceServer
+// for forward compatibility.
+//
+// DirectoryService works on whole directory trees. The streaming calls send
+// one PathResult per entry as it is processed and carry on after a failed
+// entry; the call itself only fails if it cannot start.
+type DirectoryServiceServer interface {
+	CreateDirectory(context.Context, *CreateDirectoryRequest) (*PathResult, error)
+	DeleteDirectory(*DeleteDirectoryRequest, grpc.ServerStreamingServer[PathResult]) error
+	CopyPath(*TreeRequest, grpc.ServerStreamingServer[PathResult]) error
+	MovePath(*TreeRequest, grpc.ServerStreamingServer[PathResult]) error
+	mustEmbedUnimplementedDirectoryServiceServer()
+}
+
+// UnimplementedDirectoryServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedDirectoryServiceServer struct{}
+
+func (UnimplementedDirectoryServiceServer) CreateDirectory(context.Context, *CreateDirectoryRequest) (*PathResult, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateDirectory not implemented")
+}
+func (UnimplementedDirectoryServiceServer) DeleteDirectory(*DeleteDirectoryRequest, grpc.ServerStreamingServer[PathResult]) error {
+	return status.Errorf(codes.Unimplemented, "method DeleteDirectory not implemented")
+}
+func (UnimplementedDirectoryServiceServer) CopyPath(*TreeRequest, grpc.ServerStreamingServer[PathResult]) error {
+	return status.Errorf(codes.Unimplemented, "method CopyPath not implemented")
+}
+func (UnimplementedDirectoryServiceServer) MovePath(*TreeRequest, grpc.ServerStreamingServer[PathResult]) error {
+	return status.Errorf(codes.Unimplemented, "method MovePath not implemented")
+}
+func (UnimplementedDirectoryServiceServer) mustEmbedUnimplementedDirectoryServiceServer() {}
+func (UnimplementedDirectoryServiceServer) testEmbeddedByValue()                          {}
+
+// UnsafeDirectoryServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to DirectoryServiceServer will
+// result in compilation errors.
+type UnsafeDirectoryServiceServer interface {
+	mustEmbedUnimplementedDirectoryServiceServer()
+}
+
+func RegisterDirectoryServiceServer(s grpc.ServiceRegistrar, srv DirectoryServiceServer) {
+	// If the following call pancis, it indicates UnimplementedDirectoryServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&DirectoryService_ServiceDesc, srv)
+}
+
+func _DirectoryService_CreateDirectory_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateDirectoryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DirectoryServiceServer).CreateDirectory(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DirectoryService_CreateDirectory_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DirectoryServiceServer).CreateDirectory(ctx, req.(*CreateDirectoryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DirectoryService_DeleteDirectory_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(DeleteDirectoryRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(DirectoryServiceServer).DeleteDirectory(m, &grpc.GenericServerStream[DeleteDirectoryRequest, PathResult]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type DirectoryService_DeleteDirectoryServer = grpc.ServerStreamingServer[PathResult]
+
+func _DirectoryService_CopyPath_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(TreeRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(DirectoryServiceServer).CopyPath(m, &grpc.GenericServerStream[TreeRequest, PathResult]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type DirectoryService_CopyPathServer = grpc.ServerStreamingServer[PathResult]
+
+func _DirectoryService_MovePath_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(TreeRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(DirectoryServiceServer).MovePath(m, &grpc.GenericServerStream[TreeRequest, PathResult]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type DirectoryService_MovePathServer = grpc.ServerStreamingServer[PathResult]
+
+// DirectoryService_ServiceDesc is the grpc.ServiceDesc for DirectoryService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var DirectoryService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "file_manager.v1.DirectoryService",
+	HandlerType: (*DirectoryServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "CreateDirectory",
+			Handler:    _DirectoryService_CreateDirectory_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "DeleteDirectory",
+			Handler:       _DirectoryService_DeleteDirectory_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "CopyPath",
+			Handler:       _DirectoryService_CopyPath_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "MovePath",
+			Handler:       _DirectoryService_MovePath_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "pkg/api/fmpb/directory.proto",
+}
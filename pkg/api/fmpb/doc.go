@@ -0,0 +1,5 @@
+// Package fmpb contains the FileManager gRPC services that are defined in this
+// repository in addition to the base FileService from proto_fileManager.
+package fmpb
+
+//go:generate sh -c "protoc -I ../../.. --go_out=../../.. --go_opt=paths=source_relative --go-grpc_out=../../.. --go-grpc_opt=paths=source_relative ../../../pkg/api/fmpb/*.proto"
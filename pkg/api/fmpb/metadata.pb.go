@@ -0,0 +1,486 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        v5.29.3
+// source: pkg/api/fmpb/metadata.proto
+
+package fmpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type SortField int32
+
+const (
+	SortField_SORT_FIELD_NAME     SortField = 0
+	SortField_SORT_FIELD_SIZE     SortField = 1
+	SortField_SORT_FIELD_MOD_TIME SortField = 2
+)
+
+// Enum value maps for SortField.
+var (
+	SortField_name = map[int32]string{
+		0: "SORT_FIELD_NAME",
+		1: "SORT_FIELD_SIZE",
+		2: "SORT_FIELD_MOD_TIME",
+	}
+	SortField_value = map[string]int32{
+		"SORT_FIELD_NAME":     0,
+		"SORT_FIELD_SIZE":     1,
+		"SORT_FIELD_MOD_TIME": 2,
+	}
+)
+
+func (x SortField) Enum() *SortField {
+	p := new(SortField)
+	*p = x
+	return p
+}
+
+func (x SortField) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (SortField) Descriptor() protoreflect.EnumDescriptor {
+	return file_pkg_api_fmpb_metadata_proto_enumTypes[0].Descriptor()
+}
+
+func (SortField) Type() protoreflect.EnumType {
+	return &file_pkg_api_fmpb_metadata_proto_enumTypes[0]
+}
+
+func (x SortField) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use SortField.Descriptor instead.
+func (SortField) EnumDescriptor() ([]byte, []int) {
+	return file_pkg_api_fmpb_metadata_proto_rawDescGZIP(), []int{0}
+}
+
+type StatRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Path  string                 `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	// Adds the checksum of the content, which may need to read the file.
+	Checksum      bool `protobuf:"varint,2,opt,name=checksum,proto3" json:"checksum,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StatRequest) Reset() {
+	*x = StatRequest{}
+	mi := &file_pkg_api_fmpb_metadata_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StatRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StatRequest) ProtoMessage() {}
+
+func (x *StatRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_api_fmpb_metadata_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StatRequest.ProtoReflect.Descriptor instead.
+func (*StatRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_api_fmpb_metadata_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *StatRequest) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *StatRequest) GetChecksum() bool {
+	if x != nil {
+		return x.Checksum
+	}
+	return false
+}
+
+type FileStat struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Name  string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Path  string                 `protobuf:"bytes,2,opt,name=path,proto3" json:"path,omitempty"`
+	IsDir bool                   `protobuf:"varint,3,opt,name=is_dir,json=isDir,proto3" json:"is_dir,omitempty"`
+	Size  int64                  `protobuf:"varint,4,opt,name=size,proto3" json:"size,omitempty"`
+	// Unix time of the last modification.
+	ModTime int64 `protobuf:"varint,5,opt,name=mod_time,json=modTime,proto3" json:"mod_time,omitempty"`
+	// Go fs.FileMode bits.
+	Mode uint32 `protobuf:"varint,6,opt,name=mode,proto3" json:"mode,omitempty"`
+	// MIME type guessed from the extension; empty for directories.
+	ContentType string `protobuf:"bytes,7,opt,name=content_type,json=contentType,proto3" json:"content_type,omitempty"`
+	// "sha256:<hex>" of the content, if requested.
+	Checksum string `protobuf:"bytes,8,opt,name=checksum,proto3" json:"checksum,omitempty"`
+	// Bytes the file takes in the storage. It differs from size for files
+	// stored compressed or encrypted.
+	PhysicalSize  int64 `protobuf:"varint,9,opt,name=physical_size,json=physicalSize,proto3" json:"physical_size,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FileStat) Reset() {
+	*x = FileStat{}
+	mi := &file_pkg_api_fmpb_metadata_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FileStat) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FileStat) ProtoMessage() {}
+
+func (x *FileStat) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_api_fmpb_metadata_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FileStat.ProtoReflect.Descriptor instead.
+func (*FileStat) Descriptor() ([]byte, []int) {
+	return file_pkg_api_fmpb_metadata_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *FileStat) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *FileStat) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *FileStat) GetIsDir() bool {
+	if x != nil {
+		return x.IsDir
+	}
+	return false
+}
+
+func (x *FileStat) GetSize() int64 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+func (x *FileStat) GetModTime() int64 {
+	if x != nil {
+		return x.ModTime
+	}
+	return 0
+}
+
+func (x *FileStat) GetMode() uint32 {
+	if x != nil {
+		return x.Mode
+	}
+	return 0
+}
+
+func (x *FileStat) GetContentType() string {
+	if x != nil {
+		return x.ContentType
+	}
+	return ""
+}
+
+func (x *FileStat) GetChecksum() string {
+	if x != nil {
+		return x.Checksum
+	}
+	return ""
+}
+
+func (x *FileStat) GetPhysicalSize() int64 {
+	if x != nil {
+		return x.PhysicalSize
+	}
+	return 0
+}
+
+type ListDirectoryRequest struct {
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	Path       string                 `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	SortBy     SortField              `protobuf:"varint,2,opt,name=sort_by,json=sortBy,proto3,enum=file_manager.v1.SortField" json:"sort_by,omitempty"`
+	Descending bool                   `protobuf:"varint,3,opt,name=descending,proto3" json:"descending,omitempty"`
+	// Shell pattern the entry names must match, e.g. "*.log".
+	Pattern string `protobuf:"bytes,4,opt,name=pattern,proto3" json:"pattern,omitempty"`
+	// Maximum number of entries to return; 0 uses the server default.
+	PageSize int32 `protobuf:"varint,5,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`
+	// next_cursor of the previous page.
+	Cursor        string `protobuf:"bytes,6,opt,name=cursor,proto3" json:"cursor,omitempty"`
+	Checksum      bool   `protobuf:"varint,7,opt,name=checksum,proto3" json:"checksum,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListDirectoryRequest) Reset() {
+	*x = ListDirectoryRequest{}
+	mi := &file_pkg_api_fmpb_metadata_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListDirectoryRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListDirectoryRequest) ProtoMessage() {}
+
+func (x *ListDirectoryRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_api_fmpb_metadata_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListDirectoryRequest.ProtoReflect.Descriptor instead.
+func (*ListDirectoryRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_api_fmpb_metadata_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *ListDirectoryRequest) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *ListDirectoryRequest) GetSortBy() SortField {
+	if x != nil {
+		return x.SortBy
+	}
+	return SortField_SORT_FIELD_NAME
+}
+
+func (x *ListDirectoryRequest) GetDescending() bool {
+	if x != nil {
+		return x.Descending
+	}
+	return false
+}
+
+func (x *ListDirectoryRequest) GetPattern() string {
+	if x != nil {
+		return x.Pattern
+	}
+	return ""
+}
+
+func (x *ListDirectoryRequest) GetPageSize() int32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
+func (x *ListDirectoryRequest) GetCursor() string {
+	if x != nil {
+		return x.Cursor
+	}
+	return ""
+}
+
+func (x *ListDirectoryRequest) GetChecksum() bool {
+	if x != nil {
+		return x.Checksum
+	}
+	return false
+}
+
+type ListDirectoryResponse struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Entries []*FileStat            `protobuf:"bytes,1,rep,name=entries,proto3" json:"entries,omitempty"`
+	// Empty on the last page.
+	NextCursor    string `protobuf:"bytes,2,opt,name=next_cursor,json=nextCursor,proto3" json:"next_cursor,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListDirectoryResponse) Reset() {
+	*x = ListDirectoryResponse{}
+	mi := &file_pkg_api_fmpb_metadata_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListDirectoryResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListDirectoryResponse) ProtoMessage() {}
+
+func (x *ListDirectoryResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_api_fmpb_metadata_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListDirectoryResponse.ProtoReflect.Descriptor instead.
+func (*ListDirectoryResponse) Descriptor() ([]byte, []int) {
+	return file_pkg_api_fmpb_metadata_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *ListDirectoryResponse) GetEntries() []*FileStat {
+	if x != nil {
+		return x.Entries
+	}
+	return nil
+}
+
+func (x *ListDirectoryResponse) GetNextCursor() string {
+	if x != nil {
+		return x.NextCursor
+	}
+	return ""
+}
+
+var File_pkg_api_fmpb_metadata_proto protoreflect.FileDescriptor
+
+const file_pkg_api_fmpb_metadata_proto_rawDesc = "" +
+	"\n" +
+	"\x1bpkg/api/fmpb/metadata.proto\x12\x0ffile_manager.v1\"=\n" +
+	"\vStatRequest\x12\x12\n" +
+	"\x04path\x18\x01 \x01(\tR\x04path\x12\x1a\n" +
+	"\bchecksum\x18\x02 \x01(\bR\bchecksum\"\xf0\x01\n" +
+	"\bFileStat\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x12\n" +
+	"\x04path\x18\x02 \x01(\tR\x04path\x12\x15\n" +
+	"\x06is_dir\x18\x03 \x01(\bR\x05isDir\x12\x12\n" +
+	"\x04size\x18\x04 \x01(\x03R\x04size\x12\x19\n" +
+	"\bmod_time\x18\x05 \x01(\x03R\amodTime\x12\x12\n" +
+	"\x04mode\x18\x06 \x01(\rR\x04mode\x12!\n" +
+	"\fcontent_type\x18\a \x01(\tR\vcontentType\x12\x1a\n" +
+	"\bchecksum\x18\b \x01(\tR\bchecksum\x12#\n" +
+	"\rphysical_size\x18\t \x01(\x03R\fphysicalSize\"\xea\x01\n" +
+	"\x14ListDirectoryRequest\x12\x12\n" +
+	"\x04path\x18\x01 \x01(\tR\x04path\x123\n" +
+	"\asort_by\x18\x02 \x01(\x0e2\x1a.file_manager.v1.SortFieldR\x06sortBy\x12\x1e\n" +
+	"\n" +
+	"descending\x18\x03 \x01(\bR\n" +
+	"descending\x12\x18\n" +
+	"\apattern\x18\x04 \x01(\tR\apattern\x12\x1b\n" +
+	"\tpage_size\x18\x05 \x01(\x05R\bpageSize\x12\x16\n" +
+	"\x06cursor\x18\x06 \x01(\tR\x06cursor\x12\x1a\n" +
+	"\bchecksum\x18\a \x01(\bR\bchecksum\"m\n" +
+	"\x15ListDirectoryResponse\x123\n" +
+	"\aentries\x18\x01 \x03(\v2\x19.file_manager.v1.FileStatR\aentries\x12\x1f\n" +
+	"\vnext_cursor\x18\x02 \x01(\tR\n" +
+	"nextCursor*N\n" +
+	"\tSortField\x12\x13\n" +
+	"\x0fSORT_FIELD_NAME\x10\x00\x12\x13\n" +
+	"\x0fSORT_FIELD_SIZE\x10\x01\x12\x17\n" +
+	"\x13SORT_FIELD_MOD_TIME\x10\x022\xb2\x01\n" +
+	"\x0fMetadataService\x12?\n" +
+	"\x04Stat\x12\x1c.file_manager.v1.StatRequest\x1a\x19.file_manager.v1.FileStat\x12^\n" +
+	"\rListDirectory\x12%.file_manager.v1.ListDirectoryRequest\x1a&.file_manager.v1.ListDirectoryResponseB2Z0github.com/JunBSer/FileManager/pkg/api/fmpb;fmpbb\x06proto3"
+
+var (
+	file_pkg_api_fmpb_metadata_proto_rawDescOnce sync.Once
+	file_pkg_api_fmpb_metadata_proto_rawDescData []byte
+)
+
+func file_pkg_api_fmpb_metadata_proto_rawDescGZIP() []byte {
+	file_pkg_api_fmpb_metadata_proto_rawDescOnce.Do(func() {
+		file_pkg_api_fmpb_metadata_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_pkg_api_fmpb_metadata_proto_rawDesc), len(file_pkg_api_fmpb_metadata_proto_rawDesc)))
+	})
+	return file_pkg_api_fmpb_metadata_proto_rawDescData
+}
+
+var file_pkg_api_fmpb_metadata_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_pkg_api_fmpb_metadata_proto_msgTypes = make([]protoimpl.MessageInfo, 4)
+var file_pkg_api_fmpb_metadata_proto_goTypes = []any{
+	(SortField)(0),                // 0: file_manager.v1.SortField
+	(*StatRequest)(nil),           // 1: file_manager.v1.StatRequest
+	(*FileStat)(nil),              // 2: file_manager.v1.FileStat
+	(*ListDirectoryRequest)(nil),  // 3: file_manager.v1.ListDirectoryRequest
+	(*ListDirectoryResponse)(nil), // 4: file_manager.v1.ListDirectoryResponse
+}
+var file_pkg_api_fmpb_metadata_proto_depIdxs = []int32{
+	0, // 0: file_manager.v1.ListDirectoryRequest.sort_by:type_name -> file_manager.v1.SortField
+	2, // 1: file_manager.v1.ListDirectoryResponse.entries:type_name -> file_manager.v1.FileStat
+	1, // 2: file_manager.v1.MetadataService.Stat:input_type -> file_manager.v1.StatRequest
+	3, // 3: file_manager.v1.MetadataService.ListDirectory:input_type -> file_manager.v1.ListDirectoryRequest
+	2, // 4: file_manager.v1.MetadataService.Stat:output_type -> file_manager.v1.FileStat
+	4, // 5: file_manager.v1.MetadataService.ListDirectory:output_type -> file_manager.v1.ListDirectoryResponse
+	4, // [4:6] is the sub-list for method output_type
+	2, // [2:4] is the sub-list for method input_type
+	2, // [2:2] is the sub-list for extension type_name
+	2, // [2:2] is the sub-list for extension extendee
+	0, // [0:2] is the sub-list for field type_name
+}
+
+func init() { file_pkg_api_fmpb_metadata_proto_init() }
+func file_pkg_api_fmpb_metadata_proto_init() {
+	if File_pkg_api_fmpb_metadata_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_pkg_api_fmpb_metadata_proto_rawDesc), len(file_pkg_api_fmpb_metadata_proto_rawDesc)),
+			NumEnums:      1,
+			NumMessages:   4,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_pkg_api_fmpb_metadata_proto_goTypes,
+		DependencyIndexes: file_pkg_api_fmpb_metadata_proto_depIdxs,
+		EnumInfos:         file_pkg_api_fmpb_metadata_proto_enumTypes,
+		MessageInfos:      file_pkg_api_fmpb_metadata_proto_msgTypes,
+	}.Build()
+	File_pkg_api_fmpb_metadata_proto = out.File
+	file_pkg_api_fmpb_metadata_proto_goTypes = nil
+	file_pkg_api_fmpb_metadata_proto_depIdxs = nil
+}
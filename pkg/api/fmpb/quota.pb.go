@@ -0,0 +1,315 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        v5.29.3
+// source: pkg/api/fmpb/quota.proto
+
+package fmpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type GetUsageRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Top level directory; empty or "/" for the files stored in the root.
+	Namespace string `protobuf:"bytes,1,opt,name=namespace,proto3" json:"namespace,omitempty"`
+	// Counts the usage again instead of reading the usage index.
+	Recount       bool `protobuf:"varint,2,opt,name=recount,proto3" json:"recount,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetUsageRequest) Reset() {
+	*x = GetUsageRequest{}
+	mi := &file_pkg_api_fmpb_quota_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetUsageRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetUsageRequest) ProtoMessage() {}
+
+func (x *GetUsageRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_api_fmpb_quota_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetUsageRequest.ProtoReflect.Descriptor instead.
+func (*GetUsageRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_api_fmpb_quota_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *GetUsageRequest) GetNamespace() string {
+	if x != nil {
+		return x.Namespace
+	}
+	return ""
+}
+
+func (x *GetUsageRequest) GetRecount() bool {
+	if x != nil {
+		return x.Recount
+	}
+	return false
+}
+
+type ListUsageRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListUsageRequest) Reset() {
+	*x = ListUsageRequest{}
+	mi := &file_pkg_api_fmpb_quota_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListUsageRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListUsageRequest) ProtoMessage() {}
+
+func (x *ListUsageRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_api_fmpb_quota_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListUsageRequest.ProtoReflect.Descriptor instead.
+func (*ListUsageRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_api_fmpb_quota_proto_rawDescGZIP(), []int{1}
+}
+
+type ListUsageResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The root and every top level directory, sorted by namespace.
+	Namespaces    []*NamespaceUsage `protobuf:"bytes,1,rep,name=namespaces,proto3" json:"namespaces,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListUsageResponse) Reset() {
+	*x = ListUsageResponse{}
+	mi := &file_pkg_api_fmpb_quota_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListUsageResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListUsageResponse) ProtoMessage() {}
+
+func (x *ListUsageResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_api_fmpb_quota_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListUsageResponse.ProtoReflect.Descriptor instead.
+func (*ListUsageResponse) Descriptor() ([]byte, []int) {
+	return file_pkg_api_fmpb_quota_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *ListUsageResponse) GetNamespaces() []*NamespaceUsage {
+	if x != nil {
+		return x.Namespaces
+	}
+	return nil
+}
+
+type NamespaceUsage struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Top level directory, or "/" for the root.
+	Namespace string `protobuf:"bytes,1,opt,name=namespace,proto3" json:"namespace,omitempty"`
+	// Logical size of the files, before compression and deduplication.
+	Bytes int64 `protobuf:"varint,2,opt,name=bytes,proto3" json:"bytes,omitempty"`
+	Files int64 `protobuf:"varint,3,opt,name=files,proto3" json:"files,omitempty"`
+	// Limits of the namespace; 0 means unlimited.
+	MaxBytes      int64 `protobuf:"varint,4,opt,name=max_bytes,json=maxBytes,proto3" json:"max_bytes,omitempty"`
+	MaxFiles      int64 `protobuf:"varint,5,opt,name=max_files,json=maxFiles,proto3" json:"max_files,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *NamespaceUsage) Reset() {
+	*x = NamespaceUsage{}
+	mi := &file_pkg_api_fmpb_quota_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *NamespaceUsage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*NamespaceUsage) ProtoMessage() {}
+
+func (x *NamespaceUsage) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_api_fmpb_quota_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use NamespaceUsage.ProtoReflect.Descriptor instead.
+func (*NamespaceUsage) Descriptor() ([]byte, []int) {
+	return file_pkg_api_fmpb_quota_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *NamespaceUsage) GetNamespace() string {
+	if x != nil {
+		return x.Namespace
+	}
+	return ""
+}
+
+func (x *NamespaceUsage) GetBytes() int64 {
+	if x != nil {
+		return x.Bytes
+	}
+	return 0
+}
+
+func (x *NamespaceUsage) GetFiles() int64 {
+	if x != nil {
+		return x.Files
+	}
+	return 0
+}
+
+func (x *NamespaceUsage) GetMaxBytes() int64 {
+	if x != nil {
+		return x.MaxBytes
+	}
+	return 0
+}
+
+func (x *NamespaceUsage) GetMaxFiles() int64 {
+	if x != nil {
+		return x.MaxFiles
+	}
+	return 0
+}
+
+var File_pkg_api_fmpb_quota_proto protoreflect.FileDescriptor
+
+const file_pkg_api_fmpb_quota_proto_rawDesc = "" +
+	"\n" +
+	"\x18pkg/api/fmpb/quota.proto\x12\x0ffile_manager.v1\"I\n" +
+	"\x0fGetUsageRequest\x12\x1c\n" +
+	"\tnamespace\x18\x01 \x01(\tR\tnamespace\x12\x18\n" +
+	"\arecount\x18\x02 \x01(\bR\arecount\"\x12\n" +
+	"\x10ListUsageRequest\"T\n" +
+	"\x11ListUsageResponse\x12?\n" +
+	"\n" +
+	"namespaces\x18\x01 \x03(\v2\x1f.file_manager.v1.NamespaceUsageR\n" +
+	"namespaces\"\x94\x01\n" +
+	"\x0eNamespaceUsage\x12\x1c\n" +
+	"\tnamespace\x18\x01 \x01(\tR\tnamespace\x12\x14\n" +
+	"\x05bytes\x18\x02 \x01(\x03R\x05bytes\x12\x14\n" +
+	"\x05files\x18\x03 \x01(\x03R\x05files\x12\x1b\n" +
+	"\tmax_bytes\x18\x04 \x01(\x03R\bmaxBytes\x12\x1b\n" +
+	"\tmax_files\x18\x05 \x01(\x03R\bmaxFiles2\xb1\x01\n" +
+	"\fQuotaService\x12M\n" +
+	"\bGetUsage\x12 .file_manager.v1.GetUsageRequest\x1a\x1f.file_manager.v1.NamespaceUsage\x12R\n" +
+	"\tListUsage\x12!.file_manager.v1.ListUsageRequest\x1a\".file_manager.v1.ListUsageResponseB2Z0github.com/JunBSer/FileManager/pkg/api/fmpb;fmpbb\x06proto3"
+
+var (
+	file_pkg_api_fmpb_quota_proto_rawDescOnce sync.Once
+	file_pkg_api_fmpb_quota_proto_rawDescData []byte
+)
+
+func file_pkg_api_fmpb_quota_proto_rawDescGZIP() []byte {
+	file_pkg_api_fmpb_quota_proto_rawDescOnce.Do(func() {
+		file_pkg_api_fmpb_quota_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_pkg_api_fmpb_quota_proto_rawDesc), len(file_pkg_api_fmpb_quota_proto_rawDesc)))
+	})
+	return file_pkg_api_fmpb_quota_proto_rawDescData
+}
+
+var file_pkg_api_fmpb_quota_proto_msgTypes = make([]protoimpl.MessageInfo, 4)
+var file_pkg_api_fmpb_quota_proto_goTypes = []any{
+	(*GetUsageRequest)(nil),   // 0: file_manager.v1.GetUsageRequest
+	(*ListUsageRequest)(nil),  // 1: file_manager.v1.ListUsageRequest
+	(*ListUsageResponse)(nil), // 2: file_manager.v1.ListUsageResponse
+	(*NamespaceUsage)(nil),    // 3: file_manager.v1.NamespaceUsage
+}
+var file_pkg_api_fmpb_quota_proto_depIdxs = []int32{
+	3, // 0: file_manager.v1.ListUsageResponse.namespaces:type_name -> file_manager.v1.NamespaceUsage
+	0, // 1: file_manager.v1.QuotaService.GetUsage:input_type -> file_manager.v1.GetUsageRequest
+	1, // 2: file_manager.v1.QuotaService.ListUsage:input_type -> file_manager.v1.ListUsageRequest
+	3, // 3: file_manager.v1.QuotaService.GetUsage:output_type -> file_manager.v1.NamespaceUsage
+	2, // 4: file_manager.v1.QuotaService.ListUsage:output_type -> file_manager.v1.ListUsageResponse
+	3, // [3:5] is the sub-list for method output_type
+	1, // [1:3] is the sub-list for method input_type
+	1, // [1:1] is the sub-list for extension type_name
+	1, // [1:1] is the sub-list for extension extendee
+	0, // [0:1] is the sub-list for field type_name
+}
+
+func init() { file_pkg_api_fmpb_quota_proto_init() }
+func file_pkg_api_fmpb_quota_proto_init() {
+	if File_pkg_api_fmpb_quota_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_pkg_api_fmpb_quota_proto_rawDesc), len(file_pkg_api_fmpb_quota_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   4,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_pkg_api_fmpb_quota_proto_goTypes,
+		DependencyIndexes: file_pkg_api_fmpb_quota_proto_depIdxs,
+		MessageInfos:      file_pkg_api_fmpb_quota_proto_msgTypes,
+	}.Build()
+	File_pkg_api_fmpb_quota_proto = out.File
+	file_pkg_api_fmpb_quota_proto_goTypes = nil
+	file_pkg_api_fmpb_quota_proto_depIdxs = nil
+}
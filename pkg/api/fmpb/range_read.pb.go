@@ -0,0 +1,275 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        v5.29.3
+// source: pkg/api/fmpb/range_read.proto
+
+package fmpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// ByteRange selects length bytes starting at offset. A negative offset selects
+// the last -offset bytes of the file, a zero length reads up to the end.
+type ByteRange struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Offset        int64                  `protobuf:"varint,1,opt,name=offset,proto3" json:"offset,omitempty"`
+	Length        int64                  `protobuf:"varint,2,opt,name=length,proto3" json:"length,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ByteRange) Reset() {
+	*x = ByteRange{}
+	mi := &file_pkg_api_fmpb_range_read_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ByteRange) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ByteRange) ProtoMessage() {}
+
+func (x *ByteRange) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_api_fmpb_range_read_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ByteRange.ProtoReflect.Descriptor instead.
+func (*ByteRange) Descriptor() ([]byte, []int) {
+	return file_pkg_api_fmpb_range_read_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *ByteRange) GetOffset() int64 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+func (x *ByteRange) GetLength() int64 {
+	if x != nil {
+		return x.Length
+	}
+	return 0
+}
+
+type ReadRangeRequest struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	FileName string                 `protobuf:"bytes,1,opt,name=file_name,json=fileName,proto3" json:"file_name,omitempty"`
+	// Ranges to read. An empty list reads the whole file.
+	Ranges        []*ByteRange `protobuf:"bytes,2,rep,name=ranges,proto3" json:"ranges,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReadRangeRequest) Reset() {
+	*x = ReadRangeRequest{}
+	mi := &file_pkg_api_fmpb_range_read_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReadRangeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReadRangeRequest) ProtoMessage() {}
+
+func (x *ReadRangeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_api_fmpb_range_read_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReadRangeRequest.ProtoReflect.Descriptor instead.
+func (*ReadRangeRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_api_fmpb_range_read_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *ReadRangeRequest) GetFileName() string {
+	if x != nil {
+		return x.FileName
+	}
+	return ""
+}
+
+func (x *ReadRangeRequest) GetRanges() []*ByteRange {
+	if x != nil {
+		return x.Ranges
+	}
+	return nil
+}
+
+// The first message of the stream carries file_size and the resolved ranges,
+// which are empty if none of the requested ranges can be satisfied. Every
+// following message carries content of the range with index range_index.
+type RangeChunk struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	FileSize      int64                  `protobuf:"varint,1,opt,name=file_size,json=fileSize,proto3" json:"file_size,omitempty"`
+	Ranges        []*ByteRange           `protobuf:"bytes,2,rep,name=ranges,proto3" json:"ranges,omitempty"`
+	RangeIndex    int32                  `protobuf:"varint,3,opt,name=range_index,json=rangeIndex,proto3" json:"range_index,omitempty"`
+	Content       []byte                 `protobuf:"bytes,4,opt,name=content,proto3" json:"content,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RangeChunk) Reset() {
+	*x = RangeChunk{}
+	mi := &file_pkg_api_fmpb_range_read_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RangeChunk) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RangeChunk) ProtoMessage() {}
+
+func (x *RangeChunk) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_api_fmpb_range_read_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RangeChunk.ProtoReflect.Descriptor instead.
+func (*RangeChunk) Descriptor() ([]byte, []int) {
+	return file_pkg_api_fmpb_range_read_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *RangeChunk) GetFileSize() int64 {
+	if x != nil {
+		return x.FileSize
+	}
+	return 0
+}
+
+func (x *RangeChunk) GetRanges() []*ByteRange {
+	if x != nil {
+		return x.Ranges
+	}
+	return nil
+}
+
+func (x *RangeChunk) GetRangeIndex() int32 {
+	if x != nil {
+		return x.RangeIndex
+	}
+	return 0
+}
+
+func (x *RangeChunk) GetContent() []byte {
+	if x != nil {
+		return x.Content
+	}
+	return nil
+}
+
+var File_pkg_api_fmpb_range_read_proto protoreflect.FileDescriptor
+
+const file_pkg_api_fmpb_range_read_proto_rawDesc = "" +
+	"\n" +
+	"\x1dpkg/api/fmpb/range_read.proto\x12\x0ffile_manager.v1\";\n" +
+	"\tByteRange\x12\x16\n" +
+	"\x06offset\x18\x01 \x01(\x03R\x06offset\x12\x16\n" +
+	"\x06length\x18\x02 \x01(\x03R\x06length\"c\n" +
+	"\x10ReadRangeRequest\x12\x1b\n" +
+	"\tfile_name\x18\x01 \x01(\tR\bfileName\x122\n" +
+	"\x06ranges\x18\x02 \x03(\v2\x1a.file_manager.v1.ByteRangeR\x06ranges\"\x98\x01\n" +
+	"\n" +
+	"RangeChunk\x12\x1b\n" +
+	"\tfile_size\x18\x01 \x01(\x03R\bfileSize\x122\n" +
+	"\x06ranges\x18\x02 \x03(\v2\x1a.file_manager.v1.ByteRangeR\x06ranges\x12\x1f\n" +
+	"\vrange_index\x18\x03 \x01(\x05R\n" +
+	"rangeIndex\x12\x18\n" +
+	"\acontent\x18\x04 \x01(\fR\acontent2a\n" +
+	"\x10RangeReadService\x12M\n" +
+	"\tReadRange\x12!.file_manager.v1.ReadRangeRequest\x1a\x1b.file_manager.v1.RangeChunk0\x01B2Z0github.com/JunBSer/FileManager/pkg/api/fmpb;fmpbb\x06proto3"
+
+var (
+	file_pkg_api_fmpb_range_read_proto_rawDescOnce sync.Once
+	file_pkg_api_fmpb_range_read_proto_rawDescData []byte
+)
+
+func file_pkg_api_fmpb_range_read_proto_rawDescGZIP() []byte {
+	file_pkg_api_fmpb_range_read_proto_rawDescOnce.Do(func() {
+		file_pkg_api_fmpb_range_read_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_pkg_api_fmpb_range_read_proto_rawDesc), len(file_pkg_api_fmpb_range_read_proto_rawDesc)))
+	})
+	return file_pkg_api_fmpb_range_read_proto_rawDescData
+}
+
+var file_pkg_api_fmpb_range_read_proto_msgTypes = make([]protoimpl.MessageInfo, 3)
+var file_pkg_api_fmpb_range_read_proto_goTypes = []any{
+	(*ByteRange)(nil),        // 0: file_manager.v1.ByteRange
+	(*ReadRangeRequest)(nil), // 1: file_manager.v1.ReadRangeRequest
+	(*RangeChunk)(nil),       // 2: file_manager.v1.RangeChunk
+}
+var file_pkg_api_fmpb_range_read_proto_depIdxs = []int32{
+	0, // 0: file_manager.v1.ReadRangeRequest.ranges:type_name -> file_manager.v1.ByteRange
+	0, // 1: file_manager.v1.RangeChunk.ranges:type_name -> file_manager.v1.ByteRange
+	1, // 2: file_manager.v1.RangeReadService.ReadRange:input_type -> file_manager.v1.ReadRangeRequest
+	2, // 3: file_manager.v1.RangeReadService.ReadRange:output_type -> file_manager.v1.RangeChunk
+	3, // [3:4] is the sub-list for method output_type
+	2, // [2:3] is the sub-list for method input_type
+	2, // [2:2] is the sub-list for extension type_name
+	2, // [2:2] is the sub-list for extension extendee
+	0, // [0:2] is the sub-list for field type_name
+}
+
+func init() { file_pkg_api_fmpb_range_read_proto_init() }
+func file_pkg_api_fmpb_range_read_proto_init() {
+	if File_pkg_api_fmpb_range_read_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_pkg_api_fmpb_range_read_proto_rawDesc), len(file_pkg_api_fmpb_range_read_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   3,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_pkg_api_fmpb_range_read_proto_goTypes,
+		DependencyIndexes: file_pkg_api_fmpb_range_read_proto_depIdxs,
+		MessageInfos:      file_pkg_api_fmpb_range_read_proto_msgTypes,
+	}.Build()
+	File_pkg_api_fmpb_range_read_proto = out.File
+	file_pkg_api_fmpb_range_read_proto_goTypes = nil
+	file_pkg_api_fmpb_range_read_proto_depIdxs = nil
+}
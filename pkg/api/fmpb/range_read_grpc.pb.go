@@ -0,0 +1,128 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             v5.29.3
+// source: pkg/api/fmpb/range_read.proto
+
+package fmpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	RangeReadService_ReadRange_FullMethodName = "/file_manager.v1.RangeReadService/ReadRange"
+)
+
+// RangeReadServiceClient is the client API for RangeReadService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// RangeReadService streams selected byte ranges of a file.
+type RangeReadServiceClient interface {
+	ReadRange(ctx context.Context, in *ReadRangeRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[RangeChunk], error)
+}
+
+type rangeReadServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewRangeReadServiceClient(cc grpc.ClientConnInterface) RangeReadServiceClient {
+	return &rangeReadServiceClient{cc}
+}
+
+func (c *rangeReadServiceClient) ReadRange(ctx context.Context, in *ReadRangeRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[RangeChunk], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &RangeReadService_ServiceDesc.Streams[0], RangeReadService_ReadRange_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ReadRangeRequest, RangeChunk]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type RangeReadService_ReadRangeClient = grpc.ServerStreamingClient[RangeChunk]
+
+// RangeReadServiceServer is the server API for RangeReadService service.
+// All implementations must embed UnimplementedRangeReadServiceServer
+// for forward compatibility.
+//
+// RangeReadService streams selected byte ranges of a file.
+type RangeReadServiceServer interface {
+	ReadRange(*ReadRangeRequest, grpc.ServerStreamingServer[RangeChunk]) error
+	mustEmbedUnimplementedRangeReadServiceServer()
+}
+
+// UnimplementedRangeReadServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedRangeReadServiceServer struct{}
+
+func (UnimplementedRangeReadServiceServer) ReadRange(*ReadRangeRequest, grpc.ServerStreamingServer[RangeChunk]) error {
+	return status.Errorf(codes.Unimplemented, "method ReadRange not implemented")
+}
+func (UnimplementedRangeReadServiceServer) mustEmbedUnimplementedRangeReadServiceServer() {}
+func (UnimplementedRangeReadServiceServer) testEmbeddedByValue()                          {}
+
+// UnsafeRangeReadServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to RangeReadServiceServer will
+// result in compilation errors.
+type UnsafeRangeReadServiceServer interface {
+	mustEmbedUnimplementedRangeReadServiceServer()
+}
+
+func RegisterRangeReadServiceServer(s grpc.ServiceRegistrar, srv RangeReadServiceServer) {
+	// If the following call pancis, it indicates UnimplementedRangeReadServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&RangeReadService_ServiceDesc, srv)
+}
+
+func _RangeReadService_ReadRange_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ReadRangeRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(RangeReadServiceServer).ReadRange(m, &grpc.GenericServerStream[ReadRangeRequest, RangeChunk]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type RangeReadService_ReadRangeServer = grpc.ServerStreamingServer[RangeChunk]
+
+// RangeReadService_ServiceDesc is the grpc.ServiceDesc for RangeReadService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var RangeReadService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "file_manager.v1.RangeReadService",
+	HandlerType: (*RangeReadServiceServer)(nil),
+	Methods:     []grpc.MethodDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "ReadRange",
+			Handler:       _RangeReadService_ReadRange_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "pkg/api/fmpb/range_read.proto",
+}
@@ -0,0 +1,381 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        v5.29.3
+// source: pkg/api/fmpb/upload_session.proto
+
+package fmpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type CreateUploadSessionRequest struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	FileName string                 `protobuf:"bytes,1,opt,name=file_name,json=fileName,proto3" json:"file_name,omitempty"`
+	// Expected size of the whole file, 0 if unknown.
+	TotalSize int64 `protobuf:"varint,2,opt,name=total_size,json=totalSize,proto3" json:"total_size,omitempty"`
+	// Expected SHA-256 of the whole file as lowercase hex, empty if unknown.
+	// FinalizeUploadSession fails if the uploaded data does not match.
+	Sha256        string `protobuf:"bytes,3,opt,name=sha256,proto3" json:"sha256,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateUploadSessionRequest) Reset() {
+	*x = CreateUploadSessionRequest{}
+	mi := &file_pkg_api_fmpb_upload_session_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateUploadSessionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateUploadSessionRequest) ProtoMessage() {}
+
+func (x *CreateUploadSessionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_api_fmpb_upload_session_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateUploadSessionRequest.ProtoReflect.Descriptor instead.
+func (*CreateUploadSessionRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_api_fmpb_upload_session_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *CreateUploadSessionRequest) GetFileName() string {
+	if x != nil {
+		return x.FileName
+	}
+	return ""
+}
+
+func (x *CreateUploadSessionRequest) GetTotalSize() int64 {
+	if x != nil {
+		return x.TotalSize
+	}
+	return 0
+}
+
+func (x *CreateUploadSessionRequest) GetSha256() string {
+	if x != nil {
+		return x.Sha256
+	}
+	return ""
+}
+
+type UploadSessionRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UploadId      string                 `protobuf:"bytes,1,opt,name=upload_id,json=uploadId,proto3" json:"upload_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UploadSessionRequest) Reset() {
+	*x = UploadSessionRequest{}
+	mi := &file_pkg_api_fmpb_upload_session_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UploadSessionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UploadSessionRequest) ProtoMessage() {}
+
+func (x *UploadSessionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_api_fmpb_upload_session_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UploadSessionRequest.ProtoReflect.Descriptor instead.
+func (*UploadSessionRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_api_fmpb_upload_session_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *UploadSessionRequest) GetUploadId() string {
+	if x != nil {
+		return x.UploadId
+	}
+	return ""
+}
+
+type UploadChunk struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	UploadId string                 `protobuf:"bytes,1,opt,name=upload_id,json=uploadId,proto3" json:"upload_id,omitempty"`
+	Offset   int64                  `protobuf:"varint,2,opt,name=offset,proto3" json:"offset,omitempty"`
+	Content  []byte                 `protobuf:"bytes,3,opt,name=content,proto3" json:"content,omitempty"`
+	// CRC32C (Castagnoli) of content. A chunk that does not match is rejected
+	// before anything of it is stored.
+	Crc32C        *uint32 `protobuf:"fixed32,4,opt,name=crc32c,proto3,oneof" json:"crc32c,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UploadChunk) Reset() {
+	*x = UploadChunk{}
+	mi := &file_pkg_api_fmpb_upload_session_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UploadChunk) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UploadChunk) ProtoMessage() {}
+
+func (x *UploadChunk) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_api_fmpb_upload_session_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UploadChunk.ProtoReflect.Descriptor instead.
+func (*UploadChunk) Descriptor() ([]byte, []int) {
+	return file_pkg_api_fmpb_upload_session_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *UploadChunk) GetUploadId() string {
+	if x != nil {
+		return x.UploadId
+	}
+	return ""
+}
+
+func (x *UploadChunk) GetOffset() int64 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+func (x *UploadChunk) GetContent() []byte {
+	if x != nil {
+		return x.Content
+	}
+	return nil
+}
+
+func (x *UploadChunk) GetCrc32C() uint32 {
+	if x != nil && x.Crc32C != nil {
+		return *x.Crc32C
+	}
+	return 0
+}
+
+type UploadSession struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	UploadId        string                 `protobuf:"bytes,1,opt,name=upload_id,json=uploadId,proto3" json:"upload_id,omitempty"`
+	FileName        string                 `protobuf:"bytes,2,opt,name=file_name,json=fileName,proto3" json:"file_name,omitempty"`
+	CommittedOffset int64                  `protobuf:"varint,3,opt,name=committed_offset,json=committedOffset,proto3" json:"committed_offset,omitempty"`
+	TotalSize       int64                  `protobuf:"varint,4,opt,name=total_size,json=totalSize,proto3" json:"total_size,omitempty"`
+	CreatedAt       int64                  `protobuf:"varint,5,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	// Expected SHA-256 while the session is open, the SHA-256 of the file once
+	// it is finalized.
+	Sha256        string `protobuf:"bytes,6,opt,name=sha256,proto3" json:"sha256,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UploadSession) Reset() {
+	*x = UploadSession{}
+	mi := &file_pkg_api_fmpb_upload_session_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UploadSession) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UploadSession) ProtoMessage() {}
+
+func (x *UploadSession) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_api_fmpb_upload_session_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UploadSession.ProtoReflect.Descriptor instead.
+func (*UploadSession) Descriptor() ([]byte, []int) {
+	return file_pkg_api_fmpb_upload_session_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *UploadSession) GetUploadId() string {
+	if x != nil {
+		return x.UploadId
+	}
+	return ""
+}
+
+func (x *UploadSession) GetFileName() string {
+	if x != nil {
+		return x.FileName
+	}
+	return ""
+}
+
+func (x *UploadSession) GetCommittedOffset() int64 {
+	if x != nil {
+		return x.CommittedOffset
+	}
+	return 0
+}
+
+func (x *UploadSession) GetTotalSize() int64 {
+	if x != nil {
+		return x.TotalSize
+	}
+	return 0
+}
+
+func (x *UploadSession) GetCreatedAt() int64 {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return 0
+}
+
+func (x *UploadSession) GetSha256() string {
+	if x != nil {
+		return x.Sha256
+	}
+	return ""
+}
+
+var File_pkg_api_fmpb_upload_session_proto protoreflect.FileDescriptor
+
+const file_pkg_api_fmpb_upload_session_proto_rawDesc = "" +
+	"\n" +
+	"!pkg/api/fmpb/upload_session.proto\x12\x0ffile_manager.v1\"p\n" +
+	"\x1aCreateUploadSessionRequest\x12\x1b\n" +
+	"\tfile_name\x18\x01 \x01(\tR\bfileName\x12\x1d\n" +
+	"\n" +
+	"total_size\x18\x02 \x01(\x03R\ttotalSize\x12\x16\n" +
+	"\x06sha256\x18\x03 \x01(\tR\x06sha256\"3\n" +
+	"\x14UploadSessionRequest\x12\x1b\n" +
+	"\tupload_id\x18\x01 \x01(\tR\buploadId\"\x84\x01\n" +
+	"\vUploadChunk\x12\x1b\n" +
+	"\tupload_id\x18\x01 \x01(\tR\buploadId\x12\x16\n" +
+	"\x06offset\x18\x02 \x01(\x03R\x06offset\x12\x18\n" +
+	"\acontent\x18\x03 \x01(\fR\acontent\x12\x1b\n" +
+	"\x06crc32c\x18\x04 \x01(\aH\x00R\x06crc32c\x88\x01\x01B\t\n" +
+	"\a_crc32c\"\xca\x01\n" +
+	"\rUploadSession\x12\x1b\n" +
+	"\tupload_id\x18\x01 \x01(\tR\buploadId\x12\x1b\n" +
+	"\tfile_name\x18\x02 \x01(\tR\bfileName\x12)\n" +
+	"\x10committed_offset\x18\x03 \x01(\x03R\x0fcommittedOffset\x12\x1d\n" +
+	"\n" +
+	"total_size\x18\x04 \x01(\x03R\ttotalSize\x12\x1d\n" +
+	"\n" +
+	"created_at\x18\x05 \x01(\x03R\tcreatedAt\x12\x16\n" +
+	"\x06sha256\x18\x06 \x01(\tR\x06sha2562\xe2\x03\n" +
+	"\x14UploadSessionService\x12b\n" +
+	"\x13CreateUploadSession\x12+.file_manager.v1.CreateUploadSessionRequest\x1a\x1e.file_manager.v1.UploadSession\x12N\n" +
+	"\fUploadChunks\x12\x1c.file_manager.v1.UploadChunk\x1a\x1e.file_manager.v1.UploadSession(\x01\x12Y\n" +
+	"\x10GetUploadSession\x12%.file_manager.v1.UploadSessionRequest\x1a\x1e.file_manager.v1.UploadSession\x12^\n" +
+	"\x15FinalizeUploadSession\x12%.file_manager.v1.UploadSessionRequest\x1a\x1e.file_manager.v1.UploadSession\x12[\n" +
+	"\x12AbortUploadSession\x12%.file_manager.v1.UploadSessionRequest\x1a\x1e.file_manager.v1.UploadSessionB2Z0github.com/JunBSer/FileManager/pkg/api/fmpb;fmpbb\x06proto3"
+
+var (
+	file_pkg_api_fmpb_upload_session_proto_rawDescOnce sync.Once
+	file_pkg_api_fmpb_upload_session_proto_rawDescData []byte
+)
+
+func file_pkg_api_fmpb_upload_session_proto_rawDescGZIP() []byte {
+	file_pkg_api_fmpb_upload_session_proto_rawDescOnce.Do(func() {
+		file_pkg_api_fmpb_upload_session_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_pkg_api_fmpb_upload_session_proto_rawDesc), len(file_pkg_api_fmpb_upload_session_proto_rawDesc)))
+	})
+	return file_pkg_api_fmpb_upload_session_proto_rawDescData
+}
+
+var file_pkg_api_fmpb_upload_session_proto_msgTypes = make([]protoimpl.MessageInfo, 4)
+var file_pkg_api_fmpb_upload_session_proto_goTypes = []any{
+	(*CreateUploadSessionRequest)(nil), // 0: file_manager.v1.CreateUploadSessionRequest
+	(*UploadSessionRequest)(nil),       // 1: file_manager.v1.UploadSessionRequest
+	(*UploadChunk)(nil),                // 2: file_manager.v1.UploadChunk
+	(*UploadSession)(nil),              // 3: file_manager.v1.UploadSession
+}
+var file_pkg_api_fmpb_upload_session_proto_depIdxs = []int32{
+	0, // 0: file_manager.v1.UploadSessionService.CreateUploadSession:input_type -> file_manager.v1.CreateUploadSessionRequest
+	2, // 1: file_manager.v1.UploadSessionService.UploadChunks:input_type -> file_manager.v1.UploadChunk
+	1, // 2: file_manager.v1.UploadSessionService.GetUploadSession:input_type -> file_manager.v1.UploadSessionRequest
+	1, // 3: file_manager.v1.UploadSessionService.FinalizeUploadSession:input_type -> file_manager.v1.UploadSessionRequest
+	1, // 4: file_manager.v1.UploadSessionService.AbortUploadSession:input_type -> file_manager.v1.UploadSessionRequest
+	3, // 5: file_manager.v1.UploadSessionService.CreateUploadSession:output_type -> file_manager.v1.UploadSession
+	3, // 6: file_manager.v1.UploadSessionService.UploadChunks:output_type -> file_manager.v1.UploadSession
+	3, // 7: file_manager.v1.UploadSessionService.GetUploadSession:output_type -> file_manager.v1.UploadSession
+	3, // 8: file_manager.v1.UploadSessionService.FinalizeUploadSession:output_type -> file_manager.v1.UploadSession
+	3, // 9: file_manager.v1.UploadSessionService.AbortUploadSession:output_type -> file_manager.v1.UploadSession
+	5, // [5:10] is the sub-list for method output_type
+	0, // [0:5] is the sub-list for method input_type
+	0, // [0:0] is the sub-list for extension type_name
+	0, // [0:0] is the sub-list for extension extendee
+	0, // [0:0] is the sub-list for field type_name
+}
+
+func init() { file_pkg_api_fmpb_upload_session_proto_init() }
+func file_pkg_api_fmpb_upload_session_proto_init() {
+	if File_pkg_api_fmpb_upload_session_proto != nil {
+		return
+	}
+	file_pkg_api_fmpb_upload_session_proto_msgTypes[2].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_pkg_api_fmpb_upload_session_proto_rawDesc), len(file_pkg_api_fmpb_upload_session_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   4,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_pkg_api_fmpb_upload_session_proto_goTypes,
+		DependencyIndexes: file_pkg_api_fmpb_upload_session_proto_depIdxs,
+		MessageInfos:      file_pkg_api_fmpb_upload_session_proto_msgTypes,
+	}.Build()
+	File_pkg_api_fmpb_upload_session_proto = out.File
+	file_pkg_api_fmpb_upload_session_proto_goTypes = nil
+	file_pkg_api_fmpb_upload_session_proto_depIdxs = nil
+}
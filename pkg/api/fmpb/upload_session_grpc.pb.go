@@ -0,0 +1,275 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             v5.29.3
+// source: pkg/api/fmpb/upload_session.proto
+
+package fmpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	UploadSessionService_CreateUploadSession_FullMethodName   = "/file_manager.v1.UploadSessionService/CreateUploadSession"
+	UploadSessionService_UploadChunks_FullMethodName          = "/file_manager.v1.UploadSessionService/UploadChunks"
+	UploadSessionService_GetUploadSession_FullMethodName      = "/file_manager.v1.UploadSessionService/GetUploadSession"
+	UploadSessionService_FinalizeUploadSession_FullMethodName = "/file_manager.v1.UploadSessionService/FinalizeUploadSession"
+	UploadSessionService_AbortUploadSession_FullMethodName    = "/file_manager.v1.UploadSessionService/AbortUploadSession"
+)
+
+// UploadSessionServiceClient is the client API for UploadSessionService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// UploadSessionService provides resumable uploads. A client creates a session,
+// streams chunks tagged with offsets, asks for the committed offset after a
+// disconnect and finally finalizes or aborts the session.
+type UploadSessionServiceClient interface {
+	CreateUploadSession(ctx context.Context, in *CreateUploadSessionRequest, opts ...grpc.CallOption) (*UploadSession, error)
+	UploadChunks(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[UploadChunk, UploadSession], error)
+	GetUploadSession(ctx context.Context, in *UploadSessionRequest, opts ...grpc.CallOption) (*UploadSession, error)
+	FinalizeUploadSession(ctx context.Context, in *UploadSessionRequest, opts ...grpc.CallOption) (*UploadSession, error)
+	AbortUploadSession(ctx context.Context, in *UploadSessionRequest, opts ...grpc.CallOption) (*UploadSession, error)
+}
+
+type uploadSessionServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewUploadSessionServiceClient(cc grpc.ClientConnInterface) UploadSessionServiceClient {
+	return &uploadSessionServiceClient{cc}
+}
+
+func (c *uploadSessionServiceClient) CreateUploadSession(ctx context.Context, in *CreateUploadSessionRequest, opts ...grpc.CallOption) (*UploadSession, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(UploadSession)
+	err := c.cc.Invoke(ctx, UploadSessionService_CreateUploadSession_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *uploadSessionServiceClient) UploadChunks(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[UploadChunk, UploadSession], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &UploadSessionService_ServiceDesc.Streams[0], UploadSessionService_UploadChunks_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[UploadChunk, UploadSession]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type UploadSessionService_UploadChunksClient = grpc.ClientStreamingClient[UploadChunk, UploadSession]
+
+func (c *uploadSessionServiceClient) GetUploadSession(ctx context.Context, in *UploadSessionRequest, opts ...grpc.CallOption) (*UploadSession, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(UploadSession)
+	err := c.cc.Invoke(ctx, UploadSessionService_GetUploadSession_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *uploadSessionServiceClient) FinalizeUploadSession(ctx context.Context, in *UploadSessionRequest, opts ...grpc.CallOption) (*UploadSession, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(UploadSession)
+	err := c.cc.Invoke(ctx, UploadSessionService_FinalizeUploadSession_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *uploadSessionServiceClient) AbortUploadSession(ctx context.Context, in *UploadSessionRequest, opts ...grpc.CallOption) (*UploadSession, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(UploadSession)
+	err := c.cc.Invoke(ctx, UploadSessionService_AbortUploadSession_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// UploadSessionServiceServer is the server API for UploadSessionService service.
+// All implementations must embed UnimplementedUploadSessionServiceServer
+// for forward compatibility.
+//
+// UploadSessionService provides resumable uploads. A client creates a session,
+// streams chunks tagged with offsets, asks for the committed offset after a
+// disconnect and finally finalizes or aborts the session.
+type UploadSessionServiceServer interface {
+	CreateUploadSession(context.Context, *CreateUploadSessionRequest) (*UploadSession, error)
+	UploadChunks(grpc.ClientStreamingServer[UploadChunk, UploadSession]) error
+	GetUploadSession(context.Context, *UploadSessionRequest) (*UploadSession, error)
+	FinalizeUploadSession(context.Context, *UploadSessionRequest) (*UploadSession, error)
+	AbortUploadSession(context.Context, *UploadSessionRequest) (*UploadSession, error)
+	mustEmbedUnimplementedUploadSessionServiceServer()
+}
+
+// UnimplementedUploadSessionServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedUploadSessionServiceServer struct{}
+
+func (UnimplementedUploadSessionServiceServer) CreateUploadSession(context.Context, *CreateUploadSessionRequest) (*UploadSession, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateUploadSession not implemented")
+}
+func (UnimplementedUploadSessionServiceServer) UploadChunks(grpc.ClientStreamingServer[UploadChunk, UploadSession]) error {
+	return status.Errorf(codes.Unimplemented, "method UploadChunks not implemented")
+}
+func (UnimplementedUploadSessionServiceServer) GetUploadSession(context.Context, *UploadSessionRequest) (*UploadSession, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetUploadSession not implemented")
+}
+func (UnimplementedUploadSessionServiceServer) FinalizeUploadSession(context.Context, *UploadSessionRequest) (*UploadSession, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method FinalizeUploadSession not implemented")
+}
+func (UnimplementedUploadSessionServiceServer) AbortUploadSession(context.Context, *UploadSessionRequest) (*UploadSession, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AbortUploadSession not implemented")
+}
+func (UnimplementedUploadSessionServiceServer) mustEmbedUnimplementedUploadSessionServiceServer() {}
+func (UnimplementedUploadSessionServiceServer) testEmbeddedByValue()                              {}
+
+// UnsafeUploadSessionServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to UploadSessionServiceServer will
+// result in compilation errors.
+type UnsafeUploadSessionServiceServer interface {
+	mustEmbedUnimplementedUploadSessionServiceServer()
+}
+
+func RegisterUploadSessionServiceServer(s grpc.ServiceRegistrar, srv UploadSessionServiceServer) {
+	// If the following call pancis, it indicates UnimplementedUploadSessionServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&UploadSessionService_ServiceDesc, srv)
+}
+
+func _UploadSessionService_CreateUploadSession_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateUploadSessionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UploadSessionServiceServer).CreateUploadSession(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UploadSessionService_CreateUploadSession_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UploadSessionServiceServer).CreateUploadSession(ctx, req.(*CreateUploadSessionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UploadSessionService_UploadChunks_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(UploadSessionServiceServer).UploadChunks(&grpc.GenericServerStream[UploadChunk, UploadSession]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type UploadSessionService_UploadChunksServer = grpc.ClientStreamingServer[UploadChunk, UploadSession]
+
+func _UploadSessionService_GetUploadSession_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UploadSessionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UploadSessionServiceServer).GetUploadSession(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UploadSessionService_GetUploadSession_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UploadSessionServiceServer).GetUploadSession(ctx, req.(*UploadSessionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UploadSessionService_FinalizeUploadSession_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UploadSessionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UploadSessionServiceServer).FinalizeUploadSession(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UploadSessionService_FinalizeUploadSession_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UploadSessionServiceServer).FinalizeUploadSession(ctx, req.(*UploadSessionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UploadSessionService_AbortUploadSession_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UploadSessionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UploadSessionServiceServer).AbortUploadSession(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UploadSessionService_AbortUploadSession_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UploadSessionServiceServer).AbortUploadSession(ctx, req.(*UploadSessionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// UploadSessionService_ServiceDesc is the grpc.ServiceDesc for UploadSessionService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var UploadSessionService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "file_manager.v1.UploadSessionService",
+	HandlerType: (*UploadSessionServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "CreateUploadSession",
+			Handler:    _UploadSessionService_CreateUploadSession_Handler,
+		},
+		{
+			MethodName: "GetUploadSession",
+			Handler:    _UploadSessionService_GetUploadSession_Handler,
+		},
+		{
+			MethodName: "FinalizeUploadSession",
+			Handler:    _UploadSessionService_FinalizeUploadSession_Handler,
+		},
+		{
+			MethodName: "AbortUploadSession",
+			Handler:    _UploadSessionService_AbortUploadSession_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "UploadChunks",
+			Handler:       _UploadSessionService_UploadChunks_Handler,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "pkg/api/fmpb/upload_session.proto",
+}
@@ -0,0 +1,494 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        v5.29.3
+// source: pkg/api/fmpb/versions.proto
+
+package fmpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type ListVersionsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	FileName      string                 `protobuf:"bytes,1,opt,name=file_name,json=fileName,proto3" json:"file_name,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListVersionsRequest) Reset() {
+	*x = ListVersionsRequest{}
+	mi := &file_pkg_api_fmpb_versions_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListVersionsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListVersionsRequest) ProtoMessage() {}
+
+func (x *ListVersionsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_api_fmpb_versions_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListVersionsRequest.ProtoReflect.Descriptor instead.
+func (*ListVersionsRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_api_fmpb_versions_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *ListVersionsRequest) GetFileName() string {
+	if x != nil {
+		return x.FileName
+	}
+	return ""
+}
+
+type ListVersionsResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Versions ordered from the newest to the oldest.
+	Versions      []*FileVersion `protobuf:"bytes,1,rep,name=versions,proto3" json:"versions,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListVersionsResponse) Reset() {
+	*x = ListVersionsResponse{}
+	mi := &file_pkg_api_fmpb_versions_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListVersionsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListVersionsResponse) ProtoMessage() {}
+
+func (x *ListVersionsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_api_fmpb_versions_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListVersionsResponse.ProtoReflect.Descriptor instead.
+func (*ListVersionsResponse) Descriptor() ([]byte, []int) {
+	return file_pkg_api_fmpb_versions_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *ListVersionsResponse) GetVersions() []*FileVersion {
+	if x != nil {
+		return x.Versions
+	}
+	return nil
+}
+
+type VersionRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	FileName      string                 `protobuf:"bytes,1,opt,name=file_name,json=fileName,proto3" json:"file_name,omitempty"`
+	VersionId     string                 `protobuf:"bytes,2,opt,name=version_id,json=versionId,proto3" json:"version_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *VersionRequest) Reset() {
+	*x = VersionRequest{}
+	mi := &file_pkg_api_fmpb_versions_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *VersionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*VersionRequest) ProtoMessage() {}
+
+func (x *VersionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_api_fmpb_versions_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use VersionRequest.ProtoReflect.Descriptor instead.
+func (*VersionRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_api_fmpb_versions_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *VersionRequest) GetFileName() string {
+	if x != nil {
+		return x.FileName
+	}
+	return ""
+}
+
+func (x *VersionRequest) GetVersionId() string {
+	if x != nil {
+		return x.VersionId
+	}
+	return ""
+}
+
+type FileVersion struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	VersionId string                 `protobuf:"bytes,1,opt,name=version_id,json=versionId,proto3" json:"version_id,omitempty"`
+	FileName  string                 `protobuf:"bytes,2,opt,name=file_name,json=fileName,proto3" json:"file_name,omitempty"`
+	Size      int64                  `protobuf:"varint,3,opt,name=size,proto3" json:"size,omitempty"`
+	// Unix time the version was saved.
+	CreatedAt int64 `protobuf:"varint,4,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	// Operation that replaced this content: upload, append, overwrite, move,
+	// delete or restore.
+	Reason        string `protobuf:"bytes,5,opt,name=reason,proto3" json:"reason,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FileVersion) Reset() {
+	*x = FileVersion{}
+	mi := &file_pkg_api_fmpb_versions_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FileVersion) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FileVersion) ProtoMessage() {}
+
+func (x *FileVersion) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_api_fmpb_versions_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FileVersion.ProtoReflect.Descriptor instead.
+func (*FileVersion) Descriptor() ([]byte, []int) {
+	return file_pkg_api_fmpb_versions_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *FileVersion) GetVersionId() string {
+	if x != nil {
+		return x.VersionId
+	}
+	return ""
+}
+
+func (x *FileVersion) GetFileName() string {
+	if x != nil {
+		return x.FileName
+	}
+	return ""
+}
+
+func (x *FileVersion) GetSize() int64 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+func (x *FileVersion) GetCreatedAt() int64 {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return 0
+}
+
+func (x *FileVersion) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+type VersionChunk struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Content       []byte                 `protobuf:"bytes,1,opt,name=content,proto3" json:"content,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *VersionChunk) Reset() {
+	*x = VersionChunk{}
+	mi := &file_pkg_api_fmpb_versions_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *VersionChunk) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*VersionChunk) ProtoMessage() {}
+
+func (x *VersionChunk) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_api_fmpb_versions_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use VersionChunk.ProtoReflect.Descriptor instead.
+func (*VersionChunk) Descriptor() ([]byte, []int) {
+	return file_pkg_api_fmpb_versions_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *VersionChunk) GetContent() []byte {
+	if x != nil {
+		return x.Content
+	}
+	return nil
+}
+
+type RetentionRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Path          string                 `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RetentionRequest) Reset() {
+	*x = RetentionRequest{}
+	mi := &file_pkg_api_fmpb_versions_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RetentionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RetentionRequest) ProtoMessage() {}
+
+func (x *RetentionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_api_fmpb_versions_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RetentionRequest.ProtoReflect.Descriptor instead.
+func (*RetentionRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_api_fmpb_versions_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *RetentionRequest) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+// RetentionRule limits the versions kept for the files under path. Zero
+// values do not limit; setting a rule without limits makes path inherit the
+// rule of its parent again.
+type RetentionRule struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Path          string                 `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	KeepVersions  int32                  `protobuf:"varint,2,opt,name=keep_versions,json=keepVersions,proto3" json:"keep_versions,omitempty"`
+	MaxAgeSeconds int64                  `protobuf:"varint,3,opt,name=max_age_seconds,json=maxAgeSeconds,proto3" json:"max_age_seconds,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RetentionRule) Reset() {
+	*x = RetentionRule{}
+	mi := &file_pkg_api_fmpb_versions_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RetentionRule) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RetentionRule) ProtoMessage() {}
+
+func (x *RetentionRule) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_api_fmpb_versions_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RetentionRule.ProtoReflect.Descriptor instead.
+func (*RetentionRule) Descriptor() ([]byte, []int) {
+	return file_pkg_api_fmpb_versions_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *RetentionRule) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *RetentionRule) GetKeepVersions() int32 {
+	if x != nil {
+		return x.KeepVersions
+	}
+	return 0
+}
+
+func (x *RetentionRule) GetMaxAgeSeconds() int64 {
+	if x != nil {
+		return x.MaxAgeSeconds
+	}
+	return 0
+}
+
+var File_pkg_api_fmpb_versions_proto protoreflect.FileDescriptor
+
+const file_pkg_api_fmpb_versions_proto_rawDesc = "" +
+	"\n" +
+	"\x1bpkg/api/fmpb/versions.proto\x12\x0ffile_manager.v1\"2\n" +
+	"\x13ListVersionsRequest\x12\x1b\n" +
+	"\tfile_name\x18\x01 \x01(\tR\bfileName\"P\n" +
+	"\x14ListVersionsResponse\x128\n" +
+	"\bversions\x18\x01 \x03(\v2\x1c.file_manager.v1.FileVersionR\bversions\"L\n" +
+	"\x0eVersionRequest\x12\x1b\n" +
+	"\tfile_name\x18\x01 \x01(\tR\bfileName\x12\x1d\n" +
+	"\n" +
+	"version_id\x18\x02 \x01(\tR\tversionId\"\x94\x01\n" +
+	"\vFileVersion\x12\x1d\n" +
+	"\n" +
+	"version_id\x18\x01 \x01(\tR\tversionId\x12\x1b\n" +
+	"\tfile_name\x18\x02 \x01(\tR\bfileName\x12\x12\n" +
+	"\x04size\x18\x03 \x01(\x03R\x04size\x12\x1d\n" +
+	"\n" +
+	"created_at\x18\x04 \x01(\x03R\tcreatedAt\x12\x16\n" +
+	"\x06reason\x18\x05 \x01(\tR\x06reason\"(\n" +
+	"\fVersionChunk\x12\x18\n" +
+	"\acontent\x18\x01 \x01(\fR\acontent\"&\n" +
+	"\x10RetentionRequest\x12\x12\n" +
+	"\x04path\x18\x01 \x01(\tR\x04path\"p\n" +
+	"\rRetentionRule\x12\x12\n" +
+	"\x04path\x18\x01 \x01(\tR\x04path\x12#\n" +
+	"\rkeep_versions\x18\x02 \x01(\x05R\fkeepVersions\x12&\n" +
+	"\x0fmax_age_seconds\x18\x03 \x01(\x03R\rmaxAgeSeconds2\xb6\x03\n" +
+	"\x0eVersionService\x12[\n" +
+	"\fListVersions\x12$.file_manager.v1.ListVersionsRequest\x1a%.file_manager.v1.ListVersionsResponse\x12S\n" +
+	"\x0fDownloadVersion\x12\x1f.file_manager.v1.VersionRequest\x1a\x1d.file_manager.v1.VersionChunk0\x01\x12O\n" +
+	"\x0eRestoreVersion\x12\x1f.file_manager.v1.VersionRequest\x1a\x1c.file_manager.v1.FileVersion\x12Q\n" +
+	"\fGetRetention\x12!.file_manager.v1.RetentionRequest\x1a\x1e.file_manager.v1.RetentionRule\x12N\n" +
+	"\fSetRetention\x12\x1e.file_manager.v1.RetentionRule\x1a\x1e.file_manager.v1.RetentionRuleB2Z0github.com/JunBSer/FileManager/pkg/api/fmpb;fmpbb\x06proto3"
+
+var (
+	file_pkg_api_fmpb_versions_proto_rawDescOnce sync.Once
+	file_pkg_api_fmpb_versions_proto_rawDescData []byte
+)
+
+func file_pkg_api_fmpb_versions_proto_rawDescGZIP() []byte {
+	file_pkg_api_fmpb_versions_proto_rawDescOnce.Do(func() {
+		file_pkg_api_fmpb_versions_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_pkg_api_fmpb_versions_proto_rawDesc), len(file_pkg_api_fmpb_versions_proto_rawDesc)))
+	})
+	return file_pkg_api_fmpb_versions_proto_rawDescData
+}
+
+var file_pkg_api_fmpb_versions_proto_msgTypes = make([]protoimpl.MessageInfo, 7)
+var file_pkg_api_fmpb_versions_proto_goTypes = []any{
+	(*ListVersionsRequest)(nil),  // 0: file_manager.v1.ListVersionsRequest
+	(*ListVersionsResponse)(nil), // 1: file_manager.v1.ListVersionsResponse
+	(*VersionRequest)(nil),       // 2: file_manager.v1.VersionRequest
+	(*FileVersion)(nil),          // 3: file_manager.v1.FileVersion
+	(*VersionChunk)(nil),         // 4: file_manager.v1.VersionChunk
+	(*RetentionRequest)(nil),     // 5: file_manager.v1.RetentionRequest
+	(*RetentionRule)(nil),        // 6: file_manager.v1.RetentionRule
+}
+var file_pkg_api_fmpb_versions_proto_depIdxs = []int32{
+	3, // 0: file_manager.v1.ListVersionsResponse.versions:type_name -> file_manager.v1.FileVersion
+	0, // 1: file_manager.v1.VersionService.ListVersions:input_type -> file_manager.v1.ListVersionsRequest
+	2, // 2: file_manager.v1.VersionService.DownloadVersion:input_type -> file_manager.v1.VersionRequest
+	2, // 3: file_manager.v1.VersionService.RestoreVersion:input_type -> file_manager.v1.VersionRequest
+	5, // 4: file_manager.v1.VersionService.GetRetention:input_type -> file_manager.v1.RetentionRequest
+	6, // 5: file_manager.v1.VersionService.SetRetention:input_type -> file_manager.v1.RetentionRule
+	1, // 6: file_manager.v1.VersionService.ListVersions:output_type -> file_manager.v1.ListVersionsResponse
+	4, // 7: file_manager.v1.VersionService.DownloadVersion:output_type -> file_manager.v1.VersionChunk
+	3, // 8: file_manager.v1.VersionService.RestoreVersion:output_type -> file_manager.v1.FileVersion
+	6, // 9: file_manager.v1.VersionService.GetRetention:output_type -> file_manager.v1.RetentionRule
+	6, // 10: file_manager.v1.VersionService.SetRetention:output_type -> file_manager.v1.RetentionRule
+	6, // [6:11] is the sub-list for method output_type
+	1, // [1:6] is the sub-list for method input_type
+	1, // [1:1] is the sub-list for extension type_name
+	1, // [1:1] is the sub-list for extension extendee
+	0, // [0:1] is the sub-list for field type_name
+}
+
+func init() { file_pkg_api_fmpb_versions_proto_init() }
+func file_pkg_api_fmpb_versions_proto_init() {
+	if File_pkg_api_fmpb_versions_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_pkg_api_fmpb_versions_proto_rawDesc), len(file_pkg_api_fmpb_versions_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   7,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_pkg_api_fmpb_versions_proto_goTypes,
+		DependencyIndexes: file_pkg_api_fmpb_versions_proto_depIdxs,
+		MessageInfos:      file_pkg_api_fmpb_versions_proto_msgTypes,
+	}.Build()
+	File_pkg_api_fmpb_versions_proto = out.File
+	file_pkg_api_fmpb_versions_proto_goTypes = nil
+	file_pkg_api_fmpb_versions_proto_depIdxs = nil
+}
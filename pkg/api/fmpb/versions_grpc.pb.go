@@ -0,0 +1,285 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             v5.29.3
+// source: pkg/api/fmpb/versions.proto
+
+package fmpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	VersionService_ListVersions_FullMethodName    = "/file_manager.v1.VersionService/ListVersions"
+	VersionService_DownloadVersion_FullMethodName = "/file_manager.v1.VersionService/DownloadVersion"
+	VersionService_RestoreVersion_FullMethodName  = "/file_manager.v1.VersionService/RestoreVersion"
+	VersionService_GetRetention_FullMethodName    = "/file_manager.v1.VersionService/GetRetention"
+	VersionService_SetRetention_FullMethodName    = "/file_manager.v1.VersionService/SetRetention"
+)
+
+// VersionServiceClient is the client API for VersionService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// VersionService gives access to the previous contents of files. A version is
+// kept whenever a file is overwritten, appended to or replaced, and when it is
+// deleted while the trash is disabled.
+type VersionServiceClient interface {
+	ListVersions(ctx context.Context, in *ListVersionsRequest, opts ...grpc.CallOption) (*ListVersionsResponse, error)
+	DownloadVersion(ctx context.Context, in *VersionRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[VersionChunk], error)
+	RestoreVersion(ctx context.Context, in *VersionRequest, opts ...grpc.CallOption) (*FileVersion, error)
+	GetRetention(ctx context.Context, in *RetentionRequest, opts ...grpc.CallOption) (*RetentionRule, error)
+	SetRetention(ctx context.Context, in *RetentionRule, opts ...grpc.CallOption) (*RetentionRule, error)
+}
+
+type versionServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewVersionServiceClient(cc grpc.ClientConnInterface) VersionServiceClient {
+	return &versionServiceClient{cc}
+}
+
+func (c *versionServiceClient) ListVersions(ctx context.Context, in *ListVersionsRequest, opts ...grpc.CallOption) (*ListVersionsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListVersionsResponse)
+	err := c.cc.Invoke(ctx, VersionService_ListVersions_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *versionServiceClient) DownloadVersion(ctx context.Context, in *VersionRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[VersionChunk], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &VersionService_ServiceDesc.Streams[0], VersionService_DownloadVersion_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[VersionRequest, VersionChunk]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type VersionService_DownloadVersionClient = grpc.ServerStreamingClient[VersionChunk]
+
+func (c *versionServiceClient) RestoreVersion(ctx context.Context, in *VersionRequest, opts ...grpc.CallOption) (*FileVersion, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(FileVersion)
+	err := c.cc.Invoke(ctx, VersionService_RestoreVersion_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *versionServiceClient) GetRetention(ctx context.Context, in *RetentionRequest, opts ...grpc.CallOption) (*RetentionRule, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RetentionRule)
+	err := c.cc.Invoke(ctx, VersionService_GetRetention_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *versionServiceClient) SetRetention(ctx context.Context, in *RetentionRule, opts ...grpc.CallOption) (*RetentionRule, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RetentionRule)
+	err := c.cc.Invoke(ctx, VersionService_SetRetention_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// VersionServiceServer is the server API for VersionService service.
+// All implementations must embed UnimplementedVersionServiceServer
+// for forward compatibility.
+//
+// VersionService gives access to the previous contents of files. A version is
+// kept whenever a file is overwritten, appended to or replaced, and when it is
+// deleted while the trash is disabled.
+type VersionServiceServer interface {
+	ListVersions(context.Context, *ListVersionsRequest) (*ListVersionsResponse, error)
+	DownloadVersion(*VersionRequest, grpc.ServerStreamingServer[VersionChunk]) error
+	RestoreVersion(context.Context, *VersionRequest) (*FileVersion, error)
+	GetRetention(context.Context, *RetentionRequest) (*RetentionRule, error)
+	SetRetention(context.Context, *RetentionRule) (*RetentionRule, error)
+	mustEmbedUnimplementedVersionServiceServer()
+}
+
+// UnimplementedVersionServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedVersionServiceServer struct{}
+
+func (UnimplementedVersionServiceServer) ListVersions(context.Context, *ListVersionsRequest) (*ListVersionsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListVersions not implemented")
+}
+func (UnimplementedVersionServiceServer) DownloadVersion(*VersionRequest, grpc.ServerStreamingServer[VersionChunk]) error {
+	return status.Errorf(codes.Unimplemented, "method DownloadVersion not implemented")
+}
+func (UnimplementedVersionServiceServer) RestoreVersion(context.Context, *VersionRequest) (*FileVersion, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RestoreVersion not implemented")
+}
+func (UnimplementedVersionServiceServer) GetRetention(context.Context, *RetentionRequest) (*RetentionRule, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetRetention not implemented")
+}
+func (UnimplementedVersionServiceServer) SetRetention(context.Context, *RetentionRule) (*RetentionRule, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetRetention not implemented")
+}
+func (UnimplementedVersionServiceServer) mustEmbedUnimplementedVersionServiceServer() {}
+func (UnimplementedVersionServiceServer) testEmbeddedByValue()                        {}
+
+// UnsafeVersionServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to VersionServiceServer will
+// result in compilation errors.
+type UnsafeVersionServiceServer interface {
+	mustEmbedUnimplementedVersionServiceServer()
+}
+
+func RegisterVersionServiceServer(s grpc.ServiceRegistrar, srv VersionServiceServer) {
+	// If the following call pancis, it indicates UnimplementedVersionServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&VersionService_ServiceDesc, srv)
+}
+
+func _VersionService_ListVersions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListVersionsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(VersionServiceServer).ListVersions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: VersionService_ListVersions_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(VersionServiceServer).ListVersions(ctx, req.(*ListVersionsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _VersionService_DownloadVersion_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(VersionRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(VersionServiceServer).DownloadVersion(m, &grpc.GenericServerStream[VersionRequest, VersionChunk]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type VersionService_DownloadVersionServer = grpc.ServerStreamingServer[VersionChunk]
+
+func _VersionService_RestoreVersion_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(VersionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(VersionServiceServer).RestoreVersion(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: VersionService_RestoreVersion_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(VersionServiceServer).RestoreVersion(ctx, req.(*VersionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _VersionService_GetRetention_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RetentionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(VersionServiceServer).GetRetention(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: VersionService_GetRetention_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(VersionServiceServer).GetRetention(ctx, req.(*RetentionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _VersionService_SetRetention_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RetentionRule)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(VersionServiceServer).SetRetention(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: VersionService_SetRetention_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(VersionServiceServer).SetRetention(ctx, req.(*RetentionRule))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// VersionService_ServiceDesc is the grpc.ServiceDesc for VersionService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var VersionService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "file_manager.v1.VersionService",
+	HandlerType: (*VersionServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListVersions",
+			Handler:    _VersionService_ListVersions_Handler,
+		},
+		{
+			MethodName: "RestoreVersion",
+			Handler:    _VersionService_RestoreVersion_Handler,
+		},
+		{
+			MethodName: "GetRetention",
+			Handler:    _VersionService_GetRetention_Handler,
+		},
+		{
+			MethodName: "SetRetention",
+			Handler:    _VersionService_SetRetention_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "DownloadVersion",
+			Handler:       _VersionService_DownloadVersion_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "pkg/api/fmpb/versions.proto",
+}
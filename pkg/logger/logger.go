@@ -2,6 +2,7 @@ package logger
 
 import (
 	"context"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 	"log"
@@ -51,30 +52,27 @@ func New(serviceName, lvlInfo string) Logger {
 	return logger{log: zapLogger.With(zap.String("service", serviceName))}
 }
 
-func (l logger) Info(ctx context.Context, msg string, fields ...zap.Field) {
-
+// contextFields adds the request ID and the trace of ctx, if any, to fields.
+func contextFields(ctx context.Context, fields []zap.Field) []zap.Field {
 	if ctx.Value(RequestID) != nil {
 		fields = append(fields, zap.String("requestID", ctx.Value(RequestID).(string)))
 	}
+	if span := trace.SpanContextFromContext(ctx); span.IsValid() {
+		fields = append(fields, zap.String("traceID", span.TraceID().String()), zap.String("spanID", span.SpanID().String()))
+	}
+	return fields
+}
 
-	l.log.Info(msg, fields...)
+func (l logger) Info(ctx context.Context, msg string, fields ...zap.Field) {
+	l.log.Info(msg, contextFields(ctx, fields)...)
 }
 
 func (l logger) Error(ctx context.Context, msg string, fields ...zap.Field) {
-
-	if ctx.Value(RequestID) != nil {
-		fields = append(fields, zap.String("requestID", ctx.Value(RequestID).(string)))
-	}
-	l.log.Error(msg, fields...)
+	l.log.Error(msg, contextFields(ctx, fields)...)
 }
 
 func (l logger) Debug(ctx context.Context, msg string, fields ...zap.Field) {
-
-	if ctx.Value(RequestID) != nil {
-		fields = append(fields, zap.String("requestID", ctx.Value(RequestID).(string)))
-	}
-
-	l.log.Debug(msg, fields...)
+	l.log.Debug(msg, contextFields(ctx, fields)...)
 }
 
 func (l logger) CreateChildLogger(fields ...zap.Field) Logger {